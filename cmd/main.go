@@ -2,114 +2,502 @@ package main
 
 import (
 	"context"
+	"flag"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"syscall"
-	"time"
+	// Часовые пояса окон скачивания задач доступны и в образах без системной базы часовых поясов
+	_ "time/tzdata"
 
+	"file-downloader/internal/adapters/fetcher"
 	httpHandlers "file-downloader/internal/adapters/http"
 	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/config"
 	"file-downloader/internal/entities"
 	"file-downloader/internal/infrastructure"
 	"file-downloader/internal/interfaces"
 	"file-downloader/internal/usecases"
+
+	"github.com/google/uuid"
 )
 
+// Сведения о сборке задаются при сборке:
+//
+//	go build -ldflags "-X main.Version=1.2.0 -X main.Commit=$(git rev-parse HEAD) -X main.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+var Version, Commit, BuildTime string
+
+// buildInfo возвращает сведения о сборке для /version. Значения, не заданные через -ldflags,
+// берутся из информации о сборке Go (модуль и VCS), а при её отсутствии - "dev" и "unknown".
+func buildInfo() httpHandlers.BuildInfo {
+	info := httpHandlers.BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
+
 // syncRepositories синхронизирует данные между in-memory и file-based репозиториями
-func syncRepositories(taskRepo interfaces.TaskRepository, fileRepo interfaces.PersistentRepository) error {
+func syncRepositories(taskRepo interfaces.TaskRepository, fileRepo interfaces.PersistentRepository, conflicts usecases.ImportConflictPolicy) error {
 	// Получаем все задачи из file-based репозитория
 	tasks, err := fileRepo.GetAll(context.Background())
 	if err != nil {
 		return err
 	}
 
-	// Добавляем их в in-memory репозиторий
-	for _, task := range tasks {
-		if err := taskRepo.Create(context.Background(), task); err != nil {
-			log.Printf("Предупреждение: не удалось добавить задачу %s в in-memory репозиторий: %v", task.ID.String(), err)
-		}
-	}
+	// Добавляем их в in-memory репозиторий; задачи, уже находящиеся в памяти, обрабатываются по политике
+	summary, err := usecases.ImportTasks(context.Background(), taskRepo, tasks, conflicts)
+	log.Printf("Синхронизировано %d задач между репозиториями: создано %d, пропущено %d, заменено %d",
+		len(tasks), summary.Created, summary.Skipped, summary.Overwritten)
+	return err
+}
 
-	log.Printf("Синхронизировано %d задач между репозиториями", len(tasks))
-	return nil
+// readinessChecks возвращает проверки для /health/ready из компонентов, умеющих сообщать о своем состоянии
+func readinessChecks(fileRepo interfaces.PersistentRepository, maintenance interfaces.MaintenanceMode) map[string]interfaces.HealthChecker {
+	checks := map[string]interfaces.HealthChecker{"maintenance": maintenance}
+	if checker, ok := fileRepo.(interfaces.HealthChecker); ok {
+		checks["persistence"] = checker
+	}
+	return checks
 }
 
 func main() {
+	configPath := flag.String("config", "", "путь к YAML-файлу конфигурации (переменные окружения имеют приоритет)")
+	flag.Parse()
+
+	// Записи журнала дублируются в трансляцию для GET /admin/logs/stream
+	logBroadcaster := infrastructure.NewLogBroadcaster()
+	log.SetOutput(io.MultiWriter(os.Stderr, logBroadcaster))
+
+	version := buildInfo()
+	log.Printf("Версия %s (коммит %s, собрано %s, %s)", version.Version, version.Commit, version.BuildTime, version.GoVersion)
+
+	// Загрузка конфигурации
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить конфигурацию: %v", err)
+	}
+
+	// Единый источник времени для use case'ов и фоновых компонентов
+	clock := infrastructure.NewSystemClock()
+
 	// Инициализация зависимостей
-	taskRepo := repository.NewInMemoryTaskRepository()
-	fileRepo := repository.NewFileBasedTaskRepository("./data/tasks.json")
+	repoOptions := []repository.FileRepositoryOption{
+		repository.WithSeparateFileStorage(cfg.StateFilesThreshold),
+		repository.WithRepositoryClock(clock),
+	}
+	if cfg.StateWriteBehind {
+		repoOptions = append(repoOptions, repository.WithWriteBehind(cfg.StateRetryInterval, clock))
+	}
+	fileRepo := repository.NewFileBasedTaskRepository(cfg.StatePath(), repoOptions...)
+	taskRepo := repository.NewInMemoryTaskRepository(repository.WithCapacity(cfg.MemoryTaskLimit, fileRepo))
 
 	// Загрузка существующих задач из файла
 	if err := fileRepo.LoadTasks(); err != nil {
 		log.Printf("Предупреждение: не удалось загрузить задачи из файла: %v", err)
 	}
 
+	presetRepo := repository.NewFileBasedPresetRepository(cfg.PresetsFile)
+	if err := presetRepo.LoadPresets(); err != nil {
+		log.Printf("Предупреждение: не удалось загрузить пресеты из файла: %v", err)
+	}
+
+	// Окончательно неудачные задачи переносятся в отдельное хранилище, только если задан его файл
+	var deadLetterRepo interfaces.DeadLetterRepository
+	if cfg.DeadLetterFile != "" {
+		deadLetterRepo = repository.NewFileBasedDeadLetterRepository(cfg.DeadLetterFile)
+		if err := deadLetterRepo.LoadDeadLetters(); err != nil {
+			log.Printf("Предупреждение: не удалось загрузить неудачные задачи из файла: %v", err)
+		}
+	}
+
+	// Режим обслуживания восстанавливается из файла, если он задан, иначе сервис запускается в обычном режиме
+	var maintenanceRepo interfaces.MaintenanceRepository
+	if cfg.MaintenanceFile != "" {
+		maintenanceRepo = repository.NewFileBasedMaintenanceRepository(cfg.MaintenanceFile)
+	}
+	maintenance, err := usecases.NewMaintenanceMode(maintenanceRepo, clock)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить режим обслуживания: %v", err)
+	}
+
 	// Синхронизация данных между репозиториями
-	if err := syncRepositories(taskRepo, fileRepo); err != nil {
+	importConflicts, err := usecases.ParseImportConflictPolicy(cfg.ImportConflicts)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+	if err := syncRepositories(taskRepo, fileRepo, importConflicts); err != nil {
 		log.Printf("Предупреждение: не удалось синхронизировать репозитории: %v", err)
 	}
 
-	// Инициализация use case'ов
-	taskUsecase := usecases.NewTaskUsecase(taskRepo, fileRepo)
-	downloadUsecase := usecases.NewDownloadUsecase(taskRepo, fileRepo)
+	// Проверка возможности записи в директорию скачивания до приема задач
+	if err := infrastructure.ProbeWritable(cfg.DownloadDir); err != nil {
+		log.Fatalf("Директория скачивания недоступна: %v", err)
+	}
+
+	layout, err := usecases.ParseLayout(cfg.Layout)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+	naming, err := usecases.ParseNamingStrategy(cfg.FileNaming)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+	orphanAction, err := usecases.ParseOrphanAction(cfg.OrphanCleanup)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+	existingFiles, err := usecases.ParseExistingFilePolicy(cfg.ExistingFiles)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+	httpProtocol, err := usecases.ParseHTTPProtocol(cfg.HTTPProtocol)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+
+	failureThreshold, err := usecases.ParseFailureThreshold(cfg.FailureThreshold)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+	retryOn, err := usecases.ParseRetryConditions(cfg.RetryOn)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+	slowAction, err := usecases.ParseSlowAction(cfg.SlowDownloadAction)
+	if err != nil {
+		log.Fatalf("Неверная конфигурация: %v", err)
+	}
+
+	// Инициализация реестра метрик
+	metrics := infrastructure.NewMetricsRegistry()
+	metrics.Describe("downloader_circuit_breaker_state", "Состояние автомата размыкания хоста (0 - закрыт, 1 - полуоткрыт, 2 - открыт)")
+	metrics.Describe("downloader_circuit_breaker_opened_total", "Количество размыканий автомата для хоста")
+	metrics.Describe("downloader_dedup_files_total", "Количество скачанных файлов, замененных ссылкой на файл с тем же содержимым")
+	metrics.Describe("downloader_dedup_saved_bytes_total", "Место на диске, сэкономленное дедупликацией, в байтах")
+	metrics.Describe("downloader_retries_total", "Количество повторных попыток скачивания с хоста")
+	metrics.Describe("downloader_slow_downloads_total", "Количество окон, в которых скорость скачивания с хоста была ниже минимальной")
+	metrics.Describe("downloader_retries_window", "Количество повторных попыток скачивания за окно RETRY_ALERT_WINDOW")
+	metrics.Describe("downloader_retry_alerts_total", "Количество оповещений о превышении порога повторных попыток")
+	metrics.Describe("downloader_queue_full_total", "Количество опросов, в которых задачи не поместились в очередь пула воркеров")
+	metrics.Describe("downloader_tasks_waiting_for_capacity", "Количество задач, ожидающих места в очереди пула воркеров")
+	metrics.Describe("downloader_goroutines", "Количество горутин процесса")
+	metrics.Describe("downloader_active_downloads", "Количество скачиваемых сейчас файлов всех задач")
+	metrics.Describe("downloader_requeue_pending", "Количество задач, ожидающих возврата в очередь после отсутствия свободных воркеров")
+	metrics.Describe("downloader_dispatch_paused", "Раздача задач воркерам приостановлена из-за числа горутин (1 - да, 0 - нет)")
+	metrics.Describe("downloader_disk_free_bytes", "Свободное место на диске директории скачивания в байтах")
+	metrics.Describe("downloader_disk_low", "Скачивание приостановлено из-за нехватки места на диске (1 - да, 0 - нет)")
+	metrics.Describe("downloader_task_events_total", "Количество событий жизненного цикла задач по типам (без событий прогресса)")
+	metrics.Describe("downloader_tasks_finished_total", "Количество завершенных задач по итоговому статусу")
+	metrics.Describe("downloader_downloaded_bytes_total", "Объем скачанных файлов в байтах")
+
+	// Шина событий жизненного цикла задач: use case'ы публикуют события, наблюдатели подписываются независимо.
+	// Подписчики регистрируются до запуска воркеров, чтобы не пропустить события
+	events := infrastructure.NewEventBus()
+	eventMetrics := infrastructure.NewEventMetrics(events, metrics)
+
+	hostPolicy := usecases.HostPolicy{Allow: cfg.AllowedHosts, Deny: cfg.DeniedHosts}
+
+	// Защита от SSRF: соединения с внутренними адресами запрещены для всех протоколов
+	transport := http.DefaultTransport
+	var dial fetcher.DialContextFunc
+	if cfg.SSRFProtection {
+		allowedNetworks, err := infrastructure.ParseCIDRs(cfg.SSRFAllowedNetworks)
+		if err != nil {
+			log.Fatalf("Неверная конфигурация: %v", err)
+		}
+		safeDialer := infrastructure.NewSafeDialer(infrastructure.SafeDialerConfig{AllowedNetworks: allowedNetworks})
+		transport = safeDialer.Transport()
+		dial = safeDialer.DialContext
+	}
+	if cfg.SFTPKnownHosts == "" && cfg.SFTPInsecureIgnoreHostKey {
+		log.Printf("Предупреждение: ключ SFTP-серверов не проверяется (SFTP_INSECURE_IGNORE_HOST_KEY), учетные данные могут быть перехвачены")
+	}
+
+	// Инициализация use case'ов; изменения задачи через API и её обработка воркером используют общие блокировки задач
+	taskLocker := usecases.NewTaskLocker()
+	downloadOptions := []usecases.DownloadOption{
+		usecases.WithDownloadTaskLocker(taskLocker),
+		usecases.WithDownloadDir(cfg.DownloadDir),
+		usecases.WithLayout(layout),
+		usecases.WithNameResolver(usecases.NewNameResolver(naming)),
+		usecases.WithDirectoryIndexName(cfg.DirectoryIndex),
+		usecases.WithEvents(events),
+		usecases.WithExistingFilePolicy(existingFiles),
+		usecases.WithRoundTripper(transport),
+		usecases.WithHTTPConfig(usecases.HTTPFetcherConfig{
+			UserAgent:  cfg.UserAgent,
+			Headers:    cfg.DefaultHeaders,
+			LogHeaders: cfg.LogHTTPHeaders,
+			Protocol:   httpProtocol,
+			Timeouts: usecases.HTTPTimeouts{
+				Connect:        cfg.HTTPConnectTimeout,
+				TLSHandshake:   cfg.HTTPTLSHandshakeTimeout,
+				ResponseHeader: cfg.HTTPResponseHeaderTimeout,
+				FirstByte:      cfg.HTTPFirstByteTimeout,
+				Idle:           cfg.HTTPIdleTimeout,
+				Total:          cfg.HTTPTimeout,
+				RedirectHop:    cfg.HTTPRedirectHopTimeout,
+				RedirectTotal:  cfg.HTTPRedirectTimeout,
+			},
+			Signer: usecases.URLSigner{
+				Hosts:          cfg.URLSigningHosts,
+				Secret:         cfg.URLSigningSecret,
+				TTL:            cfg.URLSigningTTL,
+				SignatureParam: cfg.URLSigningSignatureParam,
+				ExpiresParam:   cfg.URLSigningExpiresParam,
+			},
+		}),
+		usecases.WithHostPolicy(hostPolicy),
+		usecases.WithMetrics(metrics),
+		usecases.WithClock(clock),
+		usecases.WithCircuitBreaker(usecases.CircuitBreakerConfig{
+			FailureThreshold: cfg.CircuitBreakerThreshold,
+			Window:           cfg.CircuitBreakerWindow,
+			Cooldown:         cfg.CircuitBreakerCooldown,
+		}),
+		usecases.WithFetcher("ftp", fetcher.NewFTPFetcher(cfg.FetchTimeout, dial)),
+		usecases.WithFetcher("sftp", fetcher.NewSFTPFetcher(cfg.FetchTimeout, cfg.SFTPKnownHosts, cfg.SFTPPrivateKey, cfg.SFTPInsecureIgnoreHostKey, dial)),
+		usecases.WithProgressPersistence(usecases.ProgressConfig{
+			Interval: cfg.ProgressInterval,
+			MinBytes: cfg.ProgressMinBytes,
+		}),
+		usecases.WithSegmentedDownload(usecases.SegmentConfig{
+			Count:   cfg.SegmentCount,
+			MinSize: cfg.SegmentMinSize,
+		}),
+		usecases.WithFileConcurrency(cfg.FileConcurrency),
+		usecases.WithFairFileSlots(cfg.FileSlots()),
+		usecases.WithRequestRateLimit(usecases.RequestRateLimit{
+			Requests: cfg.RequestRateLimit,
+			Interval: cfg.RequestRateInterval,
+			Burst:    cfg.RequestRateBurst,
+		}),
+		usecases.WithResourceBudget(usecases.ResourceBudget{Memory: cfg.ResourceMemoryBudget, FileDescriptors: cfg.ResourceFDBudget}),
+		usecases.WithMinFileSize(cfg.MinFileSize),
+		usecases.WithExtractionLimits(usecases.ExtractionLimits{MaxSize: cfg.ExtractMaxSize, MaxFiles: cfg.ExtractMaxFiles}),
+		usecases.WithDiskQuota(cfg.DiskQuota, cfg.DiskQuotaCleanup),
+		usecases.WithFailureThreshold(failureThreshold),
+		usecases.WithCompression(cfg.CompressFiles),
+		usecases.WithCopyBufferSize(cfg.CopyBufferSize),
+		usecases.WithRetryPolicy(usecases.RetryPolicy{
+			MaxAttempts: cfg.RetryMaxAttempts,
+			Backoff:     cfg.RetryBackoff,
+			MaxBackoff:  cfg.RetryMaxBackoff,
+			RetryOn:     retryOn,
+		}),
+		usecases.WithSlowDownloadPolicy(usecases.SlowDownloadPolicy{
+			MinSpeed: cfg.SlowDownloadMinSpeed,
+			Window:   cfg.SlowDownloadWindow,
+			Action:   slowAction,
+		}),
+	}
+
+	// Общая проверка свободного места: при нехватке новые файлы всех задач не начинают скачиваться
+	var diskWatchdog *infrastructure.DiskWatchdog
+	if cfg.MinFreeDisk > 0 {
+		diskWatchdog = infrastructure.NewDiskWatchdog(cfg.DownloadDir, cfg.MinFreeDisk, cfg.DiskCheckInterval,
+			infrastructure.WithDiskClock(clock), infrastructure.WithDiskMetrics(metrics))
+		downloadOptions = append(downloadOptions, usecases.WithDiskSpaceGate(diskWatchdog))
+	}
+
+	var retryNotifier interfaces.AlertNotifier
+	if cfg.RetryAlertWebhook != "" {
+		retryNotifier = infrastructure.NewWebhookNotifier(cfg.RetryAlertWebhook)
+	}
+	downloadOptions = append(downloadOptions, usecases.WithRetryAlerts(usecases.RetryAlertConfig{
+		Window:    cfg.RetryAlertWindow,
+		Threshold: cfg.RetryAlertThreshold,
+	}, retryNotifier))
+	downloadOptions = append(downloadOptions, usecases.WithCallbacks(
+		infrastructure.NewCallbackSender(transport, cfg.CallbackTimeout),
+		usecases.RetryPolicy{
+			MaxAttempts: cfg.CallbackMaxAttempts,
+			Backoff:     cfg.CallbackBackoff,
+			MaxBackoff:  cfg.CallbackMaxBackoff,
+		}))
+	if cfg.Deduplication {
+		downloadOptions = append(downloadOptions, usecases.WithDeduplication(filepath.Join(cfg.DownloadDir, usecases.ContentStoreDirName)))
+	}
+	downloadUsecase := usecases.NewDownloadUsecase(taskRepo, fileRepo, downloadOptions...)
+	taskOptions := []usecases.TaskOption{
+		usecases.WithTaskLocker(taskLocker),
+		usecases.WithSupportedSchemes(downloadUsecase.SupportedSchemes()),
+		usecases.WithPageSize(cfg.PageSize, cfg.MaxPageSize),
+		usecases.WithTrashDir(cfg.DownloadDir),
+		usecases.WithOutputDirRoots(cfg.OutputDirRoots),
+		usecases.WithOrphanAction(orphanAction),
+		usecases.WithHostValidation(hostPolicy),
+		usecases.WithMaxURLLength(cfg.MaxURLLength),
+		usecases.WithMaxInlineContentSize(cfg.MaxInlineContentSize),
+		usecases.WithManifestFetcher(infrastructure.NewManifestFetcher(transport, cfg.ManifestTimeout, cfg.UserAgent), cfg.MaxManifestSize),
+		usecases.WithMaxActiveTasks(cfg.MaxActiveTasks),
+		usecases.WithDefaultTTL(cfg.TaskTTL),
+		usecases.WithTaskClock(clock),
+		usecases.WithTaskEvents(events),
+		usecases.WithPresets(presetRepo),
+		usecases.WithMaintenance(maintenance),
+		usecases.WithTreeLimits(usecases.TreeLimits{MaxDepth: cfg.TreeMaxDepth, MaxEntries: cfg.TreeMaxEntries}),
+		usecases.WithCompactionRetention(cfg.CompactionRetention),
+	}
+	if deadLetterRepo != nil {
+		taskOptions = append(taskOptions, usecases.WithDeadLetters(deadLetterRepo, cfg.DeadLetterAfter))
+	}
+	if cfg.Deduplication {
+		taskOptions = append(taskOptions, usecases.WithContentStore(filepath.Join(cfg.DownloadDir, usecases.ContentStoreDirName)))
+	}
+	if cfg.ContentTaskIDs {
+		namespace := usecases.DefaultTaskIDNamespace
+		if cfg.TaskIDNamespace != "" {
+			// Формат пространства имен проверен при загрузке конфигурации
+			namespace = uuid.MustParse(cfg.TaskIDNamespace)
+		}
+		taskOptions = append(taskOptions, usecases.WithContentTaskIDs(namespace))
+	}
+	taskUsecase := usecases.NewTaskUsecase(taskRepo, fileRepo, taskOptions...)
+
+	// Задачи, прерванные аварийной остановкой, возвращаются в очередь до запуска воркеров
+	if requeued, err := downloadUsecase.RequeueInterruptedTasks(context.Background()); err != nil {
+		log.Printf("Предупреждение: не удалось вернуть в очередь прерванные задачи: %v", err)
+	} else if requeued > 0 {
+		log.Printf("Прерванных задач возвращено в очередь: %d", requeued)
+	}
+
+	// Инициализация пула воркеров для скачивания
+	poolOptions := []infrastructure.WorkerPoolOption{
+		infrastructure.WithPoolClock(clock),
+		infrastructure.WithQueueCapacity(cfg.QueueCapacity),
+		infrastructure.WithQueueWait(cfg.QueueWaitTimeout),
+		infrastructure.WithPoolMetrics(metrics),
+		infrastructure.WithGoroutineLimit(cfg.MaxGoroutines),
+	}
+	if diskWatchdog != nil && cfg.DiskLowPausePool {
+		poolOptions = append(poolOptions, infrastructure.WithDiskSpacePause(diskWatchdog))
+	}
+	workerPool := infrastructure.NewWorkerPool(cfg.PoolWorkers(), downloadUsecase, poolOptions...)
+	workerPool.Start()
 
 	// Инициализация HTTP-обработчиков
-	taskHandler := httpHandlers.NewTaskHandler(taskUsecase, downloadUsecase)
+	taskHandler := httpHandlers.NewTaskHandler(taskUsecase, downloadUsecase,
+		httpHandlers.WithMaxRequestTimeout(cfg.MaxRequestTimeout),
+		httpHandlers.WithSizeStrings(cfg.APISizeStrings),
+		httpHandlers.WithEventStream(events))
+	adminHandler := httpHandlers.NewAdminHandler(taskUsecase, workerPool, logBroadcaster, downloadUsecase, maintenance, downloadUsecase)
 
 	// Инициализация сервера
+	checks := readinessChecks(fileRepo, maintenance)
+	if diskWatchdog != nil {
+		checks["disk"] = diskWatchdog
+	}
+	routeOptions := []httpHandlers.RouteOption{
+		httpHandlers.WithMetricsHandler(metrics.Handler()),
+		httpHandlers.WithAdminHandler(adminHandler, cfg.APIKey),
+		httpHandlers.WithReadinessChecks(checks),
+		httpHandlers.WithBuildInfo(version),
+		// Изменяющие и дорогие запросы сверх MAX_IN_FLIGHT_REQUESTS отклоняются до обработки
+		httpHandlers.WithInFlightLimit(cfg.MaxInFlightRequests, cfg.InFlightRetryAfter),
+	}
+	if cfg.WebUI {
+		routeOptions = append(routeOptions, httpHandlers.WithWebUI())
+	}
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: httpHandlers.SetupRoutes(taskHandler),
+		Addr:    cfg.ServerAddr,
+		Handler: httpHandlers.SetupRoutes(taskHandler, routeOptions...),
 	}
 
-	// Инициализация пула воркеров для скачивания
-	workerPool := infrastructure.NewWorkerPool(3, downloadUsecase) // 3 параллельных скачивания
-	workerPool.Start()
+	// Трансляции журнала завершаются при остановке сервера, иначе Shutdown ждал бы отключения клиентов
+	server.RegisterOnShutdown(logBroadcaster.Close)
 
 	// Настройка graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Свободное место проверяется сразу при запуске и затем каждые DISK_CHECK_INTERVAL
+	if diskWatchdog != nil {
+		go diskWatchdog.Run(ctx)
+	}
+
 	// Запуск процессора задач для обработки новых задач
-	go func() {
-		log.Println("Процессор задач запущен")
-		for {
-			select {
-			case <-ctx.Done():
-				log.Println("Процессор задач остановлен")
-				return
-			default:
-				// Получение ожидающих задач и добавление их в пул воркеров
-				pendingTasks, err := downloadUsecase.GetPendingTasks(ctx)
-				if err != nil {
-					log.Printf("Ошибка получения ожидающих задач: %v", err)
-					time.Sleep(5 * time.Second)
-					continue
-				}
-
-				log.Printf("Найдено %d ожидающих задач", len(pendingTasks))
-				for _, task := range pendingTasks {
-					log.Printf("Задача %s имеет статус: %s", task.ID.String(), task.Status)
-					if task.Status == entities.TaskStatusNew {
-						log.Printf("Добавляем задачу %s в пул воркеров", task.ID.String())
-						if err := workerPool.AddTask(task.ID.String()); err != nil {
-							log.Printf("Ошибка добавления задачи в пул воркеров: %v", err)
-						} else {
-							log.Printf("Задача %s успешно добавлена в пул воркеров", task.ID.String())
-						}
-					}
-				}
-
-				time.Sleep(2 * time.Second)
-			}
-		}
-	}()
+	scheduler := infrastructure.NewTaskScheduler(downloadUsecase, workerPool, infrastructure.SchedulerConfig{
+		PollInterval: cfg.PollInterval,
+		RampWindow:   cfg.RestartRampWindow,
+		Jitter:       cfg.RestartRampJitter,
+		Clock:        clock,
+		Metrics:      metrics,
+		Maintenance:  maintenance,
+	})
+	go scheduler.Run(ctx)
+
+	// Запуск очистки корзины удаленных задач
+	trashCollector := infrastructure.NewTrashCollector(taskUsecase, cfg.TrashRetention, cfg.TrashGCInterval,
+		infrastructure.WithCollectorClock(clock))
+	go trashCollector.Run(ctx)
+
+	// Запуск удаления задач с истекшим сроком хранения
+	expiryReaper := infrastructure.NewExpiryReaper(taskUsecase, cfg.TaskExpiryInterval,
+		infrastructure.WithReaperClock(clock))
+	go expiryReaper.Run(ctx)
+	if deadLetterRepo != nil {
+		deadLetterMover := infrastructure.NewDeadLetterMover(taskUsecase, cfg.DeadLetterInterval,
+			infrastructure.WithDeadLetterClock(clock))
+		go deadLetterMover.Run(ctx)
+	}
+
+	// Запуск периодического сжатия файла состояния
+	if cfg.CompactionInterval > 0 {
+		storeCompactor := infrastructure.NewStoreCompactor(taskUsecase, cfg.CompactionInterval,
+			infrastructure.WithCompactorClock(clock))
+		go storeCompactor.Run(ctx)
+	}
+
+	// Запуск очистки директорий задач, которых больше нет в хранилище
+	if orphanAction != usecases.OrphanActionOff {
+		orphanCollector := infrastructure.NewOrphanCollector(taskUsecase, cfg.OrphanGracePeriod, cfg.OrphanCleanupInterval,
+			infrastructure.WithOrphanCollectorClock(clock))
+		go orphanCollector.Run(ctx)
+	}
+
+	// Запуск доставки уведомлений о завершении задач; недоставленные уведомления сохраняются в задачах
+	callbackDispatcher := infrastructure.NewCallbackDispatcher(downloadUsecase, cfg.CallbackPollInterval,
+		infrastructure.WithDispatcherClock(clock),
+		infrastructure.WithDispatcherEvents(events))
+	go callbackDispatcher.Run(ctx)
+	go eventMetrics.Run(ctx)
 
 	// Запуск сервера в горутине
 	go func() {
-		log.Println("Запуск сервера на :8080")
+		log.Printf("Запуск сервера на %s", cfg.ServerAddr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Не удалось запустить сервер: %v", err)
 		}
@@ -125,19 +513,38 @@ func main() {
 	// Отмена контекста для прекращения приёма новых задач
 	cancel()
 
-	// Graceful остановка пула воркеров
-	workerPool.Stop()
+	// Остановка воркеров ограничена сроком: зависшие воркеры оставляются,
+	// а их задачи после перезапуска возвращаются в очередь как прерванные
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer shutdownCancel()
 
+	// Graceful остановка пула воркеров: при SHUTDOWN_DRAIN принятые задачи дорабатываются в пределах срока
+	var report *entities.ShutdownReport
+	if cfg.ShutdownDrain {
+		report = workerPool.Drain(shutdownCtx)
+	} else {
+		if err := workerPool.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Принудительная остановка пула воркеров: %v", err)
+		}
+		report = workerPool.ShutdownReport()
+	}
+	// Отчет о незавершенной работе пул пишет в журнал; при заданном файле он сохраняется и для сверки после перезапуска
+	if report != nil && cfg.ShutdownReportFile != "" {
+		if err := infrastructure.WriteShutdownReport(cfg.ShutdownReportFile, report); err != nil {
+			log.Printf("Ошибка сохранения отчета об остановке: %v", err)
+		}
+	}
 	// Сохранение текущего состояния в файл
 	if err := fileRepo.SaveTasks(); err != nil {
 		log.Printf("Ошибка сохранения задач: %v", err)
 	}
 
-	// Остановка HTTP-сервера
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdownCancel()
-
-	if err := server.Shutdown(shutdownCtx); err != nil {
+	// Остановка HTTP-сервера с собственным сроком, чтобы долгое ожидание воркеров не лишало запросы
+	// времени на завершение. Потоки /events и /admin/logs/stream закрываются до ожидания запросов
+	events.Close()
+	serverCtx, serverCancel := context.WithTimeout(context.Background(), cfg.ServerShutdownTimeout)
+	defer serverCancel()
+	if err := server.Shutdown(serverCtx); err != nil {
 		log.Printf("Принудительная остановка сервера: %v", err)
 	}
 
@@ -0,0 +1,20 @@
+package fetcher
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DialContextFunc устанавливает сетевое соединение (например, с защитой от SSRF)
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// dialWithTimeout устанавливает соединение, ограничивая время подключения таймаутом (0 - без ограничения)
+func dialWithTimeout(ctx context.Context, dial DialContextFunc, timeout time.Duration, network, address string) (net.Conn, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return dial(ctx, network, address)
+}
@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+
+	"file-downloader/internal/interfaces"
+)
+
+// FTPFetcher реализует Fetcher для схемы ftp
+type FTPFetcher struct {
+	timeout time.Duration
+	dial    DialContextFunc
+}
+
+// NewFTPFetcher создает новый FTP fetcher.
+// Если dial равен nil, используется стандартный dialer.
+func NewFTPFetcher(timeout time.Duration, dial DialContextFunc) interfaces.Fetcher {
+	return &FTPFetcher{
+		timeout: timeout,
+		dial:    dial,
+	}
+}
+
+// Fetch подключается к FTP-серверу и открывает файл на чтение
+func (f *FTPFetcher) Fetch(ctx context.Context, req interfaces.FetchRequest) (*interfaces.FetchResult, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("неверный URL: %w", err)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "21")
+	}
+
+	options := []ftp.DialOption{ftp.DialWithContext(ctx), ftp.DialWithTimeout(f.timeout)}
+	if f.dial != nil {
+		// Через dial проходят и управляющее соединение, и соединения передачи данных
+		options = append(options, ftp.DialWithDialFunc(func(network, address string) (net.Conn, error) {
+			return dialWithTimeout(ctx, f.dial, f.timeout, network, address)
+		}))
+	}
+
+	conn, err := ftp.Dial(addr, options...)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключиться к FTP-серверу: %w", err)
+	}
+
+	// Анонимный вход, если учетные данные не указаны в URL
+	user, password := "anonymous", "anonymous"
+	if u.User != nil {
+		user = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			password = p
+		}
+	}
+
+	if err := conn.Login(user, password); err != nil {
+		conn.Quit()
+		return nil, fmt.Errorf("не удалось авторизоваться на FTP-сервере: %w", err)
+	}
+
+	size, err := conn.FileSize(u.Path)
+	if err != nil {
+		size = -1
+	}
+
+	resp, err := conn.Retr(u.Path)
+	if err != nil {
+		conn.Quit()
+		return nil, fmt.Errorf("не удалось скачать: %w", err)
+	}
+
+	return &interfaces.FetchResult{
+		Body: &ftpBody{resp: resp, conn: conn},
+		Size: size,
+	}, nil
+}
+
+// ftpBody закрывает FTP-соединение вместе с потоком данных
+type ftpBody struct {
+	resp *ftp.Response
+	conn *ftp.ServerConn
+}
+
+func (b *ftpBody) Read(p []byte) (int, error) {
+	return b.resp.Read(p)
+}
+
+func (b *ftpBody) Close() error {
+	err := b.resp.Close()
+	b.conn.Quit()
+	return err
+}
@@ -0,0 +1,180 @@
+package fetcher
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// startFTPServer serves files to the user "user" with the given password over a minimal passive-mode FTP server
+func startFTPServer(t *testing.T, password string, files map[string]string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error listening, got %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFTP(conn, password, files)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// serveFTP answers the commands the FTP client issues for a login and a single download
+func serveFTP(conn net.Conn, password string, files map[string]string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+	var data net.Listener
+	defer func() {
+		if data != nil {
+			data.Close()
+		}
+	}()
+
+	reply("220 ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch command {
+		case "USER":
+			reply("331 password required")
+		case "PASS":
+			if arg != password {
+				reply("530 login incorrect")
+				continue
+			}
+			reply("230 logged in")
+		case "TYPE":
+			reply("200 type set")
+		case "SIZE":
+			content, ok := files[arg]
+			if !ok {
+				reply("550 no such file")
+				continue
+			}
+			reply("213 %d", len(content))
+		case "EPSV":
+			data, err = net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				reply("425 cannot open data connection")
+				continue
+			}
+			reply("229 entering extended passive mode (|||%d|)", data.Addr().(*net.TCPAddr).Port)
+		case "RETR":
+			content, ok := files[arg]
+			if !ok || data == nil {
+				reply("550 no such file")
+				continue
+			}
+			reply("150 opening data connection")
+			dataConn, err := data.Accept()
+			if err != nil {
+				return
+			}
+			io.WriteString(dataConn, content)
+			dataConn.Close()
+			reply("226 transfer complete")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestFTPFetcherRoundTrip(t *testing.T) {
+	addr := startFTPServer(t, "secret", map[string]string{"/pub/file.bin": "ftp content"})
+
+	testCases := []struct {
+		name     string
+		password string
+		path     string
+		succeeds bool
+	}{
+		{"existing file", "secret", "/pub/file.bin", true},
+		{"wrong password", "wrong", "/pub/file.bin", false},
+		{"missing file", "secret", "/pub/missing.bin", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			fetcher := NewFTPFetcher(time.Second, nil)
+			rawURL := (&url.URL{Scheme: "ftp", User: url.UserPassword("user", tc.password), Host: addr, Path: tc.path}).String()
+
+			// Execute
+			result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: rawURL})
+
+			// Assert
+			if !tc.succeeds {
+				if err == nil {
+					result.Body.Close()
+					t.Fatal("Expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			data, err := io.ReadAll(result.Body)
+			if err != nil || string(data) != "ftp content" {
+				t.Errorf("Expected content %q, got %q (%v)", "ftp content", data, err)
+			}
+			if result.Size != int64(len("ftp content")) {
+				t.Errorf("Expected size %d, got %d", len("ftp content"), result.Size)
+			}
+			if err := result.Body.Close(); err != nil {
+				t.Errorf("Expected the transfer to close cleanly, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFTPFetcherUsesDialFunc(t *testing.T) {
+	// Setup: every connection, including data connections, goes through the dial function
+	addr := startFTPServer(t, "anonymous", map[string]string{"/file.bin": "via dial"})
+	var dialed []string
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return (&net.Dialer{}).DialContext(ctx, network, address)
+	}
+	fetcher := NewFTPFetcher(time.Second, dial)
+
+	// Execute
+	result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: "ftp://" + addr + "/file.bin"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected anonymous login to succeed, got %v", err)
+	}
+	data, _ := io.ReadAll(result.Body)
+	result.Body.Close()
+	if string(data) != "via dial" {
+		t.Errorf("Expected content %q, got %q", "via dial", data)
+	}
+	if len(dialed) != 2 {
+		t.Errorf("Expected control and data connections through the dial function, got %v", dialed)
+	}
+}
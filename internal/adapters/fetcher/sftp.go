@@ -0,0 +1,161 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// SFTPFetcher реализует Fetcher для схемы sftp
+type SFTPFetcher struct {
+	timeout        time.Duration
+	knownHostsPath string
+	privateKeyPath string
+	insecure       bool // подключаться без проверки ключа хоста, если knownHostsPath пуст
+	dial           DialContextFunc
+}
+
+// NewSFTPFetcher создает новый SFTP fetcher.
+// Если knownHostsPath пуст, подключения отклоняются, пока insecure не разрешает не проверять ключ хоста:
+// иначе пароль из URL или вход по ключу достались бы любому, кто перехватит соединение.
+// Если dial равен nil, используется стандартный dialer.
+func NewSFTPFetcher(timeout time.Duration, knownHostsPath, privateKeyPath string, insecure bool, dial DialContextFunc) interfaces.Fetcher {
+	if dial == nil {
+		dialer := &net.Dialer{}
+		dial = dialer.DialContext
+	}
+
+	return &SFTPFetcher{
+		timeout:        timeout,
+		knownHostsPath: knownHostsPath,
+		privateKeyPath: privateKeyPath,
+		insecure:       insecure,
+		dial:           dial,
+	}
+}
+
+// Fetch подключается к SSH-серверу и открывает файл через SFTP
+func (f *SFTPFetcher) Fetch(ctx context.Context, req interfaces.FetchRequest) (*interfaces.FetchResult, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("неверный URL: %w", err)
+	}
+
+	config, err := f.clientConfig(u)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "22")
+	}
+
+	netConn, err := dialWithTimeout(ctx, f.dial, f.timeout, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключиться к SFTP-серверу: %w", err)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("не удалось установить SSH-соединение: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("не удалось открыть SFTP-сессию: %w", err)
+	}
+
+	file, err := client.Open(u.Path)
+	if err != nil {
+		client.Close()
+		sshClient.Close()
+		return nil, fmt.Errorf("не удалось скачать: %w", err)
+	}
+
+	var size int64 = -1
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return &interfaces.FetchResult{
+		Body: &sftpBody{file: file, client: client, sshClient: sshClient},
+		Size: size,
+	}, nil
+}
+
+// clientConfig формирует конфигурацию SSH-клиента из URL и настроек fetcher'а
+func (f *SFTPFetcher) clientConfig(u *url.URL) (*ssh.ClientConfig, error) {
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("в SFTP URL не указан пользователь")
+	}
+
+	var auth []ssh.AuthMethod
+	if password, ok := u.User.Password(); ok {
+		auth = append(auth, ssh.Password(password))
+	}
+
+	if f.privateKeyPath != "" {
+		key, err := os.ReadFile(f.privateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать приватный ключ: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось распарсить приватный ключ: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case f.knownHostsPath != "":
+		callback, err := knownhosts.New(f.knownHostsPath)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить known_hosts: %w", err)
+		}
+		hostKeyCallback = callback
+	case f.insecure:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, fmt.Errorf("%w: ключ SFTP-сервера %s нечем проверить, задайте SFTP_KNOWN_HOSTS", entities.ErrHostNotAllowed, u.Hostname())
+	}
+
+	return &ssh.ClientConfig{
+		User:            u.User.Username(),
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         f.timeout,
+	}, nil
+}
+
+// sftpBody закрывает SFTP-сессию и SSH-соединение вместе с файлом
+type sftpBody struct {
+	file      *sftp.File
+	client    *sftp.Client
+	sshClient *ssh.Client
+}
+
+func (b *sftpBody) Read(p []byte) (int, error) {
+	return b.file.Read(p)
+}
+
+func (b *sftpBody) Close() error {
+	err := b.file.Close()
+	b.client.Close()
+	b.sshClient.Close()
+	return err
+}
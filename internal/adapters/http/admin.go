@@ -0,0 +1,226 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// AdminHandler обрабатывает административные запросы и статистику сервиса
+type AdminHandler struct {
+	taskUsecase interfaces.TaskUsecase
+	pool        interfaces.WorkerPool
+	logs        interfaces.LogStream
+	retries     interfaces.RetryMonitor
+	maintenance interfaces.MaintenanceMode
+	resources   interfaces.ResourceMonitor
+}
+
+// NewAdminHandler создает новый административный обработчик; logs может быть nil, если трансляция журнала не нужна,
+// retries и resources - если статистика повторных попыток и ресурсов не отображается в /stats,
+// а maintenance - если режим обслуживания не используется
+func NewAdminHandler(taskUsecase interfaces.TaskUsecase, pool interfaces.WorkerPool, logs interfaces.LogStream,
+	retries interfaces.RetryMonitor, maintenance interfaces.MaintenanceMode, resources interfaces.ResourceMonitor) *AdminHandler {
+	return &AdminHandler{
+		taskUsecase: taskUsecase,
+		pool:        pool,
+		logs:        logs,
+		retries:     retries,
+		maintenance: maintenance,
+		resources:   resources,
+	}
+}
+
+// ResizeWorkersRequest представляет тело запроса изменения количества воркеров
+type ResizeWorkersRequest struct {
+	Count int `json:"count"`
+}
+
+// ResizeWorkers обрабатывает POST /admin/workers
+func (h *AdminHandler) ResizeWorkers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ResizeWorkersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Count < 1 {
+		http.Error(w, "Количество воркеров должно быть положительным", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.pool.Resize(req.Count); err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось изменить количество воркеров: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"workers": h.pool.WorkerCount(),
+	})
+}
+
+// MaintenanceRequest представляет тело запроса переключения режима обслуживания
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// Maintenance обрабатывает GET и POST /admin/maintenance: возвращает или переключает режим обслуживания
+func (h *AdminHandler) Maintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.maintenance == nil {
+		http.Error(w, "Режим обслуживания недоступен", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		writeJSON(w, r, http.StatusOK, h.maintenance.State())
+		return
+	}
+
+	var req MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Enabled == nil {
+		http.Error(w, "Поле enabled обязательно", http.StatusBadRequest)
+		return
+	}
+
+	state, err := h.maintenance.SetEnabled(r.Context(), *req.Enabled)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось переключить режим обслуживания: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, state)
+}
+
+// Compact обрабатывает POST /admin/compact: атомарно переписывает постоянное хранилище задач
+// и возвращает число освобожденных байт. Отключение клиента прерывает сжатие без изменения хранилища.
+func (h *AdminHandler) Compact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.taskUsecase.CompactStore(r.Context())
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrCompactionUnsupported):
+			http.Error(w, "Сжатие хранилища недоступно", http.StatusNotFound)
+		case errors.Is(err, entities.ErrCompactionInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось сжать хранилище: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+// StreamLogs обрабатывает GET /admin/logs/stream: транслирует записи журнала по мере их появления
+// в формате Server-Sent Events, пока клиент не отключится
+func (h *AdminHandler) StreamLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.logs == nil {
+		http.Error(w, "Трансляция журнала недоступна", http.StatusNotFound)
+		return
+	}
+
+	// Записи журнала сервиса не содержат уровня, поэтому фильтровать по нему нечего
+	if r.URL.Query().Has("level") {
+		http.Error(w, "Фильтр level не поддерживается: записи журнала не содержат уровня", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Потоковая передача не поддерживается", http.StatusInternalServerError)
+		return
+	}
+
+	lines, unsubscribe := h.logs.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			// Многострочная запись передается несколькими полями data одного события
+			for _, part := range strings.Split(line, "\n") {
+				fmt.Fprintf(w, "data: %s\n", part)
+			}
+			fmt.Fprint(w, "\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// Stats обрабатывает GET /stats
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tasks, err := h.taskUsecase.GetAllTasks(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось получить задачи: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	byStatus := make(map[entities.TaskStatus]int)
+	for _, task := range tasks {
+		if !task.IsDeleted() {
+			byStatus[task.Status]++
+		}
+	}
+
+	stats := map[string]interface{}{
+		"workers":      h.pool.WorkerCount(),
+		"busy_workers": h.pool.BusyWorkers(),
+		"queue_length": h.pool.QueueLength(),
+		"tasks":        byStatus,
+	}
+	if h.retries != nil {
+		stats["retries"] = h.retries.RetryStats()
+	}
+	if h.maintenance != nil {
+		stats["maintenance"] = h.maintenance.Enabled()
+	}
+	if h.resources != nil {
+		stats["resources"] = h.resources.ResourceUsage()
+	}
+
+	writeJSON(w, r, http.StatusOK, stats)
+}
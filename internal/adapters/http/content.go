@@ -0,0 +1,38 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"file-downloader/internal/entities"
+)
+
+// serveFileContent отдает открытое содержимое файла задачи.
+// Несжатый файл отдается через http.ServeContent с поддержкой If-Modified-Since и Range: один диапазон
+// возвращается как 206 с Content-Range, несколько диапазонов (Range: bytes=0-99,200-299) - как 206
+// с телом multipart/byteranges, в каждой части которого указаны Content-Type и Content-Range.
+// Содержимое, сжатое gzip или распаковываемое при отдаче, не поддерживает перемещение и отдается целиком
+// с Accept-Ranges: none.
+func serveFileContent(w http.ResponseWriter, r *http.Request, content *entities.FileContent) {
+	if content.ContentType != "" {
+		w.Header().Set("Content-Type", content.ContentType)
+	}
+	if content.Compression != "" {
+		w.Header().Set("Vary", "Accept-Encoding")
+	}
+
+	if seeker, ok := content.Content.(io.ReadSeeker); ok && content.Encoding == "" {
+		http.ServeContent(w, r, filepath.Base(content.Path), content.ModTime, seeker)
+		return
+	}
+
+	if content.Encoding != "" {
+		w.Header().Set("Content-Encoding", content.Encoding)
+	}
+	w.Header().Set("Accept-Ranges", "none")
+	w.Header().Set("Content-Length", strconv.FormatInt(content.Length, 10))
+	w.Header().Set("Last-Modified", content.ModTime.UTC().Format(http.TimeFormat))
+	io.Copy(w, content.Content)
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+const rangeContent = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// openContent returns the uncompressed content of a completed file as opened by the task usecase
+func openContent(t *testing.T) *entities.FileContent {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte(rangeContent), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return &entities.FileContent{
+		File:    entities.File{Path: path, ContentType: "text/plain"},
+		Content: f,
+		Length:  int64(len(rangeContent)),
+		ModTime: time.Now(),
+	}
+}
+
+func TestServeFileContentSingleRange(t *testing.T) {
+	// Setup
+	req := httptest.NewRequest(http.MethodGet, "/tasks/id/files/0/content", nil)
+	req.Header.Set("Range", "bytes=10-19")
+	rec := httptest.NewRecorder()
+
+	// Execute
+	serveFileContent(rec, req, openContent(t))
+
+	// Assert
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("Expected status 206, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 10-19/36" {
+		t.Errorf("Expected Content-Range bytes 10-19/36, got %q", got)
+	}
+	if body := rec.Body.String(); body != rangeContent[10:20] {
+		t.Errorf("Expected body %q, got %q", rangeContent[10:20], body)
+	}
+}
+
+func TestServeFileContentMultiRange(t *testing.T) {
+	// Setup
+	req := httptest.NewRequest(http.MethodGet, "/tasks/id/files/0/content", nil)
+	req.Header.Set("Range", "bytes=0-3,10-12,-4")
+	rec := httptest.NewRecorder()
+
+	// Execute
+	serveFileContent(rec, req, openContent(t))
+
+	// Assert
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("Expected status 206, got %d", rec.Code)
+	}
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Expected multipart/byteranges, got %q (%v)", rec.Header().Get("Content-Type"), err)
+	}
+
+	expected := []struct{ contentRange, body string }{
+		{"bytes 0-3/36", rangeContent[0:4]},
+		{"bytes 10-12/36", rangeContent[10:13]},
+		{"bytes 32-35/36", rangeContent[32:]},
+	}
+	reader := multipart.NewReader(rec.Body, params["boundary"])
+	for i, want := range expected {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Expected part %d, got %v", i, err)
+		}
+		body, _ := io.ReadAll(part)
+		if got := part.Header.Get("Content-Range"); got != want.contentRange {
+			t.Errorf("Expected part %d Content-Range %q, got %q", i, want.contentRange, got)
+		}
+		if got := part.Header.Get("Content-Type"); got != "text/plain" {
+			t.Errorf("Expected part %d Content-Type text/plain, got %q", i, got)
+		}
+		if string(body) != want.body {
+			t.Errorf("Expected part %d body %q, got %q", i, want.body, body)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("Expected 3 parts, got another part (%v)", err)
+	}
+}
+
+func TestServeFileContentUnsatisfiableRange(t *testing.T) {
+	// Setup
+	req := httptest.NewRequest(http.MethodGet, "/tasks/id/files/0/content", nil)
+	req.Header.Set("Range", "bytes=100-200")
+	rec := httptest.NewRecorder()
+
+	// Execute
+	serveFileContent(rec, req, openContent(t))
+
+	// Assert
+	if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("Expected status 416, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes */36" {
+		t.Errorf("Expected Content-Range bytes */36, got %q", got)
+	}
+}
+
+func TestServeFileContentIgnoresRangeForCompressedContent(t *testing.T) {
+	// Setup
+	req := httptest.NewRequest(http.MethodGet, "/tasks/id/files/0/content", nil)
+	req.Header.Set("Range", "bytes=0-3,10-12")
+	rec := httptest.NewRecorder()
+	content := &entities.FileContent{
+		File:     entities.File{Path: "file.txt.gz", Compression: "gzip"},
+		Content:  io.NopCloser(strings.NewReader("compressed")),
+		Encoding: "gzip",
+		Length:   10,
+		ModTime:  time.Now(),
+	}
+
+	// Execute
+	serveFileContent(rec, req, content)
+
+	// Assert
+	if rec.Code != http.StatusOK || rec.Body.String() != "compressed" {
+		t.Errorf("Expected whole compressed body with status 200, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("Accept-Ranges") != "none" {
+		t.Errorf("Expected Content-Encoding gzip without range support, got %q and %q",
+			rec.Header().Get("Content-Encoding"), rec.Header().Get("Accept-Ranges"))
+	}
+}
+
+func TestGetFileContentRejectsNonGetMethods(t *testing.T) {
+	handler := SetupRoutes(NewTaskHandler(nil, nil))
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			// Setup
+			req := httptest.NewRequest(method, "/tasks/id/files/0/content", nil)
+			rec := httptest.NewRecorder()
+
+			// Execute
+			handler.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != http.StatusMethodNotAllowed {
+				t.Errorf("Expected status 405, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// ListDeadLetters обрабатывает GET /dead-letters
+func (h *TaskHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	letters, err := h.taskUsecase.ListDeadLetters(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось получить неудачные задачи: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"dead_letters": h.view.deadLetters(letters),
+	})
+}
+
+// RedriveDeadLetter обрабатывает POST /dead-letters/{id}/redrive: возвращает неудачную задачу в обработку
+func (h *TaskHandler) RedriveDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := extractDeadLetterID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "Не найдено", http.StatusNotFound)
+		return
+	}
+
+	task, err := h.taskUsecase.RedriveDeadLetter(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrDeadLetterNotFound):
+			http.Error(w, "Неудачная задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskExists):
+			http.Error(w, "Задача уже есть среди активных", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось вернуть задачу в обработку: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, h.view.task(task))
+}
+
+// extractDeadLetterID извлекает ID задачи из пути /dead-letters/{id}/redrive
+func extractDeadLetterID(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 3 && parts[0] == "dead-letters" && parts[2] == "redrive" {
+		return parts[1]
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestTimeoutHeader - заголовок, в котором клиент указывает, сколько готов ждать ответа
+// на синхронную операцию: длительность ("30s", "1m30s") или число секунд ("30", "2.5")
+const RequestTimeoutHeader = "X-Request-Timeout"
+
+// withRequestTimeout возвращает запрос с контекстом, ограниченным временем из X-Request-Timeout,
+// но не больше maxRequestTimeout. Без заголовка или при отключенном ограничении запрос возвращается как есть.
+// Неверное значение заголовка отклоняется с 400, и тогда ok равен false.
+func (h *TaskHandler) withRequestTimeout(w http.ResponseWriter, r *http.Request) (*http.Request, context.CancelFunc, bool) {
+	value := r.Header.Get(RequestTimeoutHeader)
+	if value == "" || h.maxRequestTimeout <= 0 {
+		return r, func() {}, true
+	}
+
+	timeout, err := parseRequestTimeout(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return r, nil, false
+	}
+	if timeout > h.maxRequestTimeout {
+		timeout = h.maxRequestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	return r.WithContext(ctx), cancel, true
+}
+
+// parseRequestTimeout разбирает значение X-Request-Timeout
+func parseRequestTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, parseErr := strconv.ParseFloat(value, 64)
+		if parseErr != nil || seconds > (1<<63-1)/float64(time.Second) {
+			return 0, fmt.Errorf("неверное значение %s: %q", RequestTimeoutHeader, value)
+		}
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("%s должен быть положительным: %q", RequestTimeoutHeader, value)
+	}
+	return timeout, nil
+}
+
+// writeDeadlineExceeded отвечает 504, если операция не уложилась во время из X-Request-Timeout
+func writeDeadlineExceeded(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	http.Error(w, "Операция не завершилась за время, указанное в "+RequestTimeoutHeader, http.StatusGatewayTimeout)
+	return true
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// stubTaskUsecase returns the same task for the list and status requests; other methods are not implemented
+type stubTaskUsecase struct {
+	interfaces.TaskUsecase
+	task *entities.Task
+}
+
+func (u *stubTaskUsecase) ListTasks(ctx context.Context, limit int, cursor string, filter entities.TaskFilter) (*entities.TaskPage, error) {
+	return &entities.TaskPage{Tasks: []*entities.Task{u.task}, Limit: 100}, nil
+}
+
+func (u *stubTaskUsecase) GetTaskStatus(ctx context.Context, id string) (*entities.Task, error) {
+	return u.task, nil
+}
+
+func TestConditionalGet(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target func(task *entities.Task) string
+	}{
+		{"task list", func(task *entities.Task) string { return "/tasks" }},
+		{"task progress", func(task *entities.Task) string { return "/tasks/" + task.ID.String() + "/progress" }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			task := entities.NewTask([]string{"https://example.com/file.bin"}, time.Now())
+			handler := SetupRoutes(NewTaskHandler(&stubTaskUsecase{task: task}, nil))
+			target := tc.target(task)
+			get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodGet, target, nil)
+				if ifNoneMatch != "" {
+					req.Header.Set("If-None-Match", ifNoneMatch)
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				return rec
+			}
+
+			// Execute
+			first := get("")
+			etag := first.Header().Get("ETag")
+			unchanged := get(etag)
+			task.UpdatedAt = task.UpdatedAt.Add(time.Second)
+			task.Status = "completed"
+			changed := get(etag)
+
+			// Assert
+			if first.Code != http.StatusOK || etag == "" {
+				t.Fatalf("Expected status 200 with an ETag, got %d and %q", first.Code, etag)
+			}
+			if unchanged.Code != http.StatusNotModified || unchanged.Body.Len() != 0 {
+				t.Errorf("Expected status 304 without a body for a matching ETag, got %d with %d bytes", unchanged.Code, unchanged.Body.Len())
+			}
+			if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+				t.Errorf("Expected status 200 with a new ETag after the task changed, got %d and %q", changed.Code, changed.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	testCases := []struct {
+		header   string
+		etag     string
+		expected bool
+	}{
+		{"", `"a"`, false},
+		{`"a"`, `"a"`, true},
+		{`"b", "a"`, `"a"`, true},
+		{`W/"a"`, `"a"`, true},
+		{`"a"`, `W/"a"`, true},
+		{"*", `"a"`, true},
+		{`"b"`, `"a"`, false},
+	}
+
+	for _, tc := range testCases {
+		// Execute
+		got := etagMatches(tc.header, tc.etag)
+
+		// Assert
+		if got != tc.expected {
+			t.Errorf("Expected etagMatches(%q, %q) to be %v, got %v", tc.header, tc.etag, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// WithEventStream включает GET /events - поток событий жизненного цикла задач в формате Server-Sent Events
+func WithEventStream(events interfaces.EventSubscriber) TaskHandlerOption {
+	return func(h *TaskHandler) {
+		h.events = events
+	}
+}
+
+// StreamEvents обрабатывает GET /events?task_id=: передает события задач по мере публикации,
+// каждое событие - JSON в поле data. Параметр task_id оставляет только события одной задачи.
+func (h *TaskHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.events == nil {
+		http.Error(w, "Поток событий недоступен", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Потоковая передача не поддерживается", http.StatusInternalServerError)
+		return
+	}
+
+	taskID := r.URL.Query().Get("task_id")
+	events, unsubscribe := h.events.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if taskID != "" && event.TaskID.String() != taskID {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent записывает событие задачи в поток Server-Sent Events
+func writeEvent(w http.ResponseWriter, event entities.TaskEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// channelSubscriber hands out a single prepared event channel
+type channelSubscriber struct {
+	events chan entities.TaskEvent
+}
+
+func (s *channelSubscriber) Subscribe(types ...entities.EventType) (<-chan entities.TaskEvent, func()) {
+	return s.events, func() {}
+}
+
+func TestStreamEventsFiltersByTask(t *testing.T) {
+	// Setup
+	watched := entities.NewTask([]string{"https://example.com/a.jpg"}, time.Now())
+	other := entities.NewTask([]string{"https://example.com/b.jpg"}, time.Now())
+	subscriber := &channelSubscriber{events: make(chan entities.TaskEvent, 2)}
+	subscriber.events <- entities.NewTaskEvent(entities.EventTaskCreated, other, time.Now())
+	subscriber.events <- entities.NewTaskEvent(entities.EventTaskCompleted, watched, time.Now())
+	close(subscriber.events)
+	handler := &TaskHandler{events: subscriber}
+	recorder := httptest.NewRecorder()
+
+	// Execute: the stream ends when the event channel is closed
+	handler.StreamEvents(recorder, httptest.NewRequest(http.MethodGet, "/events?task_id="+watched.ID.String(), nil))
+
+	// Assert
+	if recorder.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected event stream content type, got %q", recorder.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "data: ") {
+		t.Fatalf("Expected a single event of the watched task, got %q", recorder.Body.String())
+	}
+	var event entities.TaskEvent
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[0], "data: ")), &event); err != nil {
+		t.Fatalf("Expected JSON event, got %v", err)
+	}
+	if event.TaskID != watched.ID || event.Type != entities.EventTaskCompleted {
+		t.Errorf("Expected %s of task %s, got %s of task %s", entities.EventTaskCompleted, watched.ID, event.Type, event.TaskID)
+	}
+}
+
+func TestStreamEventsUnavailable(t *testing.T) {
+	// Setup
+	handler := &TaskHandler{}
+	recorder := httptest.NewRecorder()
+
+	// Execute
+	handler.StreamEvents(recorder, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	// Assert
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, recorder.Code)
+	}
+}
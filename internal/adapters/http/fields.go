@@ -0,0 +1,88 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// parseFields разбирает параметр fields - список полей ответа через запятую.
+// Возвращает nil, если параметр не задан и ответ нужно вернуть целиком.
+func parseFields(r *http.Request) []string {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// project оставляет в объекте только указанные поля; неизвестные поля пропускаются
+func project(object map[string]interface{}, fields []string) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := object[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
+
+// projectTask возвращает задачу для ответа: без fields - задачу целиком, иначе только запрошенные поля.
+// Помимо полей задачи можно запросить вычисляемое поле progress.
+func (v view) projectTask(task *entities.Task, fields []string) (interface{}, error) {
+	response := v.task(task)
+	if fields == nil {
+		return response, nil
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	// UseNumber сохраняет точность размеров файлов при повторном кодировании
+	var object map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	object["progress"] = task.GetProgress()
+
+	return project(object, fields), nil
+}
+
+// projectPage применяет projectTask к каждой задаче страницы, сохраняя параметры пагинации
+func (v view) projectPage(page *entities.TaskPage, fields []string) (interface{}, error) {
+	if fields == nil {
+		return v.page(page), nil
+	}
+
+	tasks := make([]interface{}, 0, len(page.Tasks))
+	for _, task := range page.Tasks {
+		projected, err := v.projectTask(task, fields)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, projected)
+	}
+
+	response := map[string]interface{}{
+		"tasks": tasks,
+		"limit": page.Limit,
+	}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+	return response, nil
+}
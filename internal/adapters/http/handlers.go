@@ -1,11 +1,17 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"file-downloader/internal/entities"
 	"file-downloader/internal/interfaces"
 )
 
@@ -13,19 +19,91 @@ import (
 type TaskHandler struct {
 	taskUsecase     interfaces.TaskUsecase
 	downloadUsecase interfaces.DownloadUsecase
+
+	maxRequestTimeout time.Duration              // верхняя граница X-Request-Timeout (0 - заголовок не учитывается)
+	events            interfaces.EventSubscriber // источник потока событий /events (nil - поток недоступен)
+	view              view                       // представление задач в ответах
+}
+
+// TaskHandlerOption настраивает обработчик задач
+type TaskHandlerOption func(*TaskHandler)
+
+// WithMaxRequestTimeout включает заголовок X-Request-Timeout для синхронных операций,
+// ограничивая запрошенное клиентом время значением max
+func WithMaxRequestTimeout(max time.Duration) TaskHandlerOption {
+	return func(h *TaskHandler) {
+		h.maxRequestTimeout = max
+	}
 }
 
 // NewTaskHandler создает новый обработчик задач
-func NewTaskHandler(taskUsecase interfaces.TaskUsecase, downloadUsecase interfaces.DownloadUsecase) interfaces.HTTPHandler {
-	return &TaskHandler{
+func NewTaskHandler(taskUsecase interfaces.TaskUsecase, downloadUsecase interfaces.DownloadUsecase, opts ...TaskHandlerOption) interfaces.HTTPHandler {
+	h := &TaskHandler{
 		taskUsecase:     taskUsecase,
 		downloadUsecase: downloadUsecase,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // CreateTaskRequest представляет тело запроса для создания задачи
 type CreateTaskRequest struct {
-	URLs []string `json:"urls"`
+	URLs    []string            `json:"urls"`
+	Files   []entities.FileSpec `json:"files,omitempty"`
+	Headers map[string]string   `json:"headers,omitempty"`
+	StartAt *time.Time          `json:"start_at,omitempty"`
+	TTL     entities.Duration   `json:"ttl,omitempty"` // срок хранения задачи, например "72h"
+
+	MaxConcurrency   int               `json:"max_concurrency,omitempty"`
+	FailureThreshold string            `json:"failure_threshold,omitempty"`
+	Compress         *bool             `json:"compress,omitempty"`
+	PreservePath     bool              `json:"preserve_path,omitempty"`
+	OutputDir        string            `json:"output_dir,omitempty"`
+	Extract          bool              `json:"extract,omitempty"` // распаковать скачанные архивы zip и tar.gz
+	DiskQuota        int64             `json:"disk_quota,omitempty"`
+	FreshnessWindow  entities.Duration `json:"freshness_window,omitempty"` // не скачивать повторно файлы, скачанные в пределах окна
+	CallbackURL      string            `json:"callback_url,omitempty"`
+	ManifestURL      string            `json:"manifest_url,omitempty"`
+	Preset           string            `json:"preset,omitempty"` // пресет, параметры которого используются для незаданных полей
+
+	RetryPolicy  *entities.TaskRetryPolicy        `json:"retry_policy,omitempty"`
+	SlowDownload *entities.TaskSlowDownloadPolicy `json:"slow_download,omitempty"`
+
+	DownloadWindow *entities.DownloadWindow `json:"download_window,omitempty"` // время суток, в которое разрешено скачивание
+}
+
+// spec преобразует запрос в описание задачи: сначала URL из urls, затем записи из files
+func (req CreateTaskRequest) spec() entities.TaskSpec {
+	spec := entities.NewTaskSpec(req.URLs)
+	spec.Files = append(spec.Files, req.Files...)
+	spec.Headers = req.Headers
+	spec.StartAt = req.StartAt
+	spec.TTL = time.Duration(req.TTL)
+	spec.MaxConcurrency = req.MaxConcurrency
+	spec.FailureThreshold = req.FailureThreshold
+	spec.Compress = req.Compress
+	spec.PreservePath = req.PreservePath
+	spec.OutputDir = req.OutputDir
+	spec.Extract = req.Extract
+	spec.DiskQuota = req.DiskQuota
+	spec.FreshnessWindow = time.Duration(req.FreshnessWindow)
+	spec.CallbackURL = req.CallbackURL
+	spec.RetryPolicy = req.RetryPolicy
+	spec.SlowDownload = req.SlowDownload
+	spec.DownloadWindow = req.DownloadWindow
+	spec.ManifestURL = req.ManifestURL
+	spec.Preset = req.Preset
+	return spec
+}
+
+// fileStatus представляет файл в ответе статуса задачи с вычисленной длительностью
+type fileStatus struct {
+	fileResponse
+	DurationMs int64 `json:"duration_ms"`
 }
 
 // CreateTask обрабатывает POST /tasks
@@ -41,23 +119,57 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.URLs) == 0 {
+	if len(req.URLs) == 0 && len(req.Files) == 0 && req.ManifestURL == "" {
 		http.Error(w, "URL обязательны", http.StatusBadRequest)
 		return
 	}
 
-	task, err := h.taskUsecase.CreateTask(r.Context(), req.URLs)
+	spec := req.spec()
+	spec.RequestID = RequestIDFromContext(r.Context())
+
+	task, err := h.taskUsecase.CreateTaskFromSpec(r.Context(), spec)
 	if err != nil {
+		// Задача с тем же набором файлов уже создана (ID по содержимому): запрос идемпотентен
+		if errors.Is(err, entities.ErrTaskExists) && task != nil {
+			writeJSON(w, r, http.StatusOK, h.view.task(task))
+			return
+		}
+		if errors.Is(err, entities.ErrTaskDeleted) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		var validationErr *entities.ValidationError
+		if errors.As(err, &validationErr) {
+			writeJSON(w, r, http.StatusBadRequest, map[string]interface{}{
+				"error":        "Неверные URL",
+				"invalid_urls": validationErr.Errors,
+			})
+			return
+		}
+		if errors.Is(err, entities.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, entities.ErrTooManyActiveTasks) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		if errors.Is(err, entities.ErrMaintenance) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, entities.ErrManifestUnavailable) {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Не удалось создать задачу: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(task)
+	writeJSON(w, r, http.StatusCreated, h.view.task(task))
 }
 
-// GetTask обрабатывает GET /tasks/{id}
+// GetTask обрабатывает GET /tasks/{id}?fields=
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
@@ -72,7 +184,7 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 
 	task, err := h.taskUsecase.GetTask(r.Context(), id)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, entities.ErrTaskNotFound) {
 			http.Error(w, "Задача не найдена", http.StatusNotFound)
 			return
 		}
@@ -80,28 +192,81 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	response, err := h.view.projectTask(task, parseFields(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось сформировать ответ: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, response)
 }
 
-// GetAllTasks обрабатывает GET /tasks
+// GetAllTasks обрабатывает GET /tasks?limit=&cursor=&include_deleted=&order=&fields=
 func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
 		return
 	}
 
-	tasks, err := h.taskUsecase.GetAllTasks(r.Context())
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Параметр limit должен быть положительным числом", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	var filter entities.TaskFilter
+	if value := r.URL.Query().Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Параметр include_deleted должен быть true или false", http.StatusBadRequest)
+			return
+		}
+		filter.IncludeDeleted = includeDeleted
+	}
+	switch r.URL.Query().Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Newest = true
+	default:
+		http.Error(w, "Параметр order должен быть asc или desc", http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.taskUsecase.ListTasks(r.Context(), limit, r.URL.Query().Get("cursor"), filter)
 	if err != nil {
+		if errors.Is(err, entities.ErrInvalidCursor) {
+			http.Error(w, "Неверный курсор пагинации", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Не удалось получить задачи: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(tasks)
+	response, err := h.view.projectPage(page, parseFields(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось сформировать ответ: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// ETag вычисляется по сформированному ответу, поэтому учитывает фильтры, курсор и набор полей;
+	// опрашивающий клиент получает 304 без тела, пока список не изменился
+	body, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось сформировать ответ: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if notModified(w, r, contentETag(body)) {
+		return
+	}
+
+	writeJSONBody(w, r, http.StatusOK, body)
 }
 
-// GetTaskStatus обрабатывает GET /tasks/{id}/status
+// GetTaskStatus обрабатывает GET /tasks/{id}/status?fields=
 func (h *TaskHandler) GetTaskStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
@@ -116,7 +281,7 @@ func (h *TaskHandler) GetTaskStatus(w http.ResponseWriter, r *http.Request) {
 
 	task, err := h.taskUsecase.GetTaskStatus(r.Context(), id)
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, entities.ErrTaskNotFound) {
 			http.Error(w, "Задача не найдена", http.StatusNotFound)
 			return
 		}
@@ -125,17 +290,594 @@ func (h *TaskHandler) GetTaskStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Возврат только информации о статусе
-	statusResponse := map[string]interface{}{
-		"id":         task.ID,
-		"status":     task.Status,
-		"progress":   task.GetProgress(),
-		"created_at": task.CreatedAt,
-		"updated_at": task.UpdatedAt,
-		"files":      task.Files,
+	now := time.Now()
+	files := make([]fileStatus, len(task.Files))
+	for i := range task.Files {
+		files[i] = fileStatus{
+			fileResponse: h.view.file(task.Files[i]),
+			DurationMs:   task.Files[i].Duration(now).Milliseconds(),
+		}
+	}
+
+	statusResponse := statusSummary(task, now)
+	statusResponse["files"] = files
+	if fields := parseFields(r); fields != nil {
+		statusResponse = project(statusResponse, fields)
+	}
+
+	writeJSON(w, r, http.StatusOK, statusResponse)
+}
+
+// TaskStatusesRequest представляет тело запроса статусов нескольких задач
+type TaskStatusesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// GetTaskStatuses обрабатывает POST /tasks/status?fields= - сводки статусов нескольких задач за один запрос.
+// Ненайденные ID перечисляются в not_found и не приводят к ошибке всего запроса.
+func (h *TaskHandler) GetTaskStatuses(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req TaskStatusesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+
+	statuses, err := h.taskUsecase.GetTaskStatuses(r.Context(), req.IDs)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Не удалось получить статусы задач: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	fields := parseFields(r)
+	tasks := make(map[string]interface{}, len(statuses.Tasks))
+	for id, task := range statuses.Tasks {
+		summary := statusSummary(task, now)
+		if fields != nil {
+			summary = project(summary, fields)
+		}
+		tasks[id] = summary
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"tasks":     tasks,
+		"not_found": statuses.NotFound,
+	})
+}
+
+// statusSummary возвращает сводку статуса задачи без списка файлов
+func statusSummary(task *entities.Task, now time.Time) map[string]interface{} {
+	completedFiles, failedFiles, slowFiles := 0, 0, 0
+	for _, file := range task.Files {
+		switch file.Status {
+		case "completed":
+			completedFiles++
+		case "failed":
+			failedFiles++
+		}
+		if file.Slow {
+			slowFiles++
+		}
+	}
+
+	downloaded, total := task.DownloadedBytes()
+	return map[string]interface{}{
+		"id":               task.ID,
+		"status":           task.Status,
+		"progress":         task.GetProgress(),
+		"partial":          task.IsPartial(),
+		"completed_files":  completedFiles,
+		"failed_files":     failedFiles,
+		"slow_files":       slowFiles,
+		"downloaded_bytes": downloaded,
+		"total_bytes":      total,
+		"retrying":         task.IsRetrying(),
+		"created_at":       task.CreatedAt,
+		"updated_at":       task.UpdatedAt,
+		"start_at":         task.StartAt,
+		"download_window":  task.DownloadWindow,
+		"started_at":       task.StartedAt,
+		"finished_at":      task.FinishedAt,
+		"expires_at":       task.ExpiresAt,
+		"duration_ms":      task.Duration(now).Milliseconds(),
+		"checksum":         task.Checksum,
+		"callback":         task.Callback,
+
+		"waiting_for_capacity_since": task.WaitingForCapacitySince,
+	}
+}
+
+// GetTaskProgress обрабатывает GET /tasks/{id}/progress - облегченный ответ для частого опроса.
+// Ответ снабжается ETag, поэтому повторный запрос с If-None-Match без изменений получает 304 без тела.
+func (h *TaskHandler) GetTaskProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskUsecase.GetTaskStatus(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, entities.ErrTaskNotFound) {
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Не удалось получить статус задачи: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	downloaded, _ := task.DownloadedBytes()
+	etag := fmt.Sprintf(`W/"%d-%s-%d"`, task.UpdatedAt.UnixNano(), task.Status, downloaded)
+	if notModified(w, r, etag) {
+		return
+	}
+
+	var eta interface{}
+	if remaining, ok := task.ETA(time.Now()); ok {
+		eta = int64(remaining.Seconds())
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"id":            task.ID,
+		"status":        task.Status,
+		"progress":      task.GetProgress(),
+		"byte_progress": task.BytePercent(),
+		"eta":           eta,
+	})
+}
+
+// ListTaskFiles обрабатывает GET /tasks/{id}/files?status=&limit=&cursor= (или устаревший offset= вместо cursor=)
+func (h *TaskHandler) ListTaskFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Параметр limit должен быть положительным числом", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	filter := entities.FileFilter{Status: r.URL.Query().Get("status")}
+
+	// Смещение оставлено для старых клиентов: ответ уже содержит next_cursor, которым продолжаются следующие страницы
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Параметр offset должен быть неотрицательным числом", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("cursor") != "" {
+			http.Error(w, "Параметры offset и cursor нельзя передавать одновременно", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = parsed
+	}
+
+	page, err := h.taskUsecase.ListFiles(r.Context(), id, filter, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidCursor) {
+			http.Error(w, "Неверный курсор пагинации", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, entities.ErrTaskNotFound) {
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Не удалось получить файлы задачи: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, h.view.filePage(page))
+}
+
+// GetFileContent обрабатывает GET /tasks/{id}/files/{index}/content. Сжатый на диске файл отдается
+// как есть с Content-Encoding: gzip, если клиент его принимает, иначе распаковывается при отдаче
+func (h *TaskHandler) GetFileContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 {
+		http.Error(w, "Индекс файла обязателен", http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(parts[3])
+	if err != nil {
+		http.Error(w, "Файл не найден", http.StatusNotFound)
+		return
+	}
+
+	r, cancel, ok := h.withRequestTimeout(w, r)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	content, err := h.taskUsecase.OpenFileContent(r.Context(), id, index, !acceptsGzip(r))
+	if err != nil {
+		if writeDeadlineExceeded(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrInvalidFileIndex):
+			http.Error(w, "Файл не найден", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskDeleted):
+			http.Error(w, "Задача удалена", http.StatusConflict)
+		case errors.Is(err, entities.ErrFileNotReady):
+			http.Error(w, "Файл еще не скачан", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось открыть файл: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer content.Content.Close()
+
+	// Начатую отдачу нельзя завершить кодом 504: по истечении срока соединение обрывается
+	if deadline, ok := r.Context().Deadline(); ok {
+		http.NewResponseController(w).SetWriteDeadline(deadline)
+	}
+
+	serveFileContent(w, r, content)
+}
+
+// acceptsGzip возвращает true, если клиент принимает ответ, сжатый gzip (заголовок Accept-Encoding)
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+				continue
+			}
+			weight := strings.ReplaceAll(params, " ", "")
+			return weight != "q=0" && weight != "q=0.0" && weight != "q=0.00" && weight != "q=0.000"
+		}
+	}
+	return false
+}
+
+// mutableTaskFields - поля задачи, которые можно изменить через PATCH /tasks/{id}
+var mutableTaskFields = map[string]bool{"headers": true, "retry_policy": true, "callback_url": true}
+
+// UpdateTaskRequest представляет запрос на изменение параметров задачи; отсутствующие поля не меняются
+type UpdateTaskRequest struct {
+	Headers     *map[string]string        `json:"headers"`
+	RetryPolicy *entities.TaskRetryPolicy `json:"retry_policy"`
+	CallbackURL *string                   `json:"callback_url"`
+}
+
+// UpdateTask обрабатывает PATCH /tasks/{id}
+func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+	var immutable []string
+	for name := range fields {
+		if !mutableTaskFields[name] {
+			immutable = append(immutable, name)
+		}
+	}
+	if len(immutable) > 0 {
+		sort.Strings(immutable)
+		http.Error(w, fmt.Sprintf("Поля нельзя изменить: %s", strings.Join(immutable, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateTaskRequest
+	body, _ := json.Marshal(fields)
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+	// null удаляет значение поля, как в JSON Merge Patch
+	update := entities.TaskUpdate{Headers: req.Headers, RetryPolicy: req.RetryPolicy, CallbackURL: req.CallbackURL}
+	if isNull(fields["headers"]) {
+		update.Headers = &map[string]string{}
+	}
+	if isNull(fields["retry_policy"]) {
+		update.RetryPolicy = &entities.TaskRetryPolicy{}
+	}
+	if isNull(fields["callback_url"]) {
+		update.CallbackURL = new(string)
+	}
+
+	task, err := h.taskUsecase.UpdateTask(r.Context(), id, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrInvalidRequest):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, entities.ErrTaskProcessing):
+			http.Error(w, "Задача находится в обработке", http.StatusConflict)
+		case errors.Is(err, entities.ErrTaskDeleted):
+			http.Error(w, "Задача удалена", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось изменить задачу: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, h.view.task(task))
+}
+
+// isNull возвращает true, если поле запроса передано со значением null
+func isNull(value json.RawMessage) bool {
+	return value != nil && string(bytes.TrimSpace(value)) == "null"
+}
+
+// RetryFile обрабатывает POST /tasks/{id}/files/{index}/retry
+func (h *TaskHandler) RetryFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 {
+		http.Error(w, "Индекс файла обязателен", http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(parts[3])
+	if err != nil {
+		http.Error(w, "Файл не найден", http.StatusNotFound)
+		return
+	}
+
+	task, err := h.taskUsecase.RetryFile(r.Context(), id, index)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrInvalidFileIndex):
+			http.Error(w, "Файл не найден", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskProcessing):
+			http.Error(w, "Задача находится в обработке", http.StatusConflict)
+		case errors.Is(err, entities.ErrTaskDeleted):
+			http.Error(w, "Задача удалена", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось повторить скачивание файла: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, h.view.task(task))
+}
+
+// RetryFailedFiles обрабатывает POST /tasks/{id}/retry-failed-only
+func (h *TaskHandler) RetryFailedFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	task, requeued, err := h.taskUsecase.RetryFailedFiles(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskProcessing):
+			http.Error(w, "Задача находится в обработке", http.StatusConflict)
+		case errors.Is(err, entities.ErrTaskDeleted):
+			http.Error(w, "Задача удалена", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось повторить скачивание файлов: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"requeued": requeued,
+		"task":     h.view.task(task),
+	})
+}
+
+// VerifyTask обрабатывает POST /tasks/{id}/verify?requeue=
+func (h *TaskHandler) VerifyTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	requeue := false
+	if value := r.URL.Query().Get("requeue"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Параметр requeue должен быть true или false", http.StatusBadRequest)
+			return
+		}
+		requeue = parsed
+	}
+
+	r, cancel, ok := h.withRequestTimeout(w, r)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	report, err := h.taskUsecase.VerifyTask(r.Context(), id, requeue)
+	if err != nil {
+		if writeDeadlineExceeded(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskProcessing):
+			http.Error(w, "Задача находится в обработке", http.StatusConflict)
+		case errors.Is(err, entities.ErrTaskDeleted):
+			http.Error(w, "Задача удалена", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось проверить файлы задачи: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+// DeleteTask обрабатывает DELETE /tasks/{id}: задача перемещается в корзину
+func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskUsecase.DeleteTask(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskProcessing):
+			http.Error(w, "Задача находится в обработке", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось удалить задачу: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, h.view.task(task))
+}
+
+// RestoreTask обрабатывает POST /tasks/{id}/restore
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	task, err := h.taskUsecase.RestoreTask(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskNotDeleted):
+			http.Error(w, "Задача не находится в корзине", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось восстановить задачу: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, h.view.task(task))
+}
+
+// ListFailures обрабатывает GET /failures?since=&limit=
+func (h *TaskHandler) ListFailures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			http.Error(w, "Параметр since должен быть положительной длительностью, например 1h", http.StatusBadRequest)
+			return
+		}
+		since = time.Now().Add(-window)
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Параметр limit должен быть положительным числом", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	failures, err := h.taskUsecase.ListFailures(r.Context(), since, limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось получить ошибки скачивания: %v", err), http.StatusInternalServerError)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(statusResponse)
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"failures": failures,
+	})
 }
 
 // extractTaskID извлекает ID задачи из пути URL
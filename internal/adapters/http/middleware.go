@@ -0,0 +1,136 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader - заголовок с ID запроса для сквозной трассировки
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину принимаемого от клиента ID запроса
+const maxRequestIDLength = 128
+
+// requestIDKey - ключ ID запроса в контексте
+type requestIDKey struct{}
+
+// RequireAPIKey пропускает запрос, только если он содержит верный API-ключ
+// в заголовке X-API-Key или Authorization: Bearer. Если ключ не задан, доступ закрыт.
+func RequireAPIKey(apiKey string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiKey == "" {
+			http.Error(w, "Административный API отключен: API-ключ не настроен", http.StatusForbidden)
+			return
+		}
+
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			http.Error(w, "Неверный API-ключ", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitInFlight ограничивает число одновременно обрабатываемых изменяющих запросов (POST, PUT, PATCH, DELETE)
+// и дорогих запросов чтения (см. expensiveRead) значением limit: запрос сверх предела не ждет освобождения места,
+// а сразу отклоняется с 503 и Retry-After. Остальное чтение и административные маршруты /admin/ не ограничиваются,
+// чтобы перегрузку можно было наблюдать и устранять. limit <= 0 отключает ограничение.
+func LimitInFlight(limit int, retryAfter time.Duration, next http.Handler) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+
+	slots := make(chan struct{}, limit)
+	seconds := strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds()))))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !limitedRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Retry-After", seconds)
+			http.Error(w, "Сервер перегружен: слишком много одновременных запросов, повторите позже", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// limitedRequest возвращает true для изменяющих и дорогих запросов, на которые действует LimitInFlight
+func limitedRequest(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/admin/") {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return expensiveRead(r.URL.Path)
+	case http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// expensiveRead возвращает true для запросов чтения, которые обходят диск или все задачи: дерево файлов задачи
+// (/tasks/{id}/tree), отдача содержимого файла (/tasks/{id}/files/{index}/content) и сводка ошибок (/failures)
+func expensiveRead(path string) bool {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) == 1:
+		return parts[0] == "failures"
+	case len(parts) == 3 && parts[0] == "tasks":
+		return parts[2] == "tree"
+	case len(parts) == 5 && parts[0] == "tasks":
+		return parts[2] == "files" && parts[4] == "content"
+	}
+	return false
+}
+
+// RequestID присваивает запросу ID из заголовка X-Request-ID или генерирует новый,
+// сохраняет его в контексте запроса и возвращает клиенту в том же заголовке
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext возвращает ID текущего запроса или пустую строку
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID проверяет, что ID от клиента не пустой, ограничен по длине
+// и состоит только из безопасных для журнала символов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
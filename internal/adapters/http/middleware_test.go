@@ -0,0 +1,155 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name      string
+		header    string
+		generated bool
+	}{
+		{"client ID is propagated", "req-42_a.b:c", false},
+		{"missing ID is generated", "", true},
+		{"ID with unsafe characters is replaced", "req 42\n", true},
+		{"too long ID is replaced", strings.Repeat("a", maxRequestIDLength+1), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			var fromContext string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext = RequestIDFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			// Execute
+			handler.ServeHTTP(rec, req)
+
+			// Assert
+			got := rec.Header().Get(RequestIDHeader)
+			if got != fromContext {
+				t.Errorf("Expected response header to match the context ID %q, got %q", fromContext, got)
+			}
+			if tc.generated {
+				if _, err := uuid.Parse(got); err != nil {
+					t.Errorf("Expected a generated UUID, got %q", got)
+				}
+			} else if got != tc.header {
+				t.Errorf("Expected request ID %q, got %q", tc.header, got)
+			}
+		})
+	}
+}
+
+func TestLimitInFlightRejectsWritesOverLimit(t *testing.T) {
+	// Setup: the only slot is held by a request blocked in the handler
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := LimitInFlight(1, 1500*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tasks" && r.Method == http.MethodPost && r.Header.Get("X-Block") != "" {
+			close(entered)
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+		req.Header.Set("X-Block", "1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-entered
+
+	testCases := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{"write over the limit", http.MethodPost, "/tasks", http.StatusServiceUnavailable},
+		{"delete over the limit", http.MethodDelete, "/tasks/1", http.StatusServiceUnavailable},
+		{"read is not limited", http.MethodGet, "/tasks", http.StatusOK},
+		{"tree over the limit", http.MethodGet, "/tasks/1/tree", http.StatusServiceUnavailable},
+		{"file content over the limit", http.MethodGet, "/tasks/1/files/0/content", http.StatusServiceUnavailable},
+		{"failures over the limit", http.MethodGet, "/failures", http.StatusServiceUnavailable},
+		{"admin is not limited", http.MethodPost, "/admin/maintenance", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+			// Assert
+			if rec.Code != tc.expected {
+				t.Errorf("Expected status %d, got %d", tc.expected, rec.Code)
+			}
+			if tc.expected == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") != "2" {
+				t.Errorf("Expected Retry-After 2, got %q", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+
+	// Execute: the slot is released after the blocked request completes
+	close(release)
+	<-done
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tasks", nil))
+
+	// Assert
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected write to pass once the slot is free, got %d", rec.Code)
+	}
+}
+
+func TestSetupRoutesRejectsOverLimitWithRequestID(t *testing.T) {
+	// Setup: the only slot is held by a write blocked in the handler
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	blocking := func(mux *router) {
+		mux.HandleFunc("/blocking", func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+		})
+	}
+	handler := SetupRoutes(NewTaskHandler(nil, nil), blocking, WithInFlightLimit(1, time.Second))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/blocking", nil))
+	}()
+	<-entered
+	defer func() {
+		close(release)
+		<-done
+	}()
+
+	// Execute
+	req := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	req.Header.Set(RequestIDHeader, "req-503")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	// Assert
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", rec.Code)
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != "req-503" {
+		t.Errorf("Expected request ID req-503 in the rejected response, got %q", got)
+	}
+}
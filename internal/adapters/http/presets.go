@@ -0,0 +1,115 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// SavePreset обрабатывает POST /presets: создает пресет или заменяет пресет с тем же именем
+func (h *TaskHandler) SavePreset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req entities.Preset
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Неверный JSON", http.StatusBadRequest)
+		return
+	}
+
+	preset, err := h.taskUsecase.SavePreset(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Не удалось сохранить пресет: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, preset)
+}
+
+// ListPresets обрабатывает GET /presets
+func (h *TaskHandler) ListPresets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	presets, err := h.taskUsecase.ListPresets(r.Context())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Не удалось получить пресеты: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"presets": presets,
+	})
+}
+
+// GetPreset обрабатывает GET /presets/{name}
+func (h *TaskHandler) GetPreset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := extractPresetName(r.URL.Path)
+	if name == "" {
+		http.Error(w, "Имя пресета обязательно", http.StatusBadRequest)
+		return
+	}
+
+	preset, err := h.taskUsecase.GetPreset(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, entities.ErrPresetNotFound) {
+			http.Error(w, "Пресет не найден", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Не удалось получить пресет: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, preset)
+}
+
+// DeletePreset обрабатывает DELETE /presets/{name}
+func (h *TaskHandler) DeletePreset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := extractPresetName(r.URL.Path)
+	if name == "" {
+		http.Error(w, "Имя пресета обязательно", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.taskUsecase.DeletePreset(r.Context(), name); err != nil {
+		if errors.Is(err, entities.ErrPresetNotFound) {
+			http.Error(w, "Пресет не найден", http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Не удалось удалить пресет: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// extractPresetName извлекает имя пресета из пути /presets/{name}
+func extractPresetName(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 2 && parts[0] == "presets" {
+		return parts[1]
+	}
+	return ""
+}
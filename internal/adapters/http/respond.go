@@ -0,0 +1,36 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"file-downloader/internal/entities"
+)
+
+// writeJSON отправляет value в формате JSON со статусом status. Ответ кодируется целиком до отправки
+// заголовков, поэтому ошибка кодирования возвращает клиенту 500, а не обрезанный ответ с успешным статусом.
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Не удалось закодировать ответ на %s: %v", requestLogID(r), err)
+		http.Error(w, "Не удалось сформировать ответ", http.StatusInternalServerError)
+		return
+	}
+	writeJSONBody(w, r, status, body)
+}
+
+// writeJSONBody отправляет уже закодированный JSON. Заголовки к моменту записи тела отправлены,
+// поэтому ошибка записи (например, отключение клиента) только пишется в журнал.
+func writeJSONBody(w http.ResponseWriter, r *http.Request, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		log.Printf("Не удалось отправить ответ на %s: %v", requestLogID(r), err)
+	}
+}
+
+// requestLogID возвращает метод и путь запроса с его ID для журнала
+func requestLogID(r *http.Request) string {
+	return entities.LogID(r.Method+" "+r.URL.Path, RequestIDFromContext(r.Context()))
+}
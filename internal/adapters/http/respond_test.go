@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// brokenWriter records the status and fails every body write, like a connection closed by the client
+type brokenWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *brokenWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *brokenWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+// captureLog redirects the standard logger into a buffer for the duration of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestWriteJSONRejectsUnencodableValue(t *testing.T) {
+	// Setup
+	logs := captureLog(t)
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	recorder := httptest.NewRecorder()
+
+	// Execute
+	writeJSON(recorder, req, http.StatusOK, map[string]interface{}{"value": make(chan int)})
+
+	// Assert
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", recorder.Code)
+	}
+	if strings.Contains(recorder.Header().Get("Content-Type"), "json") {
+		t.Errorf("Expected no JSON content type for the error, got %q", recorder.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(logs.String(), "GET /stats") {
+		t.Errorf("Expected encoding failure to be logged with the request, got %q", logs.String())
+	}
+}
+
+func TestWriteJSONLogsWriteFailure(t *testing.T) {
+	// Setup
+	logs := captureLog(t)
+	var req *http.Request
+	RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+	})).ServeHTTP(httptest.NewRecorder(), withRequestID(httptest.NewRequest(http.MethodGet, "/tasks", nil), "req-42"))
+	writer := &brokenWriter{header: http.Header{}}
+
+	// Execute
+	writeJSON(writer, req, http.StatusCreated, map[string]string{"status": "ok"})
+
+	// Assert
+	if writer.status != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", writer.status)
+	}
+	if output := logs.String(); !strings.Contains(output, "GET /tasks [request_id=req-42]") || !strings.Contains(output, "broken pipe") {
+		t.Errorf("Expected write failure to be logged with the request ID, got %q", output)
+	}
+}
+
+// withRequestID sets the X-Request-ID header on the request
+func withRequestID(r *http.Request, id string) *http.Request {
+	r.Header.Set(RequestIDHeader, id)
+	return r
+}
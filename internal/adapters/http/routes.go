@@ -2,14 +2,103 @@ package http
 
 import (
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"file-downloader/internal/interfaces"
 )
 
+// RouteOption настраивает дополнительные маршруты и middleware сервера
+type RouteOption func(mux *router)
+
+// router - маршруты сервера и параметры middleware, задаваемые опциями
+type router struct {
+	*http.ServeMux
+	inFlightLimit      int           // одновременно обрабатываемые ограничиваемые запросы (0 - без ограничения)
+	inFlightRetryAfter time.Duration // Retry-After ответа 503 при превышении inFlightLimit
+}
+
+// WithInFlightLimit ограничивает одновременно обрабатываемые изменяющие и дорогие запросы (см. LimitInFlight).
+// Ограничение действует внутри RequestID, поэтому ответ 503 тоже содержит X-Request-ID.
+func WithInFlightLimit(limit int, retryAfter time.Duration) RouteOption {
+	return func(mux *router) {
+		mux.inFlightLimit = limit
+		mux.inFlightRetryAfter = retryAfter
+	}
+}
+
+// WithMetricsHandler подключает обработчик метрик на /metrics
+func WithMetricsHandler(metricsHandler http.Handler) RouteOption {
+	return func(mux *router) {
+		mux.Handle("/metrics", metricsHandler)
+	}
+}
+
+// WithAdminHandler подключает статистику на /stats и административные маршруты, защищенные API-ключом
+func WithAdminHandler(admin *AdminHandler, apiKey string) RouteOption {
+	return func(mux *router) {
+		mux.HandleFunc("/stats", admin.Stats)
+		mux.Handle("/admin/workers", RequireAPIKey(apiKey, http.HandlerFunc(admin.ResizeWorkers)))
+		mux.Handle("/admin/logs/stream", RequireAPIKey(apiKey, http.HandlerFunc(admin.StreamLogs)))
+		mux.Handle("/admin/maintenance", RequireAPIKey(apiKey, http.HandlerFunc(admin.Maintenance)))
+		mux.Handle("/admin/compact", RequireAPIKey(apiKey, http.HandlerFunc(admin.Compact)))
+	}
+}
+
+// WithReadinessChecks подключает /health/ready: 200, если все проверки проходят, иначе 503
+// с описанием ошибок по именам проверок
+func WithReadinessChecks(checks map[string]interfaces.HealthChecker) RouteOption {
+	return func(mux *router) {
+		names := make([]string, 0, len(checks))
+		for name := range checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		mux.HandleFunc("/health/ready", func(w http.ResponseWriter, r *http.Request) {
+			status := http.StatusOK
+			results := make(map[string]string, len(names))
+			for _, name := range names {
+				results[name] = "ok"
+				if err := checks[name].Health(); err != nil {
+					results[name] = err.Error()
+					status = http.StatusServiceUnavailable
+				}
+			}
+
+			writeJSON(w, r, status, map[string]interface{}{
+				"ready":  status == http.StatusOK,
+				"checks": results,
+			})
+		})
+	}
+}
+
+// BuildInfo описывает сборку сервиса для /version
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// WithBuildInfo подключает /version, возвращающий сведения о сборке
+func WithBuildInfo(info BuildInfo) RouteOption {
+	return func(mux *router) {
+		mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+				return
+			}
+			writeJSON(w, r, http.StatusOK, info)
+		})
+	}
+}
+
 // SetupRoutes настраивает HTTP маршруты
-func SetupRoutes(handler interfaces.HTTPHandler) http.Handler {
-	mux := http.NewServeMux()
+func SetupRoutes(handler interfaces.HTTPHandler, opts ...RouteOption) http.Handler {
+	mux := &router{ServeMux: http.NewServeMux()}
 
 	// Маршруты задач
 	mux.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {
@@ -25,11 +114,79 @@ func SetupRoutes(handler interfaces.HTTPHandler) http.Handler {
 
 	// Маршрут для конкретных задач и их статуса
 	mux.HandleFunc("/tasks/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+		// Статусы нескольких задач: POST /tasks/status
+		if len(parts) == 2 && parts[1] == "status" {
+			handler.GetTaskStatuses(w, r)
+			return
+		}
+
+		// Повтор скачивания отдельного файла: /tasks/{id}/files/{index}/retry
+		if len(parts) == 5 && parts[2] == "files" && parts[4] == "retry" {
+			handler.RetryFile(w, r)
+			return
+		}
+
+		// Повтор только файлов с ошибкой: /tasks/{id}/retry-failed-only
+		if len(parts) == 3 && parts[2] == "retry-failed-only" {
+			handler.RetryFailedFiles(w, r)
+			return
+		}
+
+		// Проверка скачанных файлов на диске: /tasks/{id}/verify
+		if len(parts) == 3 && parts[2] == "verify" {
+			handler.VerifyTask(w, r)
+			return
+		}
+
+		// Восстановление задачи из корзины: /tasks/{id}/restore
+		if len(parts) == 3 && parts[2] == "restore" {
+			handler.RestoreTask(w, r)
+			return
+		}
+
+		// Удаление задачи в корзину: DELETE /tasks/{id}
+		if r.Method == http.MethodDelete && len(parts) == 2 {
+			handler.DeleteTask(w, r)
+			return
+		}
+
+		// Изменение параметров задачи: PATCH /tasks/{id}
+		if r.Method == http.MethodPatch && len(parts) == 2 {
+			handler.UpdateTask(w, r)
+			return
+		}
+
 		if r.Method != http.MethodGet {
 			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
 			return
 		}
 
+		// Облегченный прогресс для частого опроса: /tasks/{id}/progress
+		if len(parts) == 3 && parts[2] == "progress" {
+			handler.GetTaskProgress(w, r)
+			return
+		}
+
+		// Содержимое скачанного файла: /tasks/{id}/files/{index}/content
+		if len(parts) == 5 && parts[2] == "files" && parts[4] == "content" {
+			handler.GetFileContent(w, r)
+			return
+		}
+
+		// Дерево директорий и файлов результата задачи на диске: /tasks/{id}/tree
+		if len(parts) == 3 && parts[2] == "tree" {
+			handler.GetTaskTree(w, r)
+			return
+		}
+
+		// Список файлов задачи с фильтрацией и пагинацией: /tasks/{id}/files
+		if len(parts) == 3 && parts[2] == "files" {
+			handler.ListTaskFiles(w, r)
+			return
+		}
+
 		// Проверяем, является ли это запросом статуса
 		if strings.HasSuffix(r.URL.Path, "/status") {
 			handler.GetTaskStatus(w, r)
@@ -40,11 +197,47 @@ func SetupRoutes(handler interfaces.HTTPHandler) http.Handler {
 		handler.GetTask(w, r)
 	})
 
+	// Пресеты параметров задач: /presets и /presets/{name}
+	mux.HandleFunc("/presets", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handler.SavePreset(w, r)
+		case http.MethodGet:
+			handler.ListPresets(w, r)
+		default:
+			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/presets/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetPreset(w, r)
+		case http.MethodDelete:
+			handler.DeletePreset(w, r)
+		default:
+			http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Окончательно неудачные задачи: /dead-letters и /dead-letters/{id}/redrive
+	mux.HandleFunc("/dead-letters", handler.ListDeadLetters)
+	mux.HandleFunc("/dead-letters/", handler.RedriveDeadLetter)
+
+	// Сводный список файлов, которые не удалось скачать
+	mux.HandleFunc("/failures", handler.ListFailures)
+
+	// Поток событий задач
+	mux.HandleFunc("/events", handler.StreamEvents)
+
 	// Проверка здоровья
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
-	return mux
+	for _, opt := range opts {
+		opt(mux)
+	}
+
+	return RequestID(LimitInFlight(mux.inFlightLimit, mux.inFlightRetryAfter, mux.ServeMux))
 }
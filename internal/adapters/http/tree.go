@@ -0,0 +1,47 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"file-downloader/internal/entities"
+)
+
+// GetTaskTree обрабатывает GET /tasks/{id}/tree: дерево директорий и файлов результата задачи на диске
+func (h *TaskHandler) GetTaskTree(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := h.extractTaskID(r.URL.Path)
+	if id == "" {
+		http.Error(w, "ID задачи обязателен", http.StatusBadRequest)
+		return
+	}
+
+	r, cancel, ok := h.withRequestTimeout(w, r)
+	if !ok {
+		return
+	}
+	defer cancel()
+
+	tree, err := h.taskUsecase.TaskTree(r.Context(), id)
+	if err != nil {
+		if writeDeadlineExceeded(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, entities.ErrTaskNotFound):
+			http.Error(w, "Задача не найдена", http.StatusNotFound)
+		case errors.Is(err, entities.ErrTaskDeleted):
+			http.Error(w, "Задача удалена", http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Не удалось получить дерево файлов задачи: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, tree)
+}
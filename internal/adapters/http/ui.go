@@ -0,0 +1,30 @@
+package http
+
+import (
+	"embed"
+	"net/http"
+)
+
+// uiFiles содержит страницу веб-интерфейса, встроенную в бинарный файл
+//
+//go:embed ui/index.html
+var uiFiles embed.FS
+
+// WithWebUI подключает на / страницу веб-интерфейса: список задач с прогрессом и форму создания задачи.
+// Страница работает через тот же публичный API, что и остальные клиенты.
+func WithWebUI() RouteOption {
+	return func(mux *router) {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			// Маршрут / совпадает со всеми путями без собственного обработчика: им отвечаем 404
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, "Метод не разрешен", http.StatusMethodNotAllowed)
+				return
+			}
+			http.ServeFileFS(w, r, uiFiles, "ui/index.html")
+		})
+	}
+}
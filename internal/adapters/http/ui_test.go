@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebUIServesEmbeddedPage(t *testing.T) {
+	// Setup
+	handler := &router{ServeMux: http.NewServeMux()}
+	WithWebUI()(handler)
+
+	testCases := []struct {
+		path   string
+		status int
+	}{
+		{"/", http.StatusOK},
+		{"/missing", http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			// Execute
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			// Assert
+			if recorder.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d", tc.status, recorder.Code)
+			}
+			if tc.status == http.StatusOK {
+				if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/html") {
+					t.Errorf("Expected HTML content type, got %q", recorder.Header().Get("Content-Type"))
+				}
+				if !strings.Contains(recorder.Body.String(), `fetch(path, options)`) {
+					t.Error("Expected the embedded page to be served")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"strconv"
+
+	"file-downloader/internal/entities"
+)
+
+// view формирует ответы API из задач и файлов, не изменяя их: встроенное содержимое файлов хранится в задаче для
+// повторного скачивания, но клиент его уже знает, а в каждом ответе занимало бы до MAX_INLINE_CONTENT_SIZE
+type view struct {
+	sizeStrings bool // добавлять к файлам размер строкой size_str (см. WithSizeStrings)
+}
+
+// WithSizeStrings добавляет к файлам в ответах API поле size_str - размер строкой. Числа больше 2^53 клиенты
+// на JavaScript читают с потерей точности, поэтому size_str позволяет им получить точный размер очень больших файлов.
+func WithSizeStrings(enabled bool) TaskHandlerOption {
+	return func(h *TaskHandler) {
+		h.view.sizeStrings = enabled
+	}
+}
+
+// taskResponse - задача в ответе API
+type taskResponse struct {
+	*entities.Task
+	Files []fileResponse `json:"files"`
+}
+
+// fileResponse - файл в ответе API
+type fileResponse struct {
+	entities.File
+	SizeStr string `json:"size_str,omitempty"`
+}
+
+// taskFileResponse - файл задачи с индексом в ответе API
+type taskFileResponse struct {
+	Index int `json:"index"`
+	fileResponse
+}
+
+// pageResponse - страница списка задач в ответе API
+type pageResponse struct {
+	*entities.TaskPage
+	Tasks []*taskResponse `json:"tasks"`
+}
+
+// filePageResponse - страница списка файлов задачи в ответе API
+type filePageResponse struct {
+	*entities.FilePage
+	Files []taskFileResponse `json:"files"`
+}
+
+// deadLetterResponse - неудачная задача в ответе API
+type deadLetterResponse struct {
+	*entities.DeadLetter
+	Task *taskResponse `json:"task"`
+}
+
+// task возвращает задачу для ответа API
+func (v view) task(task *entities.Task) *taskResponse {
+	if task == nil {
+		return nil
+	}
+	response := &taskResponse{Task: task}
+	if task.Files != nil {
+		response.Files = make([]fileResponse, len(task.Files))
+		for i, file := range task.Files {
+			response.Files[i] = v.file(file)
+		}
+	}
+	return response
+}
+
+// file возвращает файл для ответа API без встроенного содержимого
+func (v view) file(file entities.File) fileResponse {
+	file.Content = ""
+	response := fileResponse{File: file}
+	if v.sizeStrings && file.Size != 0 {
+		response.SizeStr = strconv.FormatInt(file.Size, 10)
+	}
+	return response
+}
+
+// page применяет task к задачам страницы списка
+func (v view) page(page *entities.TaskPage) *pageResponse {
+	response := &pageResponse{TaskPage: page, Tasks: make([]*taskResponse, len(page.Tasks))}
+	for i, task := range page.Tasks {
+		response.Tasks[i] = v.task(task)
+	}
+	return response
+}
+
+// filePage применяет file к файлам страницы списка файлов задачи
+func (v view) filePage(page *entities.FilePage) *filePageResponse {
+	response := &filePageResponse{FilePage: page, Files: make([]taskFileResponse, len(page.Files))}
+	for i, file := range page.Files {
+		response.Files[i] = taskFileResponse{Index: file.Index, fileResponse: v.file(file.File)}
+	}
+	return response
+}
+
+// deadLetters применяет task к задачам списка неудачных задач
+func (v view) deadLetters(letters []*entities.DeadLetter) []*deadLetterResponse {
+	response := make([]*deadLetterResponse, len(letters))
+	for i, letter := range letters {
+		response[i] = &deadLetterResponse{DeadLetter: letter, Task: v.task(letter.Task)}
+	}
+	return response
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"file-downloader/internal/entities"
+)
+
+func TestTaskViewOmitsInlineContent(t *testing.T) {
+	// Setup
+	task := &entities.Task{Files: []entities.File{
+		{URL: "https://example.com/a.txt"},
+		{Name: "b.txt", Content: "YWJj"},
+	}}
+
+	// Execute
+	body, err := json.Marshal(view{}.task(task))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(string(body), `"content"`) {
+		t.Errorf("Expected no inline content in response, got %s", body)
+	}
+	if !strings.Contains(string(body), `"name":"b.txt"`) {
+		t.Errorf("Expected inline file name in response, got %s", body)
+	}
+	if task.Files[1].Content != "YWJj" {
+		t.Errorf("Expected stored task to keep inline content, got %q", task.Files[1].Content)
+	}
+}
+
+func TestViewAddsSizeStrings(t *testing.T) {
+	const size = int64(1<<53 + 1)
+	testCases := []struct {
+		name        string
+		sizeStrings bool
+		expected    interface{}
+	}{
+		{"enabled", true, "9007199254740993"},
+		{"disabled", false, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			page := &entities.FilePage{Files: []entities.TaskFile{
+				{Index: 3, File: entities.File{URL: "https://example.com/huge.bin", Size: size, Status: "completed"}},
+			}}
+
+			// Execute
+			data, err := json.Marshal(view{sizeStrings: tc.sizeStrings}.filePage(page))
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			var decoded struct {
+				Files []map[string]interface{} `json:"files"`
+			}
+			if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Files) != 1 {
+				t.Fatalf("Expected one file in valid JSON, got %s (%v)", data, err)
+			}
+			file := decoded.Files[0]
+			if file["size_str"] != tc.expected {
+				t.Errorf("Expected size_str %v, got %v", tc.expected, file["size_str"])
+			}
+			if file["index"] != float64(3) || file["url"] != "https://example.com/huge.bin" {
+				t.Errorf("Expected index and file fields to be kept, got %s", data)
+			}
+		})
+	}
+}
+
+func TestFileJSONOmitsSizeString(t *testing.T) {
+	// Setup
+	file := entities.File{URL: "https://example.com/a.bin", Size: 10, Status: "completed"}
+
+	// Execute
+	data, err := json.Marshal(file)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(string(data), "size_str") {
+		t.Errorf("Expected stored file JSON without size_str, got %s", data)
+	}
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "time"
+
+// systemClock используется, когда часы не заданы
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"file-downloader/internal/entities"
+)
+
+// compactChunkSize - размер порции записи сжатого файла, между порциями проверяется отмена контекста
+const compactChunkSize = 1 << 20
+
+// Compact атомарно переписывает файл состояния, удаляя задачи, для которых drop возвращает true
+// (drop вызывается под блокировкой и не должен изменять задачу). Снимок задач делается под блокировкой,
+// а запись во временный файл - без неё, поэтому обычные операции во время сжатия не ждут. Если задачи изменились
+// после снимка, файл состояния переписывается заново под блокировкой. Прерванное отменой контекста сжатие
+// оставляет прежний файл без изменений и может быть просто запущено снова.
+func (r *FileBasedTaskRepository) Compact(ctx context.Context, drop func(*entities.Task) bool) (entities.CompactionReport, error) {
+	report := entities.CompactionReport{StartedAt: r.clock.Now()}
+
+	// Снимок задач под блокировкой
+	r.mutex.Lock()
+	if r.compacting {
+		r.mutex.Unlock()
+		return report, entities.ErrCompactionInProgress
+	}
+	r.compacting = true
+	defer func() {
+		r.mutex.Lock()
+		r.compacting = false
+		r.mutex.Unlock()
+	}()
+
+	report.BytesBefore = r.storeSizeUnsafe()
+	kept := make(map[string]*entities.Task, len(r.tasks))
+	dropped := make(map[string]*entities.Task)
+	for id, task := range r.tasks {
+		if drop != nil && drop(task) {
+			dropped[id] = task
+			continue
+		}
+		kept[id] = task
+	}
+	snapshot, err := r.stripFilesUnsafe(kept)
+	generation := r.generation
+	r.mutex.Unlock()
+	if err != nil {
+		return report, err
+	}
+
+	// Запись во временный файл без блокировки
+	tmpPath := r.filePath + ".compact"
+	if err := r.writeSnapshot(ctx, tmpPath, snapshot); err != nil {
+		os.Remove(tmpPath)
+		return report, err
+	}
+	if r.afterSnapshot != nil {
+		r.afterSnapshot()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		os.Remove(tmpPath)
+		return report, err
+	}
+	if generation == r.generation {
+		if err := os.Rename(tmpPath, r.filePath); err != nil {
+			os.Remove(tmpPath)
+			return report, fmt.Errorf("не удалось заменить файл состояния: %w", err)
+		}
+		for id := range dropped {
+			delete(r.tasks, id)
+		}
+	} else {
+		// Задачи изменились после снимка: снимок устарел, удаляются только не изменившиеся с тех пор задачи
+		os.Remove(tmpPath)
+		for id, task := range dropped {
+			if r.tasks[id] != task {
+				delete(dropped, id)
+				continue
+			}
+			delete(r.tasks, id)
+		}
+		if err := r.saveTasksUnsafe(); err != nil {
+			return report, err
+		}
+	}
+	r.removeStaleFilesUnsafe()
+	r.saveErr = nil
+
+	for id := range dropped {
+		report.Dropped = append(report.Dropped, id)
+	}
+	slices.Sort(report.Dropped)
+	report.TasksDropped = len(report.Dropped)
+	report.TasksKept = len(r.tasks)
+	report.Reclaim(r.storeSizeUnsafe())
+	report.FinishedAt = r.clock.Now()
+	return report, nil
+}
+
+// writeSnapshot записывает задачи в файл path порциями, прерываясь при отмене контекста
+func (r *FileBasedTaskRepository) writeSnapshot(ctx context.Context, path string, tasks map[string]*entities.Task) error {
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось маршалить JSON: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.compress {
+		data, err = compress(data)
+		if err != nil {
+			return fmt.Errorf("не удалось сжать данные: %w", err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("не удалось создать временный файл: %w", err)
+	}
+	for len(data) > 0 {
+		if err := ctx.Err(); err != nil {
+			file.Close()
+			return err
+		}
+		chunk := data[:min(len(data), compactChunkSize)]
+		if _, err := file.Write(chunk); err != nil {
+			file.Close()
+			return fmt.Errorf("не удалось записать временный файл: %w", err)
+		}
+		data = data[len(chunk):]
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("не удалось записать временный файл: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("не удалось записать временный файл: %w", err)
+	}
+	return nil
+}
+
+// storeSizeUnsafe возвращает размер файла состояния и отдельных списков файлов задач на диске
+func (r *FileBasedTaskRepository) storeSizeUnsafe() int64 {
+	var size int64
+	if info, err := os.Stat(r.filePath); err == nil {
+		size += info.Size()
+	}
+	filepath.WalkDir(r.filePath+".files", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+// createCompactionTasks stores a completed task and an active task in the repository
+func createCompactionTasks(t *testing.T, path string) (*FileBasedTaskRepository, *entities.Task, *entities.Task) {
+	t.Helper()
+	repo := NewFileBasedTaskRepository(path).(*FileBasedTaskRepository)
+	ctx := context.Background()
+	if err := repo.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	finished := time.Now().Add(-48 * time.Hour)
+	done := entities.NewTask([]string{"https://example.com/done.bin"}, finished)
+	done.Status = entities.TaskStatusCompleted
+	done.FinishedAt = &finished
+	active := entities.NewTask([]string{"https://example.com/active.bin"}, time.Now())
+	for _, task := range []*entities.Task{done, active} {
+		if err := repo.Create(ctx, task); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	return repo, done, active
+}
+
+func TestFileBasedRepositoryCompactDropsTasks(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo, done, active := createCompactionTasks(t, path)
+	ctx := context.Background()
+
+	// Execute
+	report, err := repo.Compact(ctx, func(task *entities.Task) bool {
+		return task.Status == entities.TaskStatusCompleted
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.TasksDropped != 1 || report.TasksKept != 1 || len(report.Dropped) != 1 || report.Dropped[0] != done.ID.String() {
+		t.Errorf("Expected completed task to be dropped, got %+v", report)
+	}
+	if report.BytesReclaimed <= 0 || report.BytesAfter != report.BytesBefore-report.BytesReclaimed {
+		t.Errorf("Expected reclaimed bytes to be reported, got %+v", report)
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("Expected temporary file to be removed, got %v", err)
+	}
+
+	reloaded := NewFileBasedTaskRepository(path)
+	if err := reloaded.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	if _, err := reloaded.GetByID(ctx, done.ID.String()); !errors.Is(err, entities.ErrTaskNotFound) {
+		t.Errorf("Expected dropped task to be gone after reload, got %v", err)
+	}
+	if _, err := reloaded.GetByID(ctx, active.ID.String()); err != nil {
+		t.Errorf("Expected active task to be kept, got %v", err)
+	}
+}
+
+func TestFileBasedRepositoryCompactInterrupted(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo, done, _ := createCompactionTasks(t, path)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read state file: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Execute
+	_, err = repo.Compact(ctx, func(*entities.Task) bool { return true })
+
+	// Assert
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	after, _ := os.ReadFile(path)
+	if string(after) != string(before) {
+		t.Error("Expected state file to stay unchanged after an interrupted compaction")
+	}
+	if _, err := repo.GetByID(context.Background(), done.ID.String()); err != nil {
+		t.Errorf("Expected tasks to be kept after an interrupted compaction, got %v", err)
+	}
+
+	// A restarted compaction completes normally
+	report, err := repo.Compact(context.Background(), func(*entities.Task) bool { return true })
+	if err != nil || report.TasksDropped != 2 {
+		t.Errorf("Expected restarted compaction to drop 2 tasks, got %+v (%v)", report, err)
+	}
+}
+
+func TestFileBasedRepositoryCompactKeepsTasksChangedAfterSnapshot(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo, done, active := createCompactionTasks(t, path)
+	ctx := context.Background()
+	finished := time.Now().Add(-48 * time.Hour)
+	changed := entities.NewTask([]string{"https://example.com/changed.bin"}, finished)
+	changed.Status = entities.TaskStatusCompleted
+	changed.FinishedAt = &finished
+	repo.Create(ctx, changed)
+	created := entities.NewTask([]string{"https://example.com/created.bin"}, time.Now())
+	clock := testutil.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	repo.clock = clock
+
+	// The task is changed and another one is created while the snapshot is written without the lock
+	repo.afterSnapshot = func() {
+		update := changed.Clone()
+		update.RequestID = "changed"
+		if err := repo.Update(ctx, update); err != nil {
+			t.Errorf("Failed to update task: %v", err)
+		}
+		if err := repo.Create(ctx, created); err != nil {
+			t.Errorf("Failed to create task: %v", err)
+		}
+	}
+
+	// Execute
+	report, err := repo.Compact(ctx, func(task *entities.Task) bool {
+		return task.Status == entities.TaskStatusCompleted
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(report.Dropped) != 1 || report.Dropped[0] != done.ID.String() {
+		t.Errorf("Expected only the unchanged completed task to be dropped, got %v", report.Dropped)
+	}
+	if report.TasksKept != 3 {
+		t.Errorf("Expected 3 kept tasks, got %d", report.TasksKept)
+	}
+	if !report.StartedAt.Equal(clock.Now()) || !report.FinishedAt.Equal(clock.Now()) {
+		t.Errorf("Expected report times from the repository clock, got %v and %v", report.StartedAt, report.FinishedAt)
+	}
+
+	reloaded := NewFileBasedTaskRepository(path)
+	if err := reloaded.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	if _, err := reloaded.GetByID(ctx, done.ID.String()); !errors.Is(err, entities.ErrTaskNotFound) {
+		t.Errorf("Expected dropped task to be gone after reload, got %v", err)
+	}
+	if task, err := reloaded.GetByID(ctx, changed.ID.String()); err != nil || task.RequestID != "changed" {
+		t.Errorf("Expected task changed after the snapshot to be kept with its changes, got %v", err)
+	}
+	for _, id := range []string{active.ID.String(), created.ID.String()} {
+		if _, err := reloaded.GetByID(ctx, id); err != nil {
+			t.Errorf("Expected task %s to be kept, got %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// FileBasedDeadLetterRepository хранит окончательно неудачные задачи в памяти и сохраняет их в JSON-файл
+// после каждого изменения
+type FileBasedDeadLetterRepository struct {
+	filePath string
+	letters  map[string]*entities.DeadLetter
+	mutex    sync.RWMutex
+}
+
+// NewFileBasedDeadLetterRepository создает хранилище неудачных задач в файле filePath
+func NewFileBasedDeadLetterRepository(filePath string) interfaces.DeadLetterRepository {
+	return &FileBasedDeadLetterRepository{
+		filePath: filePath,
+		letters:  make(map[string]*entities.DeadLetter),
+	}
+}
+
+// LoadDeadLetters загружает записи из файла; отсутствующий файл означает пустое хранилище
+func (r *FileBasedDeadLetterRepository) LoadDeadLetters() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		r.letters = make(map[string]*entities.DeadLetter)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать файл неудачных задач: %w", err)
+	}
+
+	letters := make(map[string]*entities.DeadLetter)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &letters); err != nil {
+			return fmt.Errorf("не удалось распарсить файл неудачных задач: %w", err)
+		}
+	}
+	for id, letter := range letters {
+		if letter.Task == nil {
+			return fmt.Errorf("запись неудачной задачи %s не содержит задачу", id)
+		}
+	}
+
+	r.letters = letters
+	return nil
+}
+
+// Save добавляет задачу или заменяет запись с тем же ID задачи
+func (r *FileBasedDeadLetterRepository) Save(ctx context.Context, letter *entities.DeadLetter) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := letter.ID()
+	previous, existed := r.letters[id]
+	r.letters[id] = letter.Clone()
+	if err := r.saveUnsafe(); err != nil {
+		// Неудачная запись не должна менять состояние в памяти
+		if existed {
+			r.letters[id] = previous
+		} else {
+			delete(r.letters, id)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get получает запись по ID задачи
+func (r *FileBasedDeadLetterRepository) Get(ctx context.Context, id string) (*entities.DeadLetter, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	letter, exists := r.letters[id]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", entities.ErrDeadLetterNotFound, id)
+	}
+	return letter.Clone(), nil
+}
+
+// List возвращает записи в порядке переноса задач
+func (r *FileBasedDeadLetterRepository) List(ctx context.Context) ([]*entities.DeadLetter, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	letters := make([]*entities.DeadLetter, 0, len(r.letters))
+	for _, letter := range r.letters {
+		letters = append(letters, letter.Clone())
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if !letters[i].DeadLetteredAt.Equal(letters[j].DeadLetteredAt) {
+			return letters[i].DeadLetteredAt.Before(letters[j].DeadLetteredAt)
+		}
+		return letters[i].ID() < letters[j].ID()
+	})
+	return letters, nil
+}
+
+// Delete удаляет запись по ID задачи
+func (r *FileBasedDeadLetterRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	letter, exists := r.letters[id]
+	if !exists {
+		return fmt.Errorf("%w: %s", entities.ErrDeadLetterNotFound, id)
+	}
+	delete(r.letters, id)
+	if err := r.saveUnsafe(); err != nil {
+		r.letters[id] = letter
+		return err
+	}
+	return nil
+}
+
+// saveUnsafe записывает записи в файл через временный файл (вызывающий должен держать блокировку на запись)
+func (r *FileBasedDeadLetterRepository) saveUnsafe() error {
+	if err := os.MkdirAll(filepath.Dir(r.filePath), 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию: %w", err)
+	}
+
+	data, err := json.MarshalIndent(r.letters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось маршалить JSON: %w", err)
+	}
+
+	tmpPath := r.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось записать файл неудачных задач: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось записать файл неудачных задач: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// collectFailedFiles отбирает ошибки скачивания неудаленных задач без копирования самих задач
+// (вызывающий должен держать блокировку). limit <= 0 снимает ограничение.
+func collectFailedFiles(tasks map[string]*entities.Task, since time.Time, limit int) []entities.FailedFile {
+	failed := []entities.FailedFile{}
+	for _, task := range tasks {
+		if task.IsDeleted() {
+			continue
+		}
+		failed = append(failed, task.FailedFiles(since)...)
+	}
+
+	entities.SortFailedFiles(failed)
+	if limit > 0 && len(failed) > limit {
+		failed = failed[:limit]
+	}
+	return failed
+}
@@ -1,31 +1,115 @@
 package repository
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"file-downloader/internal/entities"
 	"file-downloader/internal/interfaces"
 )
 
+// gzipMagic - сигнатура gzip-потока
+var gzipMagic = []byte{0x1f, 0x8b}
+
 // FileBasedTaskRepository реализует PersistentRepository используя файловое хранилище
 type FileBasedTaskRepository struct {
 	filePath string
+	compress bool
 	tasks    map[string]*entities.Task
 	mutex    sync.RWMutex
+
+	filesThreshold int                     // число файлов, начиная с которого файлы задачи хранятся отдельно (0 - всегда в файле состояния)
+	fileSidecars   map[string]*fileSidecar // отдельные списки файлов задач на диске
+
+	retryInterval time.Duration // интервал повторной записи в режиме отложенной записи (0 - режим выключен)
+	clock         interfaces.Clock
+	saveErr       error     // ошибка последней неудачной записи, пока состояние не сохранено
+	failingSince  time.Time // время первой неудачной записи
+
+	generation    uint64 // номер изменения задач в памяти: по нему сжатие узнает, что задачи изменились после снимка
+	compacting    bool   // выполняется сжатие хранилища
+	afterSnapshot func() // вызывается после записи снимка сжатия, пока блокировка не удерживается (для тестов)
+}
+
+// filesChunkSize - число файлов в одной порции отдельного списка файлов задачи
+const filesChunkSize = 1000
+
+// fileSidecar описывает отдельный список файлов задачи на диске
+type fileSidecar struct {
+	written []bool // порции списка на диске; true - порция совпадает с задачей в памяти
+	loaded  bool   // список находится в памяти; иначе он читается с диска при каждом обращении к задаче
+	legacy  bool   // список хранится одним файлом {ID задачи}.json прежнего формата
+}
+
+// sidecarIndex - оглавление отдельного списка файлов задачи
+type sidecarIndex struct {
+	Chunks int `json:"chunks"` // число порций списка
+}
+
+// FileRepositoryOption настраивает файловый репозиторий задач
+type FileRepositoryOption func(*FileBasedTaskRepository)
+
+// WithWriteBehind включает отложенную запись: если файл состояния не удается записать (например, диск заполнен),
+// изменения остаются в памяти, ошибка записывается в журнал, а не возвращается вызывающему,
+// и запись повторяется в фоне каждые interval, пока не удастся. До восстановления синхронные записи не выполняются.
+func WithWriteBehind(interval time.Duration, clock interfaces.Clock) FileRepositoryOption {
+	return func(r *FileBasedTaskRepository) {
+		if interval > 0 && clock != nil {
+			r.retryInterval = interval
+			r.clock = clock
+		}
+	}
 }
 
-// NewFileBasedTaskRepository создает новый репозиторий задач на основе файлов
-func NewFileBasedTaskRepository(filePath string) interfaces.PersistentRepository {
-	return &FileBasedTaskRepository{
+// WithRepositoryClock задает источник времени для отметок времени хранилища (по умолчанию - системное время)
+func WithRepositoryClock(clock interfaces.Clock) FileRepositoryOption {
+	return func(r *FileBasedTaskRepository) {
+		if clock != nil {
+			r.clock = clock
+		}
+	}
+}
+
+// WithSeparateFileStorage хранит список файлов задач, в которых не меньше threshold файлов, отдельно
+// в директории {путь состояния}.files/{ID задачи}/ порциями по filesChunkSize файлов. Общий файл состояния
+// при этом остается небольшим, а при изменении задачи переписываются только изменившиеся порции её списка.
+// Списки файлов задач, которые не скачиваются, не хранятся в памяти и читаются с диска при обращении к задаче.
+func WithSeparateFileStorage(threshold int) FileRepositoryOption {
+	return func(r *FileBasedTaskRepository) {
+		if threshold > 0 {
+			r.filesThreshold = threshold
+		}
+	}
+}
+
+// NewFileBasedTaskRepository создает новый репозиторий задач на основе файлов.
+// Если путь заканчивается на .gz, файл состояния сохраняется в сжатом виде.
+func NewFileBasedTaskRepository(filePath string, opts ...FileRepositoryOption) interfaces.PersistentRepository {
+	r := &FileBasedTaskRepository{
 		filePath: filePath,
+		compress: strings.HasSuffix(filePath, ".gz"),
 		tasks:    make(map[string]*entities.Task),
+		clock:    systemClock{},
+
+		fileSidecars: make(map[string]*fileSidecar),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
 // LoadTasks загружает задачи из файла
@@ -39,18 +123,32 @@ func (r *FileBasedTaskRepository) LoadTasks() error {
 	}
 
 	// Проверка существования файла
-	if _, err := os.Stat(r.filePath); os.IsNotExist(err) {
-		// Файл не существует, создаем пустую карту
-		r.tasks = make(map[string]*entities.Task)
-		return nil
+	path := r.filePath
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// Сжатого файла еще нет: пробуем загрузить несжатый файл от предыдущей конфигурации
+		legacyPath := strings.TrimSuffix(path, ".gz")
+		if _, err := os.Stat(legacyPath); !r.compress || os.IsNotExist(err) {
+			// Файл не существует, создаем пустую карту
+			r.tasks = make(map[string]*entities.Task)
+			return nil
+		}
+		path = legacyPath
 	}
 
 	// Чтение файла
-	data, err := ioutil.ReadFile(r.filePath)
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("не удалось прочитать файл: %w", err)
 	}
 
+	// Распаковка, если файл сжат (определяется по сигнатуре, а не по расширению)
+	if bytes.HasPrefix(data, gzipMagic) {
+		data, err = decompress(data)
+		if err != nil {
+			return fmt.Errorf("не удалось распаковать файл: %w", err)
+		}
+	}
+
 	// Парсинг JSON
 	var tasks map[string]*entities.Task
 	if len(data) > 0 {
@@ -61,41 +159,197 @@ func (r *FileBasedTaskRepository) LoadTasks() error {
 		tasks = make(map[string]*entities.Task)
 	}
 
+	// Списки файлов крупных задач остаются на диске и читаются при обращении к задаче
+	r.fileSidecars = make(map[string]*fileSidecar)
+	for id, task := range tasks {
+		if len(task.Files) > 0 {
+			continue
+		}
+		sidecar, err := r.findSidecar(id)
+		if err != nil {
+			return err
+		}
+		if sidecar != nil {
+			r.fileSidecars[id] = sidecar
+		}
+	}
+
 	r.tasks = tasks
+	r.generation++
+	return nil
+}
+
+// filesDir возвращает директорию отдельного списка файлов задачи
+func (r *FileBasedTaskRepository) filesDir(id string) string {
+	return filepath.Join(r.filePath+".files", id)
+}
+
+// chunkPath возвращает путь порции отдельного списка файлов задачи
+func (r *FileBasedTaskRepository) chunkPath(id string, chunk int) string {
+	return filepath.Join(r.filesDir(id), fmt.Sprintf("%06d.json", chunk))
+}
+
+// indexPath возвращает путь оглавления отдельного списка файлов задачи
+func (r *FileBasedTaskRepository) indexPath(id string) string {
+	return filepath.Join(r.filesDir(id), "index.json")
+}
+
+// legacyFilesPath возвращает путь списка файлов задачи прежнего формата, хранившегося одним файлом
+func (r *FileBasedTaskRepository) legacyFilesPath(id string) string {
+	return filepath.Join(r.filePath+".files", id+".json")
+}
+
+// findSidecar возвращает описание отдельного списка файлов задачи на диске, не читая сам список;
+// если списка нет, возвращается nil
+func (r *FileBasedTaskRepository) findSidecar(id string) (*fileSidecar, error) {
+	var index sidecarIndex
+	err := readJSON(r.indexPath(id), &index)
+	if err == nil {
+		written := make([]bool, index.Chunks)
+		for i := range written {
+			written[i] = true
+		}
+		return &fileSidecar{written: written}, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("не удалось прочитать файлы задачи %s: %w", id, err)
+	}
+	if _, err := os.Stat(r.legacyFilesPath(id)); err == nil {
+		return &fileSidecar{legacy: true}, nil
+	}
+	return nil, nil
+}
+
+// loadFilesUnsafe читает отдельный список файлов задачи с диска
+func (r *FileBasedTaskRepository) loadFilesUnsafe(id string, sidecar *fileSidecar) ([]entities.File, error) {
+	if sidecar.legacy {
+		var files []entities.File
+		if err := readJSON(r.legacyFilesPath(id), &files); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать файлы задачи %s: %w", id, err)
+		}
+		return files, nil
+	}
+
+	var files []entities.File
+	for chunk := range sidecar.written {
+		var part []entities.File
+		if err := readJSON(r.chunkPath(id, chunk), &part); err != nil {
+			return nil, fmt.Errorf("не удалось прочитать файлы задачи %s: %w", id, err)
+		}
+		files = append(files, part...)
+	}
+	return files, nil
+}
+
+// attachFilesUnsafe подставляет в копию задачи список файлов, который не хранится в памяти
+func (r *FileBasedTaskRepository) attachFilesUnsafe(task *entities.Task) error {
+	id := task.ID.String()
+	sidecar := r.fileSidecars[id]
+	if sidecar == nil || sidecar.loaded {
+		return nil
+	}
+	files, err := r.loadFilesUnsafe(id, sidecar)
+	if err != nil {
+		return err
+	}
+	task.Files = files
 	return nil
 }
 
-// SaveTasks сохраняет задачи в файл
+// cloneUnsafe возвращает копию задачи вместе со списком файлов
+func (r *FileBasedTaskRepository) cloneUnsafe(task *entities.Task) (*entities.Task, error) {
+	clone := task.Clone()
+	if err := r.attachFilesUnsafe(clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// SaveTasks сохраняет задачи в файл. Ошибка возвращается и в режиме отложенной записи,
+// так как вызывается при остановке сервиса, когда повторить запись в фоне уже нельзя.
 func (r *FileBasedTaskRepository) SaveTasks() error {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
 	// Создание директории, если она не существует
 	if err := os.MkdirAll(filepath.Dir(r.filePath), 0755); err != nil {
 		return fmt.Errorf("не удалось создать директорию: %w", err)
 	}
 
-	// Маршалинг в JSON
-	data, err := json.MarshalIndent(r.tasks, "", "  ")
-	if err != nil {
-		return fmt.Errorf("не удалось маршалить JSON: %w", err)
+	if err := r.saveTasksUnsafe(); err != nil {
+		return err
 	}
+	r.saveErr = nil
+	return nil
+}
 
-	// Запись в файл
-	if err := ioutil.WriteFile(r.filePath, data, 0644); err != nil {
-		return fmt.Errorf("не удалось записать файл: %w", err)
+// Health возвращает ошибку, если состояние задач не удается сохранить в файл
+func (r *FileBasedTaskRepository) Health() error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.saveErr == nil {
+		return nil
+	}
+	return fmt.Errorf("состояние не сохраняется с %s: %w", r.failingSince.Format(time.RFC3339), r.saveErr)
+}
+
+// persistUnsafe сохраняет задачи после изменения (вызывающий должен держать блокировку на запись).
+// В режиме отложенной записи ошибка не возвращается: запись повторяется в фоне.
+func (r *FileBasedTaskRepository) persistUnsafe() error {
+	r.generation++
+	if r.retryInterval == 0 {
+		return r.saveTasksUnsafe()
+	}
+
+	// Фоновая запись уже повторяется и сохранит последнее состояние
+	if r.saveErr != nil {
+		return nil
 	}
 
+	if err := r.saveTasksUnsafe(); err != nil {
+		log.Printf("Не удалось сохранить состояние, запись будет повторяться каждые %v: %v", r.retryInterval, err)
+		r.saveErr = err
+		r.failingSince = r.clock.Now()
+		go r.retrySave()
+	}
 	return nil
 }
 
+// retrySave повторяет запись состояния, пока она не удастся
+func (r *FileBasedTaskRepository) retrySave() {
+	for {
+		<-r.clock.After(r.retryInterval)
+
+		r.mutex.Lock()
+		if r.saveErr == nil {
+			// Состояние уже сохранено вызовом SaveTasks
+			r.mutex.Unlock()
+			return
+		}
+		err := r.saveTasksUnsafe()
+		if err == nil {
+			log.Printf("Сохранение состояния восстановлено после сбоя с %s", r.failingSince.Format(time.RFC3339))
+			r.saveErr = nil
+			r.mutex.Unlock()
+			return
+		}
+		r.saveErr = err
+		r.mutex.Unlock()
+	}
+}
+
 // Create добавляет новую задачу в репозиторий
 func (r *FileBasedTaskRepository) Create(ctx context.Context, task *entities.Task) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	r.tasks[task.ID.String()] = task
-	return r.saveTasksUnsafe()
+	if _, exists := r.tasks[task.ID.String()]; exists {
+		return fmt.Errorf("%w: %s", entities.ErrTaskExists, task.ID.String())
+	}
+	r.tasks[task.ID.String()] = task.Clone()
+	r.markFilesChangedUnsafe(task.ID.String(), nil, r.tasks[task.ID.String()])
+	return r.persistUnsafe()
 }
 
 // GetByID получает задачу по её ID
@@ -105,10 +359,29 @@ func (r *FileBasedTaskRepository) GetByID(ctx context.Context, id string) (*enti
 
 	task, exists := r.tasks[id]
 	if !exists {
-		return nil, fmt.Errorf("задача с id %s не найдена", id)
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskNotFound, id)
+	}
+
+	return r.cloneUnsafe(task)
+}
+
+// GetByIDs получает задачи по списку ID под одной блокировкой; отсутствующие ID пропускаются
+func (r *FileBasedTaskRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*entities.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tasks := make(map[string]*entities.Task, len(ids))
+	for _, id := range ids {
+		if task, exists := r.tasks[id]; exists {
+			clone, err := r.cloneUnsafe(task)
+			if err != nil {
+				return nil, err
+			}
+			tasks[id] = clone
+		}
 	}
 
-	return task, nil
+	return tasks, nil
 }
 
 // GetAll получает все задачи
@@ -118,23 +391,81 @@ func (r *FileBasedTaskRepository) GetAll(ctx context.Context) ([]*entities.Task,
 
 	tasks := make([]*entities.Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
-		tasks = append(tasks, task)
+		clone, err := r.cloneUnsafe(task)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, clone)
 	}
 
 	return tasks, nil
 }
 
+// GetTasksAfter получает до limit задач, следующих за курсором, в порядке списка фильтра
+func (r *FileBasedTaskRepository) GetTasksAfter(ctx context.Context, after entities.TaskCursor, limit int, filter entities.TaskFilter) ([]*entities.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	page := collectTasksAfter(r.tasks, after, limit, filter)
+	for _, task := range page {
+		if err := r.attachFilesUnsafe(task); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
 // Update обновляет существующую задачу
 func (r *FileBasedTaskRepository) Update(ctx context.Context, task *entities.Task) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.tasks[task.ID.String()]; !exists {
-		return fmt.Errorf("задача с id %s не найдена", task.ID.String())
+	old, exists := r.tasks[task.ID.String()]
+	if !exists {
+		return fmt.Errorf("%w: %s", entities.ErrTaskNotFound, task.ID.String())
+	}
+
+	r.tasks[task.ID.String()] = task.Clone()
+	r.markFilesChangedUnsafe(task.ID.String(), old, r.tasks[task.ID.String()])
+	return r.persistUnsafe()
+}
+
+// markFilesChangedUnsafe отмечает порции отдельного списка файлов задачи, которые нужно переписать
+// при следующем сохранении: изменившиеся по сравнению с прежней версией задачи old (nil - все порции).
+// Список прежней версии, не находившийся в памяти, читается для сравнения с диска, а список новой версии
+// остается в памяти до следующего сохранения.
+func (r *FileBasedTaskRepository) markFilesChangedUnsafe(id string, old, task *entities.Task) {
+	sidecar, exists := r.fileSidecars[id]
+	if !exists {
+		return
 	}
+	var previous []entities.File
+	if old != nil {
+		previous = old.Files
+	}
+	if old != nil && !sidecar.loaded {
+		files, err := r.loadFilesUnsafe(id, sidecar)
+		if err != nil {
+			log.Printf("Не удалось прочитать файлы задачи %s, список будет записан целиком: %v", id, err)
+			old = nil
+		}
+		previous = files
+	}
+	sidecar.loaded = true
+	for chunk := range sidecar.written {
+		if old == nil || !reflect.DeepEqual(filesChunk(previous, chunk), filesChunk(task.Files, chunk)) {
+			sidecar.written[chunk] = false
+		}
+	}
+}
 
-	r.tasks[task.ID.String()] = task
-	return r.saveTasksUnsafe()
+// filesChunk возвращает порцию chunk списка файлов (nil, если список короче)
+func filesChunk(files []entities.File, chunk int) []entities.File {
+	start := chunk * filesChunkSize
+	if start >= len(files) {
+		return nil
+	}
+	return files[start:min(start+filesChunkSize, len(files))]
 }
 
 // Delete удаляет задачу по её ID
@@ -143,22 +474,54 @@ func (r *FileBasedTaskRepository) Delete(ctx context.Context, id string) error {
 	defer r.mutex.Unlock()
 
 	if _, exists := r.tasks[id]; !exists {
-		return fmt.Errorf("задача с id %s не найдена", id)
+		return fmt.Errorf("%w: %s", entities.ErrTaskNotFound, id)
 	}
 
 	delete(r.tasks, id)
-	return r.saveTasksUnsafe()
+	return r.persistUnsafe()
 }
 
-// GetPendingTasks получает все задачи со статусом "new" или "processing"
+// GetFailedFiles возвращает файлы неудаленных задач, завершившиеся ошибкой не раньше since, от самых свежих
+func (r *FileBasedTaskRepository) GetFailedFiles(ctx context.Context, since time.Time, limit int) ([]entities.FailedFile, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// Ошибки скачивания выгруженных из памяти задач есть только у завершившихся ошибкой или частично
+	tasks, copied := r.tasks, false
+	for id, sidecar := range r.fileSidecars {
+		task, exists := r.tasks[id]
+		if !exists || sidecar.loaded || (task.Status != entities.TaskStatusFailed && task.Status != entities.TaskStatusPartial) {
+			continue
+		}
+		if !copied {
+			tasks, copied = maps.Clone(r.tasks), true
+		}
+		withFiles := *task
+		if err := r.attachFilesUnsafe(&withFiles); err != nil {
+			return nil, err
+		}
+		tasks[id] = &withFiles
+	}
+
+	return collectFailedFiles(tasks, since, limit), nil
+}
+
+// GetPendingTasks получает все неудаленные задачи со статусом "new" или "processing"
 func (r *FileBasedTaskRepository) GetPendingTasks(ctx context.Context) ([]*entities.Task, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	var pendingTasks []*entities.Task
 	for _, task := range r.tasks {
+		if task.IsDeleted() {
+			continue
+		}
 		if task.Status == entities.TaskStatusNew || task.Status == entities.TaskStatusProcessing {
-			pendingTasks = append(pendingTasks, task)
+			clone, err := r.cloneUnsafe(task)
+			if err != nil {
+				return nil, err
+			}
+			pendingTasks = append(pendingTasks, clone)
 		}
 	}
 
@@ -167,16 +530,209 @@ func (r *FileBasedTaskRepository) GetPendingTasks(ctx context.Context) ([]*entit
 
 // saveTasksUnsafe сохраняет задачи без получения блокировки (вызывающий должен держать блокировку)
 func (r *FileBasedTaskRepository) saveTasksUnsafe() error {
+	// Списки файлов крупных задач записываются до общего файла, чтобы он не ссылался на несохраненные списки
+	tasks, err := r.saveFilesUnsafe()
+	if err != nil {
+		return err
+	}
+
 	// Маршалинг в JSON
-	data, err := json.MarshalIndent(r.tasks, "", "  ")
+	data, err := json.MarshalIndent(tasks, "", "  ")
 	if err != nil {
 		return fmt.Errorf("не удалось маршалить JSON: %w", err)
 	}
 
-	// Запись в файл
-	if err := ioutil.WriteFile(r.filePath, data, 0644); err != nil {
+	// Сжатие, если включено
+	if r.compress {
+		data, err = compress(data)
+		if err != nil {
+			return fmt.Errorf("не удалось сжать данные: %w", err)
+		}
+	}
+
+	// Запись во временный файл с последующим переименованием: неудачная запись (например, при заполненном диске)
+	// не повреждает последнее сохраненное состояние
+	tmpPath := r.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось записать файл: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.filePath); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("не удалось записать файл: %w", err)
 	}
 
 	return nil
 }
+
+// saveFilesUnsafe записывает измененные отдельные списки файлов, удаляет ненужные и возвращает задачи
+// для общего файла состояния, в которых файлы крупных задач опущены
+func (r *FileBasedTaskRepository) saveFilesUnsafe() (map[string]*entities.Task, error) {
+	if r.filesThreshold == 0 && len(r.fileSidecars) == 0 {
+		return r.tasks, nil
+	}
+
+	tasks, err := r.stripFilesUnsafe(r.tasks)
+	if err != nil {
+		return nil, err
+	}
+	r.removeStaleFilesUnsafe()
+	return tasks, nil
+}
+
+// stripFilesUnsafe записывает измененные порции отдельных списков файлов задач tasks и возвращает задачи,
+// в которых файлы крупных задач опущены. Записанные списки задач, которые не скачиваются, выгружаются из памяти.
+func (r *FileBasedTaskRepository) stripFilesUnsafe(tasks map[string]*entities.Task) (map[string]*entities.Task, error) {
+	stripped := make(map[string]*entities.Task, len(tasks))
+	for id, task := range tasks {
+		sidecar := r.fileSidecars[id]
+		if sidecar != nil && !sidecar.loaded {
+			// Список не менялся с момента записи и хранится только на диске
+			stripped[id] = task
+			continue
+		}
+		if r.filesThreshold == 0 || len(task.Files) < r.filesThreshold {
+			stripped[id] = task
+			continue
+		}
+		if sidecar == nil {
+			sidecar = &fileSidecar{loaded: true}
+			r.fileSidecars[id] = sidecar
+		}
+		if err := r.writeFilesUnsafe(id, task.Files, sidecar); err != nil {
+			return nil, err
+		}
+		if !task.IsActive() {
+			task.Files = nil
+			sidecar.loaded = false
+		}
+		withoutFiles := *task
+		withoutFiles.Files = nil
+		stripped[id] = &withoutFiles
+	}
+	return stripped, nil
+}
+
+// removeStaleFilesUnsafe удаляет списки удаленных задач и задач, файлы которых снова хранятся в общем файле
+func (r *FileBasedTaskRepository) removeStaleFilesUnsafe() {
+	for id, sidecar := range r.fileSidecars {
+		if task, exists := r.tasks[id]; exists &&
+			(!sidecar.loaded || r.filesThreshold > 0 && len(task.Files) >= r.filesThreshold) {
+			continue
+		}
+		if err := os.RemoveAll(r.filesDir(id)); err != nil {
+			log.Printf("Не удалось удалить файлы задачи %s: %v", id, err)
+			continue
+		}
+		if err := os.Remove(r.legacyFilesPath(id)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Не удалось удалить файлы задачи %s: %v", id, err)
+			continue
+		}
+		delete(r.fileSidecars, id)
+	}
+}
+
+// writeFilesUnsafe записывает изменившиеся порции отдельного списка файлов задачи, а затем его оглавление.
+// Каждая порция и оглавление записываются через временный файл, поэтому прерванная запись
+// оставляет на диске прежнее оглавление.
+func (r *FileBasedTaskRepository) writeFilesUnsafe(id string, files []entities.File, sidecar *fileSidecar) error {
+	if err := os.MkdirAll(r.filesDir(id), 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию: %w", err)
+	}
+
+	chunks := (len(files) + filesChunkSize - 1) / filesChunkSize
+	written := make([]bool, chunks)
+	copy(written, sidecar.written)
+	for chunk := range written {
+		if written[chunk] {
+			continue
+		}
+		if err := r.writeJSON(r.chunkPath(id, chunk), filesChunk(files, chunk)); err != nil {
+			return fmt.Errorf("не удалось записать файлы задачи %s: %w", id, err)
+		}
+		written[chunk] = true
+		if chunk < len(sidecar.written) {
+			sidecar.written[chunk] = true
+		}
+	}
+
+	if chunks != len(sidecar.written) || sidecar.legacy {
+		if err := r.writeJSON(r.indexPath(id), sidecarIndex{Chunks: chunks}); err != nil {
+			return fmt.Errorf("не удалось записать файлы задачи %s: %w", id, err)
+		}
+	}
+	// Порции, оставшиеся от более длинного списка, и список прежнего формата больше не нужны
+	for chunk := chunks; chunk < len(sidecar.written); chunk++ {
+		os.Remove(r.chunkPath(id, chunk))
+	}
+	if sidecar.legacy {
+		os.Remove(r.legacyFilesPath(id))
+	}
+	sidecar.written = written
+	sidecar.legacy = false
+	return nil
+}
+
+// writeJSON записывает значение в файл через временный файл, сжимая его при сжатом файле состояния
+func (r *FileBasedTaskRepository) writeJSON(path string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if r.compress {
+		data, err = compress(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// readJSON читает значение из файла, записанного writeJSON; сжатие определяется по сигнатуре
+func readJSON(path string, value any) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(data, gzipMagic) {
+		data, err = decompress(data)
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, value)
+}
+
+// compress сжимает данные в формате gzip
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress распаковывает данные в формате gzip
+func decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
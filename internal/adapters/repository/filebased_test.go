@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestFileBasedRepositoryCompressedRoundTrip(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json.gz")
+	repo := NewFileBasedTaskRepository(path)
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/file1.jpg"}, time.Now())
+
+	// Execute
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// Assert
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read state file: %v", err)
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		t.Error("Expected state file to be gzip-compressed")
+	}
+
+	reloaded := NewFileBasedTaskRepository(path)
+	if err := reloaded.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	if _, err := reloaded.GetByID(ctx, task.ID.String()); err != nil {
+		t.Errorf("Expected task to be loaded, got %v", err)
+	}
+}
+
+func TestFileBasedRepositoryLoadsLegacyUncompressedFile(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/file1.jpg"}, time.Now())
+
+	legacy := NewFileBasedTaskRepository(filepath.Join(dir, "tasks.json"))
+	if err := legacy.Create(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// Execute
+	repo := NewFileBasedTaskRepository(filepath.Join(dir, "tasks.json.gz"))
+	if err := repo.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+
+	// Assert
+	if _, err := repo.GetByID(ctx, task.ID.String()); err != nil {
+		t.Errorf("Expected legacy task to be loaded, got %v", err)
+	}
+}
+
+// blockStatePath makes the state file unwritable by putting a non-empty directory in its place
+func blockStatePath(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(path, "blocker"), 0755); err != nil {
+		t.Fatalf("Failed to block state path: %v", err)
+	}
+}
+
+func TestFileBasedRepositoryFailsWithoutWriteBehind(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	blockStatePath(t, path)
+	repo := NewFileBasedTaskRepository(path)
+
+	// Execute
+	err := repo.Create(context.Background(), entities.NewTask([]string{"https://example.com/file1.jpg"}, time.Now()))
+
+	// Assert
+	if err == nil {
+		t.Error("Expected write error without write-behind")
+	}
+}
+
+func TestFileBasedRepositoryWriteBehindRecovers(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	blockStatePath(t, path)
+	clock := testutil.NewFakeClock(time.Now())
+	repo := NewFileBasedTaskRepository(path, WithWriteBehind(time.Second, clock))
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/file1.jpg"}, time.Now())
+
+	// Execute: changes are accepted while the disk is unwritable
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Expected create to succeed in write-behind mode, got %v", err)
+	}
+	task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+	if err := repo.Update(ctx, task); err != nil {
+		t.Fatalf("Expected update to succeed in write-behind mode, got %v", err)
+	}
+
+	// Assert
+	checker := repo.(interface{ Health() error })
+	if checker.Health() == nil {
+		t.Fatal("Expected unhealthy persistence while writes fail")
+	}
+	if stored, err := repo.GetByID(ctx, task.ID.String()); err != nil || stored.Status != entities.TaskStatusCompleted {
+		t.Fatalf("Expected in-memory state to stay current, got %v (%v)", stored, err)
+	}
+
+	// Execute: the disk recovers and the background retry catches up
+	waitFor(t, func() bool { return clock.Waiters() == 1 })
+	os.RemoveAll(path)
+	clock.Advance(time.Second)
+
+	// Assert
+	waitFor(t, func() bool { return checker.Health() == nil })
+	reloaded := NewFileBasedTaskRepository(path)
+	if err := reloaded.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	stored, err := reloaded.GetByID(ctx, task.ID.String())
+	if err != nil || stored.Status != entities.TaskStatusCompleted {
+		t.Errorf("Expected persisted task to be completed, got %v (%v)", stored, err)
+	}
+}
+
+// waitFor polls condition until it holds or the test times out
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFileBasedRepositoryStoresLargeTaskFilesSeparately(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo := NewFileBasedTaskRepository(path, WithSeparateFileStorage(2))
+	ctx := context.Background()
+	large := entities.NewTask([]string{"https://example.com/a.jpg", "https://example.com/b.jpg"}, time.Now())
+	small := entities.NewTask([]string{"https://example.com/c.jpg"}, time.Now())
+	repo.Create(ctx, large)
+	repo.Create(ctx, small)
+	filesPath := filepath.Join(path+".files", large.ID.String(), "000000.json")
+	before, err := os.Stat(filesPath)
+	if err != nil {
+		t.Fatalf("Expected files of the large task in a separate file, got %v", err)
+	}
+
+	// Execute
+	small.Status = entities.TaskStatusProcessing
+	if err := repo.Update(ctx, small); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	// Assert
+	after, _ := os.Stat(filesPath)
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Error("Expected files of the large task not to be rewritten when another task changes")
+	}
+	state, _ := os.ReadFile(path)
+	if !bytes.Contains(state, []byte(`"files": null`)) {
+		t.Error("Expected state file to omit files of the large task")
+	}
+
+	reloaded := NewFileBasedTaskRepository(path)
+	if err := reloaded.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	loaded, err := reloaded.GetByID(ctx, large.ID.String())
+	if err != nil || len(loaded.Files) != 2 {
+		t.Fatalf("Expected large task with 2 files after reload, got %v (%v)", loaded, err)
+	}
+
+	reloaded.Delete(ctx, large.ID.String())
+	if _, err := os.Stat(filesPath); !os.IsNotExist(err) {
+		t.Errorf("Expected separate files to be removed with the task, got %v", err)
+	}
+}
+
+// newLargeTask creates a task with the given number of files
+func newLargeTask(files int) *entities.Task {
+	urls := make([]string, files)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/%d.jpg", i)
+	}
+	task := entities.NewTask(urls, time.Now())
+	for i := range task.Files {
+		task.Files[i].URL = urls[i]
+	}
+	return task
+}
+
+func TestFileBasedRepositoryRewritesOnlyChangedFileChunks(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo := NewFileBasedTaskRepository(path, WithSeparateFileStorage(2))
+	ctx := context.Background()
+	task := newLargeTask(filesChunkSize + 1)
+	repo.Create(ctx, task)
+	chunkPath := func(chunk int) string {
+		return filepath.Join(path+".files", task.ID.String(), fmt.Sprintf("%06d.json", chunk))
+	}
+	first, err := os.Stat(chunkPath(0))
+	if err != nil {
+		t.Fatalf("Expected first chunk of files, got %v", err)
+	}
+	os.Chtimes(chunkPath(0), first.ModTime().Add(-time.Hour), first.ModTime().Add(-time.Hour))
+	os.Chtimes(chunkPath(1), first.ModTime().Add(-time.Hour), first.ModTime().Add(-time.Hour))
+	before, _ := os.Stat(chunkPath(0))
+
+	// Execute
+	task.Files[filesChunkSize].Status = "completed"
+	if err := repo.Update(ctx, task); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	// Assert
+	after, _ := os.Stat(chunkPath(0))
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Error("Expected unchanged chunk not to be rewritten")
+	}
+	changed, _ := os.Stat(chunkPath(1))
+	if !changed.ModTime().After(before.ModTime()) {
+		t.Error("Expected changed chunk to be rewritten")
+	}
+
+	reloaded := NewFileBasedTaskRepository(path)
+	if err := reloaded.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	loaded, err := reloaded.GetByID(ctx, task.ID.String())
+	if err != nil || len(loaded.Files) != filesChunkSize+1 {
+		t.Fatalf("Expected task with %d files after reload, got %v", filesChunkSize+1, err)
+	}
+	if loaded.Files[filesChunkSize].Status != "completed" || loaded.Files[0].URL != task.Files[0].URL {
+		t.Errorf("Expected files to be restored in order, got %q with status %q", loaded.Files[0].URL, loaded.Files[filesChunkSize].Status)
+	}
+}
+
+func TestFileBasedRepositoryKeepsFinishedTaskFilesOnDisk(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	repo := NewFileBasedTaskRepository(path, WithSeparateFileStorage(2)).(*FileBasedTaskRepository)
+	ctx := context.Background()
+	task := newLargeTask(3)
+	repo.Create(ctx, task)
+
+	// Execute
+	task.Status = entities.TaskStatusCompleted
+	if err := repo.Update(ctx, task); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	// Assert
+	if files := repo.tasks[task.ID.String()].Files; files != nil {
+		t.Errorf("Expected files of the finished task not to be kept in memory, got %d", len(files))
+	}
+	loaded, err := repo.GetByID(ctx, task.ID.String())
+	if err != nil || len(loaded.Files) != 3 {
+		t.Fatalf("Expected finished task with 3 files read from disk, got %v", err)
+	}
+	all, _ := repo.GetAll(ctx)
+	if len(all) != 1 || len(all[0].Files) != 3 {
+		t.Errorf("Expected listed task with 3 files, got %d tasks", len(all))
+	}
+
+	reloaded := NewFileBasedTaskRepository(path).(*FileBasedTaskRepository)
+	if err := reloaded.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	if files := reloaded.tasks[task.ID.String()].Files; files != nil {
+		t.Errorf("Expected files not to be loaded eagerly, got %d", len(files))
+	}
+	if loaded, err := reloaded.GetByID(ctx, task.ID.String()); err != nil || len(loaded.Files) != 3 {
+		t.Errorf("Expected finished task with 3 files after reload, got %v", err)
+	}
+}
+
+func TestFileBasedRepositoryLoadsLegacyFilesSidecar(t *testing.T) {
+	// Setup: files of the task are stored in a single file of the previous format
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	task := newLargeTask(2)
+	files, _ := json.Marshal(task.Files)
+	stripped := *task
+	stripped.Files = nil
+	state, _ := json.Marshal(map[string]*entities.Task{task.ID.String(): &stripped})
+	os.WriteFile(path, state, 0644)
+	os.MkdirAll(path+".files", 0755)
+	legacyPath := filepath.Join(path+".files", task.ID.String()+".json")
+	os.WriteFile(legacyPath, files, 0644)
+	repo := NewFileBasedTaskRepository(path, WithSeparateFileStorage(2))
+	ctx := context.Background()
+	if err := repo.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+
+	// Execute
+	loaded, err := repo.GetByID(ctx, task.ID.String())
+	if err != nil || len(loaded.Files) != 2 {
+		t.Fatalf("Expected task with 2 files from the legacy file, got %v", err)
+	}
+	loaded.Files[0].Status = "completed"
+	if err := repo.Update(ctx, loaded); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	// Assert
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Errorf("Expected legacy file to be replaced by chunks, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path+".files", task.ID.String(), "000000.json")); err != nil {
+		t.Errorf("Expected files to be stored in chunks, got %v", err)
+	}
+}
@@ -1,25 +1,56 @@
 package repository
 
 import (
+	"container/list"
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"file-downloader/internal/entities"
 	"file-downloader/internal/interfaces"
 )
 
-// InMemoryTaskRepository реализует TaskRepository используя хранилище в памяти
+// InMemoryTaskRepository реализует TaskRepository используя хранилище в памяти.
+// При заданной емкости давно не использованные завершенные задачи вытесняются из памяти
+// и при обращении загружаются обратно из постоянного хранилища.
 type InMemoryTaskRepository struct {
-	tasks map[string]*entities.Task
-	mutex sync.RWMutex
+	tasks    map[string]*entities.Task
+	mutex    sync.RWMutex
+	capacity int
+	backing  interfaces.TaskRepository
+	recent   *list.List               // ID задач от недавно использованных к давно использованным
+	elements map[string]*list.Element // элементы recent по ID задачи
+}
+
+// InMemoryOption настраивает репозиторий задач в памяти
+type InMemoryOption func(*InMemoryTaskRepository)
+
+// WithCapacity ограничивает число задач в памяти. При превышении вытесняются давно не использованные
+// завершенные задачи; задачи в ожидании и в обработке не вытесняются никогда.
+// Вытесненные задачи остаются в backing и загружаются из него при обращении. Нулевая емкость снимает ограничение.
+func WithCapacity(capacity int, backing interfaces.TaskRepository) InMemoryOption {
+	return func(r *InMemoryTaskRepository) {
+		if capacity > 0 && backing != nil {
+			r.capacity = capacity
+			r.backing = backing
+		}
+	}
 }
 
 // NewInMemoryTaskRepository создает новый репозиторий задач в памяти
-func NewInMemoryTaskRepository() interfaces.TaskRepository {
-	return &InMemoryTaskRepository{
-		tasks: make(map[string]*entities.Task),
+func NewInMemoryTaskRepository(opts ...InMemoryOption) interfaces.TaskRepository {
+	r := &InMemoryTaskRepository{
+		tasks:    make(map[string]*entities.Task),
+		recent:   list.New(),
+		elements: make(map[string]*list.Element),
 	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 // Create добавляет новую задачу в репозиторий
@@ -27,33 +58,120 @@ func (r *InMemoryTaskRepository) Create(ctx context.Context, task *entities.Task
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	r.tasks[task.ID.String()] = task
+	if _, exists := r.tasks[task.ID.String()]; exists {
+		return fmt.Errorf("%w: %s", entities.ErrTaskExists, task.ID.String())
+	}
+	r.store(task.Clone())
 	return nil
 }
 
-// GetByID получает задачу по её ID
+// GetByID получает задачу по её ID, при необходимости загружая вытесненную задачу из постоянного хранилища
 func (r *InMemoryTaskRepository) GetByID(ctx context.Context, id string) (*entities.Task, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
 	task, exists := r.tasks[id]
-	if !exists {
-		return nil, fmt.Errorf("задача с id %s не найдена", id)
+	if exists {
+		r.touch(id)
+		return task.Clone(), nil
+	}
+
+	if r.backing == nil {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskNotFound, id)
+	}
+
+	task, err := r.backing.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.store(task)
+	return task.Clone(), nil
+}
+
+// GetByIDs получает задачи по списку ID; задачи, вытесненные из памяти, загружаются из постоянного хранилища
+// одним обращением. Отсутствующие ID пропускаются.
+func (r *InMemoryTaskRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*entities.Task, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tasks := make(map[string]*entities.Task, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if task, exists := r.tasks[id]; exists {
+			r.touch(id)
+			tasks[id] = task.Clone()
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) == 0 || r.backing == nil {
+		return tasks, nil
 	}
 
-	return task, nil
+	stored, err := r.backing.GetByIDs(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for id, task := range stored {
+		r.store(task)
+		tasks[id] = task.Clone()
+	}
+
+	return tasks, nil
 }
 
-// GetAll получает все задачи
+// GetAll получает все задачи, включая вытесненные из памяти
 func (r *InMemoryTaskRepository) GetAll(ctx context.Context) ([]*entities.Task, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	tasks := make([]*entities.Task, 0, len(r.tasks))
 	for _, task := range r.tasks {
-		tasks = append(tasks, task)
+		tasks = append(tasks, task.Clone())
+	}
+
+	if r.backing != nil {
+		stored, err := r.backing.GetAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range stored {
+			if _, exists := r.tasks[task.ID.String()]; !exists {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	return tasks, nil
+}
+
+// GetTasksAfter получает до limit задач, следующих за курсором, в порядке списка фильтра, включая вытесненные из памяти
+func (r *InMemoryTaskRepository) GetTasksAfter(ctx context.Context, after entities.TaskCursor, limit int, filter entities.TaskFilter) ([]*entities.Task, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tasks := collectTasksAfter(r.tasks, after, limit, filter)
+	if r.backing == nil {
+		return tasks, nil
+	}
+
+	// Задачи в памяти берутся из памяти, вытесненные - из постоянного хранилища. Первые limit задач
+	// объединения всегда входят в первые limit задач одного из источников.
+	stored, err := r.backing.GetTasksAfter(ctx, after, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range stored {
+		if _, exists := r.tasks[task.ID.String()]; !exists {
+			tasks = append(tasks, task)
+		}
 	}
 
+	filter.Sort(tasks)
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
 	return tasks, nil
 }
 
@@ -62,11 +180,11 @@ func (r *InMemoryTaskRepository) Update(ctx context.Context, task *entities.Task
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.tasks[task.ID.String()]; !exists {
-		return fmt.Errorf("задача с id %s не найдена", task.ID.String())
+	if !r.exists(ctx, task.ID.String()) {
+		return fmt.Errorf("%w: %s", entities.ErrTaskNotFound, task.ID.String())
 	}
 
-	r.tasks[task.ID.String()] = task
+	r.store(task.Clone())
 	return nil
 }
 
@@ -75,25 +193,138 @@ func (r *InMemoryTaskRepository) Delete(ctx context.Context, id string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.tasks[id]; !exists {
-		return fmt.Errorf("задача с id %s не найдена", id)
+	if !r.exists(ctx, id) {
+		return fmt.Errorf("%w: %s", entities.ErrTaskNotFound, id)
 	}
 
-	delete(r.tasks, id)
+	r.remove(id)
 	return nil
 }
 
-// GetPendingTasks получает все задачи со статусом "new" или "processing"
+// GetFailedFiles возвращает файлы неудаленных задач, завершившиеся ошибкой не раньше since, от самых свежих
+func (r *InMemoryTaskRepository) GetFailedFiles(ctx context.Context, since time.Time, limit int) ([]entities.FailedFile, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.backing == nil {
+		return collectFailedFiles(r.tasks, since, limit), nil
+	}
+
+	// Ошибки задач в памяти берутся из памяти, ошибки вытесненных задач - из постоянного хранилища
+	failed := collectFailedFiles(r.tasks, since, 0)
+	stored, err := r.backing.GetFailedFiles(ctx, since, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range stored {
+		if _, exists := r.tasks[file.TaskID]; !exists {
+			failed = append(failed, file)
+		}
+	}
+
+	entities.SortFailedFiles(failed)
+	if limit > 0 && len(failed) > limit {
+		failed = failed[:limit]
+	}
+	return failed, nil
+}
+
+// GetPendingTasks получает все неудаленные задачи со статусом "new" или "processing", включая задачи
+// постоянного хранилища, которые ещё не загружены в память (например, после перезапуска) или были вытеснены
+// до возврата в очередь. Для задач, находящихся в памяти, используется их состояние в памяти.
 func (r *InMemoryTaskRepository) GetPendingTasks(ctx context.Context) ([]*entities.Task, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	var pendingTasks []*entities.Task
 	for _, task := range r.tasks {
+		if task.IsDeleted() {
+			continue
+		}
 		if task.Status == entities.TaskStatusNew || task.Status == entities.TaskStatusProcessing {
-			pendingTasks = append(pendingTasks, task)
+			pendingTasks = append(pendingTasks, task.Clone())
+		}
+	}
+
+	if r.backing != nil {
+		stored, err := r.backing.GetPendingTasks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range stored {
+			if _, exists := r.tasks[task.ID.String()]; !exists {
+				pendingTasks = append(pendingTasks, task)
+			}
 		}
 	}
 
 	return pendingTasks, nil
 }
+
+// exists проверяет наличие задачи в памяти или в постоянном хранилище (вызывающий должен держать блокировку)
+func (r *InMemoryTaskRepository) exists(ctx context.Context, id string) bool {
+	if _, exists := r.tasks[id]; exists {
+		return true
+	}
+	if r.backing == nil {
+		return false
+	}
+	_, err := r.backing.GetByID(ctx, id)
+	return err == nil
+}
+
+// store сохраняет задачу в памяти и вытесняет лишние задачи (вызывающий должен держать блокировку)
+func (r *InMemoryTaskRepository) store(task *entities.Task) {
+	id := task.ID.String()
+	r.tasks[id] = task
+	r.touch(id)
+	r.evict()
+}
+
+// remove удаляет задачу из памяти (вызывающий должен держать блокировку)
+func (r *InMemoryTaskRepository) remove(id string) {
+	delete(r.tasks, id)
+	if element, ok := r.elements[id]; ok {
+		r.recent.Remove(element)
+		delete(r.elements, id)
+	}
+}
+
+// touch отмечает задачу как недавно использованную (вызывающий должен держать блокировку)
+func (r *InMemoryTaskRepository) touch(id string) {
+	if r.capacity == 0 {
+		return
+	}
+	if element, ok := r.elements[id]; ok {
+		r.recent.MoveToFront(element)
+		return
+	}
+	r.elements[id] = r.recent.PushFront(id)
+}
+
+// evict вытесняет давно не использованные завершенные задачи, пока их число превышает емкость
+// (вызывающий должен держать блокировку)
+func (r *InMemoryTaskRepository) evict() {
+	if r.capacity == 0 {
+		return
+	}
+
+	for element := r.recent.Back(); element != nil && len(r.tasks) > r.capacity; {
+		prev := element.Prev()
+		id := element.Value.(string)
+		if isEvictable(r.tasks[id]) {
+			r.remove(id)
+		}
+		element = prev
+	}
+}
+
+// isEvictable возвращает true для задач с итоговым статусом, которые больше не изменяются воркерами
+func isEvictable(task *entities.Task) bool {
+	switch task.Status {
+	case entities.TaskStatusCompleted, entities.TaskStatusPartial, entities.TaskStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestInMemoryRepositoryIsolatesStoredTasks(t *testing.T) {
+	// Setup
+	repo := NewInMemoryTaskRepository()
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/file1.jpg"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	if err := repo.Create(ctx, task); err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// Execute
+	task.Files[0].Status = "downloading"
+	read, err := repo.GetByID(ctx, task.ID.String())
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	read.Files[0].Status = "completed"
+
+	// Assert
+	stored, _ := repo.GetByID(ctx, task.ID.String())
+	if stored.Files[0].Status != "pending" {
+		t.Errorf("Expected stored status pending, got %s", stored.Files[0].Status)
+	}
+
+	if err := repo.Update(ctx, read); err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	stored, _ = repo.GetByID(ctx, task.ID.String())
+	if stored.Files[0].Status != "completed" {
+		t.Errorf("Expected updated status completed, got %s", stored.Files[0].Status)
+	}
+}
+
+func TestInMemoryRepositoryEvictsFinishedTasks(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	backing := NewFileBasedTaskRepository(filepath.Join(t.TempDir(), "tasks.json"))
+	repo := NewInMemoryTaskRepository(WithCapacity(2, backing))
+	memory := repo.(*InMemoryTaskRepository)
+
+	active := entities.NewTask([]string{"https://example.com/active.bin"}, time.Now())
+	var finished []*entities.Task
+	for i := 0; i < 3; i++ {
+		task := entities.NewTask([]string{"https://example.com/done.bin"}, time.Now())
+		task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+		finished = append(finished, task)
+	}
+
+	// Execute
+	for _, task := range append([]*entities.Task{active}, finished...) {
+		backing.Create(ctx, task)
+		repo.Create(ctx, task)
+	}
+
+	// Assert: the active task stays, only the most recent finished task fits
+	if len(memory.tasks) != 2 {
+		t.Fatalf("Expected 2 tasks in memory, got %d", len(memory.tasks))
+	}
+	if _, ok := memory.tasks[active.ID.String()]; !ok {
+		t.Error("Expected active task to never be evicted")
+	}
+	if _, ok := memory.tasks[finished[0].ID.String()]; ok {
+		t.Error("Expected least recently used finished task to be evicted")
+	}
+
+	reloaded, err := repo.GetByID(ctx, finished[0].ID.String())
+	if err != nil {
+		t.Fatalf("Expected evicted task to be reloaded, got %v", err)
+	}
+	if reloaded.Status != entities.TaskStatusCompleted {
+		t.Errorf("Expected reloaded status completed, got %s", reloaded.Status)
+	}
+	if _, ok := memory.tasks[finished[0].ID.String()]; !ok {
+		t.Error("Expected reloaded task to be cached again")
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("Expected GetAll to include evicted tasks, got %d", len(all))
+	}
+}
+
+func TestInMemoryRepositoryGetByIDsLoadsEvictedTasks(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	backing := NewFileBasedTaskRepository(filepath.Join(t.TempDir(), "tasks.json"))
+	repo := NewInMemoryTaskRepository(WithCapacity(1, backing))
+
+	var finished []*entities.Task
+	for i := 0; i < 3; i++ {
+		task := entities.NewTask([]string{"https://example.com/done.bin"}, time.Now())
+		task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+		backing.Create(ctx, task)
+		repo.Create(ctx, task)
+		finished = append(finished, task)
+	}
+	ids := []string{finished[0].ID.String(), "missing", finished[2].ID.String()}
+
+	// Execute
+	tasks, err := repo.GetByIDs(ctx, ids)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 found tasks, got %d", len(tasks))
+	}
+	for _, id := range []string{ids[0], ids[2]} {
+		if task, ok := tasks[id]; !ok || task.Status != entities.TaskStatusCompleted {
+			t.Errorf("Expected completed task %s, got %+v", id, task)
+		}
+	}
+	if _, ok := tasks["missing"]; ok {
+		t.Error("Expected missing ID to be absent from the result")
+	}
+}
+
+func TestInMemoryRepositoryGetPendingTasksIncludesStoredTasks(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	backing := NewFileBasedTaskRepository(filepath.Join(t.TempDir(), "tasks.json"))
+	repo := NewInMemoryTaskRepository(WithCapacity(1, backing))
+
+	cached := entities.NewTask([]string{"https://example.com/cached.bin"}, time.Now())
+	backing.Create(ctx, cached)
+	repo.Create(ctx, cached)
+	cached.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	repo.Update(ctx, cached)
+
+	// The task is pending only in the state file, e.g. after a restart
+	stored := entities.NewTask([]string{"https://example.com/stored.bin"}, time.Now())
+	backing.Create(ctx, stored)
+
+	// Execute
+	tasks, err := repo.GetPendingTasks(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	statuses := make(map[string]entities.TaskStatus, len(tasks))
+	for _, task := range tasks {
+		statuses[task.ID.String()] = task.Status
+	}
+	if len(tasks) != 2 || statuses[stored.ID.String()] != entities.TaskStatusNew {
+		t.Fatalf("Expected the cached and the stored pending task, got %v", statuses)
+	}
+	if statuses[cached.ID.String()] != entities.TaskStatusProcessing {
+		t.Errorf("Expected the in-memory state of the cached task, got %s", statuses[cached.ID.String()])
+	}
+}
+
+func TestInMemoryRepositoryGetTasksAfterMergesEvictedTasks(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	backing := NewFileBasedTaskRepository(filepath.Join(t.TempDir(), "tasks.json"))
+	repo := NewInMemoryTaskRepository(WithCapacity(2, backing))
+
+	created := time.Now()
+	var tasks []*entities.Task
+	for i := 0; i < 5; i++ {
+		task := entities.NewTask([]string{"https://example.com/done.bin"}, created.Add(time.Duration(i)*time.Second))
+		task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+		backing.Create(ctx, task)
+		repo.Create(ctx, task)
+		tasks = append(tasks, task)
+	}
+
+	// Execute
+	page, err := repo.GetTasksAfter(ctx, entities.CursorAfter(tasks[0]), 3, entities.TaskFilter{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page) != 3 {
+		t.Fatalf("Expected 3 tasks, got %d", len(page))
+	}
+	for i, task := range page {
+		if task.ID != tasks[i+1].ID {
+			t.Errorf("Expected task %d to be %s, got %s", i, tasks[i+1].ID, task.ID)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// FileBasedMaintenanceRepository сохраняет состояние режима обслуживания в JSON-файл,
+// чтобы режим, включенный перед развертыванием, сохранялся после перезапуска
+type FileBasedMaintenanceRepository struct {
+	filePath string
+}
+
+// NewFileBasedMaintenanceRepository создает хранилище состояния режима обслуживания в файле filePath
+func NewFileBasedMaintenanceRepository(filePath string) interfaces.MaintenanceRepository {
+	return &FileBasedMaintenanceRepository{filePath: filePath}
+}
+
+// LoadMaintenance читает состояние из файла; отсутствующий файл означает выключенный режим
+func (r *FileBasedMaintenanceRepository) LoadMaintenance() (entities.Maintenance, error) {
+	var state entities.Maintenance
+	data, err := ioutil.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("не удалось прочитать файл режима обслуживания: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return state, fmt.Errorf("не удалось распарсить файл режима обслуживания: %w", err)
+		}
+	}
+	return state, nil
+}
+
+// SaveMaintenance записывает состояние в файл через временный файл
+func (r *FileBasedMaintenanceRepository) SaveMaintenance(state entities.Maintenance) error {
+	if err := os.MkdirAll(filepath.Dir(r.filePath), 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось маршалить JSON: %w", err)
+	}
+
+	tmpPath := r.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось записать файл режима обслуживания: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось записать файл режима обслуживания: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"file-downloader/internal/entities"
+)
+
+// collectTasksAfter отбирает до limit задач, следующих за курсором, в порядке списка фильтра и копирует только их
+// (вызывающий должен держать блокировку). limit <= 0 снимает ограничение.
+func collectTasksAfter(tasks map[string]*entities.Task, after entities.TaskCursor, limit int, filter entities.TaskFilter) []*entities.Task {
+	selected := []*entities.Task{}
+	for _, task := range tasks {
+		if task.IsDeleted() && !filter.IncludeDeleted {
+			continue
+		}
+		if filter.Follows(after, task) {
+			selected = append(selected, task)
+		}
+	}
+
+	filter.Sort(selected)
+	if limit > 0 && len(selected) > limit {
+		selected = selected[:limit]
+	}
+	for i, task := range selected {
+		selected[i] = task.Clone()
+	}
+	return selected
+}
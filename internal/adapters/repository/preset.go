@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// FileBasedPresetRepository хранит пресеты параметров задач в памяти и сохраняет их в JSON-файл после каждого изменения
+type FileBasedPresetRepository struct {
+	filePath string
+	presets  map[string]*entities.Preset
+	mutex    sync.RWMutex
+}
+
+// NewFileBasedPresetRepository создает хранилище пресетов в файле filePath
+func NewFileBasedPresetRepository(filePath string) interfaces.PresetRepository {
+	return &FileBasedPresetRepository{
+		filePath: filePath,
+		presets:  make(map[string]*entities.Preset),
+	}
+}
+
+// LoadPresets загружает пресеты из файла; отсутствующий файл означает пустой список
+func (r *FileBasedPresetRepository) LoadPresets() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	data, err := ioutil.ReadFile(r.filePath)
+	if os.IsNotExist(err) {
+		r.presets = make(map[string]*entities.Preset)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать файл пресетов: %w", err)
+	}
+
+	presets := make(map[string]*entities.Preset)
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &presets); err != nil {
+			return fmt.Errorf("не удалось распарсить файл пресетов: %w", err)
+		}
+	}
+
+	r.presets = presets
+	return nil
+}
+
+// Save создает пресет или заменяет пресет с тем же именем
+func (r *FileBasedPresetRepository) Save(ctx context.Context, preset *entities.Preset) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, existed := r.presets[preset.Name]
+	r.presets[preset.Name] = preset.Clone()
+	if err := r.saveUnsafe(); err != nil {
+		// Неудачная запись не должна менять состояние в памяти
+		if existed {
+			r.presets[preset.Name] = previous
+		} else {
+			delete(r.presets, preset.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get получает пресет по имени
+func (r *FileBasedPresetRepository) Get(ctx context.Context, name string) (*entities.Preset, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	preset, exists := r.presets[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", entities.ErrPresetNotFound, name)
+	}
+	return preset.Clone(), nil
+}
+
+// List возвращает пресеты, упорядоченные по имени
+func (r *FileBasedPresetRepository) List(ctx context.Context) ([]*entities.Preset, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	presets := make([]*entities.Preset, 0, len(r.presets))
+	for _, preset := range r.presets {
+		presets = append(presets, preset.Clone())
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets, nil
+}
+
+// Delete удаляет пресет по имени
+func (r *FileBasedPresetRepository) Delete(ctx context.Context, name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	preset, exists := r.presets[name]
+	if !exists {
+		return fmt.Errorf("%w: %s", entities.ErrPresetNotFound, name)
+	}
+	delete(r.presets, name)
+	if err := r.saveUnsafe(); err != nil {
+		r.presets[name] = preset
+		return err
+	}
+	return nil
+}
+
+// saveUnsafe записывает пресеты в файл через временный файл (вызывающий должен держать блокировку на запись)
+func (r *FileBasedPresetRepository) saveUnsafe() error {
+	if err := os.MkdirAll(filepath.Dir(r.filePath), 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию: %w", err)
+	}
+
+	data, err := json.MarshalIndent(r.presets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось маршалить JSON: %w", err)
+	}
+
+	tmpPath := r.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось записать файл пресетов: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("не удалось записать файл пресетов: %w", err)
+	}
+	return nil
+}
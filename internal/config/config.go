@@ -0,0 +1,566 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"file-downloader/internal/infrastructure"
+	"file-downloader/internal/usecases"
+)
+
+// Config содержит параметры запуска сервиса
+type Config struct {
+	ServerAddr      string `yaml:"server_addr"`
+	APIKey          string `yaml:"api_key"` // ключ доступа к административному API
+	WebUI           bool   `yaml:"web_ui"`  // отдавать веб-интерфейс на /
+	WorkerCount     int    `yaml:"worker_count"`
+	StateFile       string `yaml:"state_file"`
+	StateCompress   bool   `yaml:"state_compress"`   // gzip-сжатие файла состояния (к пути добавляется .gz)
+	PresetsFile     string `yaml:"presets_file"`     // файл пресетов параметров задач
+	MaintenanceFile string `yaml:"maintenance_file"` // файл состояния режима обслуживания (пусто - не сохраняется)
+	DownloadDir     string `yaml:"download_dir"`
+	Layout          string `yaml:"download_layout"`
+	FileNaming      string `yaml:"file_naming"`            // различение совпадающих имен: index, numeric, hash или host
+	DirectoryIndex  string `yaml:"directory_index_name"`   // имя файла для URL директории с HTML-ответом (пусто - по сегменту пути)
+	ExistingFiles   string `yaml:"existing_file_policy"`   // overwrite, skip или error
+	ImportConflicts string `yaml:"import_conflict_policy"` // задачи с совпадающими ID при импорте: overwrite, skip или error
+
+	MaxRequestTimeout     time.Duration `yaml:"max_request_timeout"`     // верхняя граница X-Request-Timeout (0 - заголовок не учитывается)
+	APISizeStrings        bool          `yaml:"api_size_strings"`        // добавлять к файлам в ответах API размер строкой size_str
+	ShutdownTimeout       time.Duration `yaml:"shutdown_timeout"`        // время на остановку воркеров после сигнала
+	ShutdownDrain         bool          `yaml:"shutdown_drain"`          // дорабатывать принятые задачи при остановке в пределах ShutdownTimeout
+	ServerShutdownTimeout time.Duration `yaml:"server_shutdown_timeout"` // время на завершение HTTP-запросов после остановки воркеров
+	ShutdownReportFile    string        `yaml:"shutdown_report_file"`    // файл отчета о незавершенной при остановке работе (пусто - только журнал)
+	MaxInFlightRequests   int           `yaml:"max_in_flight_requests"`  // одновременно обрабатываемые изменяющие запросы (0 - без ограничения)
+	InFlightRetryAfter    time.Duration `yaml:"in_flight_retry_after"`   // Retry-After ответа 503 при превышении MaxInFlightRequests
+
+	StateWriteBehind    bool          `yaml:"state_write_behind"`    // при ошибке записи состояния повторять её в фоне
+	StateRetryInterval  time.Duration `yaml:"state_retry_interval"`  // интервал повторной записи состояния
+	StateFilesThreshold int           `yaml:"state_files_threshold"` // файлы задач с не меньшим числом файлов хранятся отдельно (0 - в файле состояния)
+
+	MemoryTaskLimit int `yaml:"memory_task_limit"` // максимум задач в памяти (0 - без ограничения)
+	MaxActiveTasks  int `yaml:"max_active_tasks"`  // максимум незавершенных задач (0 - без ограничения)
+
+	ContentTaskIDs  bool   `yaml:"content_task_ids"`  // ID задачи вычисляется по набору её файлов (UUIDv5) вместо случайного
+	TaskIDNamespace string `yaml:"task_id_namespace"` // пространство имен UUIDv5 для ID по содержимому (пусто - встроенное)
+
+	MaxURLLength         int   `yaml:"max_url_length"`          // максимальная длина URL файла в байтах
+	MaxInlineContentSize int64 `yaml:"max_inline_content_size"` // максимальный размер встроенного содержимого задачи в байтах
+
+	MaxManifestSize int64         `yaml:"max_manifest_size"` // максимальный размер манифеста manifest_url в байтах
+	ManifestTimeout time.Duration `yaml:"manifest_timeout"`  // время загрузки манифеста
+
+	UserAgent      string            `yaml:"user_agent"`
+	DefaultHeaders map[string]string `yaml:"default_headers"`
+	LogHTTPHeaders bool              `yaml:"debug_http_headers"` // писать в журнал заголовки запросов и ответов скачивания
+	HTTPProtocol   string            `yaml:"http_protocol"`      // auto (HTTP/2 при согласовании) или http1
+
+	AllowedHosts []string `yaml:"allowed_hosts"`
+	DeniedHosts  []string `yaml:"denied_hosts"`
+
+	URLSigningHosts          []string      `yaml:"url_signing_hosts"`           // хосты, запросы к которым подписываются
+	URLSigningSecret         string        `yaml:"url_signing_secret"`          // ключ HMAC-SHA256 подписи
+	URLSigningTTL            time.Duration `yaml:"url_signing_ttl"`             // срок действия подписи
+	URLSigningSignatureParam string        `yaml:"url_signing_signature_param"` // имя параметра подписи
+	URLSigningExpiresParam   string        `yaml:"url_signing_expires_param"`   // имя параметра срока действия
+
+	SSRFProtection      bool     `yaml:"ssrf_protection"`       // запрет соединений с внутренними адресами
+	SSRFAllowedNetworks []string `yaml:"ssrf_allowed_networks"` // диапазоны CIDR, разрешенные несмотря на защиту
+
+	FetchTimeout              time.Duration `yaml:"fetch_timeout"` // таймаут подключения FTP/SFTP
+	SFTPKnownHosts            string        `yaml:"sftp_known_hosts"`
+	SFTPPrivateKey            string        `yaml:"sftp_private_key"`
+	SFTPInsecureIgnoreHostKey bool          `yaml:"sftp_insecure_ignore_host_key"` // SFTP без проверки ключа хоста, если known_hosts не задан
+
+	HTTPConnectTimeout        time.Duration `yaml:"http_connect_timeout"`
+	HTTPTLSHandshakeTimeout   time.Duration `yaml:"http_tls_handshake_timeout"`
+	HTTPResponseHeaderTimeout time.Duration `yaml:"http_response_header_timeout"`
+	HTTPFirstByteTimeout      time.Duration `yaml:"http_first_byte_timeout"`   // ожидание первых байт тела после заголовков
+	HTTPIdleTimeout           time.Duration `yaml:"http_idle_timeout"`         // максимальный простой при чтении тела ответа
+	HTTPTimeout               time.Duration `yaml:"http_timeout"`              // общее время HTTP-запроса (0 - без ограничения)
+	HTTPRedirectHopTimeout    time.Duration `yaml:"http_redirect_hop_timeout"` // ожидание ответа на каждый запрос цепочки перенаправлений
+	HTTPRedirectTimeout       time.Duration `yaml:"http_redirect_timeout"`     // ожидание окончательного ответа, если запрос перенаправлен
+
+	RetryMaxAttempts int           `yaml:"retry_max_attempts"`
+	RetryBackoff     time.Duration `yaml:"retry_backoff"`
+	RetryMaxBackoff  time.Duration `yaml:"retry_max_backoff"`
+	RetryOn          []string      `yaml:"retry_on"` // категории ошибок для повтора: 5xx, 4xx, timeout, connection (пусто - любые)
+
+	SlowDownloadMinSpeed int64         `yaml:"slow_download_min_speed"` // минимальная скорость скачивания, байт/с (0 - не отслеживается)
+	SlowDownloadWindow   time.Duration `yaml:"slow_download_window"`    // окно усреднения скорости
+	SlowDownloadAction   string        `yaml:"slow_download_action"`    // flag или retry
+
+	RetryAlertWindow    time.Duration `yaml:"retry_alert_window"`    // окно подсчета повторных попыток
+	RetryAlertThreshold int           `yaml:"retry_alert_threshold"` // число повторов за окно для оповещения (0 - отключено)
+	RetryAlertWebhook   string        `yaml:"retry_alert_webhook"`   // URL для POST-оповещений о всплеске повторов
+
+	CallbackMaxAttempts  int           `yaml:"callback_max_attempts"` // попыток доставки уведомления о завершении задачи
+	CallbackBackoff      time.Duration `yaml:"callback_backoff"`
+	CallbackMaxBackoff   time.Duration `yaml:"callback_max_backoff"`
+	CallbackTimeout      time.Duration `yaml:"callback_timeout"`       // время одной попытки доставки
+	CallbackPollInterval time.Duration `yaml:"callback_poll_interval"` // интервал проверки очереди уведомлений
+
+	PageSize    int `yaml:"tasks_page_size"`
+	MaxPageSize int `yaml:"tasks_max_page_size"`
+
+	ProgressInterval time.Duration `yaml:"progress_persist_interval"`
+	ProgressMinBytes int64         `yaml:"progress_persist_min_bytes"`
+
+	SegmentCount   int   `yaml:"segment_count"`
+	SegmentMinSize int64 `yaml:"segment_min_size"`
+
+	FileConcurrency  int    `yaml:"file_concurrency"`  // число одновременно скачиваемых файлов задачи
+	FairFileSlots    int    `yaml:"fair_file_slots"`   // общее число одновременно скачиваемых файлов всех задач (AutoFairFileSlots - см. FileSlots, 0 - без ограничения)
+	FailureThreshold string `yaml:"failure_threshold"` // порог неудачных файлов задачи: "3" или "50%" (пусто - без порога)
+
+	ResourceMemoryBudget int64 `yaml:"resource_memory_budget"` // память буферов всех скачиваемых файлов в байтах (0 - без ограничения)
+	ResourceFDBudget     int   `yaml:"resource_fd_budget"`     // соединения и открытые файлы всех скачиваемых файлов (0 - без ограничения)
+
+	RequestRateLimit    int           `yaml:"request_rate_limit"`    // HTTP(S)-запросов скачивания за интервал на весь сервис (0 - без ограничения)
+	RequestRateInterval time.Duration `yaml:"request_rate_interval"` // интервал ограничения частоты запросов
+	RequestRateBurst    int           `yaml:"request_rate_burst"`    // запросов подряд без ожидания (0 - request_rate_limit)
+
+	CopyBufferSize int `yaml:"copy_buffer_size"` // размер буфера копирования данных при скачивании
+
+	MinFileSize int64 `yaml:"min_file_size"` // минимальный размер успешно скачанного файла в байтах (0 - без проверки)
+
+	ExtractMaxSize  int64 `yaml:"extract_max_size"`  // суммарный размер файлов, распаковываемых из одного архива
+	ExtractMaxFiles int   `yaml:"extract_max_files"` // число файлов, распаковываемых из одного архива
+
+	TreeMaxDepth   int `yaml:"tree_max_depth"`   // глубина дерева файлов задачи в /tasks/{id}/tree
+	TreeMaxEntries int `yaml:"tree_max_entries"` // число элементов дерева файлов задачи
+
+	DiskQuota        int64 `yaml:"disk_quota"`         // предел байт файлов одной задачи на диске (0 - без ограничения)
+	DiskQuotaCleanup bool  `yaml:"disk_quota_cleanup"` // при превышении квоты удалять и уже скачанные файлы задачи
+
+	MinFreeDisk       int64         `yaml:"min_free_disk"`       // минимум свободных байт на диске скачивания (0 - без проверки)
+	DiskCheckInterval time.Duration `yaml:"disk_check_interval"` // интервал проверки свободного места
+	DiskLowPausePool  bool          `yaml:"disk_low_pause_pool"` // при нехватке места не раздавать воркерам и новые задачи
+
+	Deduplication bool `yaml:"deduplication"`  // замена файлов с одинаковым содержимым жесткими ссылками
+	CompressFiles bool `yaml:"compress_files"` // gzip-сжатие скачанных файлов на диске (к имени добавляется .gz)
+
+	OutputDirRoots []string `yaml:"output_dir_roots"` // разрешенные базовые директории для output_dir задач
+
+	PollInterval      time.Duration `yaml:"poll_interval"`
+	RestartRampWindow time.Duration `yaml:"restart_ramp_window"`
+	RestartRampJitter time.Duration `yaml:"restart_ramp_jitter"`
+
+	QueueCapacity    int           `yaml:"queue_capacity"`     // размер очереди пула воркеров
+	QueueWaitTimeout time.Duration `yaml:"queue_wait_timeout"` // ожидание места в переполненной очереди (0 - не ждать)
+	MaxGoroutines    int           `yaml:"max_goroutines"`     // предел горутин, у которого приостанавливается раздача задач (0 - без ограничения)
+
+	CircuitBreakerThreshold int           `yaml:"circuit_breaker_threshold"`
+	CircuitBreakerWindow    time.Duration `yaml:"circuit_breaker_window"`
+	CircuitBreakerCooldown  time.Duration `yaml:"circuit_breaker_cooldown"`
+
+	TrashRetention  time.Duration `yaml:"trash_retention"` // срок хранения удаленных задач в корзине (0 отключает очистку)
+	TrashGCInterval time.Duration `yaml:"trash_gc_interval"`
+
+	TaskTTL            time.Duration `yaml:"task_ttl"` // срок хранения задач без ttl в запросе (0 - бессрочно)
+	TaskExpiryInterval time.Duration `yaml:"task_expiry_interval"`
+
+	DeadLetterFile     string        `yaml:"dead_letter_file"`     // хранилище окончательно неудачных задач (пусто - не переносятся)
+	DeadLetterAfter    time.Duration `yaml:"dead_letter_after"`    // время после завершения задачи ошибкой до переноса
+	DeadLetterInterval time.Duration `yaml:"dead_letter_interval"` // интервал переноса неудачных задач
+
+	CompactionInterval  time.Duration `yaml:"compaction_interval"`  // интервал сжатия файла состояния (0 - только вручную)
+	CompactionRetention time.Duration `yaml:"compaction_retention"` // срок хранения завершенных задач при сжатии (0 - бессрочно)
+
+	OrphanCleanup         string        `yaml:"orphan_cleanup"`          // off, quarantine или remove
+	OrphanCleanupInterval time.Duration `yaml:"orphan_cleanup_interval"` // интервал поиска осиротевших директорий
+	OrphanGracePeriod     time.Duration `yaml:"orphan_grace_period"`     // директории, изменявшиеся позже, не затрагиваются
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() Config {
+	return Config{
+		ServerAddr:     ":8080",
+		WebUI:          true,
+		WorkerCount:    3,
+		StateFile:      "./data/tasks.json",
+		PresetsFile:    "./data/presets.json",
+		DownloadDir:    "./downloads",
+		Layout:         "by-task",
+		FileNaming:     "index",
+		DirectoryIndex: "index.html",
+
+		ShutdownTimeout:       30 * time.Second,
+		ServerShutdownTimeout: 10 * time.Second,
+		InFlightRetryAfter:    time.Second,
+
+		ExistingFiles:   "overwrite",
+		ImportConflicts: "overwrite",
+
+		MaxRequestTimeout: time.Minute,
+		APISizeStrings:    true,
+
+		StateRetryInterval:  5 * time.Second,
+		StateFilesThreshold: 1000,
+
+		MaxURLLength:         8192,
+		MaxInlineContentSize: 1 << 20,
+
+		MaxManifestSize: usecases.DefaultMaxManifestSize,
+		ManifestTimeout: infrastructure.DefaultManifestTimeout,
+
+		UserAgent:    "file-downloader/1.0",
+		HTTPProtocol: "auto",
+
+		URLSigningTTL:            15 * time.Minute,
+		URLSigningSignatureParam: "signature",
+		URLSigningExpiresParam:   "expires",
+
+		SSRFProtection: true,
+
+		FetchTimeout: 30 * time.Second,
+
+		HTTPConnectTimeout:        10 * time.Second,
+		HTTPTLSHandshakeTimeout:   10 * time.Second,
+		HTTPResponseHeaderTimeout: 30 * time.Second,
+		HTTPFirstByteTimeout:      30 * time.Second,
+		HTTPIdleTimeout:           time.Minute,
+		HTTPRedirectHopTimeout:    time.Minute,
+		HTTPRedirectTimeout:       2 * time.Minute,
+
+		RequestRateInterval: time.Minute,
+
+		RetryMaxAttempts: 3,
+		RetryBackoff:     time.Second,
+		RetryMaxBackoff:  30 * time.Second,
+
+		SlowDownloadWindow: 30 * time.Second,
+		SlowDownloadAction: "flag",
+
+		RetryAlertWindow: time.Minute,
+
+		CallbackMaxAttempts:  10,
+		CallbackBackoff:      10 * time.Second,
+		CallbackMaxBackoff:   10 * time.Minute,
+		CallbackTimeout:      10 * time.Second,
+		CallbackPollInterval: 5 * time.Second,
+
+		PageSize:    100,
+		MaxPageSize: 1000,
+
+		ProgressInterval: 5 * time.Second,
+		ProgressMinBytes: 1 << 20,
+
+		SegmentCount:   1,
+		SegmentMinSize: 64 << 20,
+
+		FileConcurrency: 1,
+		FairFileSlots:   AutoFairFileSlots,
+
+		CopyBufferSize: 256 << 10,
+
+		ExtractMaxSize:  1 << 30,
+		ExtractMaxFiles: 10000,
+
+		TreeMaxDepth:   16,
+		TreeMaxEntries: 10000,
+
+		DiskCheckInterval: 10 * time.Second,
+
+		PollInterval:      2 * time.Second,
+		RestartRampWindow: 10 * time.Second,
+		RestartRampJitter: 500 * time.Millisecond,
+
+		QueueCapacity: 100,
+
+		CircuitBreakerThreshold: 5,
+		CircuitBreakerWindow:    time.Minute,
+		CircuitBreakerCooldown:  30 * time.Second,
+
+		TrashRetention:  7 * 24 * time.Hour,
+		TrashGCInterval: time.Hour,
+
+		TaskExpiryInterval: time.Minute,
+		DeadLetterAfter:    time.Hour,
+		DeadLetterInterval: time.Minute,
+
+		CompactionInterval: 24 * time.Hour,
+
+		OrphanCleanup:         "off",
+		OrphanCleanupInterval: time.Hour,
+		OrphanGracePeriod:     time.Hour,
+	}
+}
+
+// FromEnv возвращает конфигурацию по умолчанию, переопределенную переменными окружения
+func FromEnv() Config {
+	cfg := Default()
+	applyEnv(&cfg)
+	return cfg
+}
+
+// applyEnv переопределяет параметры конфигурации заданными переменными окружения
+func applyEnv(cfg *Config) {
+	cfg.ServerAddr = getString("SERVER_ADDR", cfg.ServerAddr)
+	cfg.APIKey = getString("API_KEY", cfg.APIKey)
+	cfg.WebUI = getBool("WEB_UI", cfg.WebUI)
+	cfg.WorkerCount = getInt("WORKER_COUNT", cfg.WorkerCount)
+	cfg.ShutdownTimeout = getDuration("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	cfg.ShutdownDrain = getBool("SHUTDOWN_DRAIN", cfg.ShutdownDrain)
+	cfg.ServerShutdownTimeout = getDuration("SERVER_SHUTDOWN_TIMEOUT", cfg.ServerShutdownTimeout)
+	cfg.ShutdownReportFile = getString("SHUTDOWN_REPORT_FILE", cfg.ShutdownReportFile)
+	cfg.MaxInFlightRequests = getInt("MAX_IN_FLIGHT_REQUESTS", cfg.MaxInFlightRequests)
+	cfg.InFlightRetryAfter = getDuration("IN_FLIGHT_RETRY_AFTER", cfg.InFlightRetryAfter)
+	cfg.StateFile = getString("STATE_FILE", cfg.StateFile)
+	cfg.StateCompress = getBool("STATE_COMPRESS", cfg.StateCompress)
+	cfg.PresetsFile = getString("PRESETS_FILE", cfg.PresetsFile)
+	cfg.MaintenanceFile = getString("MAINTENANCE_FILE", cfg.MaintenanceFile)
+	cfg.DownloadDir = getString("DOWNLOAD_DIR", cfg.DownloadDir)
+	cfg.Layout = getString("DOWNLOAD_LAYOUT", cfg.Layout)
+	cfg.FileNaming = getString("FILE_NAMING", cfg.FileNaming)
+	cfg.DirectoryIndex = getString("DIRECTORY_INDEX_NAME", cfg.DirectoryIndex)
+	cfg.OutputDirRoots = getList("OUTPUT_DIR_ROOTS", cfg.OutputDirRoots)
+	cfg.ExistingFiles = getString("EXISTING_FILE_POLICY", cfg.ExistingFiles)
+	cfg.ImportConflicts = getString("IMPORT_CONFLICT_POLICY", cfg.ImportConflicts)
+
+	cfg.MaxRequestTimeout = getDuration("MAX_REQUEST_TIMEOUT", cfg.MaxRequestTimeout)
+	cfg.APISizeStrings = getBool("API_SIZE_STRINGS", cfg.APISizeStrings)
+
+	cfg.StateWriteBehind = getBool("STATE_WRITE_BEHIND", cfg.StateWriteBehind)
+	cfg.StateRetryInterval = getDuration("STATE_RETRY_INTERVAL", cfg.StateRetryInterval)
+	cfg.StateFilesThreshold = getInt("STATE_FILES_THRESHOLD", cfg.StateFilesThreshold)
+
+	cfg.MemoryTaskLimit = getInt("MEMORY_TASK_LIMIT", cfg.MemoryTaskLimit)
+	cfg.MaxActiveTasks = getInt("MAX_ACTIVE_TASKS", cfg.MaxActiveTasks)
+	cfg.ContentTaskIDs = getBool("CONTENT_TASK_IDS", cfg.ContentTaskIDs)
+	cfg.TaskIDNamespace = getString("TASK_ID_NAMESPACE", cfg.TaskIDNamespace)
+
+	cfg.MaxURLLength = getInt("MAX_URL_LENGTH", cfg.MaxURLLength)
+	cfg.MaxInlineContentSize = int64(getInt("MAX_INLINE_CONTENT_SIZE", int(cfg.MaxInlineContentSize)))
+	cfg.MaxManifestSize = int64(getInt("MAX_MANIFEST_SIZE", int(cfg.MaxManifestSize)))
+	cfg.ManifestTimeout = getDuration("MANIFEST_TIMEOUT", cfg.ManifestTimeout)
+
+	cfg.UserAgent = getString("USER_AGENT", cfg.UserAgent)
+	cfg.DefaultHeaders = getHeaders("DEFAULT_HEADERS", cfg.DefaultHeaders)
+	cfg.LogHTTPHeaders = getBool("DEBUG_HTTP_HEADERS", cfg.LogHTTPHeaders)
+	cfg.HTTPProtocol = getString("HTTP_PROTOCOL", cfg.HTTPProtocol)
+
+	cfg.AllowedHosts = getList("ALLOWED_HOSTS", cfg.AllowedHosts)
+	cfg.DeniedHosts = getList("DENIED_HOSTS", cfg.DeniedHosts)
+
+	cfg.SSRFProtection = getBool("SSRF_PROTECTION", cfg.SSRFProtection)
+	cfg.SSRFAllowedNetworks = getList("SSRF_ALLOWED_NETWORKS", cfg.SSRFAllowedNetworks)
+
+	cfg.URLSigningHosts = getList("URL_SIGNING_HOSTS", cfg.URLSigningHosts)
+	cfg.URLSigningSecret = getString("URL_SIGNING_SECRET", cfg.URLSigningSecret)
+	cfg.URLSigningTTL = getDuration("URL_SIGNING_TTL", cfg.URLSigningTTL)
+	cfg.URLSigningSignatureParam = getString("URL_SIGNING_SIGNATURE_PARAM", cfg.URLSigningSignatureParam)
+	cfg.URLSigningExpiresParam = getString("URL_SIGNING_EXPIRES_PARAM", cfg.URLSigningExpiresParam)
+
+	cfg.FetchTimeout = getDuration("FETCH_TIMEOUT", cfg.FetchTimeout)
+	cfg.HTTPConnectTimeout = getDuration("HTTP_CONNECT_TIMEOUT", cfg.HTTPConnectTimeout)
+	cfg.HTTPTLSHandshakeTimeout = getDuration("HTTP_TLS_HANDSHAKE_TIMEOUT", cfg.HTTPTLSHandshakeTimeout)
+	cfg.HTTPResponseHeaderTimeout = getDuration("HTTP_RESPONSE_HEADER_TIMEOUT", cfg.HTTPResponseHeaderTimeout)
+	cfg.HTTPFirstByteTimeout = getDuration("HTTP_FIRST_BYTE_TIMEOUT", cfg.HTTPFirstByteTimeout)
+	cfg.HTTPIdleTimeout = getDuration("HTTP_IDLE_TIMEOUT", cfg.HTTPIdleTimeout)
+	cfg.HTTPTimeout = getDuration("HTTP_TIMEOUT", cfg.HTTPTimeout)
+	cfg.HTTPRedirectHopTimeout = getDuration("HTTP_REDIRECT_HOP_TIMEOUT", cfg.HTTPRedirectHopTimeout)
+	cfg.HTTPRedirectTimeout = getDuration("HTTP_REDIRECT_TIMEOUT", cfg.HTTPRedirectTimeout)
+	cfg.SFTPKnownHosts = getString("SFTP_KNOWN_HOSTS", cfg.SFTPKnownHosts)
+	cfg.SFTPPrivateKey = getString("SFTP_PRIVATE_KEY", cfg.SFTPPrivateKey)
+	cfg.SFTPInsecureIgnoreHostKey = getBool("SFTP_INSECURE_IGNORE_HOST_KEY", cfg.SFTPInsecureIgnoreHostKey)
+
+	cfg.RetryMaxAttempts = getInt("RETRY_MAX_ATTEMPTS", cfg.RetryMaxAttempts)
+	cfg.RetryBackoff = getDuration("RETRY_BACKOFF", cfg.RetryBackoff)
+	cfg.RetryMaxBackoff = getDuration("RETRY_MAX_BACKOFF", cfg.RetryMaxBackoff)
+	cfg.RetryOn = getList("RETRY_ON", cfg.RetryOn)
+
+	cfg.SlowDownloadMinSpeed = int64(getInt("SLOW_DOWNLOAD_MIN_SPEED", int(cfg.SlowDownloadMinSpeed)))
+	cfg.SlowDownloadWindow = getDuration("SLOW_DOWNLOAD_WINDOW", cfg.SlowDownloadWindow)
+	cfg.SlowDownloadAction = getString("SLOW_DOWNLOAD_ACTION", cfg.SlowDownloadAction)
+	cfg.RetryAlertWindow = getDuration("RETRY_ALERT_WINDOW", cfg.RetryAlertWindow)
+	cfg.RetryAlertThreshold = getInt("RETRY_ALERT_THRESHOLD", cfg.RetryAlertThreshold)
+	cfg.RetryAlertWebhook = getString("RETRY_ALERT_WEBHOOK", cfg.RetryAlertWebhook)
+	cfg.CallbackMaxAttempts = getInt("CALLBACK_MAX_ATTEMPTS", cfg.CallbackMaxAttempts)
+	cfg.CallbackBackoff = getDuration("CALLBACK_BACKOFF", cfg.CallbackBackoff)
+	cfg.CallbackMaxBackoff = getDuration("CALLBACK_MAX_BACKOFF", cfg.CallbackMaxBackoff)
+	cfg.CallbackTimeout = getDuration("CALLBACK_TIMEOUT", cfg.CallbackTimeout)
+	cfg.CallbackPollInterval = getDuration("CALLBACK_POLL_INTERVAL", cfg.CallbackPollInterval)
+
+	cfg.PageSize = getInt("TASKS_PAGE_SIZE", cfg.PageSize)
+	cfg.MaxPageSize = getInt("TASKS_MAX_PAGE_SIZE", cfg.MaxPageSize)
+
+	cfg.ProgressInterval = getDuration("PROGRESS_PERSIST_INTERVAL", cfg.ProgressInterval)
+	cfg.ProgressMinBytes = int64(getInt("PROGRESS_PERSIST_MIN_BYTES", int(cfg.ProgressMinBytes)))
+
+	cfg.SegmentCount = getInt("SEGMENT_COUNT", cfg.SegmentCount)
+	cfg.SegmentMinSize = int64(getInt("SEGMENT_MIN_SIZE", int(cfg.SegmentMinSize)))
+
+	cfg.FileConcurrency = getInt("FILE_CONCURRENCY", cfg.FileConcurrency)
+	cfg.FairFileSlots = getInt("FAIR_FILE_SLOTS", cfg.FairFileSlots)
+	cfg.ResourceMemoryBudget = int64(getInt("RESOURCE_MEMORY_BUDGET", int(cfg.ResourceMemoryBudget)))
+	cfg.ResourceFDBudget = getInt("RESOURCE_FD_BUDGET", cfg.ResourceFDBudget)
+	cfg.RequestRateLimit = getInt("REQUEST_RATE_LIMIT", cfg.RequestRateLimit)
+	cfg.RequestRateInterval = getDuration("REQUEST_RATE_INTERVAL", cfg.RequestRateInterval)
+	cfg.RequestRateBurst = getInt("REQUEST_RATE_BURST", cfg.RequestRateBurst)
+	cfg.MinFileSize = int64(getInt("MIN_FILE_SIZE", int(cfg.MinFileSize)))
+	cfg.ExtractMaxSize = int64(getInt("EXTRACT_MAX_SIZE", int(cfg.ExtractMaxSize)))
+	cfg.ExtractMaxFiles = getInt("EXTRACT_MAX_FILES", cfg.ExtractMaxFiles)
+	cfg.TreeMaxDepth = getInt("TREE_MAX_DEPTH", cfg.TreeMaxDepth)
+	cfg.TreeMaxEntries = getInt("TREE_MAX_ENTRIES", cfg.TreeMaxEntries)
+	cfg.DiskQuota = int64(getInt("DISK_QUOTA", int(cfg.DiskQuota)))
+	cfg.DiskQuotaCleanup = getBool("DISK_QUOTA_CLEANUP", cfg.DiskQuotaCleanup)
+	cfg.MinFreeDisk = int64(getInt("MIN_FREE_DISK", int(cfg.MinFreeDisk)))
+	cfg.DiskCheckInterval = getDuration("DISK_CHECK_INTERVAL", cfg.DiskCheckInterval)
+	cfg.DiskLowPausePool = getBool("DISK_LOW_PAUSE_POOL", cfg.DiskLowPausePool)
+	cfg.FailureThreshold = getString("FAILURE_THRESHOLD", cfg.FailureThreshold)
+
+	cfg.CopyBufferSize = getInt("COPY_BUFFER_SIZE", cfg.CopyBufferSize)
+
+	cfg.Deduplication = getBool("DEDUPLICATION", cfg.Deduplication)
+	cfg.CompressFiles = getBool("COMPRESS_FILES", cfg.CompressFiles)
+
+	cfg.PollInterval = getDuration("POLL_INTERVAL", cfg.PollInterval)
+	cfg.RestartRampWindow = getDuration("RESTART_RAMP_WINDOW", cfg.RestartRampWindow)
+	cfg.RestartRampJitter = getDuration("RESTART_RAMP_JITTER", cfg.RestartRampJitter)
+
+	cfg.QueueCapacity = getInt("QUEUE_CAPACITY", cfg.QueueCapacity)
+	cfg.QueueWaitTimeout = getDuration("QUEUE_WAIT_TIMEOUT", cfg.QueueWaitTimeout)
+	cfg.MaxGoroutines = getInt("MAX_GOROUTINES", cfg.MaxGoroutines)
+
+	cfg.CircuitBreakerThreshold = getInt("CIRCUIT_BREAKER_THRESHOLD", cfg.CircuitBreakerThreshold)
+	cfg.CircuitBreakerWindow = getDuration("CIRCUIT_BREAKER_WINDOW", cfg.CircuitBreakerWindow)
+	cfg.CircuitBreakerCooldown = getDuration("CIRCUIT_BREAKER_COOLDOWN", cfg.CircuitBreakerCooldown)
+
+	cfg.TrashRetention = getDuration("TRASH_RETENTION", cfg.TrashRetention)
+	cfg.TrashGCInterval = getDuration("TRASH_GC_INTERVAL", cfg.TrashGCInterval)
+
+	cfg.TaskTTL = getDuration("TASK_TTL", cfg.TaskTTL)
+	cfg.TaskExpiryInterval = getDuration("TASK_EXPIRY_INTERVAL", cfg.TaskExpiryInterval)
+	cfg.DeadLetterFile = getString("DEAD_LETTER_FILE", cfg.DeadLetterFile)
+	cfg.DeadLetterAfter = getDuration("DEAD_LETTER_AFTER", cfg.DeadLetterAfter)
+	cfg.DeadLetterInterval = getDuration("DEAD_LETTER_INTERVAL", cfg.DeadLetterInterval)
+	cfg.CompactionInterval = getDuration("COMPACTION_INTERVAL", cfg.CompactionInterval)
+	cfg.CompactionRetention = getDuration("COMPACTION_RETENTION", cfg.CompactionRetention)
+
+	cfg.OrphanCleanup = getString("ORPHAN_CLEANUP", cfg.OrphanCleanup)
+	cfg.OrphanCleanupInterval = getDuration("ORPHAN_CLEANUP_INTERVAL", cfg.OrphanCleanupInterval)
+	cfg.OrphanGracePeriod = getDuration("ORPHAN_GRACE_PERIOD", cfg.OrphanGracePeriod)
+}
+
+// AutoFairFileSlots - значение FairFileSlots, при котором число общих слотов скачивания выводится из WorkerCount
+const AutoFairFileSlots = -1
+
+// FileSlots возвращает общее число одновременно скачиваемых файлов всех задач (0 - без ограничения).
+// По умолчанию это WorkerCount × FileConcurrency: столько файлов пул скачивал бы и без общего ограничения.
+func (c Config) FileSlots() int {
+	if c.FairFileSlots == AutoFairFileSlots {
+		return c.WorkerCount * c.FileConcurrency
+	}
+	return c.FairFileSlots
+}
+
+// PoolWorkers возвращает число воркеров пула. При слотах по умолчанию воркеров вдвое больше, чем WorkerCount:
+// задачи, пришедшие, пока воркеры заняты большими задачами, получают воркер и ждут ближайшего слота, не занимая
+// соединений, а распределитель отдает его задаче с наименьшим числом скачиваемых файлов.
+func (c Config) PoolWorkers() int {
+	if c.FairFileSlots == AutoFairFileSlots {
+		return 2 * c.WorkerCount
+	}
+	return c.WorkerCount
+}
+
+// StatePath возвращает путь к файлу состояния с учетом сжатия
+func (c Config) StatePath() string {
+	if c.StateCompress && !strings.HasSuffix(c.StateFile, ".gz") {
+		return c.StateFile + ".gz"
+	}
+	return c.StateFile
+}
+
+// getString возвращает значение переменной окружения или значение по умолчанию
+func getString(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getInt возвращает целочисленное значение переменной окружения или значение по умолчанию
+func getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Предупреждение: неверное значение %s=%q, используется %d", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getBool возвращает логическое значение переменной окружения или значение по умолчанию
+func getBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Предупреждение: неверное значение %s=%q, используется %t", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// getList разбирает список значений, разделенных запятыми
+func getList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getHeaders разбирает заголовки в формате "Name: Value; Name2: Value2"
+func getHeaders(key string, fallback map[string]string) map[string]string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		name, headerValue, found := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			log.Printf("Предупреждение: неверный заголовок %q в %s, пропускается", pair, key)
+			continue
+		}
+		headers[name] = strings.TrimSpace(headerValue)
+	}
+	return headers
+}
+
+// getDuration возвращает длительность из переменной окружения или значение по умолчанию
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Предупреждение: неверное значение %s=%q, используется %v", key, value, fallback)
+		return fallback
+	}
+	return parsed
+}
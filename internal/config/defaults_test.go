@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/entities"
+	"file-downloader/internal/infrastructure"
+	"file-downloader/internal/usecases"
+)
+
+// gatedTransport answers large.example.com/{first,second} only after the matching gate is closed
+// and every other request immediately
+type gatedTransport struct {
+	first, second chan struct{}
+}
+
+func (t gatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host == "large.example.com" {
+		gate := t.first
+		if strings.HasSuffix(req.URL.Path, "/second") {
+			gate = t.second
+		}
+		select {
+		case <-gate:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Header:        http.Header{},
+		Body:          io.NopCloser(strings.NewReader("data")),
+		ContentLength: 4,
+		Request:       req,
+	}, nil
+}
+
+func TestDefaultFairSlots(t *testing.T) {
+	// Execute
+	cfg := Default()
+
+	// Assert
+	if cfg.FileSlots() != cfg.WorkerCount*cfg.FileConcurrency {
+		t.Errorf("Expected %d default file slots, got %d", cfg.WorkerCount*cfg.FileConcurrency, cfg.FileSlots())
+	}
+	if cfg.PoolWorkers() != 2*cfg.WorkerCount {
+		t.Errorf("Expected %d pool workers by default, got %d", 2*cfg.WorkerCount, cfg.PoolWorkers())
+	}
+
+	// Execute: explicit slots keep the configured worker count
+	cfg.FairFileSlots = 0
+
+	// Assert
+	if cfg.FileSlots() != 0 || cfg.PoolWorkers() != cfg.WorkerCount {
+		t.Errorf("Expected no slots and %d workers, got %d slots and %d workers", cfg.WorkerCount, cfg.FileSlots(), cfg.PoolWorkers())
+	}
+}
+
+func TestDefaultConfigFinishesSmallTaskBesideLargeTasks(t *testing.T) {
+	// Setup: WorkerCount large tasks occupy every file slot under the default configuration
+	cfg := Default()
+	ctx := context.Background()
+	repo := repository.NewFileBasedTaskRepository(filepath.Join(t.TempDir(), "tasks.json"))
+	transport := gatedTransport{first: make(chan struct{}), second: make(chan struct{})}
+	usecase := usecases.NewDownloadUsecase(repo, repo,
+		usecases.WithDownloadDir(t.TempDir()),
+		usecases.WithRoundTripper(transport),
+		usecases.WithFileConcurrency(cfg.FileConcurrency),
+		usecases.WithFairFileSlots(cfg.FileSlots()))
+	pool := infrastructure.NewWorkerPool(cfg.PoolWorkers(), usecase)
+	pool.Start()
+	defer pool.Stop()
+	defer close(transport.second)
+
+	var large []*entities.Task
+	for i := 0; i < cfg.WorkerCount; i++ {
+		task := entities.NewTask([]string{"https://large.example.com/first", "https://large.example.com/second"}, time.Now())
+		for j, url := range task.URLs {
+			task.Files[j] = entities.File{URL: url, Status: "pending"}
+		}
+		repo.Create(ctx, task)
+		pool.AddTask(task)
+		large = append(large, task)
+	}
+	waitForUsecase(t, func() bool { return usecase.ResourceUsage().ActiveFiles == cfg.WorkerCount })
+
+	small := entities.NewTask([]string{"https://small.example.com/file.bin"}, time.Now())
+	small.Files[0] = entities.File{URL: small.URLs[0], Status: "pending"}
+	repo.Create(ctx, small)
+	pool.AddTask(small)
+	// The small task gets a worker and queues for a file slot without holding one
+	waitForUsecase(t, func() bool {
+		stored, err := repo.GetByID(ctx, small.ID.String())
+		return err == nil && stored.Status == entities.TaskStatusProcessing
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	// Execute: the first files of the large tasks finish, their second files stay blocked
+	close(transport.first)
+
+	// Assert
+	waitForUsecase(t, func() bool {
+		stored, err := repo.GetByID(ctx, small.ID.String())
+		return err == nil && stored.Status == entities.TaskStatusCompleted
+	})
+	for _, task := range large {
+		stored, _ := repo.GetByID(ctx, task.ID.String())
+		if stored.Status == entities.TaskStatusCompleted {
+			t.Errorf("Expected large task %s to still be running, got %s", task.ID, stored.Status)
+		}
+	}
+}
+
+// waitForUsecase polls condition until it holds or 5s pass
+func waitForUsecase(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected condition to hold within 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
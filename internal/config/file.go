@@ -0,0 +1,133 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Load возвращает конфигурацию из YAML-файла path, переопределенную переменными окружения, и проверяет её.
+// Параметры, не указанные в файле, сохраняют значения по умолчанию; при пустом path используются только
+// значения по умолчанию и переменные окружения. Длительности задаются строками вида "30s" или "1h".
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("не удалось прочитать файл конфигурации: %w", err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return cfg, fmt.Errorf("не удалось разобрать файл конфигурации %s: %w", path, err)
+		}
+	}
+
+	applyEnv(&cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// Validate проверяет, что параметры конфигурации допустимы
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.ServerAddr != "", "server_addr не задан")
+	check(c.WorkerCount >= 1, "worker_count должен быть положительным: %d", c.WorkerCount)
+	check(c.ShutdownTimeout > 0, "shutdown_timeout должен быть положительным: %v", c.ShutdownTimeout)
+	check(c.ServerShutdownTimeout > 0, "server_shutdown_timeout должен быть положительным: %v", c.ServerShutdownTimeout)
+	check(c.MaxInFlightRequests >= 0, "max_in_flight_requests не может быть отрицательным: %d", c.MaxInFlightRequests)
+	check(c.InFlightRetryAfter > 0, "in_flight_retry_after должен быть положительным: %v", c.InFlightRetryAfter)
+	check(c.StateFile != "", "state_file не задан")
+	check(c.PresetsFile != "", "presets_file не задан")
+	check(c.DownloadDir != "", "download_dir не задан")
+	check(c.DirectoryIndex != "." && c.DirectoryIndex != ".." && !strings.ContainsAny(c.DirectoryIndex, `/\`),
+		"directory_index_name должно быть именем файла без пути: %q", c.DirectoryIndex)
+	for _, root := range c.OutputDirRoots {
+		check(root != "", "output_dir_roots не может содержать пустой путь")
+	}
+	check(c.MaxRequestTimeout >= 0, "max_request_timeout не может быть отрицательным: %v", c.MaxRequestTimeout)
+	check(!c.StateWriteBehind || c.StateRetryInterval > 0, "state_retry_interval должен быть положительным: %v", c.StateRetryInterval)
+	check(c.StateFilesThreshold >= 0, "state_files_threshold не может быть отрицательным: %d", c.StateFilesThreshold)
+	check(c.MemoryTaskLimit >= 0, "memory_task_limit не может быть отрицательным: %d", c.MemoryTaskLimit)
+	check(c.MaxActiveTasks >= 0, "max_active_tasks не может быть отрицательным: %d", c.MaxActiveTasks)
+	if c.TaskIDNamespace != "" {
+		_, err := uuid.Parse(c.TaskIDNamespace)
+		check(err == nil, "task_id_namespace должен быть UUID: %q", c.TaskIDNamespace)
+	}
+	check(c.MaxURLLength >= 1, "max_url_length должен быть положительным: %d", c.MaxURLLength)
+	check(c.MaxInlineContentSize >= 1, "max_inline_content_size должен быть положительным: %d", c.MaxInlineContentSize)
+	check(c.MaxManifestSize >= 1, "max_manifest_size должен быть положительным: %d", c.MaxManifestSize)
+	check(c.ManifestTimeout > 0, "manifest_timeout должен быть положительным: %v", c.ManifestTimeout)
+	check(len(c.URLSigningHosts) == 0 || (c.URLSigningSecret != "" && c.URLSigningTTL > 0),
+		"для url_signing_hosts нужны url_signing_secret и положительный url_signing_ttl")
+	check(c.URLSigningSignatureParam != "" && c.URLSigningExpiresParam != "" && c.URLSigningSignatureParam != c.URLSigningExpiresParam,
+		"имена параметров подписи URL должны быть заданы и различаться")
+	check(c.FetchTimeout >= 0, "fetch_timeout не может быть отрицательным: %v", c.FetchTimeout)
+	check(c.HTTPConnectTimeout >= 0 && c.HTTPTLSHandshakeTimeout >= 0 && c.HTTPResponseHeaderTimeout >= 0 &&
+		c.HTTPFirstByteTimeout >= 0 && c.HTTPIdleTimeout >= 0 && c.HTTPTimeout >= 0 &&
+		c.HTTPRedirectHopTimeout >= 0 && c.HTTPRedirectTimeout >= 0, "таймауты HTTP-запросов не могут быть отрицательными")
+	check(c.RetryMaxAttempts >= 1, "retry_max_attempts должен быть положительным: %d", c.RetryMaxAttempts)
+	check(c.RetryBackoff >= 0 && c.RetryMaxBackoff >= 0, "задержки повторов не могут быть отрицательными")
+	check(c.SlowDownloadMinSpeed >= 0, "slow_download_min_speed не может быть отрицательным: %d", c.SlowDownloadMinSpeed)
+	check(c.SlowDownloadWindow > 0, "slow_download_window должен быть положительным: %v", c.SlowDownloadWindow)
+	check(c.RetryAlertWindow > 0, "retry_alert_window должен быть положительным: %v", c.RetryAlertWindow)
+	check(c.RetryAlertThreshold >= 0, "retry_alert_threshold не может быть отрицательным: %d", c.RetryAlertThreshold)
+	check(c.CallbackMaxAttempts >= 1, "callback_max_attempts должен быть положительным: %d", c.CallbackMaxAttempts)
+	check(c.CallbackBackoff >= 0 && c.CallbackMaxBackoff >= 0, "задержки доставки уведомлений не могут быть отрицательными")
+	check(c.CallbackTimeout > 0 && c.CallbackPollInterval > 0, "callback_timeout и callback_poll_interval должны быть положительными")
+	check(c.PageSize >= 1, "tasks_page_size должен быть положительным: %d", c.PageSize)
+	check(c.MaxPageSize >= c.PageSize, "tasks_max_page_size (%d) меньше tasks_page_size (%d)", c.MaxPageSize, c.PageSize)
+	check(c.ProgressInterval >= 0 && c.ProgressMinBytes >= 0, "параметры сохранения прогресса не могут быть отрицательными")
+	check(c.SegmentCount >= 1, "segment_count должен быть положительным: %d", c.SegmentCount)
+	check(c.SegmentMinSize >= 0, "segment_min_size не может быть отрицательным: %d", c.SegmentMinSize)
+	check(c.FileConcurrency >= 1, "file_concurrency должен быть положительным: %d", c.FileConcurrency)
+	check(c.FairFileSlots >= AutoFairFileSlots, "fair_file_slots должен быть не меньше %d: %d", AutoFairFileSlots, c.FairFileSlots)
+	check(c.ResourceMemoryBudget >= 0 && c.ResourceFDBudget >= 0, "бюджет ресурсов не может быть отрицательным")
+	check(c.RequestRateLimit >= 0 && c.RequestRateBurst >= 0, "ограничение частоты запросов не может быть отрицательным")
+	check(c.RequestRateLimit == 0 || c.RequestRateInterval > 0, "request_rate_interval должен быть положительным: %v", c.RequestRateInterval)
+	check(c.ExtractMaxSize >= 1 && c.ExtractMaxFiles >= 1, "extract_max_size и extract_max_files должны быть положительными")
+	check(c.TreeMaxDepth >= 1 && c.TreeMaxEntries >= 1, "tree_max_depth и tree_max_entries должны быть положительными")
+	check(c.DiskQuota >= 0, "disk_quota не может быть отрицательным: %d", c.DiskQuota)
+	check(c.MinFreeDisk >= 0, "min_free_disk не может быть отрицательным: %d", c.MinFreeDisk)
+	check(c.DiskCheckInterval > 0, "disk_check_interval должен быть положительным: %v", c.DiskCheckInterval)
+	check(c.MinFileSize >= 0, "min_file_size не может быть отрицательным: %d", c.MinFileSize)
+	check(c.CopyBufferSize >= 0, "copy_buffer_size не может быть отрицательным: %d", c.CopyBufferSize)
+	check(c.PollInterval > 0, "poll_interval должен быть положительным: %v", c.PollInterval)
+	check(c.RestartRampWindow >= 0 && c.RestartRampJitter >= 0, "параметры постановки backlog не могут быть отрицательными")
+	check(c.QueueCapacity >= 1, "queue_capacity должен быть положительным: %d", c.QueueCapacity)
+	check(c.QueueWaitTimeout >= 0, "queue_wait_timeout не может быть отрицательным: %v", c.QueueWaitTimeout)
+	check(c.MaxGoroutines >= 0, "max_goroutines не может быть отрицательным: %d", c.MaxGoroutines)
+	check(c.CircuitBreakerThreshold >= 0, "circuit_breaker_threshold не может быть отрицательным: %d", c.CircuitBreakerThreshold)
+	check(c.TrashRetention >= 0, "trash_retention не может быть отрицательным: %v", c.TrashRetention)
+	check(c.TrashRetention == 0 || c.TrashGCInterval > 0, "trash_gc_interval должен быть положительным: %v", c.TrashGCInterval)
+	check(c.TaskTTL >= 0, "task_ttl не может быть отрицательным: %v", c.TaskTTL)
+	check(c.TaskExpiryInterval > 0, "task_expiry_interval должен быть положительным: %v", c.TaskExpiryInterval)
+	check(c.DeadLetterAfter >= 0, "dead_letter_after не может быть отрицательным: %v", c.DeadLetterAfter)
+	check(c.DeadLetterInterval > 0, "dead_letter_interval должен быть положительным: %v", c.DeadLetterInterval)
+	check(c.CompactionInterval >= 0, "compaction_interval не может быть отрицательным: %v", c.CompactionInterval)
+	check(c.CompactionRetention >= 0, "compaction_retention не может быть отрицательным: %v", c.CompactionRetention)
+	check(c.OrphanCleanupInterval > 0, "orphan_cleanup_interval должен быть положительным: %v", c.OrphanCleanupInterval)
+	check(c.OrphanGracePeriod >= 0, "orphan_grace_period не может быть отрицательным: %v", c.OrphanGracePeriod)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("неверная конфигурация: %w", errors.Join(errs...))
+	}
+	return nil
+}
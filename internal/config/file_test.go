@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFileWithEnvOverrides(t *testing.T) {
+	// Setup
+	path := writeConfig(t, `
+worker_count: 8
+fetch_timeout: 45s
+allowed_hosts: ["example.com", "*.cdn.example.com"]
+default_headers:
+  Accept: "*/*"
+`)
+	t.Setenv("WORKER_COUNT", "2")
+
+	// Execute
+	cfg, err := Load(path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.WorkerCount != 2 {
+		t.Errorf("Expected env override of worker count 2, got %d", cfg.WorkerCount)
+	}
+	if cfg.FetchTimeout != 45*time.Second {
+		t.Errorf("Expected fetch timeout 45s, got %v", cfg.FetchTimeout)
+	}
+	if len(cfg.AllowedHosts) != 2 || cfg.AllowedHosts[1] != "*.cdn.example.com" {
+		t.Errorf("Expected allowed hosts from file, got %v", cfg.AllowedHosts)
+	}
+	if cfg.DefaultHeaders["Accept"] != "*/*" {
+		t.Errorf("Expected Accept header from file, got %v", cfg.DefaultHeaders)
+	}
+	if cfg.DownloadDir != Default().DownloadDir {
+		t.Errorf("Expected default download dir, got %s", cfg.DownloadDir)
+	}
+}
+
+func TestLoadRejectsUnknownFieldsAndInvalidValues(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown field", "worker_cnt: 4\n", "worker_cnt"},
+		{"invalid value", "worker_count: 0\nsegment_count: -1\n", "segment_count"},
+		{"invalid task ID namespace", "task_id_namespace: not-a-uuid\n", "task_id_namespace"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			_, err := Load(writeConfig(t, tc.content))
+
+			// Assert
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Expected error mentioning %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
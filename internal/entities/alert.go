@@ -0,0 +1,21 @@
+package entities
+
+import "time"
+
+// Alert представляет оповещение оператора о превышении порога
+type Alert struct {
+	Name      string    `json:"name"`
+	Message   string    `json:"message"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Time      time.Time `json:"time"`
+}
+
+// RetryStats содержит статистику повторных попыток скачивания
+type RetryStats struct {
+	Total         int64   `json:"total"`               // повторов с запуска сервиса
+	InWindow      int     `json:"in_window"`           // повторов за последнее окно
+	WindowSeconds float64 `json:"window_seconds"`      // длина окна в секундах
+	Threshold     int     `json:"threshold,omitempty"` // порог повторов за окно для оповещения (0 - отключено)
+	Alerting      bool    `json:"alerting"`            // порог превышен в текущем окне
+}
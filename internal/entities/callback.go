@@ -0,0 +1,125 @@
+package entities
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CallbackStatus представляет состояние доставки уведомления о завершении задачи
+type CallbackStatus string
+
+const (
+	CallbackStatusPending   CallbackStatus = "pending"   // ожидает отправки или повторной попытки
+	CallbackStatusDelivered CallbackStatus = "delivered" // получатель ответил кодом 2xx
+	CallbackStatusFailed    CallbackStatus = "failed"    // попытки доставки исчерпаны
+)
+
+// CallbackDelivery хранит состояние доставки уведомления о завершении задачи на callback_url.
+// Сохраняется вместе с задачей, поэтому недоставленные уведомления отправляются и после перезапуска сервиса.
+type CallbackDelivery struct {
+	Status        CallbackStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	QueuedAt      time.Time      `json:"queued_at"`                 // момент завершения задачи, о котором уведомление
+	NextAttemptAt *time.Time     `json:"next_attempt_at,omitempty"` // время следующей попытки (nil - как можно скорее)
+	LastAttemptAt *time.Time     `json:"last_attempt_at,omitempty"`
+	DeliveredAt   *time.Time     `json:"delivered_at,omitempty"`
+	ResponseCode  int            `json:"response_code,omitempty"` // код ответа получателя на последнюю попытку
+	Error         string         `json:"error,omitempty"`         // ошибка последней попытки
+}
+
+// Manifest - итог обработки задачи, отправляемый в уведомлении о завершении
+type Manifest struct {
+	TaskID         uuid.UUID      `json:"task_id"`
+	Status         TaskStatus     `json:"status"`
+	Error          string         `json:"error,omitempty"`
+	ErrorKind      ErrorKind      `json:"error_kind,omitempty"`
+	RequestID      string         `json:"request_id,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	StartedAt      *time.Time     `json:"started_at,omitempty"`
+	FinishedAt     *time.Time     `json:"finished_at,omitempty"`
+	CompletedFiles int            `json:"completed_files"`
+	FailedFiles    int            `json:"failed_files"`
+	TotalBytes     int64          `json:"total_bytes"`
+	Checksum       string         `json:"checksum,omitempty"` // общая контрольная сумма скачанных файлов задачи
+	Files          []ManifestFile `json:"files"`
+}
+
+// ManifestFile - итог обработки одного файла задачи
+type ManifestFile struct {
+	Index       int       `json:"index"`
+	URL         string    `json:"url,omitempty"`
+	Name        string    `json:"name,omitempty"` // имя файла со встроенным содержимым
+	Path        string    `json:"path,omitempty"`
+	Status      string    `json:"status"`
+	Size        int64     `json:"size,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Compression string    `json:"compression,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	ErrorKind   ErrorKind `json:"error_kind,omitempty"`
+	Extracted   []string  `json:"extracted,omitempty"` // файлы, распакованные из архива
+}
+
+// NewManifest формирует итог обработки задачи по её текущему состоянию
+func NewManifest(task *Task) Manifest {
+	manifest := Manifest{
+		TaskID:     task.ID,
+		Status:     task.Status,
+		Error:      task.Error,
+		ErrorKind:  task.ErrorKind,
+		RequestID:  task.RequestID,
+		CreatedAt:  task.CreatedAt,
+		StartedAt:  cloneTime(task.StartedAt),
+		FinishedAt: cloneTime(task.FinishedAt),
+		Checksum:   task.Checksum,
+		Files:      make([]ManifestFile, len(task.Files)),
+	}
+
+	for i, file := range task.Files {
+		manifest.Files[i] = ManifestFile{
+			Index:       i,
+			URL:         file.URL,
+			Name:        file.Name,
+			Path:        file.Path,
+			Status:      file.Status,
+			Size:        file.Size,
+			SHA256:      file.SHA256,
+			ContentType: file.ContentType,
+			Compression: file.Compression,
+			Error:       file.Error,
+			ErrorKind:   file.ErrorKind,
+			Extracted:   slices.Clone(file.Extracted),
+		}
+		switch file.Status {
+		case "completed":
+			manifest.CompletedFiles++
+			manifest.TotalBytes += file.Size
+		case "failed":
+			manifest.FailedFiles++
+		}
+	}
+
+	return manifest
+}
+
+// CallbackDue возвращает true, если у задачи есть недоставленное уведомление, время попытки которого наступило
+func (t *Task) CallbackDue(now time.Time) bool {
+	if t.Callback == nil || t.Callback.Status != CallbackStatusPending {
+		return false
+	}
+	return t.Callback.NextAttemptAt == nil || !now.Before(*t.Callback.NextAttemptAt)
+}
+
+// clone возвращает копию состояния доставки, не разделяющую с оригиналом указатели
+func (d *CallbackDelivery) clone() *CallbackDelivery {
+	if d == nil {
+		return nil
+	}
+	copied := *d
+	copied.NextAttemptAt = cloneTime(d.NextAttemptAt)
+	copied.LastAttemptAt = cloneTime(d.LastAttemptAt)
+	copied.DeliveredAt = cloneTime(d.DeliveredAt)
+	return &copied
+}
@@ -0,0 +1,21 @@
+package entities
+
+import "time"
+
+// CompactionReport - результат сжатия постоянного хранилища задач
+type CompactionReport struct {
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at"`
+	TasksKept      int       `json:"tasks_kept"`
+	TasksDropped   int       `json:"tasks_dropped"`   // задачи с итоговым статусом старше срока хранения
+	BytesBefore    int64     `json:"bytes_before"`    // размер хранилища на диске до сжатия
+	BytesAfter     int64     `json:"bytes_after"`     // размер хранилища на диске после сжатия
+	BytesReclaimed int64     `json:"bytes_reclaimed"` // освобождено байт (0, если хранилище выросло)
+	Dropped        []string  `json:"-"`               // ID удаленных из хранилища задач
+}
+
+// Reclaim заполняет размер хранилища после сжатия и число освобожденных байт
+func (r *CompactionReport) Reclaim(after int64) {
+	r.BytesAfter = after
+	r.BytesReclaimed = max(r.BytesBefore-after, 0)
+}
@@ -0,0 +1,15 @@
+package entities
+
+import (
+	"io"
+	"time"
+)
+
+// FileContent представляет открытое содержимое скачанного файла задачи
+type FileContent struct {
+	File
+	Content  io.ReadCloser // данные файла; закрываются вызывающей стороной
+	Encoding string        // сжатие данных Content ("gzip"); пусто - исходные данные
+	Length   int64         // размер данных Content в байтах
+	ModTime  time.Time     // время изменения файла на диске
+}
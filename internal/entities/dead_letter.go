@@ -0,0 +1,42 @@
+package entities
+
+import "time"
+
+// DeadLetter - окончательно завершившаяся ошибкой задача, перенесенная из активного хранилища для разбора
+// оператором. Задача хранится целиком, чтобы её можно было вернуть в обработку.
+type DeadLetter struct {
+	Task           *Task     `json:"task"`
+	Error          string    `json:"error,omitempty"`
+	ErrorKind      ErrorKind `json:"error_kind,omitempty"`
+	FailedFiles    int       `json:"failed_files"`
+	DeadLetteredAt time.Time `json:"dead_lettered_at"`
+}
+
+// NewDeadLetter создает запись о неудачной задаче, перенесенной в момент now
+func NewDeadLetter(task *Task, now time.Time) *DeadLetter {
+	failed := 0
+	for _, file := range task.Files {
+		if file.Status == "failed" {
+			failed++
+		}
+	}
+	return &DeadLetter{
+		Task:           task.Clone(),
+		Error:          task.Error,
+		ErrorKind:      task.ErrorKind,
+		FailedFiles:    failed,
+		DeadLetteredAt: now,
+	}
+}
+
+// ID возвращает ID перенесенной задачи
+func (d *DeadLetter) ID() string {
+	return d.Task.ID.String()
+}
+
+// Clone возвращает копию записи с независимой копией задачи
+func (d *DeadLetter) Clone() *DeadLetter {
+	clone := *d
+	clone.Task = d.Task.Clone()
+	return &clone
+}
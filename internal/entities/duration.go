@@ -0,0 +1,29 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration - длительность, которая в JSON записывается строкой в формате time.ParseDuration ("500ms", "2s", "1m")
+type Duration time.Duration
+
+// MarshalJSON записывает длительность строкой
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON разбирает длительность из строки
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("длительность должна быть строкой вида \"2s\": %s", data)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("неверная длительность %q: %w", value, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,42 @@
+package entities
+
+import "fmt"
+
+// ErrorKind классифицирует причину ошибки скачивания
+type ErrorKind string
+
+const (
+	ErrorKindPermission     ErrorKind = "permission"
+	ErrorKindFilesystem     ErrorKind = "filesystem"
+	ErrorKindTimeout        ErrorKind = "timeout"
+	ErrorKindNetwork        ErrorKind = "network"
+	ErrorKindHTTP           ErrorKind = "http"
+	ErrorKindCircuitOpen    ErrorKind = "circuit_open"
+	ErrorKindHostNotAllowed ErrorKind = "host_not_allowed"
+	ErrorKindFileExists     ErrorKind = "file_exists"
+	// ErrorKindSlowDownload - попытка прервана, так как скорость скачивания была ниже минимальной
+	ErrorKindSlowDownload ErrorKind = "slow_download"
+	// ErrorKindFailureThreshold - скачивание отменено, так как слишком много файлов задачи завершились ошибкой
+	ErrorKindFailureThreshold ErrorKind = "failure_threshold"
+	// ErrorKindInternal - обработка задачи прервана внутренней ошибкой сервиса (паникой воркера)
+	ErrorKindInternal ErrorKind = "internal"
+	// ErrorKindTooSmall - источник успешно ответил, но файл меньше минимального размера
+	ErrorKindTooSmall ErrorKind = "too_small"
+	// ErrorKindExtraction - файл скачан, но архив не удалось распаковать
+	ErrorKindExtraction ErrorKind = "extraction"
+	// ErrorKindRedirectTimeout - исчерпано время ожидания ответа на перенаправление или всей цепочки перенаправлений
+	ErrorKindRedirectTimeout ErrorKind = "redirect_timeout"
+	// ErrorKindDiskQuota - скачивание отменено, так как файлы задачи превысили её дисковую квоту
+	ErrorKindDiskQuota ErrorKind = "disk_quota"
+)
+
+// HTTPStatusError возвращается, если сервер ответил неуспешным HTTP-статусом
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+// Error реализует интерфейс error
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Status)
+}
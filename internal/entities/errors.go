@@ -0,0 +1,87 @@
+package entities
+
+import "errors"
+
+var (
+	// ErrTaskNotFound возвращается, если задача отсутствует в репозитории
+	ErrTaskNotFound = errors.New("задача не найдена")
+
+	// ErrTaskExists возвращается при создании задачи с ID, который уже есть в хранилище
+	ErrTaskExists = errors.New("задача с таким ID уже существует")
+
+	// ErrInvalidFileIndex возвращается, если индекс файла выходит за границы списка файлов задачи
+	ErrInvalidFileIndex = errors.New("неверный индекс файла")
+
+	// ErrTaskProcessing возвращается при попытке изменить задачу, которая сейчас обрабатывается
+	ErrTaskProcessing = errors.New("задача находится в обработке")
+
+	// ErrTaskDeleted возвращается при попытке изменить задачу, находящуюся в корзине
+	ErrTaskDeleted = errors.New("задача удалена")
+
+	// ErrTaskNotDeleted возвращается при попытке восстановить задачу, которая не была удалена
+	ErrTaskNotDeleted = errors.New("задача не удалена")
+
+	// ErrCircuitOpen возвращается, если запросы к хосту временно заблокированы после серии сбоев
+	ErrCircuitOpen = errors.New("circuit open: хост временно недоступен после серии сбоев")
+
+	// ErrHostNotAllowed возвращается, если скачивание с хоста запрещено политикой хостов
+	ErrHostNotAllowed = errors.New("хост не разрешен")
+
+	// ErrFileExists возвращается, если файл назначения уже существует, а политика запрещает его перезапись
+	ErrFileExists = errors.New("файл уже существует")
+
+	// ErrFailureThreshold возвращается, если число неудачных файлов задачи превысило порог ошибок
+	ErrFailureThreshold = errors.New("превышен порог неудачных файлов")
+
+	// ErrSlowDownload возвращается, если скорость скачивания файла за окно наблюдения ниже минимальной
+	ErrSlowDownload = errors.New("скачивание слишком медленное")
+
+	// ErrFileNotReady возвращается при запросе содержимого файла, скачивание которого не завершено
+	ErrFileNotReady = errors.New("файл еще не скачан")
+
+	// ErrTooManyActiveTasks возвращается при создании задачи, если незавершенных задач уже максимальное количество
+	ErrTooManyActiveTasks = errors.New("слишком много активных задач")
+
+	// ErrManifestUnavailable возвращается, если манифест со списком файлов задачи не удалось загрузить
+	ErrManifestUnavailable = errors.New("не удалось загрузить манифест")
+
+	// ErrPresetNotFound возвращается, если пресет параметров задачи с указанным именем не определен
+	ErrPresetNotFound = errors.New("пресет не найден")
+
+	// ErrInvalidRequest возвращается при некорректных параметрах запроса
+	ErrInvalidRequest = errors.New("неверный запрос")
+
+	// ErrInvalidCursor возвращается при неверном курсоре пагинации
+	ErrInvalidCursor = errors.New("неверный курсор пагинации")
+
+	// ErrQueueFull возвращается, когда в очереди пула воркеров нет места для задачи
+	ErrQueueFull = errors.New("очередь задач переполнена")
+
+	// ErrExtraction возвращается, если скачанный архив не удалось распаковать или он нарушает ограничения распаковки
+	ErrExtraction = errors.New("не удалось распаковать архив")
+
+	// ErrMaintenance возвращается при создании задачи, пока включен режим обслуживания
+	ErrMaintenance = errors.New("сервис в режиме обслуживания: новые задачи не принимаются")
+
+	// ErrFileTooSmall возвращается, если успешный ответ источника меньше минимального размера файла
+	// (например, сервер отвечает 200 с пустым телом вместо 404)
+	ErrFileTooSmall = errors.New("скачанный файл меньше минимального размера")
+
+	// ErrDiskQuota возвращается, если скачанные файлы задачи превысили её дисковую квоту
+	ErrDiskQuota = errors.New("превышена дисковая квота задачи")
+
+	// ErrRedirectTimeout возвращается, если запрос цепочки перенаправлений или вся цепочка не получили ответа вовремя
+	ErrRedirectTimeout = errors.New("превышено время ожидания перенаправлений")
+
+	// ErrDeadLetterNotFound возвращается, если в хранилище окончательно неудачных задач нет задачи с таким ID
+	ErrDeadLetterNotFound = errors.New("задача не найдена среди окончательно неудачных")
+
+	// ErrInternal возвращается, если скачивание прервано внутренней ошибкой сервиса (паникой)
+	ErrInternal = errors.New("внутренняя ошибка сервиса")
+
+	// ErrCompactionInProgress возвращается, если сжатие хранилища уже выполняется
+	ErrCompactionInProgress = errors.New("сжатие хранилища уже выполняется")
+
+	// ErrCompactionUnsupported возвращается, если постоянное хранилище не поддерживает сжатие
+	ErrCompactionUnsupported = errors.New("хранилище не поддерживает сжатие")
+)
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType - тип события жизненного цикла задачи
+type EventType string
+
+const (
+	EventTaskCreated   EventType = "task_created"
+	EventFileStarted   EventType = "file_started"
+	EventFileProgress  EventType = "file_progress"
+	EventFileCompleted EventType = "file_completed"
+	EventFileFailed    EventType = "file_failed"
+	EventTaskCompleted EventType = "task_completed" // задача завершена с любым итоговым статусом
+)
+
+// TaskEvent - событие жизненного цикла задачи, публикуемое в шину событий
+type TaskEvent struct {
+	Type      EventType  `json:"type"`
+	TaskID    uuid.UUID  `json:"task_id"`
+	RequestID string     `json:"request_id,omitempty"`
+	Status    TaskStatus `json:"status"`               // статус задачи в момент события
+	FileIndex int        `json:"file_index,omitempty"` // индекс файла для событий файла
+	File      *File      `json:"file,omitempty"`       // копия состояния файла для событий файла
+	Time      time.Time  `json:"time"`
+}
+
+// NewTaskEvent создает событие задачи
+func NewTaskEvent(eventType EventType, task *Task, now time.Time) TaskEvent {
+	return TaskEvent{Type: eventType, TaskID: task.ID, RequestID: task.RequestID, Status: task.Status, Time: now}
+}
+
+// NewFileEvent создает событие файла задачи с копией его текущего состояния
+func NewFileEvent(eventType EventType, task *Task, fileIndex int, now time.Time) TaskEvent {
+	event := NewTaskEvent(eventType, task, now)
+	file := task.Files[fileIndex]
+	file.Content = "" // встроенное содержимое не передается подписчикам
+	event.FileIndex = fileIndex
+	event.File = &file
+	return event
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"sort"
+	"time"
+)
+
+// FailedFile описывает файл, который не удалось скачать, для сводного списка ошибок
+type FailedFile struct {
+	TaskID    string    `json:"task_id"`
+	FileIndex int       `json:"file_index"`
+	URL       string    `json:"url"`
+	Error     string    `json:"error,omitempty"`
+	ErrorKind ErrorKind `json:"error_kind,omitempty"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// FailedFiles возвращает файлы задачи, завершившиеся ошибкой не раньше since.
+// Временем ошибки считается завершение скачивания файла, а если оно не зафиксировано - последнее обновление задачи.
+func (t *Task) FailedFiles(since time.Time) []FailedFile {
+	var failed []FailedFile
+	for i, file := range t.Files {
+		if file.Status != "failed" {
+			continue
+		}
+
+		failedAt := t.UpdatedAt
+		if file.DownloadFinishedAt != nil {
+			failedAt = *file.DownloadFinishedAt
+		}
+		if failedAt.Before(since) {
+			continue
+		}
+
+		failed = append(failed, FailedFile{
+			TaskID:    t.ID.String(),
+			FileIndex: i,
+			URL:       file.URL,
+			Error:     file.Error,
+			ErrorKind: file.ErrorKind,
+			FailedAt:  failedAt,
+		})
+	}
+	return failed
+}
+
+// SortFailedFiles сортирует ошибки от самой свежей к самой старой
+func SortFailedFiles(failed []FailedFile) {
+	sort.Slice(failed, func(i, j int) bool {
+		if !failed[i].FailedAt.Equal(failed[j].FailedAt) {
+			return failed[i].FailedAt.After(failed[j].FailedAt)
+		}
+		if failed[i].TaskID != failed[j].TaskID {
+			return failed[i].TaskID < failed[j].TaskID
+		}
+		return failed[i].FileIndex < failed[j].FileIndex
+	})
+}
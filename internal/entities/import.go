@@ -0,0 +1,8 @@
+package entities
+
+// ImportSummary описывает результат импорта задач
+type ImportSummary struct {
+	Created     int `json:"created"`
+	Skipped     int `json:"skipped"`     // задачи с существующими ID, оставленные без изменений
+	Overwritten int `json:"overwritten"` // задачи с существующими ID, замененные импортируемыми
+}
@@ -0,0 +1,9 @@
+package entities
+
+import "time"
+
+// Maintenance представляет состояние режима обслуживания сервиса
+type Maintenance struct {
+	Enabled   bool       `json:"enabled"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // время последнего переключения
+}
@@ -0,0 +1,16 @@
+package entities
+
+// OrphanDirectory описывает директорию скачивания, не принадлежащую ни одной известной задаче
+type OrphanDirectory struct {
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"` // ошибка удаления или перемещения директории
+}
+
+// OrphanReport содержит результат очистки осиротевших директорий
+type OrphanReport struct {
+	Action      string            `json:"action"` // remove или quarantine
+	Directories []OrphanDirectory `json:"directories"`
+	Bytes       int64             `json:"bytes"` // объем данных в обработанных без ошибок директориях
+}
@@ -0,0 +1,112 @@
+package entities
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TaskPage представляет страницу списка задач
+type TaskPage struct {
+	Tasks      []*Task `json:"tasks"`
+	Limit      int     `json:"limit"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// TaskStatuses представляет задачи, запрошенные списком ID
+type TaskStatuses struct {
+	Tasks    map[string]*Task // найденные задачи по ID
+	NotFound []string         // ID, для которых задача не найдена, в порядке запроса
+}
+
+// TaskFilter задает условия отбора задач в списке
+type TaskFilter struct {
+	IncludeDeleted bool // включать задачи, находящиеся в корзине
+	Newest         bool // список от новых задач к старым
+}
+
+// Follows возвращает true, если задача находится после позиции курсора в порядке списка фильтра
+func (f TaskFilter) Follows(after TaskCursor, task *Task) bool {
+	if f.Newest {
+		return after.Succeeds(task)
+	}
+	return after.Precedes(task)
+}
+
+// Sort сортирует задачи в порядке списка фильтра
+func (f TaskFilter) Sort(tasks []*Task) {
+	SortTasks(tasks)
+	if f.Newest {
+		slices.Reverse(tasks)
+	}
+}
+
+// TaskFile представляет файл задачи вместе с его индексом в списке файлов
+type TaskFile struct {
+	Index int `json:"index"`
+	File
+}
+
+// FilePage представляет страницу списка файлов задачи
+type FilePage struct {
+	Files      []TaskFile `json:"files"`
+	Total      int        `json:"total"` // количество файлов, подходящих под фильтр
+	Limit      int        `json:"limit"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// FileFilter задает условия отбора файлов задачи
+type FileFilter struct {
+	Status string // статус файла (пустая строка - любой)
+	Offset int    // число пропускаемых подходящих файлов; оставлено для совместимости с клиентами до курсоров
+}
+
+// TaskCursor - позиция в списке задач, отсортированном по времени создания и ID.
+// Позиция задается последней полученной задачей, поэтому добавление и удаление задач
+// между запросами страниц не сдвигает следующие страницы. Нулевой курсор указывает на начало списка.
+type TaskCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// CursorAfter возвращает курсор на позицию сразу после задачи
+func CursorAfter(task *Task) TaskCursor {
+	return TaskCursor{CreatedAt: task.CreatedAt, ID: task.ID.String()}
+}
+
+// IsZero возвращает true для курсора на начало списка
+func (c TaskCursor) IsZero() bool {
+	return c.ID == ""
+}
+
+// Precedes возвращает true, если задача находится в списке после позиции курсора
+func (c TaskCursor) Precedes(task *Task) bool {
+	return c.IsZero() || c.compare(task) < 0
+}
+
+// Succeeds возвращает true, если задача находится после позиции курсора в списке от новых задач к старым
+func (c TaskCursor) Succeeds(task *Task) bool {
+	return c.IsZero() || c.compare(task) > 0
+}
+
+// compare сравнивает позицию курсора с задачей в порядке списка задач
+func (c TaskCursor) compare(task *Task) int {
+	if !task.CreatedAt.Equal(c.CreatedAt) {
+		if task.CreatedAt.After(c.CreatedAt) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(c.ID, task.ID.String())
+}
+
+// SortTasks сортирует задачи по времени создания и ID - в порядке списка задач
+func SortTasks(tasks []*Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+		}
+		return tasks[i].ID.String() < tasks[j].ID.String()
+	})
+}
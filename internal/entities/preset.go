@@ -0,0 +1,122 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// Preset - именованный набор параметров задачи, хранящийся на сервере. Запрос на создание задачи
+// ссылается на пресет по имени и наследует его параметры; заданные в запросе поля переопределяют пресет.
+type Preset struct {
+	Name    string            `json:"name"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	MaxConcurrency   int    `json:"max_concurrency,omitempty"`
+	FailureThreshold string `json:"failure_threshold,omitempty"`
+	Compress         *bool  `json:"compress,omitempty"`
+	PreservePath     bool   `json:"preserve_path,omitempty"`
+	OutputDir        string `json:"output_dir,omitempty"`
+	Extract          bool   `json:"extract,omitempty"`
+	DiskQuota        int64  `json:"disk_quota,omitempty"`
+	CallbackURL      string `json:"callback_url,omitempty"`
+
+	RetryPolicy  *TaskRetryPolicy        `json:"retry_policy,omitempty"`
+	SlowDownload *TaskSlowDownloadPolicy `json:"slow_download,omitempty"`
+
+	DownloadWindow *DownloadWindow `json:"download_window,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Apply возвращает описание задачи, в котором незаданные (нулевые) параметры взяты из пресета.
+// Заголовки объединяются: заголовок запроса заменяет одноименный (без учета регистра) заголовок пресета.
+// PreservePath и Extract наследуются, если пресет их включает: отключить их в запросе нельзя.
+func (p *Preset) Apply(spec TaskSpec) TaskSpec {
+	if len(p.Headers) > 0 {
+		headers := make(map[string]string, len(p.Headers)+len(spec.Headers))
+		for name, value := range p.Headers {
+			if !hasHeader(spec.Headers, name) {
+				headers[name] = value
+			}
+		}
+		for name, value := range spec.Headers {
+			headers[name] = value
+		}
+		spec.Headers = headers
+	}
+	if spec.MaxConcurrency == 0 {
+		spec.MaxConcurrency = p.MaxConcurrency
+	}
+	if strings.TrimSpace(spec.FailureThreshold) == "" {
+		spec.FailureThreshold = p.FailureThreshold
+	}
+	if spec.Compress == nil && p.Compress != nil {
+		compress := *p.Compress
+		spec.Compress = &compress
+	}
+	spec.PreservePath = spec.PreservePath || p.PreservePath
+	spec.Extract = spec.Extract || p.Extract
+	if spec.OutputDir == "" {
+		spec.OutputDir = p.OutputDir
+	}
+	if spec.CallbackURL == "" {
+		spec.CallbackURL = p.CallbackURL
+	}
+	if spec.DiskQuota == 0 {
+		spec.DiskQuota = p.DiskQuota
+	}
+	if spec.RetryPolicy == nil && p.RetryPolicy != nil {
+		policy := *p.RetryPolicy
+		policy.RetryOn = append([]string(nil), p.RetryPolicy.RetryOn...)
+		spec.RetryPolicy = &policy
+	}
+	if spec.SlowDownload == nil && p.SlowDownload != nil {
+		policy := *p.SlowDownload
+		spec.SlowDownload = &policy
+	}
+	if spec.DownloadWindow == nil && p.DownloadWindow != nil {
+		window := *p.DownloadWindow
+		spec.DownloadWindow = &window
+	}
+	return spec
+}
+
+// Clone возвращает глубокую копию пресета
+func (p *Preset) Clone() *Preset {
+	clone := *p
+	if p.Headers != nil {
+		clone.Headers = make(map[string]string, len(p.Headers))
+		for name, value := range p.Headers {
+			clone.Headers[name] = value
+		}
+	}
+	if p.Compress != nil {
+		compress := *p.Compress
+		clone.Compress = &compress
+	}
+	if p.RetryPolicy != nil {
+		policy := *p.RetryPolicy
+		policy.RetryOn = append([]string(nil), p.RetryPolicy.RetryOn...)
+		clone.RetryPolicy = &policy
+	}
+	if p.SlowDownload != nil {
+		policy := *p.SlowDownload
+		clone.SlowDownload = &policy
+	}
+	if p.DownloadWindow != nil {
+		window := *p.DownloadWindow
+		clone.DownloadWindow = &window
+	}
+	return &clone
+}
+
+// hasHeader проверяет наличие заголовка без учета регистра имени
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,11 @@
+package entities
+
+// ResourceUsage описывает оценку ресурсов, занятых одновременно скачиваемыми файлами всех задач
+type ResourceUsage struct {
+	ActiveFiles     int   `json:"active_files"`                     // скачиваемые сейчас файлы
+	FileLimit       int   `json:"file_limit,omitempty"`             // допустимое число одновременно скачиваемых файлов (0 - без ограничения)
+	MemoryBytes     int64 `json:"memory_bytes"`                     // оценка памяти буферов копирования
+	MemoryBudget    int64 `json:"memory_budget,omitempty"`          // бюджет памяти (0 - не задан)
+	FileDescriptors int   `json:"file_descriptors"`                 // оценка открытых соединений и файлов
+	FDBudget        int   `json:"file_descriptor_budget,omitempty"` // бюджет файловых дескрипторов (0 - не задан)
+}
@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ShutdownReport - работа, не завершенная к остановке сервиса: задачи в статусах new и processing
+// и их недокачанные файлы. Помогает сверить состояние после перезапуска.
+type ShutdownReport struct {
+	StoppedAt time.Time        `json:"stopped_at"`
+	Tasks     []UnfinishedTask `json:"tasks"`
+}
+
+// UnfinishedTask - незавершенная задача в отчете об остановке
+type UnfinishedTask struct {
+	ID           string           `json:"id"`
+	RequestID    string           `json:"request_id,omitempty"`
+	Status       TaskStatus       `json:"status"`
+	Abandoned    bool             `json:"abandoned,omitempty"` // воркер не завершился до истечения времени остановки
+	PendingFiles int              `json:"pending_files"`       // файлы, скачивание которых не начиналось
+	Files        []UnfinishedFile `json:"files,omitempty"`     // файлы, скачивание которых прервано
+}
+
+// UnfinishedFile - файл, скачивание которого прервано остановкой сервиса
+type UnfinishedFile struct {
+	Index  int    `json:"index"`
+	URL    string `json:"url"`
+	Status string `json:"status"`
+	Path   string `json:"path,omitempty"` // недокачанный файл на диске
+	Offset int64  `json:"offset"`         // байт, записанных на диск
+	Size   int64  `json:"size,omitempty"` // размер, заявленный источником
+}
+
+// NewShutdownReport составляет отчет о незавершенной работе из задач tasks. Задачи в других статусах
+// пропускаются, остальные упорядочены по ID; abandoned - ID задач, воркеры которых не завершились до истечения времени остановки.
+func NewShutdownReport(tasks []*Task, abandoned map[string]bool, now time.Time) *ShutdownReport {
+	report := &ShutdownReport{StoppedAt: now, Tasks: []UnfinishedTask{}}
+	for _, task := range tasks {
+		if task.IsDeleted() || (task.Status != TaskStatusNew && task.Status != TaskStatusProcessing) {
+			continue
+		}
+
+		unfinished := UnfinishedTask{
+			ID:        task.ID.String(),
+			RequestID: task.RequestID,
+			Status:    task.Status,
+			Abandoned: abandoned[task.ID.String()],
+		}
+		for i, file := range task.Files {
+			if file.Status == "completed" || file.Status == "failed" {
+				continue
+			}
+			interrupted := UnfinishedFile{Index: i, URL: file.URL, Status: file.Status, Offset: file.Downloaded, Size: file.Size}
+			switch {
+			case file.PartialPath != "":
+				interrupted.Path = file.PartialPath
+				interrupted.Offset = file.ResumeOffset
+			case file.Status == "downloading":
+				interrupted.Path = file.Path
+			case file.Downloaded == 0:
+				unfinished.PendingFiles++
+				continue
+			}
+			unfinished.Files = append(unfinished.Files, interrupted)
+		}
+		report.Tasks = append(report.Tasks, unfinished)
+	}
+	slices.SortFunc(report.Tasks, func(a, b UnfinishedTask) int { return strings.Compare(a.ID, b.ID) })
+	return report
+}
@@ -0,0 +1,85 @@
+package entities
+
+import "time"
+
+// FileSpec описывает файл в запросе на создание задачи
+type FileSpec struct {
+	URL          string `json:"url"`
+	ExpectedSize int64  `json:"expected_size,omitempty"`
+	Priority     int    `json:"priority,omitempty"` // файлы с большим приоритетом скачиваются раньше
+	SHA256       string `json:"sha256,omitempty"`   // ожидаемая контрольная сумма содержимого
+
+	// Зеркала, с которых файл скачивается по порядку после неудачи скачивания по URL
+	Mirrors []string `json:"mirrors,omitempty"`
+
+	// Встроенное содержимое в base64 записывается на диск вместо скачивания по URL
+	Content string `json:"content,omitempty"`
+	Name    string `json:"name,omitempty"` // имя файла со встроенным содержимым
+}
+
+// TaskSpec описывает параметры создания задачи
+type TaskSpec struct {
+	Files     []FileSpec
+	Headers   map[string]string // заголовки HTTP-запросов задачи
+	StartAt   *time.Time        // время запуска; до него задача находится в статусе scheduled
+	TTL       time.Duration     // срок хранения задачи с момента создания (0 - значение из конфигурации)
+	RequestID string            // ID HTTP-запроса для сквозной трассировки в журнале
+	// MaxConcurrency переопределяет число одновременно скачиваемых файлов задачи (0 - значение из конфигурации)
+	MaxConcurrency int
+	// FailureThreshold переопределяет порог неудачных файлов задачи ("3" или "50%")
+	FailureThreshold string
+	// Compress переопределяет сжатие файлов задачи на диске (nil - значение из конфигурации)
+	Compress *bool
+	// PreservePath воссоздает директории пути URL внутри директории задачи (https://host/a/b/c.png -> a/b/c.png)
+	PreservePath bool
+	// OutputDir задает директорию скачивания задачи внутри одной из разрешенных директорий вместо общей
+	OutputDir string
+	// Extract распаковывает скачанные архивы zip и tar.gz рядом с архивом
+	Extract bool
+	// DiskQuota ограничивает объем файлов задачи на диске в байтах (0 - значение из конфигурации)
+	DiskQuota int64
+	// FreshnessWindow пропускает повторное скачивание файлов, успешно скачанных в пределах окна и сохранившихся на диске
+	FreshnessWindow time.Duration
+	// CallbackURL получает POST-уведомление с итогом обработки задачи после её завершения
+	CallbackURL string
+	// RetryPolicy переопределяет политику повторных попыток скачивания файлов задачи
+	RetryPolicy *TaskRetryPolicy
+	// SlowDownload переопределяет обнаружение медленных скачиваний файлов задачи
+	SlowDownload *TaskSlowDownloadPolicy
+	// DownloadWindow ограничивает скачивание задачи временем суток; вне окна задача ждет в статусе scheduled
+	DownloadWindow *DownloadWindow
+	// Preset - имя пресета, параметры которого используются для незаданных полей описания
+	Preset string
+	// ManifestURL указывает на манифест со списком файлов, которые добавляются к файлам из Files
+	ManifestURL string
+}
+
+// NewTaskSpec создает описание задачи из списка URL
+func NewTaskSpec(urls []string) TaskSpec {
+	files := make([]FileSpec, len(urls))
+	for i, url := range urls {
+		files[i] = FileSpec{URL: url}
+	}
+	return TaskSpec{Files: files}
+}
+
+// URLs возвращает список URL файлов задачи
+func (s TaskSpec) URLs() []string {
+	urls := make([]string, len(s.Files))
+	for i, file := range s.Files {
+		urls[i] = file.URL
+	}
+	return urls
+}
+
+// TaskUpdate описывает изменение параметров созданной задачи; nil-поля не меняются
+type TaskUpdate struct {
+	Headers     *map[string]string // заголовки HTTP-запросов задачи (пустая карта удаляет заголовки)
+	RetryPolicy *TaskRetryPolicy   // политика повторов (пустая политика - из конфигурации)
+	CallbackURL *string            // URL уведомления о завершении (пустая строка отключает уведомление)
+}
+
+// IsEmpty возвращает true, если изменение не затрагивает ни одного поля
+func (u TaskUpdate) IsEmpty() bool {
+	return u.Headers == nil && u.RetryPolicy == nil && u.CallbackURL == nil
+}
@@ -1,6 +1,10 @@
 package entities
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,55 +14,392 @@ import (
 type TaskStatus string
 
 const (
+	TaskStatusScheduled  TaskStatus = "scheduled" // ожидает наступления start_at или окна скачивания
 	TaskStatusNew        TaskStatus = "new"
 	TaskStatusProcessing TaskStatus = "processing"
 	TaskStatusCompleted  TaskStatus = "completed"
+	TaskStatusPartial    TaskStatus = "partial" // часть файлов скачана, остальные завершились ошибкой
 	TaskStatusFailed     TaskStatus = "failed"
 )
 
 // Task представляет задачу скачивания
 type Task struct {
-	ID        uuid.UUID  `json:"id"`
-	URLs      []string   `json:"urls"`
-	Status    TaskStatus `json:"status"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	Files     []File     `json:"files"`
-	Error     string     `json:"error,omitempty"`
+	ID         uuid.UUID         `json:"id"`
+	URLs       []string          `json:"urls"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Status     TaskStatus        `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	StartAt    *time.Time        `json:"start_at,omitempty"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	DeletedAt  *time.Time        `json:"deleted_at,omitempty"`
+	ExpiresAt  *time.Time        `json:"expires_at,omitempty"` // после этого момента задача удаляется вместе с файлами
+	Files      []File            `json:"files"`
+	Error      string            `json:"error,omitempty"`
+	ErrorKind  ErrorKind         `json:"error_kind,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"` // ID HTTP-запроса, создавшего задачу
+
+	MaxConcurrency   int      `json:"max_concurrency,omitempty"`   // число одновременно скачиваемых файлов (0 - из конфигурации)
+	FailureThreshold string   `json:"failure_threshold,omitempty"` // порог неудачных файлов ("3" или "50%"; пусто - из конфигурации)
+	Compress         *bool    `json:"compress,omitempty"`          // сжимать файлы на диске (nil - из конфигурации)
+	PreservePath     bool     `json:"preserve_path,omitempty"`     // воссоздавать директории пути URL внутри директории задачи
+	OutputDir        string   `json:"output_dir,omitempty"`        // директория скачивания задачи вместо DOWNLOAD_DIR
+	Extract          bool     `json:"extract,omitempty"`           // распаковывать скачанные архивы zip и tar.gz
+	DiskQuota        int64    `json:"disk_quota,omitempty"`        // предел байт файлов задачи на диске (0 - из конфигурации)
+	FreshnessWindow  Duration `json:"freshness_window,omitempty"`  // файлы, скачанные в пределах окна, повторно не скачиваются
+
+	CallbackURL string            `json:"callback_url,omitempty"` // URL для POST-уведомления о завершении задачи
+	Callback    *CallbackDelivery `json:"callback,omitempty"`     // состояние доставки уведомления о последнем завершении
+
+	RetryPolicy    *TaskRetryPolicy        `json:"retry_policy,omitempty"`    // политика повторов задачи (nil - из конфигурации)
+	SlowDownload   *TaskSlowDownloadPolicy `json:"slow_download,omitempty"`   // обнаружение медленных скачиваний (nil - из конфигурации)
+	DownloadWindow *DownloadWindow         `json:"download_window,omitempty"` // время суток, в которое разрешено скачивание (nil - в любое время)
+
+	ManifestURL string `json:"manifest_url,omitempty"` // манифест, из которого получен список файлов задачи
+	Checksum    string `json:"checksum,omitempty"`     // итоговая контрольная сумма скачанных файлов (см. AggregateChecksum)
+	Preset      string `json:"preset,omitempty"`       // пресет, параметры которого унаследовала задача
+
+	WaitingForCapacitySince *time.Time `json:"waiting_for_capacity_since,omitempty"` // с какого момента задача ждет места в очереди пула воркеров
+}
+
+// TaskRetryPolicy переопределяет политику повторных попыток скачивания для файлов задачи.
+// Незаданные (нулевые) поля берутся из политики по умолчанию.
+type TaskRetryPolicy struct {
+	MaxAttempts int      `json:"max_attempts,omitempty"`
+	Backoff     Duration `json:"backoff,omitempty"`
+	MaxBackoff  Duration `json:"max_backoff,omitempty"`
+	RetryOn     []string `json:"retry_on,omitempty"` // категории ошибок для повтора: 5xx, 4xx, timeout, connection
+}
+
+// TaskSlowDownloadPolicy переопределяет обнаружение медленных скачиваний для файлов задачи.
+// Незаданные (нулевые) поля берутся из конфигурации.
+type TaskSlowDownloadPolicy struct {
+	MinSpeed int64    `json:"min_speed,omitempty"` // минимальная скорость в байтах в секунду
+	Window   Duration `json:"window,omitempty"`    // окно, за которое средняя скорость должна быть не ниже минимальной
+	Action   string   `json:"action,omitempty"`    // flag - отметить файл, retry - прервать попытку и повторить
 }
 
 // File представляет файл в рамках задачи
 type File struct {
-	URL    string `json:"url"`
-	Path   string `json:"path,omitempty"`
-	Size   int64  `json:"size,omitempty"`
-	Status string `json:"status"`
-	Error  string `json:"error,omitempty"`
+	URL          string    `json:"url"`
+	Mirrors      []string  `json:"mirrors,omitempty"` // зеркала, с которых файл скачивается по порядку, если не удалось скачать по URL
+	Source       string    `json:"source,omitempty"`  // URL или зеркало, с которого файл скачан
+	Path         string    `json:"path,omitempty"`
+	Size         int64     `json:"size,omitempty"`       // итоговый размер или размер, заявленный источником
+	Downloaded   int64     `json:"downloaded,omitempty"` // скачано байт (сохраняется периодически во время скачивания)
+	SHA256       string    `json:"sha256,omitempty"`     // контрольная сумма скачанного файла
+	ContentType  string    `json:"content_type,omitempty"`
+	Protocol     string    `json:"protocol,omitempty"`    // протокол ответа источника, например HTTP/2.0 (для диагностики)
+	Compression  string    `json:"compression,omitempty"` // сжатие файла на диске ("gzip"); Size и SHA256 относятся к исходным данным
+	StoredSize   int64     `json:"stored_size,omitempty"` // размер сжатого файла на диске
+	ExpectedSize int64     `json:"expected_size,omitempty"`
+	Priority     int       `json:"priority,omitempty"` // файлы с большим приоритетом скачиваются раньше
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	ErrorKind    ErrorKind `json:"error_kind,omitempty"`
+	HTTPStatus   int       `json:"http_status,omitempty"`  // код ответа источника, которым завершилось скачивание с ошибкой
+	Attempts     int       `json:"attempts,omitempty"`     // попытки скачивания при последней обработке
+	MaxAttempts  int       `json:"max_attempts,omitempty"` // попытки, доступные при последней обработке (по всем источникам)
+
+	DownloadStartedAt  *time.Time `json:"download_started_at,omitempty"`
+	DownloadFinishedAt *time.Time `json:"download_finished_at,omitempty"`
+
+	Slow      bool  `json:"slow,omitempty"`       // скорость скачивания опускалась ниже минимальной
+	SlowSpeed int64 `json:"slow_speed,omitempty"` // последняя скорость ниже минимальной, байт в секунду
+
+	PartialPath  string `json:"partial_path,omitempty"`  // недокачанный файл, скачивание которого прервано остановкой сервиса
+	ResumeOffset int64  `json:"resume_offset,omitempty"` // байт недокачанного файла, записанных на диск; с них продолжится скачивание
+
+	ExpectedSHA256 string `json:"expected_sha256,omitempty"` // ожидаемая контрольная сумма содержимого
+	Name           string `json:"name,omitempty"`            // имя файла со встроенным содержимым
+	Content        string `json:"content,omitempty"`         // встроенное содержимое в base64 (вместо URL)
+
+	Extracted []string `json:"extracted,omitempty"` // файлы, распакованные из скачанного архива
+
+	// Последнее успешное скачивание: в пределах окна свежести задачи файл повторно не скачивается
+	LastDownloadedAt *time.Time `json:"last_downloaded_at,omitempty"`
+	LastPath         string     `json:"last_path,omitempty"`        // путь файла на диске после последнего скачивания
+	LastCompression  string     `json:"last_compression,omitempty"` // сжатие файла на диске после последнего скачивания
 }
 
-// NewTask создает новую задачу с указанными URL
-func NewTask(urls []string) *Task {
+// Pending возвращает файл в состоянии pending для повторного скачивания: сохраняются только параметры из запроса
+// и сведения о последнем успешном скачивании
+func (f File) Pending() File {
+	return File{
+		URL:            f.URL,
+		Mirrors:        f.Mirrors,
+		Status:         "pending",
+		ExpectedSize:   f.ExpectedSize,
+		ExpectedSHA256: f.ExpectedSHA256,
+		Priority:       f.Priority,
+		Name:           f.Name,
+		Content:        f.Content,
+
+		LastDownloadedAt: f.LastDownloadedAt,
+		LastPath:         f.LastPath,
+		LastCompression:  f.LastCompression,
+	}
+}
+
+// Sources возвращает URL файла и его зеркала в порядке попыток скачивания
+func (f File) Sources() []string {
+	return append([]string{f.URL}, f.Mirrors...)
+}
+
+// IsInline возвращает true, если файл записывается из встроенного содержимого, а не скачивается по URL
+func (f File) IsInline() bool {
+	return f.Content != ""
+}
+
+// NewTask создает новую задачу с указанными URL, созданную в момент now
+func NewTask(urls []string, now time.Time) *Task {
 	return &Task{
 		ID:        uuid.New(),
 		URLs:      urls,
 		Status:    TaskStatusNew,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 		Files:     make([]File, len(urls)),
 	}
 }
 
+// ContentTaskID возвращает ID задачи, вычисленный по набору её файлов (UUIDv5 в пространстве namespace):
+// одинаковые наборы дают одинаковый ID независимо от порядка файлов
+func ContentTaskID(namespace uuid.UUID, keys []string) uuid.UUID {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return uuid.NewSHA1(namespace, []byte(strings.Join(sorted, "\n")))
+}
+
+// Clone возвращает глубокую копию задачи, не разделяющую с оригиналом срезы, карты и указатели
+func (t *Task) Clone() *Task {
+	clone := *t
+	if t.URLs != nil {
+		clone.URLs = append(make([]string, 0, len(t.URLs)), t.URLs...)
+	}
+	if t.Headers != nil {
+		clone.Headers = make(map[string]string, len(t.Headers))
+		for name, value := range t.Headers {
+			clone.Headers[name] = value
+		}
+	}
+	clone.StartAt = cloneTime(t.StartAt)
+	clone.StartedAt = cloneTime(t.StartedAt)
+	clone.FinishedAt = cloneTime(t.FinishedAt)
+	clone.WaitingForCapacitySince = cloneTime(t.WaitingForCapacitySince)
+	clone.DeletedAt = cloneTime(t.DeletedAt)
+	clone.ExpiresAt = cloneTime(t.ExpiresAt)
+	if t.Compress != nil {
+		compress := *t.Compress
+		clone.Compress = &compress
+	}
+	clone.Callback = t.Callback.clone()
+	if t.RetryPolicy != nil {
+		policy := *t.RetryPolicy
+		policy.RetryOn = append([]string(nil), t.RetryPolicy.RetryOn...)
+		clone.RetryPolicy = &policy
+	}
+	if t.SlowDownload != nil {
+		policy := *t.SlowDownload
+		clone.SlowDownload = &policy
+	}
+	if t.DownloadWindow != nil {
+		window := *t.DownloadWindow
+		clone.DownloadWindow = &window
+	}
+
+	if t.Files != nil {
+		clone.Files = make([]File, len(t.Files))
+		for i, file := range t.Files {
+			file.DownloadStartedAt = cloneTime(file.DownloadStartedAt)
+			file.DownloadFinishedAt = cloneTime(file.DownloadFinishedAt)
+			file.LastDownloadedAt = cloneTime(file.LastDownloadedAt)
+			if file.Extracted != nil {
+				file.Extracted = append([]string(nil), file.Extracted...)
+			}
+			if file.Mirrors != nil {
+				file.Mirrors = append([]string(nil), file.Mirrors...)
+			}
+			clone.Files[i] = file
+		}
+	}
+
+	return &clone
+}
+
+// cloneTime копирует значение времени под указателем
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// LogID возвращает идентификатор задачи для журнала, дополненный ID создавшего её запроса
+func (t *Task) LogID() string {
+	return LogID(t.ID.String(), t.RequestID)
+}
+
+// LogID форматирует идентификатор задачи для журнала так, чтобы по ID запроса можно было найти все её записи
+func LogID(taskID, requestID string) string {
+	if requestID == "" {
+		return taskID
+	}
+	return taskID + " [request_id=" + requestID + "]"
+}
+
 // UpdateStatus обновляет статус задачи и временную метку
-func (t *Task) UpdateStatus(status TaskStatus) {
+func (t *Task) UpdateStatus(status TaskStatus, now time.Time) {
 	t.Status = status
-	t.UpdatedAt = time.Now()
+	t.UpdatedAt = now
+}
+
+// MarkStarted фиксирует начало обработки задачи
+func (t *Task) MarkStarted(now time.Time) {
+	t.StartedAt = &now
+	t.FinishedAt = nil
+	t.Checksum = ""
+}
+
+// MarkFinished фиксирует завершение обработки задачи и её итоговую контрольную сумму. Если задан callback_url,
+// в очередь ставится уведомление о завершении; оно заменяет недоставленное уведомление о предыдущем завершении задачи.
+func (t *Task) MarkFinished(now time.Time) {
+	t.FinishedAt = &now
+	t.Checksum = t.AggregateChecksum()
+	if t.CallbackURL != "" {
+		t.Callback = &CallbackDelivery{Status: CallbackStatusPending, QueuedAt: now}
+	}
+}
+
+// AggregateChecksum возвращает SHA-256 от отсортированных контрольных сумм скачанных файлов задачи,
+// по одной в строке в нижнем регистре. Значение не зависит от порядка скачивания и меняется при изменении,
+// добавлении или удалении любого скачанного файла. Без скачанных файлов возвращается пустая строка.
+func (t *Task) AggregateChecksum() string {
+	var checksums []string
+	for _, file := range t.Files {
+		if file.Status == "completed" && file.SHA256 != "" {
+			checksums = append(checksums, strings.ToLower(file.SHA256))
+		}
+	}
+	if len(checksums) == 0 {
+		return ""
+	}
+
+	sort.Strings(checksums)
+	hash := sha256.New()
+	for _, checksum := range checksums {
+		hash.Write([]byte(checksum + "\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IsDue возвращает true, если время запуска задачи не задано или уже наступило, а момент now попадает
+// в окно скачивания задачи
+func (t *Task) IsDue(now time.Time) bool {
+	if t.StartAt != nil && now.Before(*t.StartAt) {
+		return false
+	}
+	return t.DownloadWindow == nil || t.DownloadWindow.Contains(now)
+}
+
+// IsExpired возвращает true, если срок хранения задачи задан и уже истек
+func (t *Task) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// MarkDeleted помечает задачу удаленной (перемещенной в корзину)
+func (t *Task) MarkDeleted(now time.Time) {
+	t.DeletedAt = &now
+	t.UpdatedAt = now
+}
+
+// MarkRestored снимает с задачи отметку об удалении
+func (t *Task) MarkRestored(now time.Time) {
+	t.DeletedAt = nil
+	t.UpdatedAt = now
+}
+
+// IsDeleted возвращает true, если задача находится в корзине
+func (t *Task) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
+// IsActive возвращает true, если задача не удалена и еще не завершена (scheduled, new или processing)
+func (t *Task) IsActive() bool {
+	if t.IsDeleted() {
+		return false
+	}
+	return t.Status == TaskStatusScheduled || t.Status == TaskStatusNew || t.Status == TaskStatusProcessing
+}
+
+// DownloadedBytes возвращает количество скачанных байт и известный суммарный размер файлов задачи
+func (t *Task) DownloadedBytes() (downloaded, total int64) {
+	for _, file := range t.Files {
+		downloaded += file.Downloaded
+		total += file.Size
+	}
+	return downloaded, total
+}
+
+// BytePercent возвращает процент скачанных байт от известного суммарного размера файлов (0, если размер неизвестен)
+func (t *Task) BytePercent() int {
+	downloaded, total := t.DownloadedBytes()
+	if total <= 0 {
+		return 0
+	}
+	return int(downloaded * 100 / total)
+}
+
+// ETA оценивает время до завершения задачи по средней скорости скачивания с начала обработки.
+// Оценка недоступна, если задача не обрабатывается, ничего еще не скачано или размер части файлов неизвестен.
+func (t *Task) ETA(now time.Time) (time.Duration, bool) {
+	if t.Status != TaskStatusProcessing || t.StartedAt == nil {
+		return 0, false
+	}
+	for _, file := range t.Files {
+		if file.Status != "completed" && file.Status != "failed" && file.Size <= 0 {
+			return 0, false
+		}
+	}
+
+	downloaded, total := t.DownloadedBytes()
+	elapsed := now.Sub(*t.StartedAt)
+	if downloaded <= 0 || elapsed <= 0 || total < downloaded {
+		return 0, false
+	}
+
+	remaining := float64(total-downloaded) / (float64(downloaded) / elapsed.Seconds())
+	return time.Duration(remaining * float64(time.Second)), true
+}
+
+// Duration возвращает длительность обработки задачи (для незавершенной - на момент now)
+func (t *Task) Duration(now time.Time) time.Duration {
+	return duration(t.StartedAt, t.FinishedAt, now)
+}
+
+// Duration возвращает длительность скачивания файла (для незавершенного - на момент now)
+func (f *File) Duration(now time.Time) time.Duration {
+	return duration(f.DownloadStartedAt, f.DownloadFinishedAt, now)
+}
+
+// duration вычисляет длительность интервала между отметками времени
+func duration(started, finished *time.Time, now time.Time) time.Duration {
+	if started == nil {
+		return 0
+	}
+	if finished == nil {
+		return now.Sub(*started)
+	}
+	return finished.Sub(*started)
 }
 
 // SetError устанавливает сообщение об ошибке и обновляет статус на failed
-func (t *Task) SetError(err string) {
+func (t *Task) SetError(err string, now time.Time) {
 	t.Error = err
 	t.Status = TaskStatusFailed
-	t.UpdatedAt = time.Now()
+	t.UpdatedAt = now
 }
 
 // IsCompleted возвращает true, если все файлы успешно скачаны
@@ -75,10 +416,40 @@ func (t *Task) IsCompleted() bool {
 	return true
 }
 
-// IsFailed возвращает true, если любой файл не удалось скачать
+// IsFailed возвращает true, если файлы не удалось скачать и ни один файл не скачан
 func (t *Task) IsFailed() bool {
+	failed := false
+	for _, file := range t.Files {
+		switch file.Status {
+		case "completed":
+			return false
+		case "failed":
+			failed = true
+		}
+	}
+	return failed
+}
+
+// IsPartial возвращает true, если обработка всех файлов завершена, часть из них скачана, а часть - с ошибкой
+func (t *Task) IsPartial() bool {
+	completed, failed := 0, 0
+	for _, file := range t.Files {
+		switch file.Status {
+		case "completed":
+			completed++
+		case "failed":
+			failed++
+		default:
+			return false
+		}
+	}
+	return completed > 0 && failed > 0
+}
+
+// IsRetrying возвращает true, если хотя бы один файл ожидает повторной попытки
+func (t *Task) IsRetrying() bool {
 	for _, file := range t.Files {
-		if file.Status == "failed" {
+		if file.Status == "retrying" {
 			return true
 		}
 	}
@@ -1,14 +1,17 @@
 package entities
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
+	_ "time/tzdata"
 )
 
 func TestNewTask(t *testing.T) {
 	urls := []string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"}
 
-	task := NewTask(urls)
+	task := NewTask(urls, time.Now())
 
 	if task.ID.String() == "" {
 		t.Error("Expected task ID to be generated")
@@ -36,13 +39,13 @@ func TestNewTask(t *testing.T) {
 }
 
 func TestUpdateStatus(t *testing.T) {
-	task := NewTask([]string{"https://example.com/file1.jpg"})
+	task := NewTask([]string{"https://example.com/file1.jpg"}, time.Now())
 	originalTime := task.UpdatedAt
 
 	// Wait a bit to ensure time difference
 	time.Sleep(1 * time.Millisecond)
 
-	task.UpdateStatus(TaskStatusProcessing)
+	task.UpdateStatus(TaskStatusProcessing, time.Now())
 
 	if task.Status != TaskStatusProcessing {
 		t.Errorf("Expected status %s, got %s", TaskStatusProcessing, task.Status)
@@ -54,10 +57,10 @@ func TestUpdateStatus(t *testing.T) {
 }
 
 func TestSetError(t *testing.T) {
-	task := NewTask([]string{"https://example.com/file1.jpg"})
+	task := NewTask([]string{"https://example.com/file1.jpg"}, time.Now())
 	errorMsg := "Download failed"
 
-	task.SetError(errorMsg)
+	task.SetError(errorMsg, time.Now())
 
 	if task.Error != errorMsg {
 		t.Errorf("Expected error %s, got %s", errorMsg, task.Error)
@@ -69,7 +72,7 @@ func TestSetError(t *testing.T) {
 }
 
 func TestIsCompleted(t *testing.T) {
-	task := NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"})
+	task := NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"}, time.Now())
 
 	// Initially not completed
 	if task.IsCompleted() {
@@ -94,7 +97,7 @@ func TestIsCompleted(t *testing.T) {
 }
 
 func TestIsFailed(t *testing.T) {
-	task := NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"})
+	task := NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"}, time.Now())
 
 	// Initially not failed
 	if task.IsFailed() {
@@ -107,10 +110,39 @@ func TestIsFailed(t *testing.T) {
 	if !task.IsFailed() {
 		t.Error("Expected task to be failed")
 	}
+
+	// A completed file makes the task partial rather than failed
+	task.Files[1].Status = "completed"
+
+	if task.IsFailed() {
+		t.Error("Expected task with a completed file to not be failed")
+	}
+}
+
+func TestIsPartial(t *testing.T) {
+	task := NewTask([]string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}, time.Now())
+	task.Files[0].Status = "completed"
+	task.Files[1].Status = "failed"
+	task.Files[2].Status = "pending"
+
+	// Not partial while a file is still unfinished
+	if task.IsPartial() {
+		t.Error("Expected task with a pending file to not be partial")
+	}
+
+	task.Files[2].Status = "completed"
+	if !task.IsPartial() {
+		t.Error("Expected task to be partial")
+	}
+
+	task.Files[1].Status = "completed"
+	if task.IsPartial() {
+		t.Error("Expected fully completed task to not be partial")
+	}
 }
 
 func TestGetProgress(t *testing.T) {
-	task := NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"})
+	task := NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"}, time.Now())
 
 	// Initially 0% progress
 	if progress := task.GetProgress(); progress != 0 {
@@ -130,9 +162,281 @@ func TestGetProgress(t *testing.T) {
 	}
 
 	// Empty files should return 0%
-	emptyTask := NewTask([]string{})
+	emptyTask := NewTask([]string{}, time.Now())
 	if progress := emptyTask.GetProgress(); progress != 0 {
 		t.Errorf("Expected 0%% progress for empty task, got %d%%", progress)
 	}
 }
 
+func TestIsRetrying(t *testing.T) {
+	task := NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"}, time.Now())
+
+	// Initially not retrying
+	if task.IsRetrying() {
+		t.Error("Expected task to not be retrying initially")
+	}
+
+	// Mark one file as waiting for retry
+	task.Files[1].Status = "retrying"
+
+	if !task.IsRetrying() {
+		t.Error("Expected task to be retrying")
+	}
+}
+
+func TestTaskDuration(t *testing.T) {
+	now := time.Now()
+	task := NewTask([]string{"https://example.com/file1.jpg"}, now)
+
+	// Not started yet
+	if d := task.Duration(now); d != 0 {
+		t.Errorf("Expected zero duration before start, got %v", d)
+	}
+
+	started := now.Add(-3 * time.Second)
+	finished := started.Add(2 * time.Second)
+	task.StartedAt = &started
+	task.FinishedAt = &finished
+
+	if d := task.Duration(now); d != 2*time.Second {
+		t.Errorf("Expected duration 2s, got %v", d)
+	}
+
+	// In progress duration is measured until now
+	task.FinishedAt = nil
+	if d := task.Duration(now); d != 3*time.Second {
+		t.Errorf("Expected in-progress duration of 3s, got %v", d)
+	}
+
+	task.Files[0].DownloadStartedAt = &started
+	task.Files[0].DownloadFinishedAt = &finished
+	if d := task.Files[0].Duration(now); d != 2*time.Second {
+		t.Errorf("Expected file duration 2s, got %v", d)
+	}
+}
+
+func TestTaskETA(t *testing.T) {
+	task := NewTask([]string{"https://example.com/a", "https://example.com/b"}, time.Now())
+	started := time.Now().Add(-10 * time.Second)
+	task.StartedAt = &started
+	task.Status = TaskStatusProcessing
+	task.Files[0] = File{Status: "completed", Size: 100, Downloaded: 100}
+	task.Files[1] = File{Status: "downloading", Size: 300, Downloaded: 100}
+
+	eta, ok := task.ETA(started.Add(10 * time.Second))
+	if !ok {
+		t.Fatal("Expected ETA to be available")
+	}
+	if eta != 10*time.Second {
+		t.Errorf("Expected ETA 10s, got %v", eta)
+	}
+	if percent := task.BytePercent(); percent != 50 {
+		t.Errorf("Expected byte progress 50, got %d", percent)
+	}
+
+	task.Files[1].Size = 0
+	if _, ok := task.ETA(time.Now()); ok {
+		t.Error("Expected no ETA while a file size is unknown")
+	}
+
+	task.Files[1].Size = 300
+	task.Status = TaskStatusCompleted
+	if _, ok := task.ETA(time.Now()); ok {
+		t.Error("Expected no ETA for a task that is not processing")
+	}
+}
+
+func TestTaskRetryPolicyJSON(t *testing.T) {
+	// Setup
+	data := []byte(`{"max_attempts": 5, "backoff": "500ms", "max_backoff": "1m", "retry_on": ["5xx", "timeout"]}`)
+
+	// Execute
+	var policy TaskRetryPolicy
+	err := json.Unmarshal(data, &policy)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if policy.MaxAttempts != 5 || time.Duration(policy.Backoff) != 500*time.Millisecond || time.Duration(policy.MaxBackoff) != time.Minute {
+		t.Errorf("Expected 5 attempts with 500ms..1m backoff, got %+v", policy)
+	}
+	encoded, _ := json.Marshal(policy)
+	if !strings.Contains(string(encoded), `"backoff":"500ms"`) {
+		t.Errorf("Expected backoff encoded as duration string, got %s", encoded)
+	}
+	if err := json.Unmarshal([]byte(`{"backoff": 5}`), &policy); err == nil {
+		t.Error("Expected error for numeric backoff, got nil")
+	}
+}
+
+func TestAggregateChecksum(t *testing.T) {
+	// Setup
+	task := NewTask([]string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}, time.Now())
+	task.Files[0] = File{Status: "completed", SHA256: "BBBB"}
+	task.Files[1] = File{Status: "completed", SHA256: "aaaa"}
+	task.Files[2] = File{Status: "failed", SHA256: "cccc"}
+	reordered := task.Clone()
+	reordered.Files[0], reordered.Files[1] = reordered.Files[1], reordered.Files[0]
+
+	// Execute
+	task.MarkFinished(time.Now())
+	checksum := task.Checksum
+
+	// Assert
+	if len(checksum) != 64 {
+		t.Fatalf("Expected a hex SHA-256 checksum, got %q", checksum)
+	}
+	if reordered.AggregateChecksum() != checksum {
+		t.Error("Expected checksum not to depend on file order")
+	}
+	reordered.Files[0].SHA256 = "dddd"
+	if reordered.AggregateChecksum() == checksum {
+		t.Error("Expected checksum to change when a file changes")
+	}
+	if empty := NewTask([]string{"https://example.com/a"}, time.Now()).AggregateChecksum(); empty != "" {
+		t.Errorf("Expected empty checksum without completed files, got %q", empty)
+	}
+	task.MarkStarted(time.Now())
+	if task.Checksum != "" {
+		t.Errorf("Expected checksum to be cleared when processing restarts, got %q", task.Checksum)
+	}
+}
+
+func TestNewShutdownReport(t *testing.T) {
+	// Setup
+	now := time.Now()
+	interrupted := NewTask([]string{"https://example.com/a.bin", "https://example.com/b.bin", "https://example.com/c.bin"}, now)
+	interrupted.Files[0] = File{URL: "https://example.com/a.bin", Status: "completed"}
+	interrupted.Files[1] = File{URL: "https://example.com/b.bin", Status: "pending", PartialPath: "/downloads/b.bin.part", ResumeOffset: 512, Downloaded: 512, Size: 1024}
+	interrupted.Files[2] = File{URL: "https://example.com/c.bin", Status: "pending"}
+	abandoned := NewTask([]string{"https://example.com/d.bin"}, now)
+	abandoned.Files[0] = File{URL: "https://example.com/d.bin", Status: "downloading", Path: "/downloads/d.bin", Downloaded: 100}
+	abandoned.Status = TaskStatusProcessing
+	finished := NewTask([]string{"https://example.com/e.bin"}, now)
+	finished.Status = TaskStatusCompleted
+
+	// Execute
+	report := NewShutdownReport([]*Task{interrupted, abandoned, finished}, map[string]bool{abandoned.ID.String(): true}, now)
+
+	// Assert
+	if len(report.Tasks) != 2 {
+		t.Fatalf("Expected 2 unfinished tasks, got %d", len(report.Tasks))
+	}
+	byID := map[string]UnfinishedTask{}
+	for _, task := range report.Tasks {
+		byID[task.ID] = task
+	}
+	first := byID[interrupted.ID.String()]
+	if first.Abandoned || first.PendingFiles != 1 || len(first.Files) != 1 {
+		t.Fatalf("Expected 1 interrupted and 1 pending file, got %+v", first)
+	}
+	if file := first.Files[0]; file.Index != 1 || file.Offset != 512 || file.Path != "/downloads/b.bin.part" {
+		t.Errorf("Expected file 1 interrupted at offset 512 in the partial file, got %+v", file)
+	}
+	second := byID[abandoned.ID.String()]
+	if !second.Abandoned || len(second.Files) != 1 || second.Files[0].Offset != 100 {
+		t.Errorf("Expected abandoned task with a file at offset 100, got %+v", second)
+	}
+}
+
+func TestDownloadWindowContains(t *testing.T) {
+	testCases := []struct {
+		name     string
+		window   DownloadWindow
+		at       time.Time
+		contains bool
+		opens    time.Time
+	}{
+		{"inside daytime window", DownloadWindow{Start: "09:00", End: "17:00"}, time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC), true, time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)},
+		{"end is exclusive", DownloadWindow{Start: "09:00", End: "17:00"}, time.Date(2025, 10, 1, 17, 0, 0, 0, time.UTC), false, time.Date(2025, 10, 2, 9, 0, 0, 0, time.UTC)},
+		{"before overnight window", DownloadWindow{Start: "22:00", End: "06:00"}, time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC), false, time.Date(2025, 10, 1, 22, 0, 0, 0, time.UTC)},
+		{"after midnight in overnight window", DownloadWindow{Start: "22:00", End: "06:00"}, time.Date(2025, 10, 1, 3, 0, 0, 0, time.UTC), true, time.Date(2025, 10, 1, 3, 0, 0, 0, time.UTC)},
+		{"window in another timezone", DownloadWindow{Start: "01:00", End: "05:00", Timezone: "Europe/Moscow"}, time.Date(2025, 10, 1, 23, 0, 0, 0, time.UTC), true, time.Date(2025, 10, 1, 23, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			contains := tc.window.Contains(tc.at)
+			opens := tc.window.NextOpen(tc.at)
+
+			// Assert
+			if contains != tc.contains {
+				t.Errorf("Expected contains %v, got %v", tc.contains, contains)
+			}
+			if !opens.Equal(tc.opens) {
+				t.Errorf("Expected window to open at %v, got %v", tc.opens, opens)
+			}
+		})
+	}
+}
+
+func TestDownloadWindowValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		window DownloadWindow
+		valid  bool
+	}{
+		{"valid", DownloadWindow{Start: "22:00", End: "06:00", Timezone: "Europe/Moscow"}, true},
+		{"bad start", DownloadWindow{Start: "25:00", End: "06:00"}, false},
+		{"empty end", DownloadWindow{Start: "22:00"}, false},
+		{"empty window", DownloadWindow{Start: "06:00", End: "06:00"}, false},
+		{"unknown timezone", DownloadWindow{Start: "22:00", End: "06:00", Timezone: "Mars/Olympus"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			err := tc.window.Validate()
+
+			// Assert
+			if (err == nil) != tc.valid {
+				t.Errorf("Expected valid %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestNewManifestIncludesExtractedFiles(t *testing.T) {
+	// Setup
+	task := NewTask([]string{"https://example.com/archive.zip"}, time.Now())
+	task.Files[0] = File{URL: task.URLs[0], Status: "completed", Path: "/data/archive.zip",
+		Extracted: []string{"/data/archive/a.txt", "/data/archive/b.txt"}}
+
+	// Execute
+	manifest := NewManifest(task)
+	task.Files[0].Extracted[0] = "/data/changed.txt"
+
+	// Assert
+	extracted := manifest.Files[0].Extracted
+	if len(extracted) != 2 || extracted[0] != "/data/archive/a.txt" || extracted[1] != "/data/archive/b.txt" {
+		t.Errorf("Expected a copy of the extracted paths, got %v", extracted)
+	}
+}
+
+func TestManifestChecksumRoundTrip(t *testing.T) {
+	// Setup
+	task := NewTask([]string{"https://example.com/a", "https://example.com/b"}, time.Now())
+	task.Files[0] = File{URL: task.URLs[0], Status: "completed", SHA256: strings.Repeat("a", 64)}
+	task.Files[1] = File{URL: task.URLs[1], Status: "completed", SHA256: strings.Repeat("b", 64)}
+	task.MarkFinished(time.Now())
+
+	// Execute
+	data, err := json.Marshal(NewManifest(task))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var decoded Manifest
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if task.Checksum == "" || decoded.Checksum != task.Checksum {
+		t.Errorf("Expected manifest checksum %q, got %q", task.Checksum, decoded.Checksum)
+	}
+	if !strings.Contains(string(data), `"checksum":"`+task.Checksum+`"`) {
+		t.Errorf("Expected checksum field in manifest JSON, got %s", data)
+	}
+}
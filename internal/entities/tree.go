@@ -0,0 +1,29 @@
+package entities
+
+// TreeNodeType представляет тип элемента дерева файлов задачи
+type TreeNodeType string
+
+const (
+	TreeNodeDir     TreeNodeType = "dir"
+	TreeNodeFile    TreeNodeType = "file"
+	TreeNodeSymlink TreeNodeType = "symlink" // символическая ссылка показывается, но не раскрывается
+)
+
+// TreeNode - директория или файл в дереве результата задачи на диске
+type TreeNode struct {
+	Name     string       `json:"name"`
+	Type     TreeNodeType `json:"type"`
+	Size     int64        `json:"size"`               // размер файла; для директории - суммарный размер вложенных файлов
+	Children []*TreeNode  `json:"children,omitempty"` // вложенные элементы директории по имени
+}
+
+// TaskTree содержит дерево директорий и файлов, в которые скачаны файлы задачи
+type TaskTree struct {
+	TaskID    string    `json:"task_id"`
+	Root      string    `json:"root"` // директория скачивания задачи, относительно которой построено дерево
+	Tree      *TreeNode `json:"tree"`
+	Dirs      int       `json:"dirs"`
+	Files     int       `json:"files"`
+	Size      int64     `json:"size"`
+	Truncated bool      `json:"truncated,omitempty"` // дерево обрезано по глубине или числу элементов
+}
@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// URLValidationError описывает проблему с одним URL из запроса
+type URLValidationError struct {
+	Index  int    `json:"index"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// ValidationError содержит все ошибки валидации URL из одного запроса
+type ValidationError struct {
+	Errors []URLValidationError `json:"invalid_urls"`
+}
+
+// Add добавляет ошибку валидации URL
+func (e *ValidationError) Add(index int, url, reason string) {
+	e.Errors = append(e.Errors, URLValidationError{Index: index, URL: url, Reason: reason})
+}
+
+// HasErrors возвращает true, если найдена хотя бы одна ошибка
+func (e *ValidationError) HasErrors() bool {
+	return len(e.Errors) > 0
+}
+
+// Error реализует интерфейс error
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		reasons = append(reasons, fmt.Sprintf("[%d] %s", item.Index, item.Reason))
+	}
+	return fmt.Sprintf("неверные URL (%d): %s", len(e.Errors), strings.Join(reasons, "; "))
+}
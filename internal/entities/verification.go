@@ -0,0 +1,32 @@
+package entities
+
+// VerificationStatus представляет результат проверки файла на диске
+type VerificationStatus string
+
+const (
+	VerificationOK               VerificationStatus = "ok"
+	VerificationMissing          VerificationStatus = "missing"
+	VerificationSizeMismatch     VerificationStatus = "size_mismatch"
+	VerificationChecksumMismatch VerificationStatus = "checksum_mismatch"
+	VerificationError            VerificationStatus = "error"
+	VerificationSkipped          VerificationStatus = "skipped" // файл не был скачан, проверять нечего
+)
+
+// FileVerification содержит результат проверки одного файла задачи
+type FileVerification struct {
+	Index        int                `json:"index"`
+	URL          string             `json:"url"`
+	Path         string             `json:"path,omitempty"`
+	Status       VerificationStatus `json:"status"`
+	ExpectedSize int64              `json:"expected_size,omitempty"`
+	ActualSize   int64              `json:"actual_size,omitempty"`
+	Error        string             `json:"error,omitempty"`
+	Requeued     bool               `json:"requeued,omitempty"`
+}
+
+// VerificationReport содержит результаты проверки файлов задачи
+type VerificationReport struct {
+	TaskID string             `json:"task_id"`
+	Files  []FileVerification `json:"files"`
+	Failed int                `json:"failed"`
+}
@@ -0,0 +1,95 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+)
+
+// DownloadWindow - время суток, в которое разрешено скачивание задачи (например, ночью, чтобы не нагружать источник)
+type DownloadWindow struct {
+	Start    string `json:"start"`              // начало окна в формате ЧЧ:ММ
+	End      string `json:"end"`                // конец окна в формате ЧЧ:ММ; раньше начала - окно переходит через полночь
+	Timezone string `json:"timezone,omitempty"` // часовой пояс IANA, например Europe/Moscow (по умолчанию UTC)
+}
+
+// Validate проверяет формат времени и часовой пояс окна
+func (w DownloadWindow) Validate() error {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return fmt.Errorf("неверное начало окна %q: ожидается ЧЧ:ММ", w.Start)
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return fmt.Errorf("неверный конец окна %q: ожидается ЧЧ:ММ", w.End)
+	}
+	if start == end {
+		return fmt.Errorf("начало и конец окна совпадают: %s", w.Start)
+	}
+	if _, err := w.location(); err != nil {
+		return fmt.Errorf("неизвестный часовой пояс %q", w.Timezone)
+	}
+	return nil
+}
+
+// Contains возвращает true, если момент now попадает в окно. Окно с неверными параметрами не ограничивает
+// скачивание: параметры проверяются при создании задачи.
+func (w DownloadWindow) Contains(now time.Time) bool {
+	start, end, loc, err := w.parse()
+	if err != nil {
+		return true
+	}
+	local := now.In(loc)
+	minute := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// NextOpen возвращает ближайший момент не раньше now, когда окно открыто
+func (w DownloadWindow) NextOpen(now time.Time) time.Time {
+	start, _, loc, err := w.parse()
+	if err != nil || w.Contains(now) {
+		return now
+	}
+	local := now.In(loc)
+	opens := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc).Add(start)
+	if !opens.After(local) {
+		opens = opens.AddDate(0, 0, 1)
+	}
+	return opens
+}
+
+// parse разбирает начало и конец окна как смещения от полуночи и часовой пояс
+func (w DownloadWindow) parse() (time.Duration, time.Duration, *time.Location, error) {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	loc, err := w.location()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return start, end, loc, nil
+}
+
+// location возвращает часовой пояс окна
+func (w DownloadWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+// parseClock разбирает время суток ЧЧ:ММ как смещение от полуночи
+func parseClock(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
@@ -0,0 +1,94 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// CallbackDispatcher периодически отправляет уведомления о завершении задач, ожидающие доставки
+type CallbackDispatcher struct {
+	downloadUsecase interfaces.DownloadUsecase
+	interval        time.Duration
+	clock           interfaces.Clock
+	completed       <-chan entities.TaskEvent // события завершения задач, по которым отправка начинается сразу
+	unsubscribe     func()
+}
+
+// CallbackDispatcherOption настраивает отправку уведомлений
+type CallbackDispatcherOption func(*CallbackDispatcher)
+
+// WithDispatcherClock задает источник времени отправителя уведомлений (по умолчанию - системное время)
+func WithDispatcherClock(clock interfaces.Clock) CallbackDispatcherOption {
+	return func(d *CallbackDispatcher) {
+		d.clock = clock
+	}
+}
+
+// WithDispatcherEvents подписывает отправитель на завершение задач: уведомление отправляется сразу после
+// завершения, не дожидаясь очередного опроса
+func WithDispatcherEvents(bus interfaces.EventSubscriber) CallbackDispatcherOption {
+	return func(d *CallbackDispatcher) {
+		d.completed, d.unsubscribe = bus.Subscribe(entities.EventTaskCompleted)
+	}
+}
+
+// NewCallbackDispatcher создает отправитель уведомлений с интервалом опроса interval
+func NewCallbackDispatcher(downloadUsecase interfaces.DownloadUsecase, interval time.Duration, opts ...CallbackDispatcherOption) *CallbackDispatcher {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	d := &CallbackDispatcher{
+		downloadUsecase: downloadUsecase,
+		interval:        interval,
+		clock:           SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Run запускает цикл отправки уведомлений до отмены контекста
+func (d *CallbackDispatcher) Run(ctx context.Context) {
+	if d.unsubscribe != nil {
+		defer d.unsubscribe()
+	}
+	for {
+		if _, err := d.downloadUsecase.DeliverCallbacks(ctx); err != nil {
+			log.Printf("Ошибка отправки уведомлений о завершении задач: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.clock.After(d.interval):
+		case _, ok := <-d.completed:
+			if !ok {
+				d.completed = nil
+			}
+			d.drainCompleted()
+		}
+	}
+}
+
+// drainCompleted пропускает накопившиеся события завершения: одна отправка обрабатывает все завершенные задачи
+func (d *CallbackDispatcher) drainCompleted() {
+	for {
+		select {
+		case _, ok := <-d.completed:
+			if !ok {
+				d.completed = nil
+				return
+			}
+		default:
+			return
+		}
+	}
+}
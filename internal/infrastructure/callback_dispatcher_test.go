@@ -0,0 +1,42 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestCallbackDispatcherDeliversOnTaskCompleted(t *testing.T) {
+	// Setup
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool)}
+	bus := NewEventBus()
+	clock := testutil.NewFakeClock(time.Now())
+	dispatcher := NewCallbackDispatcher(usecase, time.Hour, WithDispatcherClock(clock), WithDispatcherEvents(bus))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+	waitFor(t, func() bool { return usecase.deliveryCount() == 1 })
+
+	// Execute: the poll interval has not elapsed, but a task completes
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+	bus.Publish(entities.NewFileEvent(entities.EventFileCompleted, task, 0, time.Now()))
+	bus.Publish(entities.NewTaskEvent(entities.EventTaskCompleted, task, time.Now()))
+
+	// Assert
+	waitFor(t, func() bool { return usecase.deliveryCount() == 2 })
+}
+
+// waitFor polls condition until it holds or the test times out
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected condition to hold within 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,30 @@
+package infrastructure
+
+import (
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// SystemClock реализует Clock через системное время
+type SystemClock struct{}
+
+// NewSystemClock создает часы, использующие системное время
+func NewSystemClock() interfaces.Clock {
+	return SystemClock{}
+}
+
+// Now возвращает текущее время
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep приостанавливает выполнение на указанное время
+func (SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// After возвращает канал, в который время будет отправлено по истечении d
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
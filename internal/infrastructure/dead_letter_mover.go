@@ -0,0 +1,69 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// DeadLetterMover периодически переносит окончательно неудачные задачи в хранилище неудачных задач
+type DeadLetterMover struct {
+	taskUsecase interfaces.TaskUsecase
+	interval    time.Duration
+	clock       interfaces.Clock
+}
+
+// DeadLetterMoverOption настраивает перенос неудачных задач
+type DeadLetterMoverOption func(*DeadLetterMover)
+
+// WithDeadLetterClock задает источник времени (по умолчанию - системное время)
+func WithDeadLetterClock(clock interfaces.Clock) DeadLetterMoverOption {
+	return func(m *DeadLetterMover) {
+		m.clock = clock
+	}
+}
+
+// NewDeadLetterMover создает цикл переноса неудачных задач
+func NewDeadLetterMover(taskUsecase interfaces.TaskUsecase, interval time.Duration, opts ...DeadLetterMoverOption) *DeadLetterMover {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	m := &DeadLetterMover{
+		taskUsecase: taskUsecase,
+		interval:    interval,
+		clock:       SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Run переносит неудачные задачи каждые interval до отмены контекста
+func (m *DeadLetterMover) Run(ctx context.Context) {
+	for {
+		m.move(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.clock.After(m.interval):
+		}
+	}
+}
+
+// move переносит задачи, окончательно завершившиеся ошибкой
+func (m *DeadLetterMover) move(ctx context.Context) {
+	moved, err := m.taskUsecase.DeadLetterTasks(ctx, m.clock.Now())
+	if err != nil {
+		log.Printf("Ошибка переноса неудачных задач: %v", err)
+	}
+	if moved > 0 {
+		log.Printf("Перенесено неудачных задач: %d", moved)
+	}
+}
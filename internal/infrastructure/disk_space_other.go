@@ -0,0 +1,10 @@
+//go:build !unix
+
+package infrastructure
+
+import "errors"
+
+// freeDiskSpace не поддерживается на этой платформе
+func freeDiskSpace(dir string) (int64, error) {
+	return 0, errors.New("проверка свободного места не поддерживается на этой платформе")
+}
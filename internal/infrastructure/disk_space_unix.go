@@ -0,0 +1,14 @@
+//go:build unix
+
+package infrastructure
+
+import "syscall"
+
+// freeDiskSpace возвращает число байт, доступных непривилегированному процессу на файловой системе dir
+func freeDiskSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
@@ -0,0 +1,164 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// DiskWatchdog периодически проверяет свободное место на диске директории скачивания. Пока его меньше
+// порога, ожидающие места скачивания не начинаются, а после освобождения места продолжаются.
+type DiskWatchdog struct {
+	dir       string
+	minFree   int64
+	interval  time.Duration
+	clock     interfaces.Clock
+	metrics   interfaces.MetricsRecorder
+	freeSpace func(dir string) (int64, error)
+
+	mu      sync.Mutex
+	low     bool
+	free    int64
+	resumed chan struct{} // закрывается, когда свободного места снова достаточно
+}
+
+// DiskWatchdogOption настраивает проверку свободного места на диске
+type DiskWatchdogOption func(*DiskWatchdog)
+
+// WithDiskClock задает источник времени (по умолчанию - системное время)
+func WithDiskClock(clock interfaces.Clock) DiskWatchdogOption {
+	return func(w *DiskWatchdog) {
+		w.clock = clock
+	}
+}
+
+// WithDiskMetrics задает реестр метрик свободного места и признака его нехватки
+func WithDiskMetrics(metrics interfaces.MetricsRecorder) DiskWatchdogOption {
+	return func(w *DiskWatchdog) {
+		w.metrics = metrics
+	}
+}
+
+// NewDiskWatchdog создает проверку свободного места на диске директории dir каждые interval:
+// при свободном месте меньше minFree байт скачивание приостанавливается
+func NewDiskWatchdog(dir string, minFree int64, interval time.Duration, opts ...DiskWatchdogOption) *DiskWatchdog {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	w := &DiskWatchdog{
+		dir:       dir,
+		minFree:   minFree,
+		interval:  interval,
+		clock:     SystemClock{},
+		freeSpace: freeDiskSpace,
+		resumed:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Run проверяет свободное место каждые interval до отмены контекста
+func (w *DiskWatchdog) Run(ctx context.Context) {
+	for {
+		w.check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.clock.After(w.interval):
+		}
+	}
+}
+
+// check измеряет свободное место и обновляет признак его нехватки. Ошибка измерения не меняет признак,
+// чтобы сбой проверки не останавливал и не возобновлял скачивание.
+func (w *DiskWatchdog) check() {
+	free, err := w.freeSpace(w.dir)
+	if err != nil {
+		log.Printf("Не удалось проверить свободное место в %s: %v", w.dir, err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.free = free
+	low := free < w.minFree
+	switch {
+	case low && !w.low:
+		log.Printf("Скачивание приостановлено: свободно %d байт в %s при минимуме %d", free, w.dir, w.minFree)
+	case !low && w.low:
+		log.Printf("Скачивание возобновлено: свободно %d байт в %s", free, w.dir)
+		close(w.resumed)
+		w.resumed = make(chan struct{})
+	}
+	w.low = low
+	w.report()
+}
+
+// report записывает метрики свободного места (вызывающий должен держать блокировку)
+func (w *DiskWatchdog) report() {
+	if w.metrics == nil {
+		return
+	}
+
+	lowValue := 0.0
+	if w.low {
+		lowValue = 1
+	}
+	w.metrics.SetGauge("downloader_disk_free_bytes", nil, float64(w.free))
+	w.metrics.SetGauge("downloader_disk_low", nil, lowValue)
+}
+
+// WaitForSpace ждет, пока свободного места станет не меньше порога
+func (w *DiskWatchdog) WaitForSpace(ctx context.Context) error {
+	for {
+		w.mu.Lock()
+		low, resumed := w.low, w.resumed
+		w.mu.Unlock()
+		if !low {
+			return nil
+		}
+
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Health возвращает ошибку, пока скачивание приостановлено из-за нехватки места
+func (w *DiskWatchdog) Health() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.low {
+		return fmt.Errorf("свободно %d байт при минимуме %d, скачивание приостановлено", w.free, w.minFree)
+	}
+	return nil
+}
+
+// WithDiskSpacePause приостанавливает раздачу задач воркерам, пока gate сообщает о нехватке места на диске:
+// задачи остаются в очереди. Без этой настройки задачи раздаются, а ждут только скачивания файлов.
+func WithDiskSpacePause(gate interfaces.DiskSpaceGate) WorkerPoolOption {
+	return func(wp *WorkerPool) {
+		wp.diskSpace = gate
+	}
+}
+
+// waitDiskSpace ждет свободного места на диске перед раздачей задачи.
+// Возвращает false, если пул остановлен во время ожидания.
+func (wp *WorkerPool) waitDiskSpace() bool {
+	if wp.diskSpace == nil {
+		return true
+	}
+	return wp.diskSpace.WaitForSpace(wp.ctx) == nil
+}
@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// newTestDiskWatchdog creates a watchdog reporting the free space stored in free
+func newTestDiskWatchdog(free *atomic.Int64, metrics *MetricsRegistry) *DiskWatchdog {
+	w := NewDiskWatchdog("/downloads", 100, time.Second, WithDiskMetrics(metrics))
+	w.freeSpace = func(string) (int64, error) { return free.Load(), nil }
+	return w
+}
+
+func TestDiskWatchdogPausesUntilSpaceIsReclaimed(t *testing.T) {
+	// Setup
+	metrics := NewMetricsRegistry()
+	var free atomic.Int64
+	free.Store(50)
+	watchdog := newTestDiskWatchdog(&free, metrics)
+	watchdog.check()
+	waited := make(chan error, 1)
+
+	// Execute
+	go func() { waited <- watchdog.WaitForSpace(context.Background()) }()
+
+	// Assert
+	if watchdog.Health() == nil {
+		t.Fatal("Expected watchdog to report low disk space")
+	}
+	if value := metrics.Value("downloader_disk_low", nil); value != 1 {
+		t.Errorf("Expected disk_low gauge 1, got %v", value)
+	}
+	if value := metrics.Value("downloader_disk_free_bytes", nil); value != 50 {
+		t.Errorf("Expected disk_free_bytes gauge 50, got %v", value)
+	}
+	select {
+	case err := <-waited:
+		t.Fatalf("Expected wait to block while space is low, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Execute: space is reclaimed
+	free.Store(200)
+	watchdog.check()
+
+	// Assert
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected wait to finish after space is reclaimed")
+	}
+	if watchdog.Health() != nil {
+		t.Error("Expected watchdog to report enough disk space")
+	}
+	if value := metrics.Value("downloader_disk_low", nil); value != 0 {
+		t.Errorf("Expected disk_low gauge 0, got %v", value)
+	}
+}
+
+func TestDiskWatchdogWaitStopsOnCancel(t *testing.T) {
+	// Setup
+	var free atomic.Int64
+	watchdog := newTestDiskWatchdog(&free, NewMetricsRegistry())
+	watchdog.check()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Execute
+	err := watchdog.WaitForSpace(ctx)
+
+	// Assert
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestWorkerPoolPausesDispatchOnLowDiskSpace(t *testing.T) {
+	// Setup
+	var free atomic.Int64
+	watchdog := newTestDiskWatchdog(&free, NewMetricsRegistry())
+	watchdog.check()
+	task := entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now())
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool), tasks: []*entities.Task{task}}
+	pool := NewWorkerPool(1, usecase, WithDiskSpacePause(watchdog))
+	pool.Start()
+	defer pool.Stop()
+
+	// Execute
+	if err := pool.AddTask(task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// Assert
+	if usecase.processedCount() != 0 {
+		t.Fatalf("Expected no task to be dispatched on low disk space, got %d", usecase.processedCount())
+	}
+
+	// Execute: space is reclaimed
+	free.Store(200)
+	watchdog.check()
+
+	// Assert
+	waitFor(t, func() bool { return usecase.processedCount() == 1 })
+}
@@ -0,0 +1,96 @@
+package infrastructure
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"file-downloader/internal/entities"
+)
+
+// eventSubscriberBuffer - число событий, которые подписчик может не успеть прочитать;
+// при переполнении новые события для него пропускаются, чтобы медленный подписчик не задерживал воркеры
+const eventSubscriberBuffer = 1024
+
+// eventSubscription - подписка на события выбранных типов
+type eventSubscription struct {
+	ch    chan entities.TaskEvent
+	types map[entities.EventType]bool // nil - все типы
+}
+
+// EventBus рассылает события жизненного цикла задач подписчикам внутри процесса.
+// Публикующий (use case скачивания) не знает о подписчиках: метрики, уведомления и другие наблюдатели
+// подписываются на шину независимо друг от друга.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[*eventSubscription]struct{}
+	closed      bool
+	dropped     atomic.Int64
+}
+
+// NewEventBus создает шину событий без подписчиков
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[*eventSubscription]struct{})}
+}
+
+// Publish отправляет событие подписчикам, не дожидаясь их
+func (b *EventBus) Publish(event entities.TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		if sub.types != nil && !sub.types[event.Type] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe подписывает на события указанных типов, опубликованные после подписки
+func (b *EventBus) Subscribe(types ...entities.EventType) (<-chan entities.TaskEvent, func()) {
+	sub := &eventSubscription{ch: make(chan entities.TaskEvent, eventSubscriberBuffer)}
+	if len(types) > 0 {
+		sub.types = make(map[entities.EventType]bool, len(types))
+		for _, eventType := range types {
+			sub.types[eventType] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	b.subscribers[sub] = struct{}{}
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[sub]; ok {
+				delete(b.subscribers, sub)
+				close(sub.ch)
+			}
+		})
+	}
+}
+
+// Dropped возвращает количество событий, пропущенных из-за переполнения буферов подписчиков
+func (b *EventBus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Close завершает рассылку и закрывает каналы всех подписчиков (при остановке сервиса)
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,73 @@
+package infrastructure
+
+import (
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestEventBusFiltersByType(t *testing.T) {
+	// Setup
+	bus := NewEventBus()
+	all, unsubscribeAll := bus.Subscribe()
+	defer unsubscribeAll()
+	completed, unsubscribeCompleted := bus.Subscribe(entities.EventTaskCompleted)
+	defer unsubscribeCompleted()
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+
+	// Execute
+	bus.Publish(entities.NewFileEvent(entities.EventFileStarted, task, 0, time.Now()))
+	bus.Publish(entities.NewTaskEvent(entities.EventTaskCompleted, task, time.Now()))
+
+	// Assert
+	if len(all) != 2 {
+		t.Errorf("Expected 2 events for the unfiltered subscriber, got %d", len(all))
+	}
+	if len(completed) != 1 {
+		t.Fatalf("Expected 1 event for the filtered subscriber, got %d", len(completed))
+	}
+	if event := <-completed; event.Type != entities.EventTaskCompleted || event.TaskID != task.ID {
+		t.Errorf("Expected task_completed for task %s, got %s for %s", task.ID, event.Type, event.TaskID)
+	}
+}
+
+func TestEventBusDropsEventsForSlowSubscriber(t *testing.T) {
+	// Setup
+	bus := NewEventBus()
+	events, unsubscribe := bus.Subscribe()
+	defer unsubscribe()
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+
+	// Execute
+	for i := 0; i < eventSubscriberBuffer+5; i++ {
+		bus.Publish(entities.NewTaskEvent(entities.EventTaskCreated, task, time.Now()))
+	}
+
+	// Assert
+	if len(events) != eventSubscriberBuffer {
+		t.Errorf("Expected %d buffered events, got %d", eventSubscriberBuffer, len(events))
+	}
+	if dropped := bus.Dropped(); dropped != 5 {
+		t.Errorf("Expected 5 dropped events, got %d", dropped)
+	}
+}
+
+func TestEventBusCloseClosesSubscriptions(t *testing.T) {
+	// Setup
+	bus := NewEventBus()
+	events, unsubscribe := bus.Subscribe()
+
+	// Execute
+	bus.Close()
+	unsubscribe()
+	late, _ := bus.Subscribe()
+
+	// Assert
+	if _, ok := <-events; ok {
+		t.Error("Expected channel to be closed after Close")
+	}
+	if _, ok := <-late; ok {
+		t.Error("Expected subscription after Close to be closed")
+	}
+}
@@ -0,0 +1,51 @@
+package infrastructure
+
+import (
+	"context"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// EventMetrics ведет метрики жизненного цикла задач по событиям шины
+type EventMetrics struct {
+	events      <-chan entities.TaskEvent
+	unsubscribe func()
+	metrics     interfaces.MetricsRecorder
+}
+
+// NewEventMetrics подписывает метрики на события шины; события учитываются после запуска Run
+func NewEventMetrics(bus interfaces.EventSubscriber, metrics interfaces.MetricsRecorder) *EventMetrics {
+	events, unsubscribe := bus.Subscribe()
+	return &EventMetrics{events: events, unsubscribe: unsubscribe, metrics: metrics}
+}
+
+// Run учитывает события до отмены контекста или закрытия шины
+func (m *EventMetrics) Run(ctx context.Context) {
+	defer m.unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-m.events:
+			if !ok {
+				return
+			}
+			m.record(event)
+		}
+	}
+}
+
+// record обновляет метрики по событию
+func (m *EventMetrics) record(event entities.TaskEvent) {
+	switch event.Type {
+	case entities.EventFileProgress:
+		// Прогресс публикуется часто и не учитывается в счетчике событий
+		return
+	case entities.EventFileCompleted:
+		m.metrics.IncCounter("downloader_downloaded_bytes_total", nil, float64(event.File.Size))
+	case entities.EventTaskCompleted:
+		m.metrics.IncCounter("downloader_tasks_finished_total", map[string]string{"status": string(event.Status)}, 1)
+	}
+	m.metrics.IncCounter("downloader_task_events_total", map[string]string{"type": string(event.Type)}, 1)
+}
@@ -0,0 +1,69 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// ExpiryReaper периодически удаляет задачи, срок хранения которых истек
+type ExpiryReaper struct {
+	taskUsecase interfaces.TaskUsecase
+	interval    time.Duration
+	clock       interfaces.Clock
+}
+
+// ExpiryReaperOption настраивает удаление задач с истекшим сроком хранения
+type ExpiryReaperOption func(*ExpiryReaper)
+
+// WithReaperClock задает источник времени (по умолчанию - системное время)
+func WithReaperClock(clock interfaces.Clock) ExpiryReaperOption {
+	return func(r *ExpiryReaper) {
+		r.clock = clock
+	}
+}
+
+// NewExpiryReaper создает цикл удаления задач с истекшим сроком хранения
+func NewExpiryReaper(taskUsecase interfaces.TaskUsecase, interval time.Duration, opts ...ExpiryReaperOption) *ExpiryReaper {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	r := &ExpiryReaper{
+		taskUsecase: taskUsecase,
+		interval:    interval,
+		clock:       SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run проверяет сроки хранения задач каждые interval до отмены контекста
+func (r *ExpiryReaper) Run(ctx context.Context) {
+	for {
+		r.reap(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(r.interval):
+		}
+	}
+}
+
+// reap удаляет задачи, срок хранения которых истек
+func (r *ExpiryReaper) reap(ctx context.Context) {
+	expired, err := r.taskUsecase.ExpireTasks(ctx, r.clock.Now())
+	if err != nil {
+		log.Printf("Ошибка удаления задач с истекшим сроком хранения: %v", err)
+	}
+	if expired > 0 {
+		log.Printf("Удалено задач с истекшим сроком хранения: %d", expired)
+	}
+}
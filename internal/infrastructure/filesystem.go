@@ -0,0 +1,37 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// ProbeWritable проверяет, что в директорию можно записывать файлы.
+// Директория создается при необходимости; в ней создается и удаляется пробный файл.
+func ProbeWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return describeProbeError(dir, err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".write-probe-*")
+	if err != nil {
+		return describeProbeError(dir, err)
+	}
+
+	name := probe.Name()
+	probe.Close()
+	if err := os.Remove(name); err != nil {
+		return fmt.Errorf("не удалось удалить пробный файл %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// describeProbeError формирует понятное сообщение об ошибке проверки записи
+func describeProbeError(dir string, err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("нет прав на запись в директорию %s (проверьте права доступа или режим монтирования): %w", dir, err)
+	}
+	return fmt.Errorf("директория %s недоступна для записи: %w", dir, err)
+}
@@ -0,0 +1,110 @@
+package infrastructure
+
+import (
+	"log"
+	"runtime"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// goroutinePollInterval - период проверки числа горутин при приостановленной раздаче задач и обновления метрик
+const goroutinePollInterval = time.Second
+
+// WithPoolMetrics задает реестр метрик пула: число горутин процесса, скачиваемых файлов, задач, ожидающих
+// возврата в очередь, и признак приостановки раздачи задач
+func WithPoolMetrics(metrics interfaces.MetricsRecorder) WorkerPoolOption {
+	return func(wp *WorkerPool) {
+		wp.metrics = metrics
+	}
+}
+
+// WithGoroutineLimit задает предел числа горутин процесса. При приближении к пределу (90%) диспетчер
+// перестает раздавать задачи воркерам, пока горутин не станет меньше: задачи остаются в очереди, а при
+// её переполнении новые задачи отклоняются. 0 отключает ограничение.
+func WithGoroutineLimit(limit int) WorkerPoolOption {
+	return func(wp *WorkerPool) {
+		if limit > 0 {
+			wp.goroutineLimit = limit
+		}
+	}
+}
+
+// goroutineHighWater возвращает число горутин, при котором раздача задач приостанавливается
+func (wp *WorkerPool) goroutineHighWater() int {
+	return max(wp.goroutineLimit-wp.goroutineLimit/10, 1)
+}
+
+// waitGoroutineCapacity ждет, пока число горутин опустится ниже порога приостановки раздачи задач.
+// Возвращает false, если пул остановлен во время ожидания.
+func (wp *WorkerPool) waitGoroutineCapacity() bool {
+	if wp.goroutineLimit == 0 {
+		return true
+	}
+
+	paused := false
+	for {
+		count := wp.goroutines()
+		if count < wp.goroutineHighWater() {
+			if paused {
+				log.Printf("Раздача задач возобновлена: %d горутин (предел %d)", count, wp.goroutineLimit)
+				wp.setDispatchPaused(false)
+			}
+			return true
+		}
+		if !paused {
+			paused = true
+			log.Printf("Раздача задач приостановлена: %d горутин при пределе %d", count, wp.goroutineLimit)
+			wp.setDispatchPaused(true)
+		}
+
+		select {
+		case <-wp.clock.After(goroutinePollInterval):
+		case <-wp.ctx.Done():
+			return false
+		}
+	}
+}
+
+// sampleGoroutines периодически обновляет метрики горутин до остановки пула
+func (wp *WorkerPool) sampleGoroutines() {
+	for {
+		wp.reportGoroutines()
+		select {
+		case <-wp.clock.After(goroutinePollInterval):
+		case <-wp.ctx.Done():
+			return
+		}
+	}
+}
+
+// setDispatchPaused отмечает приостановку или возобновление раздачи задач и сразу обновляет метрики.
+// Признак меняет только диспетчер, поэтому периодическая запись метрик не перезапишет его устаревшим значением.
+func (wp *WorkerPool) setDispatchPaused(paused bool) {
+	wp.dispatchPaused.Store(paused)
+	wp.reportGoroutines()
+}
+
+// reportGoroutines записывает метрики горутин и признак приостановки раздачи задач
+func (wp *WorkerPool) reportGoroutines() {
+	if wp.metrics == nil {
+		return
+	}
+
+	pausedValue := 0.0
+	if wp.dispatchPaused.Load() {
+		pausedValue = 1
+	}
+	wp.metrics.SetGauge("downloader_goroutines", nil, float64(wp.goroutines()))
+	wp.metrics.SetGauge("downloader_active_downloads", nil, float64(wp.downloadUsecase.ResourceUsage().ActiveFiles))
+	wp.metrics.SetGauge("downloader_requeue_pending", nil, float64(wp.requeuing.Load()))
+	wp.metrics.SetGauge("downloader_dispatch_paused", nil, pausedValue)
+}
+
+// goroutines возвращает текущее число горутин процесса
+func (wp *WorkerPool) goroutines() int {
+	if wp.goroutineCount != nil {
+		return wp.goroutineCount()
+	}
+	return runtime.NumGoroutine()
+}
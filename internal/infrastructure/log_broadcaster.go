@@ -0,0 +1,81 @@
+package infrastructure
+
+import (
+	"strings"
+	"sync"
+)
+
+// logSubscriberBuffer - число записей журнала, которые подписчик может не успеть прочитать;
+// при переполнении новые записи для него пропускаются, чтобы медленный клиент не задерживал журнал
+const logSubscriberBuffer = 256
+
+// LogBroadcaster рассылает записи журнала всем подписчикам. Подключается к стандартному журналу
+// как дополнительный io.Writer; пакет log передает каждую запись одним вызовом Write.
+type LogBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan string]struct{}
+	closed      bool
+}
+
+// NewLogBroadcaster создает трансляцию записей журнала без подписчиков
+func NewLogBroadcaster() *LogBroadcaster {
+	return &LogBroadcaster{subscribers: make(map[chan string]struct{})}
+}
+
+// Write отправляет запись журнала подписчикам, не дожидаясь их
+func (b *LogBroadcaster) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\n")
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Subscribe подписывает на записи журнала, появившиеся после подписки
+func (b *LogBroadcaster) Subscribe() (<-chan string, func()) {
+	ch := make(chan string, logSubscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[ch]; ok {
+				delete(b.subscribers, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Subscribers возвращает текущее количество подписчиков
+func (b *LogBroadcaster) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// Close завершает трансляцию и закрывает каналы всех подписчиков (при остановке сервера)
+func (b *LogBroadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
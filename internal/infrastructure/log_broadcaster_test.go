@@ -0,0 +1,96 @@
+package infrastructure
+
+import (
+	"log"
+	"testing"
+	"time"
+)
+
+func TestLogBroadcasterDeliversToAllSubscribers(t *testing.T) {
+	// Setup
+	broadcaster := NewLogBroadcaster()
+	logger := log.New(broadcaster, "", 0)
+	first, unsubscribeFirst := broadcaster.Subscribe()
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := broadcaster.Subscribe()
+	defer unsubscribeSecond()
+
+	// Execute
+	logger.Printf("Задача %s завершена", "abc")
+
+	// Assert
+	for i, ch := range []<-chan string{first, second} {
+		select {
+		case line := <-ch:
+			if line != "Задача abc завершена" {
+				t.Errorf("Expected subscriber %d to get the log line, got %q", i, line)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected subscriber %d to get the log line", i)
+		}
+	}
+}
+
+func TestLogBroadcasterUnsubscribe(t *testing.T) {
+	// Setup
+	broadcaster := NewLogBroadcaster()
+	lines, unsubscribe := broadcaster.Subscribe()
+
+	// Execute
+	unsubscribe()
+	unsubscribe()
+	broadcaster.Write([]byte("after unsubscribe\n"))
+
+	// Assert
+	if _, ok := <-lines; ok {
+		t.Error("Expected channel to be closed after unsubscribe")
+	}
+	if count := broadcaster.Subscribers(); count != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", count)
+	}
+}
+
+func TestLogBroadcasterDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	// Setup
+	broadcaster := NewLogBroadcaster()
+	lines, unsubscribe := broadcaster.Subscribe()
+	defer unsubscribe()
+
+	// Execute
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < logSubscriberBuffer*2; i++ {
+			broadcaster.Write([]byte("line\n"))
+		}
+	}()
+
+	// Assert
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected writes not to block on a subscriber that does not read")
+	}
+	if len(lines) != logSubscriberBuffer {
+		t.Errorf("Expected %d buffered lines, got %d", logSubscriberBuffer, len(lines))
+	}
+}
+
+func TestLogBroadcasterClose(t *testing.T) {
+	// Setup
+	broadcaster := NewLogBroadcaster()
+	lines, unsubscribe := broadcaster.Subscribe()
+
+	// Execute
+	broadcaster.Close()
+	unsubscribe()
+	late, _ := broadcaster.Subscribe()
+
+	// Assert
+	if _, ok := <-lines; ok {
+		t.Error("Expected subscriber channel to be closed")
+	}
+	if _, ok := <-late; ok {
+		t.Error("Expected subscription after close to be closed")
+	}
+}
@@ -0,0 +1,62 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// DefaultManifestTimeout - время загрузки манифеста по умолчанию
+const DefaultManifestTimeout = 30 * time.Second
+
+// ManifestFetcher загружает манифесты со списками файлов по HTTP
+type ManifestFetcher struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewManifestFetcher создает загрузчик манифестов. transport задает соединения
+// (например, с защитой от SSRF), timeout ограничивает время загрузки (0 - DefaultManifestTimeout).
+func NewManifestFetcher(transport http.RoundTripper, timeout time.Duration, userAgent string) interfaces.ManifestFetcher {
+	if timeout <= 0 {
+		timeout = DefaultManifestTimeout
+	}
+	return &ManifestFetcher{
+		client:    &http.Client{Transport: transport, Timeout: timeout},
+		userAgent: userAgent,
+	}
+}
+
+// Fetch загружает манифест; ответ вне 2xx считается ошибкой
+func (f *ManifestFetcher) Fetch(ctx context.Context, url string, headers map[string]string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать запрос: %w", err)
+	}
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось выполнить запрос: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("сервер ответил %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать ответ: %w", err)
+	}
+	return data, nil
+}
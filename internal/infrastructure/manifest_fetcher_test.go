@@ -0,0 +1,51 @@
+package infrastructure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestManifestFetcherSendsHeadersAndLimitsBody(t *testing.T) {
+	// Setup
+	var gotAuth, gotAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotAgent = r.Header.Get("User-Agent")
+		w.Write([]byte("https://example.com/a.txt\nhttps://example.com/b.txt\n"))
+	}))
+	defer server.Close()
+	fetcher := NewManifestFetcher(http.DefaultTransport, 0, "file-downloader/test")
+
+	// Execute
+	data, err := fetcher.Fetch(context.Background(), server.URL, map[string]string{"Authorization": "Bearer token"}, 10)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != "https://ex" {
+		t.Errorf("Expected body limited to 10 bytes, got %q", data)
+	}
+	if gotAuth != "Bearer token" || gotAgent != "file-downloader/test" {
+		t.Errorf("Expected task headers and user agent, got %q and %q", gotAuth, gotAgent)
+	}
+}
+
+func TestManifestFetcherFailsOnErrorStatus(t *testing.T) {
+	// Setup
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	fetcher := NewManifestFetcher(http.DefaultTransport, 0, "")
+
+	// Execute
+	_, err := fetcher.Fetch(context.Background(), server.URL, nil, 1<<20)
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error for 404 response, got nil")
+	}
+}
@@ -0,0 +1,163 @@
+package infrastructure
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// metricKind определяет тип метрики
+type metricKind string
+
+const (
+	metricCounter metricKind = "counter"
+	metricGauge   metricKind = "gauge"
+)
+
+// metricFamily хранит все серии одной метрики
+type metricFamily struct {
+	kind   metricKind
+	help   string
+	series map[string]float64 // ключ - отсортированные метки в формате Prometheus
+}
+
+// MetricsRegistry реализует MetricsRecorder и отдает метрики в текстовом формате Prometheus
+type MetricsRegistry struct {
+	mu       sync.RWMutex
+	families map[string]*metricFamily
+}
+
+// NewMetricsRegistry создает новый реестр метрик
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		families: make(map[string]*metricFamily),
+	}
+}
+
+// Describe задает описание метрики, отображаемое в выводе
+func (r *MetricsRegistry) Describe(name, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if family, ok := r.families[name]; ok {
+		family.help = help
+		return
+	}
+	r.families[name] = &metricFamily{help: help, series: make(map[string]float64)}
+}
+
+// IncCounter увеличивает счетчик на delta
+func (r *MetricsRegistry) IncCounter(name string, labels map[string]string, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family := r.family(name, metricCounter)
+	family.series[encodeLabels(labels)] += delta
+}
+
+// SetGauge устанавливает значение gauge-метрики
+func (r *MetricsRegistry) SetGauge(name string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family := r.family(name, metricGauge)
+	family.series[encodeLabels(labels)] = value
+}
+
+// Value возвращает текущее значение серии метрики
+func (r *MetricsRegistry) Value(name string, labels map[string]string) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	family, ok := r.families[name]
+	if !ok {
+		return 0
+	}
+	return family.series[encodeLabels(labels)]
+}
+
+// WriteText записывает все метрики в текстовом формате Prometheus
+func (r *MetricsRegistry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		family := r.families[name]
+		if len(family.series) == 0 {
+			continue
+		}
+
+		if family.help != "" {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n", name, family.help); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n", name, family.kind); err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(family.series))
+		for key := range family.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(w, "%s%s %g\n", name, key, family.series[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Handler возвращает HTTP-обработчик для GET /metrics
+func (r *MetricsRegistry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.WriteText(w)
+	})
+}
+
+// family возвращает семейство метрик, создавая его при необходимости (вызывающий должен держать блокировку)
+func (r *MetricsRegistry) family(name string, kind metricKind) *metricFamily {
+	family, ok := r.families[name]
+	if !ok {
+		family = &metricFamily{series: make(map[string]float64)}
+		r.families[name] = family
+	}
+	if family.kind == "" {
+		family.kind = kind
+	}
+	return family
+}
+
+// encodeLabels кодирует метки в формат Prometheus с сортировкой по имени
+func encodeLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[key])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, key, value))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
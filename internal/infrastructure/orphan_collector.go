@@ -0,0 +1,82 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// OrphanCollector при запуске и затем периодически убирает директории скачивания задач,
+// которых больше нет в хранилище
+type OrphanCollector struct {
+	taskUsecase interfaces.TaskUsecase
+	gracePeriod time.Duration
+	interval    time.Duration
+	clock       interfaces.Clock
+}
+
+// OrphanCollectorOption настраивает сборщик осиротевших директорий
+type OrphanCollectorOption func(*OrphanCollector)
+
+// WithOrphanCollectorClock задает источник времени сборщика (по умолчанию - системное время)
+func WithOrphanCollectorClock(clock interfaces.Clock) OrphanCollectorOption {
+	return func(c *OrphanCollector) {
+		c.clock = clock
+	}
+}
+
+// NewOrphanCollector создает сборщик осиротевших директорий. Директории, изменявшиеся
+// в течение gracePeriod, не затрагиваются.
+func NewOrphanCollector(taskUsecase interfaces.TaskUsecase, gracePeriod, interval time.Duration, opts ...OrphanCollectorOption) *OrphanCollector {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	c := &OrphanCollector{
+		taskUsecase: taskUsecase,
+		gracePeriod: gracePeriod,
+		interval:    interval,
+		clock:       SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Run запускает цикл очистки до отмены контекста
+func (c *OrphanCollector) Run(ctx context.Context) {
+	for {
+		c.collect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.clock.After(c.interval):
+		}
+	}
+}
+
+// collect убирает осиротевшие директории и сообщает об освобожденном месте
+func (c *OrphanCollector) collect(ctx context.Context) {
+	report, err := c.taskUsecase.CleanupOrphans(ctx, c.clock.Now().Add(-c.gracePeriod))
+	if err != nil {
+		log.Printf("Ошибка очистки осиротевших директорий: %v", err)
+	}
+	if report == nil {
+		return
+	}
+
+	for _, dir := range report.Directories {
+		if dir.Error != "" {
+			log.Printf("Не удалось обработать осиротевшую директорию %s (%s): %s", dir.Path, report.Action, dir.Error)
+		}
+	}
+	if len(report.Directories) > 0 {
+		log.Printf("Осиротевших директорий обработано: %d (%s), %d байт", len(report.Directories), report.Action, report.Bytes)
+	}
+}
@@ -0,0 +1,147 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// blockedNetworks - диапазоны адресов, соединения с которыми запрещены по умолчанию:
+// частные сети, loopback, link-local (включая metadata 169.254.169.254), CGNAT и служебные адреса
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// SafeDialerConfig задает параметры защиты от SSRF
+type SafeDialerConfig struct {
+	Timeout         time.Duration
+	AllowedNetworks []*net.IPNet // диапазоны, разрешенные несмотря на блокировку
+}
+
+// SafeDialer устанавливает соединения только с публичными адресами.
+// Имя хоста разрешается перед подключением, и соединение устанавливается с уже проверенным IP,
+// поэтому подмена DNS-ответа между проверкой и подключением (DNS rebinding) не обходит защиту.
+// Проверка выполняется для каждого соединения, в том числе после HTTP-перенаправлений.
+type SafeDialer struct {
+	dialer   net.Dialer
+	resolver *net.Resolver
+	allowed  []*net.IPNet
+}
+
+// NewSafeDialer создает dialer с защитой от SSRF
+func NewSafeDialer(config SafeDialerConfig) *SafeDialer {
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &SafeDialer{
+		dialer: net.Dialer{
+			Timeout:   config.Timeout,
+			KeepAlive: 30 * time.Second,
+		},
+		resolver: net.DefaultResolver,
+		allowed:  config.AllowedNetworks,
+	}
+}
+
+// DialContext разрешает адрес и подключается к первому IP, не попадающему в запрещенные диапазоны
+func (d *SafeDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, addr := range addrs {
+		if err := d.checkIP(host, addr.IP); err != nil {
+			lastErr = err
+			continue
+		}
+
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(addr.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("адрес хоста %s не найден", host)
+	}
+	return nil, lastErr
+}
+
+// Transport возвращает HTTP-транспорт, использующий защищенный dialer
+func (d *SafeDialer) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = d.DialContext
+	return transport
+}
+
+// checkIP возвращает ошибку, если адрес относится к запрещенному диапазону и явно не разрешен
+func (d *SafeDialer) checkIP(host string, ip net.IP) error {
+	for _, network := range d.allowed {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: %s разрешается во внутренний адрес %s", entities.ErrHostNotAllowed, host, ip)
+		}
+	}
+
+	return nil
+}
+
+// ParseCIDRs разбирает список диапазонов адресов в нотации CIDR
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("неверный диапазон адресов %q: %w", value, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// mustParseCIDRs разбирает встроенный список диапазонов
+func mustParseCIDRs(values ...string) []*net.IPNet {
+	networks, err := ParseCIDRs(values)
+	if err != nil {
+		panic(err)
+	}
+	return networks
+}
@@ -0,0 +1,91 @@
+package infrastructure
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"file-downloader/internal/entities"
+)
+
+func TestSafeDialerCheckIP(t *testing.T) {
+	dialer := NewSafeDialer(SafeDialerConfig{})
+
+	blocked := []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "::1", "fe80::1", "fd00:ec2::254", "::ffff:10.0.0.1"}
+	for _, value := range blocked {
+		if err := dialer.checkIP("host", net.ParseIP(value)); !errors.Is(err, entities.ErrHostNotAllowed) {
+			t.Errorf("Expected %s to be blocked, got %v", value, err)
+		}
+	}
+
+	public := []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"}
+	for _, value := range public {
+		if err := dialer.checkIP("host", net.ParseIP(value)); err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", value, err)
+		}
+	}
+}
+
+func TestSafeDialerRejectsLoopbackUnlessAllowed(t *testing.T) {
+	// Setup
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	// Execute: loopback is blocked by default
+	client := &http.Client{Transport: NewSafeDialer(SafeDialerConfig{}).Transport()}
+	_, err := client.Get(server.URL)
+
+	// Assert
+	if !errors.Is(err, entities.ErrHostNotAllowed) {
+		t.Fatalf("Expected ErrHostNotAllowed for loopback, got %v", err)
+	}
+
+	// Execute: explicitly allowed network
+	allowed, err := ParseCIDRs([]string{"127.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("Failed to parse CIDR: %v", err)
+	}
+	client = &http.Client{Transport: NewSafeDialer(SafeDialerConfig{AllowedNetworks: allowed}).Transport()}
+	resp, err := client.Get(server.URL)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected allowed network to connect, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestSafeDialerChecksRedirectTarget(t *testing.T) {
+	// Setup: internal service on a different loopback address than the allowed entry point
+	listener, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("Loopback address 127.0.0.2 is not available: %v", err)
+	}
+	internal := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	internal.Listener.Close()
+	internal.Listener = listener
+	internal.Start()
+	defer internal.Close()
+
+	entry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer entry.Close()
+
+	allowed, _ := ParseCIDRs([]string{"127.0.0.1/32"})
+	client := &http.Client{Transport: NewSafeDialer(SafeDialerConfig{AllowedNetworks: allowed}).Transport()}
+
+	// Execute
+	_, err = client.Get(entry.URL)
+
+	// Assert
+	if !errors.Is(err, entities.ErrHostNotAllowed) {
+		t.Fatalf("Expected redirect to internal address to be rejected, got %v", err)
+	}
+}
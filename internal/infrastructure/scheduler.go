@@ -0,0 +1,194 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// SchedulerConfig задает параметры планировщика постановки задач в пул воркеров
+type SchedulerConfig struct {
+	PollInterval time.Duration // интервал опроса ожидающих задач
+	RampWindow   time.Duration // окно, на которое распределяется начальный backlog после перезапуска
+	Jitter       time.Duration // случайный разброс времени постановки задач из backlog
+	Clock        interfaces.Clock
+	Metrics      interfaces.MetricsRecorder // метрики переполнения очереди (nil - не собираются)
+	Maintenance  interfaces.MaintenanceMode // пока режим включен, задачи не ставятся в пул (nil - режима нет)
+}
+
+// TaskScheduler периодически ставит ожидающие задачи в пул воркеров.
+// Задачи, найденные при первом опросе (backlog после перезапуска), ставятся постепенно в течение RampWindow,
+// новые задачи ставятся сразу.
+type TaskScheduler struct {
+	downloadUsecase interfaces.DownloadUsecase
+	pool            *WorkerPool
+	config          SchedulerConfig
+	backlog         map[string]time.Time
+	paused          bool // постановка приостановлена режимом обслуживания
+}
+
+// NewTaskScheduler создает новый планировщик
+func NewTaskScheduler(downloadUsecase interfaces.DownloadUsecase, pool *WorkerPool, config SchedulerConfig) *TaskScheduler {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock{}
+	}
+
+	return &TaskScheduler{
+		downloadUsecase: downloadUsecase,
+		pool:            pool,
+		config:          config,
+	}
+}
+
+// Run запускает цикл планировщика до отмены контекста
+func (s *TaskScheduler) Run(ctx context.Context) {
+	log.Println("Процессор задач запущен")
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Процессор задач остановлен")
+			return
+		default:
+		}
+
+		// В режиме обслуживания новая работа не ставится в пул, а начатые задачи выполняются дальше
+		if s.maintenancePaused() {
+			s.sleep(ctx, s.config.PollInterval)
+			continue
+		}
+
+		// Запуск запланированных задач, время которых наступило
+		if released, err := s.downloadUsecase.ReleaseScheduledTasks(ctx); err != nil {
+			log.Printf("Ошибка запуска запланированных задач: %v", err)
+		} else if released > 0 {
+			log.Printf("Запланированных задач переведено в очередь: %d", released)
+		}
+
+		// Получение ожидающих задач и добавление их в пул воркеров
+		pendingTasks, err := s.downloadUsecase.GetPendingTasks(ctx)
+		if err != nil {
+			log.Printf("Ошибка получения ожидающих задач: %v", err)
+			s.sleep(ctx, 5*time.Second)
+			continue
+		}
+
+		now := s.config.Clock.Now()
+		if s.backlog == nil {
+			s.backlog = s.planBacklog(pendingTasks, now)
+			log.Printf("Начальный backlog из %d задач будет поставлен в очередь в течение %v", len(s.backlog), s.config.RampWindow)
+		}
+
+		log.Printf("Найдено %d ожидающих задач", len(pendingTasks))
+		// После первого отказа из-за переполненной очереди остальные задачи опроса в пул не ставятся,
+		// а отмечаются как ожидающие места и ставятся при следующих опросах
+		var waiting []string
+		for _, task := range pendingTasks {
+			if task.Status != entities.TaskStatusNew {
+				continue
+			}
+
+			id := task.ID.String()
+			if at, ok := s.backlog[id]; ok {
+				if now.Before(at) {
+					continue
+				}
+				delete(s.backlog, id)
+			}
+
+			if len(waiting) > 0 {
+				waiting = append(waiting, id)
+				continue
+			}
+
+			log.Printf("Добавляем задачу %s в пул воркеров", task.LogID())
+			if err := s.pool.AddTask(task); errors.Is(err, entities.ErrQueueFull) {
+				waiting = append(waiting, id)
+			} else if err != nil {
+				log.Printf("Ошибка добавления задачи %s в пул воркеров: %v", task.LogID(), err)
+			} else {
+				log.Printf("Задача %s успешно добавлена в пул воркеров", task.LogID())
+			}
+		}
+		s.reportQueueFull(ctx, waiting)
+
+		s.sleep(ctx, s.config.PollInterval)
+	}
+}
+
+// maintenancePaused возвращает true, пока включен режим обслуживания, и пишет в журнал его смену
+func (s *TaskScheduler) maintenancePaused() bool {
+	paused := s.config.Maintenance != nil && s.config.Maintenance.Enabled()
+	if paused != s.paused {
+		if paused {
+			log.Println("Постановка задач в пул приостановлена режимом обслуживания")
+		} else {
+			log.Println("Постановка задач в пул возобновлена")
+		}
+		s.paused = paused
+	}
+	return paused
+}
+
+// reportQueueFull отмечает задачи, не поставленные в переполненную очередь, и обновляет метрики
+func (s *TaskScheduler) reportQueueFull(ctx context.Context, waiting []string) {
+	if s.config.Metrics != nil {
+		s.config.Metrics.SetGauge("downloader_tasks_waiting_for_capacity", nil, float64(len(waiting)))
+	}
+	if len(waiting) == 0 {
+		return
+	}
+
+	if s.config.Metrics != nil {
+		s.config.Metrics.IncCounter("downloader_queue_full_total", nil, 1)
+	}
+	log.Printf("Очередь пула воркеров переполнена: %d задач ожидают места", len(waiting))
+	if _, err := s.downloadUsecase.MarkWaitingForCapacity(ctx, waiting); err != nil {
+		log.Printf("Ошибка отметки задач, ожидающих места в очереди: %v", err)
+	}
+}
+
+// planBacklog равномерно распределяет время постановки задач начального backlog по окну RampWindow
+func (s *TaskScheduler) planBacklog(tasks []*entities.Task, now time.Time) map[string]time.Time {
+	backlog := make(map[string]time.Time)
+	if s.config.RampWindow <= 0 {
+		return backlog
+	}
+
+	var pending []*entities.Task
+	for _, task := range tasks {
+		if task.Status == entities.TaskStatusNew {
+			pending = append(pending, task)
+		}
+	}
+
+	if len(pending) == 0 {
+		return backlog
+	}
+
+	step := s.config.RampWindow / time.Duration(len(pending))
+	for i, task := range pending {
+		at := now.Add(step * time.Duration(i))
+		if s.config.Jitter > 0 {
+			at = at.Add(time.Duration(rand.Int63n(int64(s.config.Jitter))))
+		}
+		backlog[task.ID.String()] = at
+	}
+
+	return backlog
+}
+
+// sleep ожидает указанное время или отмену контекста
+func (s *TaskScheduler) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-s.config.Clock.After(d):
+	case <-ctx.Done():
+	}
+}
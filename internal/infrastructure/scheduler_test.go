@@ -0,0 +1,131 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestPlanBacklogSpreadsTasksOverRampWindow(t *testing.T) {
+	// Setup
+	scheduler := NewTaskScheduler(nil, nil, SchedulerConfig{RampWindow: 10 * time.Second})
+	now := time.Now()
+
+	var tasks []*entities.Task
+	for i := 0; i < 5; i++ {
+		tasks = append(tasks, entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now()))
+	}
+	completed := entities.NewTask([]string{"https://example.com/done.jpg"}, time.Now())
+	completed.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	tasks = append(tasks, completed)
+
+	// Execute
+	backlog := scheduler.planBacklog(tasks, now)
+
+	// Assert
+	if len(backlog) != 5 {
+		t.Fatalf("Expected 5 tasks in backlog, got %d", len(backlog))
+	}
+
+	for i, task := range tasks[:5] {
+		expected := now.Add(time.Duration(i) * 2 * time.Second)
+		if at := backlog[task.ID.String()]; !at.Equal(expected) {
+			t.Errorf("Expected task %d to be scheduled at +%v, got +%v", i, expected.Sub(now), at.Sub(now))
+		}
+	}
+}
+
+func TestPlanBacklogWithoutRampWindow(t *testing.T) {
+	// Setup
+	scheduler := NewTaskScheduler(nil, nil, SchedulerConfig{})
+	tasks := []*entities.Task{entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now())}
+
+	// Execute
+	backlog := scheduler.planBacklog(tasks, time.Now())
+
+	// Assert
+	if len(backlog) != 0 {
+		t.Errorf("Expected empty backlog without ramp window, got %d", len(backlog))
+	}
+}
+
+func TestSchedulerMarksTasksWaitingForCapacity(t *testing.T) {
+	// Setup: the dispatcher is not started, so the queue holds a single job
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool)}
+	for i := 0; i < 3; i++ {
+		usecase.tasks = append(usecase.tasks, entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now()))
+	}
+	clock := testutil.NewFakeClock(time.Now())
+	pool := NewWorkerPool(1, usecase, WithQueueCapacity(1))
+	pool.running = true
+	metrics := NewMetricsRegistry()
+	scheduler := NewTaskScheduler(usecase, pool, SchedulerConfig{Clock: clock, Metrics: metrics})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Execute
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// Assert
+	if pool.QueueLength() != 1 {
+		t.Errorf("Expected 1 queued job, got %d", pool.QueueLength())
+	}
+	if len(usecase.waiting) != 2 || usecase.waiting[0] != usecase.tasks[1].ID.String() {
+		t.Errorf("Expected the 2 tasks after the first to wait for capacity, got %v", usecase.waiting)
+	}
+	if got := metrics.Value("downloader_queue_full_total", nil); got != 1 {
+		t.Errorf("Expected 1 queue full event, got %v", got)
+	}
+	if got := metrics.Value("downloader_tasks_waiting_for_capacity", nil); got != 2 {
+		t.Errorf("Expected 2 tasks waiting for capacity, got %v", got)
+	}
+}
+
+// fixedMaintenance reports a constant maintenance state
+type fixedMaintenance bool
+
+func (m fixedMaintenance) Enabled() bool               { return bool(m) }
+func (m fixedMaintenance) State() entities.Maintenance { return entities.Maintenance{Enabled: bool(m)} }
+func (m fixedMaintenance) SetEnabled(ctx context.Context, enabled bool) (entities.Maintenance, error) {
+	return entities.Maintenance{Enabled: enabled}, nil
+}
+func (m fixedMaintenance) Health() error { return nil }
+
+func TestSchedulerPausesInMaintenance(t *testing.T) {
+	// Setup
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool)}
+	usecase.tasks = append(usecase.tasks, entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now()))
+	clock := testutil.NewFakeClock(time.Now())
+	pool := NewWorkerPool(1, usecase)
+	pool.running = true
+	scheduler := NewTaskScheduler(usecase, pool, SchedulerConfig{Clock: clock, Maintenance: fixedMaintenance(true)})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Execute
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	// Assert
+	if pool.QueueLength() != 0 {
+		t.Errorf("Expected no tasks to be queued in maintenance, got %d", pool.QueueLength())
+	}
+}
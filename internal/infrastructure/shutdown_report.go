@@ -0,0 +1,88 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"file-downloader/internal/entities"
+)
+
+// ShutdownReport возвращает отчет о работе, не завершенной к остановке пула (nil, если пул не останавливался
+// или отчет не удалось составить)
+func (wp *WorkerPool) ShutdownReport() *entities.ShutdownReport {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+	return wp.shutdownReport
+}
+
+// reportUnfinished составляет отчет о незавершенной работе и записывает его в журнал (вызывающий должен
+// держать блокировку). workers - задачи, воркеры которых не завершились до истечения времени остановки.
+func (wp *WorkerPool) reportUnfinished(workers map[string]int) {
+	abandoned := make(map[string]bool, len(workers))
+	for taskID := range workers {
+		abandoned[taskID] = true
+	}
+
+	// Контекст пула к этому моменту отменен, поэтому задачи читаются без него
+	tasks, err := wp.downloadUsecase.GetPendingTasks(context.Background())
+	if err != nil {
+		log.Printf("Не удалось составить отчет о незавершенной работе: %v", err)
+		return
+	}
+	wp.shutdownReport = entities.NewShutdownReport(tasks, abandoned, wp.clock.Now())
+	logShutdownReport(wp.shutdownReport)
+}
+
+// logShutdownReport записывает в журнал незавершенные задачи и прерванные файлы с числом записанных байт
+func logShutdownReport(report *entities.ShutdownReport) {
+	if len(report.Tasks) == 0 {
+		log.Println("Незавершенной работы при остановке нет")
+		return
+	}
+
+	log.Printf("Незавершенных задач при остановке: %d", len(report.Tasks))
+	for _, task := range report.Tasks {
+		state := string(task.Status)
+		if task.Abandoned {
+			state += ", воркер не завершился"
+		}
+		log.Printf("Задача %s (%s): прервано файлов %d, не начато %d",
+			entities.LogID(task.ID, task.RequestID), state, len(task.Files), task.PendingFiles)
+		for _, file := range task.Files {
+			progress := fmt.Sprintf("записано %d байт", file.Offset)
+			if file.Size > 0 {
+				progress = fmt.Sprintf("записано %d из %d байт", file.Offset, file.Size)
+			}
+			if file.Path != "" {
+				progress += " в " + file.Path
+			}
+			log.Printf("  файл %d %s (%s): %s", file.Index, file.URL, file.Status, progress)
+		}
+	}
+}
+
+// WriteShutdownReport сохраняет отчет об остановке в файл path в формате JSON. Отчет записывается через
+// временный файл, чтобы прерванная запись не оставила поврежденный отчет.
+func WriteShutdownReport(path string, report *entities.ShutdownReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("не удалось сериализовать отчет об остановке: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию отчета об остановке: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("не удалось записать отчет об остановке: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("не удалось сохранить отчет об остановке: %w", err)
+	}
+	return nil
+}
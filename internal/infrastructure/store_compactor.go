@@ -0,0 +1,68 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// StoreCompactor периодически сжимает постоянное хранилище задач
+type StoreCompactor struct {
+	taskUsecase interfaces.TaskUsecase
+	interval    time.Duration
+	clock       interfaces.Clock
+}
+
+// StoreCompactorOption настраивает периодическое сжатие хранилища
+type StoreCompactorOption func(*StoreCompactor)
+
+// WithCompactorClock задает источник времени (по умолчанию - системное время)
+func WithCompactorClock(clock interfaces.Clock) StoreCompactorOption {
+	return func(c *StoreCompactor) {
+		c.clock = clock
+	}
+}
+
+// NewStoreCompactor создает цикл сжатия хранилища
+func NewStoreCompactor(taskUsecase interfaces.TaskUsecase, interval time.Duration, opts ...StoreCompactorOption) *StoreCompactor {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	c := &StoreCompactor{
+		taskUsecase: taskUsecase,
+		interval:    interval,
+		clock:       SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Run сжимает хранилище каждые interval до отмены контекста. Отмена прерывает выполняющееся сжатие.
+func (c *StoreCompactor) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.clock.After(c.interval):
+		}
+
+		c.compact(ctx)
+	}
+}
+
+// compact выполняет одно сжатие; сжатие, уже запущенное вручную, не повторяется
+func (c *StoreCompactor) compact(ctx context.Context) {
+	_, err := c.taskUsecase.CompactStore(ctx)
+	if err != nil && !errors.Is(err, entities.ErrCompactionInProgress) && ctx.Err() == nil {
+		log.Printf("Ошибка сжатия хранилища: %v", err)
+	}
+}
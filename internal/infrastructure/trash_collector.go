@@ -0,0 +1,77 @@
+package infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// TrashCollector периодически окончательно удаляет задачи, пролежавшие в корзине дольше срока хранения
+type TrashCollector struct {
+	taskUsecase interfaces.TaskUsecase
+	retention   time.Duration
+	interval    time.Duration
+	clock       interfaces.Clock
+}
+
+// TrashCollectorOption настраивает сборщик корзины
+type TrashCollectorOption func(*TrashCollector)
+
+// WithCollectorClock задает источник времени сборщика (по умолчанию - системное время)
+func WithCollectorClock(clock interfaces.Clock) TrashCollectorOption {
+	return func(c *TrashCollector) {
+		c.clock = clock
+	}
+}
+
+// NewTrashCollector создает сборщик корзины
+func NewTrashCollector(taskUsecase interfaces.TaskUsecase, retention, interval time.Duration, opts ...TrashCollectorOption) *TrashCollector {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	c := &TrashCollector{
+		taskUsecase: taskUsecase,
+		retention:   retention,
+		interval:    interval,
+		clock:       SystemClock{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Run запускает цикл очистки корзины до отмены контекста.
+// Нулевой срок хранения отключает очистку.
+func (c *TrashCollector) Run(ctx context.Context) {
+	if c.retention <= 0 {
+		log.Println("Очистка корзины отключена")
+		return
+	}
+
+	for {
+		c.collect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.clock.After(c.interval):
+		}
+	}
+}
+
+// collect удаляет задачи, помещенные в корзину раньше срока хранения
+func (c *TrashCollector) collect(ctx context.Context) {
+	purged, err := c.taskUsecase.PurgeDeletedTasks(ctx, c.clock.Now().Add(-c.retention))
+	if err != nil {
+		log.Printf("Ошибка очистки корзины: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("Из корзины окончательно удалено %d задач", purged)
+	}
+}
@@ -0,0 +1,91 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// webhookTimeout - максимальное время отправки одного оповещения
+const webhookTimeout = 10 * time.Second
+
+// WebhookNotifier отправляет оповещения POST-запросом с JSON-телом на заданный URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier создает отправителя оповещений на webhook
+func NewWebhookNotifier(url string) interfaces.AlertNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Notify отправляет оповещение; ответ с кодом вне 2xx считается ошибкой
+func (n *WebhookNotifier) Notify(ctx context.Context, alert entities.Alert) error {
+	_, err := postJSON(ctx, n.client, n.url, alert)
+	return err
+}
+
+// CallbackSender отправляет итог обработки задачи POST-запросом с JSON-телом на callback_url задачи.
+// Перенаправления не выполняются: ответ 3xx считается ошибкой доставки.
+type CallbackSender struct {
+	client *http.Client
+}
+
+// NewCallbackSender создает отправителя уведомлений о завершении задач. Соединения устанавливаются через transport
+// (например, с защитой от SSRF), timeout ограничивает время одной попытки (0 - webhookTimeout).
+func NewCallbackSender(transport http.RoundTripper, timeout time.Duration) interfaces.CallbackSender {
+	if timeout <= 0 {
+		timeout = webhookTimeout
+	}
+	return &CallbackSender{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send отправляет итог обработки задачи и возвращает код ответа получателя
+func (s *CallbackSender) Send(ctx context.Context, url string, manifest entities.Manifest) (int, error) {
+	return postJSON(ctx, s.client, url, manifest)
+}
+
+// postJSON отправляет значение POST-запросом с JSON-телом и возвращает код ответа; ответ вне 2xx считается ошибкой
+func postJSON(ctx context.Context, client *http.Client, url string, value interface{}) (int, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось сформировать тело запроса: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("не удалось создать запрос: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось отправить запрос: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook ответил %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
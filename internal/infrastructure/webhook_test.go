@@ -0,0 +1,78 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestWebhookNotifierPostsAlert(t *testing.T) {
+	// Setup
+	received := make(chan entities.Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert entities.Alert
+		json.NewDecoder(r.Body).Decode(&alert)
+		received <- alert
+	}))
+	defer server.Close()
+	notifier := NewWebhookNotifier(server.URL)
+
+	// Execute
+	err := notifier.Notify(context.Background(), entities.Alert{Name: "retry_rate", Value: 12, Threshold: 10, Time: time.Now()})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	alert := <-received
+	if alert.Name != "retry_rate" || alert.Value != 12 {
+		t.Errorf("Expected retry_rate alert with value 12, got %+v", alert)
+	}
+}
+
+func TestWebhookNotifierFailsOnErrorStatus(t *testing.T) {
+	// Setup
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	notifier := NewWebhookNotifier(server.URL)
+
+	// Execute
+	err := notifier.Notify(context.Background(), entities.Alert{Name: "retry_rate"})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected error for non-2xx webhook response, got nil")
+	}
+}
+
+func TestCallbackSenderDoesNotFollowRedirects(t *testing.T) {
+	// Setup
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+	sender := NewCallbackSender(http.DefaultTransport, time.Second)
+
+	// Execute
+	code, err := sender.Send(context.Background(), server.URL, entities.Manifest{Status: entities.TaskStatusCompleted})
+
+	// Assert
+	if err == nil || code != http.StatusTemporaryRedirect {
+		t.Errorf("Expected redirect to fail delivery with code 307, got %d (%v)", code, err)
+	}
+	if redirected {
+		t.Error("Expected callback not to follow the redirect")
+	}
+}
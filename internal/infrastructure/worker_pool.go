@@ -4,9 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"file-downloader/internal/entities"
 	"file-downloader/internal/interfaces"
 )
 
@@ -21,11 +27,63 @@ type WorkerPool struct {
 	cancel          context.CancelFunc
 	mu              sync.RWMutex
 	running         bool
+	nextWorkerID    int
+	queued          map[string]bool // задачи в очереди или в обработке, повторная постановка которых игнорируется
+	processing      map[string]int  // обрабатываемые задачи и ID обрабатывающих их воркеров
+	queuedMu        sync.Mutex
+	clock           interfaces.Clock
+	queueCapacity   int           // размер очереди задач
+	queueWait       time.Duration // время ожидания места в переполненной очереди (0 - не ждать)
+	crashed         chan *Worker  // воркеры, завершившиеся после паники, которым супервизор запускает замену
+
+	metrics        interfaces.MetricsRecorder // метрики горутин пула (nil - не записываются)
+	goroutineLimit int                        // предел числа горутин процесса (0 - без ограничения)
+	goroutineCount func() int                 // число горутин процесса (nil - runtime.NumGoroutine)
+	requeuing      atomic.Int64               // задачи, которые диспетчер держит до освобождения воркера
+	dispatchPaused atomic.Bool                // раздача задач приостановлена из-за числа горутин
+	draining       atomic.Bool                // пул дорабатывает принятые задачи перед остановкой и не принимает новые
+	workerFree     chan struct{}              // сигнал диспетчеру, что воркер освободился или запущен
+	diskSpace      interfaces.DiskSpaceGate   // приостановка раздачи задач при нехватке места на диске (nil - не приостанавливается)
+
+	shutdownReport *entities.ShutdownReport // незавершенная работа на момент остановки пула
+}
+
+// WorkerPoolOption настраивает пул воркеров
+type WorkerPoolOption func(*WorkerPool)
+
+// WithPoolClock задает источник времени пула (по умолчанию - системное время)
+func WithPoolClock(clock interfaces.Clock) WorkerPoolOption {
+	return func(wp *WorkerPool) {
+		wp.clock = clock
+	}
+}
+
+// WithQueueCapacity задает размер очереди задач (по умолчанию 100)
+func WithQueueCapacity(capacity int) WorkerPoolOption {
+	return func(wp *WorkerPool) {
+		if capacity > 0 {
+			wp.queueCapacity = capacity
+		}
+	}
+}
+
+// WithQueueWait задает время, в течение которого AddTask ждет освобождения места в переполненной очереди.
+// По умолчанию AddTask не ждет и сразу возвращает entities.ErrQueueFull.
+func WithQueueWait(timeout time.Duration) WorkerPoolOption {
+	return func(wp *WorkerPool) {
+		wp.queueWait = timeout
+	}
 }
 
 // TaskJob представляет задачу для пула воркеров
 type TaskJob struct {
-	TaskID string
+	TaskID    string
+	RequestID string // ID запроса, создавшего задачу, для журнала
+}
+
+// String возвращает идентификатор задачи для журнала
+func (j *TaskJob) String() string {
+	return entities.LogID(j.TaskID, j.RequestID)
 }
 
 // Worker представляет одного воркера в пуле
@@ -34,22 +92,35 @@ type Worker struct {
 	pool     *WorkerPool
 	jobQueue chan *TaskJob
 	quit     chan bool
+	done     chan struct{} // закрывается, когда воркер завершился по любой причине
 	busy     bool
 	mu       sync.Mutex
 }
 
 // NewWorkerPool создает новый пул воркеров
-func NewWorkerPool(workerCount int, downloadUsecase interfaces.DownloadUsecase) *WorkerPool {
+func NewWorkerPool(workerCount int, downloadUsecase interfaces.DownloadUsecase, opts ...WorkerPoolOption) *WorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &WorkerPool{
+	wp := &WorkerPool{
 		workerCount:     workerCount,
 		downloadUsecase: downloadUsecase,
-		taskQueue:       make(chan *TaskJob, 100), // Буфер для 100 задач
 		ctx:             ctx,
 		cancel:          cancel,
 		running:         false,
+		queued:          make(map[string]bool),
+		processing:      make(map[string]int),
+		clock:           SystemClock{},
+		queueCapacity:   100,
+		crashed:         make(chan *Worker),
+		workerFree:      make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(wp)
 	}
+	wp.taskQueue = make(chan *TaskJob, wp.queueCapacity)
+
+	return wp
 }
 
 // Start запускает пул воркеров
@@ -62,35 +133,74 @@ func (wp *WorkerPool) Start() {
 	}
 
 	wp.running = true
+	wp.draining.Store(false)
 
 	// Создание воркеров
-	wp.workers = make([]*Worker, wp.workerCount)
+	wp.workers = make([]*Worker, 0, wp.workerCount)
 	for i := 0; i < wp.workerCount; i++ {
-		worker := &Worker{
-			id:       i,
-			pool:     wp,
-			jobQueue: make(chan *TaskJob, 1),
-			quit:     make(chan bool),
-		}
-		wp.workers[i] = worker
-
-		wp.wg.Add(1)
-		go worker.start()
+		wp.spawnWorker()
 	}
 
-	// Запуск диспетчера задач
+	// Запуск диспетчера задач и супервизора воркеров
 	go wp.dispatchTasks()
+	go wp.superviseWorkers()
+	if wp.metrics != nil {
+		go wp.sampleGoroutines()
+	}
 
 	log.Printf("Пул воркеров запущен с %d воркерами", wp.workerCount)
 }
 
-// Stop останавливает пул воркеров gracefully
-func (wp *WorkerPool) Stop() {
+// workerPollInterval - период повторной проверки свободных воркеров, пока диспетчер держит задачу
+const workerPollInterval = 100 * time.Millisecond
+
+// DefaultStopTimeout - срок, в течение которого Stop ждет завершения воркеров
+const DefaultStopTimeout = 30 * time.Second
+
+// Stop останавливает пул воркеров gracefully, ожидая завершения воркеров не дольше DefaultStopTimeout;
+// зависшие воркеры оставляются так же, как при Shutdown. Возвращает отчет о незавершенной работе.
+func (wp *WorkerPool) Stop() *entities.ShutdownReport {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultStopTimeout)
+	defer cancel()
+	if err := wp.Shutdown(ctx); err != nil {
+		log.Printf("Принудительная остановка пула воркеров: %v", err)
+	}
+	return wp.ShutdownReport()
+}
+
+// Drain прекращает прием новых задач и ждет, пока воркеры обработают задачи очереди и текущие задачи,
+// не дольше чем до отмены ctx; затем пул останавливается как при Stop, а не завершенные к этому моменту
+// скачивания прерываются и сохраняются для продолжения. Возвращает отчет о незавершенной работе.
+func (wp *WorkerPool) Drain(ctx context.Context) *entities.ShutdownReport {
+	wp.draining.Store(true)
+	log.Println("Пул воркеров дорабатывает принятые задачи перед остановкой...")
+	for remaining := wp.inPool(); remaining > 0; remaining = wp.inPool() {
+		select {
+		case <-ctx.Done():
+			log.Printf("Не дождались завершения %d задач пула воркеров: %v", remaining, ctx.Err())
+			return wp.Stop()
+		case <-wp.clock.After(workerPollInterval):
+		}
+	}
+	return wp.Stop()
+}
+
+// inPool возвращает число задач в очереди, у диспетчера и в обработке
+func (wp *WorkerPool) inPool() int {
+	wp.queuedMu.Lock()
+	defer wp.queuedMu.Unlock()
+	return len(wp.queued)
+}
+
+// Shutdown останавливает пул воркеров, ожидая завершения воркеров до отмены ctx. Воркеры, не завершившиеся
+// к этому моменту, оставляются: их задачи остаются в статусе processing и после перезапуска возвращаются
+// в очередь как прерванные аварийной остановкой. Возвращает ошибку со списком незавершенных задач.
+func (wp *WorkerPool) Shutdown(ctx context.Context) error {
 	wp.mu.Lock()
 	defer wp.mu.Unlock()
 
 	if !wp.running {
-		return
+		return nil
 	}
 
 	log.Println("Остановка пула воркеров...")
@@ -98,35 +208,248 @@ func (wp *WorkerPool) Stop() {
 	// Отмена контекста для прекращения приема новых задач
 	wp.cancel()
 
-	// Остановка всех воркеров
+	// Воркеры останавливаются параллельно: зависший воркер не читает сигнал остановки
+	// и не должен задерживать остановку остальных
 	for _, worker := range wp.workers {
-		worker.stop()
+		go worker.stop()
 	}
 
 	// Ожидание завершения всех воркеров
-	wp.wg.Wait()
+	stopped := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(stopped)
+	}()
 
 	wp.running = false
-	log.Println("Пул воркеров остановлен")
+	select {
+	case <-stopped:
+		log.Println("Пул воркеров остановлен")
+		wp.reportUnfinished(nil)
+		return nil
+	case <-ctx.Done():
+	}
+
+	abandoned := wp.processingTasks()
+	for _, task := range abandoned {
+		log.Printf("Задача %s оставлена незавершенной: воркер не завершился до истечения времени остановки", task)
+	}
+	wp.reportUnfinished(wp.processingWorkers())
+	return fmt.Errorf("воркеры не завершились до истечения времени остановки (%w), незавершенные задачи: %s",
+		ctx.Err(), strings.Join(abandoned, ", "))
+}
+
+// processingTasks возвращает обрабатываемые задачи с ID обрабатывающих их воркеров в порядке ID задач
+func (wp *WorkerPool) processingTasks() []string {
+	workers := wp.processingWorkers()
+	tasks := make([]string, 0, len(workers))
+	for taskID, workerID := range workers {
+		tasks = append(tasks, fmt.Sprintf("%s (воркер %d)", taskID, workerID))
+	}
+	sort.Strings(tasks)
+	return tasks
+}
+
+// processingWorkers возвращает копию соответствия обрабатываемых задач и ID обрабатывающих их воркеров
+func (wp *WorkerPool) processingWorkers() map[string]int {
+	wp.queuedMu.Lock()
+	defer wp.queuedMu.Unlock()
+	return maps.Clone(wp.processing)
+}
+
+// spawnWorker создает и запускает нового воркера (вызывающий должен держать блокировку)
+func (wp *WorkerPool) spawnWorker() {
+	worker := &Worker{
+		id:       wp.nextWorkerID,
+		pool:     wp,
+		jobQueue: make(chan *TaskJob, 1),
+		quit:     make(chan bool),
+		done:     make(chan struct{}),
+	}
+	wp.nextWorkerID++
+	wp.workers = append(wp.workers, worker)
+
+	wp.wg.Add(1)
+	go worker.start()
+	wp.notifyWorkerFree()
+}
+
+// superviseWorkers заменяет воркеров, завершившихся после паники, чтобы пул не остался без воркеров:
+// иначе диспетчер бесконечно ждал бы свободного воркера
+func (wp *WorkerPool) superviseWorkers() {
+	for {
+		select {
+		case worker := <-wp.crashed:
+			wp.replaceWorker(worker)
+		case <-wp.ctx.Done():
+			return
+		}
+	}
+}
+
+// replaceWorker исключает завершившегося воркера из пула и запускает вместо него нового.
+// Воркер, уже исключенный из пула при уменьшении, не заменяется.
+func (wp *WorkerPool) replaceWorker(crashed *Worker) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if !wp.running || wp.ctx.Err() != nil {
+		return
+	}
+
+	for i, worker := range wp.workers {
+		if worker == crashed {
+			wp.workers = append(wp.workers[:i], wp.workers[i+1:]...)
+			wp.spawnWorker()
+			log.Printf("Воркер %d завершился после паники, запущен воркер %d", crashed.id, wp.nextWorkerID-1)
+			return
+		}
+	}
+}
+
+// Resize изменяет количество воркеров во время работы.
+// Новые воркеры запускаются сразу; лишние воркеры (в первую очередь свободные) исключаются из распределения
+// и завершаются после текущей задачи, поэтому задачи в очереди не теряются.
+func (wp *WorkerPool) Resize(count int) error {
+	if count < 1 {
+		return fmt.Errorf("количество воркеров должно быть положительным: %d", count)
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if !wp.running {
+		wp.workerCount = count
+		return nil
+	}
+
+	for len(wp.workers) < count {
+		wp.spawnWorker()
+	}
+
+	if excess := len(wp.workers) - count; excess > 0 {
+		// Свободные воркеры удаляются раньше занятых
+		var idle, busy []*Worker
+		for _, worker := range wp.workers {
+			worker.mu.Lock()
+			if worker.busy {
+				busy = append(busy, worker)
+			} else {
+				idle = append(idle, worker)
+			}
+			worker.mu.Unlock()
+		}
+		ordered := append(idle, busy...)
+
+		removed := make(map[*Worker]bool, excess)
+		for _, worker := range ordered[:excess] {
+			removed[worker] = true
+			go worker.stop()
+		}
+
+		kept := wp.workers[:0]
+		for _, worker := range wp.workers {
+			if !removed[worker] {
+				kept = append(kept, worker)
+			}
+		}
+		wp.workers = kept
+	}
+
+	log.Printf("Количество воркеров изменено: %d -> %d", wp.workerCount, count)
+	wp.workerCount = count
+	return nil
 }
 
-// AddTask добавляет задачу в пул воркеров
-func (wp *WorkerPool) AddTask(taskID string) error {
+// WorkerCount возвращает целевое количество воркеров
+func (wp *WorkerPool) WorkerCount() int {
 	wp.mu.RLock()
 	defer wp.mu.RUnlock()
+	return wp.workerCount
+}
+
+// BusyWorkers возвращает количество воркеров, обрабатывающих задачи
+func (wp *WorkerPool) BusyWorkers() int {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+
+	busy := 0
+	for _, worker := range wp.workers {
+		worker.mu.Lock()
+		if worker.busy {
+			busy++
+		}
+		worker.mu.Unlock()
+	}
+	return busy
+}
 
+// QueueLength возвращает количество задач в очереди
+func (wp *WorkerPool) QueueLength() int {
+	return len(wp.taskQueue)
+}
+
+// AddTask добавляет задачу в пул воркеров.
+// Очередь не сохраняется: задача считается взятой в работу, только когда воркер переводит её в processing,
+// поэтому задачи, не начатые до остановки, остаются в статусе new и снова ставятся в очередь после перезапуска.
+// Задача, уже находящаяся в очереди или в обработке, повторно не добавляется.
+// Если очередь переполнена, AddTask ждет освобождения места не дольше времени, заданного WithQueueWait,
+// и возвращает ошибку, оборачивающую entities.ErrQueueFull.
+func (wp *WorkerPool) AddTask(task *entities.Task) error {
+	job := &TaskJob{TaskID: task.ID.String(), RequestID: task.RequestID}
+
+	wp.mu.RLock()
 	if !wp.running {
+		wp.mu.RUnlock()
 		return fmt.Errorf("пул воркеров не запущен")
 	}
+	if wp.draining.Load() {
+		wp.mu.RUnlock()
+		return fmt.Errorf("пул воркеров завершает работу")
+	}
 
+	wp.queuedMu.Lock()
+	if wp.queued[job.TaskID] {
+		wp.queuedMu.Unlock()
+		wp.mu.RUnlock()
+		log.Printf("Задача %s уже находится в пуле воркеров", job)
+		return nil
+	}
+	// Место в пуле резервируется до постановки, чтобы задача не была поставлена дважды во время ожидания
+	wp.queued[job.TaskID] = true
+	wp.queuedMu.Unlock()
+	wp.mu.RUnlock()
+
+	// Ожидание места выполняется без блокировок пула, чтобы не мешать Stop и Resize
+	if err := wp.enqueue(job); err != nil {
+		wp.release(job.TaskID)
+		return err
+	}
+	log.Printf("Задача %s добавлена в пул воркеров", job)
+	return nil
+}
+
+// enqueue помещает задачу в очередь, ожидая освобождения места не дольше queueWait
+func (wp *WorkerPool) enqueue(job *TaskJob) error {
 	select {
-	case wp.taskQueue <- &TaskJob{TaskID: taskID}:
-		log.Printf("Задача %s добавлена в пул воркеров", taskID)
+	case wp.taskQueue <- job:
 		return nil
 	case <-wp.ctx.Done():
 		return fmt.Errorf("пул воркеров завершает работу")
 	default:
-		return fmt.Errorf("очередь задач переполнена")
+	}
+
+	if wp.queueWait <= 0 {
+		return fmt.Errorf("%w: %d задач", entities.ErrQueueFull, wp.queueCapacity)
+	}
+
+	select {
+	case wp.taskQueue <- job:
+		return nil
+	case <-wp.ctx.Done():
+		return fmt.Errorf("пул воркеров завершает работу")
+	case <-wp.clock.After(wp.queueWait):
+		return fmt.Errorf("%w: %d задач, место не освободилось за %v", entities.ErrQueueFull, wp.queueCapacity, wp.queueWait)
 	}
 }
 
@@ -134,38 +457,34 @@ func (wp *WorkerPool) AddTask(taskID string) error {
 func (wp *WorkerPool) dispatchTasks() {
 	log.Println("Диспетчер задач запущен")
 	for {
+		// Новые задачи не раздаются, пока число горутин близко к пределу
+		if !wp.waitGoroutineCapacity() {
+			log.Println("Диспетчер задач остановлен")
+			return
+		}
+		// Пока на диске мало свободного места, задачи не раздаются, если включена приостановка пула
+		if !wp.waitDiskSpace() {
+			log.Println("Диспетчер задач остановлен")
+			return
+		}
+
 		select {
 		case job := <-wp.taskQueue:
-			log.Printf("Диспетчер получил задачу %s", job.TaskID)
-			// Поиск доступного воркера
-			worker := wp.findAvailableWorker()
-			if worker != nil {
-				log.Printf("Найден доступный воркер %d для задачи %s", worker.id, job.TaskID)
-				select {
-				case worker.jobQueue <- job:
-					log.Printf("Задача %s передана воркеру %d", job.TaskID, worker.id)
-				default:
-					log.Printf("Воркер %d занят, возвращаем задачу %s в очередь", worker.id, job.TaskID)
-					// Воркер занят, возвращаем задачу в очередь
-					go func() {
-						time.Sleep(100 * time.Millisecond)
-						select {
-						case wp.taskQueue <- job:
-						case <-wp.ctx.Done():
-						}
-					}()
+			log.Printf("Диспетчер получил задачу %s", job)
+			// Поиск доступного воркера и передача ему задачи выполняются под блокировкой пула,
+			// чтобы Resize не удалил воркера между выбором и передачей
+			wp.mu.RLock()
+			worker := wp.assignWorker(job)
+			wp.mu.RUnlock()
+
+			if worker == nil {
+				log.Printf("Нет доступных воркеров для задачи %s, ожидаем освобождения воркера", job)
+				if worker = wp.waitWorker(job); worker == nil {
+					log.Println("Диспетчер задач остановлен")
+					return
 				}
-			} else {
-				log.Printf("Нет доступных воркеров для задачи %s, возвращаем в очередь", job.TaskID)
-				// Нет доступных воркеров, возвращаем задачу в очередь
-				go func() {
-					time.Sleep(100 * time.Millisecond)
-					select {
-					case wp.taskQueue <- job:
-					case <-wp.ctx.Done():
-					}
-				}()
 			}
+			log.Printf("Задача %s передана воркеру %d", job, worker.id)
 		case <-wp.ctx.Done():
 			log.Println("Диспетчер задач остановлен")
 			return
@@ -173,16 +492,53 @@ func (wp *WorkerPool) dispatchTasks() {
 	}
 }
 
-// findAvailableWorker находит доступного воркера
-func (wp *WorkerPool) findAvailableWorker() *Worker {
+// release отмечает, что задача покинула пул и может быть поставлена в очередь снова
+func (wp *WorkerPool) release(taskID string) {
+	wp.queuedMu.Lock()
+	defer wp.queuedMu.Unlock()
+	delete(wp.queued, taskID)
+	delete(wp.processing, taskID)
+}
+
+// waitWorker держит задачу, которую некому передать, пока не освободится воркер, и возвращает его.
+// Диспетчер не берет из очереди новые задачи, пока ждет, поэтому ожидающих задач вне очереди не больше одной,
+// а переполнение очереди по-прежнему ограничивает прием задач. Проверка повторяется и без сигнала на случай
+// изменения размера пула. При остановке пула возвращает nil: задача остается в статусе new.
+func (wp *WorkerPool) waitWorker(job *TaskJob) *Worker {
+	wp.requeuing.Add(1)
+	defer wp.requeuing.Add(-1)
+	for {
+		select {
+		case <-wp.workerFree:
+		case <-wp.clock.After(workerPollInterval):
+		case <-wp.ctx.Done():
+			return nil
+		}
+
+		wp.mu.RLock()
+		worker := wp.assignWorker(job)
+		wp.mu.RUnlock()
+		if worker != nil {
+			return worker
+		}
+	}
+}
+
+// notifyWorkerFree сообщает диспетчеру, ожидающему воркера, что воркер освободился
+func (wp *WorkerPool) notifyWorkerFree() {
+	select {
+	case wp.workerFree <- struct{}{}:
+	default:
+	}
+}
+
+// assignWorker передает задачу первому свободному воркеру и возвращает его; nil - свободных воркеров нет
+// (вызывающий должен держать блокировку пула)
+func (wp *WorkerPool) assignWorker(job *TaskJob) *Worker {
 	for _, worker := range wp.workers {
-		worker.mu.Lock()
-		if !worker.busy {
-			worker.busy = true
-			worker.mu.Unlock()
+		if worker.tryAssign(job) {
 			return worker
 		}
-		worker.mu.Unlock()
 	}
 	return nil
 }
@@ -190,6 +546,7 @@ func (wp *WorkerPool) findAvailableWorker() *Worker {
 // start запускает воркера
 func (w *Worker) start() {
 	defer w.pool.wg.Done()
+	defer close(w.done)
 
 	log.Printf("Воркер %d запущен", w.id)
 
@@ -197,45 +554,102 @@ func (w *Worker) start() {
 		select {
 		case job := <-w.jobQueue:
 			if job != nil {
-				w.processJob(job)
+				if w.processJob(job) {
+					// После паники воркер не переиспользуется: супервизор запускает ему замену
+					select {
+					case w.pool.crashed <- w:
+					case <-w.pool.ctx.Done():
+					}
+					return
+				}
 				// Освобождаем воркера после обработки задачи
 				w.mu.Lock()
 				w.busy = false
 				w.mu.Unlock()
+				w.pool.notifyWorkerFree()
 			}
 		case <-w.quit:
+			// Задача, уже переданная воркеру диспетчером, обрабатывается перед остановкой воркера при уменьшении пула;
+			// при остановке пула она остается в статусе new и будет обработана после перезапуска
+			select {
+			case job := <-w.jobQueue:
+				if w.pool.ctx.Err() == nil {
+					w.processJob(job)
+				}
+			default:
+			}
 			log.Printf("Воркер %d остановлен", w.id)
 			return
 		}
 	}
 }
 
-// stop останавливает воркера
+// tryAssign передает задачу свободному воркеру. Отметка занятости и передача выполняются под одной
+// блокировкой воркера: воркер становится занятым, только если задача помещена в его очередь, поэтому
+// занятый воркер всегда получает ровно одну задачу и освобождается после её обработки.
+func (w *Worker) tryAssign(job *TaskJob) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.busy {
+		return false
+	}
+	select {
+	case w.jobQueue <- job:
+		w.busy = true
+		return true
+	default:
+		// Очередь воркера занята: отметка не ставится, и задача достанется другому воркеру
+		return false
+	}
+}
+
+// stop останавливает воркера; для уже завершившегося воркера ничего не делает
 func (w *Worker) stop() {
-	w.quit <- true
+	select {
+	case w.quit <- true:
+	case <-w.done:
+	}
 }
 
-// processJob обрабатывает задачу
-func (w *Worker) processJob(job *TaskJob) {
-	log.Printf("Воркер %d обрабатывает задачу %s", w.id, job.TaskID)
+// processJob обрабатывает задачу. Паника при обработке перехватывается: она записывается в журнал,
+// задача завершается ошибкой, а processJob возвращает true.
+func (w *Worker) processJob(job *TaskJob) (crashed bool) {
+	log.Printf("Воркер %d обрабатывает задачу %s", w.id, job)
+	w.pool.queuedMu.Lock()
+	w.pool.processing[job.TaskID] = w.id
+	w.pool.queuedMu.Unlock()
+	defer w.pool.release(job.TaskID)
+	// Задача завершается ошибкой до освобождения места в пуле, чтобы её не поставили в очередь повторно
+	defer func() {
+		if r := recover(); r != nil {
+			crashed = true
+			log.Printf("Паника воркера %d при обработке задачи %s: %v\n%s", w.id, job, r, debug.Stack())
+			reason := fmt.Sprintf("внутренняя ошибка при обработке задачи: %v", r)
+			if err := w.pool.downloadUsecase.FailTask(context.Background(), job.TaskID, reason); err != nil {
+				log.Printf("Воркер %d не смог завершить задачу %s после паники: %v", w.id, job, err)
+			}
+		}
+	}()
 
 	// Получение ожидающих задач и обработка той, которая соответствует ID
 	tasks, err := w.pool.downloadUsecase.GetPendingTasks(w.pool.ctx)
 	if err != nil {
 		log.Printf("Воркер %d не смог получить ожидающие задачи: %v", w.id, err)
-		return
+		return false
 	}
 
 	for _, task := range tasks {
 		if task.ID.String() == job.TaskID {
 			if err := w.pool.downloadUsecase.ProcessTask(w.pool.ctx, task); err != nil {
-				log.Printf("Воркер %d не смог обработать задачу %s: %v", w.id, job.TaskID, err)
+				log.Printf("Воркер %d не смог обработать задачу %s: %v", w.id, job, err)
 			} else {
-				log.Printf("Воркер %d завершил задачу %s", w.id, job.TaskID)
+				log.Printf("Воркер %d завершил задачу %s", w.id, job)
 			}
-			return
+			return false
 		}
 	}
 
-	log.Printf("Воркер %d не смог найти задачу %s", w.id, job.TaskID)
+	log.Printf("Воркер %d не смог найти задачу %s", w.id, job)
+	return false
 }
@@ -0,0 +1,643 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+	"file-downloader/internal/usecases"
+)
+
+// recordingDownloadUsecase serves a fixed set of pending tasks and records processed IDs
+type recordingDownloadUsecase struct {
+	mu        sync.Mutex
+	tasks     []*entities.Task
+	processed map[string]bool
+	waiting   []string
+	failed    []string
+	panics    map[string]bool // задачи, обработка которых вызывает панику
+
+	deliveries int
+}
+
+func (u *recordingDownloadUsecase) ProcessTask(ctx context.Context, task *entities.Task) error {
+	time.Sleep(10 * time.Millisecond)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.panics[task.ID.String()] {
+		panic("nil pointer dereference")
+	}
+	u.processed[task.ID.String()] = true
+	return nil
+}
+
+func (u *recordingDownloadUsecase) DownloadFile(ctx context.Context, url string, taskID string, fileIndex int) error {
+	return nil
+}
+
+func (u *recordingDownloadUsecase) GetPendingTasks(ctx context.Context) ([]*entities.Task, error) {
+	return u.tasks, nil
+}
+
+func (u *recordingDownloadUsecase) ReleaseScheduledTasks(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (u *recordingDownloadUsecase) RequeueInterruptedTasks(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (u *recordingDownloadUsecase) MarkWaitingForCapacity(ctx context.Context, taskIDs []string) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.waiting = append(u.waiting, taskIDs...)
+	return len(taskIDs), nil
+}
+
+func (u *recordingDownloadUsecase) FailTask(ctx context.Context, taskID string, reason string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.failed = append(u.failed, taskID)
+	return nil
+}
+
+func (u *recordingDownloadUsecase) SupportedSchemes() []string {
+	return []string{"http", "https"}
+}
+
+func (u *recordingDownloadUsecase) RetryStats() entities.RetryStats {
+	return entities.RetryStats{}
+}
+
+func (u *recordingDownloadUsecase) ResourceUsage() entities.ResourceUsage {
+	return entities.ResourceUsage{}
+}
+
+func (u *recordingDownloadUsecase) DeliverCallbacks(ctx context.Context) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.deliveries++
+	return 0, nil
+}
+
+func (u *recordingDownloadUsecase) deliveryCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.deliveries
+}
+
+func (u *recordingDownloadUsecase) failedTasks() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]string(nil), u.failed...)
+}
+
+func (u *recordingDownloadUsecase) processedCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.processed)
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	// Setup
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool)}
+	for i := 0; i < 6; i++ {
+		usecase.tasks = append(usecase.tasks, entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now()))
+	}
+	pool := NewWorkerPool(2, usecase)
+	pool.Start()
+	defer pool.Stop()
+
+	// Execute: scale up
+	if err := pool.Resize(4); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if pool.WorkerCount() != 4 || len(pool.workers) != 4 {
+		t.Errorf("Expected 4 workers, got %d (%d running)", pool.WorkerCount(), len(pool.workers))
+	}
+
+	// Execute: queue jobs and scale down while they are processed
+	for _, task := range usecase.tasks {
+		if err := pool.AddTask(task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+	if err := pool.Resize(1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if pool.WorkerCount() != 1 || len(pool.workers) != 1 {
+		t.Errorf("Expected 1 worker, got %d (%d running)", pool.WorkerCount(), len(pool.workers))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for usecase.processedCount() < len(usecase.tasks) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := usecase.processedCount(); got != len(usecase.tasks) {
+		t.Errorf("Expected all %d queued jobs to be processed after scale down, got %d", len(usecase.tasks), got)
+	}
+
+	if err := pool.Resize(0); err == nil {
+		t.Error("Expected error for non-positive worker count")
+	}
+}
+
+// blockingTransport blocks every request until it is cancelled and signals when the first one starts
+type blockingTransport struct {
+	started chan struct{}
+	once    sync.Once
+}
+
+func (t *blockingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() { close(t.started) })
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+// okTransport answers every request with a small body
+type okTransport struct{}
+
+func (okTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Header:        http.Header{},
+		Body:          io.NopCloser(strings.NewReader("data")),
+		ContentLength: 4,
+		Request:       req,
+	}, nil
+}
+
+func TestWorkerPoolQueuedTasksSurviveShutdown(t *testing.T) {
+	// Setup
+	statePath := filepath.Join(t.TempDir(), "tasks.json")
+	downloadDir := t.TempDir()
+	ctx := context.Background()
+
+	repo := repository.NewFileBasedTaskRepository(statePath)
+	transport := &blockingTransport{started: make(chan struct{})}
+	usecase := usecases.NewDownloadUsecase(repo, repo,
+		usecases.WithDownloadDir(downloadDir),
+		usecases.WithRoundTripper(transport))
+
+	var ids []string
+	var tasks []*entities.Task
+	for i := 0; i < 3; i++ {
+		task := entities.NewTask([]string{"https://example.com/file.bin"}, time.Now())
+		task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+		repo.Create(ctx, task)
+		ids = append(ids, task.ID.String())
+		tasks = append(tasks, task)
+	}
+
+	pool := NewWorkerPool(1, usecase)
+	pool.Start()
+	for _, task := range tasks {
+		if err := pool.AddTask(task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+
+	// Execute: stop while the first task is downloading and the others are still queued
+	select {
+	case <-transport.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the first task to start downloading")
+	}
+	pool.Stop()
+
+	// Assert: no task is lost or failed, all of them wait in status new
+	restarted := repository.NewFileBasedTaskRepository(statePath)
+	if err := restarted.LoadTasks(); err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	for _, id := range ids {
+		task, err := restarted.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("Expected task %s to be persisted, got %v", id, err)
+		}
+		if task.Status != entities.TaskStatusNew || task.Files[0].Status != "pending" {
+			t.Errorf("Expected task %s to be new with a pending file, got %s/%s", id, task.Status, task.Files[0].Status)
+		}
+	}
+
+	// Execute: restart and let the scheduler re-queue the tasks
+	usecase = usecases.NewDownloadUsecase(restarted, restarted,
+		usecases.WithDownloadDir(downloadDir),
+		usecases.WithRoundTripper(okTransport{}))
+	pool = NewWorkerPool(2, usecase)
+	pool.Start()
+	defer pool.Stop()
+
+	schedulerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go NewTaskScheduler(usecase, pool, SchedulerConfig{PollInterval: 20 * time.Millisecond}).Run(schedulerCtx)
+
+	// Assert
+	deadline := time.Now().Add(5 * time.Second)
+	for _, id := range ids {
+		for {
+			task, _ := restarted.GetByID(ctx, id)
+			if task.Status == entities.TaskStatusCompleted {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected task %s to complete after restart, got %s", id, task.Status)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+}
+
+func TestWorkerPoolIgnoresDuplicateTask(t *testing.T) {
+	// Setup
+	pool := NewWorkerPool(1, &recordingDownloadUsecase{processed: make(map[string]bool)})
+	pool.running = true
+
+	// Execute
+	task := entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now())
+	first := pool.AddTask(task)
+	second := pool.AddTask(task)
+
+	// Assert
+	if first != nil || second != nil {
+		t.Fatalf("Expected no errors, got %v and %v", first, second)
+	}
+	if pool.QueueLength() != 1 {
+		t.Errorf("Expected duplicate to be ignored, got queue length %d", pool.QueueLength())
+	}
+}
+
+func TestWorkerPoolAddTaskWaitsForCapacity(t *testing.T) {
+	// Setup: the dispatcher is not started, so queued jobs stay in the queue
+	clock := testutil.NewFakeClock(time.Now())
+	pool := NewWorkerPool(1, nil, WithPoolClock(clock), WithQueueCapacity(1), WithQueueWait(time.Second))
+	pool.running = true
+	first := entities.NewTask([]string{"https://example.com/a.jpg"}, time.Now())
+	second := entities.NewTask([]string{"https://example.com/b.jpg"}, time.Now())
+	if err := pool.AddTask(first); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	result := make(chan error, 1)
+	go func() { result <- pool.AddTask(second) }()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	err := <-result
+
+	// Assert
+	if !errors.Is(err, entities.ErrQueueFull) {
+		t.Fatalf("Expected ErrQueueFull after the wait timeout, got %v", err)
+	}
+	if pool.queued[second.ID.String()] {
+		t.Error("Expected rejected task not to stay reserved in the pool")
+	}
+
+	// Execute: capacity frees up while the task waits
+	go func() { result <- pool.AddTask(second) }()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	<-pool.taskQueue
+	err = <-result
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected task to be queued once capacity freed up, got %v", err)
+	}
+	if pool.QueueLength() != 1 {
+		t.Errorf("Expected 1 queued job, got %d", pool.QueueLength())
+	}
+}
+
+func TestWorkerPoolReplacesWorkerAfterPanic(t *testing.T) {
+	// Setup
+	crashing := entities.NewTask([]string{"https://example.com/crash.bin"}, time.Now())
+	healthy := entities.NewTask([]string{"https://example.com/file.bin"}, time.Now())
+	usecase := &recordingDownloadUsecase{
+		tasks:     []*entities.Task{crashing, healthy},
+		processed: make(map[string]bool),
+		panics:    map[string]bool{crashing.ID.String(): true},
+	}
+	pool := NewWorkerPool(1, usecase)
+	pool.Start()
+	defer pool.Stop()
+
+	// Execute
+	if err := pool.AddTask(crashing); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	if err := pool.AddTask(healthy); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+
+	// Assert: the healthy task is processed by the replacement worker
+	waitFor(t, func() bool { return usecase.processedCount() == 1 })
+	if failed := usecase.failedTasks(); len(failed) != 1 || failed[0] != crashing.ID.String() {
+		t.Errorf("Expected crashed task %s to be failed, got %v", crashing.ID, failed)
+	}
+	pool.mu.RLock()
+	workers := len(pool.workers)
+	pool.mu.RUnlock()
+	if workers != 1 || pool.WorkerCount() != 1 {
+		t.Errorf("Expected 1 worker after replacement, got %d (target %d)", workers, pool.WorkerCount())
+	}
+
+	// Assert: the crashed task can be queued again
+	if err := pool.AddTask(crashing); err != nil {
+		t.Errorf("Expected crashed task to leave the pool, got %v", err)
+	}
+}
+
+func TestWorkerPoolNoWorkerStuckBusyUnderLoad(t *testing.T) {
+	// Setup
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool)}
+	for i := 0; i < 200; i++ {
+		usecase.tasks = append(usecase.tasks, entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now()))
+	}
+	pool := NewWorkerPool(8, usecase, WithQueueCapacity(len(usecase.tasks)))
+	pool.Start()
+	defer pool.Stop()
+
+	// Execute: submit jobs concurrently while the pool is resized
+	var wg sync.WaitGroup
+	for _, task := range usecase.tasks {
+		wg.Add(1)
+		go func(task *entities.Task) {
+			defer wg.Done()
+			if err := pool.AddTask(task); err != nil {
+				t.Errorf("Failed to add task: %v", err)
+			}
+		}(task)
+	}
+	for _, count := range []int{3, 12, 5, 8} {
+		if err := pool.Resize(count); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	// Assert
+	waitFor(t, func() bool { return usecase.processedCount() == len(usecase.tasks) })
+	waitFor(t, func() bool { return pool.BusyWorkers() == 0 })
+	if pool.QueueLength() != 0 {
+		t.Errorf("Expected empty queue, got %d jobs", pool.QueueLength())
+	}
+}
+
+func TestWorkerPoolAssignSkipsWorkerWithFullQueue(t *testing.T) {
+	// Setup: the first worker's queue is already occupied, so the hand-off to it fails
+	pool := NewWorkerPool(2, nil)
+	full := &Worker{id: 1, jobQueue: make(chan *TaskJob, 1)}
+	full.jobQueue <- &TaskJob{TaskID: "pending"}
+	free := &Worker{id: 2, jobQueue: make(chan *TaskJob, 1)}
+	pool.workers = []*Worker{full, free}
+
+	// Execute
+	worker := pool.assignWorker(&TaskJob{TaskID: "job"})
+
+	// Assert
+	if worker != free {
+		t.Fatalf("Expected the job to go to the free worker, got %v", worker)
+	}
+	if full.busy {
+		t.Error("Expected the worker that did not receive the job to stay free")
+	}
+	if !free.busy || len(free.jobQueue) != 1 {
+		t.Errorf("Expected the free worker to be busy with the job, got busy=%v queue=%d", free.busy, len(free.jobQueue))
+	}
+}
+
+// stuckDownloadUsecase blocks in ProcessTask until released, ignoring cancellation
+type stuckDownloadUsecase struct {
+	*recordingDownloadUsecase
+	started chan struct{}
+	release chan struct{}
+}
+
+func (u *stuckDownloadUsecase) ProcessTask(ctx context.Context, task *entities.Task) error {
+	close(u.started)
+	<-u.release
+	return nil
+}
+
+func TestWorkerPoolShutdownAbandonsStuckWorker(t *testing.T) {
+	// Setup
+	task := entities.NewTask([]string{"https://example.com/file.bin"}, time.Now())
+	usecase := &stuckDownloadUsecase{
+		recordingDownloadUsecase: &recordingDownloadUsecase{tasks: []*entities.Task{task}, processed: make(map[string]bool)},
+		started:                  make(chan struct{}),
+		release:                  make(chan struct{}),
+	}
+	defer close(usecase.release)
+	pool := NewWorkerPool(2, usecase)
+	pool.Start()
+	if err := pool.AddTask(task); err != nil {
+		t.Fatalf("Failed to add task: %v", err)
+	}
+	<-usecase.started
+
+	// Execute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err := pool.Shutdown(ctx)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), task.ID.String()) {
+		t.Errorf("Expected the stuck task to be reported, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected shutdown to return after the deadline, took %v", elapsed)
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected repeated shutdown to do nothing, got %v", err)
+	}
+}
+
+// gatedDownloadUsecase processes each task only after a token is sent to gate
+type gatedDownloadUsecase struct {
+	*recordingDownloadUsecase
+	gate chan struct{}
+}
+
+func (u *gatedDownloadUsecase) ProcessTask(ctx context.Context, task *entities.Task) error {
+	<-u.gate
+	return u.recordingDownloadUsecase.ProcessTask(ctx, task)
+}
+
+func TestWorkerPoolHoldsJobUntilWorkerIsFree(t *testing.T) {
+	// Setup
+	usecase := &gatedDownloadUsecase{
+		recordingDownloadUsecase: &recordingDownloadUsecase{processed: make(map[string]bool)},
+		gate:                     make(chan struct{}),
+	}
+	for i := 0; i < 3; i++ {
+		usecase.tasks = append(usecase.tasks, entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now()))
+	}
+	pool := NewWorkerPool(1, usecase)
+	pool.Start()
+	defer pool.Stop()
+
+	// Execute
+	for _, task := range usecase.tasks {
+		if err := pool.AddTask(task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+
+	// Assert: the dispatcher holds one job while the only worker is busy, the rest stays queued
+	waitFor(t, func() bool { return pool.requeuing.Load() == 1 })
+	if pool.QueueLength() != 1 {
+		t.Errorf("Expected 1 queued job while the worker is busy, got %d", pool.QueueLength())
+	}
+
+	// Execute: the worker finishes its tasks one by one
+	for range usecase.tasks {
+		usecase.gate <- struct{}{}
+	}
+
+	// Assert
+	waitFor(t, func() bool { return usecase.processedCount() == len(usecase.tasks) })
+	if waiting := pool.requeuing.Load(); waiting != 0 {
+		t.Errorf("Expected no jobs waiting for a worker, got %d", waiting)
+	}
+}
+
+func TestWorkerPoolDrainFinishesAcceptedTasks(t *testing.T) {
+	// Setup
+	usecase := &gatedDownloadUsecase{
+		recordingDownloadUsecase: &recordingDownloadUsecase{processed: make(map[string]bool)},
+		gate:                     make(chan struct{}),
+	}
+	for i := 0; i < 2; i++ {
+		usecase.tasks = append(usecase.tasks, entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now()))
+	}
+	pool := NewWorkerPool(1, usecase)
+	pool.Start()
+	for _, task := range usecase.tasks {
+		if err := pool.AddTask(task); err != nil {
+			t.Fatalf("Failed to add task: %v", err)
+		}
+	}
+
+	// Execute
+	drained := make(chan *entities.ShutdownReport)
+	go func() {
+		drained <- pool.Drain(context.Background())
+	}()
+	waitFor(t, func() bool { return pool.draining.Load() })
+	lateErr := pool.AddTask(entities.NewTask([]string{"https://example.com/late.jpg"}, time.Now()))
+	for range usecase.tasks {
+		usecase.gate <- struct{}{}
+	}
+
+	// Assert
+	var report *entities.ShutdownReport
+	select {
+	case report = <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Drain to return after accepted tasks finish")
+	}
+	if lateErr == nil {
+		t.Error("Expected new tasks to be rejected while draining")
+	}
+	if processed := usecase.processedCount(); processed != len(usecase.tasks) {
+		t.Errorf("Expected %d processed tasks, got %d", len(usecase.tasks), processed)
+	}
+	if report == nil {
+		t.Error("Expected a shutdown report after draining")
+	}
+}
+
+func TestWorkerPoolPausesDispatchNearGoroutineLimit(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	metrics := NewMetricsRegistry()
+	task := entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now())
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool), tasks: []*entities.Task{task}}
+	pool := NewWorkerPool(1, usecase, WithPoolClock(clock), WithPoolMetrics(metrics), WithGoroutineLimit(100))
+	var goroutines atomic.Int64
+	goroutines.Store(95)
+	pool.goroutineCount = func() int { return int(goroutines.Load()) }
+	pool.Start()
+	defer pool.Stop()
+
+	// Execute
+	if err := pool.AddTask(task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	waitFor(t, func() bool { return metrics.Value("downloader_dispatch_paused", nil) == 1 })
+
+	// Assert
+	if usecase.processedCount() != 0 || pool.QueueLength() != 1 {
+		t.Fatalf("Expected job to stay queued near the limit, got %d processed, queue %d", usecase.processedCount(), pool.QueueLength())
+	}
+	if value := metrics.Value("downloader_goroutines", nil); value != 95 {
+		t.Errorf("Expected goroutines gauge 95, got %v", value)
+	}
+
+	// Execute: the goroutine count drops below the threshold
+	waitFor(t, func() bool { return clock.Waiters() == 2 })
+	goroutines.Store(10)
+	clock.Advance(time.Second)
+
+	// Assert
+	waitFor(t, func() bool { return usecase.processedCount() == 1 })
+	if value := metrics.Value("downloader_dispatch_paused", nil); value != 0 {
+		t.Errorf("Expected dispatch_paused gauge 0 after resuming, got %v", value)
+	}
+}
+
+func TestWorkerPoolReportsUnfinishedWorkOnShutdown(t *testing.T) {
+	// Setup
+	task := entities.NewTask([]string{"https://example.com/file.jpg"}, time.Now())
+	task.Files[0] = entities.File{URL: "https://example.com/file.jpg", Status: "pending", PartialPath: "/downloads/file.jpg.part", ResumeOffset: 42}
+	usecase := &recordingDownloadUsecase{processed: make(map[string]bool), tasks: []*entities.Task{task}}
+	pool := NewWorkerPool(1, usecase)
+	pool.Start()
+	path := filepath.Join(t.TempDir(), "report", "shutdown.json")
+
+	// Execute
+	report := pool.Stop()
+	err := WriteShutdownReport(path, report)
+
+	// Assert
+	if report == nil || len(report.Tasks) != 1 {
+		t.Fatalf("Expected a report with 1 unfinished task, got %+v", report)
+	}
+	if files := report.Tasks[0].Files; len(files) != 1 || files[0].Offset != 42 {
+		t.Errorf("Expected interrupted file at offset 42, got %+v", files)
+	}
+	if err != nil {
+		t.Fatalf("Expected report to be written, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	var written entities.ShutdownReport
+	if err != nil || json.Unmarshal(data, &written) != nil || len(written.Tasks) != 1 || written.Tasks[0].ID != task.ID.String() {
+		t.Errorf("Expected written report with task %s, got %s (%v)", task.ID, data, err)
+	}
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"file-downloader/internal/entities"
+)
+
+// AlertNotifier определяет интерфейс отправки оповещений оператору (например, на webhook)
+type AlertNotifier interface {
+	Notify(ctx context.Context, alert entities.Alert) error
+}
+
+// RetryMonitor определяет интерфейс получения статистики повторных попыток скачивания
+type RetryMonitor interface {
+	RetryStats() entities.RetryStats
+}
+
+// ResourceMonitor определяет интерфейс получения оценки ресурсов, занятых скачиванием
+type ResourceMonitor interface {
+	ResourceUsage() entities.ResourceUsage
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+
+	"file-downloader/internal/entities"
+)
+
+// CallbackSender определяет интерфейс отправки уведомлений о завершении задач на callback_url.
+// Возвращает код ответа получателя (0, если ответ не получен); ответ вне 2xx считается ошибкой.
+type CallbackSender interface {
+	Send(ctx context.Context, url string, manifest entities.Manifest) (int, error)
+}
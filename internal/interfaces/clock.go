@@ -0,0 +1,10 @@
+package interfaces
+
+import "time"
+
+// Clock определяет источник времени для логики, зависящей от времени (повторы, планирование, сроки хранения)
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "context"
+
+// DiskSpaceGate приостанавливает скачивание, пока на диске скачивания мало свободного места
+type DiskSpaceGate interface {
+	// WaitForSpace ждет, пока свободного места станет не меньше порога; при отмене контекста возвращает его ошибку
+	WaitForSpace(ctx context.Context) error
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import "file-downloader/internal/entities"
+
+// EventPublisher определяет интерфейс публикации событий жизненного цикла задач.
+// Publish не должен блокировать: события публикуются из воркеров во время скачивания.
+type EventPublisher interface {
+	Publish(event entities.TaskEvent)
+}
+
+// EventSubscriber определяет интерфейс подписки на события жизненного цикла задач
+type EventSubscriber interface {
+	// Subscribe возвращает канал событий указанных типов (без типов - всех событий) и функцию отписки.
+	// Канал закрывается после отписки или остановки шины.
+	Subscribe(types ...entities.EventType) (<-chan entities.TaskEvent, func())
+}
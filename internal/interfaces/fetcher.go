@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+)
+
+// FetchRequest описывает запрос на получение удаленного файла
+type FetchRequest struct {
+	URL     string
+	Headers map[string]string // дополнительные заголовки (используются протоколами, которые их поддерживают)
+}
+
+// FetchResult представляет результат открытия удаленного файла
+type FetchResult struct {
+	Body               io.ReadCloser
+	Size               int64 // -1, если размер неизвестен
+	ContentDisposition string
+	ContentType        string // тип содержимого, заявленный источником (пустой, если протокол его не сообщает)
+	AcceptRanges       bool   // источник поддерживает запросы диапазонов байт
+	Protocol           string // протокол ответа, например HTTP/1.1 или HTTP/2.0 (пустой, если не применимо)
+	ETag               string // версия содержимого, заявленная источником (пустая, если не сообщается)
+	LastModified       string // время изменения содержимого, заявленное источником (пустое, если не сообщается)
+}
+
+// Fetcher определяет интерфейс для получения файлов по URL определенной схемы
+type Fetcher interface {
+	Fetch(ctx context.Context, req FetchRequest) (*FetchResult, error)
+}
+
+// RangeFetcher реализуется fetcher'ами, умеющими скачивать диапазон байт файла
+type RangeFetcher interface {
+	Fetcher
+	// FetchRange возвращает байты с start по end включительно
+	FetchRange(ctx context.Context, req FetchRequest, start, end int64) (*FetchResult, error)
+}
@@ -0,0 +1,7 @@
+package interfaces
+
+// HealthChecker определяет интерфейс проверки работоспособности компонента для /health/ready
+type HealthChecker interface {
+	// Health возвращает ошибку, если компонент работает с ограничениями
+	Health() error
+}
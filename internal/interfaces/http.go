@@ -10,4 +10,23 @@ type HTTPHandler interface {
 	GetTask(w http.ResponseWriter, r *http.Request)
 	GetAllTasks(w http.ResponseWriter, r *http.Request)
 	GetTaskStatus(w http.ResponseWriter, r *http.Request)
+	GetTaskStatuses(w http.ResponseWriter, r *http.Request)
+	GetTaskProgress(w http.ResponseWriter, r *http.Request)
+	ListTaskFiles(w http.ResponseWriter, r *http.Request)
+	GetFileContent(w http.ResponseWriter, r *http.Request)
+	UpdateTask(w http.ResponseWriter, r *http.Request)
+	RetryFile(w http.ResponseWriter, r *http.Request)
+	RetryFailedFiles(w http.ResponseWriter, r *http.Request)
+	VerifyTask(w http.ResponseWriter, r *http.Request)
+	GetTaskTree(w http.ResponseWriter, r *http.Request)
+	DeleteTask(w http.ResponseWriter, r *http.Request)
+	RestoreTask(w http.ResponseWriter, r *http.Request)
+	ListFailures(w http.ResponseWriter, r *http.Request)
+	SavePreset(w http.ResponseWriter, r *http.Request)
+	GetPreset(w http.ResponseWriter, r *http.Request)
+	ListPresets(w http.ResponseWriter, r *http.Request)
+	DeletePreset(w http.ResponseWriter, r *http.Request)
+	ListDeadLetters(w http.ResponseWriter, r *http.Request)
+	RedriveDeadLetter(w http.ResponseWriter, r *http.Request)
+	StreamEvents(w http.ResponseWriter, r *http.Request)
 }
@@ -0,0 +1,8 @@
+package interfaces
+
+// TaskLocker определяет интерфейс блокировок отдельных задач: изменения одной задачи, выполняемые
+// разными use case (API и воркером), читают и сохраняют её под одной блокировкой
+type TaskLocker interface {
+	// Lock захватывает блокировку задачи и возвращает функцию её освобождения
+	Lock(taskID string) func()
+}
@@ -0,0 +1,8 @@
+package interfaces
+
+// LogStream определяет интерфейс подписки на записи журнала сервиса по мере их появления
+type LogStream interface {
+	// Subscribe возвращает канал записей журнала и функцию отписки.
+	// Канал закрывается после отписки или остановки трансляции.
+	Subscribe() (<-chan string, func())
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"file-downloader/internal/entities"
+)
+
+// MaintenanceMode определяет интерфейс режима обслуживания: пока он включен, новые задачи не принимаются
+// и не ставятся в пул воркеров, а уже выполняющиеся задачи продолжают работу
+type MaintenanceMode interface {
+	Enabled() bool
+	State() entities.Maintenance
+	// SetEnabled включает или выключает режим и сохраняет его состояние
+	SetEnabled(ctx context.Context, enabled bool) (entities.Maintenance, error)
+	// Health возвращает ошибку, пока режим включен (проверка /health/ready)
+	Health() error
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "context"
+
+// ManifestFetcher загружает манифест со списком файлов задачи
+type ManifestFetcher interface {
+	// Fetch возвращает не больше limit первых байт манифеста, запрошенного с заголовками headers
+	Fetch(ctx context.Context, url string, headers map[string]string, limit int64) ([]byte, error)
+}
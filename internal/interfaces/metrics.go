@@ -0,0 +1,7 @@
+package interfaces
+
+// MetricsRecorder определяет интерфейс для записи метрик сервиса
+type MetricsRecorder interface {
+	IncCounter(name string, labels map[string]string, delta float64)
+	SetGauge(name string, labels map[string]string, value float64)
+}
@@ -0,0 +1,22 @@
+package interfaces
+
+// NameRequest описывает скачиваемый файл, для которого выбирается путь на диске
+type NameRequest struct {
+	URL                string // URL файла
+	ContentDisposition string // заголовок Content-Disposition ответа источника (пустой, если не сообщается)
+	ContentType        string // тип содержимого, заявленный источником (пустой, если не сообщается)
+	IndexName          string // имя файла для URL директории с HTML-ответом (пусто - по сегменту пути)
+	Dir                string // директория файла согласно стратегии размещения
+	Prefix             string // префикс имени файла (начало ID задачи для flat и by-host)
+	Suffix             string // суффикс имени сохраняемого файла (".gz" при сжатии на диске)
+	FileIndex          int    // индекс файла в задаче
+
+	Taken  func(path string) bool // путь уже выбран другим файлом задачи
+	Exists func(path string) bool // по пути уже лежит файл, не скачанный ранее этим файлом задачи
+}
+
+// NameResolver выбирает путь, по которому сохраняется скачиваемый файл. Путь должен находиться внутри
+// Dir и не совпадать с путями, занятыми другими файлами задачи.
+type NameResolver interface {
+	Resolve(req NameRequest) (string, error)
+}
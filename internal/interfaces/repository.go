@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"file-downloader/internal/entities"
 )
@@ -10,10 +11,16 @@ import (
 type TaskRepository interface {
 	Create(ctx context.Context, task *entities.Task) error
 	GetByID(ctx context.Context, id string) (*entities.Task, error)
+	// GetByIDs получает задачи по списку ID за одно обращение; отсутствующие ID не попадают в результат
+	GetByIDs(ctx context.Context, ids []string) (map[string]*entities.Task, error)
 	GetAll(ctx context.Context) ([]*entities.Task, error)
+	// GetTasksAfter получает до limit задач, следующих за курсором, в порядке создания (при filter.Newest - от новых к старым);
+	// limit <= 0 снимает ограничение
+	GetTasksAfter(ctx context.Context, after entities.TaskCursor, limit int, filter entities.TaskFilter) ([]*entities.Task, error)
 	Update(ctx context.Context, task *entities.Task) error
 	Delete(ctx context.Context, id string) error
 	GetPendingTasks(ctx context.Context) ([]*entities.Task, error)
+	GetFailedFiles(ctx context.Context, since time.Time, limit int) ([]entities.FailedFile, error)
 }
 
 // PersistentRepository определяет интерфейс для постоянного хранилища
@@ -22,3 +29,39 @@ type PersistentRepository interface {
 	LoadTasks() error
 	SaveTasks() error
 }
+
+// Compactor определяет интерфейс постоянного хранилища, которое можно переписать целиком, освободив место
+type Compactor interface {
+	// Compact атомарно переписывает хранилище, удаляя задачи, для которых drop возвращает true.
+	// Отмена контекста прерывает сжатие, оставляя прежнее состояние на диске без изменений.
+	Compact(ctx context.Context, drop func(*entities.Task) bool) (entities.CompactionReport, error)
+}
+
+// PresetRepository определяет интерфейс хранилища пресетов параметров задач
+type PresetRepository interface {
+	// Save создает пресет или заменяет пресет с тем же именем
+	Save(ctx context.Context, preset *entities.Preset) error
+	Get(ctx context.Context, name string) (*entities.Preset, error)
+	// List возвращает пресеты, упорядоченные по имени
+	List(ctx context.Context) ([]*entities.Preset, error)
+	Delete(ctx context.Context, name string) error
+	LoadPresets() error
+}
+
+// DeadLetterRepository определяет интерфейс хранилища окончательно неудачных задач
+type DeadLetterRepository interface {
+	// Save добавляет задачу или заменяет запись с тем же ID задачи
+	Save(ctx context.Context, letter *entities.DeadLetter) error
+	Get(ctx context.Context, id string) (*entities.DeadLetter, error)
+	// List возвращает записи в порядке переноса задач
+	List(ctx context.Context) ([]*entities.DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+	LoadDeadLetters() error
+}
+
+// MaintenanceRepository определяет интерфейс хранилища состояния режима обслуживания
+type MaintenanceRepository interface {
+	// LoadMaintenance возвращает сохраненное состояние; при его отсутствии режим выключен
+	LoadMaintenance() (entities.Maintenance, error)
+	SaveMaintenance(state entities.Maintenance) error
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"file-downloader/internal/entities"
 )
@@ -9,9 +10,33 @@ import (
 // TaskUsecase определяет интерфейс для операций управления задачами
 type TaskUsecase interface {
 	CreateTask(ctx context.Context, urls []string) (*entities.Task, error)
+	CreateTaskFromSpec(ctx context.Context, spec entities.TaskSpec) (*entities.Task, error)
 	GetTask(ctx context.Context, id string) (*entities.Task, error)
 	GetAllTasks(ctx context.Context) ([]*entities.Task, error)
+	ListTasks(ctx context.Context, limit int, cursor string, filter entities.TaskFilter) (*entities.TaskPage, error)
 	GetTaskStatus(ctx context.Context, id string) (*entities.Task, error)
+	GetTaskStatuses(ctx context.Context, ids []string) (*entities.TaskStatuses, error)
+	UpdateTask(ctx context.Context, id string, update entities.TaskUpdate) (*entities.Task, error)
+	RetryFile(ctx context.Context, id string, fileIndex int) (*entities.Task, error)
+	RetryFailedFiles(ctx context.Context, id string) (*entities.Task, int, error)
+	VerifyTask(ctx context.Context, id string, requeue bool) (*entities.VerificationReport, error)
+	TaskTree(ctx context.Context, id string) (*entities.TaskTree, error)
+	DeleteTask(ctx context.Context, id string) (*entities.Task, error)
+	RestoreTask(ctx context.Context, id string) (*entities.Task, error)
+	PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int, error)
+	ExpireTasks(ctx context.Context, now time.Time) (int, error)
+	DeadLetterTasks(ctx context.Context, now time.Time) (int, error)
+	CompactStore(ctx context.Context) (entities.CompactionReport, error)
+	ListDeadLetters(ctx context.Context) ([]*entities.DeadLetter, error)
+	RedriveDeadLetter(ctx context.Context, id string) (*entities.Task, error)
+	CleanupOrphans(ctx context.Context, modifiedBefore time.Time) (*entities.OrphanReport, error)
+	ListFailures(ctx context.Context, since time.Time, limit int) ([]entities.FailedFile, error)
+	ListFiles(ctx context.Context, id string, filter entities.FileFilter, limit int, cursor string) (*entities.FilePage, error)
+	OpenFileContent(ctx context.Context, id string, fileIndex int, decompress bool) (*entities.FileContent, error)
+	SavePreset(ctx context.Context, preset entities.Preset) (*entities.Preset, error)
+	GetPreset(ctx context.Context, name string) (*entities.Preset, error)
+	ListPresets(ctx context.Context) ([]*entities.Preset, error)
+	DeletePreset(ctx context.Context, name string) error
 }
 
 // DownloadUsecase определяет интерфейс для операций скачивания файлов
@@ -19,4 +44,12 @@ type DownloadUsecase interface {
 	ProcessTask(ctx context.Context, task *entities.Task) error
 	DownloadFile(ctx context.Context, url string, taskID string, fileIndex int) error
 	GetPendingTasks(ctx context.Context) ([]*entities.Task, error)
+	ReleaseScheduledTasks(ctx context.Context) (int, error)
+	RequeueInterruptedTasks(ctx context.Context) (int, error)
+	MarkWaitingForCapacity(ctx context.Context, taskIDs []string) (int, error)
+	FailTask(ctx context.Context, taskID string, reason string) error
+	SupportedSchemes() []string
+	RetryStats() entities.RetryStats
+	ResourceUsage() entities.ResourceUsage
+	DeliverCallbacks(ctx context.Context) (int, error)
 }
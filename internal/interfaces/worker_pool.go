@@ -0,0 +1,9 @@
+package interfaces
+
+// WorkerPool определяет интерфейс управления пулом воркеров во время работы
+type WorkerPool interface {
+	Resize(count int) error
+	WorkerCount() int
+	BusyWorkers() int
+	QueueLength() int
+}
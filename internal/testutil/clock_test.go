@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockAfterFiresOnAdvance(t *testing.T) {
+	// Setup
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	ch := clock.After(time.Minute)
+
+	// Execute
+	clock.Advance(30 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("Expected timer to not fire before its deadline")
+	default:
+	}
+	clock.Advance(30 * time.Second)
+
+	// Assert
+	select {
+	case fired := <-ch:
+		if !fired.Equal(start.Add(time.Minute)) {
+			t.Errorf("Expected fire time %v, got %v", start.Add(time.Minute), fired)
+		}
+	default:
+		t.Fatal("Expected timer to fire once the deadline is reached")
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("Expected no pending waiters, got %d", clock.Waiters())
+	}
+	if !clock.Now().Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected now %v, got %v", start.Add(time.Minute), clock.Now())
+	}
+}
@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"io"
+	"sync"
+)
+
+// DefaultCopyBufferSize - размер буфера копирования по умолчанию.
+// Он больше 32 КБ, используемых io.Copy, что уменьшает число системных вызовов на быстрых каналах.
+const DefaultCopyBufferSize = 256 << 10
+
+// bufferPool переиспользует буферы копирования между скачиваниями, чтобы не выделять их заново
+type bufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+// newBufferPool создает пул буферов указанного размера
+func newBufferPool(size int) *bufferPool {
+	if size <= 0 {
+		size = DefaultCopyBufferSize
+	}
+
+	p := &bufferPool{size: size}
+	p.pool.New = func() interface{} {
+		buf := make([]byte, p.size)
+		return &buf
+	}
+	return p
+}
+
+// copy копирует данные из src в dst через буфер из пула
+func (p *bufferPool) copy(dst io.Writer, src io.Reader) (int64, error) {
+	buf := p.pool.Get().(*[]byte)
+	defer p.pool.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
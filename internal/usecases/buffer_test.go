@@ -0,0 +1,99 @@
+package usecases
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// BenchmarkDownloadFileCopyBuffer compares download throughput with the io.Copy buffer size and the default one
+func BenchmarkDownloadFileCopyBuffer(b *testing.B) {
+	payload := make([]byte, 64<<20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	for _, size := range []int{32 << 10, DefaultCopyBufferSize} {
+		b.Run(strconv.Itoa(size>>10)+"KiB", func(b *testing.B) {
+			mockRepo := NewMockTaskRepository()
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(b.TempDir()),
+				WithCopyBufferSize(size),
+				WithProgressPersistence(ProgressConfig{}))
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{server.URL + "/payload.bin"}, time.Now())
+			task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+			mockRepo.Create(ctx, task)
+
+			b.SetBytes(int64(len(payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0); err != nil {
+					b.Fatalf("Expected no error, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBufferPoolCopy(t *testing.T) {
+	// Setup
+	pool := newBufferPool(0)
+	src := make([]byte, 3*DefaultCopyBufferSize+17)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	// Execute
+	var dst bytesWriter
+	written, err := pool.copy(&dst, &onlyReader{data: src})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if written != int64(len(src)) {
+		t.Errorf("Expected %d bytes written, got %d", len(src), written)
+	}
+	if dst.maxWrite > DefaultCopyBufferSize {
+		t.Errorf("Expected writes of at most %d bytes, got %d", DefaultCopyBufferSize, dst.maxWrite)
+	}
+	if string(dst.data) != string(src) {
+		t.Error("Expected copied data to match source")
+	}
+}
+
+// onlyReader hides io.WriterTo so that the copy buffer is actually used
+type onlyReader struct {
+	data []byte
+}
+
+func (r *onlyReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// bytesWriter records written data and the largest single write
+type bytesWriter struct {
+	data     []byte
+	maxWrite int
+}
+
+func (w *bytesWriter) Write(p []byte) (int, error) {
+	w.maxWrite = max(w.maxWrite, len(p))
+	w.data = append(w.data, p...)
+	return len(p), nil
+}
@@ -0,0 +1,101 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// DefaultCallbackPolicy возвращает политику доставки уведомлений о завершении задач по умолчанию
+func DefaultCallbackPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 10,
+		Backoff:     10 * time.Second,
+		MaxBackoff:  10 * time.Minute,
+	}
+}
+
+// DeliverCallbacks отправляет уведомления о завершении задач, время очередной попытки которых наступило,
+// и возвращает число доставленных уведомлений. Уведомления обрабатываемых задач откладываются до их завершения.
+func (u *DownloadUsecase) DeliverCallbacks(ctx context.Context) (int, error) {
+	if u.callbacks == nil {
+		return 0, nil
+	}
+
+	tasks, err := u.taskRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	delivered := 0
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		if task.IsActive() || !task.CallbackDue(u.clock.Now()) {
+			continue
+		}
+		if u.deliverCallback(ctx, task) {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// deliverCallback выполняет одну попытку доставки уведомления и сохраняет её результат в задаче.
+// Возвращает true, если получатель принял уведомление.
+func (u *DownloadUsecase) deliverCallback(ctx context.Context, task *entities.Task) bool {
+	code, sendErr := u.callbacks.Send(ctx, task.CallbackURL, entities.NewManifest(task))
+	if ctx.Err() != nil {
+		// Попытка, прерванная остановкой сервиса, не учитывается и повторится после перезапуска
+		return false
+	}
+
+	unlock := u.lockTask(task.ID.String())
+	defer unlock()
+	unlockRecord := u.locker.Lock(task.ID.String())
+	defer unlockRecord()
+
+	current, err := u.taskRepo.GetByID(ctx, task.ID.String())
+	if err != nil {
+		log.Printf("Не удалось сохранить результат доставки уведомления задачи %s: %v", task.LogID(), err)
+		return false
+	}
+	// Пока уведомление отправлялось, задача могла завершиться повторно: результат относится к устаревшему уведомлению
+	if current.Callback == nil || !current.Callback.QueuedAt.Equal(task.Callback.QueuedAt) {
+		return false
+	}
+
+	now := u.clock.Now()
+	delivery := current.Callback
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.NextAttemptAt = nil
+	delivery.ResponseCode = code
+	switch {
+	case sendErr == nil:
+		delivery.Status = entities.CallbackStatusDelivered
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+		log.Printf("Уведомление о завершении задачи %s доставлено (попытка %d)", current.LogID(), delivery.Attempts)
+	case delivery.Attempts >= u.callbackPolicy.MaxAttempts:
+		delivery.Status = entities.CallbackStatusFailed
+		delivery.Error = sendErr.Error()
+		log.Printf("Уведомление о завершении задачи %s не доставлено после %d попыток: %v", current.LogID(), delivery.Attempts, sendErr)
+	default:
+		next := now.Add(u.callbackPolicy.Delay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.Error = sendErr.Error()
+		log.Printf("Попытка %d доставки уведомления о завершении задачи %s не удалась: %v; следующая в %s",
+			delivery.Attempts, current.LogID(), sendErr, next.Format(time.RFC3339))
+	}
+
+	if err := u.updateTask(current); err != nil {
+		log.Printf("Не удалось сохранить результат доставки уведомления задачи %s: %v", current.LogID(), err)
+	}
+	return sendErr == nil
+}
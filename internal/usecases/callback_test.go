@@ -0,0 +1,188 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+// recordingSender records delivered manifests and fails the first failures attempts
+type recordingSender struct {
+	mu        sync.Mutex
+	failures  int
+	urls      []string
+	manifests []entities.Manifest
+}
+
+func (s *recordingSender) Send(ctx context.Context, url string, manifest entities.Manifest) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.urls = append(s.urls, url)
+	s.manifests = append(s.manifests, manifest)
+	if len(s.manifests) <= s.failures {
+		return http.StatusServiceUnavailable, errors.New("webhook ответил 503 Service Unavailable")
+	}
+	return http.StatusOK, nil
+}
+
+func TestDeliverCallbacksSendsManifest(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	sender := &recordingSender{}
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusOK, "hello", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithCallbacks(sender, DefaultCallbackPolicy())).(*DownloadUsecase)
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	task.CallbackURL = "https://hooks.example.com/done"
+	mockRepo.Create(ctx, task)
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	delivered, err := usecase.DeliverCallbacks(ctx)
+
+	// Assert
+	if err != nil || delivered != 1 {
+		t.Fatalf("Expected 1 delivered callback, got %d (%v)", delivered, err)
+	}
+	if len(sender.urls) != 1 || sender.urls[0] != task.CallbackURL {
+		t.Fatalf("Expected one callback to %s, got %v", task.CallbackURL, sender.urls)
+	}
+	manifest := sender.manifests[0]
+	if manifest.TaskID != task.ID || manifest.Status != entities.TaskStatusCompleted || manifest.CompletedFiles != 1 {
+		t.Errorf("Expected completed manifest for task %s, got %+v", task.ID, manifest)
+	}
+	if file := manifest.Files[0]; file.Size != 5 || file.SHA256 == "" || file.Status != "completed" || file.Path == "" {
+		t.Errorf("Expected file with size, checksum, status and path, got %+v", file)
+	}
+
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	if stored.Callback == nil || stored.Callback.Status != entities.CallbackStatusDelivered || stored.Callback.Attempts != 1 {
+		t.Errorf("Expected delivered callback after one attempt, got %+v", stored.Callback)
+	}
+	if stored.Callback.ResponseCode != http.StatusOK || stored.Callback.DeliveredAt == nil {
+		t.Errorf("Expected response code 200 and delivery time, got %+v", stored.Callback)
+	}
+
+	if delivered, _ := usecase.DeliverCallbacks(ctx); delivered != 0 || len(sender.urls) != 1 {
+		t.Errorf("Expected delivered callback not to be sent again, got %d sends", len(sender.urls))
+	}
+}
+
+func TestDeliverCallbacksRetriesWithBackoff(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	clock := testutil.NewFakeClock(time.Now())
+	sender := &recordingSender{failures: 5}
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithClock(clock),
+		WithCallbacks(sender, RetryPolicy{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute})).(*DownloadUsecase)
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, clock.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "failed", Error: "connection refused"}
+	task.CallbackURL = "https://hooks.example.com/done"
+	task.MarkFinished(clock.Now())
+	task.UpdateStatus(entities.TaskStatusFailed, clock.Now())
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	usecase.DeliverCallbacks(ctx)
+	usecase.DeliverCallbacks(ctx)
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	afterFirst := *stored.Callback
+	clock.Advance(time.Second)
+	usecase.DeliverCallbacks(ctx)
+	clock.Advance(2 * time.Second)
+	usecase.DeliverCallbacks(ctx)
+	clock.Advance(time.Hour)
+	usecase.DeliverCallbacks(ctx)
+
+	// Assert
+	if afterFirst.Attempts != 1 || afterFirst.NextAttemptAt == nil || afterFirst.Status != entities.CallbackStatusPending {
+		t.Errorf("Expected one attempt with next attempt scheduled, got %+v", afterFirst)
+	}
+	if len(sender.manifests) != 3 {
+		t.Errorf("Expected 3 attempts limited by policy, got %d", len(sender.manifests))
+	}
+	stored, _ = mockRepo.GetByID(ctx, task.ID.String())
+	if stored.Callback.Status != entities.CallbackStatusFailed || stored.Callback.Attempts != 3 {
+		t.Errorf("Expected failed callback after 3 attempts, got %+v", stored.Callback)
+	}
+	if stored.Callback.ResponseCode != http.StatusServiceUnavailable || stored.Callback.Error == "" || stored.Callback.NextAttemptAt != nil {
+		t.Errorf("Expected last response code 503 with error and no next attempt, got %+v", stored.Callback)
+	}
+}
+
+func TestDeliverCallbacksWaitsForActiveTask(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	sender := &recordingSender{}
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithCallbacks(sender, DefaultCallbackPolicy())).(*DownloadUsecase)
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	task.CallbackURL = "https://hooks.example.com/done"
+	task.MarkFinished(time.Now())
+	task.UpdateStatus(entities.TaskStatusNew, time.Now())
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	delivered, err := usecase.DeliverCallbacks(ctx)
+
+	// Assert
+	if err != nil || delivered != 0 || len(sender.manifests) != 0 {
+		t.Errorf("Expected no callback for a task queued for processing, got %d sends (%v)", len(sender.manifests), err)
+	}
+}
+
+func TestCreateTaskValidatesCallbackURL(t *testing.T) {
+	testCases := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"", false},
+		{"https://hooks.example.com/done", false},
+		{"ftp://hooks.example.com/done", true},
+		{"/relative", true},
+	}
+
+	for _, tc := range testCases {
+		// Setup
+		mockRepo := NewMockTaskRepository()
+		usecase := NewTaskUsecase(mockRepo, mockRepo)
+		spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+		spec.CallbackURL = tc.url
+
+		// Execute
+		task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+		// Assert
+		if tc.wantErr {
+			if !errors.Is(err, entities.ErrInvalidRequest) {
+				t.Errorf("Expected ErrInvalidRequest for %q, got %v", tc.url, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", tc.url, err)
+		} else if task.CallbackURL != tc.url {
+			t.Errorf("Expected callback URL %q, got %q", tc.url, task.CallbackURL)
+		}
+	}
+}
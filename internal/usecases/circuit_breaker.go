@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// CircuitBreakerConfig задает параметры автомата размыкания для хостов
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // количество подряд идущих сбоев для размыкания (0 - отключено)
+	Window           time.Duration // окно, в котором учитываются подряд идущие сбои
+	Cooldown         time.Duration // время, на которое блокируются запросы к хосту
+}
+
+// breakerState представляет состояние автомата размыкания
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// hostBreaker хранит состояние автомата размыкания одного хоста
+type hostBreaker struct {
+	state        breakerState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	probing      bool
+	probeStarted time.Time
+}
+
+// circuitBreakers управляет автоматами размыкания по хостам
+type circuitBreakers struct {
+	mu      sync.Mutex
+	config  CircuitBreakerConfig
+	hosts   map[string]*hostBreaker
+	metrics interfaces.MetricsRecorder
+	clock   interfaces.Clock
+}
+
+// newCircuitBreakers создает набор автоматов размыкания
+func newCircuitBreakers(config CircuitBreakerConfig, metrics interfaces.MetricsRecorder, clock interfaces.Clock) *circuitBreakers {
+	return &circuitBreakers{
+		config:  config,
+		hosts:   make(map[string]*hostBreaker),
+		metrics: metrics,
+		clock:   clock,
+	}
+}
+
+// Allow проверяет, можно ли выполнить запрос к хосту
+func (c *circuitBreakers) Allow(host string) error {
+	if c.config.FailureThreshold <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breaker, ok := c.hosts[host]
+	if !ok {
+		return nil
+	}
+
+	now := c.clock.Now()
+	switch breaker.state {
+	case breakerOpen:
+		if now.Sub(breaker.openedAt) < c.config.Cooldown {
+			return fmt.Errorf("%w (%s)", entities.ErrCircuitOpen, host)
+		}
+		// Время блокировки истекло: пропускаем один пробный запрос
+		c.setState(host, breaker, breakerHalfOpen)
+		breaker.probing = true
+		breaker.probeStarted = now
+		return nil
+	case breakerHalfOpen:
+		// Пробный запрос уже выполняется; если он завис, разрешаем новый после истечения cooldown
+		if breaker.probing && now.Sub(breaker.probeStarted) < c.config.Cooldown {
+			return fmt.Errorf("%w (%s)", entities.ErrCircuitOpen, host)
+		}
+		breaker.probing = true
+		breaker.probeStarted = now
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success фиксирует успешный запрос к хосту
+func (c *circuitBreakers) Success(host string) {
+	if c.config.FailureThreshold <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breaker, ok := c.hosts[host]
+	if !ok {
+		return
+	}
+
+	if breaker.state != breakerClosed {
+		log.Printf("Автомат размыкания для хоста %s закрыт", host)
+	}
+	breaker.failures = 0
+	breaker.probing = false
+	c.setState(host, breaker, breakerClosed)
+}
+
+// Failure фиксирует неудачный запрос к хосту; ошибки записи файлов без хоста (встроенное содержимое) не учитываются
+func (c *circuitBreakers) Failure(host string) {
+	if c.config.FailureThreshold <= 0 || host == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	breaker, ok := c.hosts[host]
+	if !ok {
+		breaker = &hostBreaker{}
+		c.hosts[host] = breaker
+	}
+
+	now := c.clock.Now()
+	if breaker.state == breakerHalfOpen {
+		// Пробный запрос не удался: снова размыкаем
+		breaker.probing = false
+		c.open(host, breaker, now)
+		return
+	}
+
+	if breaker.failures == 0 || (c.config.Window > 0 && now.Sub(breaker.firstFailure) > c.config.Window) {
+		breaker.failures = 0
+		breaker.firstFailure = now
+	}
+	breaker.failures++
+
+	if breaker.state == breakerClosed && breaker.failures >= c.config.FailureThreshold {
+		c.open(host, breaker, now)
+	}
+}
+
+// open размыкает автомат для хоста (вызывающий должен держать блокировку)
+func (c *circuitBreakers) open(host string, breaker *hostBreaker, now time.Time) {
+	breaker.openedAt = now
+	c.setState(host, breaker, breakerOpen)
+	c.metrics.IncCounter("downloader_circuit_breaker_opened_total", map[string]string{"host": host}, 1)
+	log.Printf("Автомат размыкания для хоста %s открыт на %v после %d сбоев", host, c.config.Cooldown, breaker.failures)
+}
+
+// setState меняет состояние автомата и обновляет метрику (вызывающий должен держать блокировку)
+func (c *circuitBreakers) setState(host string, breaker *hostBreaker, state breakerState) {
+	breaker.state = state
+	c.metrics.SetGauge("downloader_circuit_breaker_state", map[string]string{"host": host}, float64(state))
+}
@@ -0,0 +1,103 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	breakers := newCircuitBreakers(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		Window:           time.Minute,
+		Cooldown:         time.Minute,
+	}, noopMetrics{}, clock)
+
+	// Execute
+	breakers.Failure("example.com")
+	if err := breakers.Allow("example.com"); err != nil {
+		t.Fatalf("Expected breaker to stay closed after one failure, got %v", err)
+	}
+	breakers.Failure("example.com")
+
+	// Assert
+	if err := breakers.Allow("example.com"); !errors.Is(err, entities.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	if err := breakers.Allow("other.com"); err != nil {
+		t.Errorf("Expected other hosts to be unaffected, got %v", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenRecovery(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	breakers := newCircuitBreakers(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		Window:           time.Minute,
+		Cooldown:         time.Minute,
+	}, noopMetrics{}, clock)
+	breakers.Failure("example.com")
+
+	// Let the cooldown elapse
+	clock.Advance(2 * time.Minute)
+
+	// Execute & Assert
+	if err := breakers.Allow("example.com"); err != nil {
+		t.Fatalf("Expected probe request to be allowed, got %v", err)
+	}
+
+	if err := breakers.Allow("example.com"); !errors.Is(err, entities.ErrCircuitOpen) {
+		t.Errorf("Expected only one probe in half-open state, got %v", err)
+	}
+
+	breakers.Success("example.com")
+
+	if err := breakers.Allow("example.com"); err != nil {
+		t.Errorf("Expected breaker to close after successful probe, got %v", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	breakers := newCircuitBreakers(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		Window:           time.Minute,
+		Cooldown:         time.Minute,
+	}, noopMetrics{}, clock)
+	breakers.Failure("example.com")
+	clock.Advance(2 * time.Minute)
+
+	// Execute
+	if err := breakers.Allow("example.com"); err != nil {
+		t.Fatalf("Expected probe request to be allowed, got %v", err)
+	}
+	breakers.Failure("example.com")
+
+	// Assert
+	if err := breakers.Allow("example.com"); !errors.Is(err, entities.ErrCircuitOpen) {
+		t.Errorf("Expected breaker to reopen after failed probe, got %v", err)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	// Setup
+	breakers := newCircuitBreakers(CircuitBreakerConfig{}, noopMetrics{}, systemClock{})
+
+	// Execute
+	for i := 0; i < 10; i++ {
+		breakers.Failure("example.com")
+	}
+
+	// Assert
+	if err := breakers.Allow("example.com"); err != nil {
+		t.Errorf("Expected disabled breaker to allow requests, got %v", err)
+	}
+}
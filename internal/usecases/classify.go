@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+
+	"file-downloader/internal/entities"
+)
+
+// classifyError определяет вид ошибки скачивания
+func classifyError(err error) entities.ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var statusErr *entities.HTTPStatusError
+	var netErr net.Error
+	var pathErr *fs.PathError
+
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return entities.ErrorKindPermission
+	case errors.Is(err, entities.ErrCircuitOpen):
+		return entities.ErrorKindCircuitOpen
+	case errors.Is(err, entities.ErrHostNotAllowed):
+		return entities.ErrorKindHostNotAllowed
+	case errors.Is(err, entities.ErrFileExists):
+		return entities.ErrorKindFileExists
+	case errors.Is(err, entities.ErrSlowDownload):
+		return entities.ErrorKindSlowDownload
+	case errors.Is(err, entities.ErrFileTooSmall):
+		return entities.ErrorKindTooSmall
+	case errors.Is(err, entities.ErrExtraction):
+		return entities.ErrorKindExtraction
+	case errors.Is(err, entities.ErrRedirectTimeout):
+		return entities.ErrorKindRedirectTimeout
+	case errors.Is(err, entities.ErrDiskQuota):
+		return entities.ErrorKindDiskQuota
+	case errors.Is(err, entities.ErrFailureThreshold):
+		return entities.ErrorKindFailureThreshold
+	case errors.Is(err, entities.ErrInternal):
+		return entities.ErrorKindInternal
+	case errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err):
+		return entities.ErrorKindTimeout
+	case errors.As(err, &statusErr):
+		return entities.ErrorKindHTTP
+	case errors.As(err, &pathErr):
+		return entities.ErrorKindFilesystem
+	case errors.As(err, &netErr):
+		return entities.ErrorKindNetwork
+	default:
+		return ""
+	}
+}
+
+// httpStatusOf возвращает код ответа источника из ошибки HTTPStatusError или 0
+func httpStatusOf(err error) int {
+	var statusErr *entities.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+// isRetryable возвращает false для ошибок, повтор которых бессмысленен:
+// отсутствие прав на запись, заблокированный автоматом размыкания хост, хост, запрещенный политикой,
+// существующий файл, перезапись которого запрещена, слишком маленький ответ источника и превышенная квота
+func isRetryable(err error) bool {
+	return isRetryableKind(classifyError(err))
+}
+
+// isRetryableKind возвращает false для видов ошибок, повтор которых бессмысленен (см. isRetryable)
+func isRetryableKind(kind entities.ErrorKind) bool {
+	switch kind {
+	case entities.ErrorKindPermission, entities.ErrorKindCircuitOpen, entities.ErrorKindHostNotAllowed, entities.ErrorKindFileExists,
+		entities.ErrorKindTooSmall, entities.ErrorKindDiskQuota, entities.ErrorKindInternal:
+		return false
+	default:
+		return true
+	}
+}
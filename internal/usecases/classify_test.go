@@ -0,0 +1,45 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"file-downloader/internal/entities"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected entities.ErrorKind
+	}{
+		{"permission", &fs.PathError{Op: "open", Path: "/downloads/file", Err: fs.ErrPermission}, entities.ErrorKindPermission},
+		{"wrapped permission", fmt.Errorf("не удалось создать файл: %w", fs.ErrPermission), entities.ErrorKindPermission},
+		{"filesystem", &fs.PathError{Op: "open", Path: "/downloads/file", Err: fs.ErrNotExist}, entities.ErrorKindFilesystem},
+		{"timeout", fmt.Errorf("не удалось скачать: %w", context.DeadlineExceeded), entities.ErrorKindTimeout},
+		{"http", &entities.HTTPStatusError{StatusCode: 503, Status: "503 Service Unavailable"}, entities.ErrorKindHTTP},
+		{"circuit open", fmt.Errorf("%w (example.com)", entities.ErrCircuitOpen), entities.ErrorKindCircuitOpen},
+		{"file exists", fmt.Errorf("%w: /downloads/file", entities.ErrFileExists), entities.ErrorKindFileExists},
+		{"unknown", fmt.Errorf("что-то пошло не так"), ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if kind := classifyError(tc.err); kind != tc.expected {
+				t.Errorf("Expected kind %q, got %q", tc.expected, kind)
+			}
+		})
+	}
+}
+
+func TestPermissionErrorsAreNotRetried(t *testing.T) {
+	if isRetryable(&fs.PathError{Op: "open", Path: "/downloads/file", Err: fs.ErrPermission}) {
+		t.Error("Expected permission errors to not be retryable")
+	}
+
+	if !isRetryable(&entities.HTTPStatusError{StatusCode: 503, Status: "503 Service Unavailable"}) {
+		t.Error("Expected HTTP errors to be retryable")
+	}
+}
@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// WithCompactionRetention удаляет при сжатии хранилища задачи с итоговым статусом (completed, partial, failed),
+// завершившиеся раньше чем за retention. 0 сохраняет все задачи: сжатие только переписывает хранилище.
+func WithCompactionRetention(retention time.Duration) TaskOption {
+	return func(u *TaskUsecase) {
+		if retention > 0 {
+			u.compactionRetention = retention
+		}
+	}
+}
+
+// CompactStore атомарно переписывает постоянное хранилище задач, освобождая место от накопившихся изменений,
+// и удаляет из него задачи с итоговым статусом старше срока хранения. Файлы удаленных задач остаются на диске.
+// Срок хранения отсчитывается от текущего времени часов use case. Отмена контекста прерывает сжатие
+// без изменения хранилища.
+func (u *TaskUsecase) CompactStore(ctx context.Context) (entities.CompactionReport, error) {
+	now := u.clock.Now()
+	compactor, ok := u.persistentRepo.(interfaces.Compactor)
+	if !ok {
+		return entities.CompactionReport{}, entities.ErrCompactionUnsupported
+	}
+
+	report, err := compactor.Compact(ctx, func(task *entities.Task) bool {
+		return u.compactionDrops(task, now)
+	})
+	if err != nil {
+		return report, fmt.Errorf("не удалось сжать хранилище: %w", err)
+	}
+
+	// Удаленные из хранилища задачи удаляются и из памяти
+	for _, id := range report.Dropped {
+		if err := u.forgetCompacted(ctx, id); err != nil {
+			return report, err
+		}
+	}
+	log.Printf("Хранилище сжато: освобождено %d байт, удалено задач: %d", report.BytesReclaimed, report.TasksDropped)
+	return report, nil
+}
+
+// forgetCompacted удаляет из памяти задачу, удаленную из хранилища при сжатии. Удаление выполняется
+// под блокировкой задачи, поэтому изменяющая задачу операция либо завершается до него, либо не находит задачу.
+func (u *TaskUsecase) forgetCompacted(ctx context.Context, id string) error {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	if err := u.taskRepo.Delete(ctx, id); err != nil && !errors.Is(err, entities.ErrTaskNotFound) {
+		return fmt.Errorf("не удалось удалить задачу: %w", err)
+	}
+	return nil
+}
+
+// compactionDrops возвращает true, если задача удаляется при сжатии: она не удалена в корзину, завершилась
+// раньше чем за срок хранения до now и не ждет доставки уведомления о завершении
+func (u *TaskUsecase) compactionDrops(task *entities.Task, now time.Time) bool {
+	if u.compactionRetention == 0 || task.IsDeleted() || task.FinishedAt == nil {
+		return false
+	}
+	switch task.Status {
+	case entities.TaskStatusCompleted, entities.TaskStatusPartial, entities.TaskStatusFailed:
+	default:
+		return false
+	}
+	if task.Callback != nil && task.Callback.Status == entities.CallbackStatusPending {
+		return false
+	}
+	return now.Sub(*task.FinishedAt) >= u.compactionRetention
+}
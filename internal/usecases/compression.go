@@ -0,0 +1,121 @@
+package usecases
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// CompressionGzip - сжатие скачанного файла на диске в формате gzip (к имени файла добавляется .gz)
+const CompressionGzip = "gzip"
+
+// compressedMediaTypes - типы содержимого, которые уже сжаты и повторно не сжимаются
+var compressedMediaTypes = map[string]bool{
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/zip":              true,
+	"application/x-bzip2":          true,
+	"application/x-xz":             true,
+	"application/zstd":             true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/vnd.rar":          true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+}
+
+// isCompressedContentType возвращает true для уже сжатого содержимого: архивов, изображений (кроме SVG и BMP),
+// аудио и видео
+func isCompressedContentType(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		return false
+	}
+	switch {
+	case compressedMediaTypes[mediaType]:
+		return true
+	case strings.HasPrefix(mediaType, "image/"):
+		return mediaType != "image/svg+xml" && mediaType != "image/bmp"
+	case strings.HasPrefix(mediaType, "audio/"), strings.HasPrefix(mediaType, "video/"):
+		return mediaType != "audio/wav" && mediaType != "audio/x-wav"
+	default:
+		return false
+	}
+}
+
+// compressFor возвращает true, если файлы задачи сжимаются на диске: по флагу задачи, а если он не задан - по конфигурации
+func (u *DownloadUsecase) compressFor(task *entities.Task) bool {
+	if task.Compress != nil {
+		return *task.Compress
+	}
+	return u.compress
+}
+
+// bufferedBody - тело ответа, начальные байты которого уже прочитаны в буфер
+type bufferedBody struct {
+	*bufio.Reader
+	io.Closer
+}
+
+// peekBody читает начальные байты тела ответа, не извлекая их из потока
+func peekBody(body io.ReadCloser) ([]byte, io.ReadCloser) {
+	reader := bufio.NewReaderSize(body, sniffLen)
+	head, _ := reader.Peek(sniffLen)
+	return head, bufferedBody{Reader: reader, Closer: body}
+}
+
+// gzipFile - файл, данные которого сжимаются при записи
+type gzipFile struct {
+	*gzip.Writer
+	file *os.File
+}
+
+// Close завершает сжатый поток и закрывает файл
+func (f gzipFile) Close() error {
+	if err := f.Writer.Close(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+// openContent открывает содержимое файла на диске, распаковывая сжатые файлы
+func openContent(path, compression string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch compression {
+	case "":
+		return f, nil
+	case CompressionGzip:
+		reader, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("не удалось распаковать файл %s: %w", path, err)
+		}
+		return gzipContent{Reader: reader, file: f}, nil
+	default:
+		f.Close()
+		return nil, fmt.Errorf("неизвестное сжатие файла %s: %q", path, compression)
+	}
+}
+
+// gzipContent - распаковываемое содержимое сжатого файла
+type gzipContent struct {
+	*gzip.Reader
+	file *os.File
+}
+
+// Close закрывает распаковку и файл
+func (c gzipContent) Close() error {
+	c.Reader.Close()
+	return c.file.Close()
+}
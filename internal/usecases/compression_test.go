@@ -0,0 +1,180 @@
+package usecases
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestIsCompressedContentType(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected bool
+	}{
+		{"application/gzip", true},
+		{"application/zip", true},
+		{"image/jpeg", true},
+		{"video/mp4", true},
+		{"image/svg+xml", false},
+		{"text/plain; charset=utf-8", false},
+		{"application/json", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if actual := isCompressedContentType(tc.value); actual != tc.expected {
+			t.Errorf("Expected %v for %q, got %v", tc.expected, tc.value, actual)
+		}
+	}
+}
+
+func TestProcessTaskCompressesFiles(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	text := strings.Repeat("compressible text ", 100)
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 1024)
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "image.png") {
+			return cannedResponse(req, http.StatusOK, png, http.Header{"Content-Type": []string{"image/png"}}), nil
+		}
+		return cannedResponse(req, http.StatusOK, text, http.Header{"Content-Type": []string{"text/plain"}}), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithCompression(true))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/notes.txt", "https://example.com/image.png"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	notes := task.Files[0]
+	if notes.Compression != CompressionGzip || !strings.HasSuffix(notes.Path, "notes.txt.gz") {
+		t.Fatalf("Expected gzip-compressed notes.txt.gz, got %q at %s", notes.Compression, notes.Path)
+	}
+	info, err := os.Stat(notes.Path)
+	if err != nil {
+		t.Fatalf("Expected compressed file to exist, got %v", err)
+	}
+	if notes.StoredSize != info.Size() || notes.StoredSize >= notes.Size {
+		t.Errorf("Expected stored size %d smaller than size %d, got %d", info.Size(), notes.Size, notes.StoredSize)
+	}
+	if notes.Size != int64(len(text)) {
+		t.Errorf("Expected uncompressed size %d, got %d", len(text), notes.Size)
+	}
+
+	f, _ := os.Open(notes.Path)
+	defer f.Close()
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected gzip file, got %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	if string(data) != text {
+		t.Errorf("Expected decompressed content to match original, got %d bytes", len(data))
+	}
+	if result := verifyFile(0, notes); result.Status != entities.VerificationOK {
+		t.Errorf("Expected compressed file to verify, got %s (%s)", result.Status, result.Error)
+	}
+
+	image := task.Files[1]
+	if image.Compression != "" || !strings.HasSuffix(image.Path, "image.png") {
+		t.Errorf("Expected already compressed image to be stored as is, got %q at %s", image.Compression, image.Path)
+	}
+}
+
+func TestProcessTaskCompressionOverride(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusOK, "plain text", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithCompression(true))
+	ctx := context.Background()
+
+	disabled := false
+	task := entities.NewTask([]string{"https://example.com/file.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	task.Compress = &disabled
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	file := task.Files[0]
+	if file.Compression != "" || strings.HasSuffix(file.Path, ".gz") {
+		t.Errorf("Expected uncompressed file for task with compress=false, got %q at %s", file.Compression, file.Path)
+	}
+}
+
+func TestOpenFileContent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusOK, "hello world", nil), nil
+	})
+	downloads := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithCompression(true))
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file.txt", "https://example.com/later.txt"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(ctx, task)
+	if err := downloads.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	plain, err := usecase.OpenFileContent(ctx, task.ID.String(), 0, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer plain.Content.Close()
+	raw, err := usecase.OpenFileContent(ctx, task.ID.String(), 0, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer raw.Content.Close()
+	_, notReady := usecase.OpenFileContent(ctx, task.ID.String(), 1, true)
+
+	// Assert
+	data, _ := io.ReadAll(plain.Content)
+	if string(data) != "hello world" || plain.Encoding != "" || plain.Length != int64(len(data)) {
+		t.Errorf("Expected decompressed content of length %d, got %q (encoding %q, length %d)", len(data), string(data), plain.Encoding, plain.Length)
+	}
+	compressed, _ := io.ReadAll(raw.Content)
+	if raw.Encoding != CompressionGzip || raw.Length != int64(len(compressed)) {
+		t.Errorf("Expected gzip content of length %d, got encoding %q and length %d", len(compressed), raw.Encoding, raw.Length)
+	}
+	if !errors.Is(notReady, entities.ErrFileNotReady) {
+		t.Errorf("Expected ErrFileNotReady, got %v", notReady)
+	}
+}
@@ -0,0 +1,184 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"file-downloader/internal/entities"
+)
+
+// fileBatch согласует скачивание файлов одной задачи. Каждый файл скачивается в собственной копии задачи,
+// а его состояние переносится в общую задачу и сохраняется под блокировкой, поэтому одновременно
+// скачиваемые файлы не изменяют общую задачу параллельно.
+type fileBatch struct {
+	mu    sync.Mutex
+	task  *entities.Task
+	save  func(*entities.Task) error
+	paths map[string]int // пути, выбранные скачиваемыми файлами -> индекс файла
+
+	interrupted []int // файлы, скачивание которых прервано остановкой сервиса
+
+	threshold FailureThreshold   // порог неудачных файлов задачи
+	failures  int                // число неудачных файлов задачи
+	abort     context.CancelFunc // отменяет скачивание остальных файлов при превышении порога
+	abortErr  error              // причина отмены по порогу ошибок или дисковой квоте
+	quota     *diskQuota         // дисковая квота задачи (nil - без ограничения)
+
+	permErr error // ошибка прав на запись: остальные файлы не скачиваются
+	saveErr error // первая ошибка сохранения задачи после скачивания файла
+}
+
+// newFileBatch создает согласование скачивания файлов задачи с функцией её сохранения
+func newFileBatch(task *entities.Task, save func(*entities.Task) error) *fileBatch {
+	return &fileBatch{task: task, save: save, paths: make(map[string]int)}
+}
+
+// snapshot возвращает копию задачи для скачивания одного файла
+func (b *fileBatch) snapshot() *entities.Task {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.task.Clone()
+}
+
+// saveFile переносит состояние файла из копии в общую задачу и сохраняет её
+func (b *fileBatch) saveFile(local *entities.Task, fileIndex int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.task.Files[fileIndex] = local.Files[fileIndex]
+	return b.save(b.task)
+}
+
+// reservePath закрепляет путь за файлом. Возвращает false, если путь уже занят другим файлом задачи,
+// в том числе скачиваемым одновременно и еще не сохранившим свой путь.
+func (b *fileBatch) reservePath(fileIndex int, path string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pathTakenLocked(fileIndex, path) {
+		return false
+	}
+	b.paths[path] = fileIndex
+	return true
+}
+
+// pathTaken проверяет, занят ли путь другим файлом задачи, не закрепляя его
+func (b *fileBatch) pathTaken(fileIndex int, path string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pathTakenLocked(fileIndex, path)
+}
+
+// pathTakenLocked проверяет занятость пути (вызывающий должен держать блокировку)
+func (b *fileBatch) pathTakenLocked(fileIndex int, path string) bool {
+	for i, other := range b.task.Files {
+		if i != fileIndex && other.Path == path {
+			return true
+		}
+	}
+	owner, ok := b.paths[path]
+	return ok && owner != fileIndex
+}
+
+// watchFailures включает отмену скачиваний через abort, когда число неудачных файлов задачи
+// (включая завершившиеся ошибкой при прошлых обработках) превысит порог
+func (b *fileBatch) watchFailures(threshold FailureThreshold, abort context.CancelFunc) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = threshold
+	b.abort = abort
+	for _, file := range b.task.Files {
+		if file.Status == "failed" {
+			b.failures++
+		}
+	}
+}
+
+// recordFailure учитывает неудачный файл и при превышении порога ошибок отменяет остальные скачивания
+func (b *fileBatch) recordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.abortErr != nil || !b.threshold.Exceeded(b.failures, len(b.task.Files)) {
+		return
+	}
+	b.abortErr = fmt.Errorf("%w: %d из %d (порог %s)", entities.ErrFailureThreshold, b.failures, len(b.task.Files), b.threshold)
+	if b.abort != nil {
+		b.abort()
+	}
+}
+
+// interrupt запоминает файл, скачивание которого прервано остановкой сервиса или отменой задачи, и переносит
+// в общую задачу точку продолжения его скачивания из копии вместе с заявленным источником размером, по которому
+// она проверяется, и путем файла, по которому удаляются его данные при отмене
+func (b *fileBatch) interrupt(local *entities.Task, fileIndex int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.interrupted = append(b.interrupted, fileIndex)
+	file, shared := local.Files[fileIndex], &b.task.Files[fileIndex]
+	shared.PartialPath, shared.ResumeOffset, shared.Size = file.PartialPath, file.ResumeOffset, file.Size
+	shared.Path = file.Path
+}
+
+// failPermission запоминает ошибку прав на запись, после которой остальные файлы не скачиваются
+func (b *fileBatch) failPermission(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.permErr == nil {
+		b.permErr = err
+	}
+}
+
+// failSave запоминает ошибку сохранения задачи, после которой остальные файлы не скачиваются
+func (b *fileBatch) failSave(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.saveErr == nil {
+		b.saveErr = err
+	}
+}
+
+// stopped возвращает true, если скачивание остальных файлов нужно прекратить
+func (b *fileBatch) stopped() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.permErr != nil || b.saveErr != nil || b.abortErr != nil
+}
+
+// concurrencyFor возвращает число одновременно скачиваемых файлов задачи
+func (u *DownloadUsecase) concurrencyFor(task *entities.Task) int {
+	if task.MaxConcurrency > 0 {
+		return clampConcurrency(task.MaxConcurrency)
+	}
+	return u.concurrency
+}
+
+// hasUnfinishedFiles возвращает true, если у задачи есть файлы без итогового статуса
+func hasUnfinishedFiles(task *entities.Task) bool {
+	for _, file := range task.Files {
+		if file.Status != "completed" && file.Status != "failed" {
+			return true
+		}
+	}
+	return false
+}
+
+// priorityGroups группирует индексы файлов по убыванию приоритета; внутри группы сохраняется порядок файлов в задаче
+func priorityGroups(files []entities.File) [][]int {
+	order := make([]int, len(files))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return files[order[a]].Priority > files[order[b]].Priority
+	})
+
+	var groups [][]int
+	for n, i := range order {
+		if n == 0 || files[i].Priority != files[order[n-1]].Priority {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], i)
+	}
+	return groups
+}
@@ -0,0 +1,205 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// concurrencyTracker records the highest number of requests in flight at once
+type concurrencyTracker struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (c *concurrencyTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return cannedResponse(req, http.StatusOK, "data", nil), nil
+}
+
+func TestProcessTaskFileConcurrency(t *testing.T) {
+	testCases := []struct {
+		name           string
+		global         int
+		maxConcurrency int
+		expectedPeak   int
+	}{
+		{"global default is serial", 1, 0, 1},
+		{"global setting", 3, 0, 3},
+		{"task override lowers global", 4, 1, 1},
+		{"task override raises global", 1, 2, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			tracker := &concurrencyTracker{}
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(t.TempDir()),
+				WithRoundTripper(tracker),
+				WithFileConcurrency(tc.global))
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{
+				"https://example.com/a.bin", "https://example.com/b.bin", "https://example.com/c.bin",
+				"https://example.com/d.bin", "https://example.com/e.bin", "https://example.com/f.bin",
+			}, time.Now())
+			for i, url := range task.URLs {
+				task.Files[i] = entities.File{URL: url, Status: "pending"}
+			}
+			task.MaxConcurrency = tc.maxConcurrency
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			if task.Status != entities.TaskStatusCompleted {
+				t.Errorf("Expected task status %s, got %s", entities.TaskStatusCompleted, task.Status)
+			}
+			if tracker.peak != tc.expectedPeak {
+				t.Errorf("Expected %d concurrent downloads, got %d", tc.expectedPeak, tracker.peak)
+			}
+		})
+	}
+}
+
+func TestProcessTaskConcurrentFilesWithSameName(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(&concurrencyTracker{}),
+		WithFileConcurrency(3))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{
+		"https://a.example.com/file.bin", "https://b.example.com/file.bin", "https://c.example.com/file.bin",
+	}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	paths := make(map[string]bool)
+	for _, file := range task.Files {
+		if file.Status != "completed" {
+			t.Errorf("Expected file %s to be completed, got %s (%s)", file.URL, file.Status, file.Error)
+		}
+		paths[file.Path] = true
+	}
+	if len(paths) != len(task.Files) {
+		t.Errorf("Expected %d distinct paths, got %v", len(task.Files), paths)
+	}
+}
+
+func TestClampConcurrency(t *testing.T) {
+	testCases := []struct {
+		value    int
+		expected int
+	}{
+		{0, 1},
+		{1, 1},
+		{5, 5},
+		{MaxFileConcurrency + 1, MaxFileConcurrency},
+	}
+
+	for _, tc := range testCases {
+		if actual := clampConcurrency(tc.value); actual != tc.expected {
+			t.Errorf("Expected %d for %d, got %d", tc.expected, tc.value, actual)
+		}
+	}
+}
+
+func TestPriorityGroups(t *testing.T) {
+	// Setup
+	files := []entities.File{{Priority: 0}, {Priority: 10}, {Priority: 0}, {Priority: 5}, {Priority: 10}}
+
+	// Execute
+	groups := priorityGroups(files)
+
+	// Assert
+	expected := [][]int{{1, 4}, {3}, {0, 2}}
+	if fmt.Sprint(groups) != fmt.Sprint(expected) {
+		t.Errorf("Expected groups %v, got %v", expected, groups)
+	}
+}
+
+func TestProcessTaskDownloadsHigherPriorityFilesFirst(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	var mu sync.Mutex
+	var events []string
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		name := path.Base(req.URL.Path)
+		mu.Lock()
+		events = append(events, "start "+name)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		events = append(events, "end "+name)
+		mu.Unlock()
+		return cannedResponse(req, http.StatusOK, "data", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithFileConcurrency(4))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{
+		"https://example.com/asset1", "https://example.com/index", "https://example.com/asset2", "https://example.com/styles",
+	}, time.Now())
+	priorities := []int{0, 10, 0, 5}
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending", Priority: priorities[i]}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if len(events) != 8 {
+		t.Fatalf("Expected 8 events, got %v", events)
+	}
+	head := strings.Join(events[:4], ", ")
+	if head != "start index, end index, start styles, end styles" {
+		t.Errorf("Expected higher-priority files to finish before lower ones start, got %v", events)
+	}
+	for _, event := range events[4:6] {
+		if !strings.HasPrefix(event, "start asset") {
+			t.Errorf("Expected equal-priority assets to start together, got %v", events)
+		}
+	}
+}
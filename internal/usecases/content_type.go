@@ -0,0 +1,68 @@
+package usecases
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// sniffLen - количество начальных байт, по которым http.DetectContentType определяет тип содержимого
+const sniffLen = 512
+
+// contentSniffer запоминает начальные байты проходящего через него потока для определения типа содержимого,
+// поэтому файл не нужно перечитывать после скачивания
+type contentSniffer struct {
+	io.ReadCloser
+	head []byte
+}
+
+// newContentSniffer оборачивает поток данных запоминанием начальных байт
+func newContentSniffer(body io.ReadCloser) *contentSniffer {
+	return &contentSniffer{ReadCloser: body}
+}
+
+// Read читает данные и сохраняет первые sniffLen байт
+func (s *contentSniffer) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if missing := sniffLen - len(s.head); missing > 0 && n > 0 {
+		s.head = append(s.head, p[:min(n, missing)]...)
+	}
+	return n, err
+}
+
+// contentType возвращает тип содержимого из заголовка источника, а если он не указан
+// или неинформативен (application/octet-stream) - определенный по начальным байтам данных
+func contentType(header string, head []byte) string {
+	header = strings.TrimSpace(header)
+	if !isGenericContentType(header) {
+		return header
+	}
+	if len(head) == 0 {
+		return header
+	}
+	return http.DetectContentType(head)
+}
+
+// isGenericContentType возвращает true для пустого или неинформативного типа содержимого
+func isGenericContentType(value string) bool {
+	if value == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(value)
+	return err != nil || mediaType == "application/octet-stream"
+}
+
+// fileHead читает начальные байты файла для определения типа содержимого
+func fileHead(path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLen)
+	n, _ := io.ReadFull(f, head)
+	return head[:n]
+}
@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestContentType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		head     []byte
+		expected string
+	}{
+		{"header kept", "image/jpeg", []byte("plain text"), "image/jpeg"},
+		{"missing header detected", "", []byte("<html><body>hi</body></html>"), "text/html; charset=utf-8"},
+		{"generic header detected", "application/octet-stream", []byte("%PDF-1.7"), "application/pdf"},
+		{"generic header without data", "application/octet-stream", nil, "application/octet-stream"},
+		{"invalid header detected", "not a type", []byte("plain text"), "text/plain; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := contentType(tc.header, tc.head); actual != tc.expected {
+				t.Errorf("Expected content type %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDownloadFileDetectsContentType(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 1024)
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "labelled.csv") {
+			return cannedResponse(req, http.StatusOK, "a,b", http.Header{"Content-Type": []string{"text/csv"}}), nil
+		}
+		return cannedResponse(req, http.StatusOK, png, http.Header{"Content-Type": []string{"application/octet-stream"}}), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(t.TempDir()), WithRoundTripper(tripper))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/labelled.csv", "https://example.com/image"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if task.Files[0].ContentType != "text/csv" {
+		t.Errorf("Expected content type from header text/csv, got %q", task.Files[0].ContentType)
+	}
+	if task.Files[1].ContentType != "image/png" {
+		t.Errorf("Expected detected content type image/png, got %q", task.Files[1].ContentType)
+	}
+}
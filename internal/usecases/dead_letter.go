@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// WithDeadLetters включает перенос окончательно неудачных задач в отдельное хранилище repo через after
+// после завершения задачи ошибкой, чтобы клиент успел получить её итоговый статус
+func WithDeadLetters(repo interfaces.DeadLetterRepository, after time.Duration) TaskOption {
+	return func(u *TaskUsecase) {
+		u.deadLetters = repo
+		u.deadLetterAfter = after
+	}
+}
+
+// DeadLetterTasks переносит в хранилище неудачных задач задачи, завершившиеся ошибкой не позже чем
+// за deadLetterAfter до now. Задача с недоставленным уведомлением о завершении переносится после его
+// доставки или исчерпания попыток. Скачанные файлы задачи остаются на диске, и очистка осиротевших
+// директорий их не затрагивает. Возвращает количество перенесенных задач.
+func (u *TaskUsecase) DeadLetterTasks(ctx context.Context, now time.Time) (int, error) {
+	if u.deadLetters == nil {
+		return 0, nil
+	}
+
+	tasks, err := u.taskRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	moved := 0
+	for _, task := range tasks {
+		if !u.deadLetterDue(task, now) {
+			continue
+		}
+		ok, err := u.deadLetterTask(ctx, task.ID.String(), now)
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// deadLetterDue возвращает true, если задача окончательно завершилась ошибкой не позже чем за deadLetterAfter до now
+// и уведомление о её завершении не ждет доставки
+func (u *TaskUsecase) deadLetterDue(task *entities.Task, now time.Time) bool {
+	if task.Status != entities.TaskStatusFailed || task.IsDeleted() || task.FinishedAt == nil ||
+		now.Sub(*task.FinishedAt) < u.deadLetterAfter {
+		return false
+	}
+	return task.Callback == nil || task.Callback.Status != entities.CallbackStatusPending
+}
+
+// deadLetterTask переносит задачу в хранилище неудачных задач. Задача перечитывается под блокировкой задачи,
+// поэтому задача, повторенная через API после получения списка, не переносится. Возвращает true, если задача перенесена.
+func (u *TaskUsecase) deadLetterTask(ctx context.Context, id string, now time.Time) (bool, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil || !u.deadLetterDue(task, now) {
+		return false, nil
+	}
+	if err := u.deadLetters.Save(ctx, entities.NewDeadLetter(task, now)); err != nil {
+		return false, fmt.Errorf("не удалось сохранить неудачную задачу: %w", err)
+	}
+	if err := u.deleteStored(ctx, id); err != nil {
+		return false, err
+	}
+	log.Printf("Задача %s перенесена в хранилище неудачных задач: %s", task.LogID(), task.Error)
+	return true, nil
+}
+
+// ListDeadLetters возвращает окончательно неудачные задачи в порядке их переноса
+func (u *TaskUsecase) ListDeadLetters(ctx context.Context) ([]*entities.DeadLetter, error) {
+	if u.deadLetters == nil {
+		return []*entities.DeadLetter{}, nil
+	}
+
+	letters, err := u.deadLetters.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить неудачные задачи: %w", err)
+	}
+	return letters, nil
+}
+
+// RedriveDeadLetter возвращает неудачную задачу в активное хранилище и в очередь: файлы с ошибкой
+// сбрасываются в pending, а скачанные файлы повторно не скачиваются. Истекший срок хранения задачи
+// отсчитывается заново от момента возврата.
+func (u *TaskUsecase) RedriveDeadLetter(ctx context.Context, id string) (*entities.Task, error) {
+	if u.deadLetters == nil {
+		return nil, fmt.Errorf("%w: %s", entities.ErrDeadLetterNotFound, id)
+	}
+
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	letter, err := u.deadLetters.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить неудачную задачу: %w", err)
+	}
+	if existing, err := u.taskRepo.GetByID(ctx, id); err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskExists, id)
+	}
+
+	task := letter.Task
+	for i := range task.Files {
+		if task.Files[i].Status == "failed" {
+			task.Files[i] = task.Files[i].Pending()
+		}
+	}
+	task.Error = ""
+	task.ErrorKind = ""
+	task.Checksum = ""
+	now := u.clock.Now()
+	if task.IsExpired(now) {
+		task.ExpiresAt = nil
+		if u.defaultTTL > 0 {
+			expiresAt := now.Add(u.defaultTTL)
+			task.ExpiresAt = &expiresAt
+		}
+	}
+	task.UpdateStatus(requeueStatus(task, now), now)
+
+	if err := u.taskRepo.Create(ctx, task); err != nil {
+		return nil, fmt.Errorf("не удалось вернуть задачу: %w", err)
+	}
+	if err := u.persistentRepo.Create(ctx, task); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить задачу: %w", err)
+	}
+	if err := u.deadLetters.Delete(ctx, id); err != nil && !errors.Is(err, entities.ErrDeadLetterNotFound) {
+		return nil, fmt.Errorf("не удалось удалить задачу из хранилища неудачных задач: %w", err)
+	}
+
+	log.Printf("Задача %s возвращена в обработку из хранилища неудачных задач", task.LogID())
+	return task, nil
+}
@@ -0,0 +1,142 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+// failTask stores a finished task with one completed and one failed file
+func failTask(t *testing.T, repo *MockTaskRepository, finishedAt time.Time) *entities.Task {
+	t.Helper()
+	task := entities.NewTask([]string{"https://example.com/a.txt", "https://example.com/b.txt"}, finishedAt)
+	task.Files[0] = entities.File{URL: "https://example.com/a.txt", Status: "completed", Path: "/tmp/a.txt"}
+	task.Files[1] = entities.File{URL: "https://example.com/b.txt", Status: "failed", Error: "404", Attempts: 3}
+	task.Error = "не удалось скачать файлов: 1"
+	task.UpdateStatus(entities.TaskStatusFailed, finishedAt)
+	task.MarkFinished(finishedAt)
+	if err := repo.Create(context.Background(), task); err != nil {
+		t.Fatalf("Failed to store task: %v", err)
+	}
+	return task
+}
+
+func TestDeadLetterTasksMovesFailedTasksAfterGracePeriod(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	letters := repository.NewFileBasedDeadLetterRepository(filepath.Join(t.TempDir(), "dead_letters.json"))
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithDeadLetters(letters, time.Hour),
+		WithTaskClock(testutil.NewFakeClock(now))).(*TaskUsecase)
+	ctx := context.Background()
+	old := failTask(t, mockRepo, now.Add(-2*time.Hour))
+	recent := failTask(t, mockRepo, now.Add(-time.Minute))
+	notified := failTask(t, mockRepo, now.Add(-2*time.Hour))
+	notified.Callback = &entities.CallbackDelivery{Status: entities.CallbackStatusPending}
+
+	// Execute
+	moved, err := usecase.DeadLetterTasks(ctx, now)
+
+	// Assert
+	if err != nil || moved != 1 {
+		t.Fatalf("Expected 1 moved task, got %d (%v)", moved, err)
+	}
+	if _, err := mockRepo.GetByID(ctx, old.ID.String()); !errors.Is(err, entities.ErrTaskNotFound) {
+		t.Errorf("Expected moved task to leave the active store, got %v", err)
+	}
+	for _, task := range []*entities.Task{recent, notified} {
+		if _, err := mockRepo.GetByID(ctx, task.ID.String()); err != nil {
+			t.Errorf("Expected task %s to stay active, got %v", task.ID, err)
+		}
+	}
+	list, err := usecase.ListDeadLetters(ctx)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d (%v)", len(list), err)
+	}
+	if list[0].ID() != old.ID.String() || list[0].FailedFiles != 1 || list[0].Error != old.Error {
+		t.Errorf("Expected dead letter of task %s with 1 failed file, got %+v", old.ID, list[0])
+	}
+	if !list[0].DeadLetteredAt.Equal(now) {
+		t.Errorf("Expected dead letter time %v, got %v", now, list[0].DeadLetteredAt)
+	}
+}
+
+func TestRedriveDeadLetter(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	letters := repository.NewFileBasedDeadLetterRepository(filepath.Join(t.TempDir(), "dead_letters.json"))
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithDeadLetters(letters, 0),
+		WithTaskClock(testutil.NewFakeClock(now))).(*TaskUsecase)
+	ctx := context.Background()
+	task := failTask(t, mockRepo, now.Add(-time.Hour))
+	if _, err := usecase.DeadLetterTasks(ctx, now); err != nil {
+		t.Fatalf("Failed to move task: %v", err)
+	}
+
+	// Execute
+	redriven, err := usecase.RedriveDeadLetter(ctx, task.ID.String())
+	_, missingErr := usecase.RedriveDeadLetter(ctx, task.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if redriven.Status != entities.TaskStatusNew || redriven.Error != "" {
+		t.Errorf("Expected requeued task without error, got %s (%q)", redriven.Status, redriven.Error)
+	}
+	if redriven.Files[0].Status != "completed" {
+		t.Errorf("Expected completed file to be kept, got %s", redriven.Files[0].Status)
+	}
+	if redriven.Files[1].Status != "pending" || redriven.Files[1].Attempts != 0 {
+		t.Errorf("Expected failed file to be reset to pending, got %s after %d attempts", redriven.Files[1].Status, redriven.Files[1].Attempts)
+	}
+	if _, err := mockRepo.GetByID(ctx, task.ID.String()); err != nil {
+		t.Errorf("Expected task back in the active store, got %v", err)
+	}
+	if list, _ := usecase.ListDeadLetters(ctx); len(list) != 0 {
+		t.Errorf("Expected dead letter to be removed, got %d", len(list))
+	}
+	if !errors.Is(missingErr, entities.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound for a second redrive, got %v", missingErr)
+	}
+}
+
+func TestCleanupOrphansKeepsDeadLetterFiles(t *testing.T) {
+	// Setup
+	root := t.TempDir()
+	mockRepo := NewMockTaskRepository()
+	now := time.Now()
+	letters := repository.NewFileBasedDeadLetterRepository(filepath.Join(t.TempDir(), "dead_letters.json"))
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithDeadLetters(letters, 0), WithTrashDir(root),
+		WithOrphanAction(OrphanActionQuarantine)).(*TaskUsecase)
+	ctx := context.Background()
+	task := failTask(t, mockRepo, now.Add(-2*time.Hour))
+	path := filepath.Join(root, task.ID.String(), "a.txt")
+	makeOrphanDir(t, filepath.Dir(path), 5, now.Add(-2*time.Hour))
+	task.Files[0].Path = path
+	if _, err := usecase.DeadLetterTasks(ctx, now); err != nil {
+		t.Fatalf("Failed to move task: %v", err)
+	}
+
+	// Execute
+	report, err := usecase.CleanupOrphans(ctx, now.Add(-time.Hour))
+
+	// Assert
+	if err != nil || len(report.Directories) != 0 {
+		t.Errorf("Expected no orphans, got %+v (%v)", report, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "file.bin")); err != nil {
+		t.Errorf("Expected files of the dead-lettered task to stay in place, got %v", err)
+	}
+	if _, err := usecase.RedriveDeadLetter(ctx, task.ID.String()); err != nil {
+		t.Errorf("Expected dead letter to be redriven, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// ContentStoreDirName - имя директории хранилища содержимого внутри директории скачивания
+const ContentStoreDirName = ".content"
+
+// contentStore - хранилище содержимого, адресуемое контрольной суммой SHA-256.
+// Каждый уникальный файл хранится как жесткая ссылка {dir}/{sha256[:2]}/{sha256}; скачанный файл
+// с уже известным содержимым заменяется жесткой ссылкой на запись хранилища, и данные на диске не дублируются.
+type contentStore struct {
+	dir     string
+	metrics interfaces.MetricsRecorder
+	mu      sync.Mutex
+}
+
+// newContentStore создает хранилище содержимого в указанной директории
+func newContentStore(dir string, metrics interfaces.MetricsRecorder) *contentStore {
+	return &contentStore{dir: dir, metrics: metrics}
+}
+
+// pathFor возвращает путь записи хранилища для контрольной суммы
+func (s *contentStore) pathFor(checksum string) string {
+	return filepath.Join(s.dir, checksum[:2], checksum)
+}
+
+// deduplicate регистрирует скачанный файл в хранилище или заменяет его жесткой ссылкой на файл
+// с тем же содержимым и возвращает количество сэкономленных байт.
+// Совпадение контрольных сумм при разных размерах считается коллизией: файл остается как есть.
+func (s *contentStore) deduplicate(path, checksum string, size int64) (int64, error) {
+	if len(checksum) < 2 {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.pathFor(checksum)
+	storedInfo, err := os.Stat(stored)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(stored), 0755); err != nil {
+			return 0, fmt.Errorf("не удалось создать директорию хранилища содержимого: %w", err)
+		}
+		if err := os.Link(path, stored); err != nil {
+			return 0, fmt.Errorf("не удалось добавить файл в хранилище содержимого: %w", err)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("не удалось проверить хранилище содержимого: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось проверить скачанный файл: %w", err)
+	}
+	if os.SameFile(info, storedInfo) {
+		return 0, nil
+	}
+	if storedInfo.Size() != size || info.Size() != size {
+		log.Printf("Коллизия контрольной суммы %s: размер %d байт, в хранилище %d байт; файл %s не дедуплицирован",
+			checksum, size, storedInfo.Size(), path)
+		return 0, nil
+	}
+
+	// Ссылка создается рядом и атомарно заменяет скачанный файл, поэтому путь файла не пропадает ни на миг
+	linkPath := path + ".dedup"
+	os.Remove(linkPath)
+	if err := os.Link(stored, linkPath); err != nil {
+		return 0, fmt.Errorf("не удалось создать жесткую ссылку: %w", err)
+	}
+	if err := os.Rename(linkPath, path); err != nil {
+		os.Remove(linkPath)
+		return 0, fmt.Errorf("не удалось заменить файл жесткой ссылкой: %w", err)
+	}
+
+	s.metrics.IncCounter("downloader_dedup_files_total", nil, 1)
+	s.metrics.IncCounter("downloader_dedup_saved_bytes_total", nil, float64(size))
+	return size, nil
+}
+
+// collect удаляет записи хранилища, на которые не ссылается ни один файл задачи. Запись - жесткая ссылка
+// на данные файлов, поэтому единственная ссылка означает, что все файлы с этим содержимым удалены или перезаписаны.
+// Возвращает число удаленных записей и освобожденных байт.
+func (s *contentStore) collect() (int, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed, freed := 0, int64(0)
+	shards, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("не удалось прочитать хранилище содержимого: %w", err)
+	}
+	for _, shard := range shards {
+		if !shard.IsDir() {
+			continue
+		}
+		shardDir := filepath.Join(s.dir, shard.Name())
+		entries, err := os.ReadDir(shardDir)
+		if err != nil {
+			return removed, freed, fmt.Errorf("не удалось прочитать хранилище содержимого: %w", err)
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			links, ok := linkCount(info)
+			if !ok {
+				return removed, freed, nil
+			}
+			if links > 1 {
+				continue
+			}
+			if err := os.Remove(filepath.Join(shardDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+				return removed, freed, fmt.Errorf("не удалось удалить запись хранилища содержимого: %w", err)
+			}
+			removed++
+			freed += info.Size()
+		}
+		os.Remove(shardDir) // удаляется только опустевшая директория
+	}
+	return removed, freed, nil
+}
+
+// deduplicate заменяет скачанный файл ссылкой на файл с тем же содержимым, если дедупликация включена.
+// Ошибка дедупликации не делает скачивание неуспешным: файл остается на месте отдельной копией.
+// Сжатые на диске файлы не дедуплицируются: хранилище содержит исходные данные.
+func (u *DownloadUsecase) deduplicate(task *entities.Task, file *entities.File) {
+	// Файлы задач с собственной директорией скачивания не связываются с общим хранилищем,
+	// чтобы оставаться изолированными (к тому же она может находиться на другой файловой системе)
+	if u.contents == nil || file.Compression != "" || task.OutputDir != "" {
+		return
+	}
+
+	saved, err := u.contents.deduplicate(file.Path, file.SHA256, file.Size)
+	if err != nil {
+		log.Printf("Задача %s: не удалось дедуплицировать файл %s: %v", task.LogID(), file.Path, err)
+		return
+	}
+	if saved > 0 {
+		log.Printf("Задача %s: файл %s совпадает с ранее скачанным, сэкономлено %d байт", task.LogID(), file.Path, saved)
+	}
+}
+
+// collectContents удаляет записи хранилища содержимого, которые после удаления файлов задач больше не используются
+func (u *TaskUsecase) collectContents() {
+	if u.contents == nil {
+		return
+	}
+	removed, freed, err := u.contents.collect()
+	if err != nil {
+		log.Printf("Ошибка очистки хранилища содержимого: %v", err)
+	}
+	if removed > 0 {
+		log.Printf("Из хранилища содержимого удалено неиспользуемых записей: %d, %d байт", removed, freed)
+	}
+}
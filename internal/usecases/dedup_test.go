@@ -0,0 +1,217 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// recordingMetrics accumulates counter increments
+type recordingMetrics struct {
+	mu       sync.Mutex
+	counters map[string]float64
+}
+
+func (m *recordingMetrics) IncCounter(name string, labels map[string]string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += delta
+}
+
+func (m *recordingMetrics) SetGauge(name string, labels map[string]string, value float64) {}
+
+func TestProcessTaskDeduplicatesIdenticalContent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	metrics := &recordingMetrics{counters: make(map[string]float64)}
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "other.bin") {
+			return cannedResponse(req, http.StatusOK, "different", nil), nil
+		}
+		return cannedResponse(req, http.StatusOK, "same content", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(dir),
+		WithRoundTripper(tripper),
+		WithMetrics(metrics),
+		WithDeduplication(filepath.Join(dir, ContentStoreDirName)))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.bin", "https://example.com/b.bin", "https://example.com/other.bin"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	first, err := os.Stat(task.Files[0].Path)
+	if err != nil {
+		t.Fatalf("Expected first file to exist, got %v", err)
+	}
+	second, err := os.Stat(task.Files[1].Path)
+	if err != nil {
+		t.Fatalf("Expected second file to exist, got %v", err)
+	}
+	other, err := os.Stat(task.Files[2].Path)
+	if err != nil {
+		t.Fatalf("Expected third file to exist, got %v", err)
+	}
+	if !os.SameFile(first, second) {
+		t.Error("Expected identical files to share one inode")
+	}
+	if os.SameFile(first, other) {
+		t.Error("Expected different content to stay a separate file")
+	}
+
+	data, err := os.ReadFile(task.Files[1].Path)
+	if err != nil || string(data) != "same content" {
+		t.Errorf("Expected deduplicated file content, got %q (%v)", string(data), err)
+	}
+	if saved := metrics.counters["downloader_dedup_saved_bytes_total"]; saved != float64(len("same content")) {
+		t.Errorf("Expected %d saved bytes, got %v", len("same content"), saved)
+	}
+}
+
+func TestContentStoreSkipsSizeCollision(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	store := newContentStore(filepath.Join(dir, ContentStoreDirName), noopMetrics{})
+	first := filepath.Join(dir, "first.bin")
+	second := filepath.Join(dir, "second.bin")
+	os.WriteFile(first, []byte("short"), 0644)
+	os.WriteFile(second, []byte("much longer"), 0644)
+	checksum := "abcdef"
+
+	if _, err := store.deduplicate(first, checksum, 5); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	saved, err := store.deduplicate(second, checksum, 11)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if saved != 0 {
+		t.Errorf("Expected no bytes saved on collision, got %d", saved)
+	}
+	data, _ := os.ReadFile(second)
+	if string(data) != "much longer" {
+		t.Errorf("Expected colliding file to keep its content, got %q", string(data))
+	}
+}
+
+func TestDownloadDoesNotOverwriteLinkedContent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	body := "original"
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusOK, body, nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(dir),
+		WithRoundTripper(tripper),
+		WithDeduplication(filepath.Join(dir, ContentStoreDirName)))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.bin"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+	if err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	body = "changed"
+	if err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	current, _ := mockRepo.GetByID(ctx, task.ID.String())
+	data, _ := os.ReadFile(current.Files[0].Path)
+	if string(data) != "changed" {
+		t.Errorf("Expected downloaded file to have new content, got %q", string(data))
+	}
+
+	entries, err := filepath.Glob(filepath.Join(dir, ContentStoreDirName, "*", "*"))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("Expected 2 store entries, got %v (%v)", entries, err)
+	}
+	contents := make(map[string]bool)
+	for _, path := range entries {
+		data, _ := os.ReadFile(path)
+		contents[string(data)] = true
+	}
+	if !contents["original"] || !contents["changed"] {
+		t.Errorf("Expected store to keep both original and changed content, got %v", contents)
+	}
+}
+
+func TestPurgeDeletedTasksCollectsUnusedContent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	storeDir := filepath.Join(dir, ContentStoreDirName)
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "other.bin") {
+			return cannedResponse(req, http.StatusOK, "different", nil), nil
+		}
+		return cannedResponse(req, http.StatusOK, "same content", nil), nil
+	})
+	downloads := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(dir),
+		WithRoundTripper(tripper),
+		WithDeduplication(storeDir))
+	tasks := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(dir), WithContentStore(storeDir))
+	ctx := context.Background()
+
+	purgedTask := entities.NewTask([]string{"https://example.com/a.bin", "https://example.com/other.bin"}, time.Now())
+	keptTask := entities.NewTask([]string{"https://example.com/b.bin"}, time.Now())
+	for _, task := range []*entities.Task{purgedTask, keptTask} {
+		for i, url := range task.URLs {
+			task.Files[i] = entities.File{URL: url, Status: "pending"}
+		}
+		mockRepo.Create(ctx, task)
+		if err := downloads.ProcessTask(ctx, task); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := tasks.DeleteTask(ctx, purgedTask.ID.String()); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	// Execute
+	purged, err := tasks.PurgeDeletedTasks(ctx, time.Now().Add(time.Hour))
+
+	// Assert
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected 1 purged task, got %d (%v)", purged, err)
+	}
+	shared := filepath.Join(storeDir, keptTask.Files[0].SHA256[:2], keptTask.Files[0].SHA256)
+	if _, err := os.Stat(shared); err != nil {
+		t.Errorf("Expected content still used by a task to be kept, got %v", err)
+	}
+	unused := filepath.Join(storeDir, purgedTask.Files[1].SHA256[:2], purgedTask.Files[1].SHA256)
+	if _, err := os.Stat(unused); !os.IsNotExist(err) {
+		t.Errorf("Expected unused content to be removed from the store, got %v", err)
+	}
+	if data, err := os.ReadFile(keptTask.Files[0].Path); err != nil || string(data) != "same content" {
+		t.Errorf("Expected kept task file to be intact, got %q (%v)", string(data), err)
+	}
+}
@@ -1,14 +1,26 @@
 package usecases
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"file-downloader/internal/entities"
 	"file-downloader/internal/interfaces"
@@ -19,133 +31,1243 @@ type DownloadUsecase struct {
 	taskRepo       interfaces.TaskRepository
 	persistentRepo interfaces.PersistentRepository
 	downloadDir    string
+	fetchers       map[string]interfaces.Fetcher
+	transport      http.RoundTripper
+	httpConfig     HTTPFetcherConfig
+	retryPolicy    RetryPolicy
+	layout         Layout
+	names          interfaces.NameResolver
+	indexName      string
+	existing       ExistingFilePolicy
+	metrics        interfaces.MetricsRecorder
+	events         interfaces.EventPublisher
+	clock          interfaces.Clock
+	breakerConfig  CircuitBreakerConfig
+	breakers       *circuitBreakers
+	hostPolicy     HostPolicy
+	progress       ProgressConfig
+	segments       SegmentConfig
+	bufferSize     int
+	buffers        *bufferPool
+	contentDir     string
+	contents       *contentStore
+	concurrency    int                      // число одновременно скачиваемых файлов задачи по умолчанию
+	fair           *fairScheduler           // общие слоты скачивания файлов всех задач (nil - без общего ограничения)
+	fairSlots      int                      // общее число одновременно скачиваемых файлов (0 - без ограничения)
+	budget         ResourceBudget           // бюджет ресурсов одновременно скачиваемых файлов
+	active         activeFiles              // скачиваемые сейчас файлы всех задач
+	requestLimit   RequestRateLimit         // ограничение частоты HTTP(S)-запросов скачивания
+	failures       FailureThreshold         // порог неудачных файлов задачи по умолчанию
+	minFileSize    int64                    // минимальный размер успешно скачанного файла (0 - без проверки)
+	compress       bool                     // сжимать скачанные файлы на диске по умолчанию
+	retryAlerts    RetryAlertConfig         // окно и порог оповещения о всплеске повторов
+	alerts         interfaces.AlertNotifier // отправка оповещений (nil - только журнал)
+	retries        *retryBudget             // счетчик повторов за скользящее окно
+	processing     *taskLocker              // обработка задачи: одновременно задачу скачивает один обработчик
+	locker         interfaces.TaskLocker    // чтение и сохранение задачи, общие с use case задач
+
+	callbacks      interfaces.CallbackSender // отправка уведомлений о завершении задач (nil - не отправляются)
+	callbackPolicy RetryPolicy               // число попыток и задержки доставки уведомлений
+
+	slowDownload SlowDownloadPolicy // обнаружение медленных скачиваний по умолчанию
+	extraction   ExtractionLimits   // ограничения распаковки архивов задач с extract
+
+	diskQuota    int64                    // дисковая квота задачи по умолчанию (0 - без ограничения)
+	quotaCleanup bool                     // при превышении квоты удалять и уже скачанные файлы задачи
+	diskSpace    interfaces.DiskSpaceGate // приостановка скачивания при нехватке места на диске (nil - без проверки)
+}
+
+// DownloadOption настраивает use case скачивания
+type DownloadOption func(*DownloadUsecase)
+
+// WithFetcher регистрирует fetcher для указанной схемы URL
+func WithFetcher(scheme string, fetcher interfaces.Fetcher) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.fetchers[strings.ToLower(scheme)] = fetcher
+	}
+}
+
+// WithRoundTripper задает транспорт для HTTP(S)-запросов (например, для тестов или промежуточной обработки запросов)
+func WithRoundTripper(transport http.RoundTripper) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.transport = transport
+	}
+}
+
+// WithHTTPConfig задает User-Agent и заголовки по умолчанию для HTTP(S)-запросов
+func WithHTTPConfig(config HTTPFetcherConfig) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.httpConfig = config
+	}
+}
+
+// WithDownloadDir задает корневую директорию для скачивания
+func WithDownloadDir(dir string) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.downloadDir = dir
+	}
+}
+
+// WithLayout задает стратегию размещения скачанных файлов
+func WithLayout(layout Layout) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.layout = layout
+	}
+}
+
+// WithExistingFilePolicy задает поведение, если файл назначения уже существует
+func WithExistingFilePolicy(policy ExistingFilePolicy) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.existing = policy
+	}
+}
+
+// WithMetrics задает реестр метрик
+func WithMetrics(metrics interfaces.MetricsRecorder) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.metrics = metrics
+	}
+}
+
+// WithClock задает источник времени (по умолчанию - системное время)
+func WithClock(clock interfaces.Clock) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.clock = clock
+	}
+}
+
+// WithCircuitBreaker включает автомат размыкания для хостов-источников
+func WithCircuitBreaker(config CircuitBreakerConfig) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.breakerConfig = config
+	}
+}
+
+// WithHostPolicy ограничивает хосты, с которых разрешено скачивание
+func WithHostPolicy(policy HostPolicy) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.hostPolicy = policy
+	}
+}
+
+// WithProgressPersistence задает частоту сохранения прогресса скачивания (нулевой интервал отключает сохранение)
+func WithProgressPersistence(config ProgressConfig) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.progress = config
+	}
+}
+
+// WithSegmentedDownload включает параллельное скачивание крупных файлов сегментами
+func WithSegmentedDownload(config SegmentConfig) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.segments = config
+	}
+}
+
+// WithCopyBufferSize задает размер буфера копирования данных (0 - размер по умолчанию)
+func WithCopyBufferSize(size int) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.bufferSize = size
+	}
+}
+
+// WithDeduplication включает дедупликацию скачанных файлов по содержимому через хранилище в директории dir
+func WithDeduplication(dir string) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.contentDir = dir
+	}
+}
+
+// WithFileConcurrency задает число одновременно скачиваемых файлов задачи по умолчанию
+// (не больше MaxFileConcurrency); задача может переопределить его полем max_concurrency
+func WithFileConcurrency(n int) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.concurrency = clampConcurrency(n)
+	}
+}
+
+// WithFailureThreshold задает порог неудачных файлов, после которого остальные скачивания задачи отменяются;
+// задача может переопределить его полем failure_threshold
+func WithFailureThreshold(threshold FailureThreshold) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.failures = threshold
+	}
+}
+
+// WithMinFileSize задает минимальный размер скачанного файла в байтах: файл меньше него при успешном ответе
+// источника завершается ошибкой ErrFileTooSmall. 0 отключает проверку, 1 отклоняет только пустые файлы.
+func WithMinFileSize(size int64) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.minFileSize = size
+	}
+}
+
+// WithCompression включает сжатие скачанных файлов на диске в gzip по умолчанию;
+// задача может переопределить его полем compress
+func WithCompression(enabled bool) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.compress = enabled
+	}
+}
+
+// WithRetryAlerts задает окно подсчета повторных попыток и порог, выше которого в журнал пишется
+// предупреждение и отправляется оповещение через notifier (notifier может быть nil)
+func WithRetryAlerts(config RetryAlertConfig, notifier interfaces.AlertNotifier) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.retryAlerts = config
+		u.alerts = notifier
+	}
+}
+
+// WithCallbacks включает доставку уведомлений о завершении задач на их callback_url через sender;
+// policy задает число попыток и экспоненциальную задержку между ними
+func WithCallbacks(sender interfaces.CallbackSender, policy RetryPolicy) DownloadOption {
+	return func(u *DownloadUsecase) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		u.callbacks = sender
+		u.callbackPolicy = policy
+	}
+}
+
+// WithDownloadTaskLocker задает блокировки задач, общие с use case задач: задача переводится в обработку
+// под той же блокировкой, под которой API проверяет и изменяет её
+func WithDownloadTaskLocker(locker interfaces.TaskLocker) DownloadOption {
+	return func(u *DownloadUsecase) {
+		if locker != nil {
+			u.locker = locker
+		}
+	}
+}
+
+// WithDiskSpaceGate приостанавливает начало скачивания новых файлов всех задач, пока gate сообщает о нехватке
+// места на диске. Уже начатые скачивания продолжаются.
+func WithDiskSpaceGate(gate interfaces.DiskSpaceGate) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.diskSpace = gate
+	}
+}
+
+// WithRetryPolicy задает политику повторных попыток скачивания
+func WithRetryPolicy(policy RetryPolicy) DownloadOption {
+	return func(u *DownloadUsecase) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		u.retryPolicy = policy
+	}
 }
 
 // NewDownloadUsecase создает новый use case для скачивания
-func NewDownloadUsecase(taskRepo interfaces.TaskRepository, persistentRepo interfaces.PersistentRepository) interfaces.DownloadUsecase {
-	return &DownloadUsecase{
+func NewDownloadUsecase(taskRepo interfaces.TaskRepository, persistentRepo interfaces.PersistentRepository, opts ...DownloadOption) interfaces.DownloadUsecase {
+	u := &DownloadUsecase{
 		taskRepo:       taskRepo,
 		persistentRepo: persistentRepo,
 		downloadDir:    "./downloads",
+		fetchers:       make(map[string]interfaces.Fetcher),
+		transport:      http.DefaultTransport,
+		httpConfig:     HTTPFetcherConfig{Timeouts: DefaultHTTPTimeouts()},
+		retryPolicy:    DefaultRetryPolicy(),
+		progress:       DefaultProgressConfig(),
+		layout:         LayoutByTask,
+		names:          indexNames{},
+		indexName:      DefaultDirectoryIndexName,
+		existing:       ExistingFileOverwrite,
+		metrics:        noopMetrics{},
+		events:         noopEvents{},
+		clock:          systemClock{},
+		concurrency:    1,
+		retryAlerts:    DefaultRetryAlertConfig(),
+		callbackPolicy: DefaultCallbackPolicy(),
+		slowDownload:   DefaultSlowDownloadPolicy(),
+		extraction:     ExtractionLimits{MaxSize: DefaultExtractMaxSize, MaxFiles: DefaultExtractMaxFiles},
+		processing:     &taskLocker{locks: make(map[string]*taskLock)},
+		locker:         NewTaskLocker(),
+	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	// HTTP(S) обслуживается встроенным fetcher'ом, если не зарегистрирован другой
+	u.httpConfig.HostPolicy = u.hostPolicy
+	u.httpConfig.requests = newRequestLimiter(u.requestLimit, u.clock, u.metrics)
+	u.httpConfig.clock = u.clock
+	httpFetcher := NewHTTPFetcher(u.transport, u.httpConfig)
+	for _, scheme := range []string{"http", "https"} {
+		if _, ok := u.fetchers[scheme]; !ok {
+			u.fetchers[scheme] = httpFetcher
+		}
+	}
+
+	u.breakers = newCircuitBreakers(u.breakerConfig, u.metrics, u.clock)
+	u.retries = newRetryBudget(u.retryAlerts, u.alerts, u.metrics, u.clock)
+	u.buffers = newBufferPool(u.bufferSize)
+	u.initFileSlots()
+	if u.contentDir != "" {
+		u.contents = newContentStore(u.contentDir, u.metrics)
 	}
+
+	return u
 }
 
 // ProcessTask обрабатывает задачу, скачивая все её файлы
 func (u *DownloadUsecase) ProcessTask(ctx context.Context, task *entities.Task) error {
-	// Обновление статуса задачи на processing
-	task.UpdateStatus(entities.TaskStatusProcessing)
-	if err := u.updateTask(task); err != nil {
-		return fmt.Errorf("не удалось обновить статус задачи: %w", err)
+	// Файлы одной задачи изменяются только одним обработчиком одновременно
+	unlock := u.lockTask(task.ID.String())
+	defer unlock()
+
+	task, err := u.startTask(ctx, task)
+	if err != nil || task == nil {
+		return err
 	}
 
-	// Создание директории для скачивания этой задачи
-	taskDir := filepath.Join(u.downloadDir, task.ID.String())
-	if err := os.MkdirAll(taskDir, 0755); err != nil {
-		task.SetError(fmt.Sprintf("не удалось создать директорию для скачивания: %v", err))
+	// Создание корневой директории для скачивания
+	if err := os.MkdirAll(u.rootDir(task), 0755); err != nil {
+		task.MarkFinished(u.clock.Now())
+		task.SetError(fmt.Sprintf("не удалось создать директорию для скачивания: %v", err), u.clock.Now())
+		task.ErrorKind = classifyError(err)
 		u.updateTask(task)
 		return fmt.Errorf("не удалось создать директорию для скачивания: %w", err)
 	}
 
-	// Скачивание каждого файла
-	for i := range task.Files {
-		if err := u.DownloadFile(ctx, task.Files[i].URL, task.ID.String(), i); err != nil {
-			task.Files[i].Status = "failed"
-			task.Files[i].Error = err.Error()
-		}
+	// Скачивание файлов: одновременно скачивается не больше concurrency файлов задачи
+	// При превышении порога ошибок filesCtx отменяется, прерывая остальные скачивания задачи
+	filesCtx, abort := context.WithCancel(ctx)
+	defer abort()
+	batch := newFileBatch(task, u.updateTask)
+	batch.watchFailures(u.failureThresholdFor(task), abort)
+	batch.limitDiskUsage(u.diskQuotaFor(task))
 
-		// Обновление задачи после каждого файла
-		if err := u.updateTask(task); err != nil {
-			return fmt.Errorf("не удалось обновить задачу: %w", err)
+	// Файлы скачиваются группами по убыванию приоритета: файлы группы скачиваются одновременно,
+	// а следующая группа начинается только после завершения предыдущей
+	slots := make(chan struct{}, u.concurrencyFor(task))
+	var wg sync.WaitGroup
+download:
+	for _, group := range priorityGroups(task.Files) {
+		for _, i := range group {
+			// Файлы с итоговым статусом пропускаются, чтобы при повторе отдельного файла скачивался только он
+			if task.Files[i].Status == "completed" || task.Files[i].Status == "failed" {
+				continue
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-filesCtx.Done():
+			}
+			if filesCtx.Err() != nil || batch.stopped() {
+				break download
+			}
+			// Общий слот скачивания занимается после слота задачи: задача ждет его, не превышая своего ограничения
+			release, err := u.acquireFileSlot(filesCtx, task)
+			if err != nil {
+				break download
+			}
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				defer release()
+				u.processFile(filesCtx, batch, i)
+			}(i)
 		}
+		wg.Wait()
+	}
+	wg.Wait()
+
+	if batch.saveErr != nil {
+		return fmt.Errorf("не удалось обновить задачу: %w", batch.saveErr)
+	}
+
+	// Слишком много неудачных файлов или превышена дисковая квота: недоскачанные файлы отменяются,
+	// задача завершается ошибкой
+	if batch.abortErr != nil {
+		return u.abortTask(task, batch.interrupted, batch.abortErr)
+	}
+
+	// Остановка сервиса прерывает скачивание: задача возвращается в очередь и продолжится после перезапуска
+	if ctx.Err() != nil && batch.permErr == nil && (len(batch.interrupted) > 0 || hasUnfinishedFiles(task)) {
+		return u.interruptTask(task, batch.interrupted, ctx.Err())
+	}
+
+	// Без прав на запись остальные файлы тоже не скачаются: задача завершается сразу
+	if batch.permErr != nil {
+		task.MarkFinished(u.clock.Now())
+		task.SetError(fmt.Sprintf("нет прав на запись в директорию скачивания: %v", batch.permErr), u.clock.Now())
+		task.ErrorKind = entities.ErrorKindPermission
+		return u.finishTask(task)
 	}
 
 	// Проверка финального статуса
+	task.MarkFinished(u.clock.Now())
 	if task.IsCompleted() {
-		task.UpdateStatus(entities.TaskStatusCompleted)
+		task.UpdateStatus(entities.TaskStatusCompleted, u.clock.Now())
+	} else if task.IsPartial() {
+		task.UpdateStatus(entities.TaskStatusPartial, u.clock.Now())
 	} else if task.IsFailed() {
-		task.UpdateStatus(entities.TaskStatusFailed)
+		task.UpdateStatus(entities.TaskStatusFailed, u.clock.Now())
+	}
+
+	return u.finishTask(task)
+}
+
+// startTask переводит задачу в обработку по её актуальному состоянию из репозитория. Чтение, проверка и сохранение
+// выполняются под блокировкой задачи, общей с API, поэтому изменение задачи через API не теряется и не применяется
+// к уже обрабатываемой задаче. Возвращает nil, если обрабатывать задачу не нужно.
+func (u *DownloadUsecase) startTask(ctx context.Context, task *entities.Task) (*entities.Task, error) {
+	unlock := u.locker.Lock(task.ID.String())
+	defer unlock()
+
+	// Повторная постановка уже обработанной задачи (например, дубль из очереди, дождавшийся завершения
+	// первой обработки) ничего не делает
+	current, err := u.taskRepo.GetByID(ctx, task.ID.String())
+	if errors.Is(err, entities.ErrTaskNotFound) {
+		// Задача удалена из хранилища, пока ждала в очереди (например, истек срок её хранения)
+		log.Printf("Задача %s больше не существует, пропускаем", task.LogID())
+		return nil, nil
+	}
+	if err == nil && current != nil {
+		if !isPending(current) {
+			log.Printf("Задача %s уже обработана (статус %s), пропускаем", current.LogID(), current.Status)
+			return nil, nil
+		}
+		task = current
+	}
+
+	// Задача, не начатая до остановки сервиса, остается в статусе new и будет обработана после перезапуска
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Задача, окно скачивания которой закрылось, пока она ждала в очереди, откладывается до его открытия:
+	// процессор вернет её в очередь, как запланированную задачу
+	if now := u.clock.Now(); task.DownloadWindow != nil && !task.DownloadWindow.Contains(now) {
+		task.WaitingForCapacitySince = nil
+		task.UpdateStatus(entities.TaskStatusScheduled, now)
+		if err := u.updateTask(task); err != nil {
+			return nil, fmt.Errorf("не удалось отложить задачу: %w", err)
+		}
+		log.Printf("Задача %s вне окна скачивания, отложена до %s", task.LogID(), task.DownloadWindow.NextOpen(now).Format(time.RFC3339))
+		return nil, nil
+	}
+
+	// Обновление статуса задачи на processing
+	task.WaitingForCapacitySince = nil
+	task.MarkStarted(u.clock.Now())
+	task.UpdateStatus(entities.TaskStatusProcessing, u.clock.Now())
+	if err := u.updateTask(task); err != nil {
+		return nil, fmt.Errorf("не удалось обновить статус задачи: %w", err)
+	}
+	return task, nil
+}
+
+// processFile скачивает файл задачи в её копии и сохраняет результат в общей задаче
+func (u *DownloadUsecase) processFile(ctx context.Context, batch *fileBatch, fileIndex int) {
+	task := batch.snapshot()
+	defer u.recoverFile(batch, task, fileIndex)
+	if err := u.downloadWithRetry(ctx, batch, task, fileIndex); err != nil {
+		if ctx.Err() != nil {
+			batch.interrupt(task, fileIndex)
+			return
+		}
+
+		file := &task.Files[fileIndex]
+		file.Status = "failed"
+		file.Error = err.Error()
+		file.ErrorKind = classifyError(err)
+		file.HTTPStatus = httpStatusOf(err)
+		if file.ErrorKind == entities.ErrorKindPermission {
+			batch.failPermission(err)
+		}
+		batch.recordFailure()
+	}
+
+	// Обновление задачи после каждого файла
+	if err := batch.saveFile(task, fileIndex); err != nil {
+		batch.failSave(err)
+	}
+	if task.Files[fileIndex].Status == "failed" {
+		u.publishFile(entities.EventFileFailed, task, fileIndex)
+	} else {
+		u.publishFile(entities.EventFileCompleted, task, fileIndex)
+	}
+}
+
+// recoverFile завершает ошибкой внутреннего вида файл, скачивание которого прервано паникой,
+// чтобы паника в горутине файла не остановила весь сервис
+func (u *DownloadUsecase) recoverFile(batch *fileBatch, task *entities.Task, fileIndex int) {
+	p := recover()
+	if p == nil {
+		return
 	}
+	log.Printf("Паника при скачивании файла %d задачи %s: %v\n%s", fileIndex, task.LogID(), p, debug.Stack())
 
-	return u.updateTask(task)
+	file := &task.Files[fileIndex]
+	file.Status = "failed"
+	file.Error = fmt.Sprintf("внутренняя ошибка при скачивании файла: %v", p)
+	file.ErrorKind = entities.ErrorKindInternal
+	batch.recordFailure()
+	if err := batch.saveFile(task, fileIndex); err != nil {
+		batch.failSave(err)
+	}
+	u.publishFile(entities.EventFileFailed, task, fileIndex)
+}
+
+// abortTask завершает задачу ошибкой после превышения порога неудачных файлов или дисковой квоты;
+// прерванные и не начатые файлы отмечаются как отмененные
+func (u *DownloadUsecase) abortTask(task *entities.Task, interrupted []int, cause error) error {
+	reason := fmt.Sprintf("скачивание отменено: %v", cause)
+	kind := classifyError(cause)
+	cancelled := make(map[int]bool, len(interrupted))
+	for _, i := range interrupted {
+		cancelled[i] = true
+	}
+	for i := range task.Files {
+		file := &task.Files[i]
+		cancel := cancelled[i] || (file.Status != "completed" && file.Status != "failed")
+		// Данные отмененных файлов (и скачанных при очистке) освобождают место, занятое сверх квоты
+		if kind == entities.ErrorKindDiskQuota && (cancel || file.Status == "completed") {
+			u.removeQuotaFiles(file, reason)
+		}
+		if cancel {
+			// Отмененный файл не будет продолжен: недокачанная часть не нужна
+			if file.PartialPath != "" {
+				removePartial(file.PartialPath)
+				file.PartialPath, file.ResumeOffset = "", 0
+			}
+			file.Status = "failed"
+			file.Error = reason
+			file.ErrorKind = kind
+		}
+	}
+
+	task.MarkFinished(u.clock.Now())
+	task.SetError(cause.Error(), u.clock.Now())
+	task.ErrorKind = kind
+	log.Printf("Задача %s завершена досрочно: %v", task.LogID(), cause)
+	return u.finishTask(task)
 }
 
-// DownloadFile скачивает один файл
+// interruptTask возвращает прерванную задачу в статус new, а недокачанные файлы - в pending
+func (u *DownloadUsecase) interruptTask(task *entities.Task, fileIndexes []int, cause error) error {
+	for _, i := range fileIndexes {
+		resetFile(&task.Files[i])
+	}
+	task.UpdateStatus(entities.TaskStatusNew, u.clock.Now())
+	if err := u.updateTask(task); err != nil {
+		return fmt.Errorf("не удалось вернуть прерванную задачу в очередь: %w", err)
+	}
+
+	log.Printf("Обработка задачи %s прервана и будет продолжена после перезапуска", task.LogID())
+	return cause
+}
+
+// RequeueInterruptedTasks возвращает в очередь задачи, оставшиеся в статусе processing
+// после аварийной остановки сервиса. Вызывается при запуске до старта воркеров.
+// Скачанные файлы не меняются, недокачанные и ожидающие возвращаются в pending, а файлы с ошибкой -
+// только если политика повторов задачи разрешает ещё попытку (см. RetryPolicy.shouldRetryFailed).
+// Задача, в которой не осталось файлов для скачивания, завершается с итоговым статусом, а не ставится в очередь.
+func (u *DownloadUsecase) RequeueInterruptedTasks(ctx context.Context) (int, error) {
+	tasks, err := u.taskRepo.GetPendingTasks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	requeued := 0
+	for _, task := range tasks {
+		if task.Status != entities.TaskStatusProcessing {
+			continue
+		}
+
+		policy := u.retryPolicyFor(task)
+		for i := range task.Files {
+			file := &task.Files[i]
+			switch file.Status {
+			case "completed":
+			case "failed":
+				if policy.shouldRetryFailed(*file) {
+					log.Printf("Задача %s: файл %s с ошибкой (%s) будет скачан снова после перезапуска", task.LogID(), u.logURL(file.URL), file.Error)
+					resetFile(file)
+				}
+			default:
+				resetFile(file)
+			}
+		}
+
+		if len(task.Files) > 0 && !hasUnfinishedFiles(task) {
+			if err := u.finishReconciledTask(task); err != nil {
+				return requeued, err
+			}
+			continue
+		}
+		task.UpdateStatus(entities.TaskStatusNew, u.clock.Now())
+		if err := u.updateTask(task); err != nil {
+			return requeued, fmt.Errorf("не удалось обновить задачу: %w", err)
+		}
+		requeued++
+	}
+
+	return requeued, nil
+}
+
+// finishReconciledTask завершает задачу, все файлы которой к моменту аварийной остановки уже получили
+// итоговый статус: скачивать повторно нечего, поэтому задача не ставится в очередь
+func (u *DownloadUsecase) finishReconciledTask(task *entities.Task) error {
+	task.MarkFinished(u.clock.Now())
+	switch {
+	case task.IsCompleted():
+		task.UpdateStatus(entities.TaskStatusCompleted, u.clock.Now())
+	case task.IsPartial():
+		task.UpdateStatus(entities.TaskStatusPartial, u.clock.Now())
+	default:
+		task.UpdateStatus(entities.TaskStatusFailed, u.clock.Now())
+	}
+	if err := u.finishTask(task); err != nil {
+		return fmt.Errorf("не удалось обновить задачу: %w", err)
+	}
+	log.Printf("Задача %s завершена при восстановлении со статусом %s: все файлы уже обработаны", task.LogID(), task.Status)
+	return nil
+}
+
+// resetFile возвращает незавершенный файл в ожидание скачивания; сохраненная часть недокачанного файла учитывается как скачанная
+func resetFile(file *entities.File) {
+	file.Status = "pending"
+	file.Error = ""
+	file.ErrorKind = ""
+	file.HTTPStatus = 0
+	file.Downloaded = file.ResumeOffset
+}
+
+// isPending возвращает true, если задача ожидает обработки или обрабатывается
+func isPending(task *entities.Task) bool {
+	if task.IsDeleted() {
+		return false
+	}
+	return task.Status == entities.TaskStatusNew || task.Status == entities.TaskStatusProcessing
+}
+
+// DownloadFile скачивает один файл и сохраняет результат в задаче
 func (u *DownloadUsecase) DownloadFile(ctx context.Context, url string, taskID string, fileIndex int) error {
+	unlock := u.lockTask(taskID)
+	defer unlock()
+
 	// Получение задачи
 	task, err := u.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
 		return fmt.Errorf("не удалось получить задачу: %w", err)
 	}
 
-	if fileIndex >= len(task.Files) {
-		return fmt.Errorf("неверный индекс файла: %d", fileIndex)
+	if task == nil {
+		return fmt.Errorf("%w: %s", entities.ErrTaskNotFound, taskID)
 	}
 
+	if fileIndex < 0 || fileIndex >= len(task.Files) {
+		return fmt.Errorf("%w: %d (файлов в задаче: %d)", entities.ErrInvalidFileIndex, fileIndex, len(task.Files))
+	}
+
+	// Репозиторий возвращает копию задачи, поэтому результат скачивания нужно сохранить явно
+	err = u.downloadFile(ctx, newFileBatch(task, u.updateTask), task, fileIndex, url)
+	if err == nil {
+		err = u.extractArchive(task, &task.Files[fileIndex])
+	}
+	if updateErr := u.updateTask(task); updateErr != nil {
+		log.Printf("Не удалось сохранить результат скачивания файла задачи %s: %v", task.LogID(), updateErr)
+	}
+	return err
+}
+
+// downloadFile скачивает файл задачи, изменяя переданную копию задачи
+// (для вызывающего, удерживающего блокировку задачи); промежуточное состояние сохраняется через batch
+func (u *DownloadUsecase) downloadFile(ctx context.Context, batch *fileBatch, task *entities.Task, fileIndex int, url string) error {
 	file := &task.Files[fileIndex]
 	file.Status = "downloading"
 
-	// Создание HTTP клиента с таймаутом
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+	// Встроенное содержимое записывается без запроса к источнику,
+	// иначе fetcher выбирается по схеме URL, а хост проверяется политикой и автоматом размыкания
+	var fetcher interfaces.Fetcher = inlineFetcher{file: *file}
+	host := hostOf(url)
+	if !file.IsInline() {
+		var err error
+		fetcher, err = u.fetcherFor(url)
+		if err != nil {
+			file.Status = "failed"
+			file.Error = err.Error()
+			return err
+		}
+
+		// Проверка хоста по политике (задача могла быть создана до изменения политики)
+		if err := u.hostPolicy.Check(host); err != nil {
+			file.Status = "failed"
+			file.Error = err.Error()
+			return err
+		}
+
+		// Быстрый отказ, если хост заблокирован автоматом размыкания
+		if err := u.breakers.Allow(host); err != nil {
+			file.Status = "failed"
+			file.Error = err.Error()
+			return err
+		}
 	}
 
-	// Выполнение запроса
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	// Запросы попытки отменяются отдельно от ctx: так прерывается полностью остановившееся скачивание,
+	// а отмена ctx по-прежнему означает остановку сервиса
+	fetchCtx, abortFetch := context.WithCancel(ctx)
+	defer abortFetch()
+
+	// Открытие удаленного файла
+	fetchReq := interfaces.FetchRequest{URL: url, Headers: task.Headers}
+	result, err := fetcher.Fetch(fetchCtx, fetchReq)
 	if err != nil {
+		if ctx.Err() == nil {
+			u.breakers.Failure(host)
+		}
 		file.Status = "failed"
-		file.Error = fmt.Sprintf("не удалось создать запрос: %v", err)
+		file.Error = err.Error()
 		return err
 	}
+	defer result.Body.Close()
+	file.Protocol = result.Protocol
 
-	// Получение информации о файле
-	resp, err := client.Do(req)
-	if err != nil {
+	// Начальные байты данных запоминаются для определения типа содержимого
+	sniffer := newContentSniffer(result.Body)
+	result.Body = sniffer
+
+	// Файл сжимается на диске, если это включено для задачи и содержимое еще не сжато;
+	// сегментированное скачивание пишет части файла по смещениям и не сжимается
+	compression := ""
+	if _, segmented := u.segmentedFetcher(fetcher, result); u.compressFor(task) && !segmented {
+		head, body := peekBody(sniffer)
+		result.Body = body
+		if !isCompressedContentType(contentType(result.ContentType, head)) {
+			compression = CompressionGzip
+		}
+	}
+
+	// Быстрая проверка заявленного источником размера
+	if file.ExpectedSize > 0 && result.Size >= 0 && result.Size != file.ExpectedSize {
+		err := fmt.Errorf("несовпадение размера: ожидалось %d байт, источник сообщает %d", file.ExpectedSize, result.Size)
 		file.Status = "failed"
-		file.Error = fmt.Sprintf("не удалось скачать: %v", err)
+		file.Error = err.Error()
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	// Выбор пути файла по имени из URL или заголовка Content-Disposition
+	suffix := ""
+	if compression != "" {
+		suffix = ".gz"
+	}
+	// Путь файла, скачиваемого с зеркала, строится по основному URL, чтобы не зависеть от источника
+	nameURL := url
+	if slices.Contains(file.Mirrors, url) {
+		nameURL = file.URL
+	}
+	filePath, err := u.filePath(batch, task, fileIndex, nameURL, result, suffix)
+	if err != nil {
 		file.Status = "failed"
-		file.Error = fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status)
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		file.Error = err.Error()
+		return err
+	}
+	if !batch.reservePath(fileIndex, filePath) {
+		filePath = indexedPath(filePath, fileIndex)
 	}
-
-	// Получение имени файла из URL или заголовка Content-Disposition
-	fileName := u.getFileName(url, resp.Header.Get("Content-Disposition"))
-	filePath := filepath.Join(u.downloadDir, taskID, fileName)
 	file.Path = filePath
+	file.Compression = compression
+	file.StoredSize = 0
 
-	// Создание файла
-	destFile, err := os.Create(filePath)
+	// Создание директории файла согласно стратегии размещения; директории из пути URL
+	// создаются без перехода по символическим ссылкам
+	if task.PreservePath {
+		err = makeDirChain(u.rootDir(task), filepath.Dir(filePath))
+	} else {
+		err = os.MkdirAll(filepath.Dir(filePath), 0755)
+	}
+	if err != nil {
+		file.Status = "failed"
+		file.Error = fmt.Sprintf("не удалось создать директорию для скачивания: %v", err)
+		return err
+	}
+
+	// Существующий файл перезаписывается, пропускается или приводит к ошибке согласно политике
+	skipped, err := u.checkExisting(file, filePath, result.Size, compression)
 	if err != nil {
 		file.Status = "failed"
-		file.Error = fmt.Sprintf("не удалось создать файл: %v", err)
+		file.Error = err.Error()
+		return err
+	}
+	if skipped {
+		log.Printf("Задача %s: файл %s уже существует, скачивание пропущено", task.LogID(), filePath)
+		if compression != "" {
+			file.ContentType = contentType(result.ContentType, sniffer.head)
+		} else {
+			file.ContentType = contentType(result.ContentType, fileHead(filePath))
+		}
+		file.Status = "completed"
+		return nil
+	}
+
+	// Файл, недокачанный при остановке сервиса, продолжается с сохраненного смещения
+	resumed, resuming := u.resumeDownload(fetchCtx, fetcher, fetchReq, result, file, filePath, compression)
+	if resuming {
+		defer resumed.body.Close()
+	}
+
+	file.Downloaded = 0
+	if resuming {
+		file.Downloaded = resumed.offset
+	}
+	file.Slow, file.SlowSpeed = false, 0
+	if result.Size >= 0 {
+		file.Size = result.Size
+	}
+	progress := newProgressTracker(file, u.progress, u.clock, func() error {
+		if err := batch.saveFile(task, fileIndex); err != nil {
+			return fmt.Errorf("задача %s: %w", task.LogID(), err)
+		}
+		u.publishFile(entities.EventFileProgress, task, fileIndex)
+		return nil
+	})
+	if policy := u.slowPolicyFor(task); policy.Enabled() {
+		progress.watchSpeed(policy, u.slowDownloadHandler(task, file, host, policy))
+		defer progress.watchStall(abortFetch)()
+	}
+
+	// Файл, который заведомо не поместится в дисковую квоту задачи, не скачивается;
+	// байты незавершенного файла возвращаются в квоту
+	progress.chargeTo(batch.quota)
+	defer func() {
+		if file.Status != "completed" {
+			progress.refund()
+		}
+	}()
+	if err := batch.quota.reserve(result.Size - file.Downloaded); err != nil {
+		file.Status = "failed"
+		file.Error = err.Error()
 		return err
 	}
-	defer destFile.Close()
 
-	// Копирование данных
-	written, err := io.Copy(destFile, resp.Body)
+	// Частично записанный файл неудачной попытки удаляется: иначе следующая попытка или зеркало
+	// приняли бы его за существующий файл назначения. Недокачанный при остановке файл к этому моменту
+	// уже переименован в path.part
+	defer func() {
+		if file.Status != "completed" {
+			os.Remove(filePath)
+		}
+	}()
+
+	// Крупные файлы с источников, поддерживающих диапазоны, скачиваются параллельными сегментами
+	if rangeFetcher, ok := u.segmentedFetcher(fetcher, result); ok && !resuming {
+		written, err := u.downloadSegmented(fetchCtx, rangeFetcher, fetchReq, result, filePath, progress)
+		if err != nil {
+			if ctx.Err() == nil {
+				u.breakers.Failure(host)
+			}
+			file.Status = "failed"
+			file.Error = err.Error()
+			return err
+		}
+		u.breakers.Success(host)
+
+		checksum, err := fileSHA256(filePath)
+		if err != nil {
+			file.Status = "failed"
+			file.Error = err.Error()
+			return err
+		}
+
+		file.Size = written
+		file.Downloaded = written
+		file.SHA256 = checksum
+		file.ContentType = contentType(result.ContentType, sniffer.head)
+		if err := u.verifyDownload(file, written); err != nil {
+			file.Status = "failed"
+			file.Error = err.Error()
+			return err
+		}
+		file.Status = "completed"
+		u.deduplicate(task, file)
+		return nil
+	}
+
+	// Существующий файл может быть жесткой ссылкой на хранилище содержимого: он удаляется,
+	// чтобы запись не изменила данные других файлов
+	if u.contents != nil {
+		os.Remove(filePath)
+	}
+
+	// Создание файла; недокачанный файл дописывается с места остановки
+	body := result.Body
+	hash := sha256.New()
+	var offset int64
+	var created *os.File
+	if resuming {
+		created, hash, offset, body = resumed.file, resumed.hash, resumed.offset, resumed.body
+		log.Printf("Задача %s: скачивание %s продолжается с %d байт", task.LogID(), u.logURL(url), offset)
+		// Продолжение, не прошедшее проверки размера или контрольной суммы, удаляется, чтобы следующая
+		// попытка не продолжила тот же испорченный файл; сохраненный при остановке файл остается
+		defer func() {
+			if file.Status != "completed" && file.PartialPath == "" {
+				removePartial(created.Name())
+			}
+		}()
+	} else {
+		created, err = os.Create(filePath)
+		if err != nil {
+			file.Status = "failed"
+			file.Error = fmt.Sprintf("не удалось создать файл: %v", err)
+			return err
+		}
+	}
+	var destFile io.WriteCloser = created
+	if compression != "" {
+		destFile = gzipFile{Writer: gzip.NewWriter(created), file: created}
+	}
+	// Файл закрывается ровно один раз: после успешной записи - через destFile, завершающий сжатый поток,
+	// при сохранении недокачанного файла - в keepPartial, иначе - при выходе
+	closed := false
+	defer func() {
+		if !closed {
+			created.Close()
+		}
+	}()
+
+	// Копирование данных с периодическим сохранением прогресса
+	written, err := u.buffers.copy(io.MultiWriter(destFile, hash), progress.reader(body))
+	written += offset
 	if err != nil {
+		if ctx.Err() == nil {
+			u.breakers.Failure(host)
+		} else if compression == "" && result.AcceptRanges {
+			// Остановка сервиса: скачанная часть сохраняется, чтобы после перезапуска продолжить с неё
+			closed = true
+			keepPartial(file, created, filePath, written, result)
+		}
 		file.Status = "failed"
 		file.Error = fmt.Sprintf("не удалось записать файл: %v", err)
 		return err
 	}
 
+	u.breakers.Success(host)
+
 	// Обновление информации о файле
 	file.Size = written
+	file.Downloaded = written
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	file.ContentType = contentType(result.ContentType, sniffer.head)
+
+	if err := u.verifyDownload(file, written); err != nil {
+		file.Status = "failed"
+		file.Error = err.Error()
+		return err
+	}
+	closed = true
+	if err := destFile.Close(); err != nil {
+		file.Status = "failed"
+		file.Error = fmt.Sprintf("не удалось записать файл: %v", err)
+		return err
+	}
+	if resuming {
+		file.ContentType = contentType(result.ContentType, fileHead(created.Name()))
+		if err := os.Rename(created.Name(), filePath); err != nil {
+			file.Status = "failed"
+			file.Error = fmt.Sprintf("не удалось сохранить файл: %v", err)
+			return err
+		}
+		os.Remove(partialMetaPath(created.Name()))
+	}
+	if compression != "" {
+		if info, err := os.Stat(filePath); err == nil {
+			file.StoredSize = info.Size()
+		}
+	}
 	file.Status = "completed"
+	u.deduplicate(task, file)
 
 	return nil
 }
 
+// checkMinSize проверяет, что успешно скачанный файл не меньше минимального размера. Встроенное содержимое
+// и файлы с заданным expected_size (он уже сверен) не проверяются: их размер указан клиентом явно.
+func (u *DownloadUsecase) checkMinSize(file *entities.File, written int64) error {
+	if u.minFileSize <= 0 || file.IsInline() || file.ExpectedSize > 0 || written >= u.minFileSize {
+		return nil
+	}
+	return fmt.Errorf("%w: получено %d байт при минимуме %d", entities.ErrFileTooSmall, written, u.minFileSize)
+}
+
+// verifyDownload выполняет проверки скачанного файла, общие для потокового и сегментированного скачивания:
+// фактический размер, минимальный размер и контрольную сумму
+func (u *DownloadUsecase) verifyDownload(file *entities.File, written int64) error {
+	if file.ExpectedSize > 0 && written != file.ExpectedSize {
+		return fmt.Errorf("несовпадение размера: ожидалось %d байт, получено %d", file.ExpectedSize, written)
+	}
+	if err := u.checkMinSize(file, written); err != nil {
+		return err
+	}
+	return checkChecksum(file)
+}
+
+// downloadWithRetry скачивает файл задачи, повторяя попытки согласно политике повторов задачи
+func (u *DownloadUsecase) downloadWithRetry(ctx context.Context, batch *fileBatch, task *entities.Task, fileIndex int) error {
+	file := &task.Files[fileIndex]
+	policy := u.retryPolicyFor(task)
+	file.Attempts = 0
+	file.MaxAttempts = policy.MaxAttempts * len(file.Sources())
+
+	// Файл, скачанный в пределах окна свежести задачи, не запрашивается у источника повторно
+	if u.reuseFresh(batch, task, fileIndex) {
+		return u.extractArchive(task, file)
+	}
+
+	started := u.clock.Now()
+	file.DownloadStartedAt = &started
+	file.DownloadFinishedAt = nil
+	u.publishFile(entities.EventFileStarted, task, fileIndex)
+	defer func() {
+		finished := u.clock.Now()
+		file.DownloadFinishedAt = &finished
+	}()
+
+	// Каждый источник (URL, затем зеркала по порядку) получает все попытки политики повторов;
+	// к следующему источнику переход выполняется, когда попытки предыдущего исчерпаны
+	sources := file.Sources()
+	file.Source = ""
+	var err error
+	for i, source := range sources {
+		if i > 0 {
+			log.Printf("Задача %s: не удалось скачать %s (%v), переход на зеркало %s", task.LogID(), u.logURL(sources[i-1]), err, u.logURL(source))
+		}
+
+		err = u.downloadFromSource(ctx, batch, task, fileIndex, policy, source)
+		if err == nil {
+			file.Source = source
+			u.markDownloaded(file)
+			// Распаковка выполняется один раз после успешного скачивания: её ошибка не повторяется
+			return u.extractArchive(task, file)
+		}
+		if ctx.Err() != nil || !failsOver(err) {
+			break
+		}
+	}
+
+	return err
+}
+
+// downloadFromSource скачивает файл задачи с одного источника, повторяя попытки по политике
+func (u *DownloadUsecase) downloadFromSource(ctx context.Context, batch *fileBatch, task *entities.Task, fileIndex int, policy RetryPolicy, source string) error {
+	file := &task.Files[fileIndex]
+
+	var err error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		file.Attempts++
+
+		err = u.downloadFile(ctx, batch, task, fileIndex, source)
+		if err == nil {
+			return nil
+		}
+
+		if attempt == policy.MaxAttempts || ctx.Err() != nil || !policy.ShouldRetry(err) {
+			break
+		}
+
+		delay := policy.Delay(attempt)
+		u.retries.record(hostOf(source))
+		file.Status = "retrying"
+		file.Error = err.Error()
+		log.Printf("Повторная попытка %d/%d скачивания %s (задача %s) через %v: %v",
+			attempt+1, policy.MaxAttempts, u.logURL(source), task.LogID(), delay, err)
+
+		if updateErr := batch.saveFile(task, fileIndex); updateErr != nil {
+			log.Printf("Не удалось сохранить состояние повторной попытки задачи %s: %v", task.LogID(), updateErr)
+		}
+
+		select {
+		case <-u.clock.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+// failsOver возвращает true, если после ошибки файл имеет смысл скачать с другого источника:
+// ошибки локального диска и ограничений задачи от источника не зависят
+func failsOver(err error) bool {
+	switch classifyError(err) {
+	case entities.ErrorKindPermission, entities.ErrorKindFilesystem, entities.ErrorKindFileExists,
+		entities.ErrorKindDiskQuota, entities.ErrorKindFailureThreshold, entities.ErrorKindExtraction:
+		return false
+	default:
+		return true
+	}
+}
+
 // GetPendingTasks получает все ожидающие задачи
 func (u *DownloadUsecase) GetPendingTasks(ctx context.Context) ([]*entities.Task, error) {
 	return u.taskRepo.GetPendingTasks(ctx)
 }
 
+// releaseScheduled переводит запланированную задачу в статус new, если она не изменилась через API после получения
+// списка задач. Возвращает true, если задача переведена.
+func (u *DownloadUsecase) releaseScheduled(ctx context.Context, id string, now time.Time) (bool, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil || task.Status != entities.TaskStatusScheduled || task.IsDeleted() || !task.IsDue(now) {
+		return false, nil
+	}
+	task.UpdateStatus(entities.TaskStatusNew, u.clock.Now())
+	if err := u.updateTask(task); err != nil {
+		return false, fmt.Errorf("не удалось обновить задачу: %w", err)
+	}
+	return true, nil
+}
+
+// ReleaseScheduledTasks переводит в статус new запланированные задачи, время запуска которых наступило.
+// Задачи, время запуска которых прошло во время простоя сервиса, запускаются сразу.
+func (u *DownloadUsecase) ReleaseScheduledTasks(ctx context.Context) (int, error) {
+	tasks, err := u.taskRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	now := u.clock.Now()
+	released := 0
+	for _, task := range tasks {
+		if task.Status != entities.TaskStatusScheduled || task.IsDeleted() || !task.IsDue(now) {
+			continue
+		}
+
+		ok, err := u.releaseScheduled(ctx, task.ID.String(), now)
+		if err != nil {
+			return released, err
+		}
+		if ok {
+			released++
+		}
+	}
+
+	return released, nil
+}
+
+// MarkWaitingForCapacity отмечает задачи, не поставленные в переполненную очередь пула воркеров,
+// временем начала ожидания, чтобы пользователь видел причину задержки. Отметка снимается, когда задача
+// берется в работу. Возвращает количество задач, отмеченных впервые.
+func (u *DownloadUsecase) MarkWaitingForCapacity(ctx context.Context, taskIDs []string) (int, error) {
+	marked := 0
+	for _, id := range taskIDs {
+		unlock := u.locker.Lock(id)
+		task, err := u.taskRepo.GetByID(ctx, id)
+		if err != nil || task.Status != entities.TaskStatusNew || task.WaitingForCapacitySince != nil {
+			unlock()
+			continue
+		}
+
+		now := u.clock.Now()
+		task.WaitingForCapacitySince = &now
+		task.UpdatedAt = now
+		err = u.updateTask(task)
+		unlock()
+		if err != nil {
+			return marked, fmt.Errorf("не удалось обновить задачу: %w", err)
+		}
+		marked++
+	}
+
+	return marked, nil
+}
+
+// FailTask завершает ошибкой задачу, обработка которой прервана вне обычного порядка (например, паникой воркера):
+// незавершенные файлы отмечаются неудачными, а задача переходит в failed с видом ошибки internal.
+// Задача, уже получившая итоговый статус, не изменяется.
+func (u *DownloadUsecase) FailTask(ctx context.Context, taskID string, reason string) error {
+	unlock := u.lockTask(taskID)
+	defer unlock()
+	unlockRecord := u.locker.Lock(taskID)
+	defer unlockRecord()
+
+	task, err := u.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+	if !isPending(task) {
+		return nil
+	}
+
+	for i := range task.Files {
+		file := &task.Files[i]
+		if file.Status == "completed" || file.Status == "failed" {
+			continue
+		}
+		if file.PartialPath != "" {
+			removePartial(file.PartialPath)
+			file.PartialPath, file.ResumeOffset = "", 0
+		}
+		file.Status = "failed"
+		file.Error = reason
+		file.ErrorKind = entities.ErrorKindInternal
+	}
+
+	now := u.clock.Now()
+	task.MarkFinished(now)
+	task.SetError(reason, now)
+	task.ErrorKind = entities.ErrorKindInternal
+	if err := u.finishTask(task); err != nil {
+		return fmt.Errorf("не удалось обновить задачу: %w", err)
+	}
+	log.Printf("Задача %s завершена ошибкой: %s", task.LogID(), reason)
+	return nil
+}
+
+// SupportedSchemes возвращает список схем URL, для которых зарегистрирован fetcher
+func (u *DownloadUsecase) SupportedSchemes() []string {
+	schemes := make([]string, 0, len(u.fetchers))
+	for scheme := range u.fetchers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// hostOf возвращает имя хоста из URL
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// logURL возвращает URL для журнала: без пароля из URL, параметров подписи и токенов presigned-ссылок
+func (u *DownloadUsecase) logURL(rawURL string) string {
+	return redactURL(u.httpConfig.Signer, rawURL)
+}
+
+// fetcherFor возвращает fetcher, соответствующий схеме URL
+func (u *DownloadUsecase) fetcherFor(rawURL string) (interfaces.Fetcher, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("неверный URL: %w", err)
+	}
+
+	fetcher, ok := u.fetchers[strings.ToLower(parsed.Scheme)]
+	if !ok {
+		return nil, fmt.Errorf("неподдерживаемая схема URL: %q", parsed.Scheme)
+	}
+
+	return fetcher, nil
+}
+
+// lockTask захватывает блокировку обработки задачи и возвращает функцию её освобождения.
+// Блокировка удерживается все время скачивания, поэтому чтение и сохранение задачи защищаются отдельно (u.locker).
+func (u *DownloadUsecase) lockTask(taskID string) func() {
+	return u.processing.Lock(taskID)
+}
+
 // updateTask обновляет задачу в обоих репозиториях
 func (u *DownloadUsecase) updateTask(task *entities.Task) error {
 	if err := u.taskRepo.Update(context.Background(), task); err != nil {
@@ -154,28 +1276,45 @@ func (u *DownloadUsecase) updateTask(task *entities.Task) error {
 	return u.persistentRepo.Update(context.Background(), task)
 }
 
-// getFileName извлекает имя файла из URL или заголовка Content-Disposition
-func (u *DownloadUsecase) getFileName(url, contentDisposition string) string {
-	// Попытка получить имя файла из заголовка Content-Disposition
-	if contentDisposition != "" {
-		parts := strings.Split(contentDisposition, "filename=")
-		if len(parts) > 1 {
-			filename := strings.Trim(parts[1], `"`)
-			if filename != "" {
-				return filename
-			}
-		}
+// dispositionFileName возвращает имя файла из заголовка Content-Disposition (включая filename* по RFC 2231).
+// Заголовки, которые не удается разобрать, обрабатываются упрощенно по первому вхождению filename=.
+func dispositionFileName(contentDisposition string) string {
+	if contentDisposition == "" {
+		return ""
+	}
+	if _, params, err := mime.ParseMediaType(contentDisposition); err == nil {
+		return params["filename"]
+	}
+	parts := strings.Split(contentDisposition, "filename=")
+	if len(parts) > 1 {
+		return strings.Trim(parts[1], `"`)
 	}
+	return ""
+}
 
-	// Извлечение имени файла из URL
-	parts := strings.Split(url, "/")
-	if len(parts) > 0 {
-		filename := parts[len(parts)-1]
-		if filename != "" && !strings.Contains(filename, "?") {
-			return filename
+// sanitizeFileName приводит имя файла к безопасному виду: некорректные последовательности UTF-8 и разделители путей
+// заменяются на "_", управляющие символы и символы форматирования (в том числе смена направления текста) удаляются,
+// а длинное имя сокращается. Возвращает пустую строку, если пригодного имени не осталось.
+func sanitizeFileName(name string) string {
+	name = strings.ToValidUTF8(name, "_")
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '_'
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			return -1
+		default:
+			return r
 		}
+	}, name)
+	name = strings.TrimSpace(name)
+	if !isSafeFileName(name) {
+		return ""
 	}
+	return truncateFileName(name)
+}
 
-	// Генерация имени файла по умолчанию
-	return fmt.Sprintf("file_%d", time.Now().Unix())
+// isSafeFileName проверяет, что имя файла не пустое и не ссылается на служебные директории
+func isSafeFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
 }
@@ -0,0 +1,910 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+	"file-downloader/internal/testutil"
+)
+
+// nilTaskRepository returns a nil task without an error to emulate a broken repository
+type nilTaskRepository struct {
+	*MockTaskRepository
+}
+
+func (r *nilTaskRepository) GetByID(ctx context.Context, id string) (*entities.Task, error) {
+	return nil, nil
+}
+
+func TestDownloadFileInvalidIndex(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"}, time.Now())
+	mockRepo.Create(ctx, task)
+
+	testCases := []struct {
+		name  string
+		index int
+	}{
+		{"negative", -1},
+		{"equal to length", len(task.Files)},
+		{"greater than length", len(task.Files) + 10},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			err := usecase.DownloadFile(ctx, "https://example.com/file1.jpg", task.ID.String(), tc.index)
+
+			// Assert
+			if !errors.Is(err, entities.ErrInvalidFileIndex) {
+				t.Errorf("Expected ErrInvalidFileIndex for index %d, got %v", tc.index, err)
+			}
+		})
+	}
+}
+
+func TestDownloadFileTaskNotFound(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	// Execute
+	err := usecase.DownloadFile(ctx, "https://example.com/file1.jpg", "non-existent-id", 0)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error for non-existent task")
+	}
+}
+
+func TestDownloadFileNilTask(t *testing.T) {
+	// Setup
+	repo := &nilTaskRepository{NewMockTaskRepository()}
+	usecase := NewDownloadUsecase(repo, repo)
+	ctx := context.Background()
+
+	// Execute
+	err := usecase.DownloadFile(ctx, "https://example.com/file1.jpg", "some-id", 0)
+
+	// Assert
+	if !errors.Is(err, entities.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound for nil task, got %v", err)
+	}
+}
+
+func TestFilePathLayouts(t *testing.T) {
+	task := entities.NewTask([]string{"https://cdn.example.com:8443/a/image.png"}, time.Now())
+	taskID := task.ID.String()
+
+	testCases := []struct {
+		layout   Layout
+		expected string
+	}{
+		{LayoutByTask, filepath.Join("downloads", taskID, "image.png")},
+		{LayoutFlat, filepath.Join("downloads", taskID[:8]+"_image.png")},
+		{LayoutByHost, filepath.Join("downloads", "cdn.example.com", taskID[:8]+"_image.png")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.layout), func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir("downloads"), WithLayout(tc.layout)).(*DownloadUsecase)
+
+			// Execute
+			path, err := usecase.filePath(nil, task, 0, task.URLs[0], &interfaces.FetchResult{}, "")
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if path != tc.expected {
+				t.Errorf("Expected path %s, got %s", tc.expected, path)
+			}
+		})
+	}
+}
+
+func TestFilePathAvoidsCollisionsWithinTask(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir("downloads")).(*DownloadUsecase)
+	task := entities.NewTask([]string{"https://a.example.com/image.png", "https://b.example.com/image.png"}, time.Now())
+	task.Files[0].Path = filepath.Join("downloads", task.ID.String(), "image.png")
+
+	// Execute
+	path, err := usecase.filePath(nil, task, 1, task.URLs[1], &interfaces.FetchResult{}, "")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := filepath.Join("downloads", task.ID.String(), "image_1.png")
+	if path != expected {
+		t.Errorf("Expected path %s, got %s", expected, path)
+	}
+}
+
+func TestSourceFileNameRejectsTraversal(t *testing.T) {
+	// Setup
+	// Execute
+	name := sourceFileName(interfaces.NameRequest{URL: "https://example.com/file.txt", ContentDisposition: `attachment; filename="../../etc/passwd"`})
+
+	// Assert
+	if name != "passwd" {
+		t.Errorf("Expected sanitized name passwd, got %s", name)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain name kept", "report.pdf", "report.pdf"},
+		{"unicode kept", "отчет 2024.pdf", "отчет 2024.pdf"},
+		{"control characters removed", "re\x00po\x1b[31mrt\n.pdf", "repo[31mrt.pdf"},
+		{"bidi override removed", "invoice\u202egpj.exe", "invoicegpj.exe"},
+		{"invalid utf-8 replaced", "caf\xe9\xff.txt", "caf_.txt"},
+		{"truncated utf-8 replaced", "name\xd0.txt", "name_.txt"},
+		{"backslash replaced", `..\..\boot.ini`, ".._.._boot.ini"},
+		{"surrounding spaces trimmed", "  spaced.txt\t", "spaced.txt"},
+		{"only control characters", "\x01\x02\x7f", ""},
+		{"dot-dot after cleanup", ".\x00.", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			actual := sanitizeFileName(tc.input)
+
+			// Assert
+			if actual != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, actual)
+			}
+			if !utf8.ValidString(actual) {
+				t.Errorf("Expected valid UTF-8, got %q", actual)
+			}
+		})
+	}
+}
+
+func TestSourceFileNameAdversarialContentDisposition(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		header   string
+		expected string
+	}{
+		{"rfc 2231 utf-8 name decoded", "https://example.com/download", `attachment; filename*=UTF-8''%D0%BE%D1%82%D1%87%D0%B5%D1%82.txt`, "отчет.txt"},
+		{"trailing parameters ignored", "https://example.com/download", `attachment; filename="data.csv"; size=10`, "data.csv"},
+		{"encoded control characters removed", "https://example.com/download", `attachment; filename*=UTF-8''a%00b%0A.txt`, "ab.txt"},
+		{"raw invalid bytes replaced", "https://example.com/download", "attachment; filename=\"\xff\xfe.bin\"", "_.bin"},
+		{"unusable header falls back to url", "https://example.com/fallback.txt", "attachment; filename=\"\x01\x02\"", "fallback.txt"},
+		{"unusable url falls back to generated name", "https://example.com/", "attachment; filename=\"\x00\"", "file_"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			name := sourceFileName(interfaces.NameRequest{URL: tc.url, ContentDisposition: tc.header})
+
+			// Assert
+			if tc.expected == "file_" {
+				if !strings.HasPrefix(name, "file_") {
+					t.Errorf("Expected generated name, got %q", name)
+				}
+				return
+			}
+			if name != tc.expected {
+				t.Errorf("Expected name %q, got %q", tc.expected, name)
+			}
+		})
+	}
+}
+
+// stubFetcher returns canned content for any URL
+type stubFetcher struct {
+	content string
+	size    int64
+}
+
+func (f *stubFetcher) Fetch(ctx context.Context, req interfaces.FetchRequest) (*interfaces.FetchResult, error) {
+	return &interfaces.FetchResult{
+		Body: io.NopCloser(strings.NewReader(f.content)),
+		Size: f.size,
+	}, nil
+}
+
+func TestFetcherForDispatchesByScheme(t *testing.T) {
+	// Setup
+	ftp := &stubFetcher{content: "ftp"}
+	sftp := &stubFetcher{content: "sftp"}
+	usecase := NewDownloadUsecase(nil, nil, WithFetcher("ftp", ftp), WithFetcher("sftp", sftp)).(*DownloadUsecase)
+	testCases := []struct {
+		rawURL   string
+		expected interfaces.Fetcher
+	}{
+		{"ftp://example.com/file.bin", ftp},
+		{"SFTP://user@example.com/file.bin", sftp},
+		{"gopher://example.com/file.bin", nil},
+	}
+
+	for _, tc := range testCases {
+		// Execute
+		fetcher, err := usecase.fetcherFor(tc.rawURL)
+
+		// Assert
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("Expected unsupported scheme error for %s, got fetcher %v", tc.rawURL, fetcher)
+			}
+			continue
+		}
+		if err != nil || fetcher != tc.expected {
+			t.Errorf("Expected the registered fetcher for %s, got %v (%v)", tc.rawURL, fetcher, err)
+		}
+	}
+}
+
+func TestProcessTaskExpectedSize(t *testing.T) {
+	testCases := []struct {
+		name           string
+		expectedSize   int64
+		reportedSize   int64
+		expectedStatus string
+	}{
+		{"matching size", 5, 5, "completed"},
+		{"unknown reported size", 5, -1, "completed"},
+		{"mismatch reported by source", 10, 5, "failed"},
+		{"mismatch after download", 10, -1, "failed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(t.TempDir()),
+				WithFetcher("https", &stubFetcher{content: "hello", size: tc.reportedSize}),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+			)
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{"https://example.com/file.txt"}, time.Now())
+			task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending", ExpectedSize: tc.expectedSize}
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			if task.Files[0].Status != tc.expectedStatus {
+				t.Errorf("Expected file status %s, got %s (error: %s)", tc.expectedStatus, task.Files[0].Status, task.Files[0].Error)
+			}
+			if tc.expectedStatus == "failed" && !strings.Contains(task.Files[0].Error, "несовпадение размера") {
+				t.Errorf("Expected size mismatch error, got %q", task.Files[0].Error)
+			}
+			if (task.Checksum != "") != (tc.expectedStatus == "completed") {
+				t.Errorf("Expected aggregate checksum only for a completed file, got %q", task.Checksum)
+			}
+		})
+	}
+}
+
+func TestProcessTaskMinFileSize(t *testing.T) {
+	testCases := []struct {
+		name           string
+		minSize        int64
+		content        string
+		expectedSize   int64
+		expectedStatus string
+	}{
+		{"check disabled", 0, "", 0, "completed"},
+		{"empty body rejected", 1, "", 0, "failed"},
+		{"large enough", 5, "hello", 0, "completed"},
+		{"below threshold", 10, "hello", 0, "failed"},
+		{"explicit expected size", 10, "hello", 5, "completed"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(t.TempDir()),
+				WithFetcher("https", &stubFetcher{content: tc.content, size: int64(len(tc.content))}),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+				WithMinFileSize(tc.minSize),
+			)
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{"https://example.com/missing.txt"}, time.Now())
+			task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending", ExpectedSize: tc.expectedSize}
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			file := task.Files[0]
+			if file.Status != tc.expectedStatus {
+				t.Fatalf("Expected file status %s, got %s (error: %s)", tc.expectedStatus, file.Status, file.Error)
+			}
+			if tc.expectedStatus != "failed" {
+				return
+			}
+			if file.ErrorKind != entities.ErrorKindTooSmall {
+				t.Errorf("Expected error kind %s, got %q", entities.ErrorKindTooSmall, file.ErrorKind)
+			}
+			if file.Attempts != 1 {
+				t.Errorf("Expected too small file not to be retried, got %d attempts", file.Attempts)
+			}
+			if _, err := os.Stat(file.Path); !os.IsNotExist(err) {
+				t.Errorf("Expected too small file to be removed, got %v", err)
+			}
+			stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+			if stored.Files[0].ErrorKind != entities.ErrorKindTooSmall {
+				t.Errorf("Expected error kind to be persisted, got %q", stored.Files[0].ErrorKind)
+			}
+		})
+	}
+}
+
+// roundTripperFunc allows using a function as http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// cannedResponse builds a fake HTTP response
+func cannedResponse(req *http.Request, status int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        http.StatusText(status),
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func TestDownloadFileWithRoundTripper(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Content-Disposition": []string{`attachment; filename="report.csv"`}}
+		return cannedResponse(req, http.StatusOK, "a,b,c", header), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(dir), WithRoundTripper(tripper))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/export?id=1"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	file := task.Files[0]
+	if file.Status != "completed" {
+		t.Errorf("Expected status completed, got %s", file.Status)
+	}
+	if file.Size != 5 {
+		t.Errorf("Expected size 5, got %d", file.Size)
+	}
+
+	expectedPath := filepath.Join(dir, task.ID.String(), "report.csv")
+	if file.Path != expectedPath {
+		t.Errorf("Expected path %s, got %s", expectedPath, file.Path)
+	}
+
+	data, err := os.ReadFile(expectedPath)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if string(data) != "a,b,c" {
+		t.Errorf("Expected file content a,b,c, got %q", string(data))
+	}
+}
+
+func TestDownloadFileHTTPErrorWithRoundTripper(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusNotFound, "not found", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(t.TempDir()), WithRoundTripper(tripper))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/missing.jpg"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error for HTTP 404")
+	}
+	if task.Files[0].Status != "failed" {
+		t.Errorf("Expected status failed, got %s", task.Files[0].Status)
+	}
+	if !strings.Contains(task.Files[0].Error, "404") {
+		t.Errorf("Expected error to mention 404, got %q", task.Files[0].Error)
+	}
+}
+
+func TestDownloadFileSendsConfiguredHeaders(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	var received http.Header
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		received = req.Header.Clone()
+		return cannedResponse(req, http.StatusOK, "ok", nil), nil
+	})
+	config := HTTPFetcherConfig{
+		UserAgent: "test-agent/2.0",
+		Headers:   map[string]string{"Accept": "*/*", "X-Env": "default"},
+	}
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()), WithRoundTripper(tripper), WithHTTPConfig(config))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file.txt"}, time.Now())
+	task.Headers = map[string]string{"X-Env": "task", "Authorization": "Bearer token"}
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := received.Get("User-Agent"); got != "test-agent/2.0" {
+		t.Errorf("Expected User-Agent test-agent/2.0, got %q", got)
+	}
+	if got := received.Get("Accept"); got != "*/*" {
+		t.Errorf("Expected Accept */*, got %q", got)
+	}
+	if got := received.Get("X-Env"); got != "task" {
+		t.Errorf("Expected task header to override default, got %q", got)
+	}
+	if got := received.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Expected Authorization header, got %q", got)
+	}
+}
+
+func TestDownloadFileDefaultUserAgent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	var userAgent string
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		userAgent = req.Header.Get("User-Agent")
+		return cannedResponse(req, http.StatusOK, "ok", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(t.TempDir()), WithRoundTripper(tripper))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if userAgent != DefaultUserAgent {
+		t.Errorf("Expected User-Agent %s, got %q", DefaultUserAgent, userAgent)
+	}
+}
+
+func TestReleaseScheduledTasks(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	due := entities.NewTask([]string{"https://example.com/due.iso"}, time.Now())
+	startedAt := time.Now().Add(-time.Minute)
+	due.StartAt = &startedAt
+	due.Status = entities.TaskStatusScheduled
+	mockRepo.Create(ctx, due)
+
+	later := entities.NewTask([]string{"https://example.com/later.iso"}, time.Now())
+	startAt := time.Now().Add(time.Hour)
+	later.StartAt = &startAt
+	later.Status = entities.TaskStatusScheduled
+	mockRepo.Create(ctx, later)
+
+	// Execute
+	released, err := usecase.ReleaseScheduledTasks(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if released != 1 {
+		t.Errorf("Expected 1 released task, got %d", released)
+	}
+	if due.Status != entities.TaskStatusNew {
+		t.Errorf("Expected due task status new, got %s", due.Status)
+	}
+	if later.Status != entities.TaskStatusScheduled {
+		t.Errorf("Expected future task to stay scheduled, got %s", later.Status)
+	}
+}
+
+func TestMarkWaitingForCapacity(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	clock := testutil.NewFakeClock(time.Now())
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusOK, "hello", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithClock(clock), WithDownloadDir(t.TempDir()), WithRoundTripper(tripper))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, clock.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+	since := clock.Now()
+
+	// Execute
+	first, err := usecase.MarkWaitingForCapacity(ctx, []string{task.ID.String()})
+	clock.Advance(time.Minute)
+	second, _ := usecase.MarkWaitingForCapacity(ctx, []string{task.ID.String()})
+
+	// Assert
+	if err != nil || first != 1 || second != 0 {
+		t.Fatalf("Expected task to be marked once, got %d and %d (%v)", first, second, err)
+	}
+	if task.WaitingForCapacitySince == nil || !task.WaitingForCapacitySince.Equal(since) {
+		t.Errorf("Expected waiting since %v, got %v", since, task.WaitingForCapacitySince)
+	}
+
+	// Execute: the task is picked up by a worker
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if task.WaitingForCapacitySince != nil {
+		t.Errorf("Expected waiting mark to be cleared, got %v", task.WaitingForCapacitySince)
+	}
+}
+
+func TestFailTaskMarksUnfinishedFiles(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.txt", "https://example.com/b.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "completed"}
+	task.Files[1] = entities.File{URL: task.URLs[1], Status: "downloading"}
+	task.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	mockRepo.Create(ctx, task)
+	finished := entities.NewTask([]string{"https://example.com/c.txt"}, time.Now())
+	finished.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+	mockRepo.Create(ctx, finished)
+
+	// Execute
+	err := usecase.FailTask(ctx, task.ID.String(), "внутренняя ошибка при обработке задачи: boom")
+	finishedErr := usecase.FailTask(ctx, finished.ID.String(), "boom")
+
+	// Assert
+	if err != nil || finishedErr != nil {
+		t.Fatalf("Expected no error, got %v and %v", err, finishedErr)
+	}
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	if stored.Status != entities.TaskStatusFailed || stored.ErrorKind != entities.ErrorKindInternal || stored.FinishedAt == nil {
+		t.Errorf("Expected finished failed task with internal error, got %s (%s)", stored.Status, stored.ErrorKind)
+	}
+	if stored.Files[0].Status != "completed" {
+		t.Errorf("Expected completed file to stay completed, got %s", stored.Files[0].Status)
+	}
+	if file := stored.Files[1]; file.Status != "failed" || file.ErrorKind != entities.ErrorKindInternal {
+		t.Errorf("Expected unfinished file to fail with internal error, got %s (%s)", file.Status, file.ErrorKind)
+	}
+	if stored, _ := mockRepo.GetByID(ctx, finished.ID.String()); stored.Status != entities.TaskStatusCompleted {
+		t.Errorf("Expected finished task to stay completed, got %s", stored.Status)
+	}
+}
+
+func TestRequeueInterruptedTasksReconcilesFileStates(t *testing.T) {
+	testCases := []struct {
+		name       string
+		policy     RetryPolicy
+		file       entities.File
+		wantStatus string
+	}{
+		{"completed file is kept", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "completed", Downloaded: 10}, "completed"},
+		{"pending file stays pending", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "pending"}, "pending"},
+		{"downloading file is reset", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "downloading", Downloaded: 7, Attempts: 1}, "pending"},
+		{"retrying file is reset", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "retrying", Attempts: 2, Error: "timeout", ErrorKind: entities.ErrorKindTimeout}, "pending"},
+		{"failed file with attempts left is retried", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "failed", Attempts: 1, Error: "connection reset", ErrorKind: entities.ErrorKindNetwork}, "pending"},
+		{"failed file with exhausted attempts stays failed", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "failed", Attempts: 3, Error: "connection reset", ErrorKind: entities.ErrorKindNetwork}, "failed"},
+		{"failed file with attempts left on mirrors is retried", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "failed", Mirrors: []string{"https://mirror.example.com/b.txt"}, Attempts: 4, MaxAttempts: 6,
+				Error: "connection reset", ErrorKind: entities.ErrorKindNetwork}, "pending"},
+		{"failed file with exhausted attempts of its last run stays failed", RetryPolicy{MaxAttempts: 5},
+			entities.File{Status: "failed", Attempts: 3, MaxAttempts: 3, Error: "connection reset", ErrorKind: entities.ErrorKindNetwork}, "failed"},
+		{"failed file with non-retryable error stays failed", RetryPolicy{MaxAttempts: 3},
+			entities.File{Status: "failed", Attempts: 1, Error: "permission denied", ErrorKind: entities.ErrorKindPermission}, "failed"},
+		{"failed file outside retry conditions stays failed", RetryPolicy{MaxAttempts: 3, RetryOn: []RetryCondition{RetryOn5xx}},
+			entities.File{Status: "failed", Attempts: 1, Error: "HTTP 404: 404 Not Found", ErrorKind: entities.ErrorKindHTTP, HTTPStatus: 404}, "failed"},
+		{"failed file matching retry conditions is retried", RetryPolicy{MaxAttempts: 3, RetryOn: []RetryCondition{RetryOn5xx}},
+			entities.File{Status: "failed", Attempts: 1, Error: "HTTP 503: 503 Service Unavailable", ErrorKind: entities.ErrorKindHTTP, HTTPStatus: 503}, "pending"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			usecase := NewDownloadUsecase(mockRepo, mockRepo, WithRetryPolicy(tc.policy))
+			ctx := context.Background()
+			task := entities.NewTask([]string{"https://example.com/a.txt", "https://example.com/b.txt"}, time.Now())
+			task.Files[0] = entities.File{URL: task.URLs[0], Status: "downloading"}
+			task.Files[1] = tc.file
+			task.Files[1].URL = task.URLs[1]
+			task.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			requeued, err := usecase.RequeueInterruptedTasks(ctx)
+
+			// Assert
+			if err != nil || requeued != 1 {
+				t.Fatalf("Expected 1 requeued task, got %d (%v)", requeued, err)
+			}
+			stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+			if stored.Status != entities.TaskStatusNew {
+				t.Errorf("Expected task status new, got %s", stored.Status)
+			}
+			file := stored.Files[1]
+			if file.Status != tc.wantStatus {
+				t.Errorf("Expected file status %s, got %s", tc.wantStatus, file.Status)
+			}
+			if tc.wantStatus == "pending" && (file.Error != "" || file.ErrorKind != "" || file.Downloaded != 0) {
+				t.Errorf("Expected reset file without error and progress, got %q (%s), downloaded %d", file.Error, file.ErrorKind, file.Downloaded)
+			}
+			if tc.wantStatus != "pending" && (file.Error != tc.file.Error || file.Downloaded != tc.file.Downloaded) {
+				t.Errorf("Expected file to be left unchanged, got %+v", file)
+			}
+		})
+	}
+}
+
+func TestRequeueInterruptedTasksFinishesTaskWithoutUnfinishedFiles(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/a.txt", "https://example.com/b.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "completed"}
+	task.Files[1] = entities.File{URL: task.URLs[1], Status: "failed", Attempts: 2, Error: "timeout", ErrorKind: entities.ErrorKindTimeout}
+	task.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	requeued, err := usecase.RequeueInterruptedTasks(ctx)
+
+	// Assert
+	if err != nil || requeued != 0 {
+		t.Fatalf("Expected no requeued tasks, got %d (%v)", requeued, err)
+	}
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	if stored.Status != entities.TaskStatusPartial || stored.FinishedAt == nil {
+		t.Errorf("Expected finished partial task, got %s", stored.Status)
+	}
+}
+
+// chunkedReader returns data in small chunks to keep a download in progress for a while
+type chunkedReader struct {
+	remaining int
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(time.Millisecond)
+	n := min(len(p), 512, r.remaining)
+	for i := range p[:n] {
+		p[i] = 'x'
+	}
+	r.remaining -= n
+	return n, nil
+}
+
+func TestConcurrentStatusReadsDuringDownload(t *testing.T) {
+	// Setup
+	taskRepo := repository.NewInMemoryTaskRepository()
+	fileRepo := repository.NewFileBasedTaskRepository(filepath.Join(t.TempDir(), "tasks.json"))
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp := cannedResponse(req, http.StatusOK, "", nil)
+		resp.Body = io.NopCloser(&chunkedReader{remaining: 32 << 10})
+		resp.ContentLength = 32 << 10
+		return resp, nil
+	})
+	usecase := NewDownloadUsecase(taskRepo, fileRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithProgressPersistence(ProgressConfig{Interval: time.Nanosecond, MinBytes: 1}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a.bin", "https://example.com/b.bin"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	taskRepo.Create(ctx, task)
+	fileRepo.Create(ctx, task)
+
+	pending, err := usecase.GetPendingTasks(ctx)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("Expected 1 pending task, got %d (%v)", len(pending), err)
+	}
+
+	done := make(chan struct{})
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			current, err := taskRepo.GetByID(ctx, task.ID.String())
+			if err != nil {
+				t.Errorf("Expected no error reading task, got %v", err)
+				return
+			}
+			current.GetProgress()
+			current.DownloadedBytes()
+			if _, err := json.Marshal(current); err != nil {
+				t.Errorf("Expected task to marshal, got %v", err)
+				return
+			}
+		}
+	}()
+
+	// Execute
+	err = usecase.ProcessTask(ctx, pending[0])
+	close(done)
+	<-readerDone
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stored, err := taskRepo.GetByID(ctx, task.ID.String())
+	if err != nil {
+		t.Fatalf("Expected task to be stored, got %v", err)
+	}
+	if stored.Status != entities.TaskStatusCompleted {
+		t.Errorf("Expected status completed, got %s", stored.Status)
+	}
+	if downloaded, _ := stored.DownloadedBytes(); downloaded != 2*(32<<10) {
+		t.Errorf("Expected %d downloaded bytes, got %d", 2*(32<<10), downloaded)
+	}
+	if task.Status != entities.TaskStatusNew {
+		t.Errorf("Expected caller's task to stay untouched, got status %s", task.Status)
+	}
+}
+
+func TestProcessTaskPartialCompletion(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "missing.bin") {
+			return cannedResponse(req, http.StatusNotFound, "", nil), nil
+		}
+		return cannedResponse(req, http.StatusOK, "data", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/ok.bin", "https://example.com/missing.bin"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.ProcessTask(ctx, task)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if task.Status != entities.TaskStatusPartial {
+		t.Errorf("Expected status %s, got %s", entities.TaskStatusPartial, task.Status)
+	}
+	if task.Files[0].Status != "completed" || task.Files[1].Status != "failed" {
+		t.Errorf("Expected completed and failed files, got %s and %s", task.Files[0].Status, task.Files[1].Status)
+	}
+}
+
+// panickingFetcher panics while fetching a file
+type panickingFetcher struct{}
+
+func (panickingFetcher) Fetch(ctx context.Context, req interfaces.FetchRequest) (*interfaces.FetchResult, error) {
+	panic("fetcher bug")
+}
+
+func TestProcessTaskRecoversFilePanic(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithFetcher("https", panickingFetcher{}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}))
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.ProcessTask(ctx, task)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	if stored.Status != entities.TaskStatusFailed {
+		t.Errorf("Expected failed task, got %s", stored.Status)
+	}
+	if file := stored.Files[0]; file.Status != "failed" || file.ErrorKind != entities.ErrorKindInternal {
+		t.Errorf("Expected file to fail with internal error, got %s (%s: %s)", file.Status, file.ErrorKind, file.Error)
+	}
+}
@@ -0,0 +1,39 @@
+package usecases
+
+import (
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// noopEvents используется, когда шина событий не задана
+type noopEvents struct{}
+
+func (noopEvents) Publish(event entities.TaskEvent) {}
+
+// WithEvents задает шину, в которую публикуются события скачивания файлов и завершения задач
+func WithEvents(events interfaces.EventPublisher) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.events = events
+	}
+}
+
+// WithTaskEvents задает шину, в которую публикуются события создания задач
+func WithTaskEvents(events interfaces.EventPublisher) TaskOption {
+	return func(u *TaskUsecase) {
+		u.events = events
+	}
+}
+
+// publishFile публикует событие файла задачи
+func (u *DownloadUsecase) publishFile(eventType entities.EventType, task *entities.Task, fileIndex int) {
+	u.events.Publish(entities.NewFileEvent(eventType, task, fileIndex, u.clock.Now()))
+}
+
+// finishTask сохраняет задачу с итоговым статусом и публикует событие её завершения
+func (u *DownloadUsecase) finishTask(task *entities.Task) error {
+	if err := u.updateTask(task); err != nil {
+		return err
+	}
+	u.events.Publish(entities.NewTaskEvent(entities.EventTaskCompleted, task, u.clock.Now()))
+	return nil
+}
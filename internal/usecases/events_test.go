@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"file-downloader/internal/entities"
+)
+
+// recordingEvents records published events
+type recordingEvents struct {
+	mu     sync.Mutex
+	events []entities.TaskEvent
+}
+
+func (r *recordingEvents) Publish(event entities.TaskEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recordingEvents) types() []entities.EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := make([]entities.EventType, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestTaskLifecyclePublishesEvents(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	events := &recordingEvents{}
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/missing.txt" {
+			return cannedResponse(req, http.StatusNotFound, "", nil), nil
+		}
+		return cannedResponse(req, http.StatusOK, "hello", nil), nil
+	})
+	taskUsecase := NewTaskUsecase(mockRepo, mockRepo, WithTaskEvents(events))
+	downloadUsecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithEvents(events))
+	ctx := context.Background()
+
+	// Execute
+	task, err := taskUsecase.CreateTask(ctx, []string{"https://example.com/a.txt", "https://example.com/missing.txt"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	downloadUsecase.ProcessTask(ctx, task)
+
+	// Assert
+	types := events.types()
+	if len(types) != 6 || types[0] != entities.EventTaskCreated || types[5] != entities.EventTaskCompleted {
+		t.Fatalf("Expected task_created, two files started and finished, then task_completed, got %v", types)
+	}
+	counts := make(map[entities.EventType]int)
+	for _, eventType := range types {
+		counts[eventType]++
+	}
+	if counts[entities.EventFileStarted] != 2 || counts[entities.EventFileCompleted] != 1 || counts[entities.EventFileFailed] != 1 {
+		t.Errorf("Expected 2 started, 1 completed and 1 failed file events, got %v", counts)
+	}
+	for _, event := range events.events[1:5] {
+		if event.File == nil || event.File.URL != task.Files[event.FileIndex].URL {
+			t.Errorf("Expected file event to carry file %d state, got %+v", event.FileIndex, event.File)
+		}
+	}
+	if last := events.events[5]; last.Status != entities.TaskStatusPartial || last.TaskID != task.ID {
+		t.Errorf("Expected task_completed with status partial, got %s", last.Status)
+	}
+}
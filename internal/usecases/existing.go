@@ -0,0 +1,86 @@
+package usecases
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// ExistingFilePolicy определяет поведение, если файл назначения уже существует на диске
+type ExistingFilePolicy string
+
+const (
+	// ExistingFileOverwrite перезаписывает существующий файл
+	ExistingFileOverwrite ExistingFilePolicy = "overwrite"
+	// ExistingFileSkip считает файл скачанным, если его размер совпадает с известным размером источника
+	ExistingFileSkip ExistingFilePolicy = "skip"
+	// ExistingFileError завершает скачивание файла ошибкой
+	ExistingFileError ExistingFilePolicy = "error"
+)
+
+// ParseExistingFilePolicy преобразует строку в политику для существующих файлов
+func ParseExistingFilePolicy(value string) (ExistingFilePolicy, error) {
+	switch ExistingFilePolicy(value) {
+	case ExistingFileOverwrite, ExistingFileSkip, ExistingFileError:
+		return ExistingFilePolicy(value), nil
+	case "":
+		return ExistingFileOverwrite, nil
+	default:
+		return "", fmt.Errorf("неизвестная политика для существующих файлов: %q", value)
+	}
+}
+
+// checkExisting применяет политику к уже существующему файлу назначения.
+// Возвращает true, если файл пропущен как уже скачанный (информация о нем заполнена),
+// и ErrFileExists, если политика запрещает перезапись. Размер для skip берется из ожидаемого размера файла,
+// а если он не задан - из размера, заявленного источником; при неизвестном размере файл скачивается заново.
+// Сжатый файл сравнивается по размеру распакованных данных.
+func (u *DownloadUsecase) checkExisting(file *entities.File, path string, sourceSize int64, compression string) (bool, error) {
+	if u.existing == ExistingFileOverwrite || u.existing == "" {
+		return false, nil
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("не удалось проверить файл %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return false, fmt.Errorf("%w: %s не является обычным файлом", entities.ErrFileExists, path)
+	}
+
+	if u.existing == ExistingFileError {
+		return false, fmt.Errorf("%w: %s", entities.ErrFileExists, path)
+	}
+
+	size := file.ExpectedSize
+	if size <= 0 {
+		size = sourceSize
+	}
+	if size < 0 || (compression == "" && info.Size() != size) {
+		return false, nil
+	}
+
+	written, checksum, err := contentSHA256(path, compression)
+	if err != nil {
+		if compression != "" {
+			// Поврежденный сжатый файл скачивается заново
+			return false, nil
+		}
+		return false, err
+	}
+	if written != size || (file.ExpectedSHA256 != "" && !strings.EqualFold(checksum, file.ExpectedSHA256)) {
+		return false, nil
+	}
+	file.Size = written
+	file.Downloaded = written
+	file.SHA256 = checksum
+	if compression != "" {
+		file.StoredSize = info.Size()
+	}
+	return true, nil
+}
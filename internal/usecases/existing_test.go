@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+func TestParseExistingFilePolicy(t *testing.T) {
+	// Setup
+	testCases := []struct {
+		value    string
+		expected ExistingFilePolicy
+		wantErr  bool
+	}{
+		{"", ExistingFileOverwrite, false},
+		{"overwrite", ExistingFileOverwrite, false},
+		{"skip", ExistingFileSkip, false},
+		{"error", ExistingFileError, false},
+		{"ignore", "", true},
+	}
+
+	for _, tc := range testCases {
+		// Execute
+		policy, err := ParseExistingFilePolicy(tc.value)
+
+		// Assert
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Expected error %v for %q, got %v", tc.wantErr, tc.value, err)
+		}
+		if policy != tc.expected {
+			t.Errorf("Expected policy %q for %q, got %q", tc.expected, tc.value, policy)
+		}
+	}
+}
+
+func TestDownloadFileExistingFilePolicy(t *testing.T) {
+	testCases := []struct {
+		name            string
+		policy          ExistingFilePolicy
+		existing        string
+		reportedSize    int64
+		expectedStatus  string
+		expectedContent string
+	}{
+		{"overwrite replaces file", ExistingFileOverwrite, "old", 5, "completed", "hello"},
+		{"skip keeps file with matching size", ExistingFileSkip, "local", 5, "completed", "local"},
+		{"skip downloads on size mismatch", ExistingFileSkip, "old", 5, "completed", "hello"},
+		{"skip downloads on unknown size", ExistingFileSkip, "local", -1, "completed", "hello"},
+		{"error fails on existing file", ExistingFileError, "old", 5, "failed", "old"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			dir := t.TempDir()
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(dir),
+				WithFetcher("https", &stubFetcher{content: "hello", size: tc.reportedSize}),
+				WithExistingFilePolicy(tc.policy),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+			)
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{"https://example.com/file.txt"}, time.Now())
+			task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+			path := filepath.Join(dir, task.ID.String(), "file.txt")
+			os.MkdirAll(filepath.Dir(path), 0755)
+			os.WriteFile(path, []byte(tc.existing), 0644)
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			file := task.Files[0]
+			if file.Status != tc.expectedStatus {
+				t.Errorf("Expected file status %s, got %s (error: %s)", tc.expectedStatus, file.Status, file.Error)
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != tc.expectedContent {
+				t.Errorf("Expected file content %q, got %q", tc.expectedContent, string(data))
+			}
+			if tc.expectedStatus == "completed" && file.Size != int64(len(tc.expectedContent)) {
+				t.Errorf("Expected size %d, got %d", len(tc.expectedContent), file.Size)
+			}
+			if tc.policy == ExistingFileError && (file.ErrorKind != entities.ErrorKindFileExists || file.Attempts != 1) {
+				t.Errorf("Expected one attempt with error kind %s, got %d with %s", entities.ErrorKindFileExists, file.Attempts, file.ErrorKind)
+			}
+		})
+	}
+}
+
+// truncatingFetcher breaks the body of the first response after a few bytes and serves the full content afterwards
+type truncatingFetcher struct {
+	content string
+	calls   int
+}
+
+func (f *truncatingFetcher) Fetch(ctx context.Context, req interfaces.FetchRequest) (*interfaces.FetchResult, error) {
+	f.calls++
+	var body io.Reader = strings.NewReader(f.content)
+	if f.calls == 1 {
+		body = io.MultiReader(strings.NewReader(f.content[:2]), iotest.ErrReader(errors.New("connection reset")))
+	}
+	return &interfaces.FetchResult{Body: io.NopCloser(body), Size: int64(len(f.content))}, nil
+}
+
+func TestProcessTaskErrorPolicyRetriesAfterPartialWrite(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	fetcher := &truncatingFetcher{content: "hello"}
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(dir),
+		WithFetcher("https", fetcher),
+		WithExistingFilePolicy(ExistingFileError),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+	)
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	file := task.Files[0]
+	if file.Status != "completed" || file.Attempts != 2 {
+		t.Errorf("Expected file completed after 2 attempts, got %s after %d (error: %s)", file.Status, file.Attempts, file.Error)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, task.ID.String(), "file.txt"))
+	if string(data) != "hello" {
+		t.Errorf("Expected file content %q, got %q", "hello", string(data))
+	}
+}
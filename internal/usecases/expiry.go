@@ -0,0 +1,117 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// ttlFor возвращает срок хранения новой задачи: заданный при создании или значение по умолчанию
+func (u *TaskUsecase) ttlFor(spec entities.TaskSpec) time.Duration {
+	if spec.TTL > 0 {
+		return spec.TTL
+	}
+	return u.defaultTTL
+}
+
+// ExpireTasks удаляет задачи, срок хранения которых истек к моменту now, вместе с их файлами независимо
+// от статуса. Обрабатываемая задача не удаляется: её удаление откладывается до проверки после завершения
+// обработки. Возвращает количество удаленных задач.
+func (u *TaskUsecase) ExpireTasks(ctx context.Context, now time.Time) (int, error) {
+	tasks, err := u.taskRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	expired := 0
+	for _, task := range tasks {
+		if !task.IsExpired(now) {
+			continue
+		}
+		ok, err := u.expireTask(ctx, task.ID.String(), now)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			expired++
+		}
+	}
+	if expired > 0 {
+		u.collectContents()
+	}
+
+	return expired, nil
+}
+
+// expireTask удаляет задачу с истекшим сроком хранения. Задача перечитывается под блокировкой задачи, поэтому
+// файлы задачи, которую воркер успел взять в обработку после получения списка, не удаляются.
+// Возвращает true, если задача удалена.
+func (u *TaskUsecase) expireTask(ctx context.Context, id string, now time.Time) (bool, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil || !task.IsExpired(now) {
+		return false, nil
+	}
+	if task.Status == entities.TaskStatusProcessing {
+		log.Printf("Срок хранения задачи %s истек во время обработки, удаление отложено", task.LogID())
+		return false, nil
+	}
+
+	if err := u.removeTaskFiles(task); err != nil {
+		log.Printf("Не удалось удалить файлы задачи %s с истекшим сроком хранения: %v", task.LogID(), err)
+		return false, nil
+	}
+	if err := u.deleteStored(ctx, id); err != nil {
+		return false, err
+	}
+	log.Printf("Задача %s удалена: срок хранения истек %s", task.LogID(), task.ExpiresAt.Format(time.RFC3339))
+	return true, nil
+}
+
+// removeTaskFiles удаляет файлы задачи с диска: скачанные, распакованные и недокачанные, а также
+// опустевшие директории задачи. Файлы задачи в корзине удаляются вместе с её директорией корзины.
+func (u *TaskUsecase) removeTaskFiles(task *entities.Task) error {
+	if task.IsDeleted() {
+		return u.trash.purge(task)
+	}
+
+	root := u.trash.rootFor(task)
+	for _, file := range task.Files {
+		if file.Path == "" {
+			continue
+		}
+		for _, path := range file.Extracted {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			removeEmptyDirs(root, filepath.Dir(path))
+		}
+		partial := partialPath(file.Path)
+		for _, path := range []string{file.Path, partial, partialMetaPath(partial)} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		removeEmptyDirs(root, filepath.Dir(file.Path))
+	}
+	return nil
+}
+
+// deleteStored удаляет задачу из репозитория и из постоянного хранилища
+func (u *TaskUsecase) deleteStored(ctx context.Context, id string) error {
+	if err := u.taskRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("не удалось удалить задачу: %w", err)
+	}
+	if err := u.persistentRepo.Delete(ctx, id); err != nil && !errors.Is(err, entities.ErrTaskNotFound) {
+		return fmt.Errorf("не удалось удалить задачу из хранилища: %w", err)
+	}
+	return nil
+}
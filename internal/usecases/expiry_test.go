@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+// createTaskWithTTL creates a single-file task with the given ttl
+func createTaskWithTTL(t *testing.T, usecase *TaskUsecase, url string, ttl time.Duration) *entities.Task {
+	t.Helper()
+	spec := entities.NewTaskSpec([]string{url})
+	spec.TTL = ttl
+	task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	return task
+}
+
+func TestCreateTaskSetsExpiry(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithDefaultTTL(24*time.Hour), WithTaskClock(testutil.NewFakeClock(now))).(*TaskUsecase)
+
+	// Execute
+	explicit := createTaskWithTTL(t, usecase, "https://example.com/a.txt", time.Hour)
+	fallback := createTaskWithTTL(t, usecase, "https://example.com/b.txt", 0)
+	_, err := usecase.CreateTaskFromSpec(context.Background(), entities.TaskSpec{
+		Files: []entities.FileSpec{{URL: "https://example.com/c.txt"}},
+		TTL:   -time.Hour,
+	})
+
+	// Assert
+	if explicit.ExpiresAt == nil || !explicit.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected expiry from the task ttl, got %v", explicit.ExpiresAt)
+	}
+	if fallback.ExpiresAt == nil || !fallback.ExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("Expected expiry from the default ttl, got %v", fallback.ExpiresAt)
+	}
+	if !errors.Is(err, entities.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a negative ttl, got %v", err)
+	}
+}
+
+func TestExpireTasks(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(dir)).(*TaskUsecase)
+	ctx := context.Background()
+
+	expired := createTaskWithTTL(t, usecase, "https://example.com/old.txt", time.Hour)
+	path := filepath.Join(dir, expired.ID.String(), "old.txt")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create task directory: %v", err)
+	}
+	os.WriteFile(path, []byte("old"), 0644)
+	expired.Status = entities.TaskStatusCompleted
+	expired.Files[0].Status = "completed"
+	expired.Files[0].Path = path
+
+	processing := createTaskWithTTL(t, usecase, "https://example.com/busy.txt", time.Hour)
+	processing.Status = entities.TaskStatusProcessing
+	fresh := createTaskWithTTL(t, usecase, "https://example.com/fresh.txt", 72*time.Hour)
+	forever := createTaskWithTTL(t, usecase, "https://example.com/forever.txt", 0)
+
+	// Execute
+	removed, err := usecase.ExpireTasks(ctx, time.Now().Add(2*time.Hour))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 expired task, got %d", removed)
+	}
+	if _, err := mockRepo.GetByID(ctx, expired.ID.String()); err == nil {
+		t.Error("Expected expired task to be removed")
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected files of the expired task to be removed, got %v", err)
+	}
+	for _, task := range []*entities.Task{processing, fresh, forever} {
+		if _, err := mockRepo.GetByID(ctx, task.ID.String()); err != nil {
+			t.Errorf("Expected task %s to be kept, got %v", task.ID, err)
+		}
+	}
+}
+
+// listingRepository signals when the task list has been read
+type listingRepository struct {
+	*MockTaskRepository
+	listed chan struct{}
+}
+
+func (r listingRepository) GetAll(ctx context.Context) ([]*entities.Task, error) {
+	tasks, err := r.MockTaskRepository.GetAll(ctx)
+	close(r.listed)
+	return tasks, err
+}
+
+func TestExpireTasksSkipsTaskStartedWhileWaiting(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	repo := listingRepository{MockTaskRepository: mockRepo, listed: make(chan struct{})}
+	dir := t.TempDir()
+	locker := NewTaskLocker()
+	usecase := NewTaskUsecase(repo, mockRepo, WithTrashDir(dir), WithTaskLocker(locker)).(*TaskUsecase)
+	ctx := context.Background()
+	task := createTaskWithTTL(t, usecase, "https://example.com/busy.txt", time.Hour)
+	path := filepath.Join(dir, task.ID.String(), "busy.txt.part")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("partial"), 0644)
+	task.Files[0].Path = filepath.Join(dir, task.ID.String(), "busy.txt")
+
+	// Execute: a worker takes the task after ExpireTasks listed it
+	unlock := locker.Lock(task.ID.String())
+	done := make(chan int, 1)
+	go func() {
+		removed, _ := usecase.ExpireTasks(ctx, time.Now().Add(2*time.Hour))
+		done <- removed
+	}()
+	<-repo.listed
+	started := task.Clone()
+	started.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	mockRepo.Update(ctx, started)
+	unlock()
+
+	// Assert
+	if removed := <-done; removed != 0 {
+		t.Errorf("Expected no expired tasks, got %d", removed)
+	}
+	if _, err := mockRepo.GetByID(ctx, task.ID.String()); err != nil {
+		t.Errorf("Expected processing task to be kept, got %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected partial file of the processing task to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package usecases
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+const (
+	// DefaultExtractMaxSize - суммарный размер файлов, распаковываемых из одного архива, по умолчанию
+	DefaultExtractMaxSize = 1 << 30
+
+	// DefaultExtractMaxFiles - число файлов, распаковываемых из одного архива, по умолчанию
+	DefaultExtractMaxFiles = 10000
+)
+
+// Форматы архивов, распаковываемых при extract
+const (
+	archiveZip   = "zip"
+	archiveTarGz = "tar.gz"
+)
+
+// ExtractionLimits ограничивает распаковку одного архива, чтобы архив-бомба не заполнил диск
+type ExtractionLimits struct {
+	MaxSize  int64 // суммарный размер распакованных файлов в байтах
+	MaxFiles int   // число распакованных файлов
+}
+
+// WithExtractionLimits задает ограничения распаковки архивов задач с extract; нулевые поля
+// заменяются значениями по умолчанию
+func WithExtractionLimits(limits ExtractionLimits) DownloadOption {
+	return func(u *DownloadUsecase) {
+		if limits.MaxSize <= 0 {
+			limits.MaxSize = DefaultExtractMaxSize
+		}
+		if limits.MaxFiles <= 0 {
+			limits.MaxFiles = DefaultExtractMaxFiles
+		}
+		u.extraction = limits
+	}
+}
+
+// archiveFormat определяет формат архива по имени файла и возвращает его вместе с именем без расширения
+func archiveFormat(name string) (format, base string) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip, name[:len(name)-len(".zip")]
+	case strings.HasSuffix(lower, ".tar.gz"):
+		return archiveTarGz, name[:len(name)-len(".tar.gz")]
+	case strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz, name[:len(name)-len(".tgz")]
+	default:
+		return "", ""
+	}
+}
+
+// extractArchive распаковывает скачанный архив задачи с extract в директорию рядом с ним, названную по имени архива,
+// и записывает пути распакованных файлов в файл задачи. Файлы, не являющиеся архивами, не меняются.
+// При ошибке уже распакованные файлы удаляются, а файл задачи завершается ошибкой ErrExtraction.
+func (u *DownloadUsecase) extractArchive(task *entities.Task, file *entities.File) error {
+	file.Extracted = nil
+	if !task.Extract || file.Path == "" {
+		return nil
+	}
+
+	name := file.Path
+	if file.Compression == CompressionGzip {
+		name = strings.TrimSuffix(name, ".gz")
+	}
+	format, base := archiveFormat(name)
+	if format == "" {
+		return nil
+	}
+
+	extractor := &archiveExtractor{dest: base, limits: u.extraction}
+	var err error
+	if err = makeDirChain(filepath.Dir(base), base); err == nil {
+		if format == archiveZip {
+			err = extractor.extractZip(file.Path, file.Compression)
+		} else {
+			err = extractor.extractTarGz(file.Path, file.Compression)
+		}
+	}
+	if err != nil {
+		extractor.cleanup()
+		err = fmt.Errorf("%w %s: %v", entities.ErrExtraction, filepath.Base(name), err)
+		file.Status = "failed"
+		file.Error = err.Error()
+		return err
+	}
+
+	file.Extracted = extractor.paths
+	log.Printf("Задача %s: из архива %s распаковано файлов: %d", task.LogID(), file.Path, len(extractor.paths))
+	return nil
+}
+
+// archiveExtractor распаковывает записи архива в директорию dest, проверяя пути и ограничения
+type archiveExtractor struct {
+	dest    string
+	limits  ExtractionLimits
+	written int64
+	paths   []string
+}
+
+// extractZip распаковывает архив zip
+func (e *archiveExtractor) extractZip(path, compression string) error {
+	if compression != "" {
+		return fmt.Errorf("архив zip сжат на диске (%s)", compression)
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, entry := range archive.File {
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			if err := e.dir(entry.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if err := e.zipFile(entry); err != nil {
+				return err
+			}
+		default:
+			log.Printf("Архив %s: запись %s не является обычным файлом и пропущена", path, entry.Name)
+		}
+	}
+	return nil
+}
+
+// zipFile распаковывает файл из архива zip
+func (e *archiveExtractor) zipFile(entry *zip.File) error {
+	reader, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return e.file(entry.Name, reader)
+}
+
+// extractTarGz распаковывает архив tar.gz
+func (e *archiveExtractor) extractTarGz(path, compression string) error {
+	content, err := openContent(path, compression)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	gz, err := gzip.NewReader(content)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := e.dir(header.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := e.file(header.Name, archive); err != nil {
+				return err
+			}
+		default:
+			// Ссылки не создаются: они могли бы указывать за пределы директории распаковки
+			log.Printf("Архив %s: запись %s не является обычным файлом и пропущена", path, header.Name)
+		}
+	}
+}
+
+// target возвращает путь записи архива внутри директории распаковки; пути, выходящие за её пределы
+// (абсолютные или с ..), отклоняются
+func (e *archiveExtractor) target(name string) (string, error) {
+	rel := filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("запись %q выходит за пределы директории распаковки", name)
+	}
+	return filepath.Join(e.dest, rel), nil
+}
+
+// dir создает директорию из архива
+func (e *archiveExtractor) dir(name string) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	return makeDirChain(e.dest, target)
+}
+
+// file записывает файл из архива, учитывая ограничения числа файлов и суммарного размера
+func (e *archiveExtractor) file(name string, content io.Reader) error {
+	if len(e.paths) >= e.limits.MaxFiles {
+		return fmt.Errorf("в архиве больше %d файлов", e.limits.MaxFiles)
+	}
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	// Директории создаются без перехода по символическим ссылкам, а существующий файл
+	// (или ссылка на его месте) заменяется новым
+	if err := makeDirChain(e.dest, filepath.Dir(target)); err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	e.paths = append(e.paths, target)
+
+	// Размер из заголовка записи не проверяется заранее: ограничение применяется к фактически распакованным данным
+	remaining := e.limits.MaxSize - e.written
+	n, err := io.Copy(out, io.LimitReader(content, remaining+1))
+	e.written += n
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if e.written > e.limits.MaxSize {
+		return fmt.Errorf("распакованные данные превышают %d байт", e.limits.MaxSize)
+	}
+	return nil
+}
+
+// cleanup удаляет файлы, распакованные до ошибки
+func (e *archiveExtractor) cleanup() {
+	for _, path := range e.paths {
+		os.Remove(path)
+	}
+	e.paths = nil
+}
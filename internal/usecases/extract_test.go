@@ -0,0 +1,142 @@
+package usecases
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// tarGz builds a tar.gz archive with the given files
+func tarGz(t *testing.T, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		archive.Write([]byte(content))
+	}
+	archive.Close()
+	gz.Close()
+	return buf.String()
+}
+
+// zipArchive builds a zip archive with the given files in order
+func zipArchive(t *testing.T, names []string, content string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add zip entry: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	archive.Close()
+	return buf.String()
+}
+
+// processArchiveTask downloads a single archive with extract enabled and returns the resulting file
+func processArchiveTask(t *testing.T, dir, url, content string, limits ExtractionLimits) entities.File {
+	t.Helper()
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(dir),
+		WithLayout(LayoutFlat),
+		WithFetcher("https", &stubFetcher{content: content, size: int64(len(content))}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithExtractionLimits(limits),
+	)
+	ctx := context.Background()
+	task := entities.NewTask([]string{url}, time.Now())
+	task.Files[0] = entities.File{URL: url, Status: "pending"}
+	task.Extract = true
+	mockRepo.Create(ctx, task)
+
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return task.Files[0]
+}
+
+func TestExtractTarGzArchive(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	archive := tarGz(t, map[string]string{"data/a.txt": "alpha", "b.txt": "beta"})
+
+	// Execute
+	file := processArchiveTask(t, dir, "https://example.com/bundle.tar.gz", archive, ExtractionLimits{})
+
+	// Assert
+	if file.Status != "completed" {
+		t.Fatalf("Expected file status completed, got %s (error: %s)", file.Status, file.Error)
+	}
+	if len(file.Extracted) != 2 {
+		t.Fatalf("Expected 2 extracted files, got %v", file.Extracted)
+	}
+	data, err := os.ReadFile(filepath.Join(strings.TrimSuffix(file.Path, ".tar.gz"), "data", "a.txt"))
+	if err != nil || string(data) != "alpha" {
+		t.Errorf("Expected extracted content alpha, got %q (%v)", data, err)
+	}
+}
+
+func TestExtractRejectsPathTraversal(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	archive := zipArchive(t, []string{"ok.txt", "../../evil.txt"}, "payload")
+
+	// Execute
+	file := processArchiveTask(t, filepath.Join(dir, "downloads"), "https://example.com/bundle.zip", archive, ExtractionLimits{})
+
+	// Assert
+	if file.Status != "failed" || file.ErrorKind != entities.ErrorKindExtraction {
+		t.Fatalf("Expected extraction failure, got status %s, kind %q (error: %s)", file.Status, file.ErrorKind, file.Error)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file outside the extraction directory, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(strings.TrimSuffix(file.Path, ".zip"), "ok.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected files extracted before the failure to be removed, got %v", err)
+	}
+	if len(file.Extracted) != 0 {
+		t.Errorf("Expected no extracted files to be recorded, got %v", file.Extracted)
+	}
+}
+
+func TestExtractEnforcesLimits(t *testing.T) {
+	testCases := []struct {
+		name   string
+		limits ExtractionLimits
+	}{
+		{"too many files", ExtractionLimits{MaxFiles: 1}},
+		{"too large", ExtractionLimits{MaxSize: 10}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			archive := zipArchive(t, []string{"a.txt", "b.txt"}, "0123456789")
+
+			// Execute
+			file := processArchiveTask(t, t.TempDir(), "https://example.com/bundle.zip", archive, tc.limits)
+
+			// Assert
+			if file.Status != "failed" || file.ErrorKind != entities.ErrorKindExtraction {
+				t.Errorf("Expected extraction failure, got status %s, kind %q (error: %s)", file.Status, file.ErrorKind, file.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// FailureThreshold задает, сколько файлов задачи может завершиться ошибкой, прежде чем остальные
+// скачивания отменяются, а задача завершается ошибкой. Нулевое значение порог не ограничивает.
+type FailureThreshold struct {
+	limit   int
+	percent bool
+	set     bool
+}
+
+// ParseFailureThreshold преобразует строку в порог ошибок: число файлов ("3") или долю файлов задачи
+// в процентах ("50%"). Пустая строка отключает порог.
+func ParseFailureThreshold(value string) (FailureThreshold, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return FailureThreshold{}, nil
+	}
+
+	raw, percent := strings.CutSuffix(value, "%")
+	limit, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || limit < 0 || (percent && limit > 100) {
+		return FailureThreshold{}, fmt.Errorf("неверный порог ошибок: %q (ожидается число файлов или доля от 0%% до 100%%)", value)
+	}
+	return FailureThreshold{limit: limit, percent: percent, set: true}, nil
+}
+
+// Exceeded возвращает true, если неудачных файлов больше, чем допускает порог
+func (t FailureThreshold) Exceeded(failed, total int) bool {
+	if !t.set || total == 0 {
+		return false
+	}
+	if t.percent {
+		return failed*100 > t.limit*total
+	}
+	return failed > t.limit
+}
+
+// String возвращает порог в исходной записи
+func (t FailureThreshold) String() string {
+	switch {
+	case !t.set:
+		return ""
+	case t.percent:
+		return fmt.Sprintf("%d%%", t.limit)
+	default:
+		return strconv.Itoa(t.limit)
+	}
+}
+
+// failureThresholdFor возвращает порог ошибок задачи: собственный порог задачи или порог из конфигурации
+func (u *DownloadUsecase) failureThresholdFor(task *entities.Task) FailureThreshold {
+	if task.FailureThreshold == "" {
+		return u.failures
+	}
+	threshold, err := ParseFailureThreshold(task.FailureThreshold)
+	if err != nil {
+		log.Printf("Задача %s: %v, используется порог из конфигурации", task.LogID(), err)
+		return u.failures
+	}
+	return threshold
+}
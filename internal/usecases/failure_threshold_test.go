@@ -0,0 +1,197 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestParseFailureThreshold(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{"", "", false},
+		{"3", "3", false},
+		{" 50% ", "50%", false},
+		{"0", "0", false},
+		{"101%", "", true},
+		{"-1", "", true},
+		{"many", "", true},
+	}
+
+	for _, tc := range testCases {
+		// Execute
+		threshold, err := ParseFailureThreshold(tc.value)
+
+		// Assert
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Expected error %v for %q, got %v", tc.wantErr, tc.value, err)
+		}
+		if threshold.String() != tc.expected {
+			t.Errorf("Expected threshold %q for %q, got %q", tc.expected, tc.value, threshold.String())
+		}
+	}
+}
+
+func TestFailureThresholdExceeded(t *testing.T) {
+	testCases := []struct {
+		threshold string
+		failed    int
+		total     int
+		expected  bool
+	}{
+		{"", 10, 10, false},
+		{"2", 2, 10, false},
+		{"2", 3, 10, true},
+		{"0", 1, 10, true},
+		{"50%", 5, 10, false},
+		{"50%", 6, 10, true},
+		{"100%", 10, 10, false},
+	}
+
+	for _, tc := range testCases {
+		threshold, _ := ParseFailureThreshold(tc.threshold)
+		if actual := threshold.Exceeded(tc.failed, tc.total); actual != tc.expected {
+			t.Errorf("Expected %v for %d/%d with threshold %q, got %v", tc.expected, tc.failed, tc.total, tc.threshold, actual)
+		}
+	}
+}
+
+func TestProcessTaskAbortsAfterFailureThreshold(t *testing.T) {
+	testCases := []struct {
+		name           string
+		global         string
+		taskThreshold  string
+		expectedStatus entities.TaskStatus
+		expectedFetch  int
+	}{
+		{"no threshold downloads every file", "", "", entities.TaskStatusPartial, 5},
+		{"global threshold aborts", "1", "", entities.TaskStatusFailed, 2},
+		{"task threshold overrides global", "1", "100%", entities.TaskStatusPartial, 5},
+		{"task percent threshold aborts", "", "20%", entities.TaskStatusFailed, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			var fetched int
+			tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				fetched++
+				if strings.HasSuffix(req.URL.Path, "ok.bin") {
+					return cannedResponse(req, http.StatusOK, "data", nil), nil
+				}
+				return cannedResponse(req, http.StatusUnauthorized, "", nil), nil
+			})
+			global, _ := ParseFailureThreshold(tc.global)
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(t.TempDir()),
+				WithRoundTripper(tripper),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+				WithFailureThreshold(global))
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{
+				"https://example.com/1.bin", "https://example.com/2.bin", "https://example.com/3.bin",
+				"https://example.com/4.bin", "https://example.com/ok.bin",
+			}, time.Now())
+			for i, url := range task.URLs {
+				task.Files[i] = entities.File{URL: url, Status: "pending"}
+			}
+			task.FailureThreshold = tc.taskThreshold
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			if task.Status != tc.expectedStatus {
+				t.Errorf("Expected task status %s, got %s (%s)", tc.expectedStatus, task.Status, task.Error)
+			}
+			if fetched != tc.expectedFetch {
+				t.Errorf("Expected %d fetches, got %d", tc.expectedFetch, fetched)
+			}
+			if tc.expectedStatus != entities.TaskStatusFailed {
+				return
+			}
+			if task.ErrorKind != entities.ErrorKindFailureThreshold {
+				t.Errorf("Expected task error kind %s, got %s", entities.ErrorKindFailureThreshold, task.ErrorKind)
+			}
+			last := task.Files[len(task.Files)-1]
+			if last.Status != "failed" || last.ErrorKind != entities.ErrorKindFailureThreshold {
+				t.Errorf("Expected remaining file to be cancelled, got %s/%s", last.Status, last.ErrorKind)
+			}
+		})
+	}
+}
+
+func TestProcessTaskFailureThresholdCancelsRunningDownloads(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, "slow.bin") {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return cannedResponse(req, http.StatusForbidden, "", nil), nil
+	})
+	threshold, _ := ParseFailureThreshold("0")
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithFileConcurrency(2),
+		WithFailureThreshold(threshold))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/slow.bin", "https://example.com/denied.bin"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	done := make(chan error, 1)
+	go func() { done <- usecase.ProcessTask(ctx, task) }()
+
+	// Assert
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected running download to be cancelled after the threshold was exceeded")
+	}
+	if task.Status != entities.TaskStatusFailed || !strings.Contains(task.Error, entities.ErrFailureThreshold.Error()) {
+		t.Errorf("Expected task to fail with threshold error, got %s (%s)", task.Status, task.Error)
+	}
+	if task.Files[0].ErrorKind != entities.ErrorKindFailureThreshold {
+		t.Errorf("Expected cancelled file error kind %s, got %s", entities.ErrorKindFailureThreshold, task.Files[0].ErrorKind)
+	}
+}
+
+func TestCreateTaskRejectsInvalidFailureThreshold(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	spec := entities.NewTaskSpec([]string{"https://example.com/file.jpg"})
+	spec.FailureThreshold = "150%"
+
+	// Execute
+	_, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+	// Assert
+	if !errors.Is(err, entities.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+}
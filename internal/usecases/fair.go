@@ -0,0 +1,174 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+
+	"file-downloader/internal/entities"
+)
+
+// fairScheduler распределяет общее число одновременно скачиваемых файлов между задачами.
+// Освободившийся слот получает ожидающая задача с наименьшим числом скачиваемых файлов, а среди равных -
+// дольше всех ожидающая, поэтому задача с тысячей файлов не занимает все слоты, пока ждут задачи поменьше.
+type fairScheduler struct {
+	mu       sync.Mutex
+	capacity int
+	used     int
+	inFlight map[string]int             // скачиваемые файлы задач
+	waiters  map[string][]chan struct{} // ожидающие слота файлы задач в порядке запросов
+	queue    []string                   // задачи с ожидающими файлами в порядке ожидания
+}
+
+// WithFairFileSlots ограничивает общее число одновременно скачиваемых файлов всех задач значением slots
+// и распределяет слоты между задачами поровну. 0 отключает общее ограничение: каждая задача скачивает
+// столько файлов одновременно, сколько разрешает её собственное ограничение.
+func WithFairFileSlots(slots int) DownloadOption {
+	return func(u *DownloadUsecase) {
+		if slots > 0 {
+			u.fairSlots = slots
+		}
+	}
+}
+
+// newFairScheduler создает распределитель capacity слотов скачивания
+func newFairScheduler(capacity int) *fairScheduler {
+	return &fairScheduler{
+		capacity: capacity,
+		inFlight: make(map[string]int),
+		waiters:  make(map[string][]chan struct{}),
+	}
+}
+
+// acquire ждет слот скачивания файла задачи и возвращает функцию его освобождения.
+// При отмене контекста ожидание прекращается и возвращается ошибка контекста.
+func (s *fairScheduler) acquire(ctx context.Context, taskID string) (func(), error) {
+	s.mu.Lock()
+	if s.used < s.capacity && len(s.queue) == 0 {
+		s.grantLocked(taskID)
+		s.mu.Unlock()
+		return s.releaser(taskID), nil
+	}
+
+	granted := make(chan struct{})
+	if len(s.waiters[taskID]) == 0 {
+		s.queue = append(s.queue, taskID)
+	}
+	s.waiters[taskID] = append(s.waiters[taskID], granted)
+	s.mu.Unlock()
+
+	select {
+	case <-granted:
+		return s.releaser(taskID), nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-granted:
+		// Слот выдан одновременно с отменой: он возвращается следующей задаче
+		s.releaseLocked(taskID)
+	default:
+		s.removeWaiterLocked(taskID, granted)
+	}
+	return nil, ctx.Err()
+}
+
+// inFlightFor возвращает число скачиваемых файлов задачи
+func (s *fairScheduler) inFlightFor(taskID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight[taskID]
+}
+
+// releaser возвращает функцию освобождения слота, которую безопасно вызвать несколько раз
+func (s *fairScheduler) releaser(taskID string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.releaseLocked(taskID)
+		})
+	}
+}
+
+// grantLocked занимает слот для файла задачи (вызывающий должен держать блокировку)
+func (s *fairScheduler) grantLocked(taskID string) {
+	s.used++
+	s.inFlight[taskID]++
+}
+
+// releaseLocked освобождает слот задачи и передает свободные слоты ожидающим (вызывающий должен держать блокировку)
+func (s *fairScheduler) releaseLocked(taskID string) {
+	s.used--
+	if s.inFlight[taskID]--; s.inFlight[taskID] <= 0 {
+		delete(s.inFlight, taskID)
+	}
+
+	for s.used < s.capacity && len(s.queue) > 0 {
+		// Задача с наименьшим числом скачиваемых файлов; среди равных - раньше вставшая в очередь
+		next := 0
+		for i, id := range s.queue {
+			if s.inFlight[id] < s.inFlight[s.queue[next]] {
+				next = i
+			}
+		}
+		id := s.queue[next]
+		s.queue = append(s.queue[:next], s.queue[next+1:]...)
+
+		waiters := s.waiters[id]
+		granted := waiters[0]
+		if len(waiters) > 1 {
+			s.waiters[id] = waiters[1:]
+			// Задача с оставшимися ожидающими файлами встает в конец очереди
+			s.queue = append(s.queue, id)
+		} else {
+			delete(s.waiters, id)
+		}
+
+		s.grantLocked(id)
+		close(granted)
+	}
+}
+
+// removeWaiterLocked убирает отмененное ожидание слота (вызывающий должен держать блокировку)
+func (s *fairScheduler) removeWaiterLocked(taskID string, granted chan struct{}) {
+	waiters := s.waiters[taskID]
+	for i, waiter := range waiters {
+		if waiter == granted {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) > 0 {
+		s.waiters[taskID] = waiters
+		return
+	}
+
+	delete(s.waiters, taskID)
+	for i, id := range s.queue {
+		if id == taskID {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			break
+		}
+	}
+}
+
+// acquireFileSlot занимает общий слот скачивания файла задачи, если общее ограничение включено.
+// При нехватке места на диске слот занимается только после его освобождения.
+func (u *DownloadUsecase) acquireFileSlot(ctx context.Context, task *entities.Task) (func(), error) {
+	if u.diskSpace != nil {
+		if err := u.diskSpace.WaitForSpace(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if u.fair == nil {
+		return u.active.track(func() {}), nil
+	}
+	release, err := u.fair.acquire(ctx, task.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	return u.active.track(release), nil
+}
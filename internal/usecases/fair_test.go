@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// acquireAsync requests a slot in the background and reports the grant on the returned channel
+func acquireAsync(ctx context.Context, s *fairScheduler, taskID string) <-chan func() {
+	granted := make(chan func(), 1)
+	go func() {
+		if release, err := s.acquire(ctx, taskID); err == nil {
+			granted <- release
+		}
+	}()
+	return granted
+}
+
+// waitForWaiters waits until the task has the given number of queued slot requests
+func waitForWaiters(t *testing.T, s *fairScheduler, taskID string, count int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		queued := len(s.waiters[taskID])
+		s.mu.Unlock()
+		if queued == count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d waiters for task %s", count, taskID)
+}
+
+func TestFairSchedulerPrefersTaskWithFewerFiles(t *testing.T) {
+	// Setup
+	scheduler := newFairScheduler(2)
+	ctx := context.Background()
+	release, err := scheduler.acquire(ctx, "big")
+	if err != nil {
+		t.Fatalf("Expected first slot to be granted, got %v", err)
+	}
+	releaseOther, err := scheduler.acquire(ctx, "big")
+	if err != nil {
+		t.Fatalf("Expected second slot to be granted, got %v", err)
+	}
+	defer releaseOther()
+	bigGranted := acquireAsync(ctx, scheduler, "big")
+	waitForWaiters(t, scheduler, "big", 1)
+	smallGranted := acquireAsync(ctx, scheduler, "small")
+	waitForWaiters(t, scheduler, "small", 1)
+
+	// Execute
+	release()
+
+	// Assert
+	select {
+	case releaseSmall := <-smallGranted:
+		if scheduler.inFlightFor("small") != 1 || scheduler.inFlightFor("big") != 1 {
+			t.Errorf("Expected each task to hold one slot, got small=%d big=%d",
+				scheduler.inFlightFor("small"), scheduler.inFlightFor("big"))
+		}
+		releaseSmall()
+	case <-bigGranted:
+		t.Fatal("Expected the freed slot to go to the task with fewer files in flight")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the freed slot to be granted")
+	}
+	select {
+	case releaseBig := <-bigGranted:
+		releaseBig()
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the big task to get the slot after the small one")
+	}
+}
+
+func TestFairSchedulerDropsCancelledWaiter(t *testing.T) {
+	// Setup
+	scheduler := newFairScheduler(1)
+	release, err := scheduler.acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("Expected first slot to be granted, got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := scheduler.acquire(ctx, "b")
+		done <- err
+	}()
+	waitForWaiters(t, scheduler, "b", 1)
+
+	// Execute
+	cancel()
+	err = <-done
+	release()
+
+	// Assert
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if scheduler.inFlightFor("b") != 0 {
+		t.Errorf("Expected cancelled task to hold no slots, got %d", scheduler.inFlightFor("b"))
+	}
+	if next, err := scheduler.acquire(context.Background(), "c"); err != nil {
+		t.Errorf("Expected the slot to be free after the cancelled waiter left, got %v", err)
+	} else {
+		next()
+	}
+}
+
+// gatedDiskSpace blocks WaitForSpace until open is closed
+type gatedDiskSpace struct {
+	open chan struct{}
+}
+
+func (g *gatedDiskSpace) WaitForSpace(ctx context.Context) error {
+	select {
+	case <-g.open:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestAcquireFileSlotWaitsForDiskSpace(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	gate := &gatedDiskSpace{open: make(chan struct{})}
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDiskSpaceGate(gate)).(*DownloadUsecase)
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+	granted := make(chan func(), 1)
+
+	// Execute
+	go func() {
+		if release, err := usecase.acquireFileSlot(context.Background(), task); err == nil {
+			granted <- release
+		}
+	}()
+
+	// Assert
+	select {
+	case <-granted:
+		t.Fatal("Expected no slot while disk space is low")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if active := usecase.ResourceUsage().ActiveFiles; active != 0 {
+		t.Errorf("Expected no active files while waiting, got %d", active)
+	}
+
+	// Execute: space is reclaimed
+	close(gate.open)
+
+	// Assert
+	select {
+	case release := <-granted:
+		release()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected slot after disk space is reclaimed")
+	}
+}
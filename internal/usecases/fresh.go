@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// markDownloaded запоминает время и расположение успешно скачанного файла для проверки окна свежести
+func (u *DownloadUsecase) markDownloaded(file *entities.File) {
+	now := u.clock.Now()
+	file.LastDownloadedAt = &now
+	file.LastPath = file.Path
+	file.LastCompression = file.Compression
+}
+
+// reuseFresh отмечает файл скачанным без запроса к источнику, если задача задает окно свежести, а файл успешно
+// скачан в его пределах и сохранился на диске. Размер и контрольная сумма вычисляются заново по файлу на диске;
+// файл, не совпадающий с ожидаемыми размером или контрольной суммой, скачивается заново.
+func (u *DownloadUsecase) reuseFresh(batch *fileBatch, task *entities.Task, fileIndex int) bool {
+	file := &task.Files[fileIndex]
+	window := time.Duration(task.FreshnessWindow)
+	if window <= 0 || file.LastDownloadedAt == nil || file.LastPath == "" {
+		return false
+	}
+	age := u.clock.Now().Sub(*file.LastDownloadedAt)
+	if age >= window {
+		return false
+	}
+
+	info, err := os.Stat(file.LastPath)
+	if err != nil || !info.Mode().IsRegular() || !batch.reservePath(fileIndex, file.LastPath) {
+		return false
+	}
+	written, checksum, err := contentSHA256(file.LastPath, file.LastCompression)
+	if err != nil || (file.ExpectedSize > 0 && written != file.ExpectedSize) ||
+		(file.ExpectedSHA256 != "" && !strings.EqualFold(checksum, file.ExpectedSHA256)) {
+		return false
+	}
+
+	file.Path = file.LastPath
+	file.Compression = file.LastCompression
+	file.Size = written
+	file.Downloaded = written
+	file.SHA256 = checksum
+	file.StoredSize = 0
+	if file.Compression != "" {
+		file.StoredSize = info.Size()
+	} else {
+		file.ContentType = contentType("", fileHead(file.Path))
+	}
+	file.Status = "completed"
+	// Файл занимает место на диске так же, как скачанный: превышение квоты отменяет остальные скачивания
+	batch.quota.add(info.Size())
+
+	log.Printf("Задача %s: файл %s скачан %v назад (окно свежести %v), повторное скачивание пропущено",
+		task.LogID(), file.Path, age.Round(time.Second), window)
+	return true
+}
@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestProcessTaskReusesFreshFiles(t *testing.T) {
+	testCases := []struct {
+		name          string
+		elapsed       time.Duration
+		expectedFetch int32
+	}{
+		{"file within the window is reused", 10 * time.Minute, 1},
+		{"file outside the window is downloaded again", 2 * time.Hour, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			clock := testutil.NewFakeClock(time.Now())
+			var fetched atomic.Int32
+			tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				fetched.Add(1)
+				return cannedResponse(req, http.StatusOK, "data", nil), nil
+			})
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(t.TempDir()),
+				WithLayout(LayoutFlat),
+				WithRoundTripper(tripper),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+				WithClock(clock))
+			ctx := context.Background()
+			urls := []string{"https://example.com/kept.bin", "https://example.com/removed.bin"}
+			task := entities.NewTask(urls, clock.Now())
+			for i, url := range urls {
+				task.Files[i] = entities.File{URL: url, Status: "pending"}
+			}
+			task.FreshnessWindow = entities.Duration(time.Hour)
+			mockRepo.Create(ctx, task)
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			first := task.Files[0]
+			os.Remove(task.Files[1].Path)
+
+			// Execute: re-run the task after the files are reset
+			clock.Advance(tc.elapsed)
+			fetched.Store(0)
+			for i := range task.Files {
+				task.Files[i] = task.Files[i].Pending()
+			}
+			task.UpdateStatus(entities.TaskStatusNew, clock.Now())
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			if got := fetched.Load(); got != tc.expectedFetch {
+				t.Errorf("Expected %d fetches, got %d", tc.expectedFetch, got)
+			}
+			if task.Status != entities.TaskStatusCompleted {
+				t.Fatalf("Expected completed task, got %s (%s)", task.Status, task.Error)
+			}
+			reused := task.Files[0]
+			if reused.Path != first.Path || reused.Size != first.Size || reused.SHA256 != first.SHA256 {
+				t.Errorf("Expected file to match the previous download %s/%d/%s, got %s/%d/%s",
+					first.Path, first.Size, first.SHA256, reused.Path, reused.Size, reused.SHA256)
+			}
+			if _, err := os.Stat(task.Files[1].Path); err != nil {
+				t.Errorf("Expected missing file to be downloaded again, got %v", err)
+			}
+		})
+	}
+}
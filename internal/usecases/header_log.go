@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// redactedValue заменяет в журнале значения чувствительных заголовков
+const redactedValue = "[скрыто]"
+
+// sensitiveHeaders - заголовки с учетными данными, значения которых не пишутся в журнал
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// sensitiveHeaderParts - части имен заголовков, по которым заголовок считается чувствительным (X-Api-Key, X-Auth-Token)
+var sensitiveHeaderParts = []string{"token", "secret", "password", "key", "signature", "auth"}
+
+// sensitiveQueryParams - параметры запроса с учетными данными (подписи presigned-ссылок S3, GCS, Azure и токены),
+// значения которых скрываются в журнале и ошибках независимо от настроек подписи URLSigner
+var sensitiveQueryParams = map[string]bool{
+	"x-amz-signature":      true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+	"x-goog-signature":     true,
+	"x-goog-credential":    true,
+	"signature":            true,
+	"sig":                  true,
+	"token":                true,
+	"access_token":         true,
+	"api_key":              true,
+	"apikey":               true,
+	"key":                  true,
+	"secret":               true,
+	"password":             true,
+}
+
+// redactedQueryValue заменяет значения чувствительных параметров URL, как пароль в url.URL.Redacted
+const redactedQueryValue = "xxxxx"
+
+// redactURL скрывает в URL пароль, параметры подписи signer и значения параметров из sensitiveQueryParams
+func redactURL(signer URLSigner, rawURL string) string {
+	rawURL = signer.Redact(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	query := u.Query()
+	redacted := false
+	for name, values := range query {
+		if !sensitiveQueryParams[strings.ToLower(name)] {
+			continue
+		}
+		for i := range values {
+			values[i] = redactedQueryValue
+		}
+		redacted = true
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
+// isSensitiveHeader возвращает true, если значение заголовка нельзя писать в журнал
+func isSensitiveHeader(name string) bool {
+	if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, part := range sensitiveHeaderParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHeaders форматирует заголовки для журнала в порядке имен, скрывая значения чувствительных заголовков
+func redactHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range header[name] {
+			if isSensitiveHeader(name) {
+				value = redactedValue
+			}
+			parts = append(parts, name+": "+value)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// headerLoggingTransport пишет в журнал заголовки каждого HTTP-запроса скачивания и ответа на него,
+// включая перенаправления. Чувствительные заголовки, подпись и учетные данные в URL скрываются.
+type headerLoggingTransport struct {
+	next   http.RoundTripper
+	signer URLSigner
+}
+
+// RoundTrip выполняет запрос через следующий транспорт и пишет заголовки в журнал
+func (t headerLoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := redactURL(t.signer, req.URL.String())
+	log.Printf("Отладка: запрос %s %s: %s", req.Method, target, redactHeaders(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		log.Printf("Отладка: запрос %s %s завершился ошибкой: %v", req.Method, target, err)
+		return nil, err
+	}
+	log.Printf("Отладка: ответ %s на %s %s: %s", resp.Status, req.Method, target, redactHeaders(resp.Header))
+	return resp, nil
+}
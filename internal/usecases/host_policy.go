@@ -0,0 +1,52 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// HostPolicy ограничивает хосты, с которых разрешено скачивание.
+// Шаблон "example.com" совпадает только с этим хостом, "*.example.com" - с любым его поддоменом.
+// Запрещающие шаблоны проверяются первыми; пустой список разрешенных допускает любой хост.
+type HostPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// Check возвращает ошибку, если скачивание с хоста запрещено политикой
+func (p HostPolicy) Check(host string) error {
+	host = normalizeHost(host)
+
+	for _, pattern := range p.Deny {
+		if matchHost(pattern, host) {
+			return fmt.Errorf("%w: %s", entities.ErrHostNotAllowed, host)
+		}
+	}
+
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range p.Allow {
+		if matchHost(pattern, host) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", entities.ErrHostNotAllowed, host)
+}
+
+// matchHost сравнивает хост с шаблоном политики
+func matchHost(pattern, host string) bool {
+	pattern = normalizeHost(pattern)
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return pattern != "" && pattern == host
+}
+
+// normalizeHost приводит имя хоста к нижнему регистру без завершающей точки
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
@@ -0,0 +1,98 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestHostPolicyCheck(t *testing.T) {
+	policy := HostPolicy{
+		Allow: []string{"example.com", "*.cdn.example.org"},
+		Deny:  []string{"blocked.cdn.example.org"},
+	}
+
+	cases := []struct {
+		host    string
+		allowed bool
+	}{
+		{"example.com", true},
+		{"EXAMPLE.com.", true},
+		{"sub.example.com", false},
+		{"img.cdn.example.org", true},
+		{"cdn.example.org", false},
+		{"blocked.cdn.example.org", false},
+		{"evil.com", false},
+	}
+
+	for _, tc := range cases {
+		err := policy.Check(tc.host)
+		if tc.allowed && err != nil {
+			t.Errorf("Expected host %s to be allowed, got %v", tc.host, err)
+		}
+		if !tc.allowed && !errors.Is(err, entities.ErrHostNotAllowed) {
+			t.Errorf("Expected ErrHostNotAllowed for host %s, got %v", tc.host, err)
+		}
+	}
+
+	if err := (HostPolicy{}).Check("anything.test"); err != nil {
+		t.Errorf("Expected empty policy to allow any host, got %v", err)
+	}
+}
+
+func TestCreateTaskRejectsDisallowedHost(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithHostValidation(HostPolicy{Allow: []string{"example.com"}}))
+
+	// Execute
+	_, err := usecase.CreateTask(context.Background(), []string{"https://example.com/a.jpg", "https://evil.com/b.jpg"})
+
+	// Assert
+	var validationErr *entities.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Errors) != 1 || validationErr.Errors[0].Index != 1 {
+		t.Errorf("Expected only URL at index 1 to be rejected, got %+v", validationErr.Errors)
+	}
+}
+
+func TestDownloadFileRejectsRedirectToDisallowedHost(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Hostname() == "example.com" {
+			header := http.Header{"Location": []string{"http://169.254.169.254/latest/meta-data"}}
+			return cannedResponse(req, http.StatusFound, "", header), nil
+		}
+		return cannedResponse(req, http.StatusOK, "secret", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithHostPolicy(HostPolicy{Allow: []string{"example.com"}}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if !errors.Is(err, entities.ErrHostNotAllowed) {
+		t.Fatalf("Expected ErrHostNotAllowed, got %v", err)
+	}
+	if task.Files[0].Status != "failed" {
+		t.Errorf("Expected status failed, got %s", task.Files[0].Status)
+	}
+	if isRetryable(err) {
+		t.Error("Expected disallowed host error not to be retryable")
+	}
+}
@@ -0,0 +1,417 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// DefaultUserAgent - значение User-Agent по умолчанию
+const DefaultUserAgent = "file-downloader/1.0"
+
+// HTTPFetcherConfig задает параметры HTTP-запросов
+type HTTPFetcherConfig struct {
+	UserAgent  string
+	Headers    map[string]string // заголовки, добавляемые к каждому запросу
+	HostPolicy HostPolicy        // проверяется для каждого перенаправления
+	Timeouts   HTTPTimeouts
+	Signer     URLSigner // подпись URL запросов к хостам, требующим подписи
+	LogHeaders bool      // писать в журнал заголовки запросов и ответов для отладки
+	Protocol   string    // выбор протокола: HTTPProtocolAuto (по умолчанию) или HTTPProtocolHTTP1
+
+	requests *requestLimiter  // ограничение частоты запросов (nil - без ограничения); задается use case'ом скачивания
+	clock    interfaces.Clock // часы для срока действия подписи (nil - системные); задаются use case'ом скачивания
+}
+
+const (
+	// HTTPProtocolAuto разрешает HTTP/2, если сервер выбирает его при TLS-рукопожатии (ALPN), иначе HTTP/1.1
+	HTTPProtocolAuto = "auto"
+	// HTTPProtocolHTTP1 всегда использует HTTP/1.1 - для серверов, которые работают с HTTP/2 некорректно
+	HTTPProtocolHTTP1 = "http1"
+)
+
+// ParseHTTPProtocol проверяет выбор протокола; пустое значение означает HTTPProtocolAuto
+func ParseHTTPProtocol(value string) (string, error) {
+	switch value {
+	case "", HTTPProtocolAuto:
+		return HTTPProtocolAuto, nil
+	case HTTPProtocolHTTP1:
+		return HTTPProtocolHTTP1, nil
+	default:
+		return "", fmt.Errorf("неизвестный протокол HTTP %q: допустимы %s и %s", value, HTTPProtocolAuto, HTTPProtocolHTTP1)
+	}
+}
+
+// HTTPTimeouts задает таймауты этапов HTTP-запроса (0 отключает соответствующий таймаут).
+// Подключение и заголовки ответа ограничиваются коротко, а передача тела - только по простою,
+// поэтому медленное соединение обрывается быстро, а большой, но идущий файл скачивается до конца.
+type HTTPTimeouts struct {
+	Connect        time.Duration // установка TCP-соединения, включая разрешение имени
+	TLSHandshake   time.Duration // TLS-рукопожатие
+	ResponseHeader time.Duration // ожидание заголовков ответа после отправки запроса
+	FirstByte      time.Duration // ожидание первых байт тела после заголовков (0 - ограничивается Idle)
+	Idle           time.Duration // максимальное время без новых данных при чтении тела ответа
+	Total          time.Duration // общее время запроса, включая чтение тела
+	RedirectHop    time.Duration // ожидание ответа на каждый запрос цепочки перенаправлений, включая подключение
+	RedirectTotal  time.Duration // ожидание заголовков окончательного ответа, если сервер перенаправил запрос, считая от его начала
+}
+
+// DefaultHTTPTimeouts возвращает таймауты HTTP-запросов по умолчанию: без общего ограничения времени скачивания
+func DefaultHTTPTimeouts() HTTPTimeouts {
+	return HTTPTimeouts{
+		Connect:        10 * time.Second,
+		TLSHandshake:   10 * time.Second,
+		ResponseHeader: 30 * time.Second,
+		FirstByte:      30 * time.Second,
+		Idle:           time.Minute,
+		RedirectHop:    time.Minute,
+		RedirectTotal:  2 * time.Minute,
+	}
+}
+
+// HTTPFetcher реализует Fetcher для схем http и https
+type HTTPFetcher struct {
+	client *http.Client
+	config HTTPFetcherConfig
+}
+
+// NewHTTPFetcher создает новый HTTP fetcher с указанным транспортом.
+// Если transport равен nil, используется http.DefaultTransport. Таймауты подключения, TLS-рукопожатия
+// и заголовков ответа, а также выбор протокола применяются к копии транспорта, если это *http.Transport.
+func NewHTTPFetcher(transport http.RoundTripper, config HTTPFetcherConfig) *HTTPFetcher {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent
+	}
+	if config.clock == nil {
+		config.clock = systemClock{}
+	}
+	if base, ok := transport.(*http.Transport); ok {
+		tuned := config.Timeouts.apply(base)
+		tuned.Protocols = config.protocols()
+		transport = tuned
+	}
+	if config.LogHeaders {
+		transport = headerLoggingTransport{next: transport, signer: config.Signer}
+	}
+	if config.Timeouts.RedirectHop > 0 {
+		transport = hopTimeoutTransport{next: transport, timeout: config.Timeouts.RedirectHop}
+	}
+
+	return &HTTPFetcher{
+		client: &http.Client{
+			Transport:     transport,
+			Timeout:       config.Timeouts.Total,
+			CheckRedirect: config.checkRedirect,
+		},
+		config: config,
+	}
+}
+
+// apply возвращает копию транспорта с таймаутами подключения, TLS-рукопожатия и заголовков ответа
+func (t HTTPTimeouts) apply(base *http.Transport) *http.Transport {
+	transport := base.Clone()
+	if t.TLSHandshake > 0 {
+		transport.TLSHandshakeTimeout = t.TLSHandshake
+	}
+	if t.ResponseHeader > 0 {
+		transport.ResponseHeaderTimeout = t.ResponseHeader
+	}
+	if t.Connect > 0 {
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		// Истечение контекста после подключения не влияет на установленное соединение
+		transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			ctx, cancel := context.WithTimeout(ctx, t.Connect)
+			defer cancel()
+			return dial(ctx, network, address)
+		}
+	}
+	return transport
+}
+
+// protocols возвращает протоколы транспорта. HTTP/2 включается явно: транспорт с собственной
+// функцией подключения и без ForceAttemptHTTP2 сам его не согласует
+func (c HTTPFetcherConfig) protocols() *http.Protocols {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if c.Protocol != HTTPProtocolHTTP1 {
+		protocols.SetHTTP2(true)
+	}
+	return protocols
+}
+
+// checkRedirect запрещает перенаправления на хосты, не разрешенные политикой, и на первом перенаправлении
+// запускает ограничение времени всей цепочки
+func (c HTTPFetcherConfig) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("слишком много перенаправлений")
+	}
+	if err := c.HostPolicy.Check(req.URL.Hostname()); err != nil {
+		return err
+	}
+	if budget, ok := req.Context().Value(redirectBudgetKey{}).(*redirectBudget); ok {
+		return budget.start()
+	}
+	return nil
+}
+
+// redirectBudgetKey - ключ контекста запроса, под которым хранится ограничение времени цепочки перенаправлений
+type redirectBudgetKey struct{}
+
+// redirectBudget ограничивает время до заголовков окончательного ответа, считая от начала запроса.
+// Таймер запускается только на первом перенаправлении, поэтому запросы без перенаправлений ограничиваются
+// лишь обычными таймаутами. start и stop вызываются из горутины http.Client.Do последовательно.
+type redirectBudget struct {
+	total   time.Duration
+	started time.Time
+	cancel  context.CancelFunc
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// start запускает таймер на оставшуюся часть ограничения, если он еще не запущен
+func (b *redirectBudget) start() error {
+	if b.timer != nil {
+		return nil
+	}
+	remaining := b.total - time.Since(b.started)
+	if remaining <= 0 {
+		b.expired.Store(true)
+		return entities.ErrRedirectTimeout
+	}
+	b.timer = time.AfterFunc(remaining, func() {
+		b.expired.Store(true)
+		b.cancel()
+	})
+	return nil
+}
+
+// stop останавливает таймер и сообщает, было ли ограничение исчерпано
+func (b *redirectBudget) stop() bool {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b.expired.Load()
+}
+
+// Fetch выполняет GET-запрос и возвращает тело ответа.
+// Заголовки запроса переопределяют заголовки по умолчанию.
+func (f *HTTPFetcher) Fetch(ctx context.Context, fetchReq interfaces.FetchRequest) (*interfaces.FetchResult, error) {
+	req, err := f.newRequest(ctx, fetchReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.do(req, http.StatusOK)
+}
+
+// FetchRange выполняет GET-запрос диапазона байт с start по end включительно
+func (f *HTTPFetcher) FetchRange(ctx context.Context, fetchReq interfaces.FetchRequest, start, end int64) (*interfaces.FetchResult, error) {
+	req, err := f.newRequest(ctx, fetchReq)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	return f.do(req, http.StatusPartialContent)
+}
+
+// newRequest дожидается разрешения ограничителя частоты и создает GET-запрос с заголовками по умолчанию и заголовками задачи
+func (f *HTTPFetcher) newRequest(ctx context.Context, fetchReq interfaces.FetchRequest) (*http.Request, error) {
+	// Очередь на запрос ожидается до подписи и до начала отсчета таймаутов запроса; перенаправления не ограничиваются
+	if err := f.config.requests.wait(ctx); err != nil {
+		return nil, fmt.Errorf("не удалось дождаться очереди на запрос: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fetchReq.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать запрос: %w", err)
+	}
+
+	req.Header.Set("User-Agent", f.config.UserAgent)
+	for name, value := range f.config.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range fetchReq.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Подпись вычисляется непосредственно перед запросом, поэтому срок её действия отсчитывается от него
+	f.config.Signer.Sign(req.URL, f.config.clock.Now())
+
+	return req, nil
+}
+
+// do выполняет запрос и проверяет, что сервер ответил ожидаемым статусом
+func (f *HTTPFetcher) do(req *http.Request, expectedStatus int) (*interfaces.FetchResult, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+
+	// Медленный сервер не должен растягивать ожидание, отвечая на каждом перенаправлении почти на пределе таймаутов
+	var budget *redirectBudget
+	if f.config.Timeouts.RedirectTotal > 0 {
+		budget = &redirectBudget{total: f.config.Timeouts.RedirectTotal, started: time.Now(), cancel: cancel}
+		ctx = context.WithValue(ctx, redirectBudgetKey{}, budget)
+	}
+
+	resp, err := f.client.Do(req.WithContext(ctx))
+	if budget != nil && budget.stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, fmt.Errorf("не удалось скачать: %w: ответ не получен за %v", entities.ErrRedirectTimeout, f.config.Timeouts.RedirectTotal)
+	}
+	if err != nil {
+		cancel()
+		// Ошибка сохраняется в задаче и пишется в журнал: подпись и учетные данные из URL в неё не попадают
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(f.config.Signer, urlErr.URL)
+		}
+		return nil, fmt.Errorf("не удалось скачать: %w", err)
+	}
+
+	if resp.StatusCode != expectedStatus {
+		resp.Body.Close()
+		cancel()
+		return nil, &entities.HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return &interfaces.FetchResult{
+		Body:               newIdleBody(resp.Body, f.config.Timeouts, cancel),
+		Size:               resp.ContentLength,
+		ContentDisposition: resp.Header.Get("Content-Disposition"),
+		ContentType:        resp.Header.Get("Content-Type"),
+		AcceptRanges:       resp.Header.Get("Accept-Ranges") == "bytes",
+		Protocol:           resp.Proto,
+		ETag:               resp.Header.Get("ETag"),
+		LastModified:       resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// hopTimeoutTransport ограничивает ожидание ответа на каждый запрос цепочки перенаправлений.
+// Ограничение снимается, как только получены заголовки, поэтому не обрывает чтение тела.
+type hopTimeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+// RoundTrip выполняет один запрос цепочки с ограничением времени до заголовков ответа
+func (t hopTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	var expired atomic.Bool
+	timer := time.AfterFunc(t.timeout, func() {
+		expired.Store(true)
+		cancel()
+	})
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	// Таймер мог сработать уже после получения заголовков: такой ответ тоже отбрасывается
+	if !timer.Stop() && expired.Load() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, fmt.Errorf("%w: %s не ответил за %v", entities.ErrRedirectTimeout, req.URL.Host, t.timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose освобождает контекст запроса при закрытии тела ответа
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close закрывает тело ответа и освобождает контекст запроса
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// errIdleTimeout возвращается при чтении тела ответа, если данные не поступали дольше таймаута простоя
+var errIdleTimeout = fmt.Errorf("нет данных от источника: %w", context.DeadlineExceeded)
+
+// errFirstByteTimeout возвращается, если после заголовков ответа тело не начало поступать вовремя
+var errFirstByteTimeout = fmt.Errorf("источник прислал заголовки, но не начал передавать данные: %w", context.DeadlineExceeded)
+
+// idleBody прерывает запрос, если чтение тела ответа не получает первых байт дольше таймаута первого байта
+// или новых данных дольше таймаута простоя
+type idleBody struct {
+	io.ReadCloser
+	timeout   time.Duration // таймаут простоя после первых байт
+	firstByte time.Duration // таймаут ожидания первых байт
+	received  bool          // первые байты тела получены
+	timer     *time.Timer
+	cancel    context.CancelFunc
+	expired   atomic.Bool
+}
+
+// newIdleBody оборачивает тело ответа таймаутами первого байта и простоя; при нулевом таймауте первого байта
+// первые байты ожидаются не дольше таймаута простоя, а при обоих нулевых ожидание не ограничивается
+func newIdleBody(body io.ReadCloser, timeouts HTTPTimeouts, cancel context.CancelFunc) io.ReadCloser {
+	b := &idleBody{ReadCloser: body, timeout: timeouts.Idle, firstByte: timeouts.FirstByte, cancel: cancel}
+	if b.firstByte <= 0 {
+		b.firstByte = b.timeout
+	}
+	if b.firstByte > 0 {
+		b.timer = time.AfterFunc(b.firstByte, func() {
+			b.expired.Store(true)
+			cancel()
+		})
+	}
+	return b
+}
+
+// Read читает данные и продлевает таймаут простоя
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.timer == nil {
+		return n, err
+	}
+	if b.expired.Load() {
+		if !b.received {
+			return n, fmt.Errorf("%w (%v)", errFirstByteTimeout, b.firstByte)
+		}
+		return n, fmt.Errorf("%w (%v)", errIdleTimeout, b.timeout)
+	}
+	if n > 0 {
+		b.received = true
+		if b.timeout > 0 {
+			b.timer.Reset(b.timeout)
+		} else {
+			b.timer.Stop()
+		}
+	}
+	return n, err
+}
+
+// Close закрывает тело ответа и освобождает контекст запроса
+func (b *idleBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,293 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// trickleHandler writes chunks with a pause between them
+func trickleHandler(chunks int, pause time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < chunks; i++ {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-time.After(pause):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+func TestHTTPFetcherIdleTimeout(t *testing.T) {
+	// Setup
+	server := httptest.NewServer(trickleHandler(2, time.Second))
+	defer server.Close()
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Timeouts: HTTPTimeouts{Idle: 50 * time.Millisecond}})
+
+	result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer result.Body.Close()
+
+	// Execute
+	_, err = io.ReadAll(result.Body)
+
+	// Assert
+	if kind := classifyError(err); kind != entities.ErrorKindTimeout {
+		t.Errorf("Expected timeout error kind for a stalled body, got %q (%v)", kind, err)
+	}
+}
+
+func TestHTTPFetcherSlowBodyWithinIdleTimeout(t *testing.T) {
+	// Setup
+	server := httptest.NewServer(trickleHandler(6, 30*time.Millisecond))
+	defer server.Close()
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Timeouts: HTTPTimeouts{Idle: 500 * time.Millisecond, ResponseHeader: time.Second}})
+
+	result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer result.Body.Close()
+
+	// Execute
+	data, err := io.ReadAll(result.Body)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected body that keeps flowing to be read, got %v", err)
+	}
+	if len(data) != 6*len("chunk") {
+		t.Errorf("Expected %d bytes, got %d", 6*len("chunk"), len(data))
+	}
+}
+
+func TestHTTPTimeoutsApplyToTransport(t *testing.T) {
+	// Setup
+	timeouts := HTTPTimeouts{Connect: time.Second, TLSHandshake: 2 * time.Second, ResponseHeader: 3 * time.Second}
+	base := http.DefaultTransport.(*http.Transport)
+
+	// Execute
+	transport := timeouts.apply(base)
+
+	// Assert
+	if transport == base {
+		t.Fatal("Expected a copy of the transport")
+	}
+	if transport.TLSHandshakeTimeout != 2*time.Second || transport.ResponseHeaderTimeout != 3*time.Second {
+		t.Errorf("Expected handshake 2s and response header 3s, got %v and %v", transport.TLSHandshakeTimeout, transport.ResponseHeaderTimeout)
+	}
+	if base.ResponseHeaderTimeout != 0 {
+		t.Errorf("Expected default transport to stay unchanged, got %v", base.ResponseHeaderTimeout)
+	}
+}
+
+func TestHTTPFetcherProtocolNegotiation(t *testing.T) {
+	testCases := []struct {
+		protocol string
+		want     string
+	}{
+		{HTTPProtocolAuto, "HTTP/2.0"},
+		{HTTPProtocolHTTP1, "HTTP/1.1"},
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	for _, tc := range testCases {
+		// Setup: a transport with a custom dialer does not attempt HTTP/2 by itself
+		base := &http.Transport{
+			TLSClientConfig: server.Client().Transport.(*http.Transport).TLSClientConfig.Clone(),
+			DialContext:     (&net.Dialer{}).DialContext,
+		}
+		fetcher := NewHTTPFetcher(base, HTTPFetcherConfig{Protocol: tc.protocol})
+
+		// Execute
+		result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", tc.protocol, err)
+		}
+		body, _ := io.ReadAll(result.Body)
+		result.Body.Close()
+		if result.Protocol != tc.want || string(body) != tc.want {
+			t.Errorf("Expected %s for %s, got %s (server saw %s)", tc.want, tc.protocol, result.Protocol, body)
+		}
+	}
+}
+
+func TestParseHTTPProtocol(t *testing.T) {
+	// Execute
+	empty, emptyErr := ParseHTTPProtocol("")
+	_, invalidErr := ParseHTTPProtocol("h3")
+
+	// Assert
+	if emptyErr != nil || empty != HTTPProtocolAuto {
+		t.Errorf("Expected empty protocol to mean auto, got %q (%v)", empty, emptyErr)
+	}
+	if invalidErr == nil {
+		t.Error("Expected error for unknown protocol")
+	}
+}
+
+func TestHTTPFetcherResponseHeaderTimeout(t *testing.T) {
+	// Setup
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Timeouts: HTTPTimeouts{ResponseHeader: 50 * time.Millisecond}})
+
+	// Execute
+	_, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL})
+
+	// Assert
+	if kind := classifyError(err); kind != entities.ErrorKindTimeout {
+		t.Errorf("Expected timeout error kind for missing headers, got %q (%v)", kind, err)
+	}
+}
+
+func TestHTTPFetcherFirstByteTimeout(t *testing.T) {
+	// Setup: headers are sent at once, the body never starts
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Timeouts: HTTPTimeouts{FirstByte: 50 * time.Millisecond, Idle: time.Minute}})
+
+	result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer result.Body.Close()
+
+	// Execute
+	start := time.Now()
+	_, err = io.ReadAll(result.Body)
+
+	// Assert
+	if !errors.Is(err, errFirstByteTimeout) || classifyError(err) != entities.ErrorKindTimeout {
+		t.Errorf("Expected first byte timeout, got %q (%v)", classifyError(err), err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected first byte timeout well before the idle timeout, took %v", elapsed)
+	}
+}
+
+// redirectChain serves /hop/N redirecting to /hop/N-1 after a pause, and /hop/0 with the given handler
+func redirectChain(pause time.Duration, final http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hop/0" {
+			final(w, r)
+			return
+		}
+		select {
+		case <-time.After(pause):
+		case <-r.Context().Done():
+			return
+		}
+		var hop int
+		fmt.Sscanf(r.URL.Path, "/hop/%d", &hop)
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", hop-1), http.StatusFound)
+	}))
+}
+
+func TestHTTPFetcherRedirectTimeouts(t *testing.T) {
+	stall := func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() }
+	testCases := []struct {
+		name     string
+		pause    time.Duration
+		final    http.HandlerFunc
+		timeouts HTTPTimeouts
+	}{
+		{"stalled hop", 0, stall, HTTPTimeouts{RedirectHop: 50 * time.Millisecond}},
+		{"slow chain", 40 * time.Millisecond, trickleHandler(1, 0), HTTPTimeouts{RedirectHop: time.Second, RedirectTotal: 100 * time.Millisecond}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			server := redirectChain(tc.pause, tc.final)
+			defer server.Close()
+			fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Timeouts: tc.timeouts})
+
+			// Execute
+			_, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL + "/hop/5"})
+
+			// Assert
+			if kind := classifyError(err); kind != entities.ErrorKindRedirectTimeout {
+				t.Errorf("Expected redirect_timeout error kind, got %q (%v)", kind, err)
+			}
+		})
+	}
+}
+
+func TestHTTPFetcherRedirectTimeoutsDoNotLimitBody(t *testing.T) {
+	// Setup
+	server := redirectChain(0, trickleHandler(4, 30*time.Millisecond))
+	defer server.Close()
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Timeouts: HTTPTimeouts{RedirectHop: 50 * time.Millisecond, RedirectTotal: 50 * time.Millisecond}})
+
+	result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL + "/hop/2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer result.Body.Close()
+
+	// Execute
+	data, err := io.ReadAll(result.Body)
+
+	// Assert
+	if err != nil || len(data) != 4*len("chunk") {
+		t.Errorf("Expected the whole body after the redirect timeouts passed, got %d bytes (%v)", len(data), err)
+	}
+}
+
+func TestHTTPFetcherRedirectTotalIgnoresDirectResponse(t *testing.T) {
+	// Setup
+	server := redirectChain(0, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("chunk"))
+	})
+	defer server.Close()
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Timeouts: HTTPTimeouts{RedirectTotal: 50 * time.Millisecond}})
+
+	// Execute
+	result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL + "/hop/0"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected a response that was not redirected to ignore the redirect budget, got %v", err)
+	}
+	result.Body.Close()
+}
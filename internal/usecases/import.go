@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// ImportConflictPolicy определяет поведение при импорте задачи, ID которой уже есть в хранилище
+type ImportConflictPolicy string
+
+const (
+	// ImportConflictOverwrite заменяет существующую задачу импортируемой
+	ImportConflictOverwrite ImportConflictPolicy = "overwrite"
+	// ImportConflictSkip оставляет существующую задачу без изменений
+	ImportConflictSkip ImportConflictPolicy = "skip"
+	// ImportConflictError прекращает импорт с ErrTaskExists
+	ImportConflictError ImportConflictPolicy = "error"
+)
+
+// ParseImportConflictPolicy преобразует строку в политику для задач с совпадающими ID
+func ParseImportConflictPolicy(value string) (ImportConflictPolicy, error) {
+	switch ImportConflictPolicy(value) {
+	case ImportConflictOverwrite, ImportConflictSkip, ImportConflictError:
+		return ImportConflictPolicy(value), nil
+	case "":
+		return ImportConflictOverwrite, nil
+	default:
+		return "", fmt.Errorf("неизвестная политика для задач с совпадающими ID: %q", value)
+	}
+}
+
+// ImportTasks добавляет задачи в repo, применяя policy к задачам с уже существующими ID, и возвращает
+// число созданных, пропущенных и замененных задач. При политике error импорт прекращается на первом совпадении:
+// сводка содержит задачи, импортированные до него, а ошибка оборачивает ErrTaskExists.
+func ImportTasks(ctx context.Context, repo interfaces.TaskRepository, tasks []*entities.Task, policy ImportConflictPolicy) (entities.ImportSummary, error) {
+	var summary entities.ImportSummary
+	for _, task := range tasks {
+		err := repo.Create(ctx, task)
+		switch {
+		case err == nil:
+			summary.Created++
+			continue
+		case !errors.Is(err, entities.ErrTaskExists):
+			return summary, fmt.Errorf("не удалось импортировать задачу %s: %w", task.LogID(), err)
+		}
+
+		switch policy {
+		case ImportConflictSkip:
+			summary.Skipped++
+		case ImportConflictError:
+			return summary, err
+		default:
+			if err := repo.Update(ctx, task); err != nil {
+				return summary, fmt.Errorf("не удалось заменить задачу %s: %w", task.LogID(), err)
+			}
+			summary.Overwritten++
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,52 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/entities"
+)
+
+func TestImportTasksConflictPolicies(t *testing.T) {
+	testCases := []struct {
+		policy        ImportConflictPolicy
+		expected      entities.ImportSummary
+		expectedError error
+		expectedURL   string
+	}{
+		{ImportConflictOverwrite, entities.ImportSummary{Created: 1, Overwritten: 1}, nil, "https://example.com/new"},
+		{ImportConflictSkip, entities.ImportSummary{Created: 1, Skipped: 1}, nil, "https://example.com/old"},
+		{ImportConflictError, entities.ImportSummary{}, entities.ErrTaskExists, "https://example.com/old"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			// Setup
+			ctx := context.Background()
+			repo := repository.NewInMemoryTaskRepository()
+			existing := entities.NewTask([]string{"https://example.com/old"}, time.Now())
+			repo.Create(ctx, existing)
+			duplicate := existing.Clone()
+			duplicate.URLs = []string{"https://example.com/new"}
+			fresh := entities.NewTask([]string{"https://example.com/fresh"}, time.Now())
+
+			// Execute
+			summary, err := ImportTasks(ctx, repo, []*entities.Task{duplicate, fresh}, tc.policy)
+
+			// Assert
+			if !errors.Is(err, tc.expectedError) {
+				t.Errorf("Expected error %v, got %v", tc.expectedError, err)
+			}
+			if summary != tc.expected {
+				t.Errorf("Expected summary %+v, got %+v", tc.expected, summary)
+			}
+			stored, _ := repo.GetByID(ctx, existing.ID.String())
+			if stored.URLs[0] != tc.expectedURL {
+				t.Errorf("Expected stored task URL %s, got %s", tc.expectedURL, stored.URLs[0])
+			}
+		})
+	}
+}
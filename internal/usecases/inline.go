@@ -0,0 +1,76 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// inlineFetcher отдает встроенное содержимое файла так же, как fetcher отдает скачиваемые данные,
+// поэтому оно записывается на диск тем же путем: с размещением, политикой существующих файлов,
+// проверкой размера и контрольной суммы, сжатием и дедупликацией
+type inlineFetcher struct {
+	file entities.File
+}
+
+// Fetch декодирует встроенное содержимое файла
+func (f inlineFetcher) Fetch(ctx context.Context, req interfaces.FetchRequest) (*interfaces.FetchResult, error) {
+	data, err := base64.StdEncoding.DecodeString(f.file.Content)
+	if err != nil {
+		return nil, fmt.Errorf("неверное встроенное содержимое: %w", err)
+	}
+
+	result := &interfaces.FetchResult{
+		Body: io.NopCloser(bytes.NewReader(data)),
+		Size: int64(len(data)),
+	}
+	if f.file.Name != "" {
+		result.ContentDisposition = fmt.Sprintf("attachment; filename=%q", f.file.Name)
+	}
+	return result, nil
+}
+
+// validateInline проверяет встроенное содержимое файла из запроса; возвращает размер декодированного содержимого
+// и причину ошибки или пустую строку
+func (u *TaskUsecase) validateInline(file entities.FileSpec) (int64, string) {
+	if file.URL != "" {
+		return 0, "нельзя указать одновременно url и content"
+	}
+	if file.Name != "" && (filepath.Base(file.Name) != file.Name || !isSafeFileName(file.Name) || strings.Contains(file.Name, `"`)) {
+		return 0, fmt.Sprintf("недопустимое имя файла %q", abbreviate(file.Name))
+	}
+
+	if size := base64.StdEncoding.DecodedLen(len(file.Content)); int64(size) > u.maxInlineSize+2 {
+		return 0, fmt.Sprintf("встроенное содержимое больше %d байт", u.maxInlineSize)
+	}
+	data, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return 0, "встроенное содержимое не в формате base64"
+	}
+	if int64(len(data)) > u.maxInlineSize {
+		return 0, fmt.Sprintf("встроенное содержимое больше %d байт (%d)", u.maxInlineSize, len(data))
+	}
+	return int64(len(data)), ""
+}
+
+// isSHA256 возвращает true для контрольной суммы SHA-256 в шестнадцатеричном виде
+func isSHA256(value string) bool {
+	decoded, err := hex.DecodeString(value)
+	return err == nil && len(decoded) == 32
+}
+
+// checkChecksum сверяет контрольную сумму записанного файла с ожидаемой
+func checkChecksum(file *entities.File) error {
+	if file.ExpectedSHA256 == "" || strings.EqualFold(file.SHA256, file.ExpectedSHA256) {
+		return nil
+	}
+	return fmt.Errorf("несовпадение контрольной суммы: ожидалось %s, получено %s", file.ExpectedSHA256, file.SHA256)
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"file-downloader/internal/entities"
+)
+
+func TestProcessTaskWritesInlineContent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	var requests atomic.Int32
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return cannedResponse(req, http.StatusOK, "downloaded", nil), nil
+	})
+	tasks := NewTaskUsecase(mockRepo, mockRepo)
+	downloads := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(t.TempDir()), WithRoundTripper(tripper))
+	ctx := context.Background()
+
+	content := "inline asset"
+	sum := sha256.Sum256([]byte(content))
+	task, err := tasks.CreateTaskFromSpec(ctx, entities.TaskSpec{Files: []entities.FileSpec{
+		{URL: "https://example.com/remote.txt"},
+		{Name: "logo.svg", Content: base64.StdEncoding.EncodeToString([]byte(content)), SHA256: hex.EncodeToString(sum[:])},
+	}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	if err := downloads.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	current, _ := mockRepo.GetByID(ctx, task.ID.String())
+	if current.Status != entities.TaskStatusCompleted {
+		t.Fatalf("Expected task status completed, got %s (error: %s)", current.Status, current.Error)
+	}
+	inline := current.Files[1]
+	if filepath.Base(inline.Path) != "logo.svg" {
+		t.Errorf("Expected inline file to be named logo.svg, got %s", inline.Path)
+	}
+	data, _ := os.ReadFile(inline.Path)
+	if string(data) != content || inline.Size != int64(len(content)) {
+		t.Errorf("Expected inline content %q of size %d, got %q of size %d", content, len(content), string(data), inline.Size)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected only the URL file to be requested, got %d requests", requests.Load())
+	}
+}
+
+func TestProcessTaskRejectsInlineChecksumMismatch(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	tasks := NewTaskUsecase(mockRepo, mockRepo)
+	downloads := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(t.TempDir()), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	ctx := context.Background()
+
+	task, err := tasks.CreateTaskFromSpec(ctx, entities.TaskSpec{Files: []entities.FileSpec{
+		{Name: "data.bin", Content: base64.StdEncoding.EncodeToString([]byte("payload")), SHA256: strings.Repeat("0", 64)},
+	}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	downloads.ProcessTask(ctx, task)
+
+	// Assert
+	current, _ := mockRepo.GetByID(ctx, task.ID.String())
+	if file := current.Files[0]; file.Status != "failed" || !strings.Contains(file.Error, "контрольной суммы") {
+		t.Errorf("Expected checksum mismatch failure, got %s (%s)", file.Status, file.Error)
+	}
+}
+
+func TestCreateTaskValidatesInlineContent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithMaxInlineContentSize(4))
+	encoded := base64.StdEncoding.EncodeToString([]byte("abc"))
+
+	// Execute
+	_, err := usecase.CreateTaskFromSpec(context.Background(), entities.TaskSpec{Files: []entities.FileSpec{
+		{Name: "ok.txt", Content: encoded},
+		{Name: "bad.txt", Content: "not base64!"},
+		{Name: "big.txt", Content: base64.StdEncoding.EncodeToString([]byte("too large"))},
+		{Name: "both.txt", Content: encoded, URL: "https://example.com/both.txt"},
+		{Name: "../escape.txt", Content: encoded},
+		{Name: "sum.txt", Content: encoded, SHA256: "abc"},
+	}})
+
+	// Assert
+	var validation *entities.ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if len(validation.Errors) != 5 {
+		t.Fatalf("Expected 5 invalid files, got %d: %v", len(validation.Errors), validation.Errors)
+	}
+	for i, invalid := range validation.Errors {
+		if invalid.Index != i+1 {
+			t.Errorf("Expected error for file %d, got %d (%s)", i+1, invalid.Index, invalid.Reason)
+		}
+	}
+}
+
+func TestCreateTaskLimitsTotalInlineContent(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithMaxInlineContentSize(4))
+	encoded := base64.StdEncoding.EncodeToString([]byte("abc"))
+
+	// Execute
+	_, err := usecase.CreateTaskFromSpec(context.Background(), entities.TaskSpec{Files: []entities.FileSpec{
+		{Name: "a.txt", Content: encoded},
+		{Name: "b.txt", Content: encoded},
+	}})
+
+	// Assert
+	if !errors.Is(err, entities.ErrInvalidRequest) {
+		t.Fatalf("Expected ErrInvalidRequest, got %v", err)
+	}
+	if len(mockRepo.tasks) != 0 {
+		t.Errorf("Expected no task to be created, got %d", len(mockRepo.tasks))
+	}
+}
@@ -0,0 +1,186 @@
+package usecases
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// Layout определяет структуру директорий для скачанных файлов
+type Layout string
+
+const (
+	// LayoutByTask размещает файлы в downloads/{task-id}/file
+	LayoutByTask Layout = "by-task"
+	// LayoutFlat размещает файлы всех задач в одной директории
+	LayoutFlat Layout = "flat"
+	// LayoutByHost группирует файлы по имени хоста источника
+	LayoutByHost Layout = "by-host"
+)
+
+// ParseLayout преобразует строку в стратегию размещения
+func ParseLayout(value string) (Layout, error) {
+	switch Layout(value) {
+	case LayoutByTask, LayoutFlat, LayoutByHost:
+		return Layout(value), nil
+	case "":
+		return LayoutByTask, nil
+	default:
+		return "", fmt.Errorf("неизвестная стратегия размещения файлов: %q", value)
+	}
+}
+
+// filePath выбирает путь к файлу задачи внутри директории скачивания задачи: директорию определяет стратегия
+// размещения, а имя - NameResolver. Для flat и by-host имя файла предваряется префиксом ID задачи, чтобы файлы
+// разных задач не пересекались. При preserve_path между директорией размещения и именем файла воссоздаются
+// директории пути URL. batch (nil - без одновременно скачиваемых файлов) учитывает пути, выбранные другими
+// файлами задачи, но еще не сохраненные в ней.
+func (u *DownloadUsecase) filePath(batch *fileBatch, task *entities.Task, fileIndex int, rawURL string, result *interfaces.FetchResult, suffix string) (string, error) {
+	taskID := task.ID.String()
+	root := u.rootDir(task)
+
+	var dir, prefix string
+	switch u.layout {
+	case LayoutFlat:
+		dir = root
+		prefix = taskID[:8] + "_"
+	case LayoutByHost:
+		dir = filepath.Join(root, hostDirName(rawURL))
+		prefix = taskID[:8] + "_"
+	default:
+		dir = filepath.Join(root, taskID)
+	}
+	if task.PreservePath {
+		dir = filepath.Join(dir, urlDirs(rawURL))
+	}
+
+	file := task.Files[fileIndex]
+	path, err := u.names.Resolve(interfaces.NameRequest{
+		URL:                rawURL,
+		ContentDisposition: result.ContentDisposition,
+		ContentType:        result.ContentType,
+		IndexName:          u.indexName,
+		Dir:                dir,
+		Prefix:             prefix,
+		Suffix:             suffix,
+		FileIndex:          fileIndex,
+		Taken: func(path string) bool {
+			for i, other := range task.Files {
+				if i != fileIndex && other.Path == path {
+					return true
+				}
+			}
+			return batch != nil && batch.pathTaken(fileIndex, path)
+		},
+		Exists: func(path string) bool {
+			if path == file.Path || path == file.LastPath {
+				return false
+			}
+			_, err := os.Lstat(path)
+			return err == nil
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Защита от выхода за пределы директории скачивания
+	if !insideDir(root, path) {
+		return "", fmt.Errorf("путь %q выходит за пределы директории скачивания", path)
+	}
+
+	return path, nil
+}
+
+// indexedPath добавляет к имени файла индекс файла в задаче, чтобы различить файлы с одинаковыми именами
+func indexedPath(path string, fileIndex int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(path, ext), fileIndex, ext)
+}
+
+// hostDirName возвращает безопасное имя директории для хоста из URL
+func hostDirName(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "unknown-host"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.ToLower(parsed.Hostname()))
+}
+
+// urlDirs возвращает директории пути URL (без последнего сегмента - имени файла) в виде относительного пути.
+// Пустые сегменты, "." и ".." отбрасываются, разделители и управляющие символы внутри сегмента заменяются на "_",
+// длинные сегменты сокращаются, а глубина и общая длина пути ограничиваются.
+func urlDirs(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	segments := strings.Split(parsed.EscapedPath(), "/")
+	var dirs []string
+	length := 0
+	for _, segment := range segments[:max(len(segments)-1, 0)] {
+		name, err := url.PathUnescape(segment)
+		if err != nil {
+			name = segment
+		}
+		name = strings.Map(func(r rune) rune {
+			if r == '/' || r == '\\' || r < 0x20 || r == 0x7f {
+				return '_'
+			}
+			return r
+		}, name)
+		if name == "" || name == "." || name == ".." {
+			continue
+		}
+
+		name = truncateFileName(name)
+		if len(dirs) == maxPreservedPathDepth || length+len(name)+1 > maxPreservedPathLength {
+			break
+		}
+		dirs = append(dirs, name)
+		length += len(name) + 1
+	}
+	return filepath.Join(dirs...)
+}
+
+// makeDirChain создает директорию dir внутри root по одному уровню, не проходя по символическим ссылкам,
+// чтобы воссозданный из URL путь не вывел запись за пределы директории скачивания
+func makeDirChain(root, dir string) error {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return err
+	}
+
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		if err := os.Mkdir(current, 0755); err != nil && !os.IsExist(err) {
+			return err
+		}
+		info, err := os.Lstat(current)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 || !info.IsDir() {
+			return fmt.Errorf("путь %q не является директорией", current)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestURLDirs(t *testing.T) {
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/a/b/c.png", filepath.Join("a", "b")},
+		{"https://example.com/c.png", ""},
+		{"https://example.com/a/../../etc/passwd", "a/etc"},
+		{"https://example.com/a//./b/", filepath.Join("a", "b")},
+		{"https://example.com/a%2F..%2Fb/c.png", "a_.._b"},
+		{"https://example.com/%2e%2e/c.png", ""},
+	}
+
+	for _, tc := range testCases {
+		if actual := urlDirs(tc.url); actual != filepath.FromSlash(tc.expected) {
+			t.Errorf("Expected dirs %q for %s, got %q", tc.expected, tc.url, actual)
+		}
+	}
+}
+
+func TestURLDirsLimitsDepth(t *testing.T) {
+	// Setup
+	rawURL := "https://example.com/" + strings.Repeat("d/", maxPreservedPathDepth+10) + "file.bin"
+
+	// Execute
+	dirs := urlDirs(rawURL)
+
+	// Assert
+	if depth := len(strings.Split(dirs, string(filepath.Separator))); depth != maxPreservedPathDepth {
+		t.Errorf("Expected depth %d, got %d", maxPreservedPathDepth, depth)
+	}
+}
+
+func TestProcessTaskPreservesURLPath(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusOK, req.URL.Path, nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(dir), WithRoundTripper(tripper))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/a/b/c.png", "https://example.com/a/d.png"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	task.PreservePath = true
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	expected := []string{
+		filepath.Join(dir, task.ID.String(), "a", "b", "c.png"),
+		filepath.Join(dir, task.ID.String(), "a", "d.png"),
+	}
+	for i, path := range expected {
+		if task.Files[i].Path != path {
+			t.Errorf("Expected path %s, got %s", path, task.Files[i].Path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected file %s to exist, got %v", path, err)
+		}
+	}
+}
+
+func TestMakeDirChainRejectsSymlink(t *testing.T) {
+	// Setup
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.Symlink(outside, filepath.Join(root, "link"))
+
+	// Execute
+	err := makeDirChain(root, filepath.Join(root, "link", "nested"))
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error for a symlinked directory, got nil")
+	}
+	if _, statErr := os.Stat(filepath.Join(outside, "nested")); !os.IsNotExist(statErr) {
+		t.Errorf("Expected nothing to be created outside the root, got %v", statErr)
+	}
+}
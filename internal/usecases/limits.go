@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"path/filepath"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMaxURLLength - максимальная длина URL файла по умолчанию
+	DefaultMaxURLLength = 8192
+
+	// DefaultMaxInlineContentSize - максимальный размер встроенного содержимого задачи по умолчанию (после декодирования base64)
+	DefaultMaxInlineContentSize = 1 << 20
+
+	// DefaultMaxManifestSize - максимальный размер манифеста со списком файлов задачи по умолчанию
+	DefaultMaxManifestSize = 1 << 20
+
+	// maxHeaderNameLength и maxHeaderValueLength ограничивают заголовки, передаваемые в задаче
+	maxHeaderNameLength  = 256
+	maxHeaderValueLength = 8192
+
+	// maxFileNameLength - максимальная длина имени скачанного файла в байтах.
+	// Она меньше ограничения большинства файловых систем (255 байт), чтобы оставить место для префикса ID задачи,
+	// суффикса индекса файла и временных суффиксов .part и .dedup.
+	maxFileNameLength = 200
+
+	// maxPreservedPathDepth и maxPreservedPathLength ограничивают число и общую длину директорий,
+	// воссоздаваемых из пути URL при preserve_path
+	maxPreservedPathDepth  = 32
+	maxPreservedPathLength = 1024
+
+	// MaxFileConcurrency - предел числа одновременно скачиваемых файлов одной задачи;
+	// большие значения из конфигурации и запроса уменьшаются до него
+	MaxFileConcurrency = 16
+
+	// MaxRetryAttempts - предел числа попыток скачивания файла в политике повторов задачи
+	MaxRetryAttempts = 100
+
+	// MaxStatusBatchSize - максимальное число ID задач в одном запросе статусов
+	MaxStatusBatchSize = 1000
+
+	// abbreviateLength - длина, до которой сокращаются URL в ответах об ошибках валидации
+	abbreviateLength = 256
+)
+
+// truncateFileName сокращает слишком длинное имя файла, сохраняя расширение и не разрывая символы UTF-8
+func truncateFileName(name string) string {
+	if len(name) <= maxFileNameLength {
+		return name
+	}
+
+	base, ext := name, filepath.Ext(name)
+	if len(ext) > maxFileNameLength/4 {
+		ext = ""
+	} else {
+		base = name[:len(name)-len(ext)]
+	}
+	return truncateUTF8(base, maxFileNameLength-len(ext)) + ext
+}
+
+// abbreviate сокращает длинное значение для ответов и журнала, отмечая сокращение многоточием
+func abbreviate(value string) string {
+	if len(value) <= abbreviateLength {
+		return value
+	}
+	return truncateUTF8(value, abbreviateLength) + "…"
+}
+
+// truncateUTF8 обрезает строку до limit байт по границе символа UTF-8
+func truncateUTF8(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	for limit > 0 && !utf8.RuneStart(value[limit]) {
+		limit--
+	}
+	return value[:limit]
+}
+
+// clampConcurrency ограничивает число одновременно скачиваемых файлов диапазоном [1, MaxFileConcurrency]
+func clampConcurrency(n int) int {
+	return max(1, min(n, MaxFileConcurrency))
+}
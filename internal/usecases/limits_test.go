@@ -0,0 +1,54 @@
+package usecases
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"file-downloader/internal/interfaces"
+)
+
+func TestTruncateFileName(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expectedExt string
+	}{
+		{"short name kept", "report.pdf", ".pdf"},
+		{"long name keeps extension", strings.Repeat("a", 300) + ".tar.gz", ".gz"},
+		{"long multibyte name", strings.Repeat("файл", 100) + ".txt", ".txt"},
+		{"long extension dropped", "name." + strings.Repeat("x", 300), ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := truncateFileName(tc.input)
+
+			if len(actual) > maxFileNameLength {
+				t.Errorf("Expected at most %d bytes, got %d", maxFileNameLength, len(actual))
+			}
+			if !utf8.ValidString(actual) {
+				t.Errorf("Expected valid UTF-8, got %q", actual)
+			}
+			if !strings.HasSuffix(actual, tc.expectedExt) {
+				t.Errorf("Expected extension %q, got %q", tc.expectedExt, actual)
+			}
+			if len(tc.input) <= maxFileNameLength && actual != tc.input {
+				t.Errorf("Expected short name %q unchanged, got %q", tc.input, actual)
+			}
+		})
+	}
+}
+
+func TestSourceFileNameTruncatesContentDisposition(t *testing.T) {
+	// Setup
+	header := `attachment; filename="` + strings.Repeat("b", 5000) + `.csv"`
+
+	// Execute
+	name := sourceFileName(interfaces.NameRequest{URL: "https://example.com/export", ContentDisposition: header})
+
+	// Assert
+	if len(name) > maxFileNameLength || !strings.HasSuffix(name, ".csv") {
+		t.Errorf("Expected truncated name with .csv extension, got %d bytes: %q", len(name), name[len(name)-10:])
+	}
+}
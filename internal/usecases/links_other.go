@@ -0,0 +1,10 @@
+//go:build !unix
+
+package usecases
+
+import "os"
+
+// linkCount не поддерживается на этой платформе: записи хранилища содержимого не удаляются
+func linkCount(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package usecases
+
+import (
+	"os"
+	"syscall"
+)
+
+// linkCount возвращает число жестких ссылок на файл; false - число ссылок неизвестно
+func linkCount(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Nlink), true
+}
@@ -0,0 +1,94 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// MaintenanceMode хранит флаг режима обслуживания в памяти и, если задано хранилище, сохраняет его при переключении
+type MaintenanceMode struct {
+	mu    sync.RWMutex
+	state entities.Maintenance
+	repo  interfaces.MaintenanceRepository // nil - состояние не переживает перезапуск
+	clock interfaces.Clock
+}
+
+// NewMaintenanceMode создает режим обслуживания, восстанавливая его состояние из repo (repo может быть nil)
+func NewMaintenanceMode(repo interfaces.MaintenanceRepository, clock interfaces.Clock) (interfaces.MaintenanceMode, error) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	m := &MaintenanceMode{repo: repo, clock: clock}
+	if repo != nil {
+		state, err := repo.LoadMaintenance()
+		if err != nil {
+			return nil, err
+		}
+		m.state = state
+	}
+	if m.state.Enabled {
+		log.Println("Режим обслуживания включен: новые задачи не принимаются")
+	}
+	return m, nil
+}
+
+// Enabled возвращает true, если режим обслуживания включен
+func (m *MaintenanceMode) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.Enabled
+}
+
+// State возвращает текущее состояние режима
+func (m *MaintenanceMode) State() entities.Maintenance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// SetEnabled переключает режим; повторное включение или выключение ничего не меняет,
+// а при ошибке сохранения состояние остается прежним
+func (m *MaintenanceMode) SetEnabled(ctx context.Context, enabled bool) (entities.Maintenance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state.Enabled == enabled {
+		return m.state, nil
+	}
+
+	now := m.clock.Now()
+	state := entities.Maintenance{Enabled: enabled, UpdatedAt: &now}
+	if m.repo != nil {
+		if err := m.repo.SaveMaintenance(state); err != nil {
+			return m.state, fmt.Errorf("не удалось сохранить режим обслуживания: %w", err)
+		}
+	}
+	m.state = state
+
+	if enabled {
+		log.Println("Режим обслуживания включен: новые задачи не принимаются, выполняющиеся задачи продолжают работу")
+	} else {
+		log.Println("Режим обслуживания выключен")
+	}
+	return m.state, nil
+}
+
+// Health возвращает ошибку, пока режим обслуживания включен
+func (m *MaintenanceMode) Health() error {
+	if m.Enabled() {
+		return entities.ErrMaintenance
+	}
+	return nil
+}
+
+// WithMaintenance отклоняет создание задач с ErrMaintenance, пока режим обслуживания включен
+func WithMaintenance(mode interfaces.MaintenanceMode) TaskOption {
+	return func(u *TaskUsecase) {
+		u.maintenance = mode
+	}
+}
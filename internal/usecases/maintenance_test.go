@@ -0,0 +1,72 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/entities"
+)
+
+func TestMaintenanceRejectsNewTasks(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	mode, err := NewMaintenanceMode(nil, nil)
+	if err != nil {
+		t.Fatalf("Expected maintenance mode to be created, got %v", err)
+	}
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithMaintenance(mode))
+	ctx := context.Background()
+	if _, err := mode.SetEnabled(ctx, true); err != nil {
+		t.Fatalf("Expected maintenance to be enabled, got %v", err)
+	}
+
+	// Execute
+	_, err = usecase.CreateTask(ctx, []string{"https://example.com/a.txt"})
+
+	// Assert
+	if !errors.Is(err, entities.ErrMaintenance) {
+		t.Errorf("Expected ErrMaintenance, got %v", err)
+	}
+	if !errors.Is(mode.Health(), entities.ErrMaintenance) {
+		t.Errorf("Expected readiness check to report maintenance, got %v", mode.Health())
+	}
+	if tasks, _ := mockRepo.GetAll(ctx); len(tasks) != 0 {
+		t.Errorf("Expected no tasks to be stored, got %d", len(tasks))
+	}
+
+	if _, err := mode.SetEnabled(ctx, false); err != nil {
+		t.Fatalf("Expected maintenance to be disabled, got %v", err)
+	}
+	if _, err := usecase.CreateTask(ctx, []string{"https://example.com/a.txt"}); err != nil {
+		t.Errorf("Expected task to be created after maintenance, got %v", err)
+	}
+}
+
+func TestMaintenanceSurvivesRestart(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "maintenance.json")
+	mode, err := NewMaintenanceMode(repository.NewFileBasedMaintenanceRepository(path), nil)
+	if err != nil {
+		t.Fatalf("Expected maintenance mode to be created, got %v", err)
+	}
+	if _, err := mode.SetEnabled(context.Background(), true); err != nil {
+		t.Fatalf("Expected maintenance to be enabled, got %v", err)
+	}
+
+	// Execute
+	restarted, err := NewMaintenanceMode(repository.NewFileBasedMaintenanceRepository(path), nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected maintenance state to be loaded, got %v", err)
+	}
+	if !restarted.Enabled() {
+		t.Error("Expected maintenance to stay enabled after restart")
+	}
+	if restarted.State().UpdatedAt == nil {
+		t.Error("Expected updated_at to be persisted")
+	}
+}
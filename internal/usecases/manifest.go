@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// WithManifestFetcher включает создание задач по манифесту manifest_url; манифесты больше maxSize байт
+// отклоняются (0 - размер по умолчанию)
+func WithManifestFetcher(fetcher interfaces.ManifestFetcher, maxSize int64) TaskOption {
+	return func(u *TaskUsecase) {
+		u.manifests = fetcher
+		if maxSize > 0 {
+			u.maxManifestSize = maxSize
+		}
+	}
+}
+
+// manifestEntry - запись JSON-манифеста: URL файла с необязательными ожидаемыми размером, контрольной суммой и приоритетом
+type manifestEntry struct {
+	URL          string `json:"url"`
+	ExpectedSize int64  `json:"expected_size"`
+	SHA256       string `json:"sha256"`
+	Priority     int    `json:"priority"`
+}
+
+// expandManifest загружает манифест задачи и возвращает описанные в нем файлы.
+// Манифест запрашивается с заголовками задачи, чтобы закрытый источник мог отдать и список файлов.
+func (u *TaskUsecase) expandManifest(ctx context.Context, spec entities.TaskSpec) ([]entities.FileSpec, error) {
+	if u.manifests == nil {
+		return nil, fmt.Errorf("%w: загрузка манифестов отключена", entities.ErrInvalidRequest)
+	}
+	if reason := u.validateHTTPURL(spec.ManifestURL); reason != "" {
+		return nil, fmt.Errorf("%w: manifest_url: %s", entities.ErrInvalidRequest, reason)
+	}
+	if parsed, err := url.Parse(spec.ManifestURL); err == nil {
+		if err := u.hostPolicy.Check(parsed.Hostname()); err != nil {
+			return nil, fmt.Errorf("%w: manifest_url: %v", entities.ErrInvalidRequest, err)
+		}
+	}
+
+	data, err := u.manifests.Fetch(ctx, spec.ManifestURL, spec.Headers, u.maxManifestSize+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", entities.ErrManifestUnavailable, abbreviate(spec.ManifestURL), err)
+	}
+	if int64(len(data)) > u.maxManifestSize {
+		return nil, fmt.Errorf("%w: манифест больше %d байт", entities.ErrInvalidRequest, u.maxManifestSize)
+	}
+
+	files, err := parseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidRequest, err)
+	}
+	return files, nil
+}
+
+// parseManifest разбирает манифест: JSON-массив URL или записей {"url", "sha256", "expected_size", "priority"}
+// либо текст, в каждой строке которого URL и необязательная контрольная сумма SHA-256 через пробел.
+// В тексте пустые строки и строки, начинающиеся с #, пропускаются.
+func parseManifest(data []byte) ([]entities.FileSpec, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+
+	var files []entities.FileSpec
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("неверный JSON манифеста: %v", err)
+		}
+		for i, raw := range entries {
+			var entry manifestEntry
+			if err := json.Unmarshal(raw, &entry.URL); err != nil {
+				if err := json.Unmarshal(raw, &entry); err != nil {
+					return nil, fmt.Errorf("запись %d манифеста: ожидается URL или объект с полем url", i)
+				}
+			}
+			files = append(files, entities.FileSpec{
+				URL:          strings.TrimSpace(entry.URL),
+				ExpectedSize: entry.ExpectedSize,
+				SHA256:       entry.SHA256,
+				Priority:     entry.Priority,
+			})
+		}
+	} else {
+		for n, line := range strings.Split(string(trimmed), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) > 2 {
+				return nil, fmt.Errorf("строка %d манифеста: ожидается URL и необязательная контрольная сумма", n+1)
+			}
+			file := entities.FileSpec{URL: fields[0]}
+			if len(fields) == 2 {
+				file.SHA256 = fields[1]
+			}
+			files = append(files, file)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("манифест не содержит файлов")
+	}
+	return files, nil
+}
@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"file-downloader/internal/entities"
+)
+
+// staticManifestFetcher возвращает заданный манифест или ошибку
+type staticManifestFetcher struct {
+	data    string
+	err     error
+	headers map[string]string
+}
+
+func (f *staticManifestFetcher) Fetch(ctx context.Context, url string, headers map[string]string, limit int64) ([]byte, error) {
+	f.headers = headers
+	if f.err != nil {
+		return nil, f.err
+	}
+	data := []byte(f.data)
+	if int64(len(data)) > limit {
+		data = data[:limit]
+	}
+	return data, nil
+}
+
+func TestParseManifest(t *testing.T) {
+	sum := strings.Repeat("a", 64)
+	testCases := []struct {
+		name     string
+		data     string
+		expected []entities.FileSpec
+		wantErr  bool
+	}{
+		{
+			name: "text with comments and checksum",
+			data: "\xef\xbb\xbf# files\r\nhttps://example.com/a.txt\r\n\n  https://example.com/b.txt " + sum + "\n",
+			expected: []entities.FileSpec{
+				{URL: "https://example.com/a.txt"},
+				{URL: "https://example.com/b.txt", SHA256: sum},
+			},
+		},
+		{
+			name: "json strings and objects",
+			data: `["https://example.com/a.txt", {"url": "https://example.com/b.txt", "sha256": "` + sum + `", "expected_size": 5, "priority": 2}]`,
+			expected: []entities.FileSpec{
+				{URL: "https://example.com/a.txt"},
+				{URL: "https://example.com/b.txt", SHA256: sum, ExpectedSize: 5, Priority: 2},
+			},
+		},
+		{name: "empty", data: "# nothing\n\n", wantErr: true},
+		{name: "empty json", data: "[]", wantErr: true},
+		{name: "extra fields in line", data: "https://example.com/a.txt " + sum + " extra", wantErr: true},
+		{name: "invalid json", data: `["https://example.com/a.txt"`, wantErr: true},
+		{name: "json number", data: `[42]`, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		// Execute
+		files, err := parseManifest([]byte(tc.data))
+
+		// Assert
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: Expected error, got %+v", tc.name, files)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Expected no error, got %v", tc.name, err)
+			continue
+		}
+		if len(files) != len(tc.expected) {
+			t.Errorf("%s: Expected %d files, got %d", tc.name, len(tc.expected), len(files))
+			continue
+		}
+		for i := range files {
+			if !reflect.DeepEqual(files[i], tc.expected[i]) {
+				t.Errorf("%s: Expected file %d %+v, got %+v", tc.name, i, tc.expected[i], files[i])
+			}
+		}
+	}
+}
+
+func TestCreateTaskFromManifest(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	fetcher := &staticManifestFetcher{data: "https://example.com/b.txt\nhttps://example.com/c.txt\n"}
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithManifestFetcher(fetcher, 0))
+	spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+	spec.ManifestURL = "https://example.com/list.txt"
+	spec.Headers = map[string]string{"Authorization": "Bearer token"}
+
+	// Execute
+	task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"https://example.com/a.txt", "https://example.com/b.txt", "https://example.com/c.txt"}
+	if len(task.Files) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(task.Files))
+	}
+	for i, url := range expected {
+		if task.Files[i].URL != url || task.URLs[i] != url {
+			t.Errorf("Expected file %d URL %s, got %s", i, url, task.Files[i].URL)
+		}
+	}
+	if task.ManifestURL != spec.ManifestURL {
+		t.Errorf("Expected manifest URL %s, got %s", spec.ManifestURL, task.ManifestURL)
+	}
+	if fetcher.headers["Authorization"] != "Bearer token" {
+		t.Errorf("Expected manifest to be fetched with task headers, got %v", fetcher.headers)
+	}
+}
+
+func TestCreateTaskFromManifestErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fetcher  *staticManifestFetcher
+		url      string
+		maxSize  int64
+		expected error
+	}{
+		{"fetch error", &staticManifestFetcher{err: errors.New("connection refused")}, "https://example.com/list.txt", 0, entities.ErrManifestUnavailable},
+		{"too large", &staticManifestFetcher{data: "https://example.com/a.txt\n"}, "https://example.com/list.txt", 10, entities.ErrInvalidRequest},
+		{"invalid entry", &staticManifestFetcher{data: "ftp://example.com/a.txt\n"}, "https://example.com/list.txt", 0, nil},
+		{"invalid manifest url", &staticManifestFetcher{data: "https://example.com/a.txt\n"}, "file:///etc/list.txt", 0, entities.ErrInvalidRequest},
+		{"disabled", nil, "https://example.com/list.txt", 0, entities.ErrInvalidRequest},
+	}
+
+	for _, tc := range testCases {
+		// Setup
+		mockRepo := NewMockTaskRepository()
+		var opts []TaskOption
+		if tc.fetcher != nil {
+			opts = append(opts, WithManifestFetcher(tc.fetcher, tc.maxSize))
+		}
+		usecase := NewTaskUsecase(mockRepo, mockRepo, opts...)
+		spec := entities.TaskSpec{ManifestURL: tc.url}
+
+		// Execute
+		_, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+		// Assert
+		if tc.expected == nil {
+			var validationErr *entities.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("%s: Expected validation error, got %v", tc.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tc.expected) {
+			t.Errorf("%s: Expected %v, got %v", tc.name, tc.expected, err)
+		}
+	}
+}
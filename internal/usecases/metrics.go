@@ -0,0 +1,7 @@
+package usecases
+
+// noopMetrics используется, когда реестр метрик не задан
+type noopMetrics struct{}
+
+func (noopMetrics) IncCounter(name string, labels map[string]string, delta float64) {}
+func (noopMetrics) SetGauge(name string, labels map[string]string, value float64)   {}
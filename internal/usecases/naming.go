@@ -0,0 +1,172 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"file-downloader/internal/interfaces"
+)
+
+// NamingStrategy определяет, как различаются файлы с совпадающими именами
+type NamingStrategy string
+
+const (
+	// NamingIndex различает совпадающие имена внутри задачи индексом файла; существующий на диске файл
+	// обрабатывается политикой EXISTING_FILE_POLICY
+	NamingIndex NamingStrategy = "index"
+	// NamingNumeric добавляет к имени первый свободный номер, если имя занято в задаче или на диске
+	NamingNumeric NamingStrategy = "numeric"
+	// NamingHash добавляет к занятому в задаче или на диске имени хеш URL файла
+	NamingHash NamingStrategy = "hash"
+	// NamingHost размещает файлы в поддиректориях по имени хоста источника
+	NamingHost NamingStrategy = "host"
+)
+
+// maxNameSuffix ограничивает перебор номеров при выборе свободного имени
+const maxNameSuffix = 10000
+
+// DefaultDirectoryIndexName - имя файла по умолчанию для URL директории с HTML-ответом
+const DefaultDirectoryIndexName = "index.html"
+
+// ParseNamingStrategy преобразует строку в стратегию именования файлов
+func ParseNamingStrategy(value string) (NamingStrategy, error) {
+	switch NamingStrategy(value) {
+	case NamingIndex, NamingNumeric, NamingHash, NamingHost:
+		return NamingStrategy(value), nil
+	case "":
+		return NamingIndex, nil
+	default:
+		return "", fmt.Errorf("неизвестная стратегия именования файлов: %q", value)
+	}
+}
+
+// NewNameResolver создает встроенный NameResolver для стратегии именования
+func NewNameResolver(strategy NamingStrategy) interfaces.NameResolver {
+	switch strategy {
+	case NamingNumeric:
+		return numericNames{}
+	case NamingHash:
+		return hashNames{}
+	case NamingHost:
+		return hostNames{}
+	default:
+		return indexNames{}
+	}
+}
+
+// WithNameResolver задает выбор пути для скачиваемых файлов
+func WithNameResolver(resolver interfaces.NameResolver) DownloadOption {
+	return func(u *DownloadUsecase) {
+		if resolver != nil {
+			u.names = resolver
+		}
+	}
+}
+
+// WithDirectoryIndexName задает имя файла для URL директории (с "/" в конце), на который источник ответил
+// HTML-страницей. Пустое имя отключает особое имя: файл называется по последнему непустому сегменту пути.
+func WithDirectoryIndexName(name string) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.indexName = name
+	}
+}
+
+// indexNames различает совпадающие имена внутри задачи индексом файла (image_1.png)
+type indexNames struct{}
+
+func (indexNames) Resolve(req interfaces.NameRequest) (string, error) {
+	path := filepath.Join(req.Dir, req.Prefix+sourceFileName(req))
+	if req.Taken(path + req.Suffix) {
+		path = indexedPath(path, req.FileIndex)
+	}
+	return path + req.Suffix, nil
+}
+
+// numericNames выбирает первое имя image.png, image_1.png, image_2.png..., не занятое в задаче и на диске
+type numericNames struct{}
+
+func (numericNames) Resolve(req interfaces.NameRequest) (string, error) {
+	base := filepath.Join(req.Dir, req.Prefix+sourceFileName(req))
+	path := base + req.Suffix
+	for n := 1; req.Taken(path) || req.Exists(path); n++ {
+		if n > maxNameSuffix {
+			return "", fmt.Errorf("не удалось подобрать свободное имя для %q", base)
+		}
+		path = indexedPath(base, n) + req.Suffix
+	}
+	return path, nil
+}
+
+// hashNames добавляет к занятому имени первые символы SHA-256 URL файла (image_3f2a9c1d.png), поэтому
+// имя файла не зависит от порядка скачивания. Совпадающие URL внутри задачи различаются индексом файла.
+type hashNames struct{}
+
+func (hashNames) Resolve(req interfaces.NameRequest) (string, error) {
+	path := filepath.Join(req.Dir, req.Prefix+sourceFileName(req))
+	if !req.Taken(path+req.Suffix) && !req.Exists(path+req.Suffix) {
+		return path + req.Suffix, nil
+	}
+
+	sum := sha256.Sum256([]byte(req.URL))
+	ext := filepath.Ext(path)
+	path = strings.TrimSuffix(path, ext) + "_" + hex.EncodeToString(sum[:4]) + ext
+	if req.Taken(path + req.Suffix) {
+		path = indexedPath(path, req.FileIndex)
+	}
+	return path + req.Suffix, nil
+}
+
+// hostNames размещает файл в поддиректории хоста источника, а совпадающие имена внутри задачи
+// различает индексом файла
+type hostNames struct{}
+
+func (hostNames) Resolve(req interfaces.NameRequest) (string, error) {
+	req.Dir = filepath.Join(req.Dir, hostDirName(req.URL))
+	return indexNames{}.Resolve(req)
+}
+
+// sourceFileName извлекает имя файла из заголовка Content-Disposition или URL. Для URL директории
+// (пустой последний сегмент пути) HTML-ответ получает имя req.IndexName, а остальные ответы - имя последнего
+// непустого сегмента пути. Имя очищается sanitizeFileName; если пригодного имени не осталось, имя строится
+// по хешу URL, чтобы повторные попытки скачивания сохраняли файл под тем же именем.
+func sourceFileName(req interfaces.NameRequest) string {
+	// Попытка получить имя файла из заголовка Content-Disposition
+	if filename := dispositionFileName(req.ContentDisposition); filename != "" {
+		if filename = sanitizeFileName(filepath.Base(filename)); filename != "" {
+			return filename
+		}
+	}
+
+	// Извлечение имени файла из пути URL (без параметров запроса)
+	if parsed, err := url.Parse(req.URL); err == nil {
+		segments := strings.Split(parsed.EscapedPath(), "/")
+		last := segments[len(segments)-1]
+		if last == "" && req.IndexName != "" && isHTML(req.ContentType) {
+			return req.IndexName
+		}
+		for i := len(segments) - 1; i >= 0; i-- {
+			if segments[i] == "" {
+				continue
+			}
+			if filename := sanitizeFileName(segments[i]); filename != "" {
+				return filename
+			}
+			break
+		}
+	}
+
+	// Имя по умолчанию не зависит от времени скачивания
+	sum := sha256.Sum256([]byte(req.URL))
+	return "file_" + hex.EncodeToString(sum[:4])
+}
+
+// isHTML проверяет, что тип содержимого ответа - HTML-страница
+func isHTML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
@@ -0,0 +1,155 @@
+package usecases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+func TestNameResolvers(t *testing.T) {
+	dir := filepath.Join("downloads", "task")
+	taken := map[string]bool{filepath.Join(dir, "image.png"): true}
+	existing := map[string]bool{filepath.Join(dir, "report.pdf"): true, filepath.Join(dir, "report_1.pdf"): true}
+
+	testCases := []struct {
+		name     string
+		strategy NamingStrategy
+		url      string
+		expected string
+	}{
+		{"index keeps free name", NamingIndex, "https://example.com/report.pdf", "report.pdf"},
+		{"index suffixes name taken in task", NamingIndex, "https://example.com/image.png", "image_2.png"},
+		{"numeric skips names on disk", NamingNumeric, "https://example.com/report.pdf", "report_2.pdf"},
+		{"numeric suffixes name taken in task", NamingNumeric, "https://example.com/image.png", "image_1.png"},
+		{"hash keeps free name", NamingHash, "https://example.com/data.csv", "data.csv"},
+		{"hash suffixes name on disk", NamingHash, "https://example.com/report.pdf", "report_b66fe2bb.pdf"},
+		{"host places file in host directory", NamingHost, "https://CDN.example.com/image.png", filepath.Join("cdn.example.com", "image.png")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			resolver := NewNameResolver(tc.strategy)
+			req := interfaces.NameRequest{
+				URL:       tc.url,
+				Dir:       dir,
+				FileIndex: 2,
+				Taken:     func(path string) bool { return taken[path] },
+				Exists:    func(path string) bool { return existing[path] },
+			}
+
+			// Execute
+			path, err := resolver.Resolve(req)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if expected := filepath.Join(dir, tc.expected); path != expected {
+				t.Errorf("Expected path %s, got %s", expected, path)
+			}
+		})
+	}
+}
+
+func TestSourceFileNameURLShapes(t *testing.T) {
+	testCases := []struct {
+		name        string
+		url         string
+		contentType string
+		indexName   string
+		expected    string
+	}{
+		{"plain file", "https://example.com/a/report.pdf", "application/pdf", "index.html", "report.pdf"},
+		{"query string ignored", "https://example.com/report.pdf?sig=a/b", "", "index.html", "report.pdf"},
+		{"escaped name kept", "https://example.com/report%20final.pdf", "", "index.html", "report%20final.pdf"},
+		{"html directory", "https://example.com/docs/", "text/html; charset=utf-8", "index.html", "index.html"},
+		{"html host root", "https://example.com", "text/html", "index.html", "index.html"},
+		{"non-html directory uses last segment", "https://example.com/releases/v1.2/", "application/zip", "index.html", "v1.2"},
+		{"repeated slashes skipped", "https://example.com/a/b//", "", "index.html", "b"},
+		{"index name disabled", "https://example.com/docs/", "text/html", "", "docs"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			name := sourceFileName(interfaces.NameRequest{URL: tc.url, ContentType: tc.contentType, IndexName: tc.indexName})
+
+			// Assert
+			if name != tc.expected {
+				t.Errorf("Expected name %q, got %q", tc.expected, name)
+			}
+		})
+	}
+}
+
+func TestSourceFileNameFallbackIsDeterministic(t *testing.T) {
+	// Execute
+	first := sourceFileName(interfaces.NameRequest{URL: "https://example.com/"})
+	second := sourceFileName(interfaces.NameRequest{URL: "https://example.com/"})
+	other := sourceFileName(interfaces.NameRequest{URL: "https://example.org/"})
+
+	// Assert
+	if first != second {
+		t.Errorf("Expected the same fallback name for the same URL, got %q and %q", first, second)
+	}
+	if first == other {
+		t.Errorf("Expected different fallback names for different URLs, got %q", first)
+	}
+	if len(first) != len("file_")+8 || first[:5] != "file_" {
+		t.Errorf("Expected file_ with 8 hex characters, got %q", first)
+	}
+}
+
+func TestParseNamingStrategy(t *testing.T) {
+	// Execute
+	strategy, err := ParseNamingStrategy("")
+	_, unknownErr := ParseNamingStrategy("random")
+
+	// Assert
+	if err != nil || strategy != NamingIndex {
+		t.Errorf("Expected index by default, got %q (%v)", strategy, err)
+	}
+	if unknownErr == nil {
+		t.Error("Expected error for unknown strategy, got nil")
+	}
+}
+
+func TestProcessTaskNumericNamingKeepsExistingFile(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(dir),
+		WithNameResolver(NewNameResolver(NamingNumeric)),
+		WithFetcher("https", &stubFetcher{content: "new", size: 3}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+	ctx := context.Background()
+	url := "https://example.com/report.txt"
+	task := entities.NewTask([]string{url}, time.Now())
+	task.Files[0] = entities.File{URL: url, Status: "pending"}
+	mockRepo.Create(ctx, task)
+	existing := filepath.Join(dir, task.ID.String(), "report.txt")
+	os.MkdirAll(filepath.Dir(existing), 0755)
+	os.WriteFile(existing, []byte("old"), 0644)
+
+	// Execute
+	err := usecase.ProcessTask(ctx, task)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := filepath.Join(dir, task.ID.String(), "report_1.txt"); task.Files[0].Path != expected {
+		t.Errorf("Expected path %s, got %s", expected, task.Files[0].Path)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "old" {
+		t.Errorf("Expected existing file to be kept, got %q", data)
+	}
+}
@@ -0,0 +1,193 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"file-downloader/internal/entities"
+
+	"github.com/google/uuid"
+)
+
+// OrphansDirName - имя директории внутри директории скачивания, куда перемещаются осиротевшие директории
+const OrphansDirName = ".orphans"
+
+// OrphanAction определяет, что делать с директориями задач, отсутствующих в хранилище
+type OrphanAction string
+
+const (
+	// OrphanActionOff отключает очистку
+	OrphanActionOff OrphanAction = "off"
+	// OrphanActionQuarantine перемещает директории в downloads/.orphans без удаления
+	OrphanActionQuarantine OrphanAction = "quarantine"
+	// OrphanActionRemove удаляет директории вместе с содержимым
+	OrphanActionRemove OrphanAction = "remove"
+)
+
+// ParseOrphanAction преобразует строку в действие с осиротевшими директориями
+func ParseOrphanAction(value string) (OrphanAction, error) {
+	switch OrphanAction(value) {
+	case OrphanActionOff, OrphanActionQuarantine, OrphanActionRemove:
+		return OrphanAction(value), nil
+	case "":
+		return OrphanActionOff, nil
+	default:
+		return "", fmt.Errorf("неизвестное действие с осиротевшими директориями: %q", value)
+	}
+}
+
+// WithOrphanAction задает действие с осиротевшими директориями (по умолчанию очистка отключена)
+func WithOrphanAction(action OrphanAction) TaskOption {
+	return func(u *TaskUsecase) {
+		u.orphanAction = action
+	}
+}
+
+// CleanupOrphans находит директории задач, отсутствующих в хранилище: downloads/{task-id} стратегии by-task
+// и downloads/.trash/{task-id} корзины, - и удаляет их или перемещает в карантин. Учитываются только
+// директории с именем-UUID, измененные раньше modifiedBefore, поэтому директории других программ
+// и только что созданных задач не затрагиваются. Директории существующих задач, в том числе
+// обрабатываемых, удаленных в корзину и перенесенных в хранилище окончательно неудачных задач, не затрагиваются.
+func (u *TaskUsecase) CleanupOrphans(ctx context.Context, modifiedBefore time.Time) (*entities.OrphanReport, error) {
+	report := &entities.OrphanReport{Action: string(u.orphanAction), Directories: []entities.OrphanDirectory{}}
+	root := u.trash.downloadDir
+	if root == "" || u.orphanAction == OrphanActionOff {
+		return report, nil
+	}
+
+	// Директории перечисляются до загрузки задач: задача создается в хранилище раньше своей директории,
+	// поэтому директория любой задачи, созданной к моменту перечисления, найдется среди задач
+	candidates := make(map[string][]string)
+	for _, dir := range []string{root, filepath.Join(root, TrashDirName)} {
+		found, err := taskDirs(dir, modifiedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать директорию %s: %w", dir, err)
+		}
+		for id, path := range found {
+			candidates[id] = append(candidates[id], path)
+		}
+	}
+	if len(candidates) == 0 {
+		return report, nil
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	known, err := u.taskRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	for _, id := range ids {
+		if _, ok := known[id]; ok {
+			continue
+		}
+		if err := u.cleanupOrphanTask(ctx, root, id, candidates[id], report); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// cleanupOrphanTask очищает директории задачи, не найденной в хранилище. Под блокировкой задачи повторно
+// проверяется, что её нет ни среди задач, ни среди окончательно неудачных: задача, перенесенная в хранилище
+// неудачных задач, сохраняет файлы на диске до возврата в обработку или удаления.
+func (u *TaskUsecase) cleanupOrphanTask(ctx context.Context, root, id string, paths []string, report *entities.OrphanReport) error {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	if _, err := u.taskRepo.GetByID(ctx, id); !errors.Is(err, entities.ErrTaskNotFound) {
+		return nil
+	}
+	if u.deadLetters != nil {
+		if _, err := u.deadLetters.Get(ctx, id); !errors.Is(err, entities.ErrDeadLetterNotFound) {
+			return nil
+		}
+	}
+
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		orphan := u.cleanupOrphan(root, path)
+		if orphan.Error == "" {
+			report.Bytes += orphan.Bytes
+		}
+		report.Directories = append(report.Directories, orphan)
+	}
+	return nil
+}
+
+// cleanupOrphan удаляет осиротевшую директорию или перемещает её в карантин с тем же относительным путем
+func (u *TaskUsecase) cleanupOrphan(root, path string) entities.OrphanDirectory {
+	orphan := entities.OrphanDirectory{Path: path}
+	orphan.Files, orphan.Bytes = dirUsage(path)
+
+	var err error
+	switch u.orphanAction {
+	case OrphanActionRemove:
+		err = os.RemoveAll(path)
+	default:
+		var rel string
+		if rel, err = filepath.Rel(root, path); err == nil {
+			err = move(path, filepath.Join(root, OrphansDirName, rel))
+		}
+	}
+	if err != nil {
+		orphan.Error = err.Error()
+	}
+	return orphan
+}
+
+// taskDirs возвращает директории внутри dir, имена которых - ID задач, измененные раньше modifiedBefore.
+// Символические ссылки не учитываются. Отсутствующая директория не считается ошибкой.
+func taskDirs(dir string, modifiedBefore time.Time) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		id, err := uuid.Parse(entry.Name())
+		if err != nil || id.String() != entry.Name() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(modifiedBefore) {
+			continue
+		}
+		found[entry.Name()] = filepath.Join(dir, entry.Name())
+	}
+	return found, nil
+}
+
+// dirUsage возвращает число обычных файлов в директории и их суммарный размер
+func dirUsage(dir string) (int, int64) {
+	files, size := 0, int64(0)
+	filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	return files, size
+}
@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+
+	"github.com/google/uuid"
+)
+
+// makeOrphanDir создает директорию с файлом указанного размера и временем изменения modTime
+func makeOrphanDir(t *testing.T, dir string, size int, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file.bin"), make([]byte, size), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chtimes(dir, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set dir time: %v", err)
+	}
+}
+
+func TestCleanupOrphans(t *testing.T) {
+	testCases := []struct {
+		action      OrphanAction
+		quarantined bool
+	}{
+		{OrphanActionQuarantine, true},
+		{OrphanActionRemove, false},
+	}
+
+	for _, tc := range testCases {
+		// Setup
+		root := t.TempDir()
+		mockRepo := NewMockTaskRepository()
+		usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(root), WithOrphanAction(tc.action))
+		ctx := context.Background()
+		old := time.Now().Add(-2 * time.Hour)
+
+		known, err := usecase.CreateTask(ctx, []string{"https://example.com/a.txt"})
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		knownDir := filepath.Join(root, known.ID.String())
+		orphanID := uuid.NewString()
+		orphanDir := filepath.Join(root, orphanID)
+		trashedOrphan := filepath.Join(root, TrashDirName, uuid.NewString())
+		recentOrphan := filepath.Join(root, uuid.NewString())
+		foreignDir := filepath.Join(root, "not-a-task")
+
+		makeOrphanDir(t, knownDir, 10, old)
+		makeOrphanDir(t, orphanDir, 100, old)
+		makeOrphanDir(t, trashedOrphan, 20, old)
+		makeOrphanDir(t, recentOrphan, 5, time.Now())
+		makeOrphanDir(t, foreignDir, 5, old)
+
+		// Execute
+		report, err := usecase.CleanupOrphans(ctx, time.Now().Add(-time.Hour))
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", tc.action, err)
+		}
+		if len(report.Directories) != 2 || report.Bytes != 120 {
+			t.Errorf("Expected 2 orphan directories with 120 bytes for %s, got %d with %d bytes", tc.action, len(report.Directories), report.Bytes)
+		}
+		if report.Action != string(tc.action) {
+			t.Errorf("Expected action %s, got %s", tc.action, report.Action)
+		}
+		for _, dir := range []string{knownDir, recentOrphan, foreignDir} {
+			if _, err := os.Stat(dir); err != nil {
+				t.Errorf("Expected %s to be kept for %s, got %v", dir, tc.action, err)
+			}
+		}
+		for _, dir := range []string{orphanDir, trashedOrphan} {
+			if _, err := os.Stat(dir); !os.IsNotExist(err) {
+				t.Errorf("Expected %s to be cleaned up for %s, got %v", dir, tc.action, err)
+			}
+		}
+		_, err = os.Stat(filepath.Join(root, OrphansDirName, orphanID, "file.bin"))
+		if quarantined := err == nil; quarantined != tc.quarantined {
+			t.Errorf("Expected quarantined %v for %s, got %v", tc.quarantined, tc.action, quarantined)
+		}
+	}
+}
+
+func TestCleanupOrphansIsOffByDefault(t *testing.T) {
+	// Setup
+	root := t.TempDir()
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(root))
+	orphanDir := filepath.Join(root, uuid.NewString())
+	makeOrphanDir(t, orphanDir, 10, time.Now().Add(-2*time.Hour))
+
+	// Execute
+	report, err := usecase.CleanupOrphans(context.Background(), time.Now().Add(-time.Hour))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if report.Action != string(OrphanActionOff) || len(report.Directories) != 0 {
+		t.Errorf("Expected disabled cleanup without directories, got %s with %d", report.Action, len(report.Directories))
+	}
+	if _, err := os.Stat(orphanDir); err != nil {
+		t.Errorf("Expected orphan directory to be kept, got %v", err)
+	}
+}
+
+func TestCleanupOrphansKeepsDeletedTasks(t *testing.T) {
+	// Setup
+	root := t.TempDir()
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(root), WithOrphanAction(OrphanActionRemove))
+	ctx := context.Background()
+
+	task, err := usecase.CreateTask(ctx, []string{"https://example.com/a.txt"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	path := filepath.Join(root, task.ID.String(), "a.txt")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("hello"), 0644)
+	task.Files[0] = entities.File{URL: task.URLs[0], Path: path, Size: 5, Status: "completed"}
+	task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+	if _, err := usecase.DeleteTask(ctx, task.ID.String()); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	// Execute
+	report, err := usecase.CleanupOrphans(ctx, time.Now().Add(time.Hour))
+
+	// Assert
+	if err != nil || len(report.Directories) != 0 {
+		t.Errorf("Expected no orphans, got %+v (%v)", report, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, TrashDirName, task.ID.String(), task.ID.String(), "a.txt")); err != nil {
+		t.Errorf("Expected trashed file to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// WithOutputDirRoots задает базовые директории, внутри которых задача может указать собственную
+// директорию скачивания output_dir. Без разрешенных директорий output_dir отклоняется.
+func WithOutputDirRoots(roots []string) TaskOption {
+	return func(u *TaskUsecase) {
+		u.outputRoots = nil
+		for _, root := range roots {
+			if abs, err := filepath.Abs(root); err == nil {
+				u.outputRoots = append(u.outputRoots, abs)
+			}
+		}
+	}
+}
+
+// resolveOutputDir проверяет директорию скачивания задачи и возвращает её очищенный путь.
+// Директория должна быть абсолютной и находиться внутри одной из разрешенных директорий,
+// в том числе после раскрытия символических ссылок в уже существующей части пути.
+func (u *TaskUsecase) resolveOutputDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	if len(u.outputRoots) == 0 {
+		return "", fmt.Errorf("%w: output_dir не поддерживается: не заданы разрешенные директории", entities.ErrInvalidRequest)
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("%w: output_dir должен быть абсолютным путем: %q", entities.ErrInvalidRequest, dir)
+	}
+
+	dir = filepath.Clean(dir)
+	for _, root := range u.outputRoots {
+		if !insideDir(root, dir) {
+			continue
+		}
+		// Символическая ссылка внутри разрешенной директории не должна уводить запись за её пределы
+		resolvedRoot, rootErr := filepath.EvalSymlinks(root)
+		resolved, err := evalExisting(dir)
+		if rootErr == nil && err == nil && !insideDir(resolvedRoot, resolved) {
+			continue
+		}
+		return dir, nil
+	}
+	return "", fmt.Errorf("%w: output_dir %q находится вне разрешенных директорий", entities.ErrInvalidRequest, dir)
+}
+
+// evalExisting раскрывает символические ссылки в самой длинной существующей части пути
+// и добавляет к результату еще не созданные директории
+func evalExisting(path string) (string, error) {
+	var missing []string
+	for current := path; ; current = filepath.Dir(current) {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) || filepath.Dir(current) == current {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(current)}, missing...)
+	}
+}
+
+// insideDir возвращает true, если path совпадает с root или находится внутри него
+func insideDir(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// rootDir возвращает директорию скачивания задачи: output_dir задачи или общую директорию скачивания
+func (u *DownloadUsecase) rootDir(task *entities.Task) string {
+	if task.OutputDir != "" {
+		return task.OutputDir
+	}
+	return u.downloadDir
+}
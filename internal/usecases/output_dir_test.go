@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestCreateTaskValidatesOutputDir(t *testing.T) {
+	// Setup
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	testCases := []struct {
+		dir     string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{root, root, false},
+		{filepath.Join(root, "customer-a") + "/", filepath.Join(root, "customer-a"), false},
+		{filepath.Join(root, "..", "other"), "", true},
+		{outside, "", true},
+		{"relative/dir", "", true},
+		{filepath.Join(root, "escape", "customer-b"), "", true},
+	}
+
+	for _, tc := range testCases {
+		mockRepo := NewMockTaskRepository()
+		usecase := NewTaskUsecase(mockRepo, mockRepo, WithOutputDirRoots([]string{root}))
+		spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+		spec.OutputDir = tc.dir
+
+		// Execute
+		task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+		// Assert
+		if tc.wantErr {
+			if !errors.Is(err, entities.ErrInvalidRequest) {
+				t.Errorf("Expected ErrInvalidRequest for %q, got %v", tc.dir, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", tc.dir, err)
+		} else if task.OutputDir != tc.want {
+			t.Errorf("Expected output dir %q, got %q", tc.want, task.OutputDir)
+		}
+	}
+}
+
+func TestCreateTaskRejectsOutputDirWithoutRoots(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+	spec.OutputDir = t.TempDir()
+
+	// Execute
+	_, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+	// Assert
+	if !errors.Is(err, entities.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest without allowed roots, got %v", err)
+	}
+}
+
+func TestProcessTaskUsesOutputDir(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	downloadDir := t.TempDir()
+	outputDir := filepath.Join(t.TempDir(), "customer-a")
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return cannedResponse(req, http.StatusOK, "hello", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(downloadDir), WithRoundTripper(tripper))
+
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	task.OutputDir = outputDir
+	mockRepo.Create(context.Background(), task)
+
+	// Execute
+	err := usecase.ProcessTask(context.Background(), task)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := filepath.Join(outputDir, task.ID.String(), "a.txt")
+	if task.Files[0].Path != expected {
+		t.Errorf("Expected file in output dir %s, got %s", expected, task.Files[0].Path)
+	}
+	if data, err := os.ReadFile(expected); err != nil || string(data) != "hello" {
+		t.Errorf("Expected downloaded content, got %q (%v)", data, err)
+	}
+	if entries, _ := os.ReadDir(downloadDir); len(entries) != 0 {
+		t.Errorf("Expected global download dir to stay empty, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,87 @@
+package usecases
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"file-downloader/internal/entities"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPageSize - размер страницы, если limit не указан
+	DefaultPageSize = 100
+	// MaxPageSize - максимальный размер страницы
+	MaxPageSize = 1000
+)
+
+// Префиксы курсоров: курсор одного списка не принимается другим
+const (
+	taskCursorPrefix = "t:"
+	fileCursorPrefix = "f:"
+)
+
+// encodeTaskCursor кодирует позицию в списке задач в непрозрачный курсор
+func encodeTaskCursor(cursor entities.TaskCursor) string {
+	raw := taskCursorPrefix + strconv.FormatInt(cursor.CreatedAt.UnixNano(), 10) + ":" + cursor.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTaskCursor декодирует курсор в позицию в списке задач; пустой курсор указывает на начало списка
+func decodeTaskCursor(cursor string) (entities.TaskCursor, error) {
+	if cursor == "" {
+		return entities.TaskCursor{}, nil
+	}
+
+	raw, ok := decodeCursor(cursor, taskCursorPrefix)
+	if !ok {
+		return entities.TaskCursor{}, entities.ErrInvalidCursor
+	}
+	nanos, id, found := strings.Cut(raw, ":")
+	if !found {
+		return entities.TaskCursor{}, entities.ErrInvalidCursor
+	}
+	createdAt, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return entities.TaskCursor{}, entities.ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return entities.TaskCursor{}, entities.ErrInvalidCursor
+	}
+
+	return entities.TaskCursor{CreatedAt: time.Unix(0, createdAt), ID: id}, nil
+}
+
+// encodeFileCursor кодирует индекс последнего полученного файла задачи в непрозрачный курсор
+func encodeFileCursor(index int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fileCursorPrefix + strconv.Itoa(index)))
+}
+
+// decodeFileCursor декодирует курсор в индекс последнего полученного файла; пустой курсор возвращает -1
+func decodeFileCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return -1, nil
+	}
+
+	raw, ok := decodeCursor(cursor, fileCursorPrefix)
+	if !ok {
+		return 0, entities.ErrInvalidCursor
+	}
+	index, err := strconv.Atoi(raw)
+	if err != nil || index < 0 {
+		return 0, entities.ErrInvalidCursor
+	}
+	return index, nil
+}
+
+// decodeCursor декодирует курсор и возвращает его содержимое без префикса
+func decodeCursor(cursor, prefix string) (string, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(string(raw), prefix), true
+}
@@ -0,0 +1,108 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// presetNamePattern ограничивает имена пресетов символами, допустимыми в пути URL без экранирования
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// WithPresets подключает хранилище пресетов параметров задач
+func WithPresets(repo interfaces.PresetRepository) TaskOption {
+	return func(u *TaskUsecase) {
+		u.presets = repo
+	}
+}
+
+// SavePreset проверяет и сохраняет пресет, заменяя пресет с тем же именем. Параметры пресета проверяются
+// так же, как параметры задачи; при создании задачи они проверяются повторно вместе с параметрами запроса.
+func (u *TaskUsecase) SavePreset(ctx context.Context, preset entities.Preset) (*entities.Preset, error) {
+	if u.presets == nil {
+		return nil, fmt.Errorf("%w: пресеты отключены", entities.ErrInvalidRequest)
+	}
+
+	preset.Name = strings.TrimSpace(preset.Name)
+	if !presetNamePattern.MatchString(preset.Name) {
+		return nil, fmt.Errorf("%w: имя пресета должно состоять из 1-64 латинских букв, цифр, '_', '-' или '.': %q",
+			entities.ErrInvalidRequest, preset.Name)
+	}
+	if preset.MaxConcurrency > 0 {
+		preset.MaxConcurrency = clampConcurrency(preset.MaxConcurrency)
+	}
+	preset.FailureThreshold = strings.TrimSpace(preset.FailureThreshold)
+	if _, err := u.validateSettings(preset.Apply(entities.TaskSpec{})); err != nil {
+		return nil, err
+	}
+
+	now := u.clock.Now()
+	preset.CreatedAt, preset.UpdatedAt = now, now
+	if existing, err := u.presets.Get(ctx, preset.Name); err == nil {
+		preset.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, entities.ErrPresetNotFound) {
+		return nil, fmt.Errorf("не удалось получить пресет: %w", err)
+	}
+
+	if err := u.presets.Save(ctx, &preset); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить пресет: %w", err)
+	}
+
+	log.Printf("Сохранен пресет %s", preset.Name)
+	return &preset, nil
+}
+
+// GetPreset получает пресет по имени
+func (u *TaskUsecase) GetPreset(ctx context.Context, name string) (*entities.Preset, error) {
+	if u.presets == nil {
+		return nil, fmt.Errorf("%w: %s", entities.ErrPresetNotFound, name)
+	}
+
+	preset, err := u.presets.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пресет: %w", err)
+	}
+	return preset, nil
+}
+
+// ListPresets возвращает все пресеты, упорядоченные по имени
+func (u *TaskUsecase) ListPresets(ctx context.Context) ([]*entities.Preset, error) {
+	if u.presets == nil {
+		return []*entities.Preset{}, nil
+	}
+
+	presets, err := u.presets.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить пресеты: %w", err)
+	}
+	return presets, nil
+}
+
+// DeletePreset удаляет пресет. Задачи, созданные по пресету, сохраняют унаследованные параметры.
+func (u *TaskUsecase) DeletePreset(ctx context.Context, name string) error {
+	if u.presets == nil {
+		return fmt.Errorf("%w: %s", entities.ErrPresetNotFound, name)
+	}
+
+	if err := u.presets.Delete(ctx, name); err != nil {
+		return fmt.Errorf("не удалось удалить пресет: %w", err)
+	}
+
+	log.Printf("Удален пресет %s", name)
+	return nil
+}
+
+// getPreset получает пресет, на который ссылается запрос создания задачи: неизвестный пресет - ошибка запроса
+func (u *TaskUsecase) getPreset(ctx context.Context, name string) (*entities.Preset, error) {
+	preset, err := u.GetPreset(ctx, name)
+	if errors.Is(err, entities.ErrPresetNotFound) {
+		return nil, fmt.Errorf("%w: %w", entities.ErrInvalidRequest, err)
+	}
+	return preset, err
+}
@@ -0,0 +1,153 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/adapters/repository"
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestCreateTaskInheritsPreset(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	presets := repository.NewFileBasedPresetRepository(filepath.Join(t.TempDir(), "presets.json"))
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithPresets(presets))
+	ctx := context.Background()
+	compress := true
+	_, err := usecase.SavePreset(ctx, entities.Preset{
+		Name:             "mirror",
+		Headers:          map[string]string{"Authorization": "Bearer preset", "X-Source": "preset"},
+		MaxConcurrency:   2,
+		FailureThreshold: "50%",
+		Compress:         &compress,
+		RetryPolicy:      &entities.TaskRetryPolicy{MaxAttempts: 5},
+	})
+	if err != nil {
+		t.Fatalf("Expected preset to be saved, got %v", err)
+	}
+
+	spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+	spec.Preset = "mirror"
+	spec.Headers = map[string]string{"authorization": "Bearer inline"}
+	spec.MaxConcurrency = 4
+
+	// Execute
+	task, err := usecase.CreateTaskFromSpec(ctx, spec)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if task.Preset != "mirror" {
+		t.Errorf("Expected task to record preset mirror, got %q", task.Preset)
+	}
+	if task.MaxConcurrency != 4 {
+		t.Errorf("Expected inline max_concurrency 4 to override preset, got %d", task.MaxConcurrency)
+	}
+	if task.FailureThreshold != "50%" || task.Compress == nil || !*task.Compress {
+		t.Errorf("Expected failure threshold and compression from preset, got %q and %v", task.FailureThreshold, task.Compress)
+	}
+	if task.RetryPolicy == nil || task.RetryPolicy.MaxAttempts != 5 {
+		t.Errorf("Expected retry policy from preset, got %+v", task.RetryPolicy)
+	}
+	if len(task.Headers) != 2 || task.Headers["authorization"] != "Bearer inline" || task.Headers["X-Source"] != "preset" {
+		t.Errorf("Expected inline authorization to replace the preset one, got %v", task.Headers)
+	}
+}
+
+func TestCreateTaskRejectsUnknownPreset(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	presets := repository.NewFileBasedPresetRepository(filepath.Join(t.TempDir(), "presets.json"))
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithPresets(presets))
+	spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+	spec.Preset = "missing"
+
+	// Execute
+	_, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+	// Assert
+	if !errors.Is(err, entities.ErrInvalidRequest) || !errors.Is(err, entities.ErrPresetNotFound) {
+		t.Errorf("Expected ErrInvalidRequest wrapping ErrPresetNotFound, got %v", err)
+	}
+}
+
+func TestSavePresetValidatesSettings(t *testing.T) {
+	testCases := []struct {
+		name   string
+		preset entities.Preset
+	}{
+		{"invalid name", entities.Preset{Name: "bad name"}},
+		{"negative concurrency", entities.Preset{Name: "p", MaxConcurrency: -1}},
+		{"invalid threshold", entities.Preset{Name: "p", FailureThreshold: "lots"}},
+		{"invalid callback", entities.Preset{Name: "p", CallbackURL: "ftp://hooks.example.com"}},
+		{"output dir outside roots", entities.Preset{Name: "p", OutputDir: "/etc"}},
+	}
+
+	for _, tc := range testCases {
+		// Setup
+		mockRepo := NewMockTaskRepository()
+		presets := repository.NewFileBasedPresetRepository(filepath.Join(t.TempDir(), "presets.json"))
+		usecase := NewTaskUsecase(mockRepo, mockRepo, WithPresets(presets))
+
+		// Execute
+		_, err := usecase.SavePreset(context.Background(), tc.preset)
+
+		// Assert
+		if !errors.Is(err, entities.ErrInvalidRequest) {
+			t.Errorf("%s: expected ErrInvalidRequest, got %v", tc.name, err)
+		}
+		if list, _ := usecase.ListPresets(context.Background()); len(list) != 0 {
+			t.Errorf("%s: expected invalid preset not to be stored, got %d presets", tc.name, len(list))
+		}
+	}
+}
+
+func TestSavePresetKeepsCreationTimeAndPersists(t *testing.T) {
+	// Setup
+	path := filepath.Join(t.TempDir(), "presets.json")
+	clock := testutil.NewFakeClock(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo,
+		WithPresets(repository.NewFileBasedPresetRepository(path)), WithTaskClock(clock))
+	ctx := context.Background()
+	created := clock.Now()
+	if _, err := usecase.SavePreset(ctx, entities.Preset{Name: "nightly", MaxConcurrency: 1}); err != nil {
+		t.Fatalf("Expected preset to be saved, got %v", err)
+	}
+	clock.Advance(time.Hour)
+
+	// Execute
+	_, err := usecase.SavePreset(ctx, entities.Preset{Name: "nightly", MaxConcurrency: 3})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected preset to be replaced, got %v", err)
+	}
+	reloaded := repository.NewFileBasedPresetRepository(path)
+	if err := reloaded.LoadPresets(); err != nil {
+		t.Fatalf("Failed to load presets: %v", err)
+	}
+	preset, err := reloaded.Get(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("Expected preset to survive reload, got %v", err)
+	}
+	if preset.MaxConcurrency != 3 {
+		t.Errorf("Expected replaced max_concurrency 3, got %d", preset.MaxConcurrency)
+	}
+	if !preset.CreatedAt.Equal(created) || !preset.UpdatedAt.Equal(created.Add(time.Hour)) {
+		t.Errorf("Expected created_at %v and updated_at %v, got %v and %v", created, created.Add(time.Hour), preset.CreatedAt, preset.UpdatedAt)
+	}
+
+	if err := usecase.DeletePreset(ctx, "nightly"); err != nil {
+		t.Fatalf("Expected preset to be deleted, got %v", err)
+	}
+	if _, err := usecase.GetPreset(ctx, "nightly"); !errors.Is(err, entities.ErrPresetNotFound) {
+		t.Errorf("Expected ErrPresetNotFound after delete, got %v", err)
+	}
+}
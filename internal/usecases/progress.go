@@ -0,0 +1,185 @@
+package usecases
+
+import (
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// ProgressConfig задает, как часто сохраняется прогресс скачивания файла.
+// Прогресс сохраняется, только если с прошлого сохранения прошло не меньше Interval
+// и скачано не меньше MinBytes, чтобы не перезаписывать файл состояния на каждый блок данных.
+type ProgressConfig struct {
+	Interval time.Duration
+	MinBytes int64
+}
+
+// DefaultProgressConfig возвращает параметры сохранения прогресса по умолчанию
+func DefaultProgressConfig() ProgressConfig {
+	return ProgressConfig{
+		Interval: 5 * time.Second,
+		MinBytes: 1 << 20,
+	}
+}
+
+// progressTracker учитывает скачанные байты файла и периодически сохраняет задачу.
+// Один трекер может использоваться несколькими потоками данных одного файла (сегментами).
+type progressTracker struct {
+	mu         sync.Mutex
+	file       *entities.File
+	config     ProgressConfig
+	clock      interfaces.Clock
+	save       func() error
+	savedAt    time.Time
+	savedBytes int64
+
+	speed   *speedMonitor           // монитор скорости (nil - медленные скачивания не отслеживаются)
+	onSlow  func(speed int64) error // вызывается при скорости ниже минимальной; ошибка прерывает скачивание
+	stopErr error                   // ошибка onSlow остановившегося скачивания, возвращаемая чтением
+
+	quota   *diskQuota // дисковая квота задачи (nil - без ограничения)
+	charged int64      // байт, учтенных в квоте этим трекером
+}
+
+// newProgressTracker создает трекер прогресса файла
+func newProgressTracker(file *entities.File, config ProgressConfig, clock interfaces.Clock, save func() error) *progressTracker {
+	return &progressTracker{
+		file:    file,
+		config:  config,
+		clock:   clock,
+		save:    save,
+		savedAt: clock.Now(),
+	}
+}
+
+// watchSpeed включает отслеживание скорости скачивания файла по политике; onSlow вызывается
+// по окончании каждого окна, средняя скорость за которое ниже минимальной
+func (t *progressTracker) watchSpeed(policy SlowDownloadPolicy, onSlow func(speed int64) error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.speed = newSpeedMonitor(policy, t.clock.Now())
+	t.onSlow = onSlow
+}
+
+// add учитывает n скачанных байт и сохраняет прогресс при значимом приросте.
+// Возвращает ошибку, если скачивание нужно прервать из-за низкой скорости.
+func (t *progressTracker) add(n int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopErr != nil {
+		return t.stopErr
+	}
+	t.file.Downloaded += int64(n)
+	t.charged += int64(n)
+	if err := t.quota.add(int64(n)); err != nil {
+		return err
+	}
+	if t.speed != nil {
+		if speed, slow := t.speed.sample(n, t.clock.Now()); slow {
+			if err := t.onSlow(speed); err != nil {
+				return err
+			}
+		}
+	}
+	if t.config.Interval <= 0 || t.file.Downloaded-t.savedBytes < t.config.MinBytes {
+		return nil
+	}
+
+	if now := t.clock.Now(); now.Sub(t.savedAt) >= t.config.Interval {
+		t.savedAt = now
+		t.savedBytes = t.file.Downloaded
+		if err := t.save(); err != nil {
+			log.Printf("Не удалось сохранить прогресс скачивания %s: %v", t.file.URL, err)
+		}
+	}
+	return nil
+}
+
+// watchStall проверяет скорость по часам раз в окно политики: скорость измеряется при чтении данных, поэтому
+// полностью остановившееся скачивание иначе не было бы замечено. Окно без данных считается медленным; если onSlow
+// возвращает ошибку, вызывается abort, прерывающий ожидание данных, а чтение возвращает эту ошибку.
+// Возвращает функцию, останавливающую проверку; без watchSpeed проверка не запускается.
+func (t *progressTracker) watchStall(abort func()) func() {
+	t.mu.Lock()
+	speed := t.speed
+	t.mu.Unlock()
+	if speed == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.clock.After(speed.policy.Window):
+			}
+			if t.checkStall() {
+				abort()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// checkStall завершает окно без данных с нулевой скоростью; возвращает true, если скачивание нужно прервать
+func (t *progressTracker) checkStall() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopErr != nil || !t.speed.stalled(t.clock.Now()) {
+		return false
+	}
+	if err := t.onSlow(0); err != nil {
+		t.stopErr = err
+		return true
+	}
+	return false
+}
+
+// chargeTo включает учет скачиваемых байт в дисковой квоте задачи
+func (t *progressTracker) chargeTo(quota *diskQuota) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.quota = quota
+}
+
+// refund возвращает в квоту байты, учтенные трекером: данные незавершенного файла перезаписываются
+// следующей попыткой или удаляются
+func (t *progressTracker) refund() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.quota.release(t.charged)
+	t.charged = 0
+}
+
+// reader оборачивает поток данных учетом прогресса
+func (t *progressTracker) reader(r io.Reader) io.Reader {
+	return &progressReader{reader: r, tracker: t}
+}
+
+// progressReader передает прочитанные байты трекеру прогресса
+type progressReader struct {
+	reader  io.Reader
+	tracker *progressTracker
+}
+
+// Read читает данные и учитывает их в прогрессе
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if stopErr := r.tracker.add(n); stopErr != nil {
+		return n, stopErr
+	}
+	return n, err
+}
@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestProgressReaderPersistsOnMeaningfulDeltas(t *testing.T) {
+	// Setup
+	file := &entities.File{URL: "https://example.com/file.bin"}
+	saves := 0
+	config := ProgressConfig{Interval: time.Nanosecond, MinBytes: 4}
+	reader := newProgressTracker(file, config, systemClock{}, func() error {
+		saves++
+		return nil
+	}).reader(iotest.OneByteReader(strings.NewReader("0123456789")))
+
+	// Execute
+	data, err := io.ReadAll(reader)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(data) != 10 || file.Downloaded != 10 {
+		t.Errorf("Expected 10 bytes downloaded, got %d (read %d)", file.Downloaded, len(data))
+	}
+	if saves != 2 {
+		t.Errorf("Expected 2 saves for 10 bytes with 4-byte threshold, got %d", saves)
+	}
+}
+
+func TestProgressReaderThrottlesByInterval(t *testing.T) {
+	// Setup
+	file := &entities.File{URL: "https://example.com/file.bin"}
+	saves := 0
+	config := ProgressConfig{Interval: time.Hour, MinBytes: 1}
+	reader := newProgressTracker(file, config, systemClock{}, func() error {
+		saves++
+		return nil
+	}).reader(iotest.OneByteReader(strings.NewReader("0123456789")))
+
+	// Execute
+	io.ReadAll(reader)
+
+	// Assert
+	if saves != 0 {
+		t.Errorf("Expected no saves within the interval, got %d", saves)
+	}
+	if file.Downloaded != 10 {
+		t.Errorf("Expected 10 bytes downloaded, got %d", file.Downloaded)
+	}
+}
@@ -0,0 +1,134 @@
+package usecases
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"file-downloader/internal/entities"
+)
+
+// WithDiskQuota ограничивает объем файлов одной задачи на диске значением limit байт; disk_quota задачи
+// может только уменьшить его. 0 отключает ограничение по умолчанию. При cleanup превышение квоты удаляет
+// и уже скачанные файлы задачи, иначе сохраняются файлы, скачанные до превышения.
+func WithDiskQuota(limit int64, cleanup bool) DownloadOption {
+	return func(u *DownloadUsecase) {
+		if limit > 0 {
+			u.diskQuota = limit
+		}
+		u.quotaCleanup = cleanup
+	}
+}
+
+// diskQuotaFor возвращает дисковую квоту задачи (0 - без ограничения)
+func (u *DownloadUsecase) diskQuotaFor(task *entities.Task) int64 {
+	if task.DiskQuota > 0 && (u.diskQuota == 0 || task.DiskQuota < u.diskQuota) {
+		return task.DiskQuota
+	}
+	return u.diskQuota
+}
+
+// diskQuota учитывает байты, записанные одновременно скачиваемыми файлами задачи, и при превышении
+// предела один раз вызывает exceeded, отменяющий остальные скачивания
+type diskQuota struct {
+	limit    int64
+	used     atomic.Int64
+	once     sync.Once
+	exceeded func(error)
+}
+
+// newDiskQuota создает квоту задачи с пределом limit; уже скачанные файлы и сохраненные части
+// недокачанных учитываются сразу. Возвращает nil, если квота не задана.
+func newDiskQuota(task *entities.Task, limit int64, exceeded func(error)) *diskQuota {
+	if limit <= 0 {
+		return nil
+	}
+	q := &diskQuota{limit: limit, exceeded: exceeded}
+	for _, file := range task.Files {
+		switch {
+		case file.Status == "completed" && file.Compression != "":
+			q.used.Add(file.StoredSize)
+		case file.Status == "completed":
+			q.used.Add(file.Size)
+		case file.PartialPath != "":
+			q.used.Add(file.ResumeOffset)
+		}
+	}
+	return q
+}
+
+// add учитывает n записанных байт и возвращает ErrDiskQuota, если квота превышена
+func (q *diskQuota) add(n int64) error {
+	if q == nil {
+		return nil
+	}
+	return q.check(q.used.Add(n))
+}
+
+// reserve проверяет до начала записи, что файл заявленного источником размера size
+// поместится в квоту вместе с уже записанными данными
+func (q *diskQuota) reserve(size int64) error {
+	if q == nil || size <= 0 {
+		return nil
+	}
+	return q.check(q.used.Load() + size)
+}
+
+// release возвращает в квоту n байт удаленных или перезаписываемых данных
+func (q *diskQuota) release(n int64) {
+	if q != nil {
+		q.used.Add(-n)
+	}
+}
+
+// check возвращает ErrDiskQuota и сообщает о превышении, если used больше предела
+func (q *diskQuota) check(used int64) error {
+	if used <= q.limit {
+		return nil
+	}
+	err := fmt.Errorf("%w: %d байт при квоте %d", entities.ErrDiskQuota, used, q.limit)
+	q.once.Do(func() { q.exceeded(err) })
+	return err
+}
+
+// limitDiskUsage включает дисковую квоту задачи: при её превышении остальные скачивания отменяются,
+// как при превышении порога ошибок (вызывается после watchFailures)
+func (b *fileBatch) limitDiskUsage(limit int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.quota = newDiskQuota(b.task, limit, b.failQuota)
+}
+
+// failQuota запоминает превышение дисковой квоты и отменяет остальные скачивания задачи
+func (b *fileBatch) failQuota(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.abortErr != nil {
+		return
+	}
+	b.abortErr = err
+	if b.abort != nil {
+		b.abort()
+	}
+}
+
+// removeQuotaFiles удаляет данные файлов задачи, отмененных из-за превышения дисковой квоты;
+// при cleanup удаляются и скачанные файлы, которые тогда тоже отмечаются ошибкой квоты
+func (u *DownloadUsecase) removeQuotaFiles(file *entities.File, reason string) {
+	if file.Status == "completed" {
+		if !u.quotaCleanup {
+			return
+		}
+		for _, path := range file.Extracted {
+			os.Remove(path)
+		}
+		file.Extracted = nil
+		file.Status = "failed"
+		file.Error = reason
+		file.ErrorKind = entities.ErrorKindDiskQuota
+	}
+	if file.Path != "" {
+		os.Remove(file.Path)
+	}
+}
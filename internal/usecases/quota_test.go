@@ -0,0 +1,113 @@
+package usecases
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// processQuotaTask downloads three 100-byte files one at a time under the given quota
+func processQuotaTask(t *testing.T, size int64, taskQuota int64, opts ...DownloadOption) *entities.Task {
+	t.Helper()
+	mockRepo := NewMockTaskRepository()
+	opts = append([]DownloadOption{
+		WithDownloadDir(t.TempDir()),
+		WithLayout(LayoutFlat),
+		WithFileConcurrency(1),
+		WithFetcher("https", &stubFetcher{content: strings.Repeat("x", 100), size: size}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	}, opts...)
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, opts...)
+	ctx := context.Background()
+	urls := []string{"https://example.com/a.txt", "https://example.com/b.txt", "https://example.com/c.txt"}
+	task := entities.NewTask(urls, time.Now())
+	for i, url := range urls {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	task.DiskQuota = taskQuota
+	mockRepo.Create(ctx, task)
+
+	if err := usecase.ProcessTask(ctx, task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	return stored
+}
+
+func TestProcessTaskEnforcesDiskQuota(t *testing.T) {
+	testCases := []struct {
+		name      string
+		size      int64
+		quota     int64
+		taskQuota int64
+	}{
+		{"reported size exceeds quota", 100, 250, 0},
+		{"unknown size exceeds quota while copying", -1, 250, 0},
+		{"task quota lowers the default", 100, 1000, 250},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Execute
+			task := processQuotaTask(t, tc.size, tc.taskQuota, WithDiskQuota(tc.quota, false))
+
+			// Assert
+			if task.Status != entities.TaskStatusFailed || task.ErrorKind != entities.ErrorKindDiskQuota {
+				t.Fatalf("Expected failed task with disk_quota error, got %s (%s: %s)", task.Status, task.ErrorKind, task.Error)
+			}
+			for i, file := range task.Files[:2] {
+				if file.Status != "completed" {
+					t.Errorf("Expected file %d downloaded before the quota to stay completed, got %s", i, file.Status)
+				}
+				if _, err := os.Stat(file.Path); err != nil {
+					t.Errorf("Expected file %d to be kept on disk, got %v", i, err)
+				}
+			}
+			last := task.Files[2]
+			if last.Status != "failed" || last.ErrorKind != entities.ErrorKindDiskQuota {
+				t.Errorf("Expected last file to fail with disk_quota, got %s (%s)", last.Status, last.ErrorKind)
+			}
+			if last.Attempts > 1 {
+				t.Errorf("Expected no retries after the quota is exceeded, got %d attempts", last.Attempts)
+			}
+			if _, err := os.Stat(last.Path); last.Path == "" || !os.IsNotExist(err) {
+				t.Errorf("Expected data of the cancelled file at %q to be removed, got %v", last.Path, err)
+			}
+		})
+	}
+}
+
+func TestProcessTaskDiskQuotaCleanup(t *testing.T) {
+	// Execute
+	task := processQuotaTask(t, 100, 0, WithDiskQuota(250, true))
+
+	// Assert
+	if task.Status != entities.TaskStatusFailed {
+		t.Fatalf("Expected failed task, got %s", task.Status)
+	}
+	for i, file := range task.Files {
+		if file.Status != "failed" || file.ErrorKind != entities.ErrorKindDiskQuota {
+			t.Errorf("Expected file %d to fail with disk_quota, got %s (%s)", i, file.Status, file.ErrorKind)
+		}
+		if file.Path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.Path); !os.IsNotExist(err) {
+			t.Errorf("Expected file %d to be removed, got %v", i, err)
+		}
+	}
+}
+
+func TestProcessTaskWithinDiskQuota(t *testing.T) {
+	// Execute
+	task := processQuotaTask(t, 100, 0, WithDiskQuota(300, true))
+
+	// Assert
+	if task.Status != entities.TaskStatusCompleted {
+		t.Errorf("Expected completed task within the quota, got %s (%s)", task.Status, task.Error)
+	}
+}
@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"file-downloader/internal/interfaces"
+)
+
+// RequestRateLimit ограничивает частоту HTTP(S)-запросов скачивания всего сервиса, например
+// чтобы не превысить квоту источника на число запросов в минуту
+type RequestRateLimit struct {
+	Requests int           // число запросов за интервал (0 - без ограничения)
+	Interval time.Duration // интервал, за который допускается Requests запросов
+	Burst    int           // число запросов, которые можно отправить подряд без ожидания (0 - Requests)
+}
+
+// WithRequestRateLimit ограничивает частоту HTTP(S)-запросов скачивания. Запрос, превышающий ограничение,
+// ждет своей очереди, а не завершается ошибкой; ожидание прерывается только отменой скачивания.
+func WithRequestRateLimit(limit RequestRateLimit) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.requestLimit = limit
+	}
+}
+
+// requestLimiter - общий для всех скачиваний ограничитель частоты запросов по алгоритму маркерной корзины.
+// Каждый запрос резервирует маркер; если маркеров нет, запрос ждет, пока зарезервированный маркер накопится,
+// поэтому ожидающие запросы отправляются в порядке очереди.
+type requestLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	rate     float64 // маркеров в наносекунду
+	burst    float64
+	tokens   float64   // доступные маркеры; отрицательное значение - маркеры, зарезервированные ожидающими
+	updated  time.Time // время последнего пополнения
+	waiting  int
+	recent   []time.Time // время запросов за последний интервал по возрастанию
+	clock    interfaces.Clock
+	metrics  interfaces.MetricsRecorder
+}
+
+// newRequestLimiter создает ограничитель частоты запросов; при нулевом ограничении возвращает nil
+func newRequestLimiter(limit RequestRateLimit, clock interfaces.Clock, metrics interfaces.MetricsRecorder) *requestLimiter {
+	if limit.Requests <= 0 || limit.Interval <= 0 {
+		return nil
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Requests
+	}
+	return &requestLimiter{
+		interval: limit.Interval,
+		rate:     float64(limit.Requests) / float64(limit.Interval),
+		burst:    float64(limit.Burst),
+		tokens:   float64(limit.Burst),
+		updated:  clock.Now(),
+		clock:    clock,
+		metrics:  metrics,
+	}
+}
+
+// wait ждет разрешения на запрос. Запрос учитывается в метриках частоты запросов, как только разрешен.
+// При отмене контекста зарезервированный маркер возвращается и возвращается ошибка контекста.
+func (l *requestLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := l.clock.Now()
+	l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.updated))*l.rate)
+	l.updated = now
+	l.tokens--
+	if l.tokens >= 0 {
+		l.recordLocked(now)
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-l.tokens / l.rate)
+	l.waiting++
+	l.metrics.IncCounter("downloader_request_limiter_waits_total", nil, 1)
+	l.metrics.SetGauge("downloader_request_limiter_waiting", nil, float64(l.waiting))
+	l.mu.Unlock()
+
+	var err error
+	select {
+	case <-l.clock.After(delay):
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting--
+	l.metrics.SetGauge("downloader_request_limiter_waiting", nil, float64(l.waiting))
+	if err != nil {
+		l.tokens++
+		return err
+	}
+	l.recordLocked(l.clock.Now())
+	return nil
+}
+
+// recordLocked учитывает разрешенный запрос в счетчике и в частоте за последний интервал
+// (вызывающий должен держать блокировку)
+func (l *requestLimiter) recordLocked(now time.Time) {
+	cutoff := now.Add(-l.interval)
+	expired := 0
+	for expired < len(l.recent) && !l.recent[expired].After(cutoff) {
+		expired++
+	}
+	l.recent = append(l.recent[expired:], now)
+
+	l.metrics.IncCounter("downloader_requests_total", nil, 1)
+	l.metrics.SetGauge("downloader_request_rate", nil, float64(len(l.recent)))
+}
@@ -0,0 +1,100 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+// waitForClockWaiters waits until the fake clock has the given number of pending timers
+func waitForClockWaiters(t *testing.T, clock *testutil.FakeClock, count int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for clock.Waiters() != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d clock waiters, got %d", count, clock.Waiters())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestProcessTaskWaitsForRequestRateLimit(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	metrics := &recordingMetrics{counters: make(map[string]float64)}
+	var requests atomic.Int32
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return cannedResponse(req, http.StatusOK, "data", nil), nil
+	})
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithClock(clock),
+		WithMetrics(metrics),
+		WithRequestRateLimit(RequestRateLimit{Requests: 2, Interval: time.Minute}),
+	)
+	task := entities.NewTask([]string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}, time.Now())
+	for i, url := range task.URLs {
+		task.Files[i] = entities.File{URL: url, Status: "pending"}
+	}
+	mockRepo.Create(context.Background(), task)
+
+	// Execute
+	done := make(chan error, 1)
+	go func() { done <- usecase.ProcessTask(context.Background(), task) }()
+	waitForClockWaiters(t, clock, 1)
+
+	// Assert: the burst is spent and the third request waits instead of failing
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("Expected 2 requests within the burst, got %d", got)
+	}
+	clock.Advance(30 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Expected the waiting request to be sent, got %d requests", got)
+	}
+	for _, file := range task.Files {
+		if file.Status != "completed" {
+			t.Errorf("Expected file %s to complete, got %s (%s)", file.URL, file.Status, file.Error)
+		}
+	}
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.counters["downloader_requests_total"] != 3 || metrics.counters["downloader_request_limiter_waits_total"] != 1 {
+		t.Errorf("Expected 3 requests and 1 wait in metrics, got %v", metrics.counters)
+	}
+}
+
+func TestRequestLimiterReturnsTokenOnCancel(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	limiter := newRequestLimiter(RequestRateLimit{Requests: 1, Interval: time.Minute}, clock, noopMetrics{})
+	limiter.wait(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- limiter.wait(ctx) }()
+	waitForClockWaiters(t, clock, 1)
+
+	// Execute
+	cancel()
+	err := <-done
+
+	// Assert
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if limiter.tokens != 0 || limiter.waiting != 0 {
+		t.Errorf("Expected the reserved token to be returned, got %v tokens and %d waiting", limiter.tokens, limiter.waiting)
+	}
+}
@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"log"
+	"sync/atomic"
+
+	"file-downloader/internal/entities"
+)
+
+// ResourceBudget ограничивает ресурсы, которые могут занять одновременно скачиваемые файлы всех задач.
+// Нулевое поле не ограничивает соответствующий ресурс.
+type ResourceBudget struct {
+	Memory          int64 // память буферов копирования в байтах
+	FileDescriptors int   // соединения и открытые файлы
+}
+
+// WithResourceBudget ограничивает общее число одновременно скачиваемых файлов так, чтобы их оценочная
+// стоимость (соединения × буфер копирования) укладывалась в бюджет. Вместе с WithFairFileSlots действует
+// меньшее из ограничений, а слоты распределяются между задачами так же поровну.
+func WithResourceBudget(budget ResourceBudget) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.budget = budget
+	}
+}
+
+// fileCost возвращает оценку памяти и дескрипторов одного скачиваемого файла. Оценка берется по худшему
+// случаю: файл скачивается всеми сегментами, и каждый сегмент держит соединение, открытый файл и буфер копирования.
+func (u *DownloadUsecase) fileCost() (memory int64, fds int) {
+	connections := 1
+	if u.segments.Count >= 2 {
+		connections = u.segments.Count
+	}
+	return int64(connections) * int64(u.buffers.size), 2 * connections
+}
+
+// initFileSlots создает общий распределитель слотов скачивания по меньшему из ограничений WithFairFileSlots
+// и WithResourceBudget (вызывается после применения опций)
+func (u *DownloadUsecase) initFileSlots() {
+	slots := u.fairSlots
+	memory, fds := u.fileCost()
+	limit := func(allowed int) {
+		allowed = max(allowed, 1)
+		if slots == 0 || allowed < slots {
+			slots = allowed
+		}
+	}
+	if u.budget.Memory > 0 {
+		limit(int(u.budget.Memory / memory))
+	}
+	if u.budget.FileDescriptors > 0 {
+		limit(u.budget.FileDescriptors / fds)
+	}
+	if slots == 0 {
+		return
+	}
+
+	u.fair = newFairScheduler(slots)
+	if u.budget != (ResourceBudget{}) {
+		log.Printf("Бюджет ресурсов: одновременно скачивается не больше %d файлов (на файл до %d байт памяти и %d дескрипторов)",
+			slots, memory, fds)
+	}
+}
+
+// activeFiles считает скачиваемые файлы всех задач
+type activeFiles struct {
+	count atomic.Int64
+}
+
+// track учитывает начало скачивания файла и возвращает функцию учета его окончания
+func (a *activeFiles) track(release func()) func() {
+	a.count.Add(1)
+	var done atomic.Bool
+	return func() {
+		if done.CompareAndSwap(false, true) {
+			a.count.Add(-1)
+			release()
+		}
+	}
+}
+
+// ResourceUsage возвращает оценку ресурсов, занятых скачиваемыми сейчас файлами, и бюджет
+func (u *DownloadUsecase) ResourceUsage() entities.ResourceUsage {
+	active := int(u.active.count.Load())
+	memory, fds := u.fileCost()
+	usage := entities.ResourceUsage{
+		ActiveFiles:     active,
+		MemoryBytes:     int64(active) * memory,
+		MemoryBudget:    u.budget.Memory,
+		FileDescriptors: active * fds,
+		FDBudget:        u.budget.FileDescriptors,
+	}
+	if u.fair != nil {
+		usage.FileLimit = u.fair.capacity
+	}
+	return usage
+}
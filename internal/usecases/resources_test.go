@@ -0,0 +1,64 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+func TestResourceBudgetLimitsFileSlots(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      []DownloadOption
+		fileLimit int
+	}{
+		{"no limits", nil, 0},
+		{"memory budget", []DownloadOption{WithResourceBudget(ResourceBudget{Memory: 3 * 1024})}, 3},
+		{"segments raise cost", []DownloadOption{WithSegmentedDownload(SegmentConfig{Count: 3}), WithResourceBudget(ResourceBudget{Memory: 3 * 1024})}, 1},
+		{"smaller of budgets", []DownloadOption{WithResourceBudget(ResourceBudget{Memory: 3 * 1024, FileDescriptors: 4})}, 2},
+		{"fair slots are smaller", []DownloadOption{WithFairFileSlots(1), WithResourceBudget(ResourceBudget{FileDescriptors: 10})}, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			opts := append([]DownloadOption{WithCopyBufferSize(1024)}, tc.opts...)
+
+			// Execute
+			usage := NewDownloadUsecase(mockRepo, mockRepo, opts...).ResourceUsage()
+
+			// Assert
+			if usage.FileLimit != tc.fileLimit {
+				t.Errorf("Expected file limit %d, got %d", tc.fileLimit, usage.FileLimit)
+			}
+		})
+	}
+}
+
+func TestResourceUsageCountsActiveFiles(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithCopyBufferSize(1024),
+		WithResourceBudget(ResourceBudget{Memory: 4 * 1024})).(*DownloadUsecase)
+	task := entities.NewTask([]string{"https://example.com/a.jpg"}, time.Now())
+
+	// Execute
+	release, err := usecase.acquireFileSlot(context.Background(), task)
+	if err != nil {
+		t.Fatalf("Expected slot to be granted, got %v", err)
+	}
+	active := usecase.ResourceUsage()
+	release()
+	release()
+
+	// Assert
+	if active.ActiveFiles != 1 || active.MemoryBytes != 1024 || active.FileDescriptors != 2 || active.MemoryBudget != 4*1024 {
+		t.Errorf("Expected one active file using 1024 bytes and 2 descriptors, got %+v", active)
+	}
+	if idle := usecase.ResourceUsage(); idle.ActiveFiles != 0 || idle.MemoryBytes != 0 {
+		t.Errorf("Expected no active files after release, got %+v", idle)
+	}
+}
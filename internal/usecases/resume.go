@@ -0,0 +1,218 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"strings"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// partialPath возвращает путь недокачанного файла, скачивание которого прервано остановкой сервиса
+func partialPath(path string) string {
+	return path + ".part"
+}
+
+// partialMetaPath возвращает путь файла метаданных рядом с недокачанным файлом
+func partialMetaPath(partial string) string {
+	return partial + ".meta"
+}
+
+// partialMeta описывает недокачанный файл: по нему после перезапуска проверяется, что источник не изменился
+type partialMeta struct {
+	URL          string `json:"url"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Offset       int64  `json:"offset"`
+}
+
+// matches возвращает true, если ответ источника описывает то же содержимое, что и при прерывании
+func (m partialMeta) matches(url string, result *interfaces.FetchResult) bool {
+	return m.URL == url && m.Size == result.Size &&
+		(m.ETag == "" || m.ETag == result.ETag) &&
+		(m.LastModified == "" || m.LastModified == result.LastModified)
+}
+
+// readPartialMeta читает метаданные недокачанного файла; если их нет или они повреждены, возвращается nil
+func readPartialMeta(partial string) *partialMeta {
+	data, err := os.ReadFile(partialMetaPath(partial))
+	if err != nil {
+		return nil
+	}
+	var meta partialMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		log.Printf("Метаданные недокачанного файла %s повреждены: %v", partial, err)
+		return nil
+	}
+	return &meta
+}
+
+// removePartial удаляет недокачанный файл вместе с его метаданными
+func removePartial(partial string) {
+	os.Remove(partial)
+	os.Remove(partialMetaPath(partial))
+}
+
+// resumedDownload - подготовленное продолжение скачивания недокачанного файла
+type resumedDownload struct {
+	file   *os.File      // недокачанный файл, открытый на дозапись после offset
+	hash   hash.Hash     // контрольная сумма уже скачанной части
+	offset int64         // число уже скачанных байт
+	body   io.ReadCloser // оставшиеся байты от источника
+}
+
+// resumeDownload продолжает скачивание файла, прерванного остановкой сервиса, с сохраненного смещения.
+// Продолжение возможно, если файл не сжимается, источник поддерживает диапазоны и сообщает тот же размер,
+// что и при прерывании, а недокачанный файл на диске не короче смещения. Если рядом с недокачанным файлом
+// сохранены метаданные, источник должен сообщить и те же ETag и Last-Modified; по ним же файл продолжается,
+// если точка продолжения не попала в состояние задачи. Иначе недокачанный файл удаляется и возвращается false:
+// файл скачивается заново. Запрос диапазона отправляется с If-Range, поэтому источник, изменившийся
+// после первого ответа, тоже приводит к скачиванию заново. Точка продолжения в файле задачи сбрасывается в любом случае.
+func (u *DownloadUsecase) resumeDownload(ctx context.Context, fetcher interfaces.Fetcher, req interfaces.FetchRequest,
+	result *interfaces.FetchResult, file *entities.File, path, compression string) (*resumedDownload, bool) {
+	partial, offset, size := file.PartialPath, file.ResumeOffset, file.Size
+	file.PartialPath, file.ResumeOffset = "", 0
+	meta := readPartialMeta(partialPath(path))
+	if partial == "" {
+		if meta == nil {
+			return nil, false
+		}
+		partial, offset, size = partialPath(path), meta.Offset, meta.Size
+	}
+
+	rangeFetcher, ok := fetcher.(interfaces.RangeFetcher)
+	if !ok || partial != partialPath(path) || compression != "" || !result.AcceptRanges ||
+		result.Size != size || offset <= 0 || offset >= result.Size {
+		removePartial(partial)
+		return nil, false
+	}
+	if meta != nil && !meta.matches(file.URL, result) {
+		log.Printf("Источник %s изменился после прерывания скачивания (ETag %q -> %q); файл будет скачан заново",
+			u.logURL(file.URL), meta.ETag, result.ETag)
+		removePartial(partial)
+		return nil, false
+	}
+
+	resumed, err := openPartial(partial, offset)
+	if err != nil {
+		log.Printf("Не удалось продолжить скачивание %s: %v; файл будет скачан заново", u.logURL(file.URL), err)
+		removePartial(partial)
+		return nil, false
+	}
+
+	// If-Range защищает от изменения источника между прерыванием и запросом диапазона:
+	// изменившийся источник отвечает полным содержимым, и файл скачивается заново
+	etag, lastModified := result.ETag, result.LastModified
+	if meta != nil {
+		etag, lastModified = meta.ETag, meta.LastModified
+	}
+	if validator := ifRangeValidator(etag, lastModified); validator != "" {
+		headers := maps.Clone(req.Headers)
+		if headers == nil {
+			headers = make(map[string]string, 1)
+		}
+		headers["If-Range"] = validator
+		req.Headers = headers
+	}
+
+	rest, err := rangeFetcher.FetchRange(ctx, req, offset, result.Size-1)
+	if err != nil {
+		log.Printf("Не удалось продолжить скачивание %s: %v; файл будет скачан заново", u.logURL(file.URL), err)
+		resumed.file.Close()
+		removePartial(partial)
+		return nil, false
+	}
+	// Ответ на полный запрос больше не нужен: данные читаются из ответа на запрос диапазона
+	result.Body.Close()
+	resumed.body = rest.Body
+
+	return resumed, true
+}
+
+// ifRangeValidator возвращает значение заголовка If-Range: сильный ETag или, если его нет, Last-Modified.
+// Слабый ETag в If-Range не допускается
+func ifRangeValidator(etag, lastModified string) string {
+	if etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return lastModified
+}
+
+// openPartial открывает недокачанный файл на дозапись после offset байт и считает их контрольную сумму
+func openPartial(path string, offset int64) (*resumedDownload, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть недокачанный файл: %w", err)
+	}
+
+	// Данные после смещения могли не попасть на диск до остановки и отбрасываются
+	hash := sha256.New()
+	read, err := io.Copy(hash, io.LimitReader(f, offset))
+	if err == nil && read != offset {
+		err = fmt.Errorf("недокачанный файл короче сохраненного смещения: %d байт из %d", read, offset)
+	}
+	if err == nil {
+		err = f.Truncate(offset)
+	}
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &resumedDownload{file: f, hash: hash, offset: offset}, nil
+}
+
+// keepPartial сохраняет файл, скачивание которого прервано остановкой сервиса, для продолжения после перезапуска:
+// записанные данные сбрасываются на диск, файл переименовывается в path.part, а смещение запоминается в файле задачи
+// и в метаданных path.part.meta вместе с размером, ETag и Last-Modified источника. Пустой файл просто удаляется.
+func keepPartial(file *entities.File, dest *os.File, path string, written int64, result *interfaces.FetchResult) {
+	partial := partialPath(path)
+	if written <= 0 {
+		dest.Close()
+		os.Remove(dest.Name())
+		return
+	}
+
+	err := dest.Sync()
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && dest.Name() != partial {
+		err = os.Rename(dest.Name(), partial)
+	}
+	if err != nil {
+		log.Printf("Не удалось сохранить недокачанный файл %s: %v", file.URL, err)
+		os.Remove(dest.Name())
+		return
+	}
+
+	file.PartialPath = partial
+	file.ResumeOffset = written
+
+	// Без метаданных продолжение по-прежнему возможно по состоянию задачи, но без проверки версии источника
+	meta, err := json.Marshal(partialMeta{
+		URL:          file.URL,
+		Size:         result.Size,
+		ETag:         result.ETag,
+		LastModified: result.LastModified,
+		Offset:       written,
+	})
+	if err == nil {
+		err = os.WriteFile(partialMetaPath(partial), meta, 0644)
+	}
+	if err != nil {
+		log.Printf("Не удалось сохранить метаданные недокачанного файла %s: %v", file.URL, err)
+	}
+}
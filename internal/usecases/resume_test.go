@@ -0,0 +1,270 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// stallingBody returns the first chunk and then blocks until the request is cancelled
+type stallingBody struct {
+	ctx   context.Context
+	chunk string
+	sent  chan struct{}
+	once  sync.Once
+}
+
+func (b *stallingBody) Read(p []byte) (int, error) {
+	if b.chunk != "" {
+		n := copy(p, b.chunk)
+		b.chunk = b.chunk[n:]
+		return n, nil
+	}
+	b.once.Do(func() { close(b.sent) })
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b *stallingBody) Close() error {
+	return nil
+}
+
+func TestProcessTaskResumesAfterShutdown(t *testing.T) {
+	// Setup
+	const content = "0123456789abcdefghij"
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	sent := make(chan struct{})
+	var mu sync.Mutex
+	var ranges []string
+	stalling := true
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		header := http.Header{"Accept-Ranges": {"bytes"}}
+		if value := req.Header.Get("Range"); value != "" {
+			ranges = append(ranges, value)
+			var start int
+			fmt.Sscanf(value, "bytes=%d-", &start)
+			return cannedResponse(req, http.StatusPartialContent, content[start:], header), nil
+		}
+		resp := cannedResponse(req, http.StatusOK, content, header)
+		if stalling {
+			resp.Body = &stallingBody{ctx: req.Context(), chunk: content[:8], sent: sent}
+		}
+		return resp, nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(dir), WithRoundTripper(tripper))
+
+	task := entities.NewTask([]string{"https://example.com/file.bin?token=presigned-value"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(context.Background(), task)
+
+	// Execute: the service stops after the first 8 bytes
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-sent
+		cancel()
+	}()
+	err := usecase.ProcessTask(ctx, task)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected interrupted task to return an error")
+	}
+	stored, _ := mockRepo.GetByID(context.Background(), task.ID.String())
+	file := stored.Files[0]
+	if stored.Status != entities.TaskStatusNew || file.Status != "pending" {
+		t.Fatalf("Expected task new with pending file, got %s and %s", stored.Status, file.Status)
+	}
+	if file.ResumeOffset != 8 || file.Downloaded != 8 || !strings.HasSuffix(file.PartialPath, ".part") {
+		t.Fatalf("Expected resume point at 8 bytes in a .part file, got %d (%d downloaded) in %q", file.ResumeOffset, file.Downloaded, file.PartialPath)
+	}
+	if data, err := os.ReadFile(file.PartialPath); err != nil || string(data) != content[:8] {
+		t.Fatalf("Expected partial file with %q, got %q (%v)", content[:8], data, err)
+	}
+	if meta := readPartialMeta(file.PartialPath); meta == nil || meta.Offset != 8 || meta.Size != int64(len(content)) {
+		t.Fatalf("Expected partial metadata with offset 8, got %+v", meta)
+	}
+
+	// Execute: after restart the download continues from the saved offset
+	mu.Lock()
+	stalling = false
+	mu.Unlock()
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	if err := usecase.ProcessTask(context.Background(), stored); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	file = stored.Files[0]
+	if file.Status != "completed" || file.Size != int64(len(content)) {
+		t.Fatalf("Expected completed file of %d bytes, got %s with %d bytes (%s)", len(content), file.Status, file.Size, file.Error)
+	}
+	if !strings.Contains(logs.String(), "продолжается с 8 байт") || strings.Contains(logs.String(), "presigned-value") {
+		t.Errorf("Expected the resume to be logged with a redacted URL, got %q", logs.String())
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=8-19" {
+		t.Errorf("Expected one range request from byte 8, got %v", ranges)
+	}
+	data, err := os.ReadFile(file.Path)
+	if err != nil || string(data) != content {
+		t.Errorf("Expected file content %q, got %q (%v)", content, data, err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	if file.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected checksum of the whole content, got %s", file.SHA256)
+	}
+	if file.PartialPath != "" || file.ResumeOffset != 0 {
+		t.Errorf("Expected resume point to be cleared, got %q at %d", file.PartialPath, file.ResumeOffset)
+	}
+	if _, err := os.Stat(partialPath(file.Path)); !os.IsNotExist(err) {
+		t.Errorf("Expected partial file to be removed, got %v", err)
+	}
+	if _, err := os.Stat(partialMetaPath(partialPath(file.Path))); !os.IsNotExist(err) {
+		t.Errorf("Expected partial metadata to be removed, got %v", err)
+	}
+}
+
+func TestResumeDownloadRestartsWhenSourceChanged(t *testing.T) {
+	// Setup
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.bin")
+	if err := os.WriteFile(partialPath(path), []byte("01234567"), 0644); err != nil {
+		t.Fatalf("Failed to write partial file: %v", err)
+	}
+	usecase := NewDownloadUsecase(NewMockTaskRepository(), NewMockTaskRepository()).(*DownloadUsecase)
+	file := &entities.File{URL: "https://example.com/file.bin", Size: 20, PartialPath: partialPath(path), ResumeOffset: 8}
+	result := &interfaces.FetchResult{Body: io.NopCloser(strings.NewReader("changed")), Size: 7, AcceptRanges: true}
+
+	// Execute: the source now reports a different size
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{})
+	_, resuming := usecase.resumeDownload(context.Background(), fetcher, interfaces.FetchRequest{URL: file.URL}, result, file, path, "")
+
+	// Assert
+	if resuming {
+		t.Error("Expected download to restart when the source size changed")
+	}
+	if file.PartialPath != "" || file.ResumeOffset != 0 {
+		t.Errorf("Expected resume point to be cleared, got %q at %d", file.PartialPath, file.ResumeOffset)
+	}
+	if _, err := os.Stat(partialPath(path)); !os.IsNotExist(err) {
+		t.Errorf("Expected stale partial file to be removed, got %v", err)
+	}
+}
+
+func TestResumeDownloadUsesPartialMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		etag     string
+		resuming bool
+	}{
+		{"same version", `"v1"`, true},
+		{"source changed", `"v2"`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup: the resume point was not saved in the task, only next to the partial file
+			dir := t.TempDir()
+			path := filepath.Join(dir, "file.bin")
+			os.WriteFile(partialPath(path), []byte("01234567"), 0644)
+			meta := `{"url": "https://example.com/file.bin", "size": 20, "etag": "\"v1\"", "offset": 8}`
+			os.WriteFile(partialMetaPath(partialPath(path)), []byte(meta), 0644)
+			var ifRange string
+			tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				ifRange = req.Header.Get("If-Range")
+				return cannedResponse(req, http.StatusPartialContent, "89abcdefghij", nil), nil
+			})
+			usecase := NewDownloadUsecase(NewMockTaskRepository(), NewMockTaskRepository()).(*DownloadUsecase)
+			file := &entities.File{URL: "https://example.com/file.bin"}
+			result := &interfaces.FetchResult{Body: io.NopCloser(strings.NewReader("")), Size: 20, AcceptRanges: true, ETag: tc.etag}
+
+			// Execute
+			fetcher := NewHTTPFetcher(tripper, HTTPFetcherConfig{})
+			resumed, resuming := usecase.resumeDownload(context.Background(), fetcher, interfaces.FetchRequest{URL: file.URL}, result, file, path, "")
+
+			// Assert
+			if resuming != tc.resuming {
+				t.Fatalf("Expected resuming %v, got %v", tc.resuming, resuming)
+			}
+			if resuming {
+				defer resumed.file.Close()
+				if resumed.offset != 8 {
+					t.Errorf("Expected resume from byte 8, got %d", resumed.offset)
+				}
+				if ifRange != `"v1"` {
+					t.Errorf("Expected If-Range %q, got %q", `"v1"`, ifRange)
+				}
+				return
+			}
+			if _, err := os.Stat(partialMetaPath(partialPath(path))); !os.IsNotExist(err) {
+				t.Errorf("Expected stale partial metadata to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessTaskRemovesCorruptResumedFile(t *testing.T) {
+	// Setup: the partial file left by a shutdown does not match the source content
+	const content = "0123456789abcdefghij"
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	var ranges []string
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Accept-Ranges": {"bytes"}}
+		if value := req.Header.Get("Range"); value != "" {
+			ranges = append(ranges, value)
+			return cannedResponse(req, http.StatusPartialContent, content[8:], header), nil
+		}
+		return cannedResponse(req, http.StatusOK, content, header), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo, WithDownloadDir(dir), WithRoundTripper(tripper),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+
+	task := entities.NewTask([]string{"https://example.com/file.bin"}, time.Now())
+	sum := sha256.Sum256([]byte(content))
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending", ExpectedSHA256: hex.EncodeToString(sum[:])}
+	partial := partialPath(filepath.Join(dir, task.ID.String(), "file.bin"))
+	os.MkdirAll(filepath.Dir(partial), 0755)
+	os.WriteFile(partial, []byte("XXXXXXXX"), 0644)
+	meta := `{"url": "https://example.com/file.bin", "size": 20, "offset": 8}`
+	os.WriteFile(partialMetaPath(partial), []byte(meta), 0644)
+	mockRepo.Create(context.Background(), task)
+
+	// Execute
+	if err := usecase.ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert: the corrupt resume fails the checksum and the retry downloads the whole file
+	file := task.Files[0]
+	if file.Status != "completed" || file.Attempts != 2 {
+		t.Fatalf("Expected file completed after 2 attempts, got %s after %d (%s)", file.Status, file.Attempts, file.Error)
+	}
+	if len(ranges) != 1 {
+		t.Errorf("Expected only the first attempt to resume, got range requests %v", ranges)
+	}
+	if data, err := os.ReadFile(file.Path); err != nil || string(data) != content {
+		t.Errorf("Expected file content %q, got %q (%v)", content, data, err)
+	}
+	if _, err := os.Stat(partialMetaPath(partial)); !os.IsNotExist(err) {
+		t.Errorf("Expected partial metadata to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// RetryCondition - категория ошибок скачивания, при которых выполняется повторная попытка
+type RetryCondition string
+
+const (
+	// RetryOn5xx - ответ сервера с кодом 5xx
+	RetryOn5xx RetryCondition = "5xx"
+	// RetryOn4xx - ответ сервера с кодом 4xx (например, 429 Too Many Requests)
+	RetryOn4xx RetryCondition = "4xx"
+	// RetryOnTimeout - истечение таймаута соединения или чтения
+	RetryOnTimeout RetryCondition = "timeout"
+	// RetryOnConnection - сетевая ошибка или обрыв соединения
+	RetryOnConnection RetryCondition = "connection"
+)
+
+// RetryPolicy описывает правила повторных попыток скачивания файла
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	RetryOn     []RetryCondition // категории ошибок для повтора (пусто - любые повторяемые ошибки)
+}
+
+// ParseRetryConditions преобразует названия категорий ошибок в условия повтора
+func ParseRetryConditions(values []string) ([]RetryCondition, error) {
+	var conditions []RetryCondition
+	for _, value := range values {
+		condition := RetryCondition(strings.ToLower(strings.TrimSpace(value)))
+		switch condition {
+		case RetryOn5xx, RetryOn4xx, RetryOnTimeout, RetryOnConnection:
+			conditions = append(conditions, condition)
+		default:
+			return nil, fmt.Errorf("неизвестная категория ошибок для повтора: %q (допустимы 5xx, 4xx, timeout, connection)", value)
+		}
+	}
+	return conditions, nil
+}
+
+// ShouldRetry возвращает true, если ошибку нужно повторить: она повторяема в принципе (см. isRetryable)
+// и, если заданы категории RetryOn, относится к одной из них. Попытка, прерванная из-за низкой скорости,
+// повторяется всегда: её прерывают именно для повтора.
+func (p RetryPolicy) ShouldRetry(err error) bool {
+	if !isRetryable(err) {
+		return false
+	}
+	if len(p.RetryOn) == 0 || errors.Is(err, entities.ErrSlowDownload) {
+		return true
+	}
+	for _, condition := range p.RetryOn {
+		if condition.matches(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches возвращает true, если ошибка относится к категории
+func (c RetryCondition) matches(err error) bool {
+	var statusErr *entities.HTTPStatusError
+	switch c {
+	case RetryOn5xx:
+		return errors.As(err, &statusErr) && statusErr.StatusCode >= 500
+	case RetryOn4xx:
+		return errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500
+	case RetryOnTimeout:
+		kind := classifyError(err)
+		return kind == entities.ErrorKindTimeout || kind == entities.ErrorKindRedirectTimeout
+	case RetryOnConnection:
+		return classifyError(err) == entities.ErrorKindNetwork || errors.Is(err, io.ErrUnexpectedEOF)
+	default:
+		return false
+	}
+}
+
+// shouldRetryFailed возвращает true, если файл, завершившийся ошибкой, нужно скачать снова по политике:
+// попытки последней обработки не исчерпаны, а сохраненная ошибка повторяема и относится к категориям RetryOn.
+// Исходная ошибка после перезапуска недоступна, поэтому решение принимается по виду ошибки и коду ответа.
+func (p RetryPolicy) shouldRetryFailed(file entities.File) bool {
+	if file.Attempts >= p.runAttempts(file) || !isRetryableKind(file.ErrorKind) {
+		return false
+	}
+	// Файл отменен вместе с задачей или не распакован: повтор скачивания не изменит результат
+	if file.ErrorKind == entities.ErrorKindFailureThreshold || file.ErrorKind == entities.ErrorKindExtraction {
+		return false
+	}
+	if len(p.RetryOn) == 0 || file.ErrorKind == entities.ErrorKindSlowDownload {
+		return true
+	}
+	for _, condition := range p.RetryOn {
+		if condition.matchesFile(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// runAttempts возвращает число попыток, доступных файлу за одну обработку: Attempts считаются заново при каждой
+// обработке по всем источникам файла, поэтому сравниваются с MaxAttempts той же обработки. Для файлов,
+// сохраненных без MaxAttempts, число вычисляется по политике.
+func (p RetryPolicy) runAttempts(file entities.File) int {
+	if file.MaxAttempts > 0 {
+		return file.MaxAttempts
+	}
+	return p.MaxAttempts * len(file.Sources())
+}
+
+// matchesFile возвращает true, если сохраненная ошибка файла относится к категории
+func (c RetryCondition) matchesFile(file entities.File) bool {
+	switch c {
+	case RetryOn5xx, RetryOn4xx:
+		if file.ErrorKind != entities.ErrorKindHTTP || file.HTTPStatus == 0 {
+			return false
+		}
+		if c == RetryOn5xx {
+			return file.HTTPStatus >= 500
+		}
+		return file.HTTPStatus >= 400 && file.HTTPStatus < 500
+	case RetryOnTimeout:
+		return file.ErrorKind == entities.ErrorKindTimeout || file.ErrorKind == entities.ErrorKindRedirectTimeout
+	case RetryOnConnection:
+		return file.ErrorKind == entities.ErrorKindNetwork || strings.Contains(file.Error, io.ErrUnexpectedEOF.Error())
+	default:
+		return false
+	}
+}
+
+// retryPolicyFor возвращает политику повторов задачи: поля политики задачи, заданные в запросе,
+// переопределяют политику из конфигурации
+func (u *DownloadUsecase) retryPolicyFor(task *entities.Task) RetryPolicy {
+	policy := u.retryPolicy
+	override := task.RetryPolicy
+	if override == nil {
+		return policy
+	}
+
+	if override.MaxAttempts > 0 {
+		policy.MaxAttempts = min(override.MaxAttempts, MaxRetryAttempts)
+	}
+	if override.Backoff > 0 {
+		policy.Backoff = time.Duration(override.Backoff)
+	}
+	if override.MaxBackoff > 0 {
+		policy.MaxBackoff = time.Duration(override.MaxBackoff)
+	}
+	if len(override.RetryOn) > 0 {
+		conditions, err := ParseRetryConditions(override.RetryOn)
+		if err == nil {
+			policy.RetryOn = conditions
+		}
+	}
+	return policy
+}
+
+// DefaultRetryPolicy возвращает политику повторов по умолчанию
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     time.Second,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+// Delay возвращает задержку перед следующей попыткой (экспоненциальный рост)
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// alertTimeout - максимальное время отправки оповещения о всплеске повторов
+const alertTimeout = 15 * time.Second
+
+// RetryAlertConfig задает порог оповещения о всплеске повторных попыток скачивания
+type RetryAlertConfig struct {
+	Window    time.Duration // окно, за которое считаются повторы
+	Threshold int           // число повторов за окно, выше которого отправляется оповещение (0 - отключено)
+}
+
+// DefaultRetryAlertConfig возвращает окно подсчета повторов по умолчанию без оповещений
+func DefaultRetryAlertConfig() RetryAlertConfig {
+	return RetryAlertConfig{Window: time.Minute}
+}
+
+// retryBudget считает повторные попытки скачивания за скользящее окно и оповещает оператора,
+// когда их становится больше порога: всплеск повторов обычно означает проблемы у источников
+type retryBudget struct {
+	mu       sync.Mutex
+	config   RetryAlertConfig
+	notifier interfaces.AlertNotifier // nil - оповещение только в журнале
+	metrics  interfaces.MetricsRecorder
+	clock    interfaces.Clock
+	total    int64
+	recent   []time.Time // время повторов в текущем окне по возрастанию
+	alerting bool        // оповещение уже отправлено и порог все еще превышен
+}
+
+// newRetryBudget создает счетчик повторных попыток
+func newRetryBudget(config RetryAlertConfig, notifier interfaces.AlertNotifier, metrics interfaces.MetricsRecorder, clock interfaces.Clock) *retryBudget {
+	if config.Window <= 0 {
+		config.Window = DefaultRetryAlertConfig().Window
+	}
+	return &retryBudget{config: config, notifier: notifier, metrics: metrics, clock: clock}
+}
+
+// record учитывает повторную попытку скачивания с хоста и оповещает при превышении порога
+func (b *retryBudget) record(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	b.total++
+	b.recent = append(b.recent, now)
+	b.prune(now)
+
+	b.metrics.IncCounter("downloader_retries_total", map[string]string{"host": host}, 1)
+	b.metrics.SetGauge("downloader_retries_window", nil, float64(len(b.recent)))
+
+	if b.config.Threshold <= 0 || len(b.recent) <= b.config.Threshold || b.alerting {
+		return
+	}
+	b.alerting = true
+	b.metrics.IncCounter("downloader_retry_alerts_total", nil, 1)
+
+	alert := entities.Alert{
+		Name:      "retry_rate",
+		Message:   fmt.Sprintf("повторных попыток скачивания за %v: %d (порог %d)", b.config.Window, len(b.recent), b.config.Threshold),
+		Value:     float64(len(b.recent)),
+		Threshold: float64(b.config.Threshold),
+		Time:      now,
+	}
+	log.Printf("Предупреждение: %s", alert.Message)
+	if b.notifier != nil {
+		go b.notify(alert)
+	}
+}
+
+// notify отправляет оповещение, не задерживая скачивание
+func (b *retryBudget) notify(alert entities.Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+	defer cancel()
+	if err := b.notifier.Notify(ctx, alert); err != nil {
+		log.Printf("Не удалось отправить оповещение о повторах скачивания: %v", err)
+	}
+}
+
+// prune удаляет повторы вне окна; когда их становится не больше порога, следующий всплеск снова оповещается
+func (b *retryBudget) prune(now time.Time) {
+	cutoff := now.Add(-b.config.Window)
+	expired := 0
+	for expired < len(b.recent) && !b.recent[expired].After(cutoff) {
+		expired++
+	}
+	b.recent = append(b.recent[:0], b.recent[expired:]...)
+
+	if len(b.recent) <= b.config.Threshold {
+		b.alerting = false
+	}
+}
+
+// stats возвращает статистику повторов на текущий момент
+func (b *retryBudget) stats() entities.RetryStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(b.clock.Now())
+	b.metrics.SetGauge("downloader_retries_window", nil, float64(len(b.recent)))
+	return entities.RetryStats{
+		Total:         b.total,
+		InWindow:      len(b.recent),
+		WindowSeconds: b.config.Window.Seconds(),
+		Threshold:     b.config.Threshold,
+		Alerting:      b.alerting,
+	}
+}
+
+// RetryStats возвращает число повторных попыток скачивания с запуска сервиса и за последнее окно
+func (u *DownloadUsecase) RetryStats() entities.RetryStats {
+	return u.retries.stats()
+}
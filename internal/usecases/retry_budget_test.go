@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+// channelNotifier delivers alerts to a channel
+type channelNotifier chan entities.Alert
+
+func (n channelNotifier) Notify(ctx context.Context, alert entities.Alert) error {
+	n <- alert
+	return nil
+}
+
+func TestRetryBudgetAlertsOncePerSpike(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	notifier := make(channelNotifier, 4)
+	metrics := &recordingMetrics{counters: make(map[string]float64)}
+	budget := newRetryBudget(RetryAlertConfig{Window: time.Minute, Threshold: 2}, notifier, metrics, clock)
+
+	// Execute
+	for i := 0; i < 5; i++ {
+		budget.record("example.com")
+	}
+
+	// Assert
+	select {
+	case alert := <-notifier:
+		if alert.Name != "retry_rate" || alert.Value != 3 || alert.Threshold != 2 {
+			t.Errorf("Expected retry_rate alert with value 3 and threshold 2, got %+v", alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected an alert once the threshold was exceeded")
+	}
+	if alerts := metrics.counters["downloader_retry_alerts_total"]; alerts != 1 {
+		t.Errorf("Expected 1 alert for one spike, got %v", alerts)
+	}
+	if retries := metrics.counters["downloader_retries_total"]; retries != 5 {
+		t.Errorf("Expected 5 retries counted, got %v", retries)
+	}
+
+	stats := budget.stats()
+	if stats.Total != 5 || stats.InWindow != 5 || !stats.Alerting {
+		t.Errorf("Expected 5 retries in window while alerting, got %+v", stats)
+	}
+}
+
+func TestRetryBudgetWindowExpires(t *testing.T) {
+	// Setup
+	clock := testutil.NewFakeClock(time.Now())
+	metrics := &recordingMetrics{counters: make(map[string]float64)}
+	budget := newRetryBudget(RetryAlertConfig{Window: time.Minute, Threshold: 1}, nil, metrics, clock)
+	budget.record("example.com")
+	budget.record("example.com")
+
+	// Execute
+	clock.Advance(2 * time.Minute)
+	calm := budget.stats()
+	budget.record("example.com")
+	budget.record("example.com")
+
+	// Assert
+	if calm.Total != 2 || calm.InWindow != 0 || calm.Alerting {
+		t.Errorf("Expected expired retries to leave the window, got %+v", calm)
+	}
+	if alerts := metrics.counters["downloader_retry_alerts_total"]; alerts != 2 {
+		t.Errorf("Expected a new alert after the window expired, got %v", alerts)
+	}
+}
@@ -0,0 +1,233 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     time.Second,
+		MaxBackoff:  5 * time.Second,
+	}
+
+	expected := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+	}
+
+	for i, want := range expected {
+		if got := policy.Delay(i + 1); got != want {
+			t.Errorf("Expected delay %v for attempt %d, got %v", want, i+1, got)
+		}
+	}
+}
+
+func TestDownloadRetryWaitsForBackoffOnClock(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	clock := testutil.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	var mu sync.Mutex
+	requests := 0
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			return cannedResponse(req, http.StatusServiceUnavailable, "", nil), nil
+		}
+		return cannedResponse(req, http.StatusOK, "data", nil), nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithClock(clock),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, Backoff: time.Hour}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file.bin"}, clock.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	done := make(chan error, 1)
+	go func() { done <- usecase.ProcessTask(ctx, task) }()
+
+	// Execute: wait until the retry is waiting for its backoff, then move the clock past it
+	deadline := time.Now().Add(5 * time.Second)
+	for clock.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected retry to wait for backoff on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("Expected download to wait until the clock is advanced")
+	default:
+	}
+	clock.Advance(time.Hour)
+
+	// Assert
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	file := task.Files[0]
+	if file.Status != "completed" || file.Attempts != 2 {
+		t.Errorf("Expected completed file after 2 attempts, got %s after %d", file.Status, file.Attempts)
+	}
+	if d := file.Duration(clock.Now()); d != time.Hour {
+		t.Errorf("Expected file duration of 1h on the clock, got %v", d)
+	}
+}
+
+func TestParseRetryConditions(t *testing.T) {
+	// Execute
+	conditions, err := ParseRetryConditions([]string{"5xx", " Timeout ", "connection"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []RetryCondition{RetryOn5xx, RetryOnTimeout, RetryOnConnection}
+	if len(conditions) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, conditions)
+	}
+	for i := range expected {
+		if conditions[i] != expected[i] {
+			t.Errorf("Expected condition %q at %d, got %q", expected[i], i, conditions[i])
+		}
+	}
+	if _, err := ParseRetryConditions([]string{"3xx"}); err == nil {
+		t.Error("Expected error for unknown condition, got nil")
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	serverErr := &entities.HTTPStatusError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}
+	clientErr := &entities.HTTPStatusError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+	testCases := []struct {
+		name     string
+		retryOn  []RetryCondition
+		err      error
+		expected bool
+	}{
+		{"default retries http errors", nil, clientErr, true},
+		{"default skips permission errors", nil, fs.ErrPermission, false},
+		{"5xx matches server error", []RetryCondition{RetryOn5xx}, serverErr, true},
+		{"5xx skips client error", []RetryCondition{RetryOn5xx}, clientErr, false},
+		{"4xx matches client error", []RetryCondition{RetryOn4xx}, clientErr, true},
+		{"timeout matches deadline", []RetryCondition{RetryOnTimeout}, context.DeadlineExceeded, true},
+		{"connection matches truncated body", []RetryCondition{RetryOnConnection}, io.ErrUnexpectedEOF, true},
+		{"connection skips server error", []RetryCondition{RetryOnConnection}, serverErr, false},
+		{"conditions never retry permission errors", []RetryCondition{RetryOnConnection}, fs.ErrPermission, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := RetryPolicy{MaxAttempts: 3, RetryOn: tc.retryOn}
+			if actual := policy.ShouldRetry(tc.err); actual != tc.expected {
+				t.Errorf("Expected ShouldRetry %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestProcessTaskUsesTaskRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		name             string
+		policy           *entities.TaskRetryPolicy
+		expectedStatus   string
+		expectedAttempts int
+		expectedMax      int
+	}{
+		{"default policy gives up", nil, "failed", 2, 2},
+		{"task allows more attempts", &entities.TaskRetryPolicy{MaxAttempts: 4}, "completed", 4, 4},
+		{"task retries only timeouts", &entities.TaskRetryPolicy{MaxAttempts: 4, RetryOn: []string{"timeout"}}, "failed", 1, 4},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			var mu sync.Mutex
+			requests := 0
+			tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				requests++
+				if requests < 4 {
+					return cannedResponse(req, http.StatusServiceUnavailable, "", nil), nil
+				}
+				return cannedResponse(req, http.StatusOK, "data", nil), nil
+			})
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(t.TempDir()),
+				WithRoundTripper(tripper),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{"https://example.com/file.bin"}, time.Now())
+			task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+			task.RetryPolicy = tc.policy
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			file := task.Files[0]
+			if file.Status != tc.expectedStatus || file.Attempts != tc.expectedAttempts || file.MaxAttempts != tc.expectedMax {
+				t.Errorf("Expected %s after %d attempts, got %s after %d of %d", tc.expectedStatus, tc.expectedAttempts, file.Status, file.Attempts, file.MaxAttempts)
+			}
+		})
+	}
+}
+
+func TestCreateTaskValidatesRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  *entities.TaskRetryPolicy
+		wantErr bool
+	}{
+		{"valid policy", &entities.TaskRetryPolicy{MaxAttempts: 5, Backoff: entities.Duration(time.Second), RetryOn: []string{"5xx"}}, false},
+		{"too many attempts", &entities.TaskRetryPolicy{MaxAttempts: MaxRetryAttempts + 1}, true},
+		{"negative backoff", &entities.TaskRetryPolicy{Backoff: entities.Duration(-time.Second)}, true},
+		{"unknown condition", &entities.TaskRetryPolicy{RetryOn: []string{"always"}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			usecase := NewTaskUsecase(mockRepo, mockRepo)
+			spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+			spec.RetryPolicy = tc.policy
+
+			// Execute
+			task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+			// Assert
+			if tc.wantErr != errors.Is(err, entities.ErrInvalidRequest) {
+				t.Fatalf("Expected invalid request %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && task.RetryPolicy.MaxAttempts != tc.policy.MaxAttempts {
+				t.Errorf("Expected retry policy to be stored on the task, got %+v", task.RetryPolicy)
+			}
+		})
+	}
+}
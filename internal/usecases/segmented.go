@@ -0,0 +1,152 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+// SegmentConfig задает параметры сегментированного скачивания крупных файлов
+type SegmentConfig struct {
+	Count   int   // число параллельно скачиваемых сегментов (меньше 2 отключает сегментирование)
+	MinSize int64 // минимальный размер файла, начиная с которого он скачивается сегментами
+}
+
+// byteRange - диапазон байт файла с start по end включительно
+type byteRange struct {
+	start, end int64
+}
+
+// length возвращает длину диапазона
+func (r byteRange) length() int64 {
+	return r.end - r.start + 1
+}
+
+// splitRanges делит файл указанного размера на count примерно равных диапазонов
+func splitRanges(size int64, count int) []byteRange {
+	ranges := make([]byteRange, 0, count)
+	step := size / int64(count)
+	for i := 0; i < count; i++ {
+		start := int64(i) * step
+		end := start + step - 1
+		if i == count-1 {
+			end = size - 1
+		}
+		ranges = append(ranges, byteRange{start: start, end: end})
+	}
+	return ranges
+}
+
+// segmentedFetcher возвращает fetcher диапазонов, если файл стоит скачивать сегментами:
+// сегментирование включено, источник поддерживает диапазоны и сообщил размер не меньше порога
+func (u *DownloadUsecase) segmentedFetcher(fetcher interfaces.Fetcher, result *interfaces.FetchResult) (interfaces.RangeFetcher, bool) {
+	if u.segments.Count < 2 || !result.AcceptRanges {
+		return nil, false
+	}
+	if result.Size < u.segments.MinSize || result.Size < int64(u.segments.Count) {
+		return nil, false
+	}
+
+	rangeFetcher, ok := fetcher.(interfaces.RangeFetcher)
+	return rangeFetcher, ok
+}
+
+// downloadSegmented параллельно скачивает диапазоны файла во временный файл path.part
+// и после успешного завершения всех сегментов переименовывает его в path.
+// Первый сегмент читается из уже открытого ответа result, остальные запрашиваются отдельно.
+func (u *DownloadUsecase) downloadSegmented(ctx context.Context, fetcher interfaces.RangeFetcher, req interfaces.FetchRequest,
+	result *interfaces.FetchResult, path string, progress *progressTracker) (int64, error) {
+	partPath := path + ".part"
+	part, err := os.Create(partPath)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось создать файл: %w", err)
+	}
+	completed := false
+	defer func() {
+		part.Close()
+		if !completed {
+			os.Remove(partPath)
+		}
+	}()
+
+	if err := part.Truncate(result.Size); err != nil {
+		return 0, fmt.Errorf("не удалось выделить место под файл: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// При ошибке любого сегмента прерываем и чтение первого сегмента из исходного ответа
+	stop := context.AfterFunc(ctx, func() { result.Body.Close() })
+	defer stop()
+
+	ranges := splitRanges(result.Size, u.segments.Count)
+	errs := make(chan error, len(ranges))
+	var wg sync.WaitGroup
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(i int, r byteRange) {
+			defer wg.Done()
+			// Паника в сегменте завершает ошибкой только скачивание файла, а не весь сервис
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("Паника при скачивании сегмента %d-%d: %v\n%s", r.start, r.end, p, debug.Stack())
+					errs <- fmt.Errorf("%w: паника при скачивании сегмента %d-%d: %v", entities.ErrInternal, r.start, r.end, p)
+					cancel()
+				}
+			}()
+			if err := downloadSegment(ctx, fetcher, req, result, i, r, part, progress, u.buffers); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(i, r)
+	}
+	wg.Wait()
+	close(errs)
+
+	// Первой в канал попадает исходная ошибка, остальные сегменты завершаются уже из-за отмены
+	if err := <-errs; err != nil {
+		return 0, err
+	}
+
+	if err := part.Close(); err != nil {
+		return 0, fmt.Errorf("не удалось записать файл: %w", err)
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		return 0, fmt.Errorf("не удалось сохранить файл: %w", err)
+	}
+	completed = true
+
+	return result.Size, nil
+}
+
+// downloadSegment скачивает один диапазон и записывает его в файл по нужному смещению
+func downloadSegment(ctx context.Context, fetcher interfaces.RangeFetcher, req interfaces.FetchRequest,
+	result *interfaces.FetchResult, index int, r byteRange, part *os.File, progress *progressTracker, buffers *bufferPool) error {
+	body := result.Body
+	if index > 0 {
+		segment, err := fetcher.FetchRange(ctx, req, r.start, r.end)
+		if err != nil {
+			return fmt.Errorf("не удалось скачать сегмент %d-%d: %w", r.start, r.end, err)
+		}
+		defer segment.Body.Close()
+		body = segment.Body
+	}
+
+	written, err := buffers.copy(io.NewOffsetWriter(part, r.start), progress.reader(io.LimitReader(body, r.length())))
+	if err != nil {
+		return fmt.Errorf("не удалось записать сегмент %d-%d: %w", r.start, r.end, err)
+	}
+	if written != r.length() {
+		return fmt.Errorf("сегмент %d-%d оборван: получено %d байт из %d", r.start, r.end, written, r.length())
+	}
+
+	return nil
+}
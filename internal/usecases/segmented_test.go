@@ -0,0 +1,265 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// rangeServer serves content and honours single byte-range requests
+func rangeServer(content string, rangeRequests *int32) roundTripperFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Accept-Ranges": []string{"bytes"}}
+		value := req.Header.Get("Range")
+		if value == "" {
+			return cannedResponse(req, http.StatusOK, content, header), nil
+		}
+
+		atomic.AddInt32(rangeRequests, 1)
+		var start, end int
+		if _, err := fmt.Sscanf(value, "bytes=%d-%d", &start, &end); err != nil {
+			return cannedResponse(req, http.StatusRequestedRangeNotSatisfiable, "", nil), nil
+		}
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+		return cannedResponse(req, http.StatusPartialContent, content[start:end+1], header), nil
+	}
+}
+
+func TestSplitRanges(t *testing.T) {
+	ranges := splitRanges(10, 3)
+
+	expected := []byteRange{{0, 2}, {3, 5}, {6, 9}}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Expected %d ranges, got %d", len(expected), len(ranges))
+	}
+	for i, want := range expected {
+		if ranges[i] != want {
+			t.Errorf("Expected range %d to be %v, got %v", i, want, ranges[i])
+		}
+	}
+}
+
+func TestDownloadFileSegmented(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	content := strings.Repeat("0123456789abcdef", 64)
+	var rangeRequests int32
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(rangeServer(content, &rangeRequests)),
+		WithSegmentedDownload(SegmentConfig{Count: 4, MinSize: 100}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/large.bin"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rangeRequests != 3 {
+		t.Errorf("Expected 3 range requests besides the initial stream, got %d", rangeRequests)
+	}
+
+	file := task.Files[0]
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if string(data) != content {
+		t.Error("Expected segmented file to match the source content")
+	}
+	if file.Size != int64(len(content)) || file.Downloaded != int64(len(content)) {
+		t.Errorf("Expected size and downloaded %d, got %d and %d", len(content), file.Size, file.Downloaded)
+	}
+	if _, err := os.Stat(file.Path + ".part"); !os.IsNotExist(err) {
+		t.Error("Expected temporary .part file to be removed")
+	}
+	if sum, _ := fileSHA256(file.Path); sum != file.SHA256 {
+		t.Errorf("Expected checksum %s, got %s", sum, file.SHA256)
+	}
+}
+
+func TestDownloadFileSegmentedFallsBackBelowThreshold(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	var rangeRequests int32
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(rangeServer("small file", &rangeRequests)),
+		WithSegmentedDownload(SegmentConfig{Count: 4, MinSize: 1 << 20}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/small.txt"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rangeRequests != 0 {
+		t.Errorf("Expected a single stream without range requests, got %d", rangeRequests)
+	}
+	if task.Files[0].Status != "completed" {
+		t.Errorf("Expected status completed, got %s", task.Files[0].Status)
+	}
+}
+
+func TestDownloadFileSegmentFailureRemovesPartFile(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	content := strings.Repeat("x", 400)
+	var rangeRequests int32
+	serve := rangeServer(content, &rangeRequests)
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasPrefix(req.Header.Get("Range"), "bytes=300-") {
+			return cannedResponse(req, http.StatusInternalServerError, "boom", nil), nil
+		}
+		return serve(req)
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithSegmentedDownload(SegmentConfig{Count: 4, MinSize: 100}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/large.bin"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error when a segment fails")
+	}
+	if task.Files[0].Status != "failed" {
+		t.Errorf("Expected status failed, got %s", task.Files[0].Status)
+	}
+	if _, err := os.Stat(task.Files[0].Path + ".part"); !os.IsNotExist(err) {
+		t.Error("Expected temporary .part file to be removed after failure")
+	}
+	if _, err := os.Stat(task.Files[0].Path); !os.IsNotExist(err) {
+		t.Error("Expected no final file after failure")
+	}
+}
+
+func TestDownloadFileSegmentPanic(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	content := strings.Repeat("0123456789abcdef", 64)
+	var rangeRequests int32
+	serve := rangeServer(content, &rangeRequests)
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Range") != "" {
+				panic("segment bug")
+			}
+			return serve(req)
+		})),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+		WithSegmentedDownload(SegmentConfig{Count: 4, MinSize: 100}))
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/large.bin"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.ProcessTask(ctx, task)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	file := stored.Files[0]
+	if file.Status != "failed" || file.ErrorKind != entities.ErrorKindInternal {
+		t.Errorf("Expected file to fail with internal error, got %s (%s: %s)", file.Status, file.ErrorKind, file.Error)
+	}
+	if file.Attempts != 1 {
+		t.Errorf("Expected no retries after a panic, got %d attempts", file.Attempts)
+	}
+}
+
+func TestDownloadFileSegmentedChecksMinSize(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	content := strings.Repeat("0123456789abcdef", 64)
+	var rangeRequests int32
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(rangeServer(content, &rangeRequests)),
+		WithSegmentedDownload(SegmentConfig{Count: 4, MinSize: 100}),
+		WithMinFileSize(int64(len(content))+1))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/large.bin"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if !errors.Is(err, entities.ErrFileTooSmall) {
+		t.Fatalf("Expected ErrFileTooSmall, got %v", err)
+	}
+	if rangeRequests == 0 {
+		t.Error("Expected the file to be downloaded in segments")
+	}
+	file := task.Files[0]
+	if file.Status != "failed" {
+		t.Errorf("Expected file status failed, got %s", file.Status)
+	}
+	if _, err := os.Stat(file.Path); !os.IsNotExist(err) {
+		t.Errorf("Expected too small file to be removed, got %v", err)
+	}
+}
+
+func TestDownloadFileSegmentedChecksChecksum(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	content := strings.Repeat("0123456789abcdef", 64)
+	var rangeRequests int32
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(rangeServer(content, &rangeRequests)),
+		WithSegmentedDownload(SegmentConfig{Count: 4, MinSize: 100}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/large.bin"}, time.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending", ExpectedSHA256: strings.Repeat("0", 64)}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.DownloadFile(ctx, task.URLs[0], task.ID.String(), 0)
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "контрольной суммы") {
+		t.Fatalf("Expected checksum mismatch, got %v", err)
+	}
+	if rangeRequests == 0 {
+		t.Error("Expected the file to be downloaded in segments")
+	}
+	if _, err := os.Stat(task.Files[0].Path); !os.IsNotExist(err) {
+		t.Errorf("Expected file with wrong checksum to be removed, got %v", err)
+	}
+}
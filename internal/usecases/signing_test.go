@@ -0,0 +1,137 @@
+package usecases
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+	"file-downloader/internal/testutil"
+)
+
+func TestURLSignerSign(t *testing.T) {
+	// Setup
+	signer := URLSigner{Hosts: []string{"*.s3.example.com"}, Secret: "top-secret", TTL: time.Minute, SignatureParam: "sig", ExpiresParam: "exp"}
+	now := time.Unix(1700000000, 0)
+	mac := hmac.New(sha256.New, []byte("top-secret"))
+	mac.Write([]byte("/bucket/file.bin\n1700000060"))
+	expectedSignature := hex.EncodeToString(mac.Sum(nil))
+
+	testCases := []struct {
+		url        string
+		wantSigned bool
+	}{
+		{"https://eu.s3.example.com/bucket/file.bin?sig=stale", true},
+		{"https://eu.s3.example.com/bucket/file.bin?version=2", true},
+		{"https://example.com/bucket/file.bin", false},
+	}
+
+	for _, tc := range testCases {
+		u, _ := url.Parse(tc.url)
+
+		// Execute
+		signed := signer.Sign(u, now)
+
+		// Assert
+		if signed != tc.wantSigned {
+			t.Errorf("Expected signed=%t for %s, got %t", tc.wantSigned, tc.url, signed)
+			continue
+		}
+		query := u.Query()
+		if !tc.wantSigned {
+			if u.String() != tc.url {
+				t.Errorf("Expected %s to stay unchanged, got %s", tc.url, u)
+			}
+			continue
+		}
+		if query.Get("exp") != "1700000060" || query.Get("sig") != expectedSignature || len(query["sig"]) != 1 {
+			t.Errorf("Expected exp=1700000060 and a single sig=%s, got %s", expectedSignature, u.RawQuery)
+		}
+		if strings.Contains(tc.url, "version=2") && query.Get("version") != "2" {
+			t.Errorf("Expected existing query to be kept, got %s", u.RawQuery)
+		}
+	}
+}
+
+func TestHTTPFetcherSignsRequestsWithoutLeakingSignature(t *testing.T) {
+	// Setup
+	var received url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Query()
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	signer := URLSigner{Hosts: []string{"127.0.0.1"}, Secret: "top-secret", TTL: time.Minute, SignatureParam: "signature", ExpiresParam: "expires"}
+	fetcher := NewHTTPFetcher(nil, HTTPFetcherConfig{Signer: signer})
+
+	// Execute
+	result, err := fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL + "/file.bin"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	result.Body.Close()
+	if received.Get("signature") == "" || received.Get("expires") == "" {
+		t.Errorf("Expected signature and expires parameters, got %v", received)
+	}
+
+	// Execute: connection error for a signed host
+	server.Close()
+	_, err = fetcher.Fetch(context.Background(), interfaces.FetchRequest{URL: server.URL + "/file.bin"})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected connection error")
+	}
+	if strings.Contains(err.Error(), "signature") || strings.Contains(err.Error(), "expires") {
+		t.Errorf("Expected error without signing parameters, got %q", err)
+	}
+	if !strings.Contains(err.Error(), "/file.bin") {
+		t.Errorf("Expected error to keep the URL path, got %q", err)
+	}
+}
+
+func TestDownloadUsecaseSignsWithInjectedClock(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	clock := testutil.NewFakeClock(time.Unix(1700000000, 0))
+	var expires string
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		expires = req.URL.Query().Get("exp")
+		return cannedResponse(req, http.StatusOK, "ok", nil), nil
+	})
+	signer := URLSigner{Hosts: []string{"cdn.example.com"}, Secret: "s", TTL: time.Minute, SignatureParam: "sig", ExpiresParam: "exp"}
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithClock(clock),
+		WithHTTPConfig(HTTPFetcherConfig{Signer: signer}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://cdn.example.com/file.bin"}, clock.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.ProcessTask(ctx, task)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if file := task.Files[0]; file.Status != "completed" {
+		t.Errorf("Expected completed file, got %s (%s)", file.Status, file.Error)
+	}
+	if expires != "1700000060" {
+		t.Errorf("Expected expiry from the injected clock 1700000060, got %q", expires)
+	}
+}
@@ -0,0 +1,153 @@
+package usecases
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// SlowAction определяет реакцию на медленное скачивание файла
+type SlowAction string
+
+const (
+	// SlowActionFlag отмечает файл как медленный, не прерывая скачивание
+	SlowActionFlag SlowAction = "flag"
+	// SlowActionRetry прерывает попытку с ErrSlowDownload, после чего файл скачивается повторно по политике повторов
+	SlowActionRetry SlowAction = "retry"
+)
+
+// ParseSlowAction преобразует строку в реакцию на медленное скачивание
+func ParseSlowAction(value string) (SlowAction, error) {
+	switch SlowAction(strings.ToLower(strings.TrimSpace(value))) {
+	case SlowActionFlag, "":
+		return SlowActionFlag, nil
+	case SlowActionRetry:
+		return SlowActionRetry, nil
+	default:
+		return "", fmt.Errorf("неизвестная реакция на медленное скачивание: %q (допустимы flag, retry)", value)
+	}
+}
+
+// SlowDownloadPolicy задает обнаружение медленных скачиваний: скачивание считается медленным,
+// если средняя скорость за окно Window ниже MinSpeed байт в секунду
+type SlowDownloadPolicy struct {
+	MinSpeed int64 // 0 отключает обнаружение
+	Window   time.Duration
+	Action   SlowAction
+}
+
+// DefaultSlowDownloadPolicy возвращает политику обнаружения медленных скачиваний по умолчанию (отключено)
+func DefaultSlowDownloadPolicy() SlowDownloadPolicy {
+	return SlowDownloadPolicy{
+		Window: 30 * time.Second,
+		Action: SlowActionFlag,
+	}
+}
+
+// Enabled возвращает true, если обнаружение медленных скачиваний включено
+func (p SlowDownloadPolicy) Enabled() bool {
+	return p.MinSpeed > 0 && p.Window > 0
+}
+
+// slowPolicyFor возвращает политику обнаружения медленных скачиваний задачи: поля, заданные в задаче,
+// переопределяют политику из конфигурации
+func (u *DownloadUsecase) slowPolicyFor(task *entities.Task) SlowDownloadPolicy {
+	policy := u.slowDownload
+	override := task.SlowDownload
+	if override == nil {
+		return policy
+	}
+
+	if override.MinSpeed > 0 {
+		policy.MinSpeed = override.MinSpeed
+	}
+	if override.Window > 0 {
+		policy.Window = time.Duration(override.Window)
+	}
+	if override.Action != "" {
+		if action, err := ParseSlowAction(override.Action); err == nil {
+			policy.Action = action
+		}
+	}
+	return policy
+}
+
+// WithSlowDownloadPolicy задает обнаружение медленных скачиваний по умолчанию
+func WithSlowDownloadPolicy(policy SlowDownloadPolicy) DownloadOption {
+	return func(u *DownloadUsecase) {
+		u.slowDownload = policy
+	}
+}
+
+// slowDownloadHandler возвращает реакцию на медленное скачивание файла: файл отмечается медленным,
+// а при действии retry попытка прерывается с ErrSlowDownload
+func (u *DownloadUsecase) slowDownloadHandler(task *entities.Task, file *entities.File, host string, policy SlowDownloadPolicy) func(int64) error {
+	return func(speed int64) error {
+		u.metrics.IncCounter("downloader_slow_downloads_total", map[string]string{"host": host, "action": string(policy.Action)}, 1)
+		if !file.Slow {
+			log.Printf("Задача %s: скачивание %s медленнее %d байт/с (%d байт/с за %v)",
+				task.LogID(), file.URL, policy.MinSpeed, speed, policy.Window)
+		}
+		file.Slow = true
+		file.SlowSpeed = speed
+
+		if policy.Action == SlowActionRetry {
+			return fmt.Errorf("%w: %d байт/с за %v при минимуме %d байт/с", entities.ErrSlowDownload, speed, policy.Window, policy.MinSpeed)
+		}
+		return nil
+	}
+}
+
+// validateSlowDownload проверяет политику обнаружения медленных скачиваний задачи
+func validateSlowDownload(policy *entities.TaskSlowDownloadPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MinSpeed < 0 || policy.Window < 0 {
+		return fmt.Errorf("%w: min_speed и window в slow_download не могут быть отрицательными", entities.ErrInvalidRequest)
+	}
+	if _, err := ParseSlowAction(policy.Action); err != nil {
+		return fmt.Errorf("%w: slow_download.action: %v", entities.ErrInvalidRequest, err)
+	}
+	return nil
+}
+
+// speedMonitor измеряет среднюю скорость скачивания по последовательным окнам наблюдения
+type speedMonitor struct {
+	policy      SlowDownloadPolicy
+	windowStart time.Time
+	windowBytes int64
+}
+
+// newSpeedMonitor создает монитор скорости с первым окном, начинающимся в момент now
+func newSpeedMonitor(policy SlowDownloadPolicy, now time.Time) *speedMonitor {
+	return &speedMonitor{policy: policy, windowStart: now}
+}
+
+// sample учитывает n байт, полученных к моменту now. Когда окно заканчивается, возвращает среднюю
+// скорость за него в байтах в секунду и признак того, что она ниже минимальной, и начинает новое окно.
+func (m *speedMonitor) sample(n int, now time.Time) (int64, bool) {
+	m.windowBytes += int64(n)
+	elapsed := now.Sub(m.windowStart)
+	if elapsed < m.policy.Window {
+		return 0, false
+	}
+
+	speed := int64(float64(m.windowBytes) / elapsed.Seconds())
+	m.windowStart = now
+	m.windowBytes = 0
+	return speed, speed < m.policy.MinSpeed
+}
+
+// stalled возвращает true, если к моменту now окно закончилось, а данные за него не поступали;
+// такое окно завершается с нулевой скоростью и начинается новое
+func (m *speedMonitor) stalled(now time.Time) bool {
+	if m.windowBytes > 0 || now.Sub(m.windowStart) < m.policy.Window {
+		return false
+	}
+	m.windowStart = now
+	return true
+}
@@ -0,0 +1,224 @@
+package usecases
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+// tricklingBody отдает по одному байту за чтение, переводя часы на step вперед перед каждым чтением
+type tricklingBody struct {
+	data  string
+	clock *testutil.FakeClock
+	step  time.Duration
+}
+
+func (b *tricklingBody) Read(p []byte) (int, error) {
+	if len(b.data) == 0 {
+		return 0, io.EOF
+	}
+	b.clock.Advance(b.step)
+	p[0] = b.data[0]
+	b.data = b.data[1:]
+	return 1, nil
+}
+
+func (b *tricklingBody) Close() error { return nil }
+
+// stalledBody не отдает данных до отмены запроса
+type stalledBody struct {
+	ctx context.Context
+}
+
+func (b stalledBody) Read(p []byte) (int, error) {
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b stalledBody) Close() error { return nil }
+
+func TestSpeedMonitorSample(t *testing.T) {
+	// Setup
+	start := time.Now()
+	monitor := newSpeedMonitor(SlowDownloadPolicy{MinSpeed: 100, Window: 10 * time.Second}, start)
+
+	// Execute
+	_, slowEarly := monitor.sample(50, start.Add(5*time.Second))
+	speed, slow := monitor.sample(450, start.Add(10*time.Second))
+	fastSpeed, fastSlow := monitor.sample(2000, start.Add(20*time.Second))
+
+	// Assert
+	if slowEarly {
+		t.Error("Expected no verdict before the window ends")
+	}
+	if speed != 50 || !slow {
+		t.Errorf("Expected slow window at 50 B/s, got %d B/s (slow %v)", speed, slow)
+	}
+	if fastSpeed != 200 || fastSlow {
+		t.Errorf("Expected fast window at 200 B/s, got %d B/s (slow %v)", fastSpeed, fastSlow)
+	}
+}
+
+func TestProcessTaskDetectsSlowDownload(t *testing.T) {
+	testCases := []struct {
+		name             string
+		policy           *entities.TaskSlowDownloadPolicy
+		expectedStatus   string
+		expectedAttempts int
+		expectedSlow     bool
+		expectedKind     entities.ErrorKind
+	}{
+		{"disabled", nil, "completed", 1, false, ""},
+		{"flag keeps downloading", &entities.TaskSlowDownloadPolicy{MinSpeed: 10, Window: entities.Duration(3 * time.Second)}, "completed", 1, true, ""},
+		{"retry aborts attempts", &entities.TaskSlowDownloadPolicy{MinSpeed: 10, Window: entities.Duration(3 * time.Second), Action: "retry"}, "failed", 2, true, entities.ErrorKindSlowDownload},
+		{"fast enough", &entities.TaskSlowDownloadPolicy{MinSpeed: 1, Window: entities.Duration(3 * time.Second), Action: "retry"}, "completed", 1, false, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			clock := testutil.NewFakeClock(time.Now())
+			metrics := &recordingMetrics{counters: make(map[string]float64)}
+			var mu sync.Mutex
+			tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				resp := cannedResponse(req, http.StatusOK, "", nil)
+				resp.Body = &tricklingBody{data: strings.Repeat("x", 10), clock: clock, step: time.Second}
+				resp.ContentLength = 10
+				return resp, nil
+			})
+			usecase := NewDownloadUsecase(mockRepo, mockRepo,
+				WithDownloadDir(t.TempDir()),
+				WithRoundTripper(tripper),
+				WithClock(clock),
+				WithMetrics(metrics),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 2, RetryOn: []RetryCondition{RetryOn5xx}}))
+			ctx := context.Background()
+
+			task := entities.NewTask([]string{"https://example.com/file.bin"}, clock.Now())
+			task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+			task.SlowDownload = tc.policy
+			mockRepo.Create(ctx, task)
+
+			// Execute
+			if err := usecase.ProcessTask(ctx, task); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Assert
+			file := task.Files[0]
+			if file.Status != tc.expectedStatus || file.Attempts != tc.expectedAttempts {
+				t.Errorf("Expected %s after %d attempts, got %s after %d (%s)", tc.expectedStatus, tc.expectedAttempts, file.Status, file.Attempts, file.Error)
+			}
+			if file.Slow != tc.expectedSlow || file.ErrorKind != tc.expectedKind {
+				t.Errorf("Expected slow %v with error kind %q, got %v with %q", tc.expectedSlow, tc.expectedKind, file.Slow, file.ErrorKind)
+			}
+			metrics.mu.Lock()
+			slowWindows := metrics.counters["downloader_slow_downloads_total"]
+			metrics.mu.Unlock()
+			if tc.expectedSlow && (file.SlowSpeed != 1 || slowWindows == 0) {
+				t.Errorf("Expected slow speed 1 B/s and a slow download metric, got %d B/s and %v", file.SlowSpeed, slowWindows)
+			}
+		})
+	}
+}
+
+func TestCreateTaskValidatesSlowDownload(t *testing.T) {
+	testCases := []struct {
+		policy  *entities.TaskSlowDownloadPolicy
+		wantErr bool
+	}{
+		{nil, false},
+		{&entities.TaskSlowDownloadPolicy{MinSpeed: 1024, Action: "retry"}, false},
+		{&entities.TaskSlowDownloadPolicy{MinSpeed: -1}, true},
+		{&entities.TaskSlowDownloadPolicy{MinSpeed: 1024, Action: "abort"}, true},
+	}
+
+	for _, tc := range testCases {
+		// Setup
+		mockRepo := NewMockTaskRepository()
+		usecase := NewTaskUsecase(mockRepo, mockRepo)
+		spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+		spec.SlowDownload = tc.policy
+
+		// Execute
+		task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+		// Assert
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %+v, got nil", tc.policy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error for %+v, got %v", tc.policy, err)
+		} else if task.SlowDownload != tc.policy {
+			t.Errorf("Expected slow download policy %+v, got %+v", tc.policy, task.SlowDownload)
+		}
+	}
+}
+
+func TestProcessTaskAbortsStalledDownload(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	clock := testutil.NewFakeClock(time.Now())
+	tripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp := cannedResponse(req, http.StatusOK, "", nil)
+		resp.Body = stalledBody{ctx: req.Context()}
+		resp.ContentLength = 10
+		return resp, nil
+	})
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithDownloadDir(t.TempDir()),
+		WithRoundTripper(tripper),
+		WithClock(clock),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	ctx := context.Background()
+
+	task := entities.NewTask([]string{"https://example.com/file.bin"}, clock.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	task.SlowDownload = &entities.TaskSlowDownloadPolicy{MinSpeed: 10, Window: entities.Duration(3 * time.Second), Action: "retry"}
+	mockRepo.Create(ctx, task)
+
+	// Часы идут, пока скачивание ждет данных
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if clock.Waiters() > 0 {
+				clock.Advance(time.Second)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	// Execute
+	err := usecase.ProcessTask(ctx, task)
+	close(done)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	file := task.Files[0]
+	if file.Status != "failed" || file.ErrorKind != entities.ErrorKindSlowDownload {
+		t.Errorf("Expected failed file with error kind %q, got %s with %q (%s)", entities.ErrorKindSlowDownload, file.Status, file.ErrorKind, file.Error)
+	}
+	if !file.Slow || file.SlowSpeed != 0 {
+		t.Errorf("Expected slow file at 0 B/s, got slow %v at %d B/s", file.Slow, file.SlowSpeed)
+	}
+}
@@ -2,52 +2,362 @@ package usecases
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"file-downloader/internal/entities"
 	"file-downloader/internal/interfaces"
+
+	"github.com/google/uuid"
 )
 
 // TaskUsecase реализует use case'ы управления задачами
 type TaskUsecase struct {
 	taskRepo       interfaces.TaskRepository
 	persistentRepo interfaces.PersistentRepository
+	schemes        map[string]bool
+	pageSize       int
+	maxPageSize    int
+	trash          trash
+	contents       *contentStore // хранилище содержимого дедупликации (nil - дедупликация отключена)
+	events         interfaces.EventPublisher
+	outputRoots    []string // разрешенные базовые директории для output_dir задач
+	orphanAction   OrphanAction
+	hostPolicy     HostPolicy
+	maxURLLength   int
+	maxInlineSize  int64 // максимальный размер встроенного содержимого задачи
+	clock          interfaces.Clock
+
+	presets interfaces.PresetRepository // пресеты параметров задач (nil - пресеты отключены)
+
+	manifests       interfaces.ManifestFetcher // загрузка манифестов manifest_url (nil - отключена)
+	maxManifestSize int64
+
+	maxActiveTasks int                        // максимум незавершенных задач (0 - без ограничения)
+	admission      sync.Mutex                 // подсчет активных задач и создание новой выполняются атомарно
+	maintenance    interfaces.MaintenanceMode // режим обслуживания (nil - задачи принимаются всегда)
+
+	contentIDs  bool      // ID задачи вычисляется по набору её файлов вместо случайного
+	idNamespace uuid.UUID // пространство имен UUIDv5 для ID по содержимому
+
+	defaultTTL time.Duration // срок хранения задач, для которых он не задан при создании (0 - бессрочно)
+
+	deadLetters     interfaces.DeadLetterRepository // окончательно неудачные задачи (nil - не переносятся)
+	deadLetterAfter time.Duration                   // время после завершения задачи ошибкой до её переноса
+
+	treeLimits TreeLimits // ограничения дерева файлов задачи
+
+	compactionRetention time.Duration // срок хранения задач с итоговым статусом при сжатии хранилища (0 - бессрочно)
+
+	locker interfaces.TaskLocker // блокировки задач, общие с use case скачивания
+}
+
+// TaskOption настраивает use case задач
+type TaskOption func(*TaskUsecase)
+
+// WithSupportedSchemes задает список допустимых схем URL
+func WithSupportedSchemes(schemes []string) TaskOption {
+	return func(u *TaskUsecase) {
+		u.schemes = make(map[string]bool, len(schemes))
+		for _, scheme := range schemes {
+			u.schemes[strings.ToLower(scheme)] = true
+		}
+	}
+}
+
+// WithPageSize задает размер страницы по умолчанию и максимальный размер страницы списка задач
+func WithPageSize(defaultSize, maxSize int) TaskOption {
+	return func(u *TaskUsecase) {
+		if maxSize > 0 {
+			u.maxPageSize = maxSize
+		}
+		if defaultSize > 0 {
+			u.pageSize = defaultSize
+		}
+		if u.pageSize > u.maxPageSize {
+			u.pageSize = u.maxPageSize
+		}
+	}
+}
+
+// WithTrashDir задает директорию скачивания, внутри которой размещается корзина удаленных задач
+func WithTrashDir(downloadDir string) TaskOption {
+	return func(u *TaskUsecase) {
+		u.trash = trash{downloadDir: downloadDir}
+	}
+}
+
+// WithContentStore задает директорию хранилища содержимого дедупликации: записи, на которые после
+// окончательного удаления файлов задач не ссылается ни один файл, удаляются
+func WithContentStore(dir string) TaskOption {
+	return func(u *TaskUsecase) {
+		u.contents = newContentStore(dir, noopMetrics{})
+	}
+}
+
+// WithHostValidation отклоняет при создании задачи URL с хостами, запрещенными политикой
+func WithHostValidation(policy HostPolicy) TaskOption {
+	return func(u *TaskUsecase) {
+		u.hostPolicy = policy
+	}
+}
+
+// WithMaxURLLength задает максимальную длину URL файла (0 - длина по умолчанию)
+func WithMaxURLLength(length int) TaskOption {
+	return func(u *TaskUsecase) {
+		if length > 0 {
+			u.maxURLLength = length
+		}
+	}
+}
+
+// WithMaxInlineContentSize задает максимальный размер встроенного содержимого задачи в байтах, суммарно по всем
+// её файлам (0 - размер по умолчанию)
+func WithMaxInlineContentSize(size int64) TaskOption {
+	return func(u *TaskUsecase) {
+		if size > 0 {
+			u.maxInlineSize = size
+		}
+	}
+}
+
+// WithMaxActiveTasks ограничивает число незавершенных задач (scheduled, new и processing):
+// при достижении предела новые задачи отклоняются с ErrTooManyActiveTasks. 0 снимает ограничение.
+func WithMaxActiveTasks(limit int) TaskOption {
+	return func(u *TaskUsecase) {
+		if limit > 0 {
+			u.maxActiveTasks = limit
+		}
+	}
+}
+
+// DefaultTaskIDNamespace - пространство имен UUIDv5 для ID задач по содержимому, если оно не задано в конфигурации
+var DefaultTaskIDNamespace = uuid.MustParse("cc26ad12-02c7-4861-a8a7-e8e1e5e601ca")
+
+// WithContentTaskIDs вычисляет ID новой задачи по набору её файлов (UUIDv5 в пространстве namespace) вместо
+// случайного UUIDv4: повторная отправка того же набора файлов возвращает уже созданную задачу
+func WithContentTaskIDs(namespace uuid.UUID) TaskOption {
+	return func(u *TaskUsecase) {
+		u.contentIDs = true
+		u.idNamespace = namespace
+	}
+}
+
+// WithDefaultTTL задает срок хранения задач, для которых ttl не указан при создании:
+// по его истечении задача удаляется вместе с файлами. 0 оставляет такие задачи бессрочными.
+func WithDefaultTTL(ttl time.Duration) TaskOption {
+	return func(u *TaskUsecase) {
+		if ttl > 0 {
+			u.defaultTTL = ttl
+		}
+	}
+}
+
+// WithTaskLocker задает блокировки задач, общие с use case скачивания: задача проверяется и изменяется через API
+// под той же блокировкой, под которой воркер переводит её в обработку
+func WithTaskLocker(locker interfaces.TaskLocker) TaskOption {
+	return func(u *TaskUsecase) {
+		if locker != nil {
+			u.locker = locker
+		}
+	}
+}
+
+// WithTaskClock задает источник времени (по умолчанию - системное время)
+func WithTaskClock(clock interfaces.Clock) TaskOption {
+	return func(u *TaskUsecase) {
+		u.clock = clock
+	}
 }
 
 // NewTaskUsecase создает новый use case для задач
-func NewTaskUsecase(taskRepo interfaces.TaskRepository, persistentRepo interfaces.PersistentRepository) interfaces.TaskUsecase {
-	return &TaskUsecase{
+func NewTaskUsecase(taskRepo interfaces.TaskRepository, persistentRepo interfaces.PersistentRepository, opts ...TaskOption) interfaces.TaskUsecase {
+	u := &TaskUsecase{
 		taskRepo:       taskRepo,
 		persistentRepo: persistentRepo,
+		schemes: map[string]bool{
+			"http":  true,
+			"https": true,
+		},
+		pageSize:      DefaultPageSize,
+		maxPageSize:   MaxPageSize,
+		maxURLLength:  DefaultMaxURLLength,
+		maxInlineSize: DefaultMaxInlineContentSize,
+		orphanAction:  OrphanActionOff,
+		events:        noopEvents{},
+		clock:         systemClock{},
+
+		maxManifestSize: DefaultMaxManifestSize,
+		treeLimits:      TreeLimits{MaxDepth: DefaultTreeMaxDepth, MaxEntries: DefaultTreeMaxEntries},
+		locker:          NewTaskLocker(),
+	}
+
+	for _, opt := range opts {
+		opt(u)
 	}
+
+	return u
 }
 
 // CreateTask создает новую задачу скачивания
 func (u *TaskUsecase) CreateTask(ctx context.Context, urls []string) (*entities.Task, error) {
-	if len(urls) == 0 {
+	return u.CreateTaskFromSpec(ctx, entities.NewTaskSpec(urls))
+}
+
+// CreateTaskFromSpec создает новую задачу скачивания по описанию файлов.
+// С WithContentTaskIDs уже созданная задача с тем же набором файлов возвращается вместе с ошибкой ErrTaskExists.
+func (u *TaskUsecase) CreateTaskFromSpec(ctx context.Context, spec entities.TaskSpec) (*entities.Task, error) {
+	if u.maintenance != nil && u.maintenance.Enabled() {
+		return nil, entities.ErrMaintenance
+	}
+
+	// Незаданные параметры берутся из пресета до проверки: итоговые параметры проверяются как заданные явно
+	if spec.Preset != "" {
+		preset, err := u.getPreset(ctx, spec.Preset)
+		if err != nil {
+			return nil, err
+		}
+		spec = preset.Apply(spec)
+	}
+
+	// Файлы из манифеста добавляются после явно перечисленных и проверяются так же
+	if spec.ManifestURL != "" {
+		files, err := u.expandManifest(ctx, spec)
+		if err != nil {
+			return nil, err
+		}
+		spec.Files = append(append([]entities.FileSpec(nil), spec.Files...), files...)
+	}
+
+	if len(spec.Files) == 0 {
 		return nil, fmt.Errorf("не предоставлены URL")
 	}
 
-	// Валидация URL
-	for _, url := range urls {
-		if url == "" {
-			return nil, fmt.Errorf("предоставлен пустой URL")
+	// Валидация URL и встроенного содержимого
+	validation := &entities.ValidationError{}
+	var inlineSize int64
+	for i, file := range spec.Files {
+		label := abbreviate(file.URL)
+		if file.Content != "" {
+			label = abbreviate(file.Name)
+			size, reason := u.validateInline(file)
+			if reason != "" {
+				validation.Add(i, label, reason)
+			}
+			inlineSize += size
+		} else if reason := u.validateURL(file.URL); reason != "" {
+			validation.Add(i, label, reason)
+		}
+		for _, mirror := range file.Mirrors {
+			if file.Content != "" {
+				validation.Add(i, label, "зеркала не поддерживаются для встроенного содержимого")
+				break
+			}
+			if reason := u.validateURL(mirror); reason != "" {
+				validation.Add(i, label, fmt.Sprintf("зеркало %s: %s", abbreviate(mirror), reason))
+			}
 		}
+		if file.ExpectedSize < 0 {
+			validation.Add(i, label, "ожидаемый размер не может быть отрицательным")
+		}
+		if file.SHA256 != "" && !isSHA256(file.SHA256) {
+			validation.Add(i, label, "sha256 должна содержать 64 шестнадцатеричных символа")
+		}
+	}
+	if validation.HasErrors() {
+		return nil, validation
+	}
+	// Встроенное содержимое хранится в задаче до её удаления, поэтому ограничен и его общий размер
+	if inlineSize > u.maxInlineSize {
+		return nil, fmt.Errorf("%w: встроенное содержимое задачи больше %d байт (%d)", entities.ErrInvalidRequest, u.maxInlineSize, inlineSize)
+	}
+
+	outputDir, err := u.validateSettings(spec)
+	if err != nil {
+		return nil, err
 	}
 
 	// Создание новой задачи
-	task := entities.NewTask(urls)
+	now := u.clock.Now()
+	task := entities.NewTask(spec.URLs(), now)
+	if u.contentIDs {
+		task.ID = entities.ContentTaskID(u.idNamespace, fileKeys(spec.Files))
+		if existing, err := u.existingTask(ctx, task.ID.String()); existing != nil || err != nil {
+			return existing, err
+		}
+	}
+	task.Headers = spec.Headers
+	task.RequestID = spec.RequestID
+	task.FailureThreshold = strings.TrimSpace(spec.FailureThreshold)
+	task.Compress = spec.Compress
+	task.PreservePath = spec.PreservePath
+	task.OutputDir = outputDir
+	task.Extract = spec.Extract
+	task.DiskQuota = spec.DiskQuota
+	task.FreshnessWindow = entities.Duration(spec.FreshnessWindow)
+	task.CallbackURL = spec.CallbackURL
+	task.RetryPolicy = spec.RetryPolicy
+	task.SlowDownload = spec.SlowDownload
+	task.DownloadWindow = spec.DownloadWindow
+	task.ManifestURL = spec.ManifestURL
+	task.Preset = spec.Preset
+	if spec.MaxConcurrency > 0 {
+		task.MaxConcurrency = clampConcurrency(spec.MaxConcurrency)
+	}
+	if ttl := u.ttlFor(spec); ttl > 0 {
+		expiresAt := now.Add(ttl)
+		task.ExpiresAt = &expiresAt
+	}
+	task.StartAt = spec.StartAt
+	if !task.IsDue(now) {
+		task.Status = entities.TaskStatusScheduled
+	}
 
-	// Инициализация файлов с URL
-	for i, url := range urls {
+	// Инициализация файлов с URL или встроенным содержимым
+	for i, file := range spec.Files {
 		task.Files[i] = entities.File{
-			URL:    url,
-			Status: "pending",
+			URL:            file.URL,
+			Mirrors:        file.Mirrors,
+			Status:         "pending",
+			ExpectedSize:   file.ExpectedSize,
+			ExpectedSHA256: strings.ToLower(file.SHA256),
+			Priority:       file.Priority,
+			Name:           file.Name,
+			Content:        file.Content,
+		}
+	}
+
+	// Проверка числа активных задач и сохранение выполняются под одной блокировкой,
+	// чтобы одновременные запросы не превысили ограничение
+	if u.maxActiveTasks > 0 {
+		u.admission.Lock()
+		defer u.admission.Unlock()
+
+		active, err := u.countActiveTasks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if active >= u.maxActiveTasks {
+			return nil, fmt.Errorf("%w: %d из %d", entities.ErrTooManyActiveTasks, active, u.maxActiveTasks)
 		}
 	}
 
 	// Сохранение в репозитории
 	if err := u.taskRepo.Create(ctx, task); err != nil {
+		// Одновременный запрос с тем же набором файлов успел создать задачу первым
+		if u.contentIDs && errors.Is(err, entities.ErrTaskExists) {
+			if existing, err := u.existingTask(ctx, task.ID.String()); existing != nil || err != nil {
+				return existing, err
+			}
+		}
 		return nil, fmt.Errorf("не удалось создать задачу: %w", err)
 	}
 
@@ -55,9 +365,101 @@ func (u *TaskUsecase) CreateTask(ctx context.Context, urls []string) (*entities.
 		return nil, fmt.Errorf("не удалось сохранить задачу: %w", err)
 	}
 
+	log.Printf("Создана задача %s из %d файлов", task.LogID(), len(task.Files))
+	u.events.Publish(entities.NewTaskEvent(entities.EventTaskCreated, task, now))
 	return task, nil
 }
 
+// existingTask возвращает задачу с ID по содержимому, если она уже создана, вместе с ошибкой ErrTaskExists.
+// Задача в корзине не возвращается: её ID занят, поэтому создание отклоняется с ErrTaskDeleted.
+// Если задачи нет, возвращается nil без ошибки; прочие ошибки хранилища возвращаются как есть.
+func (u *TaskUsecase) existingTask(ctx context.Context, id string) (*entities.Task, error) {
+	existing, err := u.taskRepo.GetByID(ctx, id)
+	if errors.Is(err, entities.ErrTaskNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось проверить задачу с тем же набором файлов: %w", err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+	if existing.IsDeleted() {
+		return nil, fmt.Errorf("%w: задача с тем же набором файлов находится в корзине: %s", entities.ErrTaskDeleted, id)
+	}
+	log.Printf("Задача %s с тем же набором файлов уже создана: возвращена существующая", existing.LogID())
+	return existing, fmt.Errorf("%w: %s", entities.ErrTaskExists, id)
+}
+
+// fileKeys возвращает ключи файлов описания для ID задачи по содержимому: URL файла,
+// а для встроенного содержимого - имя и контрольную сумму данных
+func fileKeys(files []entities.FileSpec) []string {
+	keys := make([]string, len(files))
+	for i, file := range files {
+		if file.Content != "" {
+			sum := sha256.Sum256([]byte(file.Content))
+			keys[i] = "inline:" + file.Name + ":" + hex.EncodeToString(sum[:])
+			continue
+		}
+		keys[i] = file.URL
+	}
+	return keys
+}
+
+// validateSettings проверяет параметры задачи, не относящиеся к отдельным файлам, и возвращает
+// проверенную директорию скачивания задачи
+func (u *TaskUsecase) validateSettings(spec entities.TaskSpec) (string, error) {
+	if err := validateHeaders(spec.Headers); err != nil {
+		return "", err
+	}
+	if spec.MaxConcurrency < 0 {
+		return "", fmt.Errorf("%w: max_concurrency не может быть отрицательным: %d", entities.ErrInvalidRequest, spec.MaxConcurrency)
+	}
+	if spec.TTL < 0 {
+		return "", fmt.Errorf("%w: ttl не может быть отрицательным: %v", entities.ErrInvalidRequest, spec.TTL)
+	}
+	if spec.FreshnessWindow < 0 {
+		return "", fmt.Errorf("%w: freshness_window не может быть отрицательным: %v", entities.ErrInvalidRequest, spec.FreshnessWindow)
+	}
+	if spec.DiskQuota < 0 {
+		return "", fmt.Errorf("%w: disk_quota не может быть отрицательным: %d", entities.ErrInvalidRequest, spec.DiskQuota)
+	}
+	if _, err := ParseFailureThreshold(spec.FailureThreshold); err != nil {
+		return "", fmt.Errorf("%w: %v", entities.ErrInvalidRequest, err)
+	}
+	if err := validateRetryPolicy(spec.RetryPolicy); err != nil {
+		return "", err
+	}
+	if err := validateSlowDownload(spec.SlowDownload); err != nil {
+		return "", err
+	}
+	if spec.DownloadWindow != nil {
+		if err := spec.DownloadWindow.Validate(); err != nil {
+			return "", fmt.Errorf("%w: download_window: %v", entities.ErrInvalidRequest, err)
+		}
+	}
+	if reason := u.validateCallbackURL(spec.CallbackURL); reason != "" {
+		return "", fmt.Errorf("%w: callback_url: %s", entities.ErrInvalidRequest, reason)
+	}
+	return u.resolveOutputDir(spec.OutputDir)
+}
+
+// countActiveTasks возвращает число незавершенных задач
+func (u *TaskUsecase) countActiveTasks(ctx context.Context) (int, error) {
+	tasks, err := u.taskRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	active := 0
+	for _, task := range tasks {
+		if task.IsActive() {
+			active++
+		}
+	}
+	return active, nil
+}
+
 // GetTask получает задачу по ID
 func (u *TaskUsecase) GetTask(ctx context.Context, id string) (*entities.Task, error) {
 	task, err := u.taskRepo.GetByID(ctx, id)
@@ -78,6 +480,40 @@ func (u *TaskUsecase) GetAllTasks(ctx context.Context) ([]*entities.Task, error)
 	return tasks, nil
 }
 
+// ListTasks возвращает страницу задач, отсортированных по времени создания и ID, следующих за курсором.
+// Если limit не задан, используется размер страницы по умолчанию; limit больше максимального ограничивается им.
+// Удаленные задачи включаются, только если это указано в фильтре.
+func (u *TaskUsecase) ListTasks(ctx context.Context, limit int, cursor string, filter entities.TaskFilter) (*entities.TaskPage, error) {
+	after, err := decodeTaskCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = u.pageSize
+	}
+	if limit > u.maxPageSize {
+		limit = u.maxPageSize
+	}
+
+	// Лишняя задача показывает, есть ли следующая страница
+	tasks, err := u.taskRepo.GetTasksAfter(ctx, after, limit+1, filter)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	page := &entities.TaskPage{
+		Tasks: tasks,
+		Limit: limit,
+	}
+	if len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		page.NextCursor = encodeTaskCursor(entities.CursorAfter(page.Tasks[limit-1]))
+	}
+
+	return page, nil
+}
+
 // GetTaskStatus получает статус задачи по ID
 func (u *TaskUsecase) GetTaskStatus(ctx context.Context, id string) (*entities.Task, error) {
 	task, err := u.taskRepo.GetByID(ctx, id)
@@ -87,3 +523,569 @@ func (u *TaskUsecase) GetTaskStatus(ctx context.Context, id string) (*entities.T
 
 	return task, nil
 }
+
+// GetTaskStatuses получает задачи по списку ID одним обращением к репозиторию.
+// Повторяющиеся ID учитываются один раз, ненайденные ID возвращаются в NotFound, а не приводят к ошибке.
+func (u *TaskUsecase) GetTaskStatuses(ctx context.Context, ids []string) (*entities.TaskStatuses, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: не переданы ID задач", entities.ErrInvalidRequest)
+	}
+
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > MaxStatusBatchSize {
+		return nil, fmt.Errorf("%w: передано %d ID задач, допустимо не больше %d", entities.ErrInvalidRequest, len(unique), MaxStatusBatchSize)
+	}
+
+	tasks, err := u.taskRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить статусы задач: %w", err)
+	}
+
+	statuses := &entities.TaskStatuses{Tasks: tasks, NotFound: []string{}}
+	for _, id := range unique {
+		if _, ok := tasks[id]; !ok {
+			statuses.NotFound = append(statuses.NotFound, id)
+		}
+	}
+	return statuses, nil
+}
+
+// UpdateTask изменяет заголовки, политику повторов или URL уведомления созданной задачи.
+// Задачу в обработке изменить нельзя: скачивание уже использует прежние параметры.
+// Новые параметры применяются к следующим скачиваниям, например после повтора файла.
+func (u *TaskUsecase) UpdateTask(ctx context.Context, id string, update entities.TaskUpdate) (*entities.Task, error) {
+	if update.IsEmpty() {
+		return nil, fmt.Errorf("%w: не заданы изменяемые поля", entities.ErrInvalidRequest)
+	}
+
+	// Проверка статуса и сохранение выполняются под блокировкой задачи: воркер не начнет её обработку между ними
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	if task.Status == entities.TaskStatusProcessing {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskProcessing, id)
+	}
+
+	if task.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskDeleted, id)
+	}
+
+	// Все поля проверяются до изменения задачи, чтобы неверное поле не оставило её изменённой частично
+	if update.Headers != nil {
+		if err := validateHeaders(*update.Headers); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateRetryPolicy(update.RetryPolicy); err != nil {
+		return nil, err
+	}
+	if update.CallbackURL != nil {
+		if reason := u.validateCallbackURL(*update.CallbackURL); reason != "" {
+			return nil, fmt.Errorf("%w: callback_url: %s", entities.ErrInvalidRequest, reason)
+		}
+	}
+
+	var changed []string
+	if update.Headers != nil {
+		task.Headers = nil
+		if len(*update.Headers) > 0 {
+			task.Headers = *update.Headers
+		}
+		changed = append(changed, "headers")
+	}
+	if update.RetryPolicy != nil {
+		task.RetryPolicy = nil
+		if policy := *update.RetryPolicy; policy.MaxAttempts != 0 || policy.Backoff != 0 || policy.MaxBackoff != 0 || len(policy.RetryOn) > 0 {
+			task.RetryPolicy = &policy
+		}
+		changed = append(changed, "retry_policy")
+	}
+	if update.CallbackURL != nil {
+		task.CallbackURL = *update.CallbackURL
+		changed = append(changed, "callback_url")
+	}
+
+	task.UpdatedAt = u.clock.Now()
+	if err := u.save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Задача %s: изменены параметры %s", task.LogID(), strings.Join(changed, ", "))
+	return task, nil
+}
+
+// RetryFile сбрасывает один файл задачи в pending и возвращает задачу в очередь
+func (u *TaskUsecase) RetryFile(ctx context.Context, id string, fileIndex int) (*entities.Task, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	if fileIndex < 0 || fileIndex >= len(task.Files) {
+		return nil, fmt.Errorf("%w: %d", entities.ErrInvalidFileIndex, fileIndex)
+	}
+
+	if task.Status == entities.TaskStatusProcessing {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskProcessing, id)
+	}
+
+	if task.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskDeleted, id)
+	}
+
+	task.Files[fileIndex] = task.Files[fileIndex].Pending()
+	task.Error = ""
+	task.Checksum = ""
+	now := u.clock.Now()
+	task.UpdateStatus(requeueStatus(task, now), now)
+
+	if err := u.taskRepo.Update(ctx, task); err != nil {
+		return nil, fmt.Errorf("не удалось обновить задачу: %w", err)
+	}
+
+	if err := u.persistentRepo.Update(ctx, task); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить задачу: %w", err)
+	}
+
+	return task, nil
+}
+
+// RetryFailedFiles сбрасывает в pending только файлы задачи, завершившиеся ошибкой, вместе с их счетчиками попыток,
+// и возвращает задачу в очередь. Скачанные файлы и история задачи (время начала и завершения) сохраняются.
+// Возвращает задачу и число возвращенных в очередь файлов; без файлов с ошибкой задача не меняется.
+func (u *TaskUsecase) RetryFailedFiles(ctx context.Context, id string) (*entities.Task, int, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	if task.Status == entities.TaskStatusProcessing {
+		return nil, 0, fmt.Errorf("%w: %s", entities.ErrTaskProcessing, id)
+	}
+
+	if task.IsDeleted() {
+		return nil, 0, fmt.Errorf("%w: %s", entities.ErrTaskDeleted, id)
+	}
+
+	requeued := 0
+	for i := range task.Files {
+		if task.Files[i].Status == "failed" {
+			task.Files[i] = task.Files[i].Pending()
+			requeued++
+		}
+	}
+	if requeued == 0 {
+		return task, 0, nil
+	}
+
+	task.Error = ""
+	task.Checksum = ""
+	now := u.clock.Now()
+	task.UpdateStatus(requeueStatus(task, now), now)
+	if err := u.save(ctx, task); err != nil {
+		return nil, 0, err
+	}
+
+	log.Printf("Задача %s: файлов с ошибкой возвращено в очередь: %d", task.LogID(), requeued)
+	return task, requeued, nil
+}
+
+// VerifyTask проверяет скачанные файлы задачи на диске: наличие, размер и контрольную сумму, если она записана.
+// Если requeue равен true, не прошедшие проверку файлы сбрасываются в pending и задача возвращается в очередь.
+func (u *TaskUsecase) VerifyTask(ctx context.Context, id string, requeue bool) (*entities.VerificationReport, error) {
+	// Без возврата в очередь задача не изменяется, и проверка не задерживает начало её обработки
+	if requeue {
+		unlock := u.locker.Lock(id)
+		defer unlock()
+	}
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	if task.Status == entities.TaskStatusProcessing {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskProcessing, id)
+	}
+
+	if task.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskDeleted, id)
+	}
+
+	report := &entities.VerificationReport{
+		TaskID: id,
+		Files:  make([]entities.FileVerification, len(task.Files)),
+	}
+	for i := range task.Files {
+		// Проверка больших задач может быть долгой: клиент, переставший ждать, её прерывает
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("проверка файлов прервана: %w", err)
+		}
+		result := verifyFile(i, task.Files[i])
+		if result.Status != entities.VerificationOK && result.Status != entities.VerificationSkipped {
+			report.Failed++
+			if requeue {
+				// Поврежденный файл скачивается заново независимо от окна свежести задачи
+				task.Files[i] = task.Files[i].Pending()
+				task.Files[i].LastDownloadedAt = nil
+				result.Requeued = true
+			}
+		}
+		report.Files[i] = result
+	}
+
+	if requeue && report.Failed > 0 {
+		task.Error = ""
+		task.Checksum = ""
+		now := u.clock.Now()
+		task.UpdateStatus(requeueStatus(task, now), now)
+		if err := u.save(ctx, task); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// DeleteTask помечает задачу удаленной и перемещает её файлы в корзину.
+// Повторное удаление задачи, уже находящейся в корзине, ничего не меняет.
+func (u *TaskUsecase) DeleteTask(ctx context.Context, id string) (*entities.Task, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	if task.IsDeleted() {
+		return task, nil
+	}
+
+	if task.Status == entities.TaskStatusProcessing {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskProcessing, id)
+	}
+
+	if err := u.trash.moveIn(task); err != nil {
+		return nil, err
+	}
+	task.MarkDeleted(u.clock.Now())
+
+	if err := u.save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// RestoreTask возвращает задачу и её файлы из корзины
+func (u *TaskUsecase) RestoreTask(ctx context.Context, id string) (*entities.Task, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	if !task.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskNotDeleted, id)
+	}
+
+	if err := u.trash.moveOut(task); err != nil {
+		return nil, err
+	}
+	task.MarkRestored(u.clock.Now())
+
+	if err := u.save(ctx, task); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// PurgeDeletedTasks окончательно удаляет задачи, помещенные в корзину раньше указанного момента.
+// Возвращает количество удаленных задач.
+func (u *TaskUsecase) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int, error) {
+	tasks, err := u.taskRepo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить задачи: %w", err)
+	}
+
+	purged := 0
+	for _, task := range tasks {
+		if !task.IsDeleted() || !task.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+
+		ok, err := u.purgeDeleted(ctx, task.ID.String(), deletedBefore)
+		if err != nil {
+			return purged, err
+		}
+		if ok {
+			purged++
+		}
+	}
+	if purged > 0 {
+		u.collectContents()
+	}
+
+	return purged, nil
+}
+
+// purgeDeleted окончательно удаляет задачу из корзины, если она не восстановлена после получения списка задач.
+// Возвращает true, если задача удалена.
+func (u *TaskUsecase) purgeDeleted(ctx context.Context, id string, deletedBefore time.Time) (bool, error) {
+	unlock := u.locker.Lock(id)
+	defer unlock()
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil || !task.IsDeleted() || !task.DeletedAt.Before(deletedBefore) {
+		return false, nil
+	}
+	if err := u.trash.purge(task); err != nil {
+		log.Printf("Не удалось удалить файлы задачи %s из корзины: %v", id, err)
+		return false, nil
+	}
+	if err := u.deleteStored(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListFailures возвращает файлы, которые не удалось скачать начиная с момента since, от самых свежих.
+// Размер списка ограничивается так же, как размер страницы списка задач.
+func (u *TaskUsecase) ListFailures(ctx context.Context, since time.Time, limit int) ([]entities.FailedFile, error) {
+	if limit <= 0 {
+		limit = u.pageSize
+	}
+	if limit > u.maxPageSize {
+		limit = u.maxPageSize
+	}
+
+	failed, err := u.taskRepo.GetFailedFiles(ctx, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить ошибки скачивания: %w", err)
+	}
+
+	return failed, nil
+}
+
+// OpenFileContent открывает содержимое скачанного файла задачи. Сжатый на диске файл распаковывается,
+// если decompress равен true, иначе возвращаются сжатые данные с указанием сжатия
+func (u *TaskUsecase) OpenFileContent(ctx context.Context, id string, fileIndex int, decompress bool) (*entities.FileContent, error) {
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	if task.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskDeleted, id)
+	}
+
+	if fileIndex < 0 || fileIndex >= len(task.Files) {
+		return nil, fmt.Errorf("%w: %d", entities.ErrInvalidFileIndex, fileIndex)
+	}
+
+	file := task.Files[fileIndex]
+	if file.Status != "completed" || file.Path == "" {
+		return nil, fmt.Errorf("%w: файл %d задачи %s в статусе %s", entities.ErrFileNotReady, fileIndex, id, file.Status)
+	}
+
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл %s: %w", file.Path, err)
+	}
+
+	content := &entities.FileContent{File: file, Length: info.Size(), ModTime: info.ModTime()}
+	unpack := ""
+	if file.Compression != "" && decompress {
+		unpack = file.Compression
+		content.Length = file.Size
+	} else {
+		content.Encoding = file.Compression
+	}
+	content.Content, err = openContent(file.Path, unpack)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл %s: %w", file.Path, err)
+	}
+	return content, nil
+}
+
+// ListFiles возвращает страницу файлов задачи, подходящих под фильтр, с индексами больше указанного в курсоре.
+// Размер страницы ограничивается так же, как в ListTasks.
+func (u *TaskUsecase) ListFiles(ctx context.Context, id string, filter entities.FileFilter, limit int, cursor string) (*entities.FilePage, error) {
+	after, err := decodeFileCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = u.pageSize
+	}
+	if limit > u.maxPageSize {
+		limit = u.maxPageSize
+	}
+
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+
+	page := &entities.FilePage{
+		Files: []entities.TaskFile{},
+		Limit: limit,
+	}
+	for i, file := range task.Files {
+		if filter.Status != "" && file.Status != filter.Status {
+			continue
+		}
+		page.Total++
+		if i <= after || page.Total <= filter.Offset {
+			continue
+		}
+		if len(page.Files) == limit {
+			page.NextCursor = encodeFileCursor(page.Files[limit-1].Index)
+			continue
+		}
+		page.Files = append(page.Files, entities.TaskFile{Index: i, File: file})
+	}
+
+	return page, nil
+}
+
+// save обновляет задачу в оперативном и постоянном хранилищах
+func (u *TaskUsecase) save(ctx context.Context, task *entities.Task) error {
+	if err := u.taskRepo.Update(ctx, task); err != nil {
+		return fmt.Errorf("не удалось обновить задачу: %w", err)
+	}
+
+	if err := u.persistentRepo.Update(ctx, task); err != nil {
+		return fmt.Errorf("не удалось сохранить задачу: %w", err)
+	}
+
+	return nil
+}
+
+// requeueStatus возвращает статус задачи, возвращаемой в очередь: до наступления start_at и вне окна скачивания
+// она остается запланированной
+func requeueStatus(task *entities.Task, now time.Time) entities.TaskStatus {
+	if !task.IsDue(now) {
+		return entities.TaskStatusScheduled
+	}
+	return entities.TaskStatusNew
+}
+
+// validateURL проверяет, что URL непустой и использует поддерживаемую схему.
+// Возвращает причину отказа или пустую строку, если URL корректен.
+func (u *TaskUsecase) validateURL(rawURL string) string {
+	if rawURL == "" {
+		return "пустой URL"
+	}
+	if len(rawURL) > u.maxURLLength {
+		return fmt.Sprintf("URL длиннее %d байт (%d)", u.maxURLLength, len(rawURL))
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Sprintf("неверный URL: %v", err)
+	}
+
+	if !u.schemes[strings.ToLower(parsed.Scheme)] {
+		return fmt.Sprintf("неподдерживаемая схема URL %q", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return "в URL отсутствует хост"
+	}
+
+	if err := u.hostPolicy.Check(parsed.Hostname()); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// validateCallbackURL проверяет URL уведомления о завершении задачи: пустой URL допустим,
+// иначе он должен быть абсолютным URL со схемой http или https.
+// Возвращает причину отказа или пустую строку, если URL корректен.
+func (u *TaskUsecase) validateCallbackURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	return u.validateHTTPURL(rawURL)
+}
+
+// validateHTTPURL проверяет, что URL - абсолютный URL со схемой http или https.
+// Возвращает причину отказа или пустую строку, если URL корректен.
+func (u *TaskUsecase) validateHTTPURL(rawURL string) string {
+	if len(rawURL) > u.maxURLLength {
+		return fmt.Sprintf("URL длиннее %d байт (%d)", u.maxURLLength, len(rawURL))
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Sprintf("неверный URL: %v", err)
+	}
+	if scheme := strings.ToLower(parsed.Scheme); scheme != "http" && scheme != "https" {
+		return fmt.Sprintf("неподдерживаемая схема URL %q", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return "в URL отсутствует хост"
+	}
+	return ""
+}
+
+// validateRetryPolicy проверяет политику повторов задачи
+func validateRetryPolicy(policy *entities.TaskRetryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxAttempts < 0 || policy.MaxAttempts > MaxRetryAttempts {
+		return fmt.Errorf("%w: retry_policy.max_attempts должен быть от 0 (из конфигурации) до %d: %d", entities.ErrInvalidRequest, MaxRetryAttempts, policy.MaxAttempts)
+	}
+	if policy.Backoff < 0 || policy.MaxBackoff < 0 {
+		return fmt.Errorf("%w: задержки retry_policy не могут быть отрицательными", entities.ErrInvalidRequest)
+	}
+	if _, err := ParseRetryConditions(policy.RetryOn); err != nil {
+		return fmt.Errorf("%w: retry_policy.retry_on: %v", entities.ErrInvalidRequest, err)
+	}
+	return nil
+}
+
+// validateHeaders проверяет имена и значения HTTP-заголовков задачи
+func validateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("%w: недопустимое имя заголовка %q", entities.ErrInvalidRequest, name)
+		}
+		if len(name) > maxHeaderNameLength {
+			return fmt.Errorf("%w: имя заголовка длиннее %d байт", entities.ErrInvalidRequest, maxHeaderNameLength)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: недопустимое значение заголовка %s", entities.ErrInvalidRequest, name)
+		}
+		if len(value) > maxHeaderValueLength {
+			return fmt.Errorf("%w: значение заголовка %s длиннее %d байт", entities.ErrInvalidRequest, name, maxHeaderValueLength)
+		}
+	}
+	return nil
+}
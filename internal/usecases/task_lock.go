@@ -0,0 +1,55 @@
+package usecases
+
+import (
+	"sync"
+
+	"file-downloader/internal/interfaces"
+)
+
+// taskLocker - блокировки задач по ID. Блокировка задачи хранится, пока её кто-то удерживает или ждет,
+// поэтому число блокировок не растет с числом когда-либо существовавших задач.
+type taskLocker struct {
+	mu    sync.Mutex
+	locks map[string]*taskLock
+}
+
+// taskLock - блокировка одной задачи и число удерживающих и ожидающих её
+type taskLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewTaskLocker создает блокировки задач, общие для use case задач и скачивания
+func NewTaskLocker() interfaces.TaskLocker {
+	return &taskLocker{locks: make(map[string]*taskLock)}
+}
+
+// Lock захватывает блокировку задачи и возвращает функцию её освобождения
+func (l *taskLocker) Lock(taskID string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[taskID]
+	if !ok {
+		lock = &taskLock{}
+		l.locks[taskID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if lock.refs--; lock.refs == 0 {
+			delete(l.locks, taskID)
+		}
+	}
+}
+
+// size возвращает число хранимых блокировок
+func (l *taskLocker) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
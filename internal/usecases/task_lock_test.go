@@ -0,0 +1,122 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/interfaces"
+)
+
+func TestTaskLockerRemovesReleasedLocks(t *testing.T) {
+	// Setup
+	locker := NewTaskLocker().(*taskLocker)
+
+	// Execute
+	unlockA := locker.Lock("a")
+	unlockB := locker.Lock("b")
+	unlockA()
+	unlockB()
+
+	// Assert
+	if size := locker.size(); size != 0 {
+		t.Errorf("Expected no locks to be kept after release, got %d", size)
+	}
+}
+
+// startWhileWaiting holds the task lock, runs call and moves the task to processing while call waits for the lock,
+// as a worker starting the task would. Returns the error of call.
+func startWhileWaiting(t *testing.T, locker interfaces.TaskLocker, repo *MockTaskRepository, id string, call func() error) error {
+	t.Helper()
+	unlock := locker.Lock(id)
+	done := make(chan error, 1)
+	go func() { done <- call() }()
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("Expected the call to wait for the task lock, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	started, _ := repo.GetByID(context.Background(), id)
+	started = started.Clone()
+	started.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	repo.Update(context.Background(), started)
+	unlock()
+	return <-done
+}
+
+// newLockedTask creates a task in a task usecase with a shared task locker
+func newLockedTask(t *testing.T) (*MockTaskRepository, interfaces.TaskLocker, interfaces.TaskUsecase, *entities.Task) {
+	t.Helper()
+	mockRepo := NewMockTaskRepository()
+	locker := NewTaskLocker()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTaskLocker(locker))
+	task, err := usecase.CreateTask(context.Background(), []string{"https://example.com/file1.jpg"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	return mockRepo, locker, usecase, task
+}
+
+func TestDeleteTaskChecksStatusUnderTaskLock(t *testing.T) {
+	// Setup
+	mockRepo, locker, usecase, task := newLockedTask(t)
+
+	// Execute
+	err := startWhileWaiting(t, locker, mockRepo, task.ID.String(), func() error {
+		_, err := usecase.DeleteTask(context.Background(), task.ID.String())
+		return err
+	})
+
+	// Assert
+	if !errors.Is(err, entities.ErrTaskProcessing) {
+		t.Errorf("Expected ErrTaskProcessing for a task started while waiting, got %v", err)
+	}
+}
+
+func TestUpdateTaskChecksStatusUnderTaskLock(t *testing.T) {
+	// Setup
+	mockRepo, locker, usecase, task := newLockedTask(t)
+	headers := map[string]string{"Authorization": "Bearer fixed"}
+
+	// Execute
+	err := startWhileWaiting(t, locker, mockRepo, task.ID.String(), func() error {
+		_, err := usecase.UpdateTask(context.Background(), task.ID.String(), entities.TaskUpdate{Headers: &headers})
+		return err
+	})
+
+	// Assert
+	if !errors.Is(err, entities.ErrTaskProcessing) {
+		t.Errorf("Expected ErrTaskProcessing for a task started while waiting, got %v", err)
+	}
+	stored, _ := mockRepo.GetByID(context.Background(), task.ID.String())
+	if stored.Status != entities.TaskStatusProcessing || len(stored.Headers) != 0 {
+		t.Errorf("Expected processing task to stay unchanged, got status %s, headers %v", stored.Status, stored.Headers)
+	}
+}
+
+func TestRetryFailedFilesChecksStatusUnderTaskLock(t *testing.T) {
+	// Setup
+	mockRepo, locker, usecase, task := newLockedTask(t)
+	failed := task.Clone()
+	failed.Files[0] = entities.File{URL: "https://example.com/file1.jpg", Status: "failed", Error: "503"}
+	failed.UpdateStatus(entities.TaskStatusFailed, time.Now())
+	mockRepo.Update(context.Background(), failed)
+
+	// Execute
+	err := startWhileWaiting(t, locker, mockRepo, task.ID.String(), func() error {
+		_, _, err := usecase.RetryFailedFiles(context.Background(), task.ID.String())
+		return err
+	})
+
+	// Assert
+	if !errors.Is(err, entities.ErrTaskProcessing) {
+		t.Errorf("Expected ErrTaskProcessing for a task started while waiting, got %v", err)
+	}
+	stored, _ := mockRepo.GetByID(context.Background(), task.ID.String())
+	if stored.Files[0].Status != "failed" {
+		t.Errorf("Expected the file of the processing task not to be reset, got %s", stored.Files[0].Status)
+	}
+}
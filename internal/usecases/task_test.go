@@ -2,7 +2,14 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"file-downloader/internal/entities"
 )
@@ -31,6 +38,16 @@ func (m *MockTaskRepository) GetByID(ctx context.Context, id string) (*entities.
 	return task, nil
 }
 
+func (m *MockTaskRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*entities.Task, error) {
+	tasks := make(map[string]*entities.Task, len(ids))
+	for _, id := range ids {
+		if task, exists := m.tasks[id]; exists {
+			tasks[id] = task
+		}
+	}
+	return tasks, nil
+}
+
 func (m *MockTaskRepository) GetAll(ctx context.Context) ([]*entities.Task, error) {
 	tasks := make([]*entities.Task, 0, len(m.tasks))
 	for _, task := range m.tasks {
@@ -39,6 +56,20 @@ func (m *MockTaskRepository) GetAll(ctx context.Context) ([]*entities.Task, erro
 	return tasks, nil
 }
 
+func (m *MockTaskRepository) GetTasksAfter(ctx context.Context, after entities.TaskCursor, limit int, filter entities.TaskFilter) ([]*entities.Task, error) {
+	tasks := []*entities.Task{}
+	for _, task := range m.tasks {
+		if (filter.IncludeDeleted || !task.IsDeleted()) && filter.Follows(after, task) {
+			tasks = append(tasks, task)
+		}
+	}
+	filter.Sort(tasks)
+	if limit > 0 && len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks, nil
+}
+
 func (m *MockTaskRepository) Update(ctx context.Context, task *entities.Task) error {
 	if _, exists := m.tasks[task.ID.String()]; !exists {
 		return &TaskNotFoundError{task.ID.String()}
@@ -58,6 +89,9 @@ func (m *MockTaskRepository) Delete(ctx context.Context, id string) error {
 func (m *MockTaskRepository) GetPendingTasks(ctx context.Context) ([]*entities.Task, error) {
 	var pendingTasks []*entities.Task
 	for _, task := range m.tasks {
+		if task.IsDeleted() {
+			continue
+		}
 		if task.Status == entities.TaskStatusNew || task.Status == entities.TaskStatusProcessing {
 			pendingTasks = append(pendingTasks, task)
 		}
@@ -65,6 +99,20 @@ func (m *MockTaskRepository) GetPendingTasks(ctx context.Context) ([]*entities.T
 	return pendingTasks, nil
 }
 
+func (m *MockTaskRepository) GetFailedFiles(ctx context.Context, since time.Time, limit int) ([]entities.FailedFile, error) {
+	failed := []entities.FailedFile{}
+	for _, task := range m.tasks {
+		if !task.IsDeleted() {
+			failed = append(failed, task.FailedFiles(since)...)
+		}
+	}
+	entities.SortFailedFiles(failed)
+	if limit > 0 && len(failed) > limit {
+		failed = failed[:limit]
+	}
+	return failed, nil
+}
+
 func (m *MockTaskRepository) LoadTasks() error {
 	return nil
 }
@@ -81,6 +129,10 @@ func (e *TaskNotFoundError) Error() string {
 	return "task with id " + e.id + " not found"
 }
 
+func (e *TaskNotFoundError) Is(target error) bool {
+	return target == entities.ErrTaskNotFound
+}
+
 func TestCreateTask(t *testing.T) {
 	// Setup
 	mockRepo := NewMockTaskRepository()
@@ -299,3 +351,1154 @@ func TestGetTaskStatus(t *testing.T) {
 		t.Errorf("Expected status %s, got %s", entities.TaskStatusNew, statusTask.Status)
 	}
 }
+
+func TestCreateTaskUnsupportedScheme(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	// Execute
+	task, err := usecase.CreateTask(ctx, []string{"ftp://example.com/file1.jpg"})
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected error for unsupported scheme")
+	}
+
+	if task != nil {
+		t.Fatal("Expected task to be nil")
+	}
+}
+
+func TestCreateTaskWithSupportedSchemes(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithSupportedSchemes([]string{"http", "https", "ftp", "sftp"}))
+	ctx := context.Background()
+
+	urls := []string{
+		"ftp://example.com/pub/file1.jpg",
+		"sftp://user@example.com/data/file2.pdf",
+	}
+
+	// Execute
+	task, err := usecase.CreateTask(ctx, urls)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(task.Files) != len(urls) {
+		t.Errorf("Expected %d files, got %d", len(urls), len(task.Files))
+	}
+}
+
+func TestRetryFile(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	task, err := usecase.CreateTask(ctx, []string{"https://example.com/file1.jpg", "https://example.com/file2.pdf"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	task.Files[0].Status = "completed"
+	task.Files[1].Status = "completed"
+	task.Files[1].Size = 100
+	task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+
+	// Execute
+	retried, err := usecase.RetryFile(ctx, task.ID.String(), 1)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if retried.Status != entities.TaskStatusNew {
+		t.Errorf("Expected status %s, got %s", entities.TaskStatusNew, retried.Status)
+	}
+
+	if retried.Files[0].Status != "completed" {
+		t.Errorf("Expected first file to stay completed, got %s", retried.Files[0].Status)
+	}
+
+	if retried.Files[1].Status != "pending" || retried.Files[1].Size != 0 {
+		t.Errorf("Expected second file to be reset to pending, got %+v", retried.Files[1])
+	}
+}
+
+func TestRetryFailedFiles(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+	task, err := usecase.CreateTask(ctx, []string{"https://example.com/a.jpg", "https://example.com/b.jpg", "https://example.com/c.jpg"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	started, finished := time.Now().Add(-time.Minute), time.Now()
+	task.StartedAt, task.FinishedAt = &started, &finished
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "completed", Size: 10, Path: "/downloads/a.jpg"}
+	task.Files[1] = entities.File{URL: task.URLs[1], Status: "failed", Attempts: 3, MaxAttempts: 3, Error: "HTTP 503: 503 Service Unavailable", ErrorKind: entities.ErrorKindHTTP}
+	task.Files[2] = entities.File{URL: task.URLs[2], Status: "failed", Attempts: 3, MaxAttempts: 3, Error: "timeout", ErrorKind: entities.ErrorKindTimeout}
+	task.SetError("превышен порог неудачных файлов", time.Now())
+
+	// Execute
+	retried, requeued, err := usecase.RetryFailedFiles(ctx, task.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requeued != 2 {
+		t.Errorf("Expected 2 requeued files, got %d", requeued)
+	}
+	if retried.Status != entities.TaskStatusNew || retried.Error != "" {
+		t.Errorf("Expected requeued task without error, got %s (%q)", retried.Status, retried.Error)
+	}
+	if file := retried.Files[0]; file.Status != "completed" || file.Path != "/downloads/a.jpg" {
+		t.Errorf("Expected completed file to be preserved, got %+v", file)
+	}
+	for _, file := range retried.Files[1:] {
+		if file.Status != "pending" || file.Attempts != 0 || file.Error != "" || file.ErrorKind != "" {
+			t.Errorf("Expected failed file to be reset, got %+v", file)
+		}
+	}
+	if retried.StartedAt == nil || !retried.StartedAt.Equal(started) {
+		t.Errorf("Expected task history to be preserved, got started_at %v", retried.StartedAt)
+	}
+
+	// Execute: nothing left to retry
+	_, requeued, err = usecase.RetryFailedFiles(ctx, task.ID.String())
+
+	// Assert
+	if err != nil || requeued != 0 {
+		t.Errorf("Expected no requeued files, got %d (%v)", requeued, err)
+	}
+
+	// Execute: processing task
+	task.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	_, _, err = usecase.RetryFailedFiles(ctx, task.ID.String())
+
+	// Assert
+	if !errors.Is(err, entities.ErrTaskProcessing) {
+		t.Errorf("Expected ErrTaskProcessing, got %v", err)
+	}
+}
+
+func TestRetryFileErrors(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	task, err := usecase.CreateTask(ctx, []string{"https://example.com/file1.jpg"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// Execute & Assert
+	if _, err := usecase.RetryFile(ctx, "non-existent-id", 0); !errors.Is(err, entities.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+
+	if _, err := usecase.RetryFile(ctx, task.ID.String(), 1); !errors.Is(err, entities.ErrInvalidFileIndex) {
+		t.Errorf("Expected ErrInvalidFileIndex, got %v", err)
+	}
+
+	task.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+	if _, err := usecase.RetryFile(ctx, task.ID.String(), 0); !errors.Is(err, entities.ErrTaskProcessing) {
+		t.Errorf("Expected ErrTaskProcessing, got %v", err)
+	}
+}
+
+func TestCreateTaskCollectsAllInvalidURLs(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	urls := []string{
+		"",
+		"https://example.com/ok.jpg",
+		"gopher://example.com/file",
+		"https:///no-host",
+	}
+
+	// Execute
+	_, err := usecase.CreateTask(ctx, urls)
+
+	// Assert
+	var validationErr *entities.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+
+	if len(validationErr.Errors) != 3 {
+		t.Fatalf("Expected 3 invalid URLs, got %d", len(validationErr.Errors))
+	}
+
+	expectedIndexes := []int{0, 2, 3}
+	for i, item := range validationErr.Errors {
+		if item.Index != expectedIndexes[i] {
+			t.Errorf("Expected invalid URL index %d, got %d", expectedIndexes[i], item.Index)
+		}
+		if item.Reason == "" {
+			t.Errorf("Expected reason for URL at index %d", item.Index)
+		}
+	}
+}
+
+func TestListTasksPagination(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithPageSize(2, 3))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if _, err := usecase.CreateTask(ctx, []string{"https://example.com/file.jpg"}); err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	// Execute: default page size without limit
+	page, err := usecase.ListTasks(ctx, 0, "", entities.TaskFilter{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Tasks) != 2 || page.NextCursor == "" {
+		t.Fatalf("Expected 2 tasks with next cursor, got %d tasks and cursor %q", len(page.Tasks), page.NextCursor)
+	}
+
+	// Execute: limit above maximum is clamped
+	page, err = usecase.ListTasks(ctx, 100, page.NextCursor, entities.TaskFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.Limit != 3 || len(page.Tasks) != 3 {
+		t.Errorf("Expected clamped limit 3 and 3 tasks, got limit %d and %d tasks", page.Limit, len(page.Tasks))
+	}
+	if page.NextCursor != "" {
+		t.Errorf("Expected no next cursor on the last page, got %q", page.NextCursor)
+	}
+
+	// Execute: invalid cursor
+	if _, err := usecase.ListTasks(ctx, 0, "not-a-cursor", entities.TaskFilter{}); !errors.Is(err, entities.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestListTasksCursorStableAcrossChanges(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	created := time.Now()
+	var tasks []*entities.Task
+	for i := 0; i < 4; i++ {
+		task := entities.NewTask([]string{"https://example.com/file.jpg"}, created.Add(time.Duration(i)*time.Second))
+		mockRepo.Create(ctx, task)
+		tasks = append(tasks, task)
+	}
+	first, err := usecase.ListTasks(ctx, 2, "", entities.TaskFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute: the first task is removed and an older one is added between pages
+	mockRepo.Delete(ctx, tasks[0].ID.String())
+	mockRepo.Create(ctx, entities.NewTask([]string{"https://example.com/old.jpg"}, created.Add(-time.Hour)))
+	second, err := usecase.ListTasks(ctx, 2, first.NextCursor, entities.TaskFilter{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(second.Tasks) != 2 || second.Tasks[0].ID != tasks[2].ID || second.Tasks[1].ID != tasks[3].ID {
+		t.Errorf("Expected the second page to continue with tasks 2 and 3, got %d tasks", len(second.Tasks))
+	}
+	if second.NextCursor != "" {
+		t.Errorf("Expected no next cursor on the last page, got %q", second.NextCursor)
+	}
+}
+
+func TestListTasksNewestFirst(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	created := time.Now()
+	var tasks []*entities.Task
+	for i := 0; i < 4; i++ {
+		task := entities.NewTask([]string{"https://example.com/file.jpg"}, created.Add(time.Duration(i)*time.Second))
+		mockRepo.Create(ctx, task)
+		tasks = append(tasks, task)
+	}
+	filter := entities.TaskFilter{Newest: true}
+
+	// Execute
+	first, err := usecase.ListTasks(ctx, 2, "", filter)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := usecase.ListTasks(ctx, 2, first.NextCursor, filter)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(first.Tasks) != 2 || first.Tasks[0].ID != tasks[3].ID || first.Tasks[1].ID != tasks[2].ID {
+		t.Errorf("Expected the first page to start with the newest tasks 3 and 2, got %d tasks", len(first.Tasks))
+	}
+	if len(second.Tasks) != 2 || second.Tasks[0].ID != tasks[1].ID || second.Tasks[1].ID != tasks[0].ID {
+		t.Errorf("Expected the second page to continue with tasks 1 and 0, got %d tasks", len(second.Tasks))
+	}
+	if second.NextCursor != "" {
+		t.Errorf("Expected no next cursor on the last page, got %q", second.NextCursor)
+	}
+}
+
+func TestDeleteAndRestoreTask(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(dir))
+	ctx := context.Background()
+
+	task, err := usecase.CreateTask(ctx, []string{"https://example.com/file.jpg"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	path := filepath.Join(dir, task.ID.String(), "file.jpg")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create task directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	task.Files[0].Path = path
+	task.Files[0].Status = "completed"
+	task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+
+	// Execute: delete
+	deleted, err := usecase.DeleteTask(ctx, task.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !deleted.IsDeleted() {
+		t.Error("Expected task to be marked deleted")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected file to be moved out of %s", path)
+	}
+	trashed := filepath.Join(dir, TrashDirName, task.ID.String(), task.ID.String(), "file.jpg")
+	if _, err := os.Stat(trashed); err != nil {
+		t.Errorf("Expected file in trash at %s, got %v", trashed, err)
+	}
+
+	page, err := usecase.ListTasks(ctx, 0, "", entities.TaskFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Tasks) != 0 {
+		t.Errorf("Expected deleted task to be excluded from listing, got %d tasks", len(page.Tasks))
+	}
+	page, err = usecase.ListTasks(ctx, 0, "", entities.TaskFilter{IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Tasks) != 1 {
+		t.Errorf("Expected deleted task with include_deleted, got %d tasks", len(page.Tasks))
+	}
+
+	// Execute: restore
+	restored, err := usecase.RestoreTask(ctx, task.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.IsDeleted() {
+		t.Error("Expected task to be restored")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected file to be restored, got %v", err)
+	}
+	if string(data) != "data" {
+		t.Errorf("Expected restored content data, got %q", string(data))
+	}
+
+	// Execute: restore of a task that is not deleted
+	if _, err := usecase.RestoreTask(ctx, task.ID.String()); !errors.Is(err, entities.ErrTaskNotDeleted) {
+		t.Errorf("Expected ErrTaskNotDeleted, got %v", err)
+	}
+}
+
+func TestUpdateTask(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+	task, err := usecase.CreateTaskFromSpec(ctx, entities.TaskSpec{
+		Files:       []entities.FileSpec{{URL: "https://example.com/file.jpg"}},
+		Headers:     map[string]string{"Authorization": "Bearer wrong"},
+		CallbackURL: "https://hooks.example.com/old",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	headers := map[string]string{"Authorization": "Bearer right"}
+	callback := ""
+
+	// Execute
+	updated, err := usecase.UpdateTask(ctx, task.ID.String(), entities.TaskUpdate{
+		Headers:     &headers,
+		RetryPolicy: &entities.TaskRetryPolicy{MaxAttempts: 5, RetryOn: []string{"5xx"}},
+		CallbackURL: &callback,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+	if stored.Headers["Authorization"] != "Bearer right" || updated.Headers["Authorization"] != "Bearer right" {
+		t.Errorf("Expected updated Authorization header, got %v", stored.Headers)
+	}
+	if stored.RetryPolicy == nil || stored.RetryPolicy.MaxAttempts != 5 {
+		t.Errorf("Expected retry policy with 5 attempts, got %+v", stored.RetryPolicy)
+	}
+	if stored.CallbackURL != "" {
+		t.Errorf("Expected callback URL to be removed, got %q", stored.CallbackURL)
+	}
+	if len(stored.URLs) != 1 || stored.URLs[0] != "https://example.com/file.jpg" {
+		t.Errorf("Expected URLs to stay unchanged, got %v", stored.URLs)
+	}
+
+	// Execute: empty retry policy falls back to the configuration
+	updated, err = usecase.UpdateTask(ctx, task.ID.String(), entities.TaskUpdate{RetryPolicy: &entities.TaskRetryPolicy{}})
+
+	// Assert
+	if err != nil || updated.RetryPolicy != nil {
+		t.Errorf("Expected retry policy to be reset, got %+v (%v)", updated.RetryPolicy, err)
+	}
+}
+
+func TestUpdateTaskRejectsInvalidChanges(t *testing.T) {
+	invalidHeaders := map[string]string{"Bad Name": "value"}
+	invalidCallback := "ftp://hooks.example.com"
+	testCases := []struct {
+		name       string
+		processing bool
+		update     entities.TaskUpdate
+		expected   error
+	}{
+		{"empty update", false, entities.TaskUpdate{}, entities.ErrInvalidRequest},
+		{"invalid header", false, entities.TaskUpdate{Headers: &invalidHeaders}, entities.ErrInvalidRequest},
+		{"invalid retry policy", false, entities.TaskUpdate{RetryPolicy: &entities.TaskRetryPolicy{RetryOn: []string{"sometimes"}}}, entities.ErrInvalidRequest},
+		{"invalid callback URL", false, entities.TaskUpdate{CallbackURL: &invalidCallback}, entities.ErrInvalidRequest},
+		{"processing task", true, entities.TaskUpdate{RetryPolicy: &entities.TaskRetryPolicy{MaxAttempts: 2}}, entities.ErrTaskProcessing},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			usecase := NewTaskUsecase(mockRepo, mockRepo)
+			ctx := context.Background()
+			task, _ := usecase.CreateTask(ctx, []string{"https://example.com/file.jpg"})
+			if tc.processing {
+				task.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+			}
+
+			// Execute
+			_, err := usecase.UpdateTask(ctx, task.ID.String(), tc.update)
+
+			// Assert
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+			if stored, _ := mockRepo.GetByID(ctx, task.ID.String()); stored.Headers != nil || stored.RetryPolicy != nil {
+				t.Errorf("Expected task to stay unchanged, got headers %v and retry policy %+v", stored.Headers, stored.RetryPolicy)
+			}
+		})
+	}
+}
+
+func TestCreateTaskWithContentIDs(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithContentTaskIDs(DefaultTaskIDNamespace))
+	ctx := context.Background()
+	first, err := usecase.CreateTask(ctx, []string{"https://example.com/a.txt", "https://example.com/b.txt"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// Execute
+	again, againErr := usecase.CreateTask(ctx, []string{"https://example.com/b.txt", "https://example.com/a.txt"})
+	other, otherErr := usecase.CreateTask(ctx, []string{"https://example.com/c.txt"})
+
+	// Assert
+	if !errors.Is(againErr, entities.ErrTaskExists) || again == nil || again.ID != first.ID {
+		t.Fatalf("Expected existing task %s with ErrTaskExists, got %v (%v)", first.ID, again, againErr)
+	}
+	if otherErr != nil || other.ID == first.ID {
+		t.Errorf("Expected a new task with a different ID, got %v (%v)", other, otherErr)
+	}
+	if len(mockRepo.tasks) != 2 {
+		t.Errorf("Expected 2 stored tasks, got %d", len(mockRepo.tasks))
+	}
+	if expected := entities.ContentTaskID(DefaultTaskIDNamespace, []string{"https://example.com/a.txt", "https://example.com/b.txt"}); first.ID != expected {
+		t.Errorf("Expected ID %s, got %s", expected, first.ID)
+	}
+
+	// Execute: the task is in the trash
+	first.MarkDeleted(time.Now())
+	_, err = usecase.CreateTask(ctx, []string{"https://example.com/a.txt", "https://example.com/b.txt"})
+
+	// Assert
+	if !errors.Is(err, entities.ErrTaskDeleted) {
+		t.Errorf("Expected ErrTaskDeleted, got %v", err)
+	}
+}
+
+// unavailableTaskRepository fails every lookup to emulate a broken storage
+type unavailableTaskRepository struct {
+	*MockTaskRepository
+}
+
+func (r *unavailableTaskRepository) GetByID(ctx context.Context, id string) (*entities.Task, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func TestCreateTaskWithContentIDsReportsRepositoryErrors(t *testing.T) {
+	// Setup
+	repo := &unavailableTaskRepository{MockTaskRepository: NewMockTaskRepository()}
+	usecase := NewTaskUsecase(repo, repo, WithContentTaskIDs(DefaultTaskIDNamespace))
+
+	// Execute
+	task, err := usecase.CreateTask(context.Background(), []string{"https://example.com/a.txt"})
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "storage unavailable") {
+		t.Fatalf("Expected repository error, got %v (%v)", task, err)
+	}
+	if len(repo.tasks) != 0 {
+		t.Errorf("Expected no task to be created, got %d", len(repo.tasks))
+	}
+}
+
+func TestCreateTaskWithRandomIDsByDefault(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	// Execute
+	first, firstErr := usecase.CreateTask(ctx, []string{"https://example.com/a.txt"})
+	second, secondErr := usecase.CreateTask(ctx, []string{"https://example.com/a.txt"})
+
+	// Assert
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("Expected no errors, got %v and %v", firstErr, secondErr)
+	}
+	if first.ID == second.ID || first.ID.Version() != 4 {
+		t.Errorf("Expected distinct random UUIDv4 IDs, got %s and %s", first.ID, second.ID)
+	}
+}
+
+func TestDeleteTaskProcessing(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(t.TempDir()))
+	ctx := context.Background()
+
+	task, _ := usecase.CreateTask(ctx, []string{"https://example.com/file.jpg"})
+	task.UpdateStatus(entities.TaskStatusProcessing, time.Now())
+
+	// Execute
+	_, err := usecase.DeleteTask(ctx, task.ID.String())
+
+	// Assert
+	if !errors.Is(err, entities.ErrTaskProcessing) {
+		t.Errorf("Expected ErrTaskProcessing, got %v", err)
+	}
+	if task.IsDeleted() {
+		t.Error("Expected processing task not to be deleted")
+	}
+}
+
+func TestDeleteRestoreAndPurgeTaskWithExtractedFiles(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(dir))
+	ctx := context.Background()
+
+	task, err := usecase.CreateTask(ctx, []string{"https://example.com/archive.zip"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	archive := filepath.Join(dir, "archive.zip")
+	extracted := []string{filepath.Join(dir, "archive", "a.txt"), filepath.Join(dir, "archive", "sub", "b.txt")}
+	for _, path := range append([]string{archive}, extracted...) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(filepath.Base(path)), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	task.Files[0].Path = archive
+	task.Files[0].Extracted = extracted
+	task.Files[0].Status = "completed"
+	task.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+	trashDir := filepath.Join(dir, TrashDirName, task.ID.String())
+
+	// Execute: delete
+	if _, err := usecase.DeleteTask(ctx, task.ID.String()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if _, err := os.Stat(filepath.Join(dir, "archive")); !os.IsNotExist(err) {
+		t.Errorf("Expected extraction directory to be moved to trash, got %v", err)
+	}
+	for _, path := range extracted {
+		rel, _ := filepath.Rel(dir, path)
+		if _, err := os.Stat(filepath.Join(trashDir, rel)); err != nil {
+			t.Errorf("Expected extracted file %s in trash, got %v", rel, err)
+		}
+	}
+
+	// Execute: restore
+	if _, err := usecase.RestoreTask(ctx, task.ID.String()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	for _, path := range extracted {
+		if data, err := os.ReadFile(path); err != nil || string(data) != filepath.Base(path) {
+			t.Errorf("Expected extracted file %s to be restored, got %q (%v)", path, data, err)
+		}
+	}
+
+	// Execute: purge
+	if _, err := usecase.DeleteTask(ctx, task.ID.String()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	purged, err := usecase.PurgeDeletedTasks(ctx, time.Now().Add(time.Hour))
+
+	// Assert
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected 1 purged task, got %d (%v)", purged, err)
+	}
+	if _, err := os.Stat(trashDir); !os.IsNotExist(err) {
+		t.Errorf("Expected trash directory with extracted files to be removed, got %v", err)
+	}
+	for _, path := range append([]string{archive}, extracted...) {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to stay removed after purge, got %v", path, err)
+		}
+	}
+}
+
+func TestPurgeDeletedTasks(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(dir))
+	ctx := context.Background()
+
+	expired, _ := usecase.CreateTask(ctx, []string{"https://example.com/old.jpg"})
+	recent, _ := usecase.CreateTask(ctx, []string{"https://example.com/new.jpg"})
+	kept, _ := usecase.CreateTask(ctx, []string{"https://example.com/kept.jpg"})
+
+	if _, err := usecase.DeleteTask(ctx, expired.ID.String()); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+	if _, err := usecase.DeleteTask(ctx, recent.ID.String()); err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	expired.DeletedAt = &old
+
+	// Execute
+	purged, err := usecase.PurgeDeletedTasks(ctx, time.Now().Add(-24*time.Hour))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged task, got %d", purged)
+	}
+	if _, err := mockRepo.GetByID(ctx, expired.ID.String()); err == nil {
+		t.Error("Expected expired task to be removed")
+	}
+	for _, task := range []*entities.Task{recent, kept} {
+		if _, err := mockRepo.GetByID(ctx, task.ID.String()); err != nil {
+			t.Errorf("Expected task %s to be kept, got %v", task.ID, err)
+		}
+	}
+}
+
+func TestVerifyTask(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	dir := t.TempDir()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	task, err := usecase.CreateTask(ctx, []string{
+		"https://example.com/ok.txt",
+		"https://example.com/missing.txt",
+		"https://example.com/changed.txt",
+		"https://example.com/pending.txt",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	okPath := filepath.Join(dir, "ok.txt")
+	os.WriteFile(okPath, []byte("hello"), 0644)
+	sum, _ := fileSHA256(okPath)
+	task.Files[0] = entities.File{URL: task.URLs[0], Path: okPath, Size: 5, SHA256: sum, Status: "completed"}
+	task.Files[1] = entities.File{URL: task.URLs[1], Path: filepath.Join(dir, "missing.txt"), Size: 5, Status: "completed"}
+	changedPath := filepath.Join(dir, "changed.txt")
+	os.WriteFile(changedPath, []byte("HELLO"), 0644)
+	task.Files[2] = entities.File{URL: task.URLs[2], Path: changedPath, Size: 5, SHA256: sum, Status: "completed"}
+	task.UpdateStatus(entities.TaskStatusFailed, time.Now())
+
+	// Execute
+	report, err := usecase.VerifyTask(ctx, task.ID.String(), true)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []entities.VerificationStatus{
+		entities.VerificationOK,
+		entities.VerificationMissing,
+		entities.VerificationChecksumMismatch,
+		entities.VerificationSkipped,
+	}
+	for i, want := range expected {
+		if report.Files[i].Status != want {
+			t.Errorf("Expected file %d status %s, got %s", i, want, report.Files[i].Status)
+		}
+	}
+	if report.Failed != 2 {
+		t.Errorf("Expected 2 failed files, got %d", report.Failed)
+	}
+
+	if task.Files[0].Status != "completed" {
+		t.Errorf("Expected verified file to stay completed, got %s", task.Files[0].Status)
+	}
+	if task.Files[1].Status != "pending" || task.Files[2].Status != "pending" {
+		t.Errorf("Expected failed files to be requeued, got %s and %s", task.Files[1].Status, task.Files[2].Status)
+	}
+	if task.Status != entities.TaskStatusNew {
+		t.Errorf("Expected task status new, got %s", task.Status)
+	}
+}
+
+func TestVerifyTaskStopsWhenContextExpires(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	task, err := usecase.CreateTask(context.Background(), []string{"https://example.com/a.txt"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	// Execute
+	report, err := usecase.VerifyTask(ctx, task.ID.String(), false)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if report != nil {
+		t.Errorf("Expected no report, got %+v", report)
+	}
+}
+
+func TestCreateScheduledTask(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	// Execute
+	scheduled, err := usecase.CreateTaskFromSpec(ctx, entities.TaskSpec{
+		Files:   []entities.FileSpec{{URL: "https://example.com/big.iso"}},
+		StartAt: &future,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	missed, err := usecase.CreateTaskFromSpec(ctx, entities.TaskSpec{
+		Files:   []entities.FileSpec{{URL: "https://example.com/big.iso"}},
+		StartAt: &past,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Assert
+	if scheduled.Status != entities.TaskStatusScheduled {
+		t.Errorf("Expected status scheduled, got %s", scheduled.Status)
+	}
+	if missed.Status != entities.TaskStatusNew {
+		t.Errorf("Expected task with past start_at to be new, got %s", missed.Status)
+	}
+
+	pending, _ := mockRepo.GetPendingTasks(ctx)
+	if len(pending) != 1 || pending[0].ID != missed.ID {
+		t.Errorf("Expected only the due task to be pending, got %d tasks", len(pending))
+	}
+}
+
+func TestListFailures(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+	now := time.Now()
+	recent, older, stale := now.Add(-time.Minute), now.Add(-10*time.Minute), now.Add(-2*time.Hour)
+
+	first := entities.NewTask([]string{"https://example.com/a", "https://example.com/b"}, time.Now())
+	first.Files[0] = entities.File{URL: first.URLs[0], Status: "failed", ErrorKind: entities.ErrorKindHTTP, DownloadFinishedAt: &older}
+	first.Files[1] = entities.File{URL: first.URLs[1], Status: "completed", DownloadFinishedAt: &recent}
+	second := entities.NewTask([]string{"https://example.com/c", "https://example.com/d"}, time.Now())
+	second.Files[0] = entities.File{URL: second.URLs[0], Status: "failed", ErrorKind: entities.ErrorKindTimeout, DownloadFinishedAt: &recent}
+	second.Files[1] = entities.File{URL: second.URLs[1], Status: "failed", DownloadFinishedAt: &stale}
+	deleted := entities.NewTask([]string{"https://example.com/e"}, time.Now())
+	deleted.Files[0] = entities.File{URL: deleted.URLs[0], Status: "failed", DownloadFinishedAt: &recent}
+	deleted.MarkDeleted(time.Now())
+	for _, task := range []*entities.Task{first, second, deleted} {
+		mockRepo.Create(ctx, task)
+	}
+
+	// Execute
+	failures, err := usecase.ListFailures(ctx, now.Add(-time.Hour), 0)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %d", len(failures))
+	}
+	if failures[0].URL != "https://example.com/c" || failures[0].ErrorKind != entities.ErrorKindTimeout {
+		t.Errorf("Expected most recent failure first, got %+v", failures[0])
+	}
+	if failures[1].TaskID != first.ID.String() || failures[1].FileIndex != 0 {
+		t.Errorf("Expected older failure of first task second, got %+v", failures[1])
+	}
+
+	limited, err := usecase.ListFailures(ctx, time.Time{}, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(limited) != 1 || limited[0].URL != "https://example.com/c" {
+		t.Errorf("Expected only the most recent failure, got %+v", limited)
+	}
+}
+
+func TestCreateTaskKeepsRequestID(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	spec := entities.NewTaskSpec([]string{"https://example.com/file1.jpg"})
+	spec.RequestID = "req-42"
+
+	// Execute
+	task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if task.RequestID != "req-42" {
+		t.Errorf("Expected request ID req-42, got %q", task.RequestID)
+	}
+	if want := task.ID.String() + " [request_id=req-42]"; task.LogID() != want {
+		t.Errorf("Expected log ID %q, got %q", want, task.LogID())
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+
+	urls := make([]string, 6)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/file%d.bin", i)
+	}
+	task := entities.NewTask(urls, time.Now())
+	for i, url := range urls {
+		status := "completed"
+		if i%2 == 1 {
+			status = "failed"
+		}
+		task.Files[i] = entities.File{URL: url, Status: status}
+	}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	page, err := usecase.ListFiles(ctx, task.ID.String(), entities.FileFilter{Status: "failed"}, 2, "")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.Total != 3 {
+		t.Errorf("Expected 3 failed files in total, got %d", page.Total)
+	}
+	if len(page.Files) != 2 || page.NextCursor == "" {
+		t.Fatalf("Expected 2 files on the page with next cursor, got %d files and cursor %q", len(page.Files), page.NextCursor)
+	}
+	if page.Files[0].Index != 1 || page.Files[1].Index != 3 {
+		t.Errorf("Expected file indexes 1 and 3, got %d and %d", page.Files[0].Index, page.Files[1].Index)
+	}
+	if page.Files[1].URL != urls[3] {
+		t.Errorf("Expected URL %s, got %s", urls[3], page.Files[1].URL)
+	}
+
+	// Execute: next page
+	page, err = usecase.ListFiles(ctx, task.ID.String(), entities.FileFilter{Status: "failed"}, 2, page.NextCursor)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Files) != 1 || page.Files[0].Index != 5 || page.NextCursor != "" {
+		t.Errorf("Expected last page with file index 5 and no cursor, got %+v", page)
+	}
+
+	// Execute: legacy offset skips matching files
+	page, err = usecase.ListFiles(ctx, task.ID.String(), entities.FileFilter{Status: "failed", Offset: 1}, 1, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page.Files) != 1 || page.Files[0].Index != 3 || page.NextCursor == "" {
+		t.Errorf("Expected file index 3 after offset 1 with next cursor, got %+v", page)
+	}
+
+	// Execute: cursor of the task list is not accepted
+	tasks, _ := usecase.ListTasks(ctx, 0, "", entities.TaskFilter{})
+	_, err = usecase.ListFiles(ctx, task.ID.String(), entities.FileFilter{}, 0, encodeTaskCursor(entities.CursorAfter(tasks.Tasks[0])))
+	if !errors.Is(err, entities.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor for a task cursor, got %v", err)
+	}
+
+	_, err = usecase.ListFiles(ctx, "00000000-0000-0000-0000-000000000000", entities.FileFilter{}, 0, "")
+	if !errors.Is(err, entities.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func TestCreateTaskRejectsLongURL(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithMaxURLLength(64))
+	ctx := context.Background()
+	longURL := "https://example.com/" + strings.Repeat("a", 1000)
+
+	// Execute
+	_, err := usecase.CreateTask(ctx, []string{"https://example.com/ok.jpg", longURL})
+
+	// Assert
+	var validationErr *entities.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Errors) != 1 || validationErr.Errors[0].Index != 1 {
+		t.Fatalf("Expected only the long URL to be rejected, got %+v", validationErr.Errors)
+	}
+	if !strings.Contains(validationErr.Errors[0].Reason, "длиннее 64 байт") {
+		t.Errorf("Expected length reason, got %q", validationErr.Errors[0].Reason)
+	}
+	if len(validationErr.Errors[0].URL) >= len(longURL) {
+		t.Errorf("Expected rejected URL to be abbreviated in the error, got %d bytes", len(validationErr.Errors[0].URL))
+	}
+}
+
+func TestCreateTaskRejectsLongHeaderValue(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	spec := entities.NewTaskSpec([]string{"https://example.com/file.jpg"})
+	spec.Headers = map[string]string{"Authorization": strings.Repeat("x", maxHeaderValueLength+1)}
+
+	// Execute
+	_, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+	// Assert
+	if !errors.Is(err, entities.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+}
+
+func TestCreateTaskMaxConcurrency(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    int
+		expected int
+		wantErr  bool
+	}{
+		{"unset uses global setting", 0, 0, false},
+		{"kept within ceiling", 2, 2, false},
+		{"clamped to ceiling", 1000, MaxFileConcurrency, false},
+		{"negative rejected", -1, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			usecase := NewTaskUsecase(mockRepo, mockRepo)
+			spec := entities.NewTaskSpec([]string{"https://example.com/file.jpg"})
+			spec.MaxConcurrency = tc.value
+
+			// Execute
+			task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+
+			// Assert
+			if tc.wantErr {
+				if !errors.Is(err, entities.ErrInvalidRequest) {
+					t.Errorf("Expected ErrInvalidRequest, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if task.MaxConcurrency != tc.expected {
+				t.Errorf("Expected max concurrency %d, got %d", tc.expected, task.MaxConcurrency)
+			}
+		})
+	}
+}
+
+func TestCreateTaskRejectsWhenActiveTasksAtLimit(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithMaxActiveTasks(2))
+	ctx := context.Background()
+
+	first, err := usecase.CreateTask(ctx, []string{"https://example.com/1.jpg"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := usecase.CreateTask(ctx, []string{"https://example.com/2.jpg"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Execute
+	_, rejected := usecase.CreateTask(ctx, []string{"https://example.com/3.jpg"})
+	first.UpdateStatus(entities.TaskStatusCompleted, time.Now())
+	mockRepo.Update(ctx, first)
+	_, accepted := usecase.CreateTask(ctx, []string{"https://example.com/4.jpg"})
+
+	// Assert
+	if !errors.Is(rejected, entities.ErrTooManyActiveTasks) {
+		t.Errorf("Expected ErrTooManyActiveTasks at the limit, got %v", rejected)
+	}
+	if accepted != nil {
+		t.Errorf("Expected task to be accepted after another one finished, got %v", accepted)
+	}
+}
+
+func TestCreateTaskConcurrentRequestsRespectActiveLimit(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithMaxActiveTasks(3))
+	ctx := context.Background()
+
+	// Execute
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := usecase.CreateTask(ctx, []string{"https://example.com/file.jpg"}); err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	if created != 3 {
+		t.Errorf("Expected 3 tasks to be created, got %d", created)
+	}
+}
+
+func TestGetTaskStatusesReportsMissingIDs(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	ctx := context.Background()
+	first, _ := usecase.CreateTask(ctx, []string{"https://example.com/a.txt"})
+	second, _ := usecase.CreateTask(ctx, []string{"https://example.com/b.txt"})
+	ids := []string{first.ID.String(), "missing", second.ID.String(), first.ID.String()}
+
+	// Execute
+	statuses, err := usecase.GetTaskStatuses(ctx, ids)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(statuses.Tasks) != 2 || statuses.Tasks[first.ID.String()] == nil || statuses.Tasks[second.ID.String()] == nil {
+		t.Errorf("Expected both existing tasks, got %v", statuses.Tasks)
+	}
+	if len(statuses.NotFound) != 1 || statuses.NotFound[0] != "missing" {
+		t.Errorf("Expected not_found [missing], got %v", statuses.NotFound)
+	}
+}
+
+func TestGetTaskStatusesValidatesBatch(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	usecase := NewTaskUsecase(mockRepo, mockRepo)
+	tooMany := make([]string, MaxStatusBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("id-%d", i)
+	}
+
+	for _, ids := range [][]string{nil, tooMany} {
+		// Execute
+		_, err := usecase.GetTaskStatuses(context.Background(), ids)
+
+		// Assert
+		if !errors.Is(err, entities.ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for %d IDs, got %v", len(ids), err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+// TrashDirName - имя директории корзины внутри директории скачивания
+const TrashDirName = ".trash"
+
+// trash перемещает файлы удаленных задач в корзину и обратно.
+// Файл задачи хранится в корзине по пути {downloadDir}/.trash/{task-id}/{путь относительно downloadDir},
+// поэтому исходное расположение восстанавливается при любой стратегии размещения. Для задачи с output_dir
+// корзина размещается внутри её директории скачивания.
+type trash struct {
+	downloadDir string
+}
+
+// rootFor возвращает директорию скачивания задачи, внутри которой размещается её корзина
+func (t trash) rootFor(task *entities.Task) string {
+	if task.OutputDir != "" {
+		return task.OutputDir
+	}
+	return t.downloadDir
+}
+
+// taskDir возвращает директорию корзины для задачи
+func (t trash) taskDir(task *entities.Task) string {
+	return filepath.Join(t.rootFor(task), TrashDirName, task.ID.String())
+}
+
+// pathFor возвращает путь файла в корзине.
+// Файлы вне директории скачивания в корзину не перемещаются.
+func (t trash) pathFor(task *entities.Task, path string) (string, bool) {
+	root := t.rootFor(task)
+	if !insideDir(root, path) {
+		return "", false
+	}
+	rel, _ := filepath.Rel(root, path)
+	return filepath.Join(t.taskDir(task), rel), true
+}
+
+// moveIn перемещает скачанные и распакованные из них файлы задачи в корзину
+func (t trash) moveIn(task *entities.Task) error {
+	for _, file := range task.Files {
+		if file.Path == "" {
+			continue
+		}
+		for _, path := range file.Extracted {
+			trashed, ok := t.pathFor(task, path)
+			if !ok {
+				continue
+			}
+			if err := move(path, trashed); err != nil {
+				return fmt.Errorf("не удалось переместить распакованный файл %s в корзину: %w", path, err)
+			}
+			// Директории распаковки созданы сервисом и удаляются вместе с файлами
+			removeEmptyDirs(t.rootFor(task), filepath.Dir(path))
+		}
+		trashed, ok := t.pathFor(task, file.Path)
+		if !ok {
+			continue
+		}
+		if err := move(file.Path, trashed); err != nil {
+			return fmt.Errorf("не удалось переместить файл %s в корзину: %w", file.Path, err)
+		}
+		if task.PreservePath {
+			removeEmptyDirs(t.rootFor(task), filepath.Dir(file.Path))
+		}
+	}
+
+	// Для стратегии by-task удаляем опустевшую директорию задачи
+	os.Remove(filepath.Join(t.rootFor(task), task.ID.String()))
+	return nil
+}
+
+// moveOut возвращает файлы задачи, в том числе распакованные, из корзины на исходные места
+func (t trash) moveOut(task *entities.Task) error {
+	for _, file := range task.Files {
+		if file.Path == "" {
+			continue
+		}
+		for _, path := range append([]string{file.Path}, file.Extracted...) {
+			trashed, ok := t.pathFor(task, path)
+			if !ok {
+				continue
+			}
+			if err := move(trashed, path); err != nil {
+				return fmt.Errorf("не удалось восстановить файл %s из корзины: %w", path, err)
+			}
+		}
+	}
+
+	return os.RemoveAll(t.taskDir(task))
+}
+
+// removeEmptyDirs удаляет опустевшие директории, воссозданные из пути URL, поднимаясь до директории скачивания root
+func removeEmptyDirs(root, dir string) {
+	for {
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return
+		}
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// purge окончательно удаляет файлы задачи из корзины вместе с перенесенными туда распакованными файлами.
+// Исходные пути файлов не трогаются: после удаления задачи их могли занять файлы других задач.
+func (t trash) purge(task *entities.Task) error {
+	return os.RemoveAll(t.taskDir(task))
+}
+
+// move переименовывает файл, создавая директорию назначения.
+// Отсутствующий исходный файл не считается ошибкой.
+func move(from, to string) error {
+	if _, err := os.Stat(from); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
@@ -0,0 +1,210 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"file-downloader/internal/entities"
+)
+
+const (
+	// DefaultTreeMaxDepth - глубина вложенности дерева файлов задачи по умолчанию
+	DefaultTreeMaxDepth = 16
+	// DefaultTreeMaxEntries - число элементов дерева файлов задачи по умолчанию
+	DefaultTreeMaxEntries = 10000
+)
+
+// TreeLimits ограничивает дерево файлов задачи, чтобы обход огромной директории не занял сервис надолго
+type TreeLimits struct {
+	MaxDepth   int // глубина вложенности относительно директории скачивания задачи
+	MaxEntries int // число директорий и файлов в дереве
+}
+
+// WithTreeLimits задает ограничения дерева файлов задачи; нулевые поля заменяются значениями по умолчанию
+func WithTreeLimits(limits TreeLimits) TaskOption {
+	return func(u *TaskUsecase) {
+		if limits.MaxDepth <= 0 {
+			limits.MaxDepth = DefaultTreeMaxDepth
+		}
+		if limits.MaxEntries <= 0 {
+			limits.MaxEntries = DefaultTreeMaxEntries
+		}
+		u.treeLimits = limits
+	}
+}
+
+// TaskTree возвращает дерево директорий и файлов результата задачи. Директория {id} задачи в директории
+// скачивания принадлежит только ей и обходится целиком, вместе с распакованными архивами и недокачанными
+// файлами. Файлы вне неё (стратегии flat и by-host) добавляются по записям задачи, чтобы в дерево не попали
+// файлы других задач. Символические ссылки не раскрываются, а дерево обрезается по TreeLimits.
+func (u *TaskUsecase) TaskTree(ctx context.Context, id string) (*entities.TaskTree, error) {
+	task, err := u.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
+	}
+	if task.IsDeleted() {
+		return nil, fmt.Errorf("%w: %s", entities.ErrTaskDeleted, id)
+	}
+
+	root := u.trash.rootFor(task)
+	builder := newTreeBuilder(root, u.treeLimits)
+	taskDir := filepath.Join(root, task.ID.String())
+	if info, err := os.Lstat(taskDir); err == nil && info.IsDir() {
+		if err := builder.walk(ctx, taskDir); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range task.Files {
+		if file.Status != "completed" || file.Path == "" {
+			continue
+		}
+		for _, path := range append([]string{file.Path}, file.Extracted...) {
+			if !insideDir(taskDir, path) {
+				builder.addPath(path)
+			}
+		}
+	}
+
+	tree := builder.finish()
+	tree.TaskID = task.ID.String()
+	return tree, nil
+}
+
+// treeBuilder собирает дерево файлов относительно корня root
+type treeBuilder struct {
+	root      string
+	limits    TreeLimits
+	nodes     map[string]*entities.TreeNode // относительный путь -> элемент дерева
+	tree      entities.TaskTree
+	entries   int
+	truncated bool
+}
+
+// newTreeBuilder создает построитель дерева с корнем root
+func newTreeBuilder(root string, limits TreeLimits) *treeBuilder {
+	rootNode := &entities.TreeNode{Name: filepath.Base(root), Type: entities.TreeNodeDir}
+	return &treeBuilder{
+		root:   root,
+		limits: limits,
+		nodes:  map[string]*entities.TreeNode{".": rootNode},
+		tree:   entities.TaskTree{Root: root, Tree: rootNode},
+	}
+}
+
+// walk обходит директорию dir, не переходя по символическим ссылкам
+func (b *treeBuilder) walk(ctx context.Context, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// Недоступная директория пропускается, остальное дерево строится
+			if entry != nil && entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if !b.add(path, info) {
+			if b.entries >= b.limits.MaxEntries {
+				return filepath.SkipAll
+			}
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+}
+
+// addPath добавляет записанный в задаче файл, если он существует внутри корня дерева
+func (b *treeBuilder) addPath(path string) {
+	if !insideDir(b.root, path) {
+		return
+	}
+	if info, err := os.Lstat(path); err == nil {
+		b.add(path, info)
+	}
+}
+
+// add добавляет элемент с недостающими родительскими директориями. Возвращает false, если элемент
+// не добавлен из-за ограничений дерева.
+func (b *treeBuilder) add(path string, info fs.FileInfo) bool {
+	rel, err := filepath.Rel(b.root, path)
+	if err != nil {
+		return false
+	}
+	if _, ok := b.nodes[rel]; ok {
+		return true
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) > b.limits.MaxDepth {
+		b.truncated = true
+		return false
+	}
+
+	parent := b.nodes["."]
+	for i := range parts {
+		current := filepath.Join(parts[:i+1]...)
+		if node, ok := b.nodes[current]; ok {
+			parent = node
+			continue
+		}
+		if b.entries >= b.limits.MaxEntries {
+			b.truncated = true
+			return false
+		}
+
+		node := &entities.TreeNode{Name: parts[i], Type: entities.TreeNodeDir}
+		if i == len(parts)-1 {
+			switch {
+			case info.Mode()&fs.ModeSymlink != 0:
+				node.Type = entities.TreeNodeSymlink
+			case !info.IsDir():
+				node.Type = entities.TreeNodeFile
+				node.Size = info.Size()
+			}
+		}
+		b.nodes[current] = node
+		b.entries++
+		parent.Children = append(parent.Children, node)
+		parent = node
+	}
+	return true
+}
+
+// finish упорядочивает дерево по именам и подсчитывает размеры директорий
+func (b *treeBuilder) finish() *entities.TaskTree {
+	b.tree.Size = b.summarize(b.tree.Tree)
+	b.tree.Truncated = b.truncated
+	return &b.tree
+}
+
+// summarize сортирует вложенные элементы директории и возвращает её суммарный размер
+func (b *treeBuilder) summarize(node *entities.TreeNode) int64 {
+	switch node.Type {
+	case entities.TreeNodeFile:
+		b.tree.Files++
+		return node.Size
+	case entities.TreeNodeSymlink:
+		return 0
+	}
+
+	if node != b.tree.Tree {
+		b.tree.Dirs++
+	}
+	slices.SortFunc(node.Children, func(a, c *entities.TreeNode) int { return strings.Compare(a.Name, c.Name) })
+	node.Size = 0
+	for _, child := range node.Children {
+		node.Size += b.summarize(child)
+	}
+	return node.Size
+}
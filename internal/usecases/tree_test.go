@@ -0,0 +1,140 @@
+package usecases
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+)
+
+// writeTreeFile creates a file with the given content and parent directories
+func writeTreeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+// childNames returns the names of the node children in order
+func childNames(node *entities.TreeNode) []string {
+	names := make([]string, 0, len(node.Children))
+	for _, child := range node.Children {
+		names = append(names, child.Name)
+	}
+	return names
+}
+
+func TestTaskTreeWalksTaskDirectory(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeTreeFile(t, filepath.Join(outside, "secret.txt"), "secret")
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(root)).(*TaskUsecase)
+	task := entities.NewTask([]string{"https://example.com/docs/bundle.zip"}, time.Now())
+	taskDir := filepath.Join(root, task.ID.String())
+	archive := filepath.Join(taskDir, "docs", "bundle.zip")
+	extracted := filepath.Join(taskDir, "docs", "bundle", "a.txt")
+	writeTreeFile(t, archive, "zipdata")
+	writeTreeFile(t, extracted, "alpha")
+	if err := os.Symlink(outside, filepath.Join(taskDir, "link")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	writeTreeFile(t, filepath.Join(root, "other.txt"), "other task")
+	task.Files[0] = entities.File{URL: "https://example.com/docs/bundle.zip", Status: "completed", Path: archive, Extracted: []string{extracted}}
+	mockRepo.Create(context.Background(), task)
+
+	// Execute
+	tree, err := usecase.TaskTree(context.Background(), task.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if names := childNames(tree.Tree); len(names) != 1 || names[0] != task.ID.String() {
+		t.Fatalf("Expected only the task directory at the root, got %v", names)
+	}
+	taskNode := tree.Tree.Children[0]
+	if names := childNames(taskNode); len(names) != 2 || names[0] != "docs" || names[1] != "link" {
+		t.Fatalf("Expected docs and link in the task directory, got %v", names)
+	}
+	if link := taskNode.Children[1]; link.Type != entities.TreeNodeSymlink || len(link.Children) != 0 {
+		t.Errorf("Expected symlink not to be followed, got %+v", link)
+	}
+	if tree.Files != 2 || tree.Dirs != 3 || tree.Size != int64(len("zipdata")+len("alpha")) {
+		t.Errorf("Expected 2 files, 3 dirs and 12 bytes, got %d files, %d dirs and %d bytes", tree.Files, tree.Dirs, tree.Size)
+	}
+	if tree.Truncated {
+		t.Error("Expected tree not to be truncated")
+	}
+}
+
+func TestTaskTreeListsOnlyTaskFilesInSharedDirectory(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	root := t.TempDir()
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(root)).(*TaskUsecase)
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+	path := filepath.Join(root, "example.com", task.ID.String()[:8]+"_a.txt")
+	writeTreeFile(t, path, "alpha")
+	writeTreeFile(t, filepath.Join(root, "example.com", "other_b.txt"), "other task")
+	task.Files[0] = entities.File{URL: "https://example.com/a.txt", Status: "completed", Path: path}
+	mockRepo.Create(context.Background(), task)
+
+	// Execute
+	tree, err := usecase.TaskTree(context.Background(), task.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tree.Tree.Children) != 1 || len(tree.Tree.Children[0].Children) != 1 {
+		t.Fatalf("Expected a single host directory with a single file, got %+v", tree.Tree)
+	}
+	if file := tree.Tree.Children[0].Children[0]; file.Name != filepath.Base(path) || file.Size != 5 {
+		t.Errorf("Expected task file of 5 bytes, got %+v", file)
+	}
+}
+
+func TestTaskTreeEnforcesLimits(t *testing.T) {
+	testCases := []struct {
+		name   string
+		limits TreeLimits
+		files  int
+	}{
+		{"depth", TreeLimits{MaxDepth: 2}, 2},
+		{"entries", TreeLimits{MaxEntries: 3}, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup
+			mockRepo := NewMockTaskRepository()
+			root := t.TempDir()
+			usecase := NewTaskUsecase(mockRepo, mockRepo, WithTrashDir(root), WithTreeLimits(tc.limits)).(*TaskUsecase)
+			task := entities.NewTask([]string{"https://example.com/a.txt"}, time.Now())
+			taskDir := filepath.Join(root, task.ID.String())
+			writeTreeFile(t, filepath.Join(taskDir, "a.txt"), "a")
+			writeTreeFile(t, filepath.Join(taskDir, "b.txt"), "b")
+			writeTreeFile(t, filepath.Join(taskDir, "deep", "c.txt"), "c")
+			mockRepo.Create(context.Background(), task)
+
+			// Execute
+			tree, err := usecase.TaskTree(context.Background(), task.ID.String())
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !tree.Truncated || tree.Files != tc.files {
+				t.Errorf("Expected truncated tree with %d files, got truncated=%v with %d files", tc.files, tree.Truncated, tree.Files)
+			}
+		})
+	}
+}
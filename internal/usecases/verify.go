@@ -0,0 +1,110 @@
+package usecases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"file-downloader/internal/entities"
+)
+
+// verifyFile сверяет файл на диске с записанными размером и контрольной суммой
+func verifyFile(index int, file entities.File) entities.FileVerification {
+	result := entities.FileVerification{
+		Index:        index,
+		URL:          file.URL,
+		Path:         file.Path,
+		ExpectedSize: file.Size,
+	}
+
+	if file.Status != "completed" || file.Path == "" {
+		result.Status = entities.VerificationSkipped
+		return result
+	}
+
+	info, err := os.Stat(file.Path)
+	if os.IsNotExist(err) {
+		result.Status = entities.VerificationMissing
+		return result
+	}
+	if err != nil {
+		result.Status = entities.VerificationError
+		result.Error = err.Error()
+		return result
+	}
+
+	// Сжатый файл проверяется по распакованным данным
+	if file.Compression != "" {
+		size, sum, err := contentSHA256(file.Path, file.Compression)
+		if err != nil {
+			result.Status = entities.VerificationError
+			result.Error = err.Error()
+			return result
+		}
+		result.ActualSize = size
+		switch {
+		case size != file.Size:
+			result.Status = entities.VerificationSizeMismatch
+		case file.SHA256 != "" && sum != file.SHA256:
+			result.Status = entities.VerificationChecksumMismatch
+		default:
+			result.Status = entities.VerificationOK
+		}
+		return result
+	}
+
+	result.ActualSize = info.Size()
+	if info.Size() != file.Size {
+		result.Status = entities.VerificationSizeMismatch
+		return result
+	}
+
+	if file.SHA256 != "" {
+		sum, err := fileSHA256(file.Path)
+		if err != nil {
+			result.Status = entities.VerificationError
+			result.Error = err.Error()
+			return result
+		}
+		if sum != file.SHA256 {
+			result.Status = entities.VerificationChecksumMismatch
+			return result
+		}
+	}
+
+	result.Status = entities.VerificationOK
+	return result
+}
+
+// fileSHA256 вычисляет контрольную сумму SHA-256 файла
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("не удалось открыть файл: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// contentSHA256 вычисляет размер и контрольную сумму SHA-256 содержимого файла, распаковывая сжатые файлы
+func contentSHA256(path, compression string) (int64, string, error) {
+	content, err := openContent(path, compression)
+	if err != nil {
+		return 0, "", fmt.Errorf("не удалось открыть файл: %w", err)
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return 0, "", fmt.Errorf("не удалось прочитать файл: %w", err)
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,80 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"file-downloader/internal/entities"
+	"file-downloader/internal/testutil"
+)
+
+func TestCreateTaskOutsideDownloadWindowIsScheduled(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	usecase := NewTaskUsecase(mockRepo, mockRepo, WithTaskClock(testutil.NewFakeClock(now)))
+	spec := entities.NewTaskSpec([]string{"https://example.com/a.txt"})
+	spec.DownloadWindow = &entities.DownloadWindow{Start: "22:00", End: "06:00"}
+	invalid := entities.NewTaskSpec([]string{"https://example.com/b.txt"})
+	invalid.DownloadWindow = &entities.DownloadWindow{Start: "22:00", End: "6am"}
+
+	// Execute
+	task, err := usecase.CreateTaskFromSpec(context.Background(), spec)
+	_, invalidErr := usecase.CreateTaskFromSpec(context.Background(), invalid)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if task.Status != entities.TaskStatusScheduled || task.DownloadWindow == nil {
+		t.Errorf("Expected scheduled task with a download window, got %s (%v)", task.Status, task.DownloadWindow)
+	}
+	if !errors.Is(invalidErr, entities.ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for an invalid window, got %v", invalidErr)
+	}
+}
+
+func TestProcessTaskDefersOutsideDownloadWindow(t *testing.T) {
+	// Setup
+	mockRepo := NewMockTaskRepository()
+	clock := testutil.NewFakeClock(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC))
+	usecase := NewDownloadUsecase(mockRepo, mockRepo,
+		WithClock(clock),
+		WithDownloadDir(t.TempDir()),
+		WithFetcher("https", &stubFetcher{content: "data", size: 4}),
+	)
+	ctx := context.Background()
+	task := entities.NewTask([]string{"https://example.com/a.txt"}, clock.Now())
+	task.Files[0] = entities.File{URL: task.URLs[0], Status: "pending"}
+	task.DownloadWindow = &entities.DownloadWindow{Start: "22:00", End: "06:00"}
+	mockRepo.Create(ctx, task)
+
+	// Execute
+	err := usecase.ProcessTask(ctx, task)
+	stored, _ := mockRepo.GetByID(ctx, task.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stored.Status != entities.TaskStatusScheduled || stored.Files[0].Status != "pending" {
+		t.Fatalf("Expected task deferred with a pending file, got %s (%s)", stored.Status, stored.Files[0].Status)
+	}
+
+	// Execute: the window opens
+	clock.Advance(10 * time.Hour)
+	released, err := usecase.ReleaseScheduledTasks(ctx)
+	stored, _ = mockRepo.GetByID(ctx, task.ID.String())
+	processErr := usecase.ProcessTask(ctx, stored)
+	stored, _ = mockRepo.GetByID(ctx, task.ID.String())
+
+	// Assert
+	if err != nil || released != 1 {
+		t.Fatalf("Expected 1 released task, got %d (%v)", released, err)
+	}
+	if processErr != nil || stored.Status != entities.TaskStatusCompleted {
+		t.Errorf("Expected task completed inside the window, got %s (%v)", stored.Status, processErr)
+	}
+}